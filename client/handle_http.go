@@ -3004,6 +3004,7 @@ func runTransferWorkerFile(ctx context.Context, file *clientTransferFile, result
 		}
 	default:
 		transferResults, err = downloadObject(file.file)
+		reportTransferFeedback(file.file, transferResults)
 	}
 	transferResults.JobId = file.jobId
 	transferResults.Scheme = file.file.remoteURL.Scheme
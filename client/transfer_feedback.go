@@ -0,0 +1,132 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/error_codes"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// How long to wait for the director to accept a transfer feedback report
+const transferFeedbackTimeout = 10 * time.Second
+
+// Classify the error that ended a transfer attempt for the director: the type of
+// the Pelican error (e.g. "Transfer.SlowTransfer"), "Unknown" for any other error,
+// or empty if the attempt succeeded.
+func transferErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	var pe *error_codes.PelicanError
+	if errors.As(err, &pe) {
+		return pe.ErrorType()
+	}
+	return "Unknown"
+}
+
+// Build the report sent to the director from the attempts made to download an object
+func newTransferFeedback(objectPath string, attempts []TransferResult) server_structs.TransferFeedback {
+	feedback := server_structs.TransferFeedback{
+		Path:     objectPath,
+		Attempts: make([]server_structs.TransferFeedbackAttempt, 0, len(attempts)),
+	}
+	for _, attempt := range attempts {
+		// Attempts through the local cache's socket say nothing about the federation's caches
+		if attempt.Endpoint == "" || attempt.Endpoint == "local-cache" {
+			continue
+		}
+		feedback.Attempts = append(feedback.Attempts, server_structs.TransferFeedbackAttempt{
+			Server:          attempt.Endpoint,
+			Bytes:           attempt.TransferFileBytes,
+			Duration:        attempt.TransferTime,
+			TimeToFirstByte: attempt.TimeToFirstByte,
+			ErrorClass:      transferErrorClass(attempt.Error),
+		})
+	}
+	return feedback
+}
+
+// Send a transfer feedback report to the director.  The report carries no credentials: the
+// token used for the transfer is scoped to the object, not to the director, so it's never
+// sent along.  The director attributes reports to the client's address instead.
+func sendTransferFeedback(ctx context.Context, directorUrl string, feedback server_structs.TransferFeedback) error {
+	dUrl, err := url.Parse(directorUrl)
+	if err != nil {
+		return errors.Wrap(err, "invalid director URL")
+	}
+	body, err := json.Marshal(feedback)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the transfer feedback")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dUrl.JoinPath("/api/v1.0/director/transferFeedback").String(), bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create the transfer feedback request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", getUserAgent(""))
+
+	client := &http.Client{Transport: config.GetTransport()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send the transfer feedback")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("director rejected the transfer feedback with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// If enabled, report the outcome of each attempt to download the file to the director in the
+// background.  The report is skipped if the transfer didn't involve a director.
+func reportTransferFeedback(transfer *transferFile, results TransferResults) {
+	if !param.Client_EnableTransferFeedback.GetBool() || transfer == nil || transfer.job == nil {
+		return
+	}
+	directorUrl := transfer.job.directorUrl
+	if directorUrl == "" || transfer.remoteURL == nil {
+		return
+	}
+	feedback := newTransferFeedback(transfer.remoteURL.Path, results.Attempts)
+	if len(feedback.Attempts) == 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), transferFeedbackTimeout)
+		defer cancel()
+		if err := sendTransferFeedback(ctx, directorUrl, feedback); err != nil {
+			log.Debugln("Failed to report transfer feedback to the director:", err)
+		}
+	}()
+}
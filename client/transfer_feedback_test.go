@@ -0,0 +1,94 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/error_codes"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+func TestTransferErrorClass(t *testing.T) {
+	assert.Equal(t, "", transferErrorClass(nil))
+	assert.Equal(t, "Unknown", transferErrorClass(errors.New("something broke")))
+
+	slowErr := error_codes.NewTransfer_SlowTransferError(errors.New("too slow"))
+	assert.Equal(t, "Transfer.SlowTransfer", transferErrorClass(slowErr))
+	// The class is found through the attempt's wrapping
+	wrapped := newTransferAttemptError("cache.example.com", "", false, false, errors.Wrap(slowErr, "download failed"))
+	assert.Equal(t, "Transfer.SlowTransfer", transferErrorClass(wrapped))
+}
+
+func TestNewTransferFeedback(t *testing.T) {
+	attempts := []TransferResult{
+		{Endpoint: "local-cache", Error: errors.New("socket missing")},
+		{Endpoint: "cache1.example.com:8443", TransferFileBytes: 10, TransferTime: 2 * time.Second, TimeToFirstByte: time.Second, Error: errors.New("reset")},
+		{Endpoint: "cache2.example.com:8443", TransferFileBytes: 100, TransferTime: time.Second},
+	}
+	feedback := newTransferFeedback("/foo/bar.txt", attempts)
+	assert.Equal(t, "/foo/bar.txt", feedback.Path)
+	require.Len(t, feedback.Attempts, 2)
+	assert.Equal(t, server_structs.TransferFeedbackAttempt{
+		Server:          "cache1.example.com:8443",
+		Bytes:           10,
+		Duration:        2 * time.Second,
+		TimeToFirstByte: time.Second,
+		ErrorClass:      "Unknown",
+	}, feedback.Attempts[0])
+	assert.Equal(t, "cache2.example.com:8443", feedback.Attempts[1].Server)
+	assert.Empty(t, feedback.Attempts[1].ErrorClass)
+}
+
+func TestSendTransferFeedback(t *testing.T) {
+	received := server_structs.TransferFeedback{}
+	reject := false
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1.0/director/transferFeedback", r.URL.Path)
+		assert.Empty(t, r.Header.Get("Authorization"), "the transfer's token must never be sent to the director")
+		if reject {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(svr.Close)
+
+	feedback := server_structs.TransferFeedback{
+		Path:     "/foo/bar.txt",
+		Attempts: []server_structs.TransferFeedbackAttempt{{Server: "cache.example.com:8443", Bytes: 5}},
+	}
+	require.NoError(t, sendTransferFeedback(context.Background(), svr.URL, feedback))
+	assert.Equal(t, feedback, received)
+
+	reject = true
+	err := sendTransferFeedback(context.Background(), svr.URL, feedback)
+	assert.ErrorContains(t, err, "status 429")
+}
@@ -987,6 +987,29 @@ func printStats(stats *local_cache.CacheStats) error {
 		}
 	}
 
+	if len(stats.NamespaceQuotas) > 0 {
+		prefixes := make([]string, 0, len(stats.NamespaceQuotas))
+		for prefix := range stats.NamespaceQuotas {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Strings(prefixes)
+		quotaStr := func(q uint64) string {
+			if q == 0 {
+				return "-"
+			}
+			return utils.HumanBytes(q)
+		}
+
+		fmt.Printf("\nNamespace Quotas:\n")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "  NAMESPACE\tUSAGE\tSOFT\tHARD\n")
+		for _, prefix := range prefixes {
+			q := stats.NamespaceQuotas[prefix]
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", prefix, utils.HumanBytes(q.Usage), quotaStr(q.SoftQuota), quotaStr(q.HardQuota))
+		}
+		w.Flush()
+	}
+
 	return nil
 }
 
@@ -0,0 +1,221 @@
+//go:build server
+
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+var (
+	directorTopologyCmd = &cobra.Command{
+		Use:   "topology",
+		Short: "Show which origins and caches serve the federation's namespaces",
+		Long: `Query the federation's director for its namespaces, the origins exporting
+them and the caches serving them, along with each server's location and status.
+
+Examples:
+  # Show every namespace in the federation
+  pelican director topology -f osg-htc.org
+
+  # Show the caches serving a namespace that support listings
+  pelican director topology --prefix /ospool/data --capability Listings`,
+		Args:         cobra.NoArgs,
+		RunE:         runDirectorTopology,
+		SilenceUsage: true,
+	}
+
+	topologyPrefix       string
+	topologyCapabilities []string
+	topologyDirectorUrl  string
+)
+
+func init() {
+	flags := directorTopologyCmd.Flags()
+	flags.StringVar(&topologyPrefix, "prefix", "", "Only show namespaces at or below this path, or containing it")
+	flags.StringSliceVar(&topologyCapabilities, "capability", nil, "Only show namespaces supporting this capability (e.g. PublicReads, Writes, Listings); may be repeated")
+	flags.StringVar(&topologyDirectorUrl, "director", "", "The director to query; defaults to the federation's director")
+
+	directorCmd.AddCommand(directorTopologyCmd)
+}
+
+// Build the URL of the director's topology API from the flags
+func getTopologyUrl(ctx context.Context) (*url.URL, error) {
+	directorUrlStr := topologyDirectorUrl
+	if directorUrlStr == "" {
+		fedInfo, err := config.GetFederation(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get federation information")
+		}
+		directorUrlStr = fedInfo.DirectorEndpoint
+		if directorUrlStr == "" {
+			return nil, errors.New("Director endpoint not found in federation configuration. Please set Federation.DirectorUrl or pass --director")
+		}
+	}
+	directorUrl, err := url.Parse(directorUrlStr)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid director URL")
+	}
+
+	topologyUrl := directorUrl.JoinPath("/api/v1.0/director/topology")
+	query := topologyUrl.Query()
+	if topologyPrefix != "" {
+		query.Set("prefix", topologyPrefix)
+	}
+	for _, capability := range topologyCapabilities {
+		query.Add("capability", capability)
+	}
+	topologyUrl.RawQuery = query.Encode()
+	return topologyUrl, nil
+}
+
+func runDirectorTopology(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := config.InitClient(); err != nil {
+		log.Errorln("Failed to initialize client:", err)
+	}
+
+	topologyUrl, err := getTopologyUrl(ctx)
+	if err != nil {
+		return err
+	}
+	log.Debugln("Requesting the federation topology from:", topologyUrl.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, topologyUrl.String(), nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create director API request")
+	}
+	req.Header.Set("User-Agent", "pelican-client/"+config.GetVersion())
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Transport: config.GetTransport()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to query the director")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the director's response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		apiResp := server_structs.SimpleApiResp{}
+		if err := json.Unmarshal(body, &apiResp); err == nil && apiResp.Msg != "" {
+			return errors.Errorf("director returned status %d: %s", resp.StatusCode, apiResp.Msg)
+		}
+		return errors.Errorf("director returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	topology := server_structs.TopologyResponse{}
+	if err := json.Unmarshal(body, &topology); err != nil {
+		return errors.Wrap(err, "failed to parse the director's response")
+	}
+
+	if outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(topology)
+	}
+	return printTopology(os.Stdout, topology)
+}
+
+// Render the enabled capabilities as a comma-separated list
+func formatCapabilities(caps server_structs.Capabilities) string {
+	names := []string{}
+	for _, c := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"PublicReads", caps.PublicReads},
+		{"Reads", caps.Reads},
+		{"Writes", caps.Writes},
+		{"Listings", caps.Listings},
+		{"DirectReads", caps.DirectReads},
+		{"Copies", caps.Copies},
+	} {
+		if c.enabled {
+			names = append(names, c.name)
+		}
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ",")
+}
+
+func printTopology(out io.Writer, topology server_structs.TopologyResponse) error {
+	if len(topology.Namespaces) == 0 {
+		fmt.Fprintln(out, "No namespaces found matching the criteria.")
+		return nil
+	}
+
+	servers := make(map[string]server_structs.TopologyServer, len(topology.Servers))
+	for _, server := range topology.Servers {
+		servers[server.Name] = server
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for idx, ns := range topology.Namespaces {
+		if idx > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Namespace %s (%s)\n", ns.Path, formatCapabilities(ns.Caps))
+		fmt.Fprintf(w, "  TYPE\tNAME\tURL\tLOCATION\tSTATUS\tFEATURES\n")
+		for _, group := range []struct {
+			sType string
+			names []string
+		}{{"origin", ns.Origins}, {"cache", ns.Caches}} {
+			for _, name := range group.names {
+				server, ok := servers[name]
+				if !ok {
+					fmt.Fprintf(w, "  %s\t%s\t-\t-\t-\t-\n", group.sType, name)
+					continue
+				}
+				features := "-"
+				if len(server.RequiredFeatures) > 0 {
+					features = strings.Join(server.RequiredFeatures, ",")
+				}
+				fmt.Fprintf(w, "  %s\t%s\t%s\t%.4f,%.4f\t%s\t%s\n",
+					group.sType, server.Name, server.URL, server.Latitude, server.Longitude, server.Status, features)
+			}
+		}
+	}
+	return w.Flush()
+}
@@ -33,6 +33,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -165,6 +166,12 @@ type storageDigestResult struct {
 
 // introspectGet calls the origin's storage API and decodes the result.
 func introspectGet(cmd *cobra.Command, endpoint string, query url.Values, out any) error {
+	return introspectRequest(cmd, http.MethodGet, endpoint, query, nil, out)
+}
+
+// introspectRequest is introspectGet for any method, sending body, when it is
+// not nil, as JSON.
+func introspectRequest(cmd *cobra.Command, method, endpoint string, query url.Values, body, out any) error {
 	if err := config.InitClient(); err != nil {
 		return errors.Wrap(err, "failed to initialize configuration")
 	}
@@ -192,11 +199,22 @@ func introspectGet(cmd *cobra.Command, endpoint string, query url.Values, out an
 		return errors.Wrap(err, "failed to obtain an administrator token")
 	}
 
-	req, err := http.NewRequestWithContext(cmd.Context(), http.MethodGet, target.String(), nil)
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode the request")
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(cmd.Context(), method, target.String(), reqBody)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Authorization", "Bearer "+tok)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
 	resp, err := config.GetClient().Do(req)
 	if err != nil {
@@ -204,7 +222,7 @@ func introspectGet(cmd *cobra.Command, endpoint string, query url.Values, out an
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return errors.Wrap(err, "failed to read the origin's response")
 	}
@@ -215,7 +233,7 @@ func introspectGet(cmd *cobra.Command, endpoint string, query url.Values, out an
 		// Distinguish "no such object" from "this origin has no storage API",
 		// which is what a non-pstore origin returns for every route here.
 		var apiResp server_structs.SimpleApiResp
-		if json.Unmarshal(body, &apiResp) == nil && apiResp.Msg != "" {
+		if json.Unmarshal(respBody, &apiResp) == nil && apiResp.Msg != "" {
 			return errors.New(apiResp.Msg)
 		}
 		return errors.New("the origin has no storage introspection API; " +
@@ -225,17 +243,17 @@ func introspectGet(cmd *cobra.Command, endpoint string, query url.Values, out an
 			"and the locally-minted token is only accepted by the origin whose issuer key signed it")
 	default:
 		var apiResp server_structs.SimpleApiResp
-		if json.Unmarshal(body, &apiResp) == nil && apiResp.Msg != "" {
+		if json.Unmarshal(respBody, &apiResp) == nil && apiResp.Msg != "" {
 			return errors.Errorf("the origin returned %d: %s", resp.StatusCode, apiResp.Msg)
 		}
 		return errors.Errorf("the origin returned %d", resp.StatusCode)
 	}
 
 	if originIntrospectJSON {
-		fmt.Println(string(body))
+		fmt.Println(string(respBody))
 		return errSuppressOutput
 	}
-	return errors.Wrap(json.Unmarshal(body, out), "the origin's response could not be decoded")
+	return errors.Wrap(json.Unmarshal(respBody, out), "the origin's response could not be decoded")
 }
 
 // errSuppressOutput signals that --json already printed everything.
@@ -44,6 +44,7 @@ import (
 
 	"github.com/pelicanplatform/pelican/config"
 	"github.com/pelicanplatform/pelican/local_cache"
+	"github.com/pelicanplatform/pelican/origin_serve"
 	"github.com/pelicanplatform/pelican/param"
 	"github.com/pelicanplatform/pelican/pstore"
 	"github.com/pelicanplatform/pelican/utils"
@@ -62,6 +63,17 @@ var (
 	pstoreRecursive bool
 	pstoreResume    bool
 
+	pstoreRestoreVersion string
+	pstoreRestoreAt      string
+
+	pstoreBackupSince     uint64
+	pstoreDataTo          string
+	pstoreDataFrom        string
+	pstoreDataFullEvery   int
+	pstoreDataKeep        int
+	pstoreDataManifest    string
+	pstoreDataStorageDirs []string
+
 	originPStoreCmd = &cobra.Command{
 		Use:   "pstore",
 		Short: "Inspect and repair a Pelican store",
@@ -167,6 +179,12 @@ Three things open the finished file: any of those issuer keys, the backup key
 from "pstore metadata-backup-key", or this one file's own key from "pstore
 metadata-backup-key <file>".
 
+Pass --since with one past the version a previous backup ran through to write
+an incremental backup instead: only the records changed from there on,
+deletions included. Every backup prints the version it runs through. An
+incremental restores only on top of the backups before it; see
+"pstore metadata-restore".
+
 Set Origin.PStoreMetadataBackupLocation to have the origin back itself up on a
 timer, which works while it is running. This command is for taking one by hand.`,
 		Args:         cobra.ExactArgs(1),
@@ -175,8 +193,8 @@ timer, which works while it is running. This command is for taking one by hand.`
 	}
 
 	originPStoreRestoreCmd = &cobra.Command{
-		Use:   "metadata-restore <file>",
-		Short: "Restore a metadata backup into an empty store",
+		Use:   "metadata-restore <file> [incremental-file...]",
+		Short: "Restore a metadata backup, and any incrementals on it, into an empty store",
 		Long: `Restore a metadata backup taken by "pstore metadata-backup".
 
 This restores the namespace only. The object data must already be in place --
@@ -188,8 +206,11 @@ two namespaces rather than replace one. Restore into a fresh directory
 alongside the object data and masterkey.json the backup was taken with, then
 point the origin at it.
 
-Every metadata backup is complete in itself; there are no incremental backups
-to chain, precisely because the target must be empty.
+An incremental backup carries only what changed after the backup before it.
+Restore a chain by naming its full backup first and then each incremental in
+the order they were taken; the origin's scheduled backups sort into that order
+by name. Restoring an incremental without its full backup, or with one missing
+in between, is refused before anything is written.
 
 The backup is opened with the origin's issuer keys, so on the origin itself
 nothing more is needed. Elsewhere -- or if those keys are gone -- pass either:
@@ -197,16 +218,65 @@ nothing more is needed. Elsewhere -- or if those keys are gone -- pass either:
   --key-file  the backup key from "pstore metadata-backup-key", which opens
               every backup this origin has written; or
   --file-key  this one file's own key, from "pstore metadata-backup-key <file>",
-              which opens this file and no other.
+              which opens this file and no other -- so it restores a single
+              file, not a chain.
 
 Which container the file is in is read from the file itself. A backup written
 in a format version this build does not read is refused by version number
 rather than decoded.`,
-		Args:         cobra.ExactArgs(1),
+		Args:         cobra.MinimumNArgs(1),
 		RunE:         runPStoreRestore,
 		SilenceUsage: true,
 	}
 
+	originPStoreDataBackupCmd = &cobra.Command{
+		Use:   "data-backup --to <location>",
+		Short: "Back up the store's object data and metadata to a directory or S3 bucket",
+		Long: `Back up the whole store -- catalog, masterkey.json, and every storage
+directory's block files -- to a directory or an "s3://bucket/prefix" location.
+
+Block files are cut into pieces stored once however many backups refer to
+them, so after the first backup to a location only what changed is sent. The
+pieces are the block files as they sit on disk, already encrypted; the catalog
+and the manifest listing what a backup holds are sealed exactly as a metadata
+backup is. The backup is complete only once its manifest is written, so an
+interrupted run leaves nothing that looks restorable.
+
+S3 settings come from Origin.PStoreDataBackupS3*. Set
+Origin.PStoreDataBackupLocation to have the origin do this on a timer while it
+runs; this command is for taking one by hand, with the origin stopped.
+
+Restore with "pstore data-restore".`,
+		Args:         cobra.NoArgs,
+		RunE:         runPStoreDataBackup,
+		SilenceUsage: true,
+	}
+
+	originPStoreDataRestoreCmd = &cobra.Command{
+		Use:   "data-restore --from <location>",
+		Short: "Rebuild a store from a data backup",
+		Long: `Rebuild a working store from a backup taken by "pstore data-backup" or by an
+origin with Origin.PStoreDataBackupLocation set.
+
+The store directory (--location, or Origin.PStoreLocation) must not already
+hold a store. The catalog is restored there along with masterkey.json, and the
+block files into the storage directories: by default those the store had when
+it was backed up, or, with --storage-dir given once per directory, new ones in
+the order of the original storage IDs. A storage directory must be empty.
+
+The newest backup at the location is restored unless --manifest names another.
+Every piece is checked against its hash as it is written. Point
+Origin.PStoreStorageDirs at the directories printed, then verify with
+"pelican-server origin pstore fsck --deep" before starting the origin.
+
+The backup is opened with the origin's issuer keys, or with --key-file holding
+the backup key from "pstore metadata-backup-key". The issuer keys are needed
+in any case to unwrap masterkey.json.`,
+		Args:         cobra.NoArgs,
+		RunE:         runPStoreDataRestore,
+		SilenceUsage: true,
+	}
+
 	originPStoreBackupKeyCmd = &cobra.Command{
 		Use:   "metadata-backup-key [backup-file]",
 		Short: "Print the key that opens this origin's metadata backups, or one file's own key",
@@ -296,6 +366,116 @@ These are the values the origin serves in response to Want-Digest.`,
 		RunE:         runPStoreDigest,
 		SilenceUsage: true,
 	}
+
+	originPStoreVersionsCmd = &cobra.Command{
+		Use:   "versions <path>",
+		Short: "List the versions of an object",
+		Long: `List the versions of an object that can still be read, newest first: the
+current one, then those retained after being overwritten or deleted under
+Origin.PStoreVersioning.
+
+A running origin serves the same list at the object's URL with ?versions, and
+any one version's content with ?version=<etag>.`,
+		Args:         cobra.ExactArgs(1),
+		RunE:         runPStoreVersions,
+		SilenceUsage: true,
+	}
+
+	originPStoreRestoreVersionCmd = &cobra.Command{
+		Use:   "restore <path>",
+		Short: "Make a retained version of an object current again",
+		Long: `Restore a retained version of an object, or everything under a directory as it
+was at a point in time.
+
+With --version, the object at <path> is put back to that version, named by the
+ETag shown by ` + "`versions`" + `. The restored object has its old ETag again.
+
+With --at, every object at or under <path> is put back to the version that was
+current at that time, given as an RFC 3339 timestamp or as a duration ago
+(for example "2h"). Objects created since, and those whose version from then is
+no longer retained, are left as they are and listed: a restore never deletes.
+
+The version being replaced is itself retained, so a restore can be undone the
+same way. Restoring a deleted object recreates its directory.`,
+		Args:         cobra.ExactArgs(1),
+		RunE:         runPStoreRestoreVersion,
+		SilenceUsage: true,
+	}
+
+	originPStoreReplicationCmd = &cobra.Command{
+		Use:   "replication",
+		Short: "Show the store's replication role and position",
+		Long: `Show the store's part in replication between two pstore origins.
+
+A primary reports its replication ID and the range of its log that its
+secondary has not yet confirmed applying. A secondary reports the primary it
+follows and the last record it applied. A running origin publishes the same
+figures as the pelican_pstore_replication_* metrics.`,
+		Args:         cobra.NoArgs,
+		RunE:         runPStoreReplication,
+		SilenceUsage: true,
+	}
+
+	originPStorePromoteCmd = &cobra.Command{
+		Use:   "promote",
+		Short: "Make a replication secondary a primary, for failover",
+		Long: `Promote a replication secondary to a primary.
+
+Everything the secondary applied is kept and it accepts writes from then on.
+Set Origin.PStoreReplicationRole to "primary" before starting the origin again,
+and Origin.PStoreReplicationSecondaryUrl if it should replicate in turn.
+
+The promoted store takes a new replication ID. The old primary must not come
+back as a primary: demote it and point it at this one, and it is
+resynchronized from here in full, discarding whatever it committed that never
+reached this store.
+
+Run it with the origin stopped.`,
+		Args:         cobra.NoArgs,
+		RunE:         runPStorePromote,
+		SilenceUsage: true,
+	}
+
+	originPStoreDemoteCmd = &cobra.Command{
+		Use:   "demote",
+		Short: "Make a store a replication secondary",
+		Long: `Demote a primary or standalone store to a replication secondary.
+
+The store's replication log is dropped, and its contents are replaced with
+the primary's when that primary first ships to it. It refuses client writes
+from then on. Set Origin.PStoreReplicationRole to "secondary" and
+Origin.PStoreReplicationPrimaryIssuer before starting the origin again.
+
+Run it with the origin stopped.`,
+		Args:         cobra.NoArgs,
+		RunE:         runPStoreDemote,
+		SilenceUsage: true,
+	}
+
+	originPStoreRebuildCmd = &cobra.Command{
+		Use:   "rebuild",
+		Short: "Rebuild erasure-coded objects after a disk failure or replacement",
+		Long: `Restore every erasure-coded object to full protection.
+
+Every shard of every erasure-coded object is checked against the digest
+recorded when it was written, and each one that is missing or corrupt is
+rebuilt from the rest of its stripe.
+
+After replacing a failed disk, mount the new one, empty, at the failed one's
+path and run this: the new directory takes over the old one's identity and the
+lost shards are rebuilt onto it. Shards whose directory is no longer present at
+all are rebuilt in other directories instead.
+
+Objects that lost more shards than they have parity are listed; restore them
+from backup or re-ingest them. The origin reads through a lost shard and the
+data scan rebuilds them on its own, so this is needed only to restore
+protection without waiting for either.
+
+Run it with the origin stopped.`,
+		Args:         cobra.NoArgs,
+		RunE:         runPStoreRebuild,
+		SilenceUsage: true,
+	}
 )
 
 func init() {
@@ -307,8 +487,16 @@ func init() {
 	originPStoreCmd.AddCommand(originPStoreDigestCmd)
 	originPStoreCmd.AddCommand(originPStoreBackupCmd)
 	originPStoreCmd.AddCommand(originPStoreRestoreCmd)
+	originPStoreCmd.AddCommand(originPStoreDataBackupCmd)
+	originPStoreCmd.AddCommand(originPStoreDataRestoreCmd)
 	originPStoreCmd.AddCommand(originPStoreBackupKeyCmd)
 	originPStoreCmd.AddCommand(originPStoreExportCmd)
+	originPStoreCmd.AddCommand(originPStoreVersionsCmd)
+	originPStoreCmd.AddCommand(originPStoreRestoreVersionCmd)
+	originPStoreCmd.AddCommand(originPStoreReplicationCmd)
+	originPStoreCmd.AddCommand(originPStorePromoteCmd)
+	originPStoreCmd.AddCommand(originPStoreDemoteCmd)
+	originPStoreCmd.AddCommand(originPStoreRebuildCmd)
 
 	originPStoreCmd.PersistentFlags().String("location", "",
 		"Store directory (defaults to Origin.PStoreLocation)")
@@ -320,6 +508,27 @@ func init() {
 		"Repair the problems found instead of only reporting them")
 	originPStoreBackupCmd.Flags().StringVar(&pstoreKeyFile, "key-file", "",
 		"Seal the backup to the backup key in this file instead of the origin's issuer keys")
+	originPStoreBackupCmd.Flags().Uint64Var(&pstoreBackupSince, "since", 0,
+		"Write an incremental backup of what changed from this catalog version on: one "+
+			"past the version the previous backup ran through")
+	originPStoreDataBackupCmd.Flags().StringVar(&pstoreDataTo, "to", "",
+		"Directory or s3://bucket/prefix to back up to (required)")
+	originPStoreDataBackupCmd.Flags().IntVar(&pstoreDataFullEvery, "full-every", 1,
+		"Make the catalog snapshot incremental on the previous backup's until this many "+
+			"backups share one full snapshot")
+	originPStoreDataBackupCmd.Flags().IntVar(&pstoreDataKeep, "keep", 0,
+		"Prune the location to this many backups afterwards (0 keeps every backup)")
+	originPStoreDataBackupCmd.Flags().StringVar(&pstoreKeyFile, "key-file", "",
+		"Seal the backup to the backup key in this file instead of the origin's issuer keys")
+	originPStoreDataRestoreCmd.Flags().StringVar(&pstoreDataFrom, "from", "",
+		"Directory or s3://bucket/prefix to restore from (required)")
+	originPStoreDataRestoreCmd.Flags().StringVar(&pstoreDataManifest, "manifest", "",
+		"Restore this backup rather than the newest")
+	originPStoreDataRestoreCmd.Flags().StringArrayVar(&pstoreDataStorageDirs, "storage-dir", nil,
+		"Restore the block files into this directory; give once per storage directory, "+
+			"in storage ID order")
+	originPStoreDataRestoreCmd.Flags().StringVar(&pstoreKeyFile, "key-file", "",
+		"Open the backup with the backup key in this file instead of the origin's issuer keys")
 	originPStoreRestoreCmd.Flags().StringVar(&pstoreKeyFile, "key-file", "",
 		"Open the backup with the backup key in this file instead of the origin's issuer "+
 			"keys (see `metadata-backup-key`)")
@@ -339,6 +548,12 @@ func init() {
 		"Skip objects already present in the destination instead of reporting them")
 	originPStoreFsckCmd.Flags().BoolVar(&pstoreDeep, "deep", false,
 		"Also read every object back and verify it against its stored checksums")
+	originPStoreRestoreVersionCmd.Flags().StringVar(&pstoreRestoreVersion, "version", "",
+		"ETag of the version to restore (see `versions`)")
+	originPStoreRestoreVersionCmd.Flags().StringVar(&pstoreRestoreAt, "at", "",
+		"Restore what was current at this time: an RFC 3339 timestamp, or a duration ago")
+	originPStoreRestoreVersionCmd.MarkFlagsMutuallyExclusive("version", "at")
+	originPStoreRestoreVersionCmd.MarkFlagsOneRequired("version", "at")
 }
 
 // openPStoreForCLI opens the configured store for offline maintenance.
@@ -701,6 +916,190 @@ func runPStoreDigest(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runPStoreVersions(cmd *cobra.Command, args []string) error {
+	store, err := openPStoreForCLI(cmd, false)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	versions, err := store.Versions(args[0])
+	if err != nil {
+		return errors.Wrapf(err, "cannot list the versions of %s", args[0])
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintf(w, "ETAG\tSIZE\tMODIFIED\tSUPERSEDED\n")
+	for _, v := range versions {
+		superseded := "current"
+		if !v.Current {
+			superseded = v.Superseded().Format(time.RFC3339)
+			if v.Deleted {
+				superseded += " (deleted)"
+			}
+		}
+		// The full ETag, unlike ls: it is what restore --version takes.
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.ETag(), utils.HumanBytes(v.Size),
+			v.ModTime().Format(time.RFC3339), superseded)
+	}
+	return nil
+}
+
+func runPStoreRestoreVersion(cmd *cobra.Command, args []string) error {
+	var at time.Time
+	if pstoreRestoreAt != "" {
+		var err error
+		if at, err = parseRestoreTime(pstoreRestoreAt, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	store, err := openPStoreForCLI(cmd, true)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if pstoreRestoreVersion != "" {
+		d, err := store.RestoreVersion(args[0], pstoreRestoreVersion)
+		if err != nil {
+			return errors.Wrapf(err, "cannot restore %s", args[0])
+		}
+		fmt.Printf("Restored %s to version %s (%s)\n", args[0], d.ETag(), utils.HumanBytes(d.Size))
+		return nil
+	}
+
+	report, err := store.RestoreAt(cmd.Context(), args[0], at)
+	if report != nil {
+		fmt.Printf("Restored %d object(s) under %s to their versions as of %s; %d already matched\n",
+			len(report.Restored), args[0], at.Format(time.RFC3339), report.Unchanged)
+		reportList("Restored", report.Restored)
+		reportList("Left as they are (no retained version from then)", report.Skipped)
+		reportList("Left as they are (locked by a retention rule or legal hold)", report.Locked)
+	}
+	return errors.Wrapf(err, "restore of %s did not complete", args[0])
+}
+
+func runPStoreReplication(cmd *cobra.Command, _ []string) error {
+	store, err := openPStoreForCLI(cmd, false)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	info, err := store.ReplicationInfo()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Role: %s\n", info.Role)
+	switch info.Role {
+	case pstore.RolePrimary:
+		fmt.Printf("Replication ID: %s\n", info.ID)
+		if info.LogFirst > info.LogLast {
+			fmt.Printf("Log: empty; the secondary has applied everything through record %d\n", info.LogLast)
+		} else {
+			fmt.Printf("Log: records %d to %d not yet confirmed by the secondary\n", info.LogFirst, info.LogLast)
+		}
+	case pstore.RoleSecondary:
+		if info.ID == "" {
+			fmt.Println("Following: no primary yet; the first one to ship will resynchronize this store")
+		} else {
+			fmt.Printf("Following: %s\n", info.ID)
+			fmt.Printf("Applied: through record %d\n", info.Applied)
+		}
+	}
+	return nil
+}
+
+func runPStorePromote(cmd *cobra.Command, _ []string) error {
+	store, err := openPStoreForCLI(cmd, true)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	info, err := store.ReplicationInfo()
+	if err != nil {
+		return err
+	}
+	id, err := store.Promote()
+	if err != nil {
+		return errors.Wrap(err, "cannot promote the store")
+	}
+	fmt.Printf("Promoted the store to a replication primary with ID %s, keeping everything it applied "+
+		"through record %d of its old primary.\n", id, info.Applied)
+	fmt.Printf("Set %s to \"primary\" before starting the origin.\n", param.Origin_PStoreReplicationRole.GetName())
+	return nil
+}
+
+func runPStoreDemote(cmd *cobra.Command, _ []string) error {
+	store, err := openPStoreForCLI(cmd, true)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if err := store.Demote(); err != nil {
+		return errors.Wrap(err, "cannot demote the store")
+	}
+	fmt.Println("Demoted the store to a replication secondary; its primary will resynchronize it in full.")
+	fmt.Printf("Set %s to \"secondary\" and %s before starting the origin.\n",
+		param.Origin_PStoreReplicationRole.GetName(), param.Origin_PStoreReplicationPrimaryIssuer.GetName())
+	return nil
+}
+
+func runPStoreRebuild(cmd *cobra.Command, _ []string) error {
+	store, err := openPStoreForCLI(cmd, true)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	report, err := store.RebuildErasure(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "rebuild failed")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, id := range report.AdoptedDirs {
+		fmt.Fprintf(w, "Adopted replacement for storage directory:\t%d\n", id)
+	}
+	for _, id := range report.RetiredDirs {
+		fmt.Fprintf(w, "Retired missing storage directory:\t%d\n", id)
+	}
+	fmt.Fprintf(w, "Erasure-coded objects checked:\t%d\n", report.Objects)
+	fmt.Fprintf(w, "Erasure-coded objects repaired:\t%d\n", report.ObjectsRepaired)
+	fmt.Fprintf(w, "Shards rebuilt:\t%d\n", report.ShardsRebuilt)
+	fmt.Fprintf(w, "Shards moved to another directory:\t%d\n", report.ShardsRelocated)
+	w.Flush()
+
+	if len(report.Unrecoverable) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(report.Unrecoverable))
+	for name := range report.Unrecoverable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Println("\nCould not be rebuilt -- restore from backup or re-ingest:")
+	for _, name := range names {
+		fmt.Printf("  %s: %s\n", name, report.Unrecoverable[name])
+	}
+	return errors.Errorf("%d object(s) could not be rebuilt", len(names))
+}
+
+// parseRestoreTime reads --at: an RFC 3339 timestamp, or a duration before now.
+func parseRestoreTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, errors.Errorf("invalid --at %q: expected an RFC 3339 time or a duration such as 2h", value)
+}
+
 // shortETag abbreviates a generation for column output.
 func shortETag(generation string) string {
 	if generation == "" {
@@ -731,7 +1130,13 @@ func runPStoreBackup(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	if _, err = store.BackupEncrypted(f, keys); err != nil {
+	var through uint64
+	if pstoreBackupSince > 0 {
+		through, err = store.BackupIncremental(f, keys, pstoreBackupSince)
+	} else {
+		through, err = store.BackupEncrypted(f, keys)
+	}
+	if err != nil {
 		// Leaving the fragment behind is worse than having written nothing:
 		// it has the name of a backup, and the next attempt refuses to
 		// overwrite it.
@@ -752,6 +1157,8 @@ func runPStoreBackup(cmd *cobra.Command, args []string) error {
 	if err == nil {
 		fmt.Printf("Wrote %s (%s)\n", args[0], utils.HumanBytes(info.Size()))
 	}
+	fmt.Printf("It runs through catalog version %d; an incremental on it takes --since %d.\n",
+		through, through+1)
 	// Guidance goes to stderr so that redirecting stdout captures the result
 	// and not the advice.
 	fmt.Fprintln(os.Stderr, "This covers the catalog only. Keep the object data and "+
@@ -772,23 +1179,20 @@ func runPStoreRestore(cmd *cobra.Command, args []string) error {
 	}
 	defer store.Close()
 
-	f, err := os.Open(args[0])
-	if err != nil {
-		return errors.Wrapf(err, "cannot read %s", args[0])
-	}
-	defer f.Close()
-
 	keys, err := pstoreBackupKeys()
 	if err != nil {
 		return err
 	}
 	// Restore decides from the file's own magic whether it is sealed and under
 	// which format version, so an operator does not have to know which release
-	// wrote the snapshot they are holding.
-	if err := store.Restore(f, keys); err != nil {
-		return err
+	// wrote the snapshot they are holding.  It also checks that each file
+	// continues the chain the ones before it restored.
+	for _, path := range args {
+		if err := pstoreRestoreFile(store, path, keys); err != nil {
+			return err
+		}
+		fmt.Printf("Restored the catalog from %s.\n", path)
 	}
-	fmt.Printf("Restored the catalog from %s.\n", args[0])
 	fmt.Fprintln(os.Stderr, "The catalog is only half of a recovery: the object data and "+
 		"masterkey.json from the same store must be in place too.")
 	fmt.Fprintln(os.Stderr,
@@ -796,6 +1200,113 @@ func runPStoreRestore(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func pstoreRestoreFile(store *pstore.Store, path string, keys pstore.BackupKeys) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read %s", path)
+	}
+	defer f.Close()
+	return errors.Wrapf(store.Restore(f, keys), "failed to restore %s", path)
+}
+
+func runPStoreDataBackup(cmd *cobra.Command, _ []string) error {
+	if pstoreDataTo == "" {
+		return errors.New("--to is required: it names the directory or bucket to back up to")
+	}
+	store, err := openPStoreForCLI(cmd, false)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	target, err := origin_serve.OpenPStoreBackupTarget(cmd.Context(), pstoreDataTo)
+	if err != nil {
+		return err
+	}
+	keys, err := pstoreBackupKeys()
+	if err != nil {
+		return err
+	}
+	report, err := store.BackupData(cmd.Context(), target, pstore.DataBackupOptions{
+		Keys:      keys,
+		FullEvery: pstoreDataFullEvery,
+		Keep:      pstoreDataKeep,
+	})
+	if err != nil {
+		return err
+	}
+
+	kind := "full"
+	if report.Incremental {
+		kind = "incremental"
+	}
+	fmt.Printf("Wrote %s to %s (%s catalog snapshot)\n", report.Manifest, target, kind)
+	fmt.Printf("%d files: %s uploaded, %s already held by earlier backups\n", report.Files,
+		utils.HumanBytes(report.BytesUploaded), utils.HumanBytes(report.BytesShared))
+	if report.Pruned > 0 {
+		fmt.Printf("Pruned %d older backups\n", report.Pruned)
+	}
+	return nil
+}
+
+func runPStoreDataRestore(cmd *cobra.Command, _ []string) error {
+	if pstoreDataFrom == "" {
+		return errors.New("--from is required: it names the directory or bucket to restore from")
+	}
+	if err := config.InitClient(); err != nil {
+		return errors.Wrap(err, "failed to initialize configuration")
+	}
+	// As in openPStoreForCLI: masterkey.json is wrapped under the issuer
+	// keys, and the restore opens the store it has just written.
+	if _, err := config.GetIssuerPublicJWKS(); err != nil {
+		return errors.Wrap(err,
+			"failed to load the origin's issuer keys, which the store is encrypted under")
+	}
+	location, err := cmd.Flags().GetString("location")
+	if err != nil {
+		return err
+	}
+	if location == "" {
+		location = param.Origin_PStoreLocation.GetString()
+	}
+	if location == "" {
+		return errors.Errorf("no store location; set %s or pass --location",
+			param.Origin_PStoreLocation.GetName())
+	}
+
+	target, err := origin_serve.OpenPStoreBackupTarget(cmd.Context(), pstoreDataFrom)
+	if err != nil {
+		return err
+	}
+	keys, err := pstoreBackupKeys()
+	if err != nil {
+		return err
+	}
+	report, err := pstore.RestoreData(cmd.Context(), location, target, pstore.DataRestoreOptions{
+		Keys:        keys,
+		Manifest:    pstoreDataManifest,
+		StorageDirs: pstoreDataStorageDirs,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored %s into %s: %d files, %s\n", report.Manifest, location,
+		report.Files, utils.HumanBytes(report.Bytes))
+	ids := make([]int, 0, len(report.Dirs))
+	for id := range report.Dirs {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		fmt.Printf("  storage %d: %s\n", id, report.Dirs[local_cache.StorageID(id)])
+	}
+	fmt.Fprintf(os.Stderr, "Set %s to these directories, then verify with "+
+		"`pelican-server origin pstore fsck --deep` before starting the origin.\n",
+		param.Origin_PStoreStorageDirs.GetName())
+	return nil
+}
+
 // pstoreBackupKeys resolves the keys a hand-run backup or restore uses.
 //
 // openPStoreForCLI has already loaded the origin's issuer keys -- the store
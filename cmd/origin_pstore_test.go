@@ -25,6 +25,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -208,3 +209,20 @@ func TestPStoreBackupKeyForAFile(t *testing.T) {
 			assert.Equal(t, firstKey, viaEscrow)
 		})
 }
+
+func TestParseRestoreTime(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	at, err := parseRestoreTime("2026-04-30T08:00:00Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 4, 30, 8, 0, 0, 0, time.UTC), at)
+
+	at, err = parseRestoreTime("90m", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-90*time.Minute), at)
+
+	for _, bad := range []string{"yesterday", "-1h", "2026-04-30"} {
+		_, err := parseRestoreTime(bad, now)
+		assert.Error(t, err, bad)
+	}
+}
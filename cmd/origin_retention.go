@@ -0,0 +1,254 @@
+//go:build server
+
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+// Managing a running pstore origin's retention rules and legal holds.
+//
+// Like `origin introspect`, these talk to the origin over its administrative
+// storage API, because a hold has to be placed while the data is being served.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	originRetentionMode   string
+	originRetentionPeriod time.Duration
+	originRetentionReason string
+
+	originRetentionCmd = &cobra.Command{
+		Use:   "retention",
+		Short: "Manage retention rules and legal holds on a running origin's store",
+		Long: `Lock objects in a running pstore origin against change.
+
+A retention rule on a path locks every object at or below it for a period
+measured from when the object was written. A legal hold locks them until it is
+released. A locked object cannot be overwritten, renamed, restored over, or
+deleted, and a directory holding one cannot be removed or moved; clients that
+try get 403 Forbidden. New objects can still be written under a locked path,
+and are locked from the moment they land.
+
+A rule is in one of two modes. A governance rule can be shortened or removed
+with these commands. A compliance rule cannot: it can only be lengthened, by
+anyone, ever. Set one only when that is what you mean.
+
+Paths are store paths, as shown by "pelican-server origin introspect ls". Like
+the introspect commands, these authenticate as an administrator with a token
+minted from the local issuer key, or with --token.`,
+		SilenceUsage: true,
+	}
+
+	originRetentionShowCmd = &cobra.Command{
+		Use:          "show [path]",
+		Short:        "Show what locks a path, and the settings beneath it",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         runRetentionShow,
+		SilenceUsage: true,
+	}
+
+	originRetentionSetCmd = &cobra.Command{
+		Use:          "set <path>",
+		Short:        "Set the retention rule on a path",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runRetentionSet,
+		SilenceUsage: true,
+	}
+
+	originRetentionClearCmd = &cobra.Command{
+		Use:          "clear <path>",
+		Short:        "Remove the governance retention rule from a path",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runRetentionClear,
+		SilenceUsage: true,
+	}
+
+	originRetentionHoldCmd = &cobra.Command{
+		Use:          "hold <path>",
+		Short:        "Place a legal hold on a path",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runRetentionHold,
+		SilenceUsage: true,
+	}
+
+	originRetentionReleaseCmd = &cobra.Command{
+		Use:          "release <path>",
+		Short:        "Release the legal hold on a path",
+		Args:         cobra.ExactArgs(1),
+		RunE:         runRetentionRelease,
+		SilenceUsage: true,
+	}
+)
+
+func init() {
+	originCmd.AddCommand(originRetentionCmd)
+	originRetentionCmd.AddCommand(originRetentionShowCmd)
+	originRetentionCmd.AddCommand(originRetentionSetCmd)
+	originRetentionCmd.AddCommand(originRetentionClearCmd)
+	originRetentionCmd.AddCommand(originRetentionHoldCmd)
+	originRetentionCmd.AddCommand(originRetentionReleaseCmd)
+
+	// The same settings as `origin introspect`, since the requests go the
+	// same way.
+	originRetentionCmd.PersistentFlags().StringVar(&originIntrospectServer, "server", "",
+		"Origin web URL (defaults to this host's Server.ExternalWebUrl)")
+	originRetentionCmd.PersistentFlags().StringVarP(&originIntrospectToken, "token", "t", "",
+		"Path to admin token file (auto-generated from the local issuer key if not provided)")
+	originRetentionCmd.PersistentFlags().BoolVar(&originIntrospectJSON, "json", false,
+		"Emit raw JSON instead of a table")
+
+	originRetentionSetCmd.Flags().StringVar(&originRetentionMode, "mode", "governance",
+		"Retention mode: governance (can be relaxed later) or compliance (can only be lengthened)")
+	originRetentionSetCmd.Flags().DurationVar(&originRetentionPeriod, "period", 0,
+		"How long each object is locked after it is written, such as 8760h for a year")
+	_ = originRetentionSetCmd.MarkFlagRequired("period")
+	originRetentionHoldCmd.Flags().StringVar(&originRetentionReason, "reason", "",
+		"Why the hold was placed, for whoever finds it later")
+}
+
+// retentionSettings mirrors the API's representation of one path's settings.
+type retentionSettings struct {
+	Path string `json:"path"`
+	Rule *struct {
+		Mode   string    `json:"mode"`
+		Period string    `json:"period"`
+		Set    time.Time `json:"set"`
+	} `json:"rule"`
+	Hold *struct {
+		Reason string    `json:"reason"`
+		Set    time.Time `json:"set"`
+	} `json:"hold"`
+}
+
+type retentionStatusResult struct {
+	Backend  string              `json:"backend"`
+	Path     string              `json:"path"`
+	Covering []retentionSettings `json:"covering"`
+	Below    []retentionSettings `json:"below"`
+	Lock     *struct {
+		Locked      bool       `json:"locked"`
+		Mode        string     `json:"mode"`
+		RetainUntil *time.Time `json:"retainUntil"`
+		RulePath    string     `json:"rulePath"`
+		HoldPath    string     `json:"holdPath"`
+		HoldReason  string     `json:"holdReason"`
+	} `json:"lock"`
+}
+
+// retentionRequest runs one request, treating --json output as done.
+func retentionRequest(cmd *cobra.Command, method, endpoint string, body, out any) (bool, error) {
+	err := introspectRequest(cmd, method, endpoint, nil, body, out)
+	if errors.Is(err, errSuppressOutput) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func runRetentionShow(cmd *cobra.Command, args []string) error {
+	var result retentionStatusResult
+	ok, err := retentionRequest(cmd, http.MethodGet, "/retention"+introspectEscapePath(introspectPathArg(args)), nil, &result)
+	if !ok {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	if l := result.Lock; l != nil {
+		switch {
+		case !l.Locked:
+			fmt.Fprintf(w, "%s is not locked\n", result.Path)
+		case l.HoldPath != "":
+			fmt.Fprintf(w, "%s is under the legal hold on %s\n", result.Path, l.HoldPath)
+		default:
+			fmt.Fprintf(w, "%s is retained in %s mode until %s by the rule on %s\n",
+				result.Path, l.Mode, l.RetainUntil.Format(time.RFC3339), l.RulePath)
+		}
+		fmt.Fprintln(w)
+	}
+	if len(result.Covering)+len(result.Below) == 0 {
+		fmt.Fprintf(w, "No retention rules or legal holds at, above, or below %s\n", result.Path)
+		return nil
+	}
+	fmt.Fprintln(w, "PATH\tRULE\tHOLD")
+	for _, rs := range append(result.Covering, result.Below...) {
+		rule, hold := "-", "-"
+		if rs.Rule != nil {
+			rule = fmt.Sprintf("%s %s", rs.Rule.Mode, rs.Rule.Period)
+		}
+		if rs.Hold != nil {
+			hold = "held since " + rs.Hold.Set.Format(time.RFC3339)
+			if rs.Hold.Reason != "" {
+				hold += ": " + rs.Hold.Reason
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", rs.Path, rule, hold)
+	}
+	return nil
+}
+
+func runRetentionSet(cmd *cobra.Command, args []string) error {
+	body := map[string]string{"mode": originRetentionMode, "period": originRetentionPeriod.String()}
+	var rs retentionSettings
+	ok, err := retentionRequest(cmd, http.MethodPut, "/retention"+introspectEscapePath(introspectPathArg(args)), body, &rs)
+	if !ok {
+		return err
+	}
+	fmt.Printf("Objects under %s are now retained in %s mode for %s after they are written\n",
+		rs.Path, rs.Rule.Mode, rs.Rule.Period)
+	return nil
+}
+
+func runRetentionClear(cmd *cobra.Command, args []string) error {
+	path := introspectPathArg(args)
+	ok, err := retentionRequest(cmd, http.MethodDelete, "/retention"+introspectEscapePath(path), nil, &struct{}{})
+	if !ok {
+		return err
+	}
+	fmt.Printf("Removed the retention rule on %s\n", path)
+	return nil
+}
+
+func runRetentionHold(cmd *cobra.Command, args []string) error {
+	body := map[string]string{"reason": originRetentionReason}
+	var rs retentionSettings
+	ok, err := retentionRequest(cmd, http.MethodPut, "/hold"+introspectEscapePath(introspectPathArg(args)), body, &rs)
+	if !ok {
+		return err
+	}
+	fmt.Printf("Placed a legal hold on %s\n", rs.Path)
+	return nil
+}
+
+func runRetentionRelease(cmd *cobra.Command, args []string) error {
+	path := introspectPathArg(args)
+	ok, err := retentionRequest(cmd, http.MethodDelete, "/hold"+introspectEscapePath(path), nil, &struct{}{})
+	if !ok {
+		return err
+	}
+	fmt.Printf("Released the legal hold on %s\n", path)
+	return nil
+}
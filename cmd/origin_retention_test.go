@@ -0,0 +1,66 @@
+//go:build server
+
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRetentionSetSendsRule confirms that `origin retention set` PUTs the
+// rule as JSON to the escaped store path, with the period in the Go duration
+// form the origin parses.
+func TestRetentionSetSendsRule(t *testing.T) {
+	setupIntrospectTest(t)
+	origMode, origPeriod := originRetentionMode, originRetentionPeriod
+	t.Cleanup(func() { originRetentionMode, originRetentionPeriod = origMode, origPeriod })
+
+	var method, path string
+	var body map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.EscapedPath()
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"path":"/raw/run 1","rule":{"mode":"compliance","period":"8760h0m0s"}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	tokenFile := filepath.Join(t.TempDir(), "admin-token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("tok"), 0600))
+	originIntrospectServer = srv.URL
+	originIntrospectToken = tokenFile
+	originRetentionMode = "compliance"
+	originRetentionPeriod = 365 * 24 * time.Hour
+
+	require.NoError(t, runRetentionSet(introspectTestCmd(), []string{"raw/run 1"}))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/api/v1.0/origin/storage/pstore/retention/raw/run%201", path)
+	assert.Equal(t, map[string]string{"mode": "compliance", "period": "8760h0m0s"}, body)
+}
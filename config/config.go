@@ -2002,6 +2002,13 @@ func SetServerDefaults(v *viper.Viper) error {
 		v.Set(param.Director_AdaptiveSortTruncateConstant.GetName(), 6)
 	}
 
+	// Same restriction as above
+	if consistentHashWorkingSet := v.GetInt(param.Director_ConsistentHashWorkingSetSize.GetName()); consistentHashWorkingSet < 3 {
+		log.Warningf("Invalid value of '%d' for config param %s; must be greater than or equal to 3. Resetting to default of %d",
+			consistentHashWorkingSet, param.Director_ConsistentHashWorkingSetSize.GetName(), 6)
+		v.Set(param.Director_ConsistentHashWorkingSetSize.GetName(), 6)
+	}
+
 	return err
 }
 
@@ -2494,15 +2501,22 @@ func InitServer(ctx context.Context, currentServers server_structs.ServerType) e
 		}
 
 		switch s := (server_structs.SortType)(param.Director_CacheSortMethod.GetString()); s {
-		case server_structs.DistanceType, server_structs.DistanceAndLoadType, server_structs.RandomType, server_structs.AdaptiveType:
+		case server_structs.DistanceType, server_structs.DistanceAndLoadType, server_structs.RandomType, server_structs.AdaptiveType, server_structs.ConsistentHashType:
 			break
 		case server_structs.SortType(""):
 			if err := param.Director_CacheSortMethod.Set(string(server_structs.DistanceType)); err != nil {
 				return err
 			}
 		default:
-			return errors.Errorf("invalid %s. Must be one of %q, %q, %q, or %q, but you configured %q.",
-				param.Director_CacheSortMethod.GetName(), server_structs.DistanceType, server_structs.DistanceAndLoadType, server_structs.RandomType, server_structs.AdaptiveType, s)
+			return errors.Errorf("invalid %s. Must be one of %q, %q, %q, %q, or %q, but you configured %q.",
+				param.Director_CacheSortMethod.GetName(), server_structs.DistanceType, server_structs.DistanceAndLoadType, server_structs.RandomType, server_structs.AdaptiveType, server_structs.ConsistentHashType, s)
+		}
+
+		switch k := param.Director_ConsistentHashKey.GetString(); k {
+		case "object", "namespace":
+		default:
+			return errors.Errorf("invalid %s. Must be one of %q or %q, but you configured %q.",
+				param.Director_ConsistentHashKey.GetName(), "object", "namespace", k)
 		}
 	}
 
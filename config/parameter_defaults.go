@@ -39,6 +39,14 @@ var (
 // Server.Hostname, RuntimeDir) must already be set in viper via SetDefault
 // before calling this function, as dependent params read them inline.
 func SetParameterDefaults(v *viper.Viper, isRoot bool, isOSDF bool) {
+	// Cache.AdmissionFilterCapacity
+	v.SetDefault(param.Cache_AdmissionFilterCapacity.GetName(), 100000)
+	// Cache.AdmissionMinAccesses
+	v.SetDefault(param.Cache_AdmissionMinAccesses.GetName(), 2)
+	// Cache.BlockCompressionMinSavings
+	v.SetDefault(param.Cache_BlockCompressionMinSavings.GetName(), 10)
+	// Cache.BlockDedup
+	v.SetDefault(param.Cache_BlockDedup.GetName(), false)
 	// Cache.BlocksToPrefetch
 	v.SetDefault(param.Cache_BlocksToPrefetch.GetName(), 0)
 	// Cache.ConcurrencyDegradedThreshold
@@ -53,6 +61,8 @@ func SetParameterDefaults(v *viper.Viper, isRoot bool, isOSDF bool) {
 	v.SetDefault(param.Cache_DirectorTest.GetName(), true)
 	// Cache.DisableClientX509
 	v.SetDefault(param.Cache_DisableClientX509.GetName(), true)
+	// Cache.EnableAdmissionFilter
+	v.SetDefault(param.Cache_EnableAdmissionFilter.GetName(), false)
 	// Cache.EnableBroker
 	v.SetDefault(param.Cache_EnableBroker.GetName(), true)
 	// Cache.EnableChaosAPI
@@ -73,10 +83,16 @@ func SetParameterDefaults(v *viper.Viper, isRoot bool, isOSDF bool) {
 	v.SetDefault(param.Cache_EnableV2.GetName(), false)
 	// Cache.EnableVoms
 	v.SetDefault(param.Cache_EnableVoms.GetName(), false)
+	// Cache.EnableWarmupExport
+	v.SetDefault(param.Cache_EnableWarmupExport.GetName(), false)
+	// Cache.EvictionFrequencyHalfLife
+	v.SetDefault(param.Cache_EvictionFrequencyHalfLife.GetName(), "24h")
 	// Cache.EvictionMonitoringInterval
 	v.SetDefault(param.Cache_EvictionMonitoringInterval.GetName(), "60s")
 	// Cache.EvictionMonitoringMaxDepth
 	v.SetDefault(param.Cache_EvictionMonitoringMaxDepth.GetName(), 1)
+	// Cache.EvictionPolicy
+	v.SetDefault(param.Cache_EvictionPolicy.GetName(), "lru")
 	// Cache.ExportLocation
 	v.SetDefault(param.Cache_ExportLocation.GetName(), "/")
 	// Cache.FedTokenLocation
@@ -89,12 +105,20 @@ func SetParameterDefaults(v *viper.Viper, isRoot bool, isOSDF bool) {
 	v.SetDefault(param.Cache_HighWaterMark.GetName(), 89)
 	// Cache.LowWaterMark
 	v.SetDefault(param.Cache_LowWaterMark.GetName(), 85)
+	// Cache.MaxObjectSize
+	v.SetDefault(param.Cache_MaxObjectSize.GetName(), "0")
+	// Cache.MemoryCacheMinAccesses
+	v.SetDefault(param.Cache_MemoryCacheMinAccesses.GetName(), 1)
 	// Cache.MemoryCacheSize
 	v.SetDefault(param.Cache_MemoryCacheSize.GetName(), "0")
 	// Cache.MinDirectorRefreshInterval
 	v.SetDefault(param.Cache_MinDirectorRefreshInterval.GetName(), "15s")
+	// Cache.PeerQueryTimeout
+	v.SetDefault(param.Cache_PeerQueryTimeout.GetName(), "2s")
 	// Cache.Port
 	v.SetDefault(param.Cache_Port.GetName(), 8442)
+	// Cache.ReadAheadSize
+	v.SetDefault(param.Cache_ReadAheadSize.GetName(), "0")
 	// Cache.RunLocation
 	if isRoot {
 		v.SetDefault(param.Cache_RunLocation.GetName(), "/run/pelican/xrootd/cache")
@@ -170,6 +194,14 @@ func SetParameterDefaults(v *viper.Viper, isRoot bool, isOSDF bool) {
 		val = strings.ReplaceAll(val, "${Cache.Port}", v.GetString(param.Cache_Port.GetName()))
 		v.SetDefault(param.Cache_Url.GetName(), val)
 	}
+	// Cache.WarmupConcurrency
+	v.SetDefault(param.Cache_WarmupConcurrency.GetName(), 4)
+	// Cache.WarmupMaxObjects
+	v.SetDefault(param.Cache_WarmupMaxObjects.GetName(), 10000)
+	// Cache.WarmupOnStart
+	v.SetDefault(param.Cache_WarmupOnStart.GetName(), false)
+	// Cache.WarmupRate
+	v.SetDefault(param.Cache_WarmupRate.GetName(), "50MB")
 	// Cache.WorkerCount
 	v.SetDefault(param.Cache_WorkerCount.GetName(), 100)
 	// Cache.XRootDPrefix
@@ -184,6 +216,8 @@ func SetParameterDefaults(v *viper.Viper, isRoot bool, isOSDF bool) {
 	v.SetDefault(param.Client_DisableProxyFallback.GetName(), false)
 	// Client.EnableOverwrites
 	v.SetDefault(param.Client_EnableOverwrites.GetName(), false)
+	// Client.EnableTransferFeedback
+	v.SetDefault(param.Client_EnableTransferFeedback.GetName(), false)
 	// Client.IsPlugin
 	v.SetDefault(param.Client_IsPlugin.GetName(), false)
 	// Client.MaximumDownloadSpeed
@@ -206,18 +240,30 @@ func SetParameterDefaults(v *viper.Viper, isRoot bool, isOSDF bool) {
 	v.SetDefault(param.ClientAgent_MaxConcurrentJobs.GetName(), 5)
 	// ClientAgent.ProgressUpdateInterval
 	v.SetDefault(param.ClientAgent_ProgressUpdateInterval.GetName(), "5s")
+	// Director.ASNLocalityFactor
+	v.SetDefault(param.Director_ASNLocalityFactor.GetName(), 10)
+	// Director.AdPersistenceInterval
+	v.SetDefault(param.Director_AdPersistenceInterval.GetName(), "1m")
 	// Director.AdaptiveSortEWMATimeConstant
 	v.SetDefault(param.Director_AdaptiveSortEWMATimeConstant.GetName(), "5m")
 	// Director.AdaptiveSortTruncateConstant
 	v.SetDefault(param.Director_AdaptiveSortTruncateConstant.GetName(), 6)
+	// Director.AdaptiveSortUseCacheProbes
+	v.SetDefault(param.Director_AdaptiveSortUseCacheProbes.GetName(), false)
 	// Director.AdvertisementTTL
 	v.SetDefault(param.Director_AdvertisementTTL.GetName(), "15m")
 	// Director.AssumePresenceAtSingleOrigin
 	v.SetDefault(param.Director_AssumePresenceAtSingleOrigin.GetName(), true)
 	// Director.CachePresenceCapacity
 	v.SetDefault(param.Director_CachePresenceCapacity.GetName(), 2000)
+	// Director.CachePresenceNegativeTTL
+	v.SetDefault(param.Director_CachePresenceNegativeTTL.GetName(), "1m")
 	// Director.CachePresenceTTL
 	v.SetDefault(param.Director_CachePresenceTTL.GetName(), "1m")
+	// Director.CacheProbeInterval
+	v.SetDefault(param.Director_CacheProbeInterval.GetName(), "1m")
+	// Director.CacheProbeTimeout
+	v.SetDefault(param.Director_CacheProbeTimeout.GetName(), "10s")
 	// Director.CacheSortMethod
 	v.SetDefault(param.Director_CacheSortMethod.GetName(), "distance")
 	// Director.CachesPullFromCaches
@@ -226,18 +272,40 @@ func SetParameterDefaults(v *viper.Viper, isRoot bool, isOSDF bool) {
 	v.SetDefault(param.Director_CheckCachePresence.GetName(), true)
 	// Director.CheckOriginPresence
 	v.SetDefault(param.Director_CheckOriginPresence.GetName(), true)
+	// Director.ConsistentHashKey
+	v.SetDefault(param.Director_ConsistentHashKey.GetName(), "object")
+	// Director.ConsistentHashWorkingSetSize
+	v.SetDefault(param.Director_ConsistentHashWorkingSetSize.GetName(), 6)
 	// Director.DefaultResponse
 	v.SetDefault(param.Director_DefaultResponse.GetName(), "cache")
+	// Director.EnableASNLocality
+	v.SetDefault(param.Director_EnableASNLocality.GetName(), false)
+	// Director.EnableAdPersistence
+	v.SetDefault(param.Director_EnableAdPersistence.GetName(), false)
 	// Director.EnableBroker
 	v.SetDefault(param.Director_EnableBroker.GetName(), true)
+	// Director.EnableCacheProbes
+	v.SetDefault(param.Director_EnableCacheProbes.GetName(), false)
 	// Director.EnableFederationMetadataHosting
 	v.SetDefault(param.Director_EnableFederationMetadataHosting.GetName(), true)
 	// Director.EnableOIDC
 	v.SetDefault(param.Director_EnableOIDC.GetName(), false)
+	// Director.EnableTransferFeedback
+	v.SetDefault(param.Director_EnableTransferFeedback.GetName(), false)
 	// Director.FedTokenLifetime
 	v.SetDefault(param.Director_FedTokenLifetime.GetName(), "15m")
 	// Director.FilterCachesInErrorState
 	v.SetDefault(param.Director_FilterCachesInErrorState.GetName(), true)
+	// Director.GeoIPASNLocation
+	if isRoot {
+		v.SetDefault(param.Director_GeoIPASNLocation.GetName(), "/var/cache/pelican/maxmind/GeoLite2-ASN.mmdb")
+	} else {
+		{
+			val := "${ConfigBase}/maxmind/GeoLite2-ASN.mmdb"
+			val = strings.ReplaceAll(val, "${ConfigBase}", v.GetString(param.ConfigBase.GetName()))
+			v.SetDefault(param.Director_GeoIPASNLocation.GetName(), val)
+		}
+	}
 	// Director.GeoIPLocation
 	if isRoot {
 		v.SetDefault(param.Director_GeoIPLocation.GetName(), "/var/cache/pelican/maxmind/GeoLite2-City.mmdb")
@@ -258,10 +326,18 @@ func SetParameterDefaults(v *viper.Viper, isRoot bool, isOSDF bool) {
 	v.SetDefault(param.Director_OriginCacheHealthTestInterval.GetName(), "15s")
 	// Director.RegistryQueryInterval
 	v.SetDefault(param.Director_RegistryQueryInterval.GetName(), "1m")
+	// Director.SiteLocalityFactor
+	v.SetDefault(param.Director_SiteLocalityFactor.GetName(), 100)
 	// Director.StatConcurrencyLimit
 	v.SetDefault(param.Director_StatConcurrencyLimit.GetName(), 100)
 	// Director.StatTimeout
 	v.SetDefault(param.Director_StatTimeout.GetName(), "2000ms")
+	// Director.TransferFeedbackErrorThreshold
+	v.SetDefault(param.Director_TransferFeedbackErrorThreshold.GetName(), 50)
+	// Director.TransferFeedbackMinAttempts
+	v.SetDefault(param.Director_TransferFeedbackMinAttempts.GetName(), 10)
+	// Director.TransferFeedbackWindow
+	v.SetDefault(param.Director_TransferFeedbackWindow.GetName(), "15m")
 	// Federation.DiscoveryUrl
 	if isOSDF {
 		v.SetDefault(param.Federation_DiscoveryUrl.GetName(), "https://osg-htc.org")
@@ -612,18 +688,38 @@ func SetParameterDefaults(v *viper.Viper, isRoot bool, isOSDF bool) {
 	v.SetDefault(param.Origin_MultiuserUmask.GetName(), -1)
 	// Origin.MultiuserVarlinkSocketPath
 	v.SetDefault(param.Origin_MultiuserVarlinkSocketPath.GetName(), "/run/systemd/userdb/io.systemd.UserDatabase")
+	// Origin.PStoreBlockDedup
+	v.SetDefault(param.Origin_PStoreBlockDedup.GetName(), false)
+	// Origin.PStoreDataBackupFullEvery
+	v.SetDefault(param.Origin_PStoreDataBackupFullEvery.GetName(), 1)
+	// Origin.PStoreDataBackupInterval
+	v.SetDefault(param.Origin_PStoreDataBackupInterval.GetName(), "24h")
+	// Origin.PStoreDataBackupS3UrlStyle
+	v.SetDefault(param.Origin_PStoreDataBackupS3UrlStyle.GetName(), "path")
+	// Origin.PStoreDataBackupsToKeep
+	v.SetDefault(param.Origin_PStoreDataBackupsToKeep.GetName(), 7)
 	// Origin.PStoreDataScanInterval
 	v.SetDefault(param.Origin_PStoreDataScanInterval.GetName(), "24h")
 	// Origin.PStoreDataScanRate
 	v.SetDefault(param.Origin_PStoreDataScanRate.GetName(), "100MB/s")
+	// Origin.PStoreErasureDataShards
+	v.SetDefault(param.Origin_PStoreErasureDataShards.GetName(), 0)
+	// Origin.PStoreErasureParityShards
+	v.SetDefault(param.Origin_PStoreErasureParityShards.GetName(), 2)
 	// Origin.PStoreIndexCheckInterval
 	v.SetDefault(param.Origin_PStoreIndexCheckInterval.GetName(), "1h")
 	// Origin.PStoreInlineMaxBytes
 	v.SetDefault(param.Origin_PStoreInlineMaxBytes.GetName(), 0)
+	// Origin.PStoreMetadataBackupFullEvery
+	v.SetDefault(param.Origin_PStoreMetadataBackupFullEvery.GetName(), 1)
 	// Origin.PStoreMetadataBackupInterval
 	v.SetDefault(param.Origin_PStoreMetadataBackupInterval.GetName(), "6h")
 	// Origin.PStoreMetadataBackupsToKeep
 	v.SetDefault(param.Origin_PStoreMetadataBackupsToKeep.GetName(), 24)
+	// Origin.PStoreReplicationSyncTimeout
+	v.SetDefault(param.Origin_PStoreReplicationSyncTimeout.GetName(), "10s")
+	// Origin.PStoreReplicationSynchronous
+	v.SetDefault(param.Origin_PStoreReplicationSynchronous.GetName(), false)
 	// Origin.Port
 	v.SetDefault(param.Origin_Port.GetName(), 8443)
 	// Origin.RunLocation
@@ -1047,6 +1143,18 @@ func ApplyDerivedDefaults(v *viper.Viper, isRoot bool, isOSDF bool) {
 			v.SetDefault(param.Cache_Url.GetName(), val)
 		}
 	}
+	// Director.GeoIPASNLocation
+	if isDefaultSource(param.Director_GeoIPASNLocation.GetName()) {
+		if isRoot {
+			v.SetDefault(param.Director_GeoIPASNLocation.GetName(), "/var/cache/pelican/maxmind/GeoLite2-ASN.mmdb")
+		} else {
+			{
+				val := "${ConfigBase}/maxmind/GeoLite2-ASN.mmdb"
+				val = strings.ReplaceAll(val, "${ConfigBase}", v.GetString(param.ConfigBase.GetName()))
+				v.SetDefault(param.Director_GeoIPASNLocation.GetName(), val)
+			}
+		}
+	}
 	// Director.GeoIPLocation
 	if isDefaultSource(param.Director_GeoIPLocation.GetName()) {
 		if isRoot {
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS director_server_ads (
+    url TEXT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    server_type TEXT NOT NULL,
+    advertisement BLOB NOT NULL,
+    health_status TEXT NOT NULL DEFAULT '',
+    expires_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS director_server_filters (
+    server_name TEXT PRIMARY KEY,
+    filter_type TEXT NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS director_server_filters;
+DROP TABLE IF EXISTS director_server_ads;
+-- +goose StatementEnd
@@ -0,0 +1,109 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type (
+	// A server (origin/cache) advertisement persisted by the director so that it
+	// can warm-start its ad cache after a restart.  The advertisement itself is
+	// stored as opaque JSON owned by the director package.
+	DirectorServerAd struct {
+		URL           string    `gorm:"primaryKey;column:url"`
+		Name          string    `gorm:"column:name"`
+		ServerType    string    `gorm:"column:server_type"`
+		Advertisement []byte    `gorm:"column:advertisement"`
+		HealthStatus  string    `gorm:"column:health_status"`
+		ExpiresAt     time.Time `gorm:"column:expires_at"`
+		UpdatedAt     time.Time `gorm:"column:updated_at"`
+	}
+
+	// A server filter (downtime) entry persisted by the director
+	DirectorServerFilter struct {
+		ServerName string    `gorm:"primaryKey;column:server_name"`
+		FilterType string    `gorm:"column:filter_type"`
+		UpdatedAt  time.Time `gorm:"column:updated_at"`
+	}
+)
+
+func (DirectorServerAd) TableName() string {
+	return "director_server_ads"
+}
+
+func (DirectorServerFilter) TableName() string {
+	return "director_server_filters"
+}
+
+// SaveDirectorState replaces the persisted director state with the given
+// server ads and filters in a single transaction, so a crash mid-save never
+// leaves a partial snapshot behind.
+func SaveDirectorState(ctx context.Context, db *gorm.DB, ads []DirectorServerAd, filters []DirectorServerFilter) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`DELETE FROM director_server_ads`).Error; err != nil {
+			return errors.Wrap(err, "failed to clear persisted server ads")
+		}
+		if err := tx.Exec(`DELETE FROM director_server_filters`).Error; err != nil {
+			return errors.Wrap(err, "failed to clear persisted server filters")
+		}
+		if len(ads) > 0 {
+			if err := tx.CreateInBatches(ads, 100).Error; err != nil {
+				return errors.Wrap(err, "failed to persist server ads")
+			}
+		}
+		if len(filters) > 0 {
+			if err := tx.CreateInBatches(filters, 100).Error; err != nil {
+				return errors.Wrap(err, "failed to persist server filters")
+			}
+		}
+		return nil
+	})
+}
+
+// LoadDirectorServerAds returns the persisted server ads that have not yet
+// expired as of `now`.
+func LoadDirectorServerAds(ctx context.Context, db *gorm.DB, now time.Time) ([]DirectorServerAd, error) {
+	rows := []DirectorServerAd{}
+	if err := db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to load persisted server ads")
+	}
+	// Filter here rather than in SQL; SQLite compares the stored timestamps as
+	// strings, which is only correct when every row uses the same time zone.
+	ads := make([]DirectorServerAd, 0, len(rows))
+	for _, row := range rows {
+		if row.ExpiresAt.After(now) {
+			ads = append(ads, row)
+		}
+	}
+	return ads, nil
+}
+
+// LoadDirectorServerFilters returns all persisted server filters
+func LoadDirectorServerFilters(ctx context.Context, db *gorm.DB) ([]DirectorServerFilter, error) {
+	filters := []DirectorServerFilter{}
+	if err := db.WithContext(ctx).Find(&filters).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to load persisted server filters")
+	}
+	return filters, nil
+}
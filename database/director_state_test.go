@@ -0,0 +1,97 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/pelicanplatform/pelican/database/utils"
+)
+
+// setupDirectorStateDB creates a temporary SQLite database with the
+// director-specific tables for testing.
+func setupDirectorStateDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "test-director-state.sqlite")
+	db, err := utils.InitSQLiteDB(dbPath)
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, utils.MigrateServerSpecificDB(sqlDB, EmbedDirectorMigrations, "director_migrations", "director"))
+	return db
+}
+
+func TestDirectorState(t *testing.T) {
+	db := setupDirectorStateDB(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	// Nothing persisted yet
+	ads, err := LoadDirectorServerAds(ctx, db, now)
+	require.NoError(t, err)
+	assert.Empty(t, ads)
+	filters, err := LoadDirectorServerFilters(ctx, db)
+	require.NoError(t, err)
+	assert.Empty(t, filters)
+
+	require.NoError(t, SaveDirectorState(ctx, db,
+		[]DirectorServerAd{
+			{URL: "https://origin.example.com", Name: "origin", ServerType: "Origin", Advertisement: []byte(`{"name":"origin"}`), HealthStatus: "OK", ExpiresAt: now.Add(time.Hour)},
+			{URL: "https://expired.example.com", Name: "expired", ServerType: "Cache", Advertisement: []byte(`{}`), ExpiresAt: now.Add(-time.Minute)},
+		},
+		[]DirectorServerFilter{{ServerName: "origin", FilterType: "tempFiltered"}},
+	))
+
+	ads, err = LoadDirectorServerAds(ctx, db, now)
+	require.NoError(t, err)
+	require.Len(t, ads, 1)
+	assert.Equal(t, "https://origin.example.com", ads[0].URL)
+	assert.Equal(t, "origin", ads[0].Name)
+	assert.Equal(t, "Origin", ads[0].ServerType)
+	assert.Equal(t, `{"name":"origin"}`, string(ads[0].Advertisement))
+	assert.Equal(t, "OK", ads[0].HealthStatus)
+	assert.WithinDuration(t, now.Add(time.Hour), ads[0].ExpiresAt, time.Second)
+
+	filters, err = LoadDirectorServerFilters(ctx, db)
+	require.NoError(t, err)
+	require.Len(t, filters, 1)
+	assert.Equal(t, "origin", filters[0].ServerName)
+	assert.Equal(t, "tempFiltered", filters[0].FilterType)
+
+	// Saving again replaces the previous snapshot entirely
+	require.NoError(t, SaveDirectorState(ctx, db,
+		[]DirectorServerAd{{URL: "https://cache.example.com", Name: "cache", ServerType: "Cache", Advertisement: []byte(`{}`), ExpiresAt: now.Add(time.Hour)}},
+		nil,
+	))
+	ads, err = LoadDirectorServerAds(ctx, db, now)
+	require.NoError(t, err)
+	require.Len(t, ads, 1)
+	assert.Equal(t, "cache", ads[0].Name)
+	filters, err = LoadDirectorServerFilters(ctx, db)
+	require.NoError(t, err)
+	assert.Empty(t, filters)
+}
@@ -41,6 +41,9 @@ var EmbedRegistryMigrations embed.FS
 //go:embed origin_migrations/*.sql
 var EmbedOriginMigrations embed.FS
 
+//go:embed director_migrations/*.sql
+var EmbedDirectorMigrations embed.FS
+
 type Counter struct {
 	Key   string `gorm:"primaryKey"`
 	Value int    `gorm:"not null;default:0"`
@@ -163,6 +166,8 @@ func runServerTypeMigrations(sqlDB *sql.DB, serverType server_structs.ServerType
 		// provisions them; the handful of origin-specific tables it also creates
 		// are unused but harmless.
 		return utils.MigrateServerSpecificDB(sqlDB, EmbedOriginMigrations, "origin_migrations", "origin")
+	case server_structs.DirectorType:
+		return utils.MigrateServerSpecificDB(sqlDB, EmbedDirectorMigrations, "director_migrations", "director")
 	default:
 		log.Debugf("No specific migrations for server type: %s", serverType.String())
 	}
@@ -0,0 +1,219 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+
+	"github.com/pelicanplatform/pelican/database"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// The on-disk form of a server advertisement
+type persistedAd struct {
+	ServerAd     server_structs.ServerAd      `json:"serverAd"`
+	NamespaceAds []server_structs.NamespaceAd `json:"namespaceAds"`
+}
+
+// Write the current server ads, server filters and health test status to the database,
+// replacing whatever was persisted before.
+//
+// Topology ads are not persisted as the director reloads them from topology at startup.
+// Filters from Director.FilteredServers are not persisted as they come from the configuration.
+func persistAdState(ctx context.Context, db *gorm.DB) error {
+	healthStatuses := map[string]HealthTestStatus{}
+	func() {
+		healthTestUtilsMutex.RLock()
+		defer healthTestUtilsMutex.RUnlock()
+		for url, util := range healthTestUtils {
+			if util != nil {
+				healthStatuses[url] = util.Status
+			}
+		}
+	}()
+
+	items := serverAds.Items()
+	rows := make([]database.DirectorServerAd, 0, len(items))
+	for key, item := range items {
+		ad := item.Value()
+		if ad == nil {
+			continue
+		}
+		row, err := func() (database.DirectorServerAd, error) {
+			ad.RLock()
+			defer ad.RUnlock()
+			if ad.FromTopology {
+				return database.DirectorServerAd{}, nil
+			}
+			adBytes, err := json.Marshal(persistedAd{ServerAd: ad.ServerAd, NamespaceAds: ad.NamespaceAds})
+			if err != nil {
+				return database.DirectorServerAd{}, err
+			}
+			return database.DirectorServerAd{
+				URL:           key,
+				Name:          ad.Name,
+				ServerType:    ad.Type,
+				Advertisement: adBytes,
+				HealthStatus:  string(healthStatuses[key]),
+				ExpiresAt:     item.ExpiresAt(),
+			}, nil
+		}()
+		if err != nil {
+			log.Warningf("Failed to serialize the ad for server %s; it will not be persisted: %v", key, err)
+			continue
+		}
+		if row.URL == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	filters := []database.DirectorServerFilter{}
+	func() {
+		filteredServersMutex.RLock()
+		defer filteredServersMutex.RUnlock()
+		for name, ft := range filteredServers {
+			if ft == permFiltered {
+				continue
+			}
+			filters = append(filters, database.DirectorServerFilter{ServerName: name, FilterType: string(ft)})
+		}
+	}()
+
+	if err := database.SaveDirectorState(ctx, db, rows, filters); err != nil {
+		return err
+	}
+	log.Debugf("Persisted %d server ads and %d server filters to the director database", len(rows), len(filters))
+	return nil
+}
+
+// Load the persisted server ads, server filters and health test status from the database
+// into the director's in-memory state.
+//
+// Restored ads keep their original expiration and are flagged as restored; they are replaced
+// as soon as the server re-advertises and expire normally if it does not.  Nothing restored
+// overrides state the director has already learned since it started.
+func restoreAdState(ctx context.Context, db *gorm.DB) error {
+	filters, err := database.LoadDirectorServerFilters(ctx, db)
+	if err != nil {
+		return err
+	}
+	func() {
+		filteredServersMutex.Lock()
+		defer filteredServersMutex.Unlock()
+		for _, filter := range filters {
+			ft := filterType(filter.FilterType)
+			if ft == permFiltered {
+				continue
+			}
+			if _, exists := filteredServers[filter.ServerName]; !exists {
+				filteredServers[filter.ServerName] = ft
+			}
+		}
+	}()
+
+	rows, err := database.LoadDirectorServerAds(ctx, db, time.Now())
+	if err != nil {
+		return err
+	}
+	restored := 0
+	for _, row := range rows {
+		pAd := persistedAd{}
+		if err := json.Unmarshal(row.Advertisement, &pAd); err != nil {
+			log.Warningf("Failed to parse the persisted ad for server %s; skipping it: %v", row.URL, err)
+			continue
+		}
+		if lookupServerAd(pAd.ServerAd.URL.String()) != nil {
+			continue
+		}
+		sAd := pAd.ServerAd
+		sAd.Restored = true
+		sAd.Expiration = row.ExpiresAt
+		func() {
+			filteredServersMutex.Lock()
+			defer filteredServersMutex.Unlock()
+			if len(sAd.Downtimes) > 0 {
+				serverDowntimes[sAd.Name] = sAd.Downtimes
+			}
+		}()
+		recordAd(ctx, sAd, &pAd.NamespaceAds)
+
+		if row.HealthStatus != "" {
+			func() {
+				healthTestUtilsMutex.Lock()
+				defer healthTestUtilsMutex.Unlock()
+				// Only carry over the last result if no test has completed since the restore
+				if util, ok := healthTestUtils[row.URL]; ok && util != nil && util.Status == HealthStatusInit {
+					util.Status = HealthTestStatus(row.HealthStatus)
+				}
+			}()
+		}
+		restored++
+	}
+	log.Infof("Restored %d server ads and %d server filters from the director database", restored, len(filters))
+	return nil
+}
+
+// Restore the director's advertisement state from the server database and launch a
+// goroutine to periodically persist it, with a final write when the director shuts down.
+func LaunchAdPersistence(ctx context.Context, egrp *errgroup.Group) {
+	if !param.Director_EnableAdPersistence.GetBool() {
+		return
+	}
+	db := database.ServerDatabase
+	if db == nil {
+		log.Warningln("The server database is not initialized; director advertisements will not be persisted")
+		return
+	}
+
+	if err := restoreAdState(ctx, db); err != nil {
+		log.Errorln("Failed to restore director advertisements from the database:", err)
+	}
+
+	interval := param.Director_AdPersistenceInterval.GetDuration()
+	if interval <= 0 {
+		log.Warningf("Invalid %s value %s; using 1m instead", param.Director_AdPersistenceInterval.GetName(), interval.String())
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	egrp.Go(func() error {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := persistAdState(ctx, db); err != nil {
+					log.Errorln("Failed to persist director advertisements to the database:", err)
+				}
+			case <-ctx.Done():
+				// The context is already cancelled; use a fresh one for the final write
+				if err := persistAdState(context.Background(), db); err != nil {
+					log.Errorln("Failed to persist director advertisements at shutdown:", err)
+				}
+				return nil
+			}
+		}
+	})
+}
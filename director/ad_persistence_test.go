@@ -0,0 +1,165 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/pelicanplatform/pelican/database"
+	dbutils "github.com/pelicanplatform/pelican/database/utils"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+func setupAdPersistenceDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := dbutils.InitSQLiteDB(filepath.Join(t.TempDir(), "director.sqlite"))
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, dbutils.MigrateServerSpecificDB(sqlDB, database.EmbedDirectorMigrations, "director_migrations", "director"))
+	return db
+}
+
+func TestAdPersistenceRoundTrip(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	db := setupAdPersistenceDB(t)
+	ctx := context.Background()
+
+	clearFilters := func() {
+		filteredServersMutex.Lock()
+		defer filteredServersMutex.Unlock()
+		delete(filteredServers, "persisted-origin")
+		delete(filteredServers, "config-filtered")
+		delete(serverDowntimes, "persisted-origin")
+	}
+	resetHealthTests()
+	shutdownStatUtils()
+	serverAds.DeleteAll()
+	clearFilters()
+	t.Cleanup(func() {
+		shutdownHealthTests()
+		shutdownStatUtils()
+		serverAds.DeleteAll()
+		clearFilters()
+		server_utils.ResetTestState()
+	})
+
+	originUrl := url.URL{Scheme: "https", Host: "persisted-origin.example.com:8443"}
+	origin := server_structs.ServerAd{
+		URL:    originUrl,
+		WebURL: url.URL{Scheme: "https", Host: "persisted-origin.example.com:8444"},
+		Type:   server_structs.OriginType.String(),
+		Caps:   server_structs.Capabilities{PublicReads: true, Reads: true},
+		Downtimes: []server_structs.Downtime{{
+			UUID:       "downtime-id",
+			ServerName: "persisted-origin",
+			StartTime:  time.Now().Add(time.Hour).UnixMilli(),
+			EndTime:    time.Now().Add(2 * time.Hour).UnixMilli(),
+		}},
+	}
+	origin.Initialize("persisted-origin")
+	nsAds := []server_structs.NamespaceAd{{Path: "/persisted", Caps: server_structs.Capabilities{PublicReads: true}}}
+	recordAd(ctx, origin, &nsAds)
+
+	topoCache := server_structs.ServerAd{
+		URL:          url.URL{Scheme: "http", Host: "topo-cache.example.com:8000"},
+		Type:         server_structs.CacheType.String(),
+		FromTopology: true,
+	}
+	topoCache.Initialize("topo-cache")
+	recordAd(ctx, topoCache, &[]server_structs.NamespaceAd{})
+
+	// The filter keeps the director tests from running (and overwriting the health status) for the origin
+	filteredServersMutex.Lock()
+	filteredServers["persisted-origin"] = tempFiltered
+	filteredServers["config-filtered"] = permFiltered
+	filteredServersMutex.Unlock()
+	healthTestUtilsMutex.Lock()
+	require.Contains(t, healthTestUtils, originUrl.String())
+	healthTestUtils[originUrl.String()].Status = HealthStatusOK
+	healthTestUtilsMutex.Unlock()
+
+	require.NoError(t, persistAdState(ctx, db))
+
+	rows, err := database.LoadDirectorServerAds(ctx, db, time.Now())
+	require.NoError(t, err)
+	require.Len(t, rows, 1, "topology ads should not be persisted")
+	assert.Equal(t, originUrl.String(), rows[0].URL)
+	assert.Equal(t, string(HealthStatusOK), rows[0].HealthStatus)
+	filters, err := database.LoadDirectorServerFilters(ctx, db)
+	require.NoError(t, err)
+	require.Len(t, filters, 1, "filters from the configuration should not be persisted")
+	assert.Equal(t, "persisted-origin", filters[0].ServerName)
+
+	// Simulate a restart
+	resetHealthTests()
+	shutdownStatUtils()
+	serverAds.DeleteAll()
+	clearFilters()
+
+	require.NoError(t, restoreAdState(ctx, db))
+
+	item := serverAds.Get(originUrl.String())
+	require.NotNil(t, item)
+	restored := item.Value()
+	assert.True(t, restored.Restored)
+	assert.Equal(t, origin.InstanceID, restored.InstanceID)
+	assert.Equal(t, origin.GenerationID, restored.GenerationID)
+	assert.Equal(t, origin.WebURL, restored.WebURL)
+	assert.Equal(t, nsAds, restored.NamespaceAds)
+	assert.WithinDuration(t, rows[0].ExpiresAt, item.ExpiresAt(), time.Second)
+	assert.False(t, serverAds.Has(topoCache.URL.String()))
+
+	filtered, ft := checkFilter("persisted-origin")
+	assert.True(t, filtered)
+	assert.Equal(t, tempFiltered, ft)
+	downtimes, err := getCachedDowntimes("persisted-origin")
+	require.NoError(t, err)
+	assert.Len(t, downtimes, 1)
+
+	healthTestUtilsMutex.RLock()
+	require.Contains(t, healthTestUtils, originUrl.String())
+	assert.Equal(t, HealthStatusOK, healthTestUtils[originUrl.String()].Status)
+	healthTestUtilsMutex.RUnlock()
+
+	// Once the server advertises again, the ad is no longer marked as restored
+	origin.Initialize("persisted-origin")
+	recordAd(ctx, origin, &nsAds)
+	item = serverAds.Get(originUrl.String())
+	require.NotNil(t, item)
+	assert.False(t, item.Value().Restored)
+
+	// Restoring never replaces ads that arrived after startup
+	require.NoError(t, restoreAdState(ctx, db))
+	item = serverAds.Get(originUrl.String())
+	require.NotNil(t, item)
+	assert.False(t, item.Value().Restored)
+}
@@ -239,6 +239,30 @@ func populateEWMAStatusWeight(newSAd, oldSAd *server_structs.ServerAd) {
 	).Set(statusWeight)
 }
 
+// Look up the recorded advertisement for a server URL without refreshing its expiration.
+//
+// Since servers from topology always use http, while servers from Pelican always use https
+// we want to ignore the scheme difference when checking duplicates (only consider hostname:port)
+func lookupServerAd(rawURL string) *ttlcache.Item[string, *server_structs.Advertisement] {
+	httpURL := rawURL
+	httpsURL := rawURL
+	if strings.HasPrefix(rawURL, "https") {
+		httpURL = "http" + strings.TrimPrefix(rawURL, "https")
+	}
+	if strings.HasPrefix(rawURL, "http://") {
+		httpsURL = "https://" + strings.TrimPrefix(rawURL, "http://")
+	}
+
+	existing := serverAds.Get(httpURL, ttlcache.WithDisableTouchOnHit[string, *server_structs.Advertisement]())
+	if existing == nil {
+		existing = serverAds.Get(httpsURL, ttlcache.WithDisableTouchOnHit[string, *server_structs.Advertisement]())
+	}
+	if existing == nil {
+		existing = serverAds.Get(rawURL, ttlcache.WithDisableTouchOnHit[string, *server_structs.Advertisement]())
+	}
+	return existing
+}
+
 // recordAd does following for an incoming ServerAd and []NamespaceAd pair:
 //
 //  1. Update the ServerAd by setting server location and updating server topology attribute
@@ -256,27 +280,9 @@ func recordAd(ctx context.Context, sAd server_structs.ServerAd, namespaceAds *[]
 		log.Debugf("Failed to lookup GeoIP coordinates for host %s: %v", sAd.URL.Host, err)
 	}
 
-	// Since servers from topology always use http, while servers from Pelican always use https
-	// we want to ignore the scheme difference when checking duplicates (only consider hostname:port)
-	rawURL := sAd.URL.String() // could be http (topology) or https (Pelican or some topology ones)
-	httpURL := sAd.URL.String()
-	httpsURL := sAd.URL.String()
-	if strings.HasPrefix(rawURL, "https") {
-		httpURL = "http" + strings.TrimPrefix(rawURL, "https")
-	}
-	if strings.HasPrefix(rawURL, "http://") {
-		httpsURL = "https://" + strings.TrimPrefix(rawURL, "http://")
-	}
-
 	// Although we're in `recordAd`, which implies we _DO_ plan to update the incoming
 	// advertisement, it's better not to refresh the expiration until we explicitly intend to do so.
-	existing := serverAds.Get(httpURL, ttlcache.WithDisableTouchOnHit[string, *server_structs.Advertisement]())
-	if existing == nil {
-		existing = serverAds.Get(httpsURL, ttlcache.WithDisableTouchOnHit[string, *server_structs.Advertisement]())
-	}
-	if existing == nil {
-		existing = serverAds.Get(rawURL, ttlcache.WithDisableTouchOnHit[string, *server_structs.Advertisement]())
-	}
+	existing := lookupServerAd(sAd.URL.String())
 
 	// There's an existing ad in the cache
 	if existing != nil {
@@ -285,6 +291,12 @@ func recordAd(ctx context.Context, sAd server_structs.ServerAd, namespaceAds *[]
 			log.Debugf("The ServerAd generated from topology with name %s and URL %s was ignored because there's already a Pelican ad for this server", sAd.Name, sAd.URL.String())
 			return
 		}
+		if !sAd.FromTopology && !existing.Value().FromTopology && existing.Value().After(&sAd) == server_structs.AdAfterTrue {
+			// With multiple directors, an ad may arrive both directly from the server and via
+			// another director; never let a delayed copy replace a newer generation of the ad.
+			log.Debugf("The ServerAd with name %s and URL %s was ignored because a newer generation of the ad is already recorded", sAd.Name, sAd.URL.String())
+			return
+		}
 		if !sAd.FromTopology && existing.Value().FromTopology {
 			// Pelican server will overwrite topology one. We leave a message to let admin know
 			log.Debugf("The existing ServerAd generated from topology with name %s and URL %s is replaced by the Pelican server with name %s", existing.Value().Name, existing.Value().URL.String(), sAd.Name)
@@ -292,6 +304,9 @@ func recordAd(ctx context.Context, sAd server_structs.ServerAd, namespaceAds *[]
 		}
 		if !sAd.FromTopology && !existing.Value().FromTopology { // Only copy the IO Load value for Pelican server
 			sAd.IOLoad = existing.Value().GetIOLoad() // we copy the value from the existing serverAD to be consistent
+			if reason := statCacheInvalidationReason(existing.Value(), &sAd, *namespaceAds); reason != "" {
+				invalidateStatCache(&existing.Value().ServerAd, reason)
+			}
 		}
 
 		populateEWMAStatusWeight(&sAd, &(existing.Value().ServerAd))
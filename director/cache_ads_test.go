@@ -257,6 +257,30 @@ func TestRecordAd(t *testing.T) {
 		assert.False(t, getAd.Value().FromTopology) // topology ad is ignored
 	})
 
+	t.Run("older-generation-is-ignored", func(t *testing.T) {
+		defer serverAds.DeleteAll()
+		older := mockPelican.ServerAd
+		older.Initialize("pelican-origin")
+		older.Status = "older"
+		newer := mockPelican.ServerAd
+		newer.Initialize("pelican-origin")
+		newer.Status = "newer"
+
+		// A delayed copy of an old ad (e.g., forwarded by another director) must not win
+		recordAd(context.Background(), newer, &mockPelican.NamespaceAds)
+		recordAd(context.Background(), older, &mockPelican.NamespaceAds)
+		getAd := serverAds.Get(pelicanServerUrl.String())
+		require.NotNil(t, getAd)
+		assert.Equal(t, "newer", getAd.Value().Status)
+
+		// Re-recording the same generation is still allowed
+		newer.Status = "newer-again"
+		recordAd(context.Background(), newer, &mockPelican.NamespaceAds)
+		getAd = serverAds.Get(pelicanServerUrl.String())
+		require.NotNil(t, getAd)
+		assert.Equal(t, "newer-again", getAd.Value().Status)
+	})
+
 	t.Run("recorded-sad-should-match-health-test-utils-one", func(t *testing.T) {
 		t.Cleanup(func() {
 			server_utils.ResetTestState()
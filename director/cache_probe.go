@@ -0,0 +1,304 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptrace"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type (
+	// The smoothed results of probing a single cache
+	cacheProbeStats struct {
+		TTFB       float64   // EWMA of the time to first byte, in seconds
+		Throughput float64   // EWMA of the throughput, in bytes per second; zero if never measured
+		LastUpdate time.Time // Time of the last successful probe
+		Failures   int       // Number of consecutive failed probes
+	}
+
+	// The raw result of a single probe
+	cacheProbeResult struct {
+		TTFB       time.Duration
+		Bytes      int64
+		Throughput float64 // Zero if too few bytes were transferred to measure throughput
+	}
+)
+
+const (
+	// Probes transferring fewer bytes than this only measure time to first byte;
+	// smaller transfers are dominated by latency and say little about throughput.
+	cacheProbeMinThroughputBytes = 64 * 1024
+	// Never read more than this much of the probe object
+	cacheProbeMaxBytes = 64 * 1024 * 1024
+	// Maximum number of caches probed at once
+	cacheProbeConcurrency = 10
+
+	// Constants for converting probe results into sort weights.  Both are expressed in
+	// milliseconds so they can share thresholds: time to first byte directly, throughput as
+	// the time taken to transfer each MiB.
+	probeHalvingThreshold = 100.0 // Latency (ms) below which a cache isn't penalized
+	probeHalvingFactor    = 500.0 // Additional latency (ms) that halves a cache's weight
+)
+
+var (
+	// Probe results for each cache, keyed by the cache's URL
+	cacheProbeStatsMap   = map[string]*cacheProbeStats{}
+	cacheProbeStatsMutex sync.RWMutex
+
+	// Whether the director has already complained that the probe object is too small
+	cacheProbeSmallObjectWarned atomic.Bool
+)
+
+// Fold a new probe result into the moving averages.  The smoothing factor depends on the
+// time since the last update, exactly like the EWMA status weight (see populateEWMAStatusWeight).
+func (s *cacheProbeStats) update(res cacheProbeResult, now time.Time) {
+	ttfb := res.TTFB.Seconds()
+	s.Failures = 0
+	if s.LastUpdate.IsZero() {
+		s.TTFB = ttfb
+		s.Throughput = res.Throughput
+		s.LastUpdate = now
+		return
+	}
+
+	tau := param.Director_AdaptiveSortEWMATimeConstant.GetDuration()
+	if tau <= 0 {
+		tau = 5 * time.Minute
+	}
+	deltaT := max(now.Sub(s.LastUpdate), 0)
+	alpha := 1 - math.Exp(-float64(deltaT)/float64(tau))
+
+	s.TTFB += alpha * (ttfb - s.TTFB)
+	if res.Throughput > 0 {
+		if s.Throughput == 0 {
+			s.Throughput = res.Throughput
+		} else {
+			s.Throughput += alpha * (res.Throughput - s.Throughput)
+		}
+	}
+	s.LastUpdate = now
+}
+
+// Get a copy of the probe results for a cache
+func getCacheProbeStats(serverUrl string) (cacheProbeStats, bool) {
+	cacheProbeStatsMutex.RLock()
+	defer cacheProbeStatsMutex.RUnlock()
+	stats, ok := cacheProbeStatsMap[serverUrl]
+	if !ok || stats.LastUpdate.IsZero() {
+		return cacheProbeStats{}, false
+	}
+	return *stats, true
+}
+
+// Forget the probe results for a cache, e.g. when its ad expires
+func deleteCacheProbeStats(ad server_structs.ServerAd) {
+	cacheProbeStatsMutex.Lock()
+	defer cacheProbeStatsMutex.Unlock()
+	delete(cacheProbeStatsMap, ad.URL.String())
+	metrics.PelicanDirectorCacheProbeTTFB.Delete(prometheus.Labels{"server_name": ad.Name, "server_url": ad.URL.String()})
+	metrics.PelicanDirectorCacheProbeThroughput.Delete(prometheus.Labels{"server_name": ad.Name, "server_url": ad.URL.String()})
+}
+
+// Record the outcome of probing a cache
+func recordCacheProbe(ad server_structs.ServerAd, res cacheProbeResult, probeErr error) {
+	cacheProbeStatsMutex.Lock()
+	defer cacheProbeStatsMutex.Unlock()
+	stats, ok := cacheProbeStatsMap[ad.URL.String()]
+	if !ok {
+		stats = &cacheProbeStats{}
+		cacheProbeStatsMap[ad.URL.String()] = stats
+	}
+
+	status := metrics.MetricSucceeded
+	if probeErr != nil {
+		status = metrics.MetricFailed
+		stats.Failures++
+	} else {
+		stats.update(res, time.Now())
+		labels := prometheus.Labels{"server_name": ad.Name, "server_url": ad.URL.String()}
+		metrics.PelicanDirectorCacheProbeTTFB.With(labels).Set(stats.TTFB)
+		if stats.Throughput > 0 {
+			metrics.PelicanDirectorCacheProbeThroughput.With(labels).Set(stats.Throughput)
+		}
+	}
+	metrics.PelicanDirectorCacheProbesTotal.With(prometheus.Labels{"server_name": ad.Name, "status": string(status)}).Inc()
+}
+
+// Get the federation path to probe caches with.  There's deliberately no default: the
+// director's own test files are a few bytes, far too small to say anything about throughput.
+func getCacheProbeObject() (string, error) {
+	obj := param.Director_CacheProbeObject.GetString()
+	if obj == "" {
+		return "", errors.Errorf("%s is set but %s is not; cache probes need an object of at least %d bytes to measure throughput",
+			param.Director_EnableCacheProbes.GetName(), param.Director_CacheProbeObject.GetName(), cacheProbeMinThroughputBytes)
+	}
+	return path.Clean("/" + obj), nil
+}
+
+// Download the probe object through a cache, measuring the time to first byte and throughput
+func probeCache(ctx context.Context, ad server_structs.ServerAd, objectPath string) (res cacheProbeResult, err error) {
+	objectUrl := ad.URL.JoinPath(objectPath)
+
+	var start, firstByte time.Time
+	trace := &httptrace.ClientTrace{
+		GotFirstResponseByte: func() { firstByte = time.Now() },
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, objectUrl.String(), nil)
+	if err != nil {
+		return res, errors.Wrap(err, "failed to create the probe request")
+	}
+	req.Header.Set("User-Agent", "pelican-director/"+config.GetVersion())
+
+	start = time.Now()
+	resp, err := config.GetClient().Do(req)
+	if err != nil {
+		return res, errors.Wrap(err, "failed to send the probe request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return res, errors.Errorf("cache responded to the probe with status %d", resp.StatusCode)
+	}
+
+	res.Bytes, err = io.Copy(io.Discard, io.LimitReader(resp.Body, cacheProbeMaxBytes))
+	if err != nil {
+		return res, errors.Wrap(err, "failed to read the probe response")
+	}
+	end := time.Now()
+	if firstByte.IsZero() {
+		firstByte = end
+	}
+	res.TTFB = firstByte.Sub(start)
+	if transferTime := end.Sub(firstByte); res.Bytes >= cacheProbeMinThroughputBytes && transferTime > 0 {
+		res.Throughput = float64(res.Bytes) / transferTime.Seconds()
+	}
+	return res, nil
+}
+
+// Probe every eligible cache once
+func runCacheProbes(ctx context.Context) {
+	objectPath, err := getCacheProbeObject()
+	if err != nil {
+		log.Warningln("Skipping cache probes:", err)
+		return
+	}
+	timeout := param.Director_CacheProbeTimeout.GetDuration()
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	egrp, ctx := errgroup.WithContext(ctx)
+	egrp.SetLimit(cacheProbeConcurrency)
+	for _, ad := range listAdvertisement([]server_structs.ServerType{server_structs.CacheType}) {
+		sAd := ad.ServerAd
+		if filtered, _ := checkFilter(sAd.Name); filtered {
+			continue
+		}
+		egrp.Go(func() error {
+			probeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			res, err := probeCache(probeCtx, sAd, objectPath)
+			if ctx.Err() != nil {
+				// The director is shutting down; don't count this as a failure
+				return nil
+			}
+			if err != nil {
+				log.Debugf("Probe of cache %s failed: %v", sAd.Name, err)
+			} else if res.Bytes < cacheProbeMinThroughputBytes && cacheProbeSmallObjectWarned.CompareAndSwap(false, true) {
+				log.Warningf("The cache probe object %s is only %d bytes; use an object of at least %d bytes in %s to measure throughput",
+					objectPath, res.Bytes, cacheProbeMinThroughputBytes, param.Director_CacheProbeObject.GetName())
+			} else {
+				log.Tracef("Probe of cache %s: ttfb=%s, bytes=%d, throughput=%.0fB/s", sAd.Name, res.TTFB, res.Bytes, res.Throughput)
+			}
+			recordCacheProbe(sAd, res, err)
+			return nil
+		})
+	}
+	_ = egrp.Wait()
+}
+
+// Converts the probe results for a server into a weight (larger = better).
+// Returns ok=false if the server has never been successfully probed so median imputation can happen.
+func probeWeightFn(ad server_structs.ServerAd) (float64, bool) {
+	stats, ok := getCacheProbeStats(ad.URL.String())
+	if !ok || stats.TTFB < 0 {
+		return 0, false
+	}
+	w := thresholdedExponentialHalvingMultiplier(stats.TTFB*1000, probeHalvingThreshold, probeHalvingFactor)
+	if stats.Throughput > 0 {
+		msPerMiB := 1000 * (1024 * 1024) / stats.Throughput
+		w *= thresholdedExponentialHalvingMultiplier(msPerMiB, probeHalvingThreshold, probeHalvingFactor)
+	}
+	if w <= 0 {
+		return 0, false
+	}
+	return w, true
+}
+
+// Launch a goroutine that periodically probes every cache known to the director,
+// measuring the time to first byte and throughput of a test transfer.  Returns an
+// error if probes are enabled without an object to probe with.
+func LaunchCacheProbes(ctx context.Context, egrp *errgroup.Group) error {
+	if !param.Director_EnableCacheProbes.GetBool() {
+		if param.Director_AdaptiveSortUseCacheProbes.GetBool() {
+			log.Warningf("%s is set but %s is not; the adaptive sort will treat every cache as unprobed",
+				param.Director_AdaptiveSortUseCacheProbes.GetName(), param.Director_EnableCacheProbes.GetName())
+		}
+		return nil
+	}
+	if _, err := getCacheProbeObject(); err != nil {
+		return err
+	}
+	interval := param.Director_CacheProbeInterval.GetDuration()
+	if interval <= 0 {
+		log.Warningf("Invalid %s value %s; using 1m instead", param.Director_CacheProbeInterval.GetName(), interval.String())
+		interval = time.Minute
+	}
+	log.Debugf("Probing caches every %s", interval.String())
+
+	egrp.Go(func() error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				runCacheProbes(ctx)
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,266 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+func resetCacheProbeStats(t *testing.T) {
+	cacheProbeStatsMutex.Lock()
+	cacheProbeStatsMap = map[string]*cacheProbeStats{}
+	cacheProbeStatsMutex.Unlock()
+	t.Cleanup(func() {
+		cacheProbeStatsMutex.Lock()
+		cacheProbeStatsMap = map[string]*cacheProbeStats{}
+		cacheProbeStatsMutex.Unlock()
+	})
+}
+
+func setCacheProbeStats(serverUrl string, ttfb, throughput float64) {
+	cacheProbeStatsMutex.Lock()
+	defer cacheProbeStatsMutex.Unlock()
+	cacheProbeStatsMap[serverUrl] = &cacheProbeStats{TTFB: ttfb, Throughput: throughput, LastUpdate: time.Now()}
+}
+
+func TestCacheProbeStatsUpdate(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+	require.NoError(t, param.Director_AdaptiveSortEWMATimeConstant.Set(time.Minute))
+
+	now := time.Now()
+	stats := cacheProbeStats{Failures: 2}
+	stats.update(cacheProbeResult{TTFB: 200 * time.Millisecond}, now)
+	assert.InDelta(t, 0.2, stats.TTFB, 1e-9)
+	assert.Zero(t, stats.Throughput)
+	assert.Zero(t, stats.Failures)
+
+	// After one time constant, the average moves 1-1/e of the way to the new value
+	stats.update(cacheProbeResult{TTFB: 1200 * time.Millisecond, Throughput: 1000}, now.Add(time.Minute))
+	assert.InDelta(t, 0.2+0.63212*(1.2-0.2), stats.TTFB, 1e-4)
+	assert.Equal(t, 1000.0, stats.Throughput, "the first throughput measurement should be used as-is")
+
+	// Probes that couldn't measure throughput leave it unchanged
+	stats.update(cacheProbeResult{TTFB: 200 * time.Millisecond}, now.Add(2*time.Minute))
+	assert.Equal(t, 1000.0, stats.Throughput)
+}
+
+func TestProbeWeightFn(t *testing.T) {
+	resetCacheProbeStats(t)
+
+	fast := server_structs.ServerAd{URL: url.URL{Scheme: "https", Host: "fast"}}
+	slow := server_structs.ServerAd{URL: url.URL{Scheme: "https", Host: "slow"}}
+	slowTransfer := server_structs.ServerAd{URL: url.URL{Scheme: "https", Host: "slow-transfer"}}
+	unknown := server_structs.ServerAd{URL: url.URL{Scheme: "https", Host: "unknown"}}
+	setCacheProbeStats(fast.URL.String(), 0.05, 100*1024*1024)
+	setCacheProbeStats(slow.URL.String(), 0.6, 0)
+	// 1MiB/s means 1000ms per MiB, which is 900ms over the threshold
+	setCacheProbeStats(slowTransfer.URL.String(), 0.05, 1024*1024)
+
+	w, ok := probeWeightFn(fast)
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, w)
+
+	w, ok = probeWeightFn(slow)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.5, w, 1e-9)
+
+	w, ok = probeWeightFn(slowTransfer)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.287175, w, 1e-6)
+
+	_, ok = probeWeightFn(unknown)
+	assert.False(t, ok)
+}
+
+func TestProbeCache(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+
+	body := bytes.Repeat([]byte("a"), 128*1024)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/probe/object" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(svr.Close)
+	svrUrl, err := url.Parse(svr.URL)
+	require.NoError(t, err)
+	ad := server_structs.ServerAd{URL: *svrUrl}
+
+	res, err := probeCache(context.Background(), ad, "/probe/object")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(body)), res.Bytes)
+	assert.GreaterOrEqual(t, res.TTFB, 20*time.Millisecond)
+	assert.Greater(t, res.Throughput, 0.0)
+
+	_, err = probeCache(context.Background(), ad, "/missing")
+	assert.ErrorContains(t, err, "status 404")
+}
+
+func TestRunCacheProbes(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	resetCacheProbeStats(t)
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		filteredServersMutex.Lock()
+		delete(filteredServers, "filtered-cache")
+		filteredServersMutex.Unlock()
+		server_utils.ResetTestState()
+	})
+	require.NoError(t, param.Director_CacheProbeObject.Set("/probe/object"))
+
+	var requests atomic.Int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "/probe/object", r.URL.Path)
+		_, _ = w.Write([]byte("probe"))
+	}))
+	t.Cleanup(svr.Close)
+	svrUrl, err := url.Parse(svr.URL)
+	require.NoError(t, err)
+
+	addCache := func(name string, u url.URL) server_structs.ServerAd {
+		ad := server_structs.ServerAd{URL: u, Type: server_structs.CacheType.String()}
+		ad.Initialize(name)
+		serverAds.Set(u.String(), &server_structs.Advertisement{ServerAd: ad}, ttlcache.DefaultTTL)
+		return ad
+	}
+	cache := addCache("probed-cache", *svrUrl)
+	filteredUrl := *svrUrl
+	filteredUrl.Path = "/filtered"
+	addCache("filtered-cache", filteredUrl)
+	filteredServersMutex.Lock()
+	filteredServers["filtered-cache"] = tempFiltered
+	filteredServersMutex.Unlock()
+	origin := server_structs.ServerAd{URL: url.URL{Scheme: "https", Host: "origin.example.com"}, Type: server_structs.OriginType.String()}
+	origin.Initialize("origin")
+	serverAds.Set(origin.URL.String(), &server_structs.Advertisement{ServerAd: origin}, ttlcache.DefaultTTL)
+
+	runCacheProbes(context.Background())
+
+	assert.Equal(t, int32(1), requests.Load(), "only the unfiltered cache should be probed")
+	stats, ok := getCacheProbeStats(cache.URL.String())
+	require.True(t, ok)
+	assert.Greater(t, stats.TTFB, 0.0)
+	assert.Zero(t, stats.Throughput, "the probe object is too small to measure throughput")
+	_, ok = getCacheProbeStats(filteredUrl.String())
+	assert.False(t, ok)
+
+	// The probe results are discarded along with the cache's ad
+	deleteCacheProbeStats(cache)
+	_, ok = getCacheProbeStats(cache.URL.String())
+	assert.False(t, ok)
+}
+
+func TestLaunchCacheProbesRequiresObject(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	egrp, ctx := errgroup.WithContext(ctx)
+	t.Cleanup(func() { cancel(); _ = egrp.Wait() })
+
+	// Off by default, and nothing to check
+	assert.False(t, param.Director_EnableCacheProbes.GetBool())
+	require.NoError(t, LaunchCacheProbes(ctx, egrp))
+
+	// On without an object is a configuration error rather than a silent TTFB-only probe
+	require.NoError(t, param.Director_EnableCacheProbes.Set(true))
+	err := LaunchCacheProbes(ctx, egrp)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), param.Director_CacheProbeObject.GetName())
+
+	require.NoError(t, param.Director_CacheProbeObject.Set("/probe/object"))
+	require.NoError(t, LaunchCacheProbes(ctx, egrp))
+}
+
+func TestAdaptiveSortWithCacheProbes(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+	resetCacheProbeStats(t)
+	setupOverrideCache(t)
+	require.NoError(t, param.Director_AdaptiveSortTruncateConstant.Set(6))
+
+	fast := getAdBase("fast", 43.07296, -89.40831)
+	slow := getAdBase("slow", 43.07296, -89.40831)
+	fast.StatusWeight, slow.StatusWeight = 1, 1
+	setCacheProbeStats(fast.URL.String(), 0.05, 0)
+	setCacheProbeStats(slow.URL.String(), 2.1, 0)
+	sAds := []server_structs.ServerAd{slow, fast}
+
+	newCtx := func() SortContext {
+		return SortContext{
+			Ctx:          context.Background(),
+			ClientAddr:   netip.MustParseAddr("192.168.1.4"),
+			RedirectInfo: &server_structs.RedirectInfo{},
+		}
+	}
+
+	// Probe results are ignored unless enabled
+	sCtx := newCtx()
+	_, err := (&AdaptiveSort{}).Sort(sAds, sCtx)
+	require.NoError(t, err)
+	assert.Zero(t, sCtx.RedirectInfo.ServersInfo[slow.URL.String()].RedirectWeights.ProbeWeight)
+
+	require.NoError(t, param.Director_AdaptiveSortUseCacheProbes.Set(true))
+	sCtx = newCtx()
+	_, err = (&AdaptiveSort{}).Sort(sAds, sCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, sCtx.RedirectInfo.ServersInfo[fast.URL.String()].RedirectWeights.ProbeWeight)
+	assert.InDelta(t, 0.0625, sCtx.RedirectInfo.ServersInfo[slow.URL.String()].RedirectWeights.ProbeWeight, 1e-9)
+
+	// With everything else equal, the cache with the faster probes should usually come first
+	fastFirst := 0
+	for range 1000 {
+		sorted, err := (&AdaptiveSort{}).Sort(sAds, newCtx())
+		require.NoError(t, err)
+		if sorted[0].Name == "fast" {
+			fastFirst++
+		}
+	}
+	assert.Greater(t, fastFirst, 800)
+}
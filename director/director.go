@@ -1319,6 +1319,29 @@ func registerServerAd(engineCtx context.Context, ctx *gin.Context, sType server_
 		ad.Version = "unknown"
 	}
 
+	applyServerAdState(&ad)
+
+	// Forward to other directors, if applicable
+	forwardServiceAd(engineCtx, &ad, sType, nil)
+
+	// Correct any clock skews detected in the client
+	now := time.Now()
+	if skew := now.Sub(ad.Now); !ad.Now.IsZero() && (skew > 100*time.Millisecond || skew < -100*time.Millisecond) {
+		lifetime := ad.GetExpiration().Sub(ad.Now)
+		if lifetime > 0 {
+			ad.Expiration = now.Add(lifetime)
+		}
+	}
+	ad.Now = time.Time{}
+
+	finishRegisterServeAd(engineCtx, ctx, &ad, sType)
+}
+
+// Apply the downtime and shutdown state carried by a server (origin/cache) advertisement
+// to the director's in-memory filters.  This is done both for ads received directly
+// from the server and for ads forwarded by other directors so that every director in
+// the federation agrees on which servers are in downtime.
+func applyServerAdState(ad *server_structs.OriginAdvertise) {
 	sn := ad.Name
 	// Process received server(origin/cache) downtimes and toggle the director's in-memory downtime tracker
 	applyServerDowntimes(sn, ad.Downtimes)
@@ -1350,21 +1373,6 @@ func registerServerAd(engineCtx context.Context, ctx *gin.Context, sType server_
 			filteredServersMutex.Unlock()
 		}
 	}
-
-	// Forward to other directors, if applicable
-	forwardServiceAd(engineCtx, &ad, sType, nil)
-
-	// Correct any clock skews detected in the client
-	now := time.Now()
-	if skew := now.Sub(ad.Now); !ad.Now.IsZero() && (skew > 100*time.Millisecond || skew < -100*time.Millisecond) {
-		lifetime := ad.GetExpiration().Sub(ad.Now)
-		if lifetime > 0 {
-			ad.Expiration = now.Add(lifetime)
-		}
-	}
-	ad.Now = time.Time{}
-
-	finishRegisterServeAd(engineCtx, ctx, &ad, sType)
 }
 
 // Finish registering the provided service ad (cache or origin) after authorization was completed.
@@ -1792,6 +1800,12 @@ func RegisterDirectorAPI(ctx context.Context, router *gin.RouterGroup) {
 		// Rename the endpoint to reflect such plan.
 		directorAPIV1.GET("/discoverServers", discoverOriginCache)
 
+		// Public view of which servers export and cache each namespace, intended for clients
+		directorAPIV1.GET("/topology", corsHeadersMiddleware, getTopology)
+
+		// Clients report how their transfers through each cache went
+		directorAPIV1.POST("/transferFeedback", postTransferFeedback)
+
 	}
 
 	directorAPIV2 := router.Group("/api/v2.0/director", web_ui.ServerHeaderMiddleware)
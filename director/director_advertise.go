@@ -69,6 +69,7 @@ type (
 		ad             atomic.Pointer[server_structs.DirectorAd]
 		forwardAdChan  chan *forwardAdInfo // Channel for ads for forwarding from the director handler to the internal buffer
 		internalAdChan chan *forwardAdInfo // Channel for ads from the internal buffer to the HTTP client forwarder goroutine.
+		forwardCtx     context.Context     // Context of the forwarding goroutines; nil if they were never launched
 		cancel         context.CancelFunc
 		token          advertiseToken
 	}
@@ -86,12 +87,19 @@ type (
 	// We provided both the director that produced the ad and the
 	// ad itself.  Having the director ad helps detect forwarding
 	// loops -- we can break early if we detect we're talking to ourself!
+	//
+	// Snapshot is set when the ad is part of the state sent to a newly-seen
+	// director (see sendStateSnapshot); the receiver records it but does not
+	// forward it any further.  ServerFilters is only set on the snapshot
+	// message of type serverFiltersAdType.
 	forwardAd struct {
-		DirectorAd *server_structs.DirectorAd      `json:"director-ad"`
-		AdType     string                          `json:"ad-type"`
-		Now        time.Time                       `json:"now"`
-		ServiceAd  *server_structs.OriginAdvertise `json:"service-ad,omitempty"`
-		SeenBy     []string                        `json:"seen-by,omitempty"`
+		DirectorAd    *server_structs.DirectorAd      `json:"director-ad"`
+		AdType        string                          `json:"ad-type"`
+		Now           time.Time                       `json:"now"`
+		ServiceAd     *server_structs.OriginAdvertise `json:"service-ad,omitempty"`
+		SeenBy        []string                        `json:"seen-by,omitempty"`
+		Snapshot      bool                            `json:"snapshot,omitempty"`
+		ServerFilters map[string]filterType           `json:"server-filters,omitempty"`
 	}
 )
 
@@ -116,6 +124,9 @@ const (
 	// functionality.
 	AdvertiseShutdownKey ContextKey = "advertise_shutdown"
 
+	// The ad type of the snapshot message carrying the admin-set server filters
+	serverFiltersAdType = "server-filters"
+
 	// SkewThreshold is the minimum clock skew that triggers expiration time correction.
 	// Skews at or below this threshold are considered negligible and do not require adjustment.
 	SkewThreshold = 100 * time.Millisecond
@@ -196,7 +207,16 @@ func registerDirectorAd(appCtx context.Context, egrp *errgroup.Group, ctx *gin.C
 		fAd.ServiceAd.Expiration = CorrectTimeSkew(fAd.Now, fAd.ServiceAd.Expiration, now)
 	}
 
-	if fAd.AdType == server_structs.DirectorType.String() {
+	if fAd.AdType == serverFiltersAdType {
+		if !fAd.Snapshot {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Bad request. Server filters may only be sent as part of a snapshot",
+			})
+			return
+		}
+		applyReplicatedFilters(fAd.ServerFilters)
+	} else if fAd.AdType == server_structs.DirectorType.String() {
 		if directorAd.Name != "" {
 			func() {
 				directorAdMutex.Lock()
@@ -217,12 +237,26 @@ func registerDirectorAd(appCtx context.Context, egrp *errgroup.Group, ctx *gin.C
 		if fAd.AdType == server_structs.OriginType.String() {
 			sType = server_structs.OriginType
 		}
+		// The same ad may reach us both directly and through one or more directors, in
+		// any order.  Drop copies older than what we already have so a delayed forward
+		// can't roll back the server's state (including its downtimes).
+		if isStaleServiceAd(fAd.ServiceAd) {
+			log.Debugf("Ignoring forwarded %s ad for %s; a newer generation of the ad is already recorded", fAd.AdType, fAd.ServiceAd.Name)
+			ctx.JSON(http.StatusOK, server_structs.SimpleApiResp{
+				Status: server_structs.RespOK,
+				Msg:    "Ad OK",
+			})
+			return
+		}
+		applyServerAdState(fAd.ServiceAd)
 		finishRegisterServeAd(appCtx, ctx, fAd.ServiceAd, sType)
 		if ctx.IsAborted() {
 			return
 		}
 
-		forwardServiceAd(appCtx, fAd.ServiceAd, sType, fAd.SeenBy)
+		if !fAd.Snapshot {
+			forwardServiceAd(appCtx, fAd.ServiceAd, sType, fAd.SeenBy)
+		}
 
 	} else {
 		log.Debugln("Received registration of unrecognized type", fAd.AdType)
@@ -322,10 +356,19 @@ func (dir *directorInfo) forwardDirector(ad *server_structs.DirectorAd) {
 // The seenBy list tracks which directors have already processed this ad to
 // prevent forwarding loops.
 func (dir *directorInfo) forwardService(ctx context.Context, ad *server_structs.OriginAdvertise, sType server_structs.ServerType, seenBy []string) {
+	if info := dir.newServiceAdInfo(ctx, ad, sType, seenBy, false); info != nil {
+		dir.forwardAdChan <- info
+	}
+}
+
+// Build the forwarding information for a service ad destined to the director
+// represented by `dir`.  Returns nil (after logging the reason) if the ad cannot
+// be forwarded.
+func (dir *directorInfo) newServiceAdInfo(ctx context.Context, ad *server_structs.OriginAdvertise, sType server_structs.ServerType, seenBy []string, snapshot bool) *forwardAdInfo {
 	name, err := getMyName(ctx)
 	if err != nil {
 		log.Errorln("This Director does not know its own name (cannot forward service ad):", err)
-		return
+		return nil
 	}
 	adUrl := param.Director_AdvertiseUrl.GetString()
 	if adUrl == "" {
@@ -341,12 +384,13 @@ func (dir *directorInfo) forwardService(ctx context.Context, ad *server_structs.
 		AdType:     sType.String(),
 		Now:        time.Now(),
 		SeenBy:     seenBy,
+		Snapshot:   snapshot,
 	}
 
 	var buf *bytes.Buffer
 	if adBytes, err := json.Marshal(forwardAd); err != nil {
 		log.Errorln("Failed to marshal service ad to JSON when sending to", dir.advertiseURL(), ":", err)
-		return
+		return nil
 	} else {
 		buf = bytes.NewBuffer(adBytes)
 	}
@@ -356,8 +400,183 @@ func (dir *directorInfo) forwardService(ctx context.Context, ad *server_structs.
 		contents: buf,
 	}
 	info.serverBase.CopyFrom(ad)
+	return info
+}
 
-	dir.forwardAdChan <- info
+// Send every server ad this director knows about to the director represented by `dir`.
+//
+// Service ads are normally forwarded as they arrive, so a director that starts (or
+// restarts) after an origin or cache advertised would not learn about that server until
+// its next advertisement.  The snapshot closes that gap.  Each ad keeps the generation
+// metadata of the original advertisement, so the remote director will never replace a
+// newer ad it has already received; snapshot ads are not forwarded any further.
+//
+// The snapshot also carries the admin-set server filters (see getReplicatedFilters),
+// which are not part of any server ad.
+func (dir *directorInfo) sendStateSnapshot(ctx context.Context) {
+	name, err := getMyName(ctx)
+	if err != nil {
+		log.Errorln("This Director does not know its own name (cannot send its server ads to", dir.advertiseURL(), "):", err)
+		return
+	}
+
+	if info := dir.newServerFiltersInfo(name, getReplicatedFilters()); info != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case dir.forwardAdChan <- info:
+		}
+	}
+
+	sent := 0
+	for _, ad := range getServerAdsSnapshot() {
+		oAd, sType, ok := advertisementToServiceAd(ad)
+		if !ok {
+			continue
+		}
+		info := dir.newServiceAdInfo(ctx, oAd, sType, []string{name}, true)
+		if info == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case dir.forwardAdChan <- info:
+			sent++
+		}
+	}
+	log.Debugf("Sent %d server ads to director %s", sent, dir.advertiseURL())
+}
+
+// Build the snapshot message carrying the given server filters to the director
+// represented by `dir`.  Returns nil if there are no filters to send.
+func (dir *directorInfo) newServerFiltersInfo(name string, filters map[string]filterType) *forwardAdInfo {
+	if len(filters) == 0 {
+		return nil
+	}
+	adUrl := param.Director_AdvertiseUrl.GetString()
+	if adUrl == "" {
+		adUrl = param.Server_ExternalWebUrl.GetString()
+	}
+	directorAd := &server_structs.DirectorAd{
+		AdvertiseUrl: adUrl,
+	}
+	directorAd.Initialize(name)
+	forwardAd := &forwardAd{
+		DirectorAd:    directorAd,
+		AdType:        serverFiltersAdType,
+		Now:           time.Now(),
+		SeenBy:        []string{name},
+		Snapshot:      true,
+		ServerFilters: filters,
+	}
+
+	adBytes, err := json.Marshal(forwardAd)
+	if err != nil {
+		log.Errorln("Failed to marshal server filters to JSON when sending to", dir.advertiseURL(), ":", err)
+		return nil
+	}
+	info := &forwardAdInfo{
+		key:      serverFiltersAdType,
+		adType:   server_structs.DirectorType,
+		contents: bytes.NewBuffer(adBytes),
+	}
+	info.serverBase.CopyFrom(directorAd)
+	return info
+}
+
+// Returns the server filters an admin set through the director's web UI.
+//
+// These are the only filters shared with other directors: Director.FilteredServers
+// comes from each director's own configuration, every director loads the topology
+// downtimes itself, and filters derived from a server's downtimes or shutdown
+// status travel with that server's ads.
+func getReplicatedFilters() map[string]filterType {
+	filteredServersMutex.RLock()
+	defer filteredServersMutex.RUnlock()
+	filters := make(map[string]filterType)
+	for name, ft := range filteredServers {
+		if ft == tempFiltered || ft == tempAllowed {
+			filters[name] = ft
+		}
+	}
+	return filters
+}
+
+// Apply the server filters received from another director's snapshot.
+//
+// As when restoring persisted filters, a received filter never overrides one this
+// director already has for the same server; the next downtime update from the
+// registry reconciles any filter that is no longer current.
+func applyReplicatedFilters(filters map[string]filterType) {
+	filteredServersMutex.Lock()
+	defer filteredServersMutex.Unlock()
+	applied := 0
+	for name, ft := range filters {
+		if ft != tempFiltered && ft != tempAllowed {
+			log.Debugf("Ignoring replicated filter %q for server %s; only admin-set filters are replicated", ft, name)
+			continue
+		}
+		if _, exists := filteredServers[name]; exists {
+			continue
+		}
+		filteredServers[name] = ft
+		applied++
+	}
+	log.Debugf("Applied %d of %d server filters received from another director", applied, len(filters))
+}
+
+// Convert a recorded server advertisement back into the form used on the wire.
+//
+// Returns false for ads that should not be shared with other directors: ads
+// generated from topology (every director loads those itself), expired ads, and
+// ads from servers too old to include generation metadata, which cannot be
+// ordered against newer copies of the same ad.
+func advertisementToServiceAd(ad *server_structs.Advertisement) (*server_structs.OriginAdvertise, server_structs.ServerType, bool) {
+	ad.RLock()
+	defer ad.RUnlock()
+
+	if ad.FromTopology || ad.GenerationID == 0 || ad.InstanceID == "" {
+		return nil, 0, false
+	}
+	if !ad.Expiration.IsZero() && ad.Expiration.Before(time.Now()) {
+		return nil, 0, false
+	}
+	var sType server_structs.ServerType
+	switch ad.Type {
+	case server_structs.OriginType.String():
+		sType = server_structs.OriginType
+	case server_structs.CacheType.String():
+		sType = server_structs.CacheType
+	default:
+		return nil, 0, false
+	}
+
+	oAd := &server_structs.OriginAdvertise{
+		ServerID:            ad.ServerID,
+		RegistryPrefix:      ad.RegistryPrefix,
+		DataURL:             ad.URL.String(),
+		WebURL:              ad.WebURL.String(),
+		BrokerURL:           ad.BrokerURL.String(),
+		Caps:                ad.Caps,
+		Namespaces:          slices.Clone(ad.NamespaceAds),
+		StorageType:         ad.StorageType,
+		DisableDirectorTest: ad.DisableDirectorTest,
+		Downtimes:           slices.Clone(ad.Downtimes),
+		RequiredFeatures:    slices.Clone(ad.RequiredFeatures),
+		Status:              ad.Status,
+	}
+	oAd.CopyFrom(&ad.ServerAd)
+	return oAd, sType, true
+}
+
+// Returns true if a newer generation of the given service ad is already recorded
+func isStaleServiceAd(ad *server_structs.OriginAdvertise) bool {
+	existing := lookupServerAd(ad.DataURL)
+	if existing == nil || existing.Value().FromTopology {
+		return false
+	}
+	return existing.Value().After(ad) == server_structs.AdAfterTrue
 }
 
 // Launch two goroutines to handle the forwarding of director ads
@@ -587,8 +806,14 @@ func updateInternalDirectorCache(ctx context.Context, egrp *errgroup.Group, dire
 	}
 	if item, found := directorAds.GetOrSet(directorAd.Name, info, ttlcache.WithTTL[string, *directorInfo](adTTL)); found {
 		if item.Value() != nil {
-			if after := directorAd.After(item.Value().ad.Load()); after == server_structs.AdAfterTrue || after == server_structs.AdAfterUnknown {
+			prevAd := item.Value().ad.Load()
+			if after := directorAd.After(prevAd); after == server_structs.AdAfterTrue || after == server_structs.AdAfterUnknown {
 				item.Value().ad.Store(directorAd)
+				// A new instance of a known director (e.g., it restarted) starts with an empty
+				// set of server ads; bring it up to date rather than waiting for re-advertisements.
+				if after == server_structs.AdAfterTrue && prevAd != nil && prevAd.InstanceID != directorAd.InstanceID {
+					item.Value().syncNewPeer(ctx, directorAd)
+				}
 				directorAds.Set(directorAd.Name, item.Value(), adTTL)
 				if after == server_structs.AdAfterTrue {
 					// Use Items() instead of Range() to avoid race conditions with the cache's internal eviction goroutine
@@ -607,11 +832,22 @@ func updateInternalDirectorCache(ctx context.Context, egrp *errgroup.Group, dire
 		}
 	} else {
 		info.ad.Store(directorAd)
-		var fwdCtx context.Context
-		fwdCtx, info.cancel = context.WithCancel(ctx)
+		info.forwardCtx, info.cancel = context.WithCancel(ctx)
 		info.forwardAdChan = make(chan *forwardAdInfo, 5)
-		go info.launchForwardAds(fwdCtx, egrp)
+		go info.launchForwardAds(info.forwardCtx, egrp)
+		info.syncNewPeer(ctx, directorAd)
+	}
+}
+
+// Start sending our server ads to a director we have not exchanged ads with before
+func (dir *directorInfo) syncNewPeer(ctx context.Context, directorAd *server_structs.DirectorAd) {
+	if dir.forwardCtx == nil || dir.forwardAdChan == nil {
+		return
+	}
+	if self, err := server_utils.IsDirectorAdFromSelf(ctx, directorAd); err != nil || self {
+		return
 	}
+	go dir.sendStateSnapshot(dir.forwardCtx)
 }
 
 // Go through the list of directors discovered via the periodic
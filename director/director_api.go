@@ -153,6 +153,11 @@ func LaunchTTLCache(ctx context.Context, egrp *errgroup.Group) {
 		} else {
 			log.Debugf("healthTestUtil: not found for %s when evicting TTL cache item", serverAd.Name)
 		}
+
+		if serverAd.Type == server_structs.CacheType.String() {
+			deleteCacheProbeStats(serverAd)
+			deleteTransferFeedback(serverAd)
+		}
 	})
 
 	directorAds.OnEviction(func(ctx context.Context, er ttlcache.EvictionReason, i *ttlcache.Item[string, *directorInfo]) {
@@ -59,6 +59,7 @@ type (
 		FilteredType string                      `json:"filteredType"`
 		Downtimes    []server_structs.Downtime   `json:"downtimes"`
 		FromTopology bool                        `json:"fromTopology"`
+		Restored     bool                        `json:"restored"` // The ad was restored from the director's database and the server has not re-advertised since
 		// HealthStatus and ServerStatus should really have been the same concept
 		// (some component of the server can indicate its health, affecting the
 		// overall server's health), but it looks like they grew organically and
@@ -101,6 +102,7 @@ type (
 		FilteredType           string                               `json:"filteredType"`
 		Downtimes              []server_structs.Downtime            `json:"downtimes"`
 		FromTopology           bool                                 `json:"fromTopology"`
+		Restored               bool                                 `json:"restored"`
 		HealthStatus           HealthTestStatus                     `json:"healthStatus"`
 		ServerStatus           string                               `json:"serverStatus"` // see comment in listServerResponse
 		IOLoad                 float64                              `json:"ioLoad"`
@@ -236,6 +238,7 @@ func advertisementToServerResponse(ad *server_structs.Advertisement) serverRespo
 		FilteredType:        ft.String(),
 		Downtimes:           ad.Downtimes,
 		FromTopology:        ad.FromTopology,
+		Restored:            ad.Restored,
 		HealthStatus:        healthStatus,
 		ServerStatus:        ad.Status,
 		IOLoad:              ad.GetIOLoad(),
@@ -268,6 +271,7 @@ func serverResponseToListServerResponse(res serverResponse) listServerResponse {
 		FilteredType:        res.FilteredType,
 		Downtimes:           res.Downtimes,
 		FromTopology:        res.FromTopology,
+		Restored:            res.Restored,
 		HealthStatus:        res.HealthStatus,
 		ServerStatus:        res.ServerStatus,
 		IOLoad:              res.IOLoad,
@@ -543,5 +547,7 @@ func RegisterDirectorWebAPI(router *gin.RouterGroup) {
 		directorWebAPI.GET("/contact", handleDirectorContact)
 		directorWebAPI.GET("/downtimes", listDowntimeDetails)
 		directorWebAPI.GET("/federation/discrepancy", web_ui.AuthHandler, web_ui.AdminAuthHandler, getFederationDiscrepancy)
+		directorWebAPI.GET("/sortPolicies", web_ui.AuthHandler, web_ui.AdminAuthHandler, listSortPolicies)
+		directorWebAPI.GET("/explain/:service/*path", web_ui.AuthHandler, web_ui.AdminAuthHandler, explainRedirect)
 	}
 }
@@ -0,0 +1,405 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/pelicanplatform/pelican/features"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+/*
+	The explain endpoint lets admins ask the Director why a redirect for a given path
+	and client IP would produce the servers it does. Rather than re-implementing
+	matchmaking, the endpoint replays the request through getSortedAds with a
+	redirectExplanation attached to the gin context. getSortedAds records each
+	candidate ad, the predicates that rejected it, and the weights assigned while
+	sorting. All recording methods are no-ops on a nil explanation, so regular
+	redirects pay nothing for the hooks.
+*/
+
+type (
+	// Everything the Director knows about why a single server ad was (or wasn't)
+	// included in a redirect
+	explainCandidate struct {
+		Name         string `json:"name"`
+		URL          string `json:"url"`
+		Namespace    string `json:"namespace"`
+		FromTopology bool   `json:"fromTopology"`
+		Status       string `json:"status"`
+		// Names of the predicates/filters that removed this server from consideration
+		RemovedBy []string `json:"removedBy,omitempty"`
+		// Names of the predicates that moved this server behind the sorted servers
+		DeprioritizedBy []string `json:"deprioritizedBy,omitempty"`
+		// For caches, "supported" or "unknown" depending on whether the cache is known to
+		// support every feature the origins require
+		FeatureSupport string                          `json:"featureSupport,omitempty"`
+		Coordinate     *server_structs.Coordinate      `json:"coordinate,omitempty"`
+		Weights        *server_structs.RedirectWeights `json:"weights,omitempty"`
+		// 1-based position in the final ordering, or 0 if the server isn't part of the response
+		Rank int `json:"rank"`
+	}
+
+	// The full explanation returned by the explain endpoint
+	redirectExplanation struct {
+		mu sync.Mutex
+
+		Path             string                    `json:"path"`
+		Service          string                    `json:"service"`
+		Verb             string                    `json:"verb"`
+		ClientIP         string                    `json:"clientIp"`
+		ClientCoordinate server_structs.Coordinate `json:"clientCoordinate"`
+		RequestID        string                    `json:"requestId"`
+		RequiredFeatures []string                  `json:"requiredFeatures"`
+		OriginSortMethod string                    `json:"originSortMethod,omitempty"`
+		OriginSortPolicy string                    `json:"originSortPolicy,omitempty"`
+		CacheSortMethod  string                    `json:"cacheSortMethod,omitempty"`
+		CacheSortPolicy  string                    `json:"cacheSortPolicy,omitempty"`
+		Origins          []*explainCandidate       `json:"origins"`
+		Caches           []*explainCandidate       `json:"caches"`
+		// The error a real client would have received, if any
+		Error string `json:"error,omitempty"`
+	}
+)
+
+const (
+	explanationCtxKey = "redirectExplanation"
+
+	explainRemovedByFilteredServer = "filteredServer"
+	explainRemovedBySort           = "sortTruncated"
+)
+
+// Retrieve the explanation attached to a request, if any
+func getRedirectExplanation(ctx *gin.Context) *redirectExplanation {
+	if ctx == nil {
+		return nil
+	}
+	val, exists := ctx.Get(explanationCtxKey)
+	if !exists {
+		return nil
+	}
+	exp, _ := val.(*redirectExplanation)
+	return exp
+}
+
+func (exp *redirectExplanation) candidates(isOrigin bool) []*explainCandidate {
+	if isOrigin {
+		return exp.Origins
+	}
+	return exp.Caches
+}
+
+func (exp *redirectExplanation) find(isOrigin bool, url string) *explainCandidate {
+	for _, c := range exp.candidates(isOrigin) {
+		if c.URL == url {
+			return c
+		}
+	}
+	return nil
+}
+
+func newExplainCandidate(ad copyAd) *explainCandidate {
+	return &explainCandidate{
+		Name:         ad.ServerAd.Name,
+		URL:          ad.ServerAd.URL.String(),
+		Namespace:    ad.NamespaceAd.Path,
+		FromTopology: ad.ServerAd.FromTopology,
+		Status:       ad.ServerAd.Status,
+	}
+}
+
+// Record the ads that match the request's namespace
+func (exp *redirectExplanation) addCandidates(isOrigin bool, ads []copyAd) {
+	if exp == nil {
+		return
+	}
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+	for _, ad := range ads {
+		if exp.find(isOrigin, ad.ServerAd.URL.String()) != nil {
+			continue
+		}
+		if isOrigin {
+			exp.Origins = append(exp.Origins, newExplainCandidate(ad))
+		} else {
+			exp.Caches = append(exp.Caches, newExplainCandidate(ad))
+		}
+	}
+}
+
+// Note that a server was removed from consideration by the named predicate/filter
+func (exp *redirectExplanation) recordRemoval(isOrigin bool, url string, reason string) {
+	if exp == nil {
+		return
+	}
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+	if c := exp.find(isOrigin, url); c != nil && !slices.Contains(c.RemovedBy, reason) {
+		c.RemovedBy = append(c.RemovedBy, reason)
+	}
+}
+
+// Note that a server was moved behind the sorted servers by the named predicate
+func (exp *redirectExplanation) recordDeprioritization(isOrigin bool, url string, reason string) {
+	if exp == nil {
+		return
+	}
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+	if c := exp.find(isOrigin, url); c != nil && !slices.Contains(c.DeprioritizedBy, reason) {
+		c.DeprioritizedBy = append(c.DeprioritizedBy, reason)
+	}
+}
+
+// Evaluate every predicate individually against every ad so the explanation lists
+// all the reasons an ad was rejected, not just the first.
+func (exp *redirectExplanation) recordPredicates(ctx *gin.Context, isOrigin bool, ads []copyAd, preds []namedAdPredicate) {
+	if exp == nil {
+		return
+	}
+	for _, ad := range ads {
+		for _, np := range preds {
+			if !np.pred(ctx, ad) {
+				exp.recordRemoval(isOrigin, ad.ServerAd.URL.String(), np.name)
+			}
+		}
+	}
+}
+
+// Mirror the grouping done by filterCaches: caches failing a common predicate are removed,
+// and the rest are classified as supported, unknown or removed by the feature predicates.
+func (exp *redirectExplanation) recordCachePredicates(ctx *gin.Context, ads []copyAd, commonPreds, supportedPreds, unknownPreds []namedAdPredicate) {
+	if exp == nil {
+		return
+	}
+	exp.recordPredicates(ctx, false, ads, commonPreds)
+	for _, ad := range ads {
+		var support string
+		if allPredicatesPass(ctx, ad, predicatesOf(supportedPreds)...) {
+			support = "supported"
+		} else if allPredicatesPass(ctx, ad, predicatesOf(unknownPreds)...) {
+			support = "unknown"
+		} else {
+			for _, np := range supportedPreds {
+				exp.recordRemoval(false, ad.ServerAd.URL.String(), np.name)
+			}
+		}
+
+		exp.mu.Lock()
+		if c := exp.find(false, ad.ServerAd.URL.String()); c != nil {
+			c.FeatureSupport = support
+		}
+		exp.mu.Unlock()
+	}
+}
+
+func (exp *redirectExplanation) setRequiredFeatures(requiredFeatures map[string]features.Feature) {
+	if exp == nil {
+		return
+	}
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+	exp.RequiredFeatures = make([]string, 0, len(requiredFeatures))
+	for name := range requiredFeatures {
+		exp.RequiredFeatures = append(exp.RequiredFeatures, name)
+	}
+	slices.Sort(exp.RequiredFeatures)
+}
+
+// Record the outcome of sorting origins or caches: which method/policy was used, the
+// weights each server received, and which servers the sort dropped (e.g. because they fell
+// outside the working set or past the response limit).
+func (exp *redirectExplanation) recordSort(isOrigin bool, sorted []copyAd, rInfo *server_structs.RedirectInfo) {
+	if exp == nil {
+		return
+	}
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+
+	if isOrigin {
+		exp.OriginSortMethod = rInfo.DirectorSortMethod
+		exp.OriginSortPolicy = rInfo.DirectorSortPolicy
+	} else {
+		exp.CacheSortMethod = rInfo.DirectorSortMethod
+		exp.CacheSortPolicy = rInfo.DirectorSortPolicy
+	}
+	if rInfo.DirectorSortMethod != "" {
+		exp.ClientCoordinate = rInfo.ClientInfo.Coordinate
+	}
+
+	kept := make(map[string]bool, len(sorted))
+	for _, ad := range sorted {
+		kept[ad.ServerAd.URL.String()] = true
+	}
+	for _, c := range exp.candidates(isOrigin) {
+		if sInfo, ok := rInfo.ServersInfo[c.URL]; ok {
+			coord := sInfo.Coordinate
+			weights := sInfo.RedirectWeights
+			c.Coordinate = &coord
+			c.Weights = &weights
+		}
+		// Unknown and deprioritized caches aren't sorted; they're appended to the end of the response
+		if len(c.RemovedBy) == 0 && len(c.DeprioritizedBy) == 0 && c.FeatureSupport != "unknown" && !kept[c.URL] {
+			c.RemovedBy = append(c.RemovedBy, explainRemovedBySort)
+		}
+	}
+}
+
+// Assign final ranks from the lists getSortedAds returned
+func (exp *redirectExplanation) recordRanks(oAds, cAds []copyAd) {
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+	for idx, ad := range oAds {
+		if c := exp.find(true, ad.ServerAd.URL.String()); c != nil {
+			c.Rank = idx + 1
+		}
+	}
+	for idx, ad := range cAds {
+		if c := exp.find(false, ad.ServerAd.URL.String()); c != nil {
+			c.Rank = idx + 1
+		}
+	}
+}
+
+// getAdsForPath silently drops servers that are filtered or in downtime. Find any such
+// servers that would otherwise have served the path so the explanation can include them.
+func (exp *redirectExplanation) addFilteredServers(reqPath string) {
+	cleaned := path.Clean(reqPath) + "/"
+	for _, ad := range getServerAdsSnapshot() {
+		filtered, fType := checkFilter(ad.Name)
+		if !filtered {
+			continue
+		}
+		nsAd := getLongestNSMatch(cleaned, ad.NamespaceAds)
+		if nsAd == nil {
+			continue
+		}
+		isOrigin := ad.Type == server_structs.OriginType.String()
+		if !isOrigin && ad.Type != server_structs.CacheType.String() {
+			continue
+		}
+		nsCopy := *nsAd
+		nsCopy.Path = strings.TrimSuffix(nsCopy.Path, "/")
+		exp.addCandidates(isOrigin, []copyAd{{ServerAd: ad.ServerAd, NamespaceAd: nsCopy}})
+		exp.recordRemoval(isOrigin, ad.URL.String(), explainRemovedByFilteredServer+":"+string(fType))
+	}
+}
+
+// Explain how the Director would handle a redirect for the given path, optionally
+// simulating a different client IP and HTTP verb. The request is replayed through the same
+// matchmaking and sorting code used for real redirects, so stat queries may be issued to
+// origins and caches just as they would for a client.
+//
+// Query parameters:
+//   - client_ip: The client IP to simulate. Defaults to the IP of the requester.
+//   - verb: The HTTP verb to simulate. Defaults to GET.
+//
+// Any other query parameters (e.g. directread) are passed along as if a client had sent them.
+func explainRedirect(ctx *gin.Context) {
+	service := ctx.Param("service")
+	if service != "object" && service != "origin" {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Invalid service '" + service + "'; must be one of 'object' or 'origin'",
+		})
+		return
+	}
+
+	reqPath := path.Clean("/" + ctx.Param("path"))
+	query := ctx.Request.URL.Query()
+
+	clientIP := ctx.ClientIP()
+	if ipStr := query.Get("client_ip"); ipStr != "" {
+		addr, err := netip.ParseAddr(ipStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Invalid client_ip: " + err.Error(),
+			})
+			return
+		}
+		clientIP = addr.String()
+	}
+
+	verb := strings.ToUpper(query.Get("verb"))
+	if verb == "" {
+		verb = http.MethodGet
+	}
+	allowedVerbs := []string{http.MethodGet, http.MethodHead}
+	if service == "origin" {
+		allowedVerbs = append(allowedVerbs, http.MethodPut, http.MethodDelete, "PROPFIND", "COPY")
+	}
+	if !slices.Contains(allowedVerbs, verb) {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Invalid verb '" + verb + "' for service '" + service + "'",
+		})
+		return
+	}
+
+	query.Del("client_ip")
+	query.Del("verb")
+
+	// Build the request a client would have sent to the redirect endpoint
+	simReq := ctx.Request.Clone(ctx.Request.Context())
+	simReq.Method = verb
+	simReq.URL = &url.URL{
+		Path:     "/api/v1.0/director/" + service + reqPath,
+		RawQuery: query.Encode(),
+	}
+	simReq.RemoteAddr = net.JoinHostPort(clientIP, "0")
+	simReq.Header.Del("X-Forwarded-For")
+	simReq.Header.Del("X-Real-Ip")
+
+	requestId := uuid.New()
+	exp := &redirectExplanation{
+		Path:      reqPath,
+		Service:   service,
+		Verb:      verb,
+		ClientIP:  clientIP,
+		RequestID: requestId.String(),
+		Origins:   []*explainCandidate{},
+		Caches:    []*explainCandidate{},
+	}
+
+	simCtx := ctx.Copy()
+	simCtx.Request = simReq
+	simCtx.Set(explanationCtxKey, exp)
+
+	exp.addFilteredServers(reqPath)
+	oAds, cAds, err := getSortedAds(simCtx, requestId)
+	if err != nil {
+		exp.Error = err.Error()
+	} else {
+		exp.recordRanks(oAds, cAds)
+	}
+
+	ctx.JSON(http.StatusOK, exp)
+}
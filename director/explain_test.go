@@ -0,0 +1,188 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+func TestExplainRedirect(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	gin.SetMode(gin.TestMode)
+	setupOverrideCache(t) // will map 192.168.1.4 --> Discovery building's lat/long
+
+	resetHealthTests()
+	shutdownStatUtils()
+	serverAds.DeleteAll()
+	go serverAds.Start()
+	t.Cleanup(func() {
+		shutdownHealthTests()
+		shutdownStatUtils()
+		serverAds.DeleteAll()
+		serverAds.Stop()
+		server_utils.ResetTestState()
+
+		filteredServersMutex.Lock()
+		delete(filteredServers, "filtered-cache")
+		filteredServersMutex.Unlock()
+	})
+
+	require.NoError(t, param.Director_CheckOriginPresence.Set(false))
+	require.NoError(t, param.Director_CheckCachePresence.Set(false))
+	require.NoError(t, param.Director_FilterCachesInErrorState.Set(true))
+	require.NoError(t, param.Director_CacheSortMethod.Set(string(server_structs.DistanceType)))
+
+	nsAd := server_structs.NamespaceAd{
+		Path: "/explain",
+		Caps: server_structs.Capabilities{PublicReads: true, Reads: true},
+	}
+	newAd := func(name string, sType server_structs.ServerType, lat, long float64) server_structs.ServerAd {
+		ad := server_structs.ServerAd{
+			URL:       url.URL{Scheme: "https", Host: name + ".example.com"},
+			Type:      sType.String(),
+			Latitude:  lat,
+			Longitude: long,
+			Caps:      server_structs.Capabilities{PublicReads: true, Reads: true},
+		}
+		ad.Initialize(name)
+		return ad
+	}
+
+	origin := newAd("origin", server_structs.OriginType, 43.0731, -89.4012)
+	nearCache := newAd("near-cache", server_structs.CacheType, 41.8781, -87.6298)
+	farCache := newAd("far-cache", server_structs.CacheType, 34.0522, -118.2437)
+	errCache := newAd("err-cache", server_structs.CacheType, 43.0731, -89.4012)
+	errCache.Status = metrics.StatusCritical.String()
+	filteredCache := newAd("filtered-cache", server_structs.CacheType, 43.0731, -89.4012)
+
+	for _, ad := range []server_structs.ServerAd{origin, nearCache, farCache, errCache, filteredCache} {
+		nsSlice := []server_structs.NamespaceAd{nsAd}
+		recordAd(context.Background(), ad, &nsSlice)
+	}
+	filteredServersMutex.Lock()
+	filteredServers["filtered-cache"] = permFiltered
+	filteredServersMutex.Unlock()
+
+	router := gin.New()
+	router.GET("/api/v1.0/director_ui/explain/:service/*path", explainRedirect)
+
+	doExplain := func(t *testing.T, target string) (int, *redirectExplanation) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		router.ServeHTTP(w, req)
+		exp := &redirectExplanation{}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), exp))
+		}
+		return w.Code, exp
+	}
+	byName := func(cs []*explainCandidate) map[string]*explainCandidate {
+		out := make(map[string]*explainCandidate, len(cs))
+		for _, c := range cs {
+			out[c.Name] = c
+		}
+		return out
+	}
+
+	t.Run("object-explanation", func(t *testing.T) {
+		code, exp := doExplain(t, "/api/v1.0/director_ui/explain/object/explain/foo.txt?client_ip=192.168.1.4")
+		require.Equal(t, http.StatusOK, code)
+		assert.Empty(t, exp.Error)
+		assert.Equal(t, "/explain/foo.txt", exp.Path)
+		assert.Equal(t, "192.168.1.4", exp.ClientIP)
+		assert.Equal(t, http.MethodGet, exp.Verb)
+		assert.Equal(t, string(server_structs.DistanceType), exp.CacheSortMethod)
+		assert.InDelta(t, 43.07296, exp.ClientCoordinate.Lat, 0.001)
+
+		caches := byName(exp.Caches)
+		require.Len(t, caches, 4)
+
+		assert.Equal(t, 1, caches["near-cache"].Rank)
+		assert.Equal(t, 2, caches["far-cache"].Rank)
+		assert.Empty(t, caches["near-cache"].RemovedBy)
+		require.NotNil(t, caches["near-cache"].Weights)
+		assert.Greater(t, caches["near-cache"].Weights.DistanceWeight, caches["far-cache"].Weights.DistanceWeight)
+
+		assert.Equal(t, 0, caches["err-cache"].Rank)
+		assert.Equal(t, []string{"cacheNotInErrorState"}, caches["err-cache"].RemovedBy)
+
+		assert.Equal(t, 0, caches["filtered-cache"].Rank)
+		assert.Equal(t, []string{"filteredServer:" + string(permFiltered)}, caches["filtered-cache"].RemovedBy)
+
+		origins := byName(exp.Origins)
+		require.Len(t, origins, 1)
+		assert.Equal(t, 1, origins["origin"].Rank)
+	})
+
+	t.Run("origin-verb-not-supported", func(t *testing.T) {
+		code, exp := doExplain(t, "/api/v1.0/director_ui/explain/origin/explain/foo.txt?client_ip=192.168.1.4&verb=PUT")
+		require.Equal(t, http.StatusOK, code)
+		assert.NotEmpty(t, exp.Error)
+		origins := byName(exp.Origins)
+		require.Contains(t, origins, "origin")
+		assert.Equal(t, []string{"originSupportsVerb"}, origins["origin"].RemovedBy)
+		assert.Equal(t, 0, origins["origin"].Rank)
+	})
+
+	t.Run("bad-inputs", func(t *testing.T) {
+		code, _ := doExplain(t, "/api/v1.0/director_ui/explain/bogus/explain/foo.txt")
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = doExplain(t, "/api/v1.0/director_ui/explain/object/explain/foo.txt?client_ip=not-an-ip")
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = doExplain(t, "/api/v1.0/director_ui/explain/object/explain/foo.txt?verb=PUT")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestMergeRedirectInfo(t *testing.T) {
+	originInfo := server_structs.NewRedirectInfoFromIP("1.2.3.4")
+	originInfo.DirectorSortMethod = string(server_structs.DistanceType)
+	originInfo.ServersInfo["https://origin"] = &server_structs.ServerRedirectInfo{}
+
+	cacheInfo := server_structs.NewRedirectInfoFromIP("1.2.3.4")
+	cacheInfo.DirectorSortMethod = string(server_structs.PolicyType)
+	cacheInfo.DirectorSortPolicy = "my-policy"
+	cacheInfo.ServersInfo["https://cache"] = &server_structs.ServerRedirectInfo{}
+
+	merged := mergeRedirectInfo("1.2.3.4", originInfo, cacheInfo, true)
+	assert.Equal(t, string(server_structs.PolicyType), merged.DirectorSortMethod)
+	assert.Equal(t, "my-policy", merged.DirectorSortPolicy)
+	assert.Len(t, merged.ServersInfo, 2)
+
+	merged = mergeRedirectInfo("1.2.3.4", originInfo, server_structs.NewRedirectInfoFromIP("1.2.3.4"), true)
+	assert.Equal(t, string(server_structs.DistanceType), merged.DirectorSortMethod)
+	assert.Empty(t, merged.DirectorSortPolicy)
+	assert.Equal(t, "1.2.3.4", merged.ClientInfo.IpAddr)
+}
@@ -24,6 +24,7 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"net/url"
 	"strconv"
 	"sync"
 	"testing"
@@ -34,6 +35,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/param"
 	"github.com/pelicanplatform/pelican/server_structs"
 )
@@ -439,3 +441,242 @@ func TestForwardServiceSeenBySerialized(t *testing.T) {
 	require.NoError(t, json.Unmarshal(data, &fwd))
 	assert.Equal(t, seenBy, fwd.SeenBy)
 }
+
+func TestAdvertisementToServiceAd(t *testing.T) {
+	dataUrl := url.URL{Scheme: "https", Host: "origin.example.com:8443"}
+	webUrl := url.URL{Scheme: "https", Host: "origin.example.com:8444"}
+	ad := &server_structs.Advertisement{
+		ServerAd: server_structs.ServerAd{
+			ServerID:         "server-id",
+			RegistryPrefix:   "/origins/origin.example.com",
+			URL:              dataUrl,
+			WebURL:           webUrl,
+			Type:             server_structs.OriginType.String(),
+			Caps:             server_structs.Capabilities{PublicReads: true},
+			RequiredFeatures: []string{"feature"},
+			Downtimes:        []server_structs.Downtime{{UUID: "downtime-id", ServerName: "origin"}},
+			Status:           "ok",
+		},
+		NamespaceAds: []server_structs.NamespaceAd{{Path: "/foo"}},
+	}
+	ad.Initialize("origin")
+
+	oAd, sType, ok := advertisementToServiceAd(ad)
+	require.True(t, ok)
+	assert.Equal(t, server_structs.OriginType, sType)
+	assert.Equal(t, ad.ServerBaseAd, oAd.ServerBaseAd)
+	assert.Equal(t, "server-id", oAd.ServerID)
+	assert.Equal(t, "/origins/origin.example.com", oAd.RegistryPrefix)
+	assert.Equal(t, dataUrl.String(), oAd.DataURL)
+	assert.Equal(t, webUrl.String(), oAd.WebURL)
+	assert.Empty(t, oAd.BrokerURL)
+	assert.True(t, oAd.Caps.PublicReads)
+	assert.Equal(t, ad.NamespaceAds, oAd.Namespaces)
+	assert.Equal(t, ad.Downtimes, oAd.Downtimes)
+	assert.Equal(t, ad.RequiredFeatures, oAd.RequiredFeatures)
+	assert.Equal(t, "ok", oAd.Status)
+
+	t.Run("topology-ads-are-skipped", func(t *testing.T) {
+		topoAd := &server_structs.Advertisement{ServerAd: ad.ServerAd}
+		topoAd.FromTopology = true
+		_, _, ok := advertisementToServiceAd(topoAd)
+		assert.False(t, ok)
+	})
+
+	t.Run("ads-without-generation-are-skipped", func(t *testing.T) {
+		legacyAd := &server_structs.Advertisement{ServerAd: ad.ServerAd}
+		legacyAd.GenerationID = 0
+		_, _, ok := advertisementToServiceAd(legacyAd)
+		assert.False(t, ok)
+	})
+
+	t.Run("expired-ads-are-skipped", func(t *testing.T) {
+		expiredAd := &server_structs.Advertisement{ServerAd: ad.ServerAd}
+		expiredAd.Expiration = time.Now().Add(-time.Minute)
+		_, _, ok := advertisementToServiceAd(expiredAd)
+		assert.False(t, ok)
+	})
+}
+
+// TestSendStateSnapshot tests that a newly-seen director receives every server ad
+// we know about, marked so that it will not be forwarded any further.
+func TestSendStateSnapshot(t *testing.T) {
+	config.ResetConfig()
+	t.Cleanup(config.ResetConfig)
+	require.NoError(t, param.Server_ExternalWebUrl.Set("http://test-self.example.com"))
+	setupForwardingState(t)
+	directorName = "dir-self"
+
+	serverAds.DeleteAll()
+	t.Cleanup(serverAds.DeleteAll)
+
+	addAd := func(name string, sType server_structs.ServerType, fromTopology bool) {
+		sAd := server_structs.ServerAd{
+			URL:          url.URL{Scheme: "https", Host: name + ".example.com"},
+			Type:         sType.String(),
+			FromTopology: fromTopology,
+		}
+		sAd.Initialize(name)
+		serverAds.Set(sAd.URL.String(), &server_structs.Advertisement{ServerAd: sAd}, 15*time.Minute)
+	}
+	addAd("origin-1", server_structs.OriginType, false)
+	addAd("cache-1", server_structs.CacheType, false)
+	addAd("topo-origin", server_structs.OriginType, true)
+
+	// Only the admin-set filters are part of the snapshot
+	filteredServersMutex.Lock()
+	oldFilters := filteredServers
+	filteredServers = map[string]filterType{
+		"origin-1":  tempFiltered,
+		"cache-1":   tempAllowed,
+		"cache-2":   permFiltered,
+		"origin-2":  serverFiltered,
+		"topo-orig": topoFiltered,
+	}
+	filteredServersMutex.Unlock()
+	t.Cleanup(func() {
+		filteredServersMutex.Lock()
+		defer filteredServersMutex.Unlock()
+		filteredServers = oldFilters
+	})
+
+	ch := make(chan *forwardAdInfo, 10)
+	dir := newTestDirectorInfo(&server_structs.DirectorAd{
+		AdvertiseUrl: "http://dir-peer.example.com",
+		ServerBaseAd: server_structs.ServerBaseAd{Name: "dir-peer"},
+	})
+	dir.forwardAdChan = ch
+
+	dir.sendStateSnapshot(context.Background())
+	close(ch)
+
+	received := map[string]server_structs.ServerType{}
+	var filters map[string]filterType
+	for info := range ch {
+		data, err := io.ReadAll(info.contents)
+		require.NoError(t, err)
+		var fwd forwardAd
+		require.NoError(t, json.Unmarshal(data, &fwd))
+		assert.True(t, fwd.Snapshot)
+		assert.Equal(t, []string{"dir-self"}, fwd.SeenBy)
+		if fwd.AdType == serverFiltersAdType {
+			assert.Nil(t, fwd.ServiceAd)
+			filters = fwd.ServerFilters
+			continue
+		}
+		require.NotNil(t, fwd.ServiceAd)
+		assert.Equal(t, info.serverBase.InstanceID, fwd.ServiceAd.InstanceID)
+		assert.Equal(t, info.serverBase.GenerationID, fwd.ServiceAd.GenerationID)
+		assert.True(t, info.serverBase.Expiration.Equal(fwd.ServiceAd.Expiration))
+		received[fwd.ServiceAd.Name] = info.adType
+	}
+	assert.Equal(t, map[string]server_structs.ServerType{
+		"origin-1": server_structs.OriginType,
+		"cache-1":  server_structs.CacheType,
+	}, received)
+	assert.Equal(t, map[string]filterType{"origin-1": tempFiltered, "cache-1": tempAllowed}, filters)
+}
+
+// TestApplyReplicatedFilters tests that admin-set filters received from another director
+// are applied without overriding filters this director already has.
+func TestApplyReplicatedFilters(t *testing.T) {
+	filteredServersMutex.Lock()
+	oldFilters := filteredServers
+	filteredServers = map[string]filterType{"cache-1": permFiltered}
+	filteredServersMutex.Unlock()
+	t.Cleanup(func() {
+		filteredServersMutex.Lock()
+		defer filteredServersMutex.Unlock()
+		filteredServers = oldFilters
+	})
+
+	applyReplicatedFilters(map[string]filterType{
+		"origin-1": tempFiltered,
+		"origin-2": tempAllowed,
+		"cache-1":  tempAllowed,
+		"cache-2":  shutdownFiltered,
+	})
+
+	filteredServersMutex.RLock()
+	defer filteredServersMutex.RUnlock()
+	assert.Equal(t, map[string]filterType{
+		"origin-1": tempFiltered,
+		"origin-2": tempAllowed,
+		"cache-1":  permFiltered,
+	}, filteredServers)
+}
+
+func TestIsStaleServiceAd(t *testing.T) {
+	serverAds.DeleteAll()
+	t.Cleanup(serverAds.DeleteAll)
+
+	sAd := server_structs.ServerAd{URL: url.URL{Scheme: "https", Host: "origin.example.com"}}
+	older := &server_structs.OriginAdvertise{DataURL: sAd.URL.String()}
+	older.Initialize("origin")
+	sAd.Initialize("origin")
+	newer := &server_structs.OriginAdvertise{DataURL: sAd.URL.String()}
+	newer.Initialize("origin")
+
+	// Nothing recorded yet
+	assert.False(t, isStaleServiceAd(older))
+
+	serverAds.Set(sAd.URL.String(), &server_structs.Advertisement{ServerAd: sAd}, 15*time.Minute)
+	assert.True(t, isStaleServiceAd(older))
+	assert.False(t, isStaleServiceAd(newer))
+
+	// Topology ads carry no generation information and never make an ad stale
+	sAd.FromTopology = true
+	serverAds.Set(sAd.URL.String(), &server_structs.Advertisement{ServerAd: sAd}, 15*time.Minute)
+	assert.False(t, isStaleServiceAd(older))
+}
+
+// TestApplyServerAdState tests that the downtime and shutdown state carried by an ad is
+// applied to the director's filters, as is done for both direct and forwarded ads.
+func TestApplyServerAdState(t *testing.T) {
+	filteredServersMutex.Lock()
+	delete(filteredServers, "origin")
+	delete(serverDowntimes, "origin")
+	filteredServersMutex.Unlock()
+	t.Cleanup(func() {
+		filteredServersMutex.Lock()
+		defer filteredServersMutex.Unlock()
+		delete(filteredServers, "origin")
+		delete(serverDowntimes, "origin")
+	})
+	getFilter := func() (filterType, bool) {
+		filteredServersMutex.RLock()
+		defer filteredServersMutex.RUnlock()
+		ft, ok := filteredServers["origin"]
+		return ft, ok
+	}
+
+	ad := &server_structs.OriginAdvertise{}
+	ad.Initialize("origin")
+	now := time.Now().UTC().UnixMilli()
+	ad.Downtimes = []server_structs.Downtime{{
+		UUID:       "downtime-id",
+		ServerName: "origin",
+		StartTime:  now - 1000,
+		EndTime:    server_structs.IndefiniteEndTime,
+	}}
+	applyServerAdState(ad)
+	ft, ok := getFilter()
+	assert.True(t, ok)
+	assert.Equal(t, serverFiltered, ft)
+
+	ad.Downtimes = nil
+	applyServerAdState(ad)
+	_, ok = getFilter()
+	assert.False(t, ok)
+
+	ad.Status = metrics.StatusShuttingDown.String()
+	applyServerAdState(ad)
+	ft, ok = getFilter()
+	assert.True(t, ok)
+	assert.Equal(t, shutdownFiltered, ft)
+
+	ad.Status = metrics.StatusOK.String()
+	applyServerAdState(ad)
+	_, ok = getFilter()
+	assert.False(t, ok)
+}
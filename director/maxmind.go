@@ -51,7 +51,11 @@ type (
 )
 
 const (
-	maxMindURL string = "https://download.maxmind.com/app/geoip_download?edition_id=GeoLite2-City&license_key=%s&suffix=tar.gz"
+	maxMindURL string = "https://download.maxmind.com/app/geoip_download?edition_id=%s&license_key=%s&suffix=tar.gz"
+
+	// The MaxMind database editions the director knows how to use
+	maxMindCityEdition string = "GeoLite2-City"
+	maxMindASNEdition  string = "GeoLite2-ASN"
 
 	MaxMindDBError MaxMindErrorKind = iota
 	MaxMindQueryError
@@ -60,14 +64,16 @@ const (
 )
 
 var (
-	maxMindReader atomic.Pointer[geoip2.Reader]
+	maxMindReader    atomic.Pointer[geoip2.Reader]
+	maxMindASNReader atomic.Pointer[geoip2.Reader]
 )
 
 func (e maxmindError) Error() string {
 	return e.Message
 }
 
-func downloadDB(localFile string) error {
+// Download the given MaxMind database edition (e.g. GeoLite2-City) to localFile
+func downloadDB(localFile, edition string) error {
 	err := os.MkdirAll(filepath.Dir(localFile), 0755)
 	if err != nil {
 		return err
@@ -86,7 +92,7 @@ func downloadDB(localFile string) error {
 
 	licenseKey = strings.TrimSpace(string(contents))
 
-	url := fmt.Sprintf(maxMindURL, licenseKey)
+	url := fmt.Sprintf(maxMindURL, edition, licenseKey)
 	localDir := filepath.Dir(localFile)
 	fileHandle, err := os.CreateTemp(localDir, filepath.Base(localFile)+".tmp")
 	if err != nil {
@@ -114,7 +120,7 @@ func downloadDB(localFile string) error {
 			return err
 		}
 		baseName := path.Base(hdr.Name)
-		if baseName != "GeoLite2-City.mmdb" {
+		if baseName != edition+".mmdb" {
 			continue
 		}
 		// Limit extraction to 512 MB to prevent a malicious or corrupted
@@ -142,6 +148,20 @@ func downloadDB(localFile string) error {
 	return nil
 }
 
+// Download a fresh copy of a MaxMind database and swap it in for the current one
+func reloadMaxMindDB(localFile, edition string, reader *atomic.Pointer[geoip2.Reader]) {
+	if err := downloadDB(localFile, edition); err != nil {
+		log.Warningf("Failed to download %s database: %v", edition, err)
+		return
+	}
+	localReader, err := geoip2.Open(localFile)
+	if err != nil {
+		log.Warningf("Failed to re-open %s database: %v", edition, err)
+		return
+	}
+	reader.Store(localReader)
+}
+
 func periodicMaxMindReload(ctx context.Context) {
 	// The MaxMindDB updates Tuesday/Thursday. While a free API key
 	// does get 1000 downloads a month, we might still want to change
@@ -153,16 +173,9 @@ func periodicMaxMindReload(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
-			localFile := param.Director_GeoIPLocation.GetString()
-			if err := downloadDB(localFile); err != nil {
-				log.Warningln("Failed to download GeoIP database:", err)
-			} else {
-				localReader, err := geoip2.Open(localFile)
-				if err != nil {
-					log.Warningln("Failed to re-open GeoIP database:", err)
-				} else {
-					maxMindReader.Store(localReader)
-				}
+			reloadMaxMindDB(param.Director_GeoIPLocation.GetString(), maxMindCityEdition, &maxMindReader)
+			if param.Director_EnableASNLocality.GetBool() {
+				reloadMaxMindDB(param.Director_GeoIPASNLocation.GetString(), maxMindASNEdition, &maxMindASNReader)
 			}
 		case <-ctx.Done():
 			return
@@ -170,24 +183,36 @@ func periodicMaxMindReload(ctx context.Context) {
 	}
 }
 
-func InitializeGeoIPDB(ctx context.Context) {
-	go periodicMaxMindReload(ctx)
-	localFile := param.Director_GeoIPLocation.GetString()
+// Open a local MaxMind database, downloading it first if it isn't present.
+// Returns nil if the database can't be made available.
+func openMaxMindDB(localFile, edition string) *geoip2.Reader {
 	localReader, err := geoip2.Open(localFile)
 	if err != nil {
-		log.Infoln("Local GeoIP database file not present; will attempt a download.", err)
-		err = downloadDB(localFile)
+		log.Infof("Local %s database file not present; will attempt a download. %v", edition, err)
+		err = downloadDB(localFile, edition)
 		if err != nil {
-			log.Errorln("Failed to download GeoIP database!  Will not be available:", err)
-			return
+			log.Errorf("Failed to download %s database!  Will not be available: %v", edition, err)
+			return nil
 		}
 		localReader, err = geoip2.Open(localFile)
 		if err != nil {
-			log.Errorln("Failed to reopen GeoIP database!  Will not be available:", err)
-			return
+			log.Errorf("Failed to reopen %s database!  Will not be available: %v", edition, err)
+			return nil
+		}
+	}
+	return localReader
+}
+
+func InitializeGeoIPDB(ctx context.Context) {
+	go periodicMaxMindReload(ctx)
+	if localReader := openMaxMindDB(param.Director_GeoIPLocation.GetString(), maxMindCityEdition); localReader != nil {
+		maxMindReader.Store(localReader)
+	}
+	if param.Director_EnableASNLocality.GetBool() {
+		if localReader := openMaxMindDB(param.Director_GeoIPASNLocation.GetString(), maxMindASNEdition); localReader != nil {
+			maxMindASNReader.Store(localReader)
 		}
 	}
-	maxMindReader.Store(localReader)
 }
 
 // Given an IP address, query MaxMind for a coordinate.
@@ -245,3 +270,21 @@ var getMaxMindCoordinate = func(addr netip.Addr) (coord server_structs.Coordinat
 	coord.AccuracyRadius = accuracyRadius
 	return
 }
+
+// Given an IP address, query the MaxMind ASN database for the autonomous system
+// it belongs to.  Like getMaxMindCoordinate, this is a package-level variable so
+// it can be overridden for unit testing.
+var getMaxMindASN = func(addr netip.Addr) (uint, error) {
+	reader := maxMindASNReader.Load()
+	if reader == nil {
+		return 0, maxmindError{Kind: MaxMindDBError, Message: "No MaxMind ASN database is available"}
+	}
+	record, err := reader.ASN(normalizeAddr(addr))
+	if err != nil {
+		return 0, maxmindError{Kind: MaxMindQueryError, Message: fmt.Sprintf("failed to retrieve ASN data from the MaxMind database: %v", err)}
+	}
+	if record == nil || record.AutonomousSystemNumber == 0 {
+		return 0, maxmindError{Kind: MaxMindQueryError, Message: fmt.Sprintf("no ASN data was returned from the MaxMind database for the address %s", addr.String())}
+	}
+	return record.AutonomousSystemNumber, nil
+}
@@ -0,0 +1,289 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+/*
+	Network locality lets the director prefer caches that are "close" to the client on
+	the network rather than on the map. Geolocation alone does a poor job for clients
+	behind a campus NAT or on an ISP whose addresses geolocate far from where they are,
+	so two extra signals are available:
+
+	1. Sites from Director.SiteNetworks, which map client CIDR blocks to the caches that
+	   serve them.
+	2. The client's autonomous system, looked up in the MaxMind ASN database when
+	   Director.EnableASNLocality is set.
+
+	Both are IPv6-aware: site networks may be IPv6 prefixes, and a cache is matched on
+	every address its hostname resolves to, so a dual-stack cache is local to clients
+	arriving over either address family.
+*/
+
+type (
+	// The declarative form of a site, as it appears in Director.SiteNetworks
+	SiteNetworkConfig struct {
+		Name     string   `mapstructure:"Name" json:"name"`
+		Networks []string `mapstructure:"Networks" json:"networks"`
+		Caches   []string `mapstructure:"Caches" json:"caches,omitempty"`
+	}
+
+	// A parsed site network, ready for matching against addresses
+	siteNetwork struct {
+		name     string
+		prefixes []netip.Prefix
+		caches   []string
+	}
+
+	// Where a client sits on the network. A nil site or zero ASN means that piece is unknown.
+	clientLocality struct {
+		site *siteNetwork
+		asn  uint
+	}
+
+	// The resolved addresses of a server and the autonomous systems they belong to
+	serverNetworkInfo struct {
+		addrs []netip.Addr
+		asns  []uint
+	}
+)
+
+var (
+	siteNetworksMutex sync.RWMutex
+	siteNetworks      []*siteNetwork
+
+	// Caches DNS and ASN lookups for server hostnames so they aren't repeated on every redirect
+	serverNetworkInfoCache = ttlcache.New(
+		ttlcache.WithTTL[string, serverNetworkInfo](30*time.Minute),
+		ttlcache.WithDisableTouchOnHit[string, serverNetworkInfo](),
+		ttlcache.WithCapacity[string, serverNetworkInfo](10_000),
+	)
+)
+
+// Given a hostname, perform a DNS lookup to find all of its IPv4 and IPv6 addresses.
+// Declared as a variable so unit tests can avoid real DNS lookups.
+var getIPsFromHostname = func(hostname string) ([]netip.Addr, error) {
+	ips, err := net.LookupIP(hostname)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]netip.Addr, 0, len(ips))
+	for _, ip := range ips {
+		if addr, ok := netip.AddrFromSlice(ip); ok {
+			addrs = append(addrs, normalizeAddr(addr))
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("unable to find an IP address for hostname '%s'", hostname)
+	}
+	return addrs, nil
+}
+
+// Parse a site network config, returning an error if it's malformed
+func newSiteNetwork(sc SiteNetworkConfig) (*siteNetwork, error) {
+	if sc.Name == "" {
+		return nil, errors.New("site is missing a Name")
+	}
+	if len(sc.Networks) == 0 {
+		return nil, errors.Errorf("site %q must define at least one network", sc.Name)
+	}
+	site := &siteNetwork{name: sc.Name, caches: sc.Caches}
+	for _, network := range sc.Networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			// Accept bare addresses as single-host networks, as GeoIPOverrides does
+			addr, aerr := netip.ParseAddr(network)
+			if aerr != nil {
+				return nil, errors.Wrapf(err, "site %q has invalid network %q", sc.Name, network)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		// Match against normalized (unmapped) addresses
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		site.prefixes = append(site.prefixes, prefix.Masked())
+	}
+	return site, nil
+}
+
+// Populate the site networks from the Director.SiteNetworks param.
+// Returns an error if any site is malformed so the Director fails fast at startup.
+func ConfigSiteNetworks() error {
+	var configs []SiteNetworkConfig
+	if param.Director_SiteNetworks.IsSet() {
+		if err := param.Director_SiteNetworks.Unmarshal(&configs); err != nil {
+			return errors.Wrapf(err, "failed to parse %s", param.Director_SiteNetworks.GetName())
+		}
+	}
+
+	sites := make([]*siteNetwork, 0, len(configs))
+	names := make(map[string]bool, len(configs))
+	for _, sc := range configs {
+		site, err := newSiteNetwork(sc)
+		if err != nil {
+			return errors.Wrapf(err, "invalid %s", param.Director_SiteNetworks.GetName())
+		}
+		if names[site.name] {
+			return errors.Errorf("invalid %s: duplicate site name %q", param.Director_SiteNetworks.GetName(), site.name)
+		}
+		names[site.name] = true
+		sites = append(sites, site)
+	}
+
+	siteNetworksMutex.Lock()
+	defer siteNetworksMutex.Unlock()
+	siteNetworks = sites
+	if len(sites) > 0 {
+		log.Debugf("Loaded %d site networks from %s", len(sites), param.Director_SiteNetworks.GetName())
+	}
+	return nil
+}
+
+// Whether any network locality information is configured
+func networkLocalityEnabled() bool {
+	if param.Director_EnableASNLocality.GetBool() {
+		return true
+	}
+	siteNetworksMutex.RLock()
+	defer siteNetworksMutex.RUnlock()
+	return len(siteNetworks) > 0
+}
+
+// Find the site whose networks most specifically contain the address, or nil if none do
+func getSiteForAddr(addr netip.Addr) *siteNetwork {
+	addr = normalizeAddr(addr)
+	siteNetworksMutex.RLock()
+	defer siteNetworksMutex.RUnlock()
+
+	var best *siteNetwork
+	bestBits := -1
+	for _, site := range siteNetworks {
+		for _, prefix := range site.prefixes {
+			if prefix.Bits() > bestBits && prefix.Contains(addr) {
+				best = site
+				bestBits = prefix.Bits()
+			}
+		}
+	}
+	return best
+}
+
+// Determine the client's site and autonomous system
+func getClientLocality(addr netip.Addr) (loc clientLocality) {
+	if !addr.IsValid() {
+		return
+	}
+	loc.site = getSiteForAddr(addr)
+	if param.Director_EnableASNLocality.GetBool() {
+		asn, err := getMaxMindASN(addr)
+		if err != nil {
+			log.Tracef("Unable to determine the ASN of client %s: %v", addr.String(), err)
+		} else {
+			loc.asn = asn
+		}
+	}
+	return
+}
+
+// Record the client's locality in the redirect info for debugging
+func (loc clientLocality) record(info *server_structs.RedirectInfo) {
+	if info == nil {
+		return
+	}
+	if loc.site != nil {
+		info.ClientInfo.Site = loc.site.name
+	}
+	info.ClientInfo.ASN = loc.asn
+}
+
+// Look up the addresses of a server and the autonomous systems they belong to,
+// using the cached result when there is one. DNS failures are cached too, so an
+// unresolvable server doesn't trigger a lookup on every redirect.
+func getServerNetworkInfo(ad server_structs.ServerAd) serverNetworkInfo {
+	hostname := ad.URL.Hostname()
+	if item := serverNetworkInfoCache.Get(hostname); item != nil {
+		return item.Value()
+	}
+
+	info := serverNetworkInfo{}
+	addrs, err := getIPsFromHostname(hostname)
+	if err != nil {
+		log.Debugf("Failed to resolve the addresses of server %s for network locality: %v", ad.Name, err)
+	}
+	info.addrs = addrs
+	if param.Director_EnableASNLocality.GetBool() {
+		for _, addr := range addrs {
+			asn, err := getMaxMindASN(addr)
+			if err != nil {
+				log.Tracef("Unable to determine the ASN of server %s address %s: %v", ad.Name, addr.String(), err)
+				continue
+			}
+			if !slices.Contains(info.asns, asn) {
+				info.asns = append(info.asns, asn)
+			}
+		}
+	}
+	serverNetworkInfoCache.Set(hostname, info, ttlcache.DefaultTTL)
+	return info
+}
+
+// Whether the server belongs to the site, either by being listed in the site's caches
+// or by having an address within one of the site's networks
+func (site *siteNetwork) containsServer(ad server_structs.ServerAd) bool {
+	if slices.Contains(site.caches, ad.Name) {
+		return true
+	}
+	for _, addr := range getServerNetworkInfo(ad).addrs {
+		for _, prefix := range site.prefixes {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Computes the network locality weight (larger = better): servers at the client's site
+// receive Director.SiteLocalityFactor, servers in the client's autonomous system receive
+// Director.ASNLocalityFactor, and all others receive 1. The weight is always valid because
+// "not local" is a perfectly good answer.
+func networkLocalityWeightFn(loc clientLocality, ad server_structs.ServerAd) (float64, bool) {
+	if loc.site != nil && loc.site.containsServer(ad) {
+		return float64(max(param.Director_SiteLocalityFactor.GetInt(), 1)), true
+	}
+	if loc.asn != 0 && slices.Contains(getServerNetworkInfo(ad).asns, loc.asn) {
+		return float64(max(param.Director_ASNLocalityFactor.GetInt(), 1)), true
+	}
+	return 1.0, true
+}
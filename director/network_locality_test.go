@@ -0,0 +1,225 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+// Configure the site networks and stub out DNS and ASN lookups for the duration of a test
+func setupNetworkLocality(t *testing.T, sites []map[string]any, hostAddrs map[string][]string, asns map[string]uint) {
+	server_utils.ResetTestState()
+	require.NoError(t, param.Director_SiteLocalityFactor.Set(100))
+	require.NoError(t, param.Director_ASNLocalityFactor.Set(10))
+	if sites != nil {
+		require.NoError(t, param.Director_SiteNetworks.Set(sites))
+	}
+	require.NoError(t, ConfigSiteNetworks())
+
+	oldIPs, oldASN := getIPsFromHostname, getMaxMindASN
+	getIPsFromHostname = func(hostname string) ([]netip.Addr, error) {
+		strs, ok := hostAddrs[hostname]
+		if !ok {
+			return nil, errors.Errorf("no such host %s", hostname)
+		}
+		addrs := make([]netip.Addr, 0, len(strs))
+		for _, s := range strs {
+			addrs = append(addrs, netip.MustParseAddr(s))
+		}
+		return addrs, nil
+	}
+	getMaxMindASN = func(addr netip.Addr) (uint, error) {
+		for prefix, asn := range asns {
+			if netip.MustParsePrefix(prefix).Contains(normalizeAddr(addr)) {
+				return asn, nil
+			}
+		}
+		return 0, errors.New("no ASN")
+	}
+	serverNetworkInfoCache.DeleteAll()
+
+	t.Cleanup(func() {
+		getIPsFromHostname, getMaxMindASN = oldIPs, oldASN
+		serverNetworkInfoCache.DeleteAll()
+		server_utils.ResetTestState()
+		siteNetworksMutex.Lock()
+		siteNetworks = nil
+		siteNetworksMutex.Unlock()
+	})
+}
+
+func TestConfigSiteNetworks(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+
+	t.Run("valid-config", func(t *testing.T) {
+		setupNetworkLocality(t, []map[string]any{
+			{"Name": "campus", "Networks": []string{"10.0.0.0/8", "2001:db8::/32"}},
+			{"Name": "dept", "Networks": []string{"10.1.0.0/16", "192.0.2.7"}, "Caches": []string{"dept-cache"}},
+		}, nil, nil)
+
+		assert.True(t, networkLocalityEnabled())
+		assert.Equal(t, "campus", getSiteForAddr(netip.MustParseAddr("10.2.3.4")).name)
+		// The most specific prefix wins
+		assert.Equal(t, "dept", getSiteForAddr(netip.MustParseAddr("10.1.3.4")).name)
+		// IPv4-mapped IPv6 client addresses match IPv4 networks
+		assert.Equal(t, "dept", getSiteForAddr(netip.MustParseAddr("::ffff:192.0.2.7")).name)
+		assert.Equal(t, "campus", getSiteForAddr(netip.MustParseAddr("2001:db8::1")).name)
+		assert.Nil(t, getSiteForAddr(netip.MustParseAddr("192.0.2.8")))
+	})
+
+	t.Run("bad-network", func(t *testing.T) {
+		setupNetworkLocality(t, nil, nil, nil)
+		require.NoError(t, param.Director_SiteNetworks.Set([]map[string]any{
+			{"Name": "campus", "Networks": []string{"10.0.0.0/33"}},
+		}))
+		err := ConfigSiteNetworks()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid network")
+	})
+
+	t.Run("duplicate-names", func(t *testing.T) {
+		setupNetworkLocality(t, nil, nil, nil)
+		require.NoError(t, param.Director_SiteNetworks.Set([]map[string]any{
+			{"Name": "dup", "Networks": []string{"10.0.0.0/8"}},
+			{"Name": "dup", "Networks": []string{"11.0.0.0/8"}},
+		}))
+		err := ConfigSiteNetworks()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "duplicate site name")
+	})
+
+	t.Run("unconfigured", func(t *testing.T) {
+		setupNetworkLocality(t, nil, nil, nil)
+		assert.False(t, networkLocalityEnabled())
+	})
+}
+
+func TestNetworkLocalityWeightFn(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	setupNetworkLocality(t,
+		[]map[string]any{
+			{"Name": "campus", "Networks": []string{"10.0.0.0/8", "2001:db8:1::/48"}, "Caches": []string{"named-cache"}},
+		},
+		map[string][]string{
+			"named-cache": {"203.0.113.1"},
+			"v6-cache":    {"198.51.100.1", "2001:db8:1::10"},
+			"isp-cache":   {"2001:db8:2::10"},
+			"far-cache":   {"203.0.113.99"},
+		},
+		map[string]uint{"2001:db8:2::/48": 64500, "192.0.2.0/24": 64500},
+	)
+	require.NoError(t, param.Director_EnableASNLocality.Set(true))
+
+	campusClient := getClientLocality(netip.MustParseAddr("10.1.2.3"))
+	require.NotNil(t, campusClient.site)
+	ispClient := getClientLocality(netip.MustParseAddr("192.0.2.10"))
+	assert.Nil(t, ispClient.site)
+	assert.Equal(t, uint(64500), ispClient.asn)
+
+	for _, tc := range []struct {
+		client   clientLocality
+		cache    string
+		expected float64
+	}{
+		{campusClient, "named-cache", 100},
+		// Matched on the cache's IPv6 address
+		{campusClient, "v6-cache", 100},
+		{campusClient, "isp-cache", 1},
+		{ispClient, "isp-cache", 10},
+		{ispClient, "far-cache", 1},
+		// Servers that can't be resolved aren't local, but still get a valid weight
+		{ispClient, "unresolvable-cache", 1},
+		{clientLocality{}, "named-cache", 1},
+	} {
+		ad := getAdBase(tc.cache, 0, 0)
+		w, ok := networkLocalityWeightFn(tc.client, ad)
+		assert.True(t, ok)
+		assert.Equal(t, tc.expected, w, "client %+v, cache %s", tc.client, tc.cache)
+	}
+}
+
+func TestAdaptiveSortWithNetworkLocality(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	setupOverrideCache(t) // will map 192.168.1.4 --> Discovery building's lat/long
+	setupNetworkLocality(t,
+		[]map[string]any{{"Name": "campus", "Networks": []string{"192.168.0.0/16"}, "Caches": []string{"site-cache"}}},
+		map[string][]string{},
+		nil,
+	)
+	require.NoError(t, param.Director_AdaptiveSortTruncateConstant.Set(2))
+
+	// The site cache geolocates to the other side of the world, so it would normally
+	// be truncated out of the working set
+	near1 := getAdBase("near1", 43.07296, -89.40831)
+	near2 := getAdBase("near2", 43.07296, -89.40831)
+	siteCache := getAdBase("site-cache", -33.8688, 151.2093)
+	for _, ad := range []*server_structs.ServerAd{&near1, &near2, &siteCache} {
+		ad.StatusWeight = 1
+	}
+	sAds := []server_structs.ServerAd{near1, near2, siteCache}
+
+	siteFirst := 0
+	for range 200 {
+		sCtx := SortContext{
+			Ctx:          context.Background(),
+			ClientAddr:   netip.MustParseAddr("192.168.1.4"),
+			RedirectInfo: &server_structs.RedirectInfo{},
+		}
+		sorted, err := (&AdaptiveSort{}).Sort(sAds, sCtx)
+		require.NoError(t, err)
+		require.Len(t, sorted, 2)
+		if sorted[0].Name == "site-cache" {
+			siteFirst++
+		}
+		assert.Equal(t, "campus", sCtx.RedirectInfo.ClientInfo.Site)
+		assert.Equal(t, 100.0, sCtx.RedirectInfo.ServersInfo[siteCache.URL.String()].RedirectWeights.NetworkWeight)
+	}
+	// The site cache is treated as nearby, so its locality factor wins most of the time
+	assert.Greater(t, siteFirst, 150)
+
+	// Sort policies can use the same preference
+	sp, err := NewSortPolicy(SortPolicyConfig{
+		Name:           "site-first",
+		Weights:        []SortPolicyWeightConfig{{Type: policyWeightNetwork}, {Type: policyWeightDistance}},
+		Order:          policyOrderDescending,
+		WorkingSetSize: 2,
+	})
+	require.NoError(t, err)
+	sCtx := SortContext{
+		Ctx:          context.Background(),
+		ClientAddr:   netip.MustParseAddr("192.168.1.4"),
+		RedirectInfo: &server_structs.RedirectInfo{},
+	}
+	sorted, err := sp.Sort(sAds, sCtx)
+	require.NoError(t, err)
+	require.Len(t, sorted, 2)
+	assert.Equal(t, "site-cache", sorted[0].Name)
+	assert.Equal(t, 100.0, sCtx.RedirectInfo.ServersInfo[siteCache.URL.String()].RedirectWeights.NetworkWeight)
+}
@@ -103,6 +103,22 @@ func checkNamespaceStatus(prefix string, registryWebUrlStr string) (bool, error)
 	return resBody.Approved, nil
 }
 
+// Get the keys hosted at keyLoc, using the namespaceKeys cache when possible
+func getCachedJwks(ctx context.Context, keyLoc string) (jwk.Set, error) {
+	log.Debugln("Attempting to fetch keys from ", keyLoc)
+	item := namespaceKeys.Get(keyLoc)
+	if item != nil && !item.IsExpired() {
+		return item.Value(), nil
+	}
+
+	keyset, err := utils.GetJwks(ctx, config.GetTransport(), keyLoc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get jwks at %s", keyLoc)
+	}
+	namespaceKeys.Set(keyLoc, keyset, param.Director_AdvertisementTTL.GetDuration())
+	return keyset, nil
+}
+
 // Given a token and a location in the namespace to advertise in,
 // see if the entity is authorized to advertise an origin for the
 // namespace
@@ -132,23 +148,9 @@ func verifyAdvertiseToken(ctx context.Context, tokenStr, namespace string) (bool
 		return false, adminApprovalErr
 	}
 
-	log.Debugln("Attempting to fetch keys from ", keyLoc)
-	var keyset jwk.Set
-
-	item := namespaceKeys.Get(keyLoc)
-	if item != nil {
-		if !item.IsExpired() {
-			keyset = item.Value()
-		}
-	}
-
-	if keyset == nil {
-		tr := config.GetTransport()
-		keyset, err = utils.GetJwks(ctx, tr, keyLoc)
-		if err != nil {
-			return false, errors.Wrapf(err, "failed to get jwks at %s", keyLoc)
-		}
-		namespaceKeys.Set(keyLoc, keyset, param.Director_AdvertisementTTL.GetDuration())
+	keyset, err := getCachedJwks(ctx, keyLoc)
+	if err != nil {
+		return false, err
 	}
 
 	tok, err := token.VerifyWithKeyset(tokenStr, keyset)
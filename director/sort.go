@@ -25,6 +25,7 @@ import (
 	"path"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -55,6 +56,8 @@ type (
 		NamespaceAd  server_structs.NamespaceAd
 		RequestId    uuid.UUID
 		IsOriginSort bool
+		// The requested object path, used by ConsistentHashSort to pick a server
+		ObjectPath string
 	}
 
 	// A function type for filtering ads -- given a request and an ad, it should
@@ -63,6 +66,12 @@ type (
 	// A collection of these are used during Director matchmaking to produce the list of
 	// caches/origins that can fulfill the request.
 	AdPredicate func(ctx *gin.Context, ad copyAd) bool
+
+	// An AdPredicate paired with a name, used to explain which predicates rejected an ad
+	namedAdPredicate struct {
+		name string
+		pred AdPredicate
+	}
 )
 
 // Constants for director sorting algorithms
@@ -75,10 +84,13 @@ const (
 //   - distanceAndLoad: sort serverAds by the distance with gated halving factor (see details in the adaptive method)
 //     and the server IO load
 //   - random: sort serverAds randomly
+//   - consistentHash: sort the closest serverAds using rendezvous hashing on the object path or namespace
 //   - adaptive:  sort serverAds based on rules discussed in these places:
 //   - https://github.com/PelicanPlatform/pelican/discussions/1198
 //   - https://docs.google.com/document/d/e/2PACX-1vQg9biPzp3RbC5qVuJFvgMZHgIM-nw92JzjHkGl-h7djeNNXa68ckv2rAqtXEDYe8QvXL3oX0Fr0-bp/pub
 //
+// If a sort policy from Director.SortPolicies matches the namespace, it's used instead of the configured method.
+//
 // Note that if the client IP isn't overridden and MaxMind cannot resolve accurate coordinates for it, the client's
 // coordinate is randomly assigned within the contiguous US and cached for re-use. This means that distance-based sorts
 // will be effectively random the first time, but subsequent requests within a short time period will still likely
@@ -98,24 +110,40 @@ func sortServerAds(ctx context.Context, ginCtx *gin.Context, clientAddr netip.Ad
 		RequestId:       requestId,
 		IsOriginSort:    isOriginSort,
 	}
+	if ginCtx != nil {
+		sortContext.ObjectPath = getObjectPathFromRequest(ginCtx)
+	}
 	var sortAlg SortAlgorithm
-	switch sortMethod {
-	case server_structs.DistanceType:
-		sortAlg = &DistanceSort{}
-	case server_structs.DistanceAndLoadType: // currently a place holder for distance (per our parameters.yaml docs)
-		sortAlg = &DistanceSort{}
-	case server_structs.AdaptiveType:
-		sortAlg = &AdaptiveSort{}
-	case server_structs.RandomType:
-		sortAlg = &RandomSort{}
-	default:
-		// Never say never, but this should never get hit because we validate the value on Director startup.
-		// The only real way to get here is through writing bad unit tests.
-		return nil, errors.Errorf("invalid sort method '%s' set in %s", param.Director_CacheSortMethod.GetString(), param.Director_CacheSortMethod.GetName())
+	if policy := getSortPolicy(nsAd.Path, isOriginSort); policy != nil {
+		// A configured sort policy for the namespace takes precedence over Director.CacheSortMethod
+		sortMethod = server_structs.PolicyType
+		redirectInfo.DirectorSortMethod = sortMethod.String()
+		sortAlg = policy
+	} else {
+		switch sortMethod {
+		case server_structs.DistanceType:
+			sortAlg = &DistanceSort{}
+		case server_structs.DistanceAndLoadType: // currently a place holder for distance (per our parameters.yaml docs)
+			sortAlg = &DistanceSort{}
+		case server_structs.AdaptiveType:
+			sortAlg = &AdaptiveSort{}
+		case server_structs.RandomType:
+			sortAlg = &RandomSort{}
+		case server_structs.ConsistentHashType:
+			sortAlg = &ConsistentHashSort{}
+		default:
+			// Never say never, but this should never get hit because we validate the value on Director startup.
+			// The only real way to get here is through writing bad unit tests.
+			return nil, errors.Errorf("invalid sort method '%s' set in %s", param.Director_CacheSortMethod.GetString(), param.Director_CacheSortMethod.GetName())
+		}
 	}
 
 	sortedAds, err := sortAlg.Sort(ads, sortContext)
-	if err != nil {
+	if err != nil && sortMethod == server_structs.PolicyType {
+		// A policy's filters decide which servers may be used at all, so falling back to a
+		// generic sort over every ad would hand out exactly the servers the policy excluded.
+		return nil, errors.Wrapf(err, "failed to sort server ads using %s", sortMethod.String())
+	} else if err != nil {
 		// Use fallbacks that are less likely to produce errors (Distance, then Random)
 		var fallbackMethod server_structs.SortType
 		if sortMethod != server_structs.DistanceType && sortMethod != server_structs.RandomType {
@@ -231,6 +259,29 @@ func getAdsForPath(reqPath string) (oAds []copyAd, cAds []copyAd) {
 	return oAds, cAds
 }
 
+// Combine the redirect info generated while sorting origins and caches into a single
+// struct. Per-server info is keyed by URL so it never collides; the client info and sort
+// method come from the cache sort when caches were sorted, since that's the sort that
+// determines where the client is redirected for object requests.
+func mergeRedirectInfo(clientIP string, originInfo, cacheInfo *server_structs.RedirectInfo, preferCache bool) *server_structs.RedirectInfo {
+	merged := server_structs.NewRedirectInfoFromIP(clientIP)
+	primary, secondary := originInfo, cacheInfo
+	if preferCache {
+		primary, secondary = cacheInfo, originInfo
+	}
+	for _, info := range []*server_structs.RedirectInfo{secondary, primary} {
+		if info.DirectorSortMethod != "" {
+			merged.ClientInfo = info.ClientInfo
+			merged.DirectorSortMethod = info.DirectorSortMethod
+			merged.DirectorSortPolicy = info.DirectorSortPolicy
+		}
+		for url, sInfo := range info.ServersInfo {
+			merged.ServersInfo[url] = sInfo
+		}
+	}
+	return merged
+}
+
 // allPass returns true if the ad satisfies all predicates.
 func allPredicatesPass(ctx *gin.Context, ad copyAd, preds ...AdPredicate) bool {
 	for _, pred := range preds {
@@ -241,6 +292,15 @@ func allPredicatesPass(ctx *gin.Context, ad copyAd, preds ...AdPredicate) bool {
 	return true
 }
 
+// Strip the names from a slice of named predicates
+func predicatesOf(named []namedAdPredicate) []AdPredicate {
+	preds := make([]AdPredicate, 0, len(named))
+	for _, np := range named {
+		preds = append(preds, np.pred)
+	}
+	return preds
+}
+
 // ORIGIN FILTERING PREDICATES
 
 // Filter out origins that don't support the incoming request verb. For example,
@@ -380,6 +440,26 @@ func cacheNotInErrorState() AdPredicate {
 	}
 }
 
+// If clients have recently reported that too many of their transfers through the cache
+// failed, the cache should only be used once the others have been tried.
+func cacheNotReportedFailing() AdPredicate {
+	return func(ctx *gin.Context, ad copyAd) bool {
+		return !cacheIsReportedFailing(ad.ServerAd.URL.String(), time.Now())
+	}
+}
+
+// Split the ads into those that pass the predicate and those that don't, preserving their order
+func partitionAds(ctx *gin.Context, ads []copyAd, pred AdPredicate) (pass, fail []copyAd) {
+	for _, ad := range ads {
+		if pred(ctx, ad) {
+			pass = append(pass, ad)
+		} else {
+			fail = append(fail, ad)
+		}
+	}
+	return
+}
+
 // classifyAds is a generic helper to classify ads into groups in a single pass.
 // It first applies the common predicates (for example, topology, err state) to every ad.
 // Then, for each ad that passes the common check, it tests a list of group predicates.
@@ -419,6 +499,12 @@ func getSortedAds(ctx *gin.Context, requestId uuid.UUID) (sortedOrigins, sortedC
 	reqVerb := ctx.Request.Method
 	originAds, cacheAds := getAdsForPath(reqPath)
 
+	// Only populated when an admin has asked the Director to explain its decision; all
+	// of its methods are no-ops on a nil explanation.
+	explanation := getRedirectExplanation(ctx)
+	explanation.addCandidates(true, originAds)
+	explanation.addCandidates(false, cacheAds)
+
 	// If there are no matching origin ads, then we also assume no caches should be serving the object as shutting
 	// down the origin(s) is the same as unplugging from the federation.
 	if len(originAds) == 0 {
@@ -433,11 +519,12 @@ func getSortedAds(ctx *gin.Context, requestId uuid.UUID) (sortedOrigins, sortedC
 	// Of the origins supporting the path, filter out those that don't support some other
 	// aspect of this request, e.g. trying to PUT to an origin/namespace that only supports
 	// GETs.
-	originPredicates := []AdPredicate{
-		originSupportsVerb(reqVerb),
-		originSupportsQuery(),
+	originPredicates := []namedAdPredicate{
+		{"originSupportsVerb", originSupportsVerb(reqVerb)},
+		{"originSupportsQuery", originSupportsQuery()},
 	}
-	sortedOrigins = filterOrigins(ctx, originAds, originPredicates...)
+	explanation.recordPredicates(ctx, true, originAds, originPredicates)
+	sortedOrigins = filterOrigins(ctx, originAds, predicatesOf(originPredicates)...)
 	if len(sortedOrigins) == 0 {
 		// Since caches are supposed to act on behalf of origins, the fact that there are no
 		// origins capable of supporting the request means we can fail early.
@@ -450,6 +537,7 @@ func getSortedAds(ctx *gin.Context, requestId uuid.UUID) (sortedOrigins, sortedC
 	// only those that support the union of all required features for all Origins.
 	requiredFeatures := computeFeaturesUnion(sortedOrigins)
 	log.Tracef("Request %s for path %s requires features %v", requestId, reqPath, requiredFeatures)
+	explanation.setRequiredFeatures(requiredFeatures)
 
 	// Now use predicate filtering against caches. This is more nuanced than origins,
 	// and the predicates are broken into three groups:
@@ -457,13 +545,25 @@ func getSortedAds(ctx *gin.Context, requestId uuid.UUID) (sortedOrigins, sortedC
 	// 2. Supported predicates: if the cache passes the common predicate, we can mark whether we know it supports a feature.
 	// 3. Unknown predicates: if the cache passes the common predicate but we don't know if it supports a feature, we can
 	//    mark it as unknown.
-	commonPredicates := []AdPredicate{cacheNotFromTopoIfPubReads()}
+	commonPredicates := []namedAdPredicate{{"cacheNotFromTopoIfPubReads", cacheNotFromTopoIfPubReads()}}
 	if param.Director_FilterCachesInErrorState.GetBool() {
-		commonPredicates = append(commonPredicates, cacheNotInErrorState())
+		commonPredicates = append(commonPredicates, namedAdPredicate{"cacheNotInErrorState", cacheNotInErrorState()})
+	}
+	supportedPredicates := []namedAdPredicate{{"cacheSupportsFeature", cacheSupportsFeature(requiredFeatures)}}
+	unknownPredicates := []namedAdPredicate{{"cacheMightSupportFeature", cacheMightSupportFeature(requiredFeatures)}}
+	explanation.recordCachePredicates(ctx, cacheAds, commonPredicates, supportedPredicates, unknownPredicates)
+	sortedCaches, unknownCaches := filterCaches(ctx, cacheAds, predicatesOf(commonPredicates), predicatesOf(supportedPredicates), predicatesOf(unknownPredicates))
+
+	// Caches that clients report are failing their transfers aren't sorted; they're placed after
+	// the sorted caches as a last resort.  If every cache is failing, there's nothing better to
+	// send clients to, so they're sorted as usual.
+	var deprioritizedCaches []copyAd
+	if healthy, failing := partitionAds(ctx, sortedCaches, cacheNotReportedFailing()); len(healthy) > 0 && len(failing) > 0 {
+		sortedCaches, deprioritizedCaches = healthy, failing
+		for _, c := range failing {
+			explanation.recordDeprioritization(false, c.ServerAd.URL.String(), "cacheNotReportedFailing")
+		}
 	}
-	supportedPredicates := []AdPredicate{cacheSupportsFeature(requiredFeatures)}
-	unknownPredicates := []AdPredicate{cacheMightSupportFeature(requiredFeatures)}
-	sortedCaches, unknownCaches := filterCaches(ctx, cacheAds, commonPredicates, supportedPredicates, unknownPredicates)
 
 	// Avoid sorting any slices we don't need to
 	shouldSortOrigins := isOriginRequest(ctx)
@@ -527,6 +627,8 @@ func getSortedAds(ctx *gin.Context, requestId uuid.UUID) (sortedOrigins, sortedC
 			if originAvailabilityMap[o.ServerAd.URL.String()] {
 				filteredOrigins = append(filteredOrigins, o)
 				filteredOServAds = append(filteredOServAds, oServAds[i])
+			} else {
+				explanation.recordRemoval(true, o.ServerAd.URL.String(), "originHasObject")
 			}
 		}
 		if len(filteredOrigins) > 0 {
@@ -545,14 +647,18 @@ func getSortedAds(ctx *gin.Context, requestId uuid.UUID) (sortedOrigins, sortedC
 		utils.ExtractProjectFromUserAgent(ctx.Request.Header.Values("User-Agent")))
 	var wg sync.WaitGroup
 	var lastError error
-	redirectInfo := server_structs.NewRedirectInfoFromIP(utils.ClientIPAddr(ctx).String())
+	// Origins and caches may be sorted concurrently, so each sort gets its own redirect info
+	// that's merged once both are done.
+	clientIP := utils.ClientIPAddr(ctx).String()
+	originRedirectInfo := server_structs.NewRedirectInfoFromIP(clientIP)
+	cacheRedirectInfo := server_structs.NewRedirectInfoFromIP(clientIP)
 	if shouldSortOrigins {
 		log.Tracef("Sorting origins for request %s for path %s", requestId.String(), reqPath)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 
-			sortedServerAds, err := sortServerAds(pCtx, ctx, utils.ClientIPAddr(ctx), oServAds, nsAd, requestId, true, originAvailabilityMap, redirectInfo)
+			sortedServerAds, err := sortServerAds(pCtx, ctx, utils.ClientIPAddr(ctx), oServAds, nsAd, requestId, true, originAvailabilityMap, originRedirectInfo)
 			if err != nil {
 				lastError = errors.Wrap(err, "failed to sort origins")
 				return
@@ -580,7 +686,7 @@ func getSortedAds(ctx *gin.Context, requestId uuid.UUID) (sortedOrigins, sortedC
 		go func() {
 			defer wg.Done()
 
-			sortedServerAds, err := sortServerAds(pCtx, ctx, utils.ClientIPAddr(ctx), cServAds, nsAd, requestId, false, nil, redirectInfo)
+			sortedServerAds, err := sortServerAds(pCtx, ctx, utils.ClientIPAddr(ctx), cServAds, nsAd, requestId, false, nil, cacheRedirectInfo)
 			if err != nil {
 				lastError = errors.Wrap(err, "failed to sort caches")
 				return
@@ -606,14 +712,23 @@ func getSortedAds(ctx *gin.Context, requestId uuid.UUID) (sortedOrigins, sortedC
 		return nil, nil, lastError
 	}
 
+	if shouldSortOrigins {
+		explanation.recordSort(true, sortedOrigins, originRedirectInfo)
+	}
+	if shouldSortCaches {
+		explanation.recordSort(false, sortedCaches, cacheRedirectInfo)
+	}
+	redirectInfo := mergeRedirectInfo(clientIP, originRedirectInfo, cacheRedirectInfo, shouldSortCaches)
+
 	// Provide redirect debugging info if asked to. This gets set in the context and should be retrieved
 	// by redirectTo{Cache/Origin}
 	if ctx.GetHeader("X-Pelican-Debug") == "true" {
 		ctx.Set("redirectInfo", redirectInfo)
 	}
 
-	// Append unknown caches to the end of the sorted caches list since we don't know whether they'll
-	// function or not.
+	// Append deprioritized caches, then unknown caches to the end of the sorted caches list since
+	// we don't know whether they'll function or not.
+	sortedCaches = append(sortedCaches, deprioritizedCaches...)
 	sortedCaches = append(sortedCaches, unknownCaches...)
 
 	return sortedOrigins, sortedCaches, nil
@@ -21,6 +21,7 @@ package director
 import (
 	"cmp"
 	"fmt"
+	"hash/fnv"
 	"math/rand"
 	"slices"
 
@@ -383,7 +384,8 @@ func (ds *DistanceSort) Sort(sAds []server_structs.ServerAd, sCtx SortContext) (
 	return dWeights.GetSortedAds(sAds, smSortDescending), nil
 }
 
-// An adaptive sort that combines multiple factors: distance, IO load, status weight, and availability.
+// An adaptive sort that combines multiple factors: distance, IO load, status weight, and availability,
+// plus the director's own cache probe measurements when Director.AdaptiveSortUseCacheProbes is set.
 // See:
 //   - https://github.com/PelicanPlatform/pelican/discussions/1198
 //   - https://docs.google.com/document/d/e/2PACX-1vQg9biPzp3RbC5qVuJFvgMZHgIM-nw92JzjHkGl-h7djeNNXa68ckv2rAqtXEDYe8QvXL3oX0Fr0-bp/pub
@@ -430,44 +432,48 @@ func (as *AdaptiveSort) Sort(sAds []server_structs.ServerAd, sCtx SortContext) (
 		return nil
 	}
 
+	// Servers at the client's site or in its autonomous system are known to be near the
+	// client on the network, which trumps wherever the client's address geolocates. They're
+	// treated as being nearby so they make it into the working set.
+	useLocality := networkLocalityEnabled()
+	var lWeights SwapMaps
+	if useLocality {
+		locality := getClientLocality(sCtx.ClientAddr)
+		locality.record(sCtx.RedirectInfo)
+		lWeights = computeWeights(sAds, func(_ int, ad server_structs.ServerAd) (float64, bool) {
+			return networkLocalityWeightFn(locality, ad)
+		})
+	}
+
 	// Sort first by distance -- we'll only keep the top N ads (configurable)
 	// from this sort to use for the rest of the algorithm
-	dWeights := computeWeights(sAds, func(_ int, ad server_structs.ServerAd) (float64, bool) {
+	dWeights := computeWeights(sAds, func(idx int, ad server_structs.ServerAd) (float64, bool) {
+		if useLocality && lWeights[idx].Weight > 1 {
+			return 1.0, true
+		}
 		return distanceWeightFn(clientCoord.Lat, clientCoord.Long, ad.Latitude, ad.Longitude)
 	})
+	tWeights := slices.Clone(dWeights)
+	if useLocality {
+		for idx := range tWeights {
+			tWeights[idx].Weight *= lWeights[idx].Weight
+		}
+	}
 
-	dWeights.smSortDescending()
+	tWeights.smSortDescending()
 
 	sourceWorkingSetSize := param.Director_AdaptiveSortTruncateConstant.GetInt()
 	// Shrink down to the working set size
-	shrinkTo := min(sourceWorkingSetSize, len(dWeights))
-	dWeights = dWeights[:shrinkTo]
-	workingSet := dWeights.GetSortedAds(sAds, smSortDescending)
+	shrinkTo := min(sourceWorkingSetSize, len(tWeights))
+	tWeights = tWeights[:shrinkTo]
+	workingSet := tWeights.GetSortedAds(sAds, smSortDescending)
 
 	// Generate the availability map for just the working set. This is the key optimization:
 	// stat requests only go to the N closest servers after the distance-based truncation,
 	// rather than all servers that match the namespace.
-	var workingAvailMap map[string]bool
-	if sCtx.AvailabilityMap != nil {
-		// Override provided (e.g., in tests) — use directly without issuing stat queries.
-		workingAvailMap = sCtx.AvailabilityMap
-	} else if sCtx.GinCtx != nil {
-		var genErr error
-		if sCtx.IsOriginSort {
-			workingAvailMap, _, genErr = generateAvailabilityMaps(sCtx.GinCtx, workingSet, nil, sCtx.NamespaceAd, sCtx.RequestId)
-		} else {
-			_, workingAvailMap, genErr = generateAvailabilityMaps(sCtx.GinCtx, nil, workingSet, sCtx.NamespaceAd, sCtx.RequestId)
-		}
-		if genErr != nil {
-			if _, ok := genErr.(objectNotFoundErr); ok {
-				return nil, genErr
-			}
-			// Non-objectNotFound stat errors should not propagate to the client.
-			// Log and fall through with nil workingAvailMap (neutral weights).
-			log.Warningf("Request %s: Stat failed during sort, proceeding with neutral availability: %v",
-				sCtx.RequestId.String(), genErr)
-			workingAvailMap = nil
-		}
+	workingAvailMap, err := getWorkingSetAvailability(workingSet, sCtx)
+	if err != nil {
+		return nil, err
 	}
 	// workingAvailMap == nil means no availability info; neutral weights will be used.
 
@@ -475,7 +481,10 @@ func (as *AdaptiveSort) Sort(sAds []server_structs.ServerAd, sCtx SortContext) (
 	serverWeights := make([]*server_structs.RedirectWeights, len(workingSet))
 	for i := range workingSet {
 		serverWeights[i] = &server_structs.RedirectWeights{
-			DistanceWeight: dWeights[i].Weight,
+			DistanceWeight: dWeights[tWeights[i].Index].Weight,
+		}
+		if useLocality {
+			serverWeights[i].NetworkWeight = lWeights[tWeights[i].Index].Weight
 		}
 	}
 
@@ -509,11 +518,30 @@ func (as *AdaptiveSort) Sort(sAds []server_structs.ServerAd, sCtx SortContext) (
 		return nil, errors.Wrap(err, "unable to generate availability weights")
 	}
 
+	// probe weights, only when enabled since they come from the director's own measurements
+	useProbes := param.Director_AdaptiveSortUseCacheProbes.GetBool()
+	if useProbes {
+		if err := applyWeights("probe", workingSet, serverWeights,
+			func(_ int, ad server_structs.ServerAd) (float64, bool) {
+				return probeWeightFn(ad)
+			},
+			func(sw *server_structs.RedirectWeights, w float64) { sw.ProbeWeight = w },
+		); err != nil {
+			return nil, errors.Wrap(err, "unable to generate probe weights")
+		}
+	}
+
 	// Final weights from each raw weight
 	sCtx.RedirectInfo.ServersInfo = make(map[string]*server_structs.ServerRedirectInfo)
 	finalWeights := make(SwapMaps, len(workingSet))
 	for idx, weights := range serverWeights {
 		finalWeight := weights.DistanceWeight * weights.IOLoadWeight * weights.StatusWeight * weights.AvailabilityWeight
+		if useProbes {
+			finalWeight *= weights.ProbeWeight
+		}
+		if useLocality {
+			finalWeight *= weights.NetworkWeight
+		}
 		finalWeights[idx] = SwapMap{finalWeight, idx}
 
 		// populate the RedirectInfo
@@ -527,10 +555,121 @@ func (as *AdaptiveSort) Sort(sAds []server_structs.ServerAd, sCtx SortContext) (
 	return finalWeights.GetSortedAds(workingSet, smSortStochastic), nil
 }
 
+// A sort that sends requests for the same object to the same server. Servers are first sorted by
+// distance and truncated to a working set of the closest N (configurable), then ordered using
+// rendezvous (highest random weight) hashing on the object path or namespace prefix. Because each
+// server's score depends only on the key and the server itself, a server joining or leaving the
+// working set only moves the objects that rank it first; every other object keeps its server.
+type ConsistentHashSort struct{}
+
+const (
+	consistentHashKeyObject    = "object"
+	consistentHashKeyNamespace = "namespace"
+
+	// Label used for the hash score in the redirect info's policy weights
+	consistentHashWeightLabel = "consistentHash"
+)
+
+func (chs *ConsistentHashSort) Type() server_structs.SortType {
+	return server_structs.ConsistentHashType
+}
+func (chs *ConsistentHashSort) String() string {
+	return string(server_structs.ConsistentHashType)
+}
+func (chs *ConsistentHashSort) Sort(sAds []server_structs.ServerAd, sCtx SortContext) ([]server_structs.ServerAd, error) {
+	key := sCtx.ObjectPath
+	if param.Director_ConsistentHashKey.GetString() == consistentHashKeyNamespace || key == "" {
+		key = sCtx.NamespaceAd.Path
+	}
+	if key == "" {
+		return nil, errors.New("no object path or namespace available to hash")
+	}
+
+	clientCoord := getClientCoordinate(sCtx.Ctx, sCtx.ClientAddr, sCtx.GinCtx)
+	sCtx.RedirectInfo.ClientInfo.Coordinate = clientCoord
+
+	dWeights := computeWeights(sAds, func(_ int, ad server_structs.ServerAd) (float64, bool) {
+		return distanceWeightFn(clientCoord.Lat, clientCoord.Long, ad.Latitude, ad.Longitude)
+	})
+	dWeights.smSortDescending()
+	shrinkTo := min(param.Director_ConsistentHashWorkingSetSize.GetInt(), len(dWeights))
+	dWeights = dWeights[:shrinkTo]
+	workingSet := dWeights.GetSortedAds(sAds, smSortDescending)
+
+	sCtx.RedirectInfo.ServersInfo = make(map[string]*server_structs.ServerRedirectInfo)
+	hWeights := make(SwapMaps, len(workingSet))
+	for idx, ad := range workingSet {
+		score := rendezvousScore(key, ad.URL.String())
+		hWeights[idx] = SwapMap{Weight: score, Index: idx}
+
+		thisServer := &server_structs.ServerRedirectInfo{}
+		thisServer.RedirectWeights.DistanceWeight = dWeights[idx].Weight
+		thisServer.RedirectWeights.PolicyWeights = map[string]float64{consistentHashWeightLabel: score}
+		thisServer.Coordinate = ad.Coordinate
+		sCtx.RedirectInfo.ServersInfo[ad.URL.String()] = thisServer
+	}
+
+	return hWeights.GetSortedAds(workingSet, smSortDescending), nil
+}
+
+// Compute the rendezvous hashing score for a key/server pair as a float in (0, 1].
+// FNV-1a on its own has poor avalanche behavior for similar inputs (e.g. object paths
+// that differ only in their last character), so the hash is passed through the
+// SplitMix64 finalizer before being scaled.
+func rendezvousScore(key, server string) float64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(server))
+
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	// Use the top 53 bits so the result is exactly representable as a float64,
+	// and shift the range up by one so a score is never zero (zero weights are filtered)
+	return float64((x>>11)+1) / float64(uint64(1)<<53)
+}
+
 ///////////////////////////
 // OTHER MISC SORT STUFF //
 ///////////////////////////
 
+// Generate the availability map for a working set of server ads. If the SortContext
+// carries an availability override (e.g. in tests), it's used directly without issuing
+// stat queries. A nil map with a nil error means no availability information could be
+// gathered and callers should use neutral weights.
+func getWorkingSetAvailability(workingSet []server_structs.ServerAd, sCtx SortContext) (map[string]bool, error) {
+	if sCtx.AvailabilityMap != nil {
+		return sCtx.AvailabilityMap, nil
+	}
+	if sCtx.GinCtx == nil {
+		return nil, nil
+	}
+
+	var availMap map[string]bool
+	var err error
+	if sCtx.IsOriginSort {
+		availMap, _, err = generateAvailabilityMaps(sCtx.GinCtx, workingSet, nil, sCtx.NamespaceAd, sCtx.RequestId)
+	} else {
+		_, availMap, err = generateAvailabilityMaps(sCtx.GinCtx, nil, workingSet, sCtx.NamespaceAd, sCtx.RequestId)
+	}
+	if err != nil {
+		if _, ok := err.(objectNotFoundErr); ok {
+			return nil, err
+		}
+		// Non-objectNotFound stat errors should not propagate to the client.
+		// Log and fall through with a nil map (neutral weights).
+		log.Warningf("Request %s: Stat failed during sort, proceeding with neutral availability: %v",
+			sCtx.RequestId.String(), err)
+		return nil, nil
+	}
+	return availMap, nil
+}
+
 // Sort a list of ServerAds with the following rule:
 //   - if a ServerAds has FromTopology = true, then it will be moved to the end of the list
 //   - if two ServerAds has the SAME FromTopology value (both true or false), then break tie them by name
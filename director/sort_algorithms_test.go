@@ -20,6 +20,7 @@ package director
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"net/netip"
 	"net/url"
@@ -34,6 +35,7 @@ import (
 	"github.com/pelicanplatform/pelican/config"
 	"github.com/pelicanplatform/pelican/param"
 	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
 	"github.com/pelicanplatform/pelican/test_utils"
 )
 
@@ -1206,3 +1208,179 @@ func TestSortServerAdsByAvailability(t *testing.T) {
 	sortServerAdsByAvailability(randomOrder, avaiMap)
 	assert.EqualValues(t, expected, randomOrder)
 }
+
+func TestRendezvousScore(t *testing.T) {
+	// Scores must be deterministic, in (0, 1], and sensitive to both the key and the server
+	s1 := rendezvousScore("/foo/bar.txt", "https://cache1")
+	assert.Equal(t, s1, rendezvousScore("/foo/bar.txt", "https://cache1"))
+	assert.NotEqual(t, s1, rendezvousScore("/foo/bar.txu", "https://cache1"))
+	assert.NotEqual(t, s1, rendezvousScore("/foo/bar.txt", "https://cache2"))
+
+	for i := range 1000 {
+		s := rendezvousScore(fmt.Sprintf("/obj/%d", i), "https://cache")
+		assert.Greater(t, s, 0.0)
+		assert.LessOrEqual(t, s, 1.0)
+	}
+}
+
+func TestConsistentHashSortAlg(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+	setupOverrideCache(t) // will map 192.168.1.4 --> Discovery building's lat/long
+
+	require.NoError(t, param.Director_ConsistentHashWorkingSetSize.Set(3))
+	require.NoError(t, param.Director_ConsistentHashKey.Set(consistentHashKeyObject))
+
+	sAds := []server_structs.ServerAd{
+		getAdBase("Chicago", 41.8781, -87.6298),
+		getAdBase("Minneapolis", 44.9778, -93.2650),
+		getAdBase("StLouis", 38.6270, -90.1994),
+		getAdBase("LA", 34.0522, -118.2437),
+		getAdBase("Seattle", 47.6062, -122.3321),
+	}
+	newCtx := func(objectPath string) SortContext {
+		return SortContext{
+			Ctx:          context.Background(),
+			ClientAddr:   ipFromOverride,
+			RedirectInfo: &server_structs.RedirectInfo{},
+			NamespaceAd:  server_structs.NamespaceAd{Path: "/foo"},
+			ObjectPath:   objectPath,
+		}
+	}
+
+	sortAlg := &ConsistentHashSort{}
+	t.Run("same-object-same-order", func(t *testing.T) {
+		first, err := sortAlg.Sort(sAds, newCtx("/foo/bar.txt"))
+		require.NoError(t, err)
+		require.Len(t, first, 3)
+		for range 20 {
+			again, err := sortAlg.Sort(sAds, newCtx("/foo/bar.txt"))
+			require.NoError(t, err)
+			assert.Equal(t, first, again)
+		}
+	})
+
+	t.Run("only-nearby-servers", func(t *testing.T) {
+		for i := range 200 {
+			sorted, err := sortAlg.Sort(sAds, newCtx(fmt.Sprintf("/foo/%d", i)))
+			require.NoError(t, err)
+			for _, ad := range sorted {
+				assert.NotContains(t, []string{"LA", "Seattle"}, ad.Name)
+			}
+		}
+	})
+
+	t.Run("objects-spread-across-working-set", func(t *testing.T) {
+		counts := map[string]int{}
+		for i := range 3000 {
+			sorted, err := sortAlg.Sort(sAds, newCtx(fmt.Sprintf("/foo/%d", i)))
+			require.NoError(t, err)
+			counts[sorted[0].Name]++
+		}
+		require.Len(t, counts, 3)
+		for name, count := range counts {
+			// Each of the 3 servers should get roughly a third of the objects
+			assert.InDelta(t, 1000, count, 150, "server %s got an unbalanced share", name)
+		}
+	})
+
+	t.Run("namespace-key", func(t *testing.T) {
+		require.NoError(t, param.Director_ConsistentHashKey.Set(consistentHashKeyNamespace))
+		t.Cleanup(func() {
+			require.NoError(t, param.Director_ConsistentHashKey.Set(consistentHashKeyObject))
+		})
+
+		first, err := sortAlg.Sort(sAds, newCtx("/foo/a"))
+		require.NoError(t, err)
+		for i := range 50 {
+			sorted, err := sortAlg.Sort(sAds, newCtx(fmt.Sprintf("/foo/%d", i)))
+			require.NoError(t, err)
+			assert.Equal(t, first[0].Name, sorted[0].Name)
+		}
+	})
+
+	t.Run("redirect-info", func(t *testing.T) {
+		sCtx := newCtx("/foo/bar.txt")
+		_, err := sortAlg.Sort(sAds, sCtx)
+		require.NoError(t, err)
+		require.Len(t, sCtx.RedirectInfo.ServersInfo, 3)
+		for _, info := range sCtx.RedirectInfo.ServersInfo {
+			assert.Greater(t, info.RedirectWeights.DistanceWeight, 0.0)
+			assert.Contains(t, info.RedirectWeights.PolicyWeights, consistentHashWeightLabel)
+		}
+	})
+
+	t.Run("no-key", func(t *testing.T) {
+		sCtx := newCtx("")
+		sCtx.NamespaceAd.Path = ""
+		_, err := sortAlg.Sort(sAds, sCtx)
+		assert.Error(t, err)
+	})
+}
+
+func TestConsistentHashRedistribution(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+	setupOverrideCache(t)
+
+	// Use a working set large enough to hold every server so the test measures the
+	// hashing itself rather than changes in which servers are closest.
+	require.NoError(t, param.Director_ConsistentHashWorkingSetSize.Set(20))
+	require.NoError(t, param.Director_ConsistentHashKey.Set(consistentHashKeyObject))
+
+	const numObjects = 5000
+	const numServers = 10
+	sAds := make([]server_structs.ServerAd, 0, numServers+1)
+	for i := range numServers + 1 {
+		sAds = append(sAds, getAdBase(fmt.Sprintf("cache%d", i), 43.0+float64(i)*0.1, -89.0))
+	}
+
+	assignments := func(ads []server_structs.ServerAd) map[string]string {
+		sortAlg := &ConsistentHashSort{}
+		result := make(map[string]string, numObjects)
+		for i := range numObjects {
+			objPath := fmt.Sprintf("/foo/object-%d", i)
+			sorted, err := sortAlg.Sort(ads, SortContext{
+				Ctx:          context.Background(),
+				ClientAddr:   ipFromOverride,
+				RedirectInfo: &server_structs.RedirectInfo{},
+				ObjectPath:   objPath,
+			})
+			require.NoError(t, err)
+			result[objPath] = sorted[0].Name
+		}
+		return result
+	}
+
+	base := assignments(sAds[:numServers])
+
+	t.Run("server-leaves", func(t *testing.T) {
+		// Remove cache3; only objects that lived on cache3 may move
+		remaining := slices.Concat(sAds[:3], sAds[4:numServers])
+		after := assignments(remaining)
+		moved := 0
+		for obj, server := range base {
+			if after[obj] != server {
+				moved++
+				assert.Equal(t, "cache3", server, "object %s moved off of a server that didn't leave", obj)
+			}
+		}
+		// cache3 held roughly 1/10th of the objects
+		assert.InDelta(t, float64(numObjects)/numServers, float64(moved), float64(numObjects)*0.03)
+	})
+
+	t.Run("server-joins", func(t *testing.T) {
+		after := assignments(sAds)
+		moved := 0
+		for obj, server := range base {
+			if after[obj] != server {
+				moved++
+				assert.Equal(t, "cache10", after[obj], "object %s moved somewhere other than the new server", obj)
+			}
+		}
+		// The new server should take roughly 1/11th of the objects
+		assert.InDelta(t, float64(numObjects)/(numServers+1), float64(moved), float64(numObjects)*0.03)
+	})
+}
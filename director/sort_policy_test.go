@@ -0,0 +1,441 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+// Swap in a set of sort policies for the duration of a test
+func setSortPolicies(t *testing.T, configs ...SortPolicyConfig) {
+	policies := make([]*SortPolicy, 0, len(configs))
+	for _, pc := range configs {
+		sp, err := NewSortPolicy(pc)
+		require.NoError(t, err)
+		policies = append(policies, sp)
+	}
+
+	sortPoliciesMutex.Lock()
+	old := sortPolicies
+	sortPolicies = policies
+	sortPoliciesMutex.Unlock()
+	t.Cleanup(func() {
+		sortPoliciesMutex.Lock()
+		sortPolicies = old
+		sortPoliciesMutex.Unlock()
+	})
+}
+
+func TestNewSortPolicyValidation(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+
+	distance := []SortPolicyWeightConfig{{Type: policyWeightDistance}}
+	testCases := []struct {
+		name   string
+		config SortPolicyConfig
+		errMsg string
+	}{
+		{
+			name:   "valid minimal policy",
+			config: SortPolicyConfig{Name: "p", Weights: distance},
+		},
+		{
+			name:   "missing name",
+			config: SortPolicyConfig{Weights: distance},
+			errMsg: "missing a Name",
+		},
+		{
+			name:   "no weights",
+			config: SortPolicyConfig{Name: "p"},
+			errMsg: "at least one weight",
+		},
+		{
+			name:   "unknown weight",
+			config: SortPolicyConfig{Name: "p", Weights: []SortPolicyWeightConfig{{Type: "bogus"}}},
+			errMsg: "unknown weight type",
+		},
+		{
+			name:   "unknown filter",
+			config: SortPolicyConfig{Name: "p", Weights: distance, Filters: []SortPolicyFilterConfig{{Type: "bogus"}}},
+			errMsg: "unknown filter type",
+		},
+		{
+			name:   "bad order",
+			config: SortPolicyConfig{Name: "p", Weights: distance, Order: "sideways"},
+			errMsg: "invalid Order",
+		},
+		{
+			name:   "bad server type",
+			config: SortPolicyConfig{Name: "p", Weights: distance, ServerType: "registry"},
+			errMsg: "invalid ServerType",
+		},
+		{
+			name:   "relative namespace",
+			config: SortPolicyConfig{Name: "p", Weights: distance, Namespaces: []string{"foo"}},
+			errMsg: "absolute paths",
+		},
+		{
+			name:   "proximity without radius",
+			config: SortPolicyConfig{Name: "p", Weights: []SortPolicyWeightConfig{{Type: policyWeightProximity, Factor: 2}}},
+			errMsg: "positive Radius",
+		},
+		{
+			name:   "negative exponent",
+			config: SortPolicyConfig{Name: "p", Weights: []SortPolicyWeightConfig{{Type: policyWeightIOLoad, Exponent: -1}}},
+			errMsg: "negative Exponent",
+		},
+		{
+			name:   "status filter out of range",
+			config: SortPolicyConfig{Name: "p", Weights: distance, Filters: []SortPolicyFilterConfig{{Type: policyFilterMinStatusWeight, Value: 2}}},
+			errMsg: "range (0, 1]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewSortPolicy(tc.config)
+			if tc.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errMsg)
+			}
+		})
+	}
+
+	// Normalizing namespaces shouldn't modify the caller's config
+	namespaces := []string{"/foo/", "/bar/../baz"}
+	sp, err := NewSortPolicy(SortPolicyConfig{Name: "p", Namespaces: namespaces, Weights: distance})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/foo", "/baz"}, sp.Config.Namespaces)
+	assert.Equal(t, []string{"/foo/", "/bar/../baz"}, namespaces)
+}
+
+func TestConfigSortPolicies(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	t.Cleanup(func() {
+		server_utils.ResetTestState()
+		sortPoliciesMutex.Lock()
+		sortPolicies = nil
+		sortPoliciesMutex.Unlock()
+	})
+
+	t.Run("valid-config", func(t *testing.T) {
+		require.NoError(t, param.Director_SortPolicies.Set([]map[string]any{
+			{
+				"Name":       "ligo",
+				"Namespaces": []string{"/ligo/"},
+				"Filters":    []map[string]any{{"Type": "maxIOLoad", "Value": 500}},
+				"Weights":    []map[string]any{{"Type": "proximity", "Radius": 50, "Factor": 10}, {"Type": "ioLoad", "Exponent": 2}},
+				"Order":      "descending",
+			},
+		}))
+		require.NoError(t, ConfigSortPolicies())
+
+		sp := getSortPolicy("/ligo/frames", false)
+		require.NotNil(t, sp)
+		assert.Equal(t, "ligo", sp.Config.Name)
+		assert.Equal(t, []string{"/ligo"}, sp.Config.Namespaces)
+		assert.Nil(t, getSortPolicy("/ligoX", false))
+	})
+
+	t.Run("duplicate-names", func(t *testing.T) {
+		require.NoError(t, param.Director_SortPolicies.Set([]map[string]any{
+			{"Name": "dup", "Weights": []map[string]any{{"Type": "distance"}}},
+			{"Name": "dup", "Weights": []map[string]any{{"Type": "ioLoad"}}},
+		}))
+		err := ConfigSortPolicies()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "duplicate policy name")
+	})
+}
+
+func TestGetSortPolicy(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+
+	weights := []SortPolicyWeightConfig{{Type: policyWeightDistance}}
+	setSortPolicies(t,
+		SortPolicyConfig{Name: "default", Weights: weights},
+		SortPolicyConfig{Name: "foo", Namespaces: []string{"/foo"}, Weights: weights},
+		SortPolicyConfig{Name: "foo-bar", Namespaces: []string{"/foo/bar"}, Weights: weights},
+		SortPolicyConfig{Name: "foo-origins", Namespaces: []string{"/foo"}, ServerType: "origin", Weights: weights},
+	)
+
+	testCases := []struct {
+		nsPath   string
+		isOrigin bool
+		expected string
+	}{
+		{"/foo", false, "foo"},
+		{"/foo/baz", false, "foo"},
+		{"/foo/bar", false, "foo-bar"},
+		{"/foo/bar/baz", true, "foo-bar"},
+		{"/foobar", false, "default"},
+		{"/other", true, "default"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.nsPath, func(t *testing.T) {
+			sp := getSortPolicy(tc.nsPath, tc.isOrigin)
+			require.NotNil(t, sp)
+			assert.Equal(t, tc.expected, sp.Config.Name)
+		})
+	}
+
+	// Ties between equally-specific namespaces go to the first-configured policy,
+	// but server type restrictions are honored
+	sp := getSortPolicy("/foo/x", true)
+	require.NotNil(t, sp)
+	assert.Equal(t, "foo", sp.Config.Name)
+}
+
+func TestSortPolicySort(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	setupOverrideCache(t) // will map 192.168.1.4 --> Discovery building's lat/long
+
+	getAd := func(name string, lat, long, ioLoad, sWeight float64) server_structs.ServerAd {
+		ad := getAdBase(name, lat, long)
+		ad.IOLoad = ioLoad
+		ad.StatusWeight = sWeight
+		return ad
+	}
+	ads := []server_structs.ServerAd{
+		getAd("Madison", 43.0731, -89.4012, 900, 1.0),
+		getAd("Chicago", 41.8781, -87.6298, 0, 1.0),
+		getAd("NYC", 40.7128, -74.0060, 0, 1.0),
+		getAd("LA", 34.0522, -118.2437, 0, 0.5),
+	}
+	newCtx := func() SortContext {
+		return SortContext{
+			Ctx:          context.Background(),
+			ClientAddr:   ipFromOverride,
+			RedirectInfo: server_structs.NewRedirectInfoFromIP(ipFromOverride.String()),
+		}
+	}
+	names := func(ads []server_structs.ServerAd) []string {
+		out := make([]string, 0, len(ads))
+		for _, ad := range ads {
+			out = append(out, ad.Name)
+		}
+		return out
+	}
+
+	t.Run("filters-remove-servers", func(t *testing.T) {
+		sp, err := NewSortPolicy(SortPolicyConfig{
+			Name: "filtered",
+			Filters: []SortPolicyFilterConfig{
+				{Type: policyFilterMaxIOLoad, Value: 500},
+				{Type: policyFilterExcludeServers, Servers: []string{"LA"}},
+			},
+			Weights: []SortPolicyWeightConfig{{Type: policyWeightDistance}},
+			Order:   policyOrderDescending,
+		})
+		require.NoError(t, err)
+
+		sCtx := newCtx()
+		sorted, err := sp.Sort(ads, sCtx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Chicago", "NYC"}, names(sorted))
+		assert.Equal(t, "filtered", sCtx.RedirectInfo.DirectorSortPolicy)
+		assert.Len(t, sCtx.RedirectInfo.ServersInfo, 2)
+	})
+
+	t.Run("proximity-dominates-load", func(t *testing.T) {
+		// Madison is heavily loaded, but it's the only server on site and the
+		// proximity weight is configured to outweigh the load penalty
+		sp, err := NewSortPolicy(SortPolicyConfig{
+			Name: "site-first",
+			Weights: []SortPolicyWeightConfig{
+				{Type: policyWeightProximity, Radius: 25, Factor: 100},
+				{Type: policyWeightIOLoad},
+			},
+			Order: policyOrderDescending,
+		})
+		require.NoError(t, err)
+
+		sCtx := newCtx()
+		sorted, err := sp.Sort(ads, sCtx)
+		require.NoError(t, err)
+		require.Len(t, sorted, 4)
+		assert.Equal(t, "Madison", sorted[0].Name)
+
+		madison := sCtx.RedirectInfo.ServersInfo[ads[0].URL.String()]
+		require.NotNil(t, madison)
+		assert.Equal(t, 1.0, madison.RedirectWeights.PolicyWeights[policyWeightProximity])
+		assert.Less(t, madison.RedirectWeights.IOLoadWeight, 1.0)
+	})
+
+	t.Run("prefer-servers-and-status", func(t *testing.T) {
+		sp, err := NewSortPolicy(SortPolicyConfig{
+			Name: "prefer",
+			Weights: []SortPolicyWeightConfig{
+				{Type: policyWeightPreferServers, Servers: []string{"NYC"}, Factor: 4},
+				{Type: policyWeightStatus},
+			},
+			Order: policyOrderDescending,
+		})
+		require.NoError(t, err)
+
+		sorted, err := sp.Sort(ads, newCtx())
+		require.NoError(t, err)
+		assert.Equal(t, "NYC", sorted[0].Name)
+		assert.Equal(t, "LA", sorted[len(sorted)-1].Name)
+	})
+
+	t.Run("working-set-and-availability", func(t *testing.T) {
+		sp, err := NewSortPolicy(SortPolicyConfig{
+			Name:           "avail",
+			Weights:        []SortPolicyWeightConfig{{Type: policyWeightAvailability, Factor: 10}},
+			Order:          policyOrderDescending,
+			WorkingSetSize: 2,
+		})
+		require.NoError(t, err)
+
+		sCtx := newCtx()
+		sCtx.AvailabilityMap = map[string]bool{
+			ads[0].URL.String(): false,
+			ads[1].URL.String(): true,
+			ads[3].URL.String(): true, // Outside the working set, so it shouldn't matter
+		}
+		sorted, err := sp.Sort(ads, sCtx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Chicago", "Madison"}, names(sorted))
+	})
+
+	t.Run("everything-filtered", func(t *testing.T) {
+		sp, err := NewSortPolicy(SortPolicyConfig{
+			Name:    "nothing",
+			Filters: []SortPolicyFilterConfig{{Type: policyFilterMaxDistance, Value: 0.01}},
+			Weights: []SortPolicyWeightConfig{{Type: policyWeightDistance}},
+		})
+		require.NoError(t, err)
+
+		sorted, err := sp.Sort(ads, newCtx())
+		require.NoError(t, err)
+		assert.Empty(t, sorted)
+	})
+}
+
+func TestSortServerAdsUsesPolicy(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+	setupOverrideCache(t)
+	require.NoError(t, param.Director_CacheSortMethod.Set(string(server_structs.DistanceType)))
+
+	setSortPolicies(t, SortPolicyConfig{
+		Name:       "nyc-first",
+		Namespaces: []string{"/foo"},
+		ServerType: "cache",
+		Weights:    []SortPolicyWeightConfig{{Type: policyWeightPreferServers, Servers: []string{"NYC"}, Factor: 1000}, {Type: policyWeightDistance}},
+		Order:      policyOrderDescending,
+	})
+
+	ads := []server_structs.ServerAd{
+		getAdBase("Chicago", 41.8781, -87.6298),
+		getAdBase("NYC", 40.7128, -74.0060),
+	}
+
+	rInfo := server_structs.NewRedirectInfoFromIP(ipFromOverride.String())
+	sorted, err := sortServerAds(context.Background(), nil, ipFromOverride, ads, server_structs.NamespaceAd{Path: "/foo"}, uuid.New(), false, nil, rInfo)
+	require.NoError(t, err)
+	assert.Equal(t, "NYC", sorted[0].Name)
+	assert.Equal(t, server_structs.PolicyType.String(), rInfo.DirectorSortMethod)
+	assert.Equal(t, "nyc-first", rInfo.DirectorSortPolicy)
+
+	// Origins and other namespaces aren't covered by the policy
+	rInfo = server_structs.NewRedirectInfoFromIP(ipFromOverride.String())
+	sorted, err = sortServerAds(context.Background(), nil, ipFromOverride, ads, server_structs.NamespaceAd{Path: "/bar"}, uuid.New(), false, nil, rInfo)
+	require.NoError(t, err)
+	assert.Equal(t, "Chicago", sorted[0].Name)
+	assert.Equal(t, server_structs.DistanceType.String(), rInfo.DirectorSortMethod)
+	assert.Empty(t, rInfo.DirectorSortPolicy)
+
+	// A policy that excludes every server doesn't fall back to a generic sort, which would
+	// hand out the very servers it excluded. It returns no servers instead, so the caller can
+	// degrade (e.g. to origins) as it would for any other empty set.
+	setSortPolicies(t, SortPolicyConfig{
+		Name:    "broken",
+		Filters: []SortPolicyFilterConfig{{Type: policyFilterExcludeServers, Servers: []string{"Chicago", "NYC"}}},
+		Weights: []SortPolicyWeightConfig{{Type: policyWeightDistance}},
+	})
+	sorted, err = sortServerAds(context.Background(), nil, netip.MustParseAddr("192.168.1.4"), ads, server_structs.NamespaceAd{Path: "/foo"}, uuid.New(), false, nil, server_structs.NewRedirectInfoFromIP("192.168.1.4"))
+	require.NoError(t, err)
+	assert.Empty(t, sorted)
+}
+
+func TestListSortPolicies(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	gin.SetMode(gin.TestMode)
+
+	setSortPolicies(t,
+		SortPolicyConfig{Name: "foo-caches", Namespaces: []string{"/foo"}, ServerType: "cache", Weights: []SortPolicyWeightConfig{{Type: policyWeightDistance}}},
+	)
+
+	router := gin.New()
+	router.GET("/sortPolicies", listSortPolicies)
+
+	t.Run("list", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/sortPolicies", nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var res sortPoliciesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Policies, 1)
+		assert.Equal(t, "foo-caches", res.Policies[0].Name)
+		assert.Empty(t, res.Path)
+	})
+
+	t.Run("match-path", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/sortPolicies?path=/foo/bar", nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var res sortPoliciesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, "/foo/bar", res.Path)
+		assert.Equal(t, "foo-caches", res.CachePolicy)
+		assert.Empty(t, res.OriginPolicy)
+	})
+
+	t.Run("relative-path", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/sortPolicies?path=foo", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -25,6 +25,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"time"
 
@@ -326,6 +327,45 @@ func getStatUtils(ads []server_structs.ServerAd) map[string]*serverStatUtil {
 	return result
 }
 
+// Determine whether a server's new advertisement invalidates the object locations
+// cached for it.  A restarted server may have lost (or gained) objects and a server
+// that changed its namespaces may now serve different objects; otherwise, the
+// cached results remain valid until they expire.
+//
+// Returns the reason for the invalidation or an empty string if none is needed.
+func statCacheInvalidationReason(oldAd *server_structs.Advertisement, newAd *server_structs.ServerAd, newNamespaces []server_structs.NamespaceAd) string {
+	if oldAd.InstanceID != newAd.InstanceID || oldAd.StartTime != newAd.StartTime {
+		return "restart"
+	}
+	oldPaths := make([]string, 0, len(oldAd.NamespaceAds))
+	for _, ns := range oldAd.NamespaceAds {
+		oldPaths = append(oldPaths, ns.Path)
+	}
+	newPaths := make([]string, 0, len(newNamespaces))
+	for _, ns := range newNamespaces {
+		newPaths = append(newPaths, ns.Path)
+	}
+	slices.Sort(oldPaths)
+	slices.Sort(newPaths)
+	if !slices.Equal(oldPaths, newPaths) {
+		return "namespaces"
+	}
+	return ""
+}
+
+// Discard the cached object locations for the given server
+func invalidateStatCache(ad *server_structs.ServerAd, reason string) {
+	statUtilsMutex.RLock()
+	statUtil, ok := statUtils[ad.URL.String()]
+	statUtilsMutex.RUnlock()
+	if !ok || statUtil.ResultCache == nil {
+		return
+	}
+	log.Debugf("Discarding %d cached object locations for %s server %s (%s)", statUtil.ResultCache.Len(), ad.Type, ad.Name, reason)
+	statUtil.ResultCache.DeleteAll()
+	metrics.PelicanDirectorStatCacheInvalidationsTotal.WithLabelValues(ad.Name, ad.Type, reason).Inc()
+}
+
 // Implementation of querying origins/cache servers for their availability of an object.
 // It blocks until max successful requests has been received, all potential origins/caches responded (or timeout), or cancelContext was closed.
 //
@@ -363,6 +403,10 @@ func (stat *ObjectStat) queryServersForObject(ctx context.Context, objectName st
 		return
 	}
 	timeout := param.Director_StatTimeout.GetDuration()
+	negativeTTL := param.Director_CachePresenceNegativeTTL.GetDuration()
+	if negativeTTL <= 0 {
+		negativeTTL = ttlcache.DefaultTTL
+	}
 	// We both send to *and* receive from these channels within this
 	// goroutine, so ensure that they have sufficiently large buffers
 	// to prevent the goroutine from blocking on itself.
@@ -442,7 +486,7 @@ func (stat *ObjectStat) queryServersForObject(ctx context.Context, objectName st
 
 				// If get a 404, record it in the cache.
 				if errors.As(err, &reqNotFound) {
-					statUtil.ResultCache.Set(objectName, nil, ttlcache.DefaultTTL)
+					statUtil.ResultCache.Set(objectName, nil, negativeTTL)
 				} else if err == nil {
 					statUtil.ResultCache.Set(objectName, metadata, ttlcache.DefaultTTL)
 				}
@@ -506,13 +550,16 @@ func (stat *ObjectStat) queryServersForObject(ctx context.Context, objectName st
 					log.Tracef("Object %s found at %s server %s: (cached result)", objectName, serverAd.Type, baseUrl.String())
 					positiveReqChan <- metadata
 					totalLabels["result"] = string(metrics.StatSucceeded)
+					metrics.PelicanDirectorStatCacheLookupsTotal.WithLabelValues(serverAd.Name, serverAd.Type, string(metrics.StatCacheHitPresent)).Inc()
 				} else {
 					log.Tracef("Object %s not found at %s server %s: (cached result)", objectName, serverAd.Type, baseUrl.String())
 					negativeReqChan <- &headReqNotFoundErr{}
 					totalLabels["result"] = string(metrics.StatNotFound)
+					metrics.PelicanDirectorStatCacheLookupsTotal.WithLabelValues(serverAd.Name, serverAd.Type, string(metrics.StatCacheHitAbsent)).Inc()
 				}
 				metrics.PelicanDirectorStatTotal.With(totalLabels).Inc()
 			} else {
+				metrics.PelicanDirectorStatCacheLookupsTotal.WithLabelValues(serverAd.Name, serverAd.Type, string(metrics.StatCacheMiss)).Inc()
 				statUtil.Errgroup.TryGoUntil(ctx, lookupFunc)
 			}
 		}(adExt)
@@ -33,10 +33,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jellydator/ttlcache/v3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/param"
 	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/server_utils"
@@ -927,6 +929,76 @@ func TestCache(t *testing.T) {
 		assert.Equal(t, queryNoSourcesErr, qResult.ErrorType)
 		require.Equal(t, startCtr+1, reqCounter.Load())
 	})
+
+	t.Run("negative-results-use-negative-ttl", func(t *testing.T) {
+		require.NoError(t, param.Director_CachePresenceNegativeTTL.Set(30*time.Second))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stat := NewObjectStat()
+		cServerAds := []server_structs.ServerAd{mockCacheAd}
+		qResult := stat.queryServersForObject(ctx, "/foo/negative-ttl.txt", server_structs.CacheType, 1, 1, withCacheAds(cServerAds))
+		assert.Equal(t, queryNoSourcesErr, qResult.ErrorType)
+		qResult = stat.queryServersForObject(ctx, "/foo/test.txt", server_structs.CacheType, 1, 1, withCacheAds(cServerAds))
+		assert.Equal(t, querySuccessful, qResult.Status)
+
+		statUtilsMutex.RLock()
+		resultCache := statUtils[mockCacheAd.URL.String()].ResultCache
+		statUtilsMutex.RUnlock()
+		negItem := resultCache.Get("/foo/negative-ttl.txt")
+		require.NotNil(t, negItem)
+		assert.Nil(t, negItem.Value())
+		assert.WithinDuration(t, time.Now().Add(30*time.Second), negItem.ExpiresAt(), 5*time.Second)
+		posItem := resultCache.Get("/foo/test.txt")
+		require.NotNil(t, posItem)
+		assert.Greater(t, time.Until(posItem.ExpiresAt()), time.Hour)
+	})
+
+	t.Run("lookups-are-counted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		counter := func(result metrics.DirectorStatCacheResult) float64 {
+			return testutil.ToFloat64(metrics.PelicanDirectorStatCacheLookupsTotal.WithLabelValues(mockCacheAd.Name, mockCacheAd.Type, string(result)))
+		}
+		startMiss := counter(metrics.StatCacheMiss)
+		startPresent := counter(metrics.StatCacheHitPresent)
+		startAbsent := counter(metrics.StatCacheHitAbsent)
+
+		stat := NewObjectStat()
+		cServerAds := []server_structs.ServerAd{mockCacheAd}
+		stat.queryServersForObject(ctx, "/foo/counted.txt", server_structs.CacheType, 1, 1, withCacheAds(cServerAds))
+		stat.queryServersForObject(ctx, "/foo/counted.txt", server_structs.CacheType, 1, 1, withCacheAds(cServerAds))
+		stat.queryServersForObject(ctx, "/foo/test.txt", server_structs.CacheType, 1, 1, withCacheAds(cServerAds))
+
+		assert.Equal(t, startMiss+1, counter(metrics.StatCacheMiss))
+		assert.Equal(t, startAbsent+1, counter(metrics.StatCacheHitAbsent))
+		assert.Equal(t, startPresent+1, counter(metrics.StatCacheHitPresent))
+	})
+
+	t.Run("restart-invalidates-cached-locations", func(t *testing.T) {
+		statUtilsMutex.RLock()
+		resultCache := statUtils[mockCacheAd.URL.String()].ResultCache
+		statUtilsMutex.RUnlock()
+		require.NotZero(t, resultCache.Len())
+
+		item := serverAds.Get(mockCacheAd.URL.String())
+		require.NotNil(t, item)
+
+		// A re-advertisement from the same instance with the same namespaces keeps the cache
+		sameAd := mockCacheAd
+		sameAd.GenerationID++
+		assert.Empty(t, statCacheInvalidationReason(item.Value(), &sameAd, []server_structs.NamespaceAd{mockNsAd}))
+		assert.Equal(t, "namespaces", statCacheInvalidationReason(item.Value(), &sameAd, []server_structs.NamespaceAd{mockNsAd, {Path: "/bar"}}))
+
+		restartedAd := mockCacheAd
+		restartedAd.InstanceID = "new-instance"
+		reason := statCacheInvalidationReason(item.Value(), &restartedAd, []server_structs.NamespaceAd{mockNsAd})
+		assert.Equal(t, "restart", reason)
+
+		invalidateStatCache(&mockCacheAd, reason)
+		assert.Zero(t, resultCache.Len())
+	})
 }
 
 func TestSendHeadReq(t *testing.T) {
@@ -0,0 +1,260 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+type topologyRequest struct {
+	Prefix       string   `form:"prefix"`
+	Capabilities []string `form:"capability"`
+}
+
+// The version reported in server_structs.TopologyResponse.APIVersion.  Bump it (and add
+// a new route) for any change to the response that existing clients can't ignore.
+const topologyAPIVersion = "v1"
+
+// Map a capability name, as accepted by the topology API, to its value in a Capabilities struct.
+// Both the Go field names (e.g. "PublicReads") and the JSON names (e.g. "PublicRead") are accepted.
+func getCapability(caps server_structs.Capabilities, name string) (bool, error) {
+	switch strings.ToLower(name) {
+	case "publicreads", "publicread":
+		return caps.PublicReads, nil
+	case "reads", "read":
+		return caps.Reads, nil
+	case "writes", "write":
+		return caps.Writes, nil
+	case "listings", "listing":
+		return caps.Listings, nil
+	case "directreads", "fallbackread":
+		return caps.DirectReads, nil
+	case "copies":
+		return caps.Copies, nil
+	default:
+		return false, errors.Errorf("unknown capability %q", name)
+	}
+}
+
+// Returns true if caps has every one of the named capabilities
+func hasCapabilities(caps server_structs.Capabilities, names []string) bool {
+	for _, name := range names {
+		if enabled, err := getCapability(caps, name); err != nil || !enabled {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true if the namespace is relevant to a query for the prefix; that is, the
+// namespace is either at or below the prefix, or the prefix lives inside the namespace
+func namespaceMatchesPrefix(nsPath, prefix string) bool {
+	if prefix == "" || prefix == "/" || nsPath == prefix {
+		return true
+	}
+	return strings.HasPrefix(nsPath+"/", prefix+"/") || strings.HasPrefix(prefix+"/", nsPath+"/")
+}
+
+// Summarize whether the director will currently send clients to the server.
+//
+// This function expects the healthTestUtilsMutex to be read-locked
+func topologyServerStatus(ad *server_structs.Advertisement) (status string, healthStatus HealthTestStatus) {
+	healthStatus = HealthStatusUnknown
+	if util, ok := healthTestUtils[ad.URL.String()]; ok && util != nil {
+		healthStatus = util.Status
+	} else if ad.DisableDirectorTest {
+		healthStatus = HealthStatusDisabled
+	}
+
+	if filtered, _ := checkFilter(ad.Name); filtered {
+		return "unavailable", healthStatus
+	}
+	switch metrics.ParseHealthStatus(ad.Status) {
+	case metrics.StatusShuttingDown, metrics.StatusCritical:
+		return "unavailable", healthStatus
+	case metrics.StatusDegraded, metrics.StatusWarning:
+		return "degraded", healthStatus
+	}
+	switch healthStatus {
+	case HealthStatusError:
+		return "degraded", healthStatus
+	case HealthStatusOK:
+		return "ok", healthStatus
+	}
+	if ad.FromTopology && ad.Status == "" {
+		// Topology servers are never health tested; the director sends clients to them
+		// as long as they aren't filtered
+		return "ok", healthStatus
+	}
+	return "unknown", healthStatus
+}
+
+func advertisementToTopologyServer(ad *server_structs.Advertisement) server_structs.TopologyServer {
+	status, healthStatus := topologyServerStatus(ad)
+	features := ad.RequiredFeatures
+	if features == nil {
+		features = []string{}
+	}
+	return server_structs.TopologyServer{
+		Name:             ad.Name,
+		Type:             ad.Type,
+		URL:              ad.URL.String(),
+		WebURL:           ad.WebURL.String(),
+		Latitude:         ad.Latitude,
+		Longitude:        ad.Longitude,
+		Caps:             ad.Caps,
+		RequiredFeatures: features,
+		FromTopology:     ad.FromTopology,
+		Version:          ad.Version,
+		Status:           status,
+		HealthStatus:     string(healthStatus),
+		ServerStatus:     ad.Status,
+	}
+}
+
+// Build the federation topology, limited to the namespaces relevant to the prefix
+// and supporting all of the requested capabilities.
+//
+// A namespace is listed if at least one origin exports it with the requested capabilities;
+// the caches listed for it are those advertising it to the director, which already excludes
+// any namespace a cache isn't allowed to serve by the registry.
+func buildTopology(prefix string, capabilities []string) server_structs.TopologyResponse {
+	ads := listAdvertisement([]server_structs.ServerType{server_structs.OriginType, server_structs.CacheType})
+	// Origins go first so that caches are only attached to namespaces some origin exports
+	slices.SortStableFunc(ads, func(a, b *server_structs.Advertisement) int {
+		aIsOrigin := a.Type == server_structs.OriginType.String()
+		bIsOrigin := b.Type == server_structs.OriginType.String()
+		if aIsOrigin == bIsOrigin {
+			return 0
+		} else if aIsOrigin {
+			return -1
+		}
+		return 1
+	})
+
+	namespaces := map[string]*server_structs.TopologyNamespace{}
+	servers := map[string]*server_structs.Advertisement{}
+	for _, ad := range ads {
+		isOrigin := ad.Type == server_structs.OriginType.String()
+		for _, ns := range ad.NamespaceAds {
+			nsPath := path.Clean(ns.Path)
+			if !namespaceMatchesPrefix(nsPath, prefix) {
+				continue
+			}
+			nsRes, exists := namespaces[nsPath]
+			if isOrigin {
+				if !hasCapabilities(ns.Caps, capabilities) {
+					continue
+				}
+				if !exists {
+					nsRes = &server_structs.TopologyNamespace{Path: nsPath, Origins: []string{}, Caches: []string{}}
+					namespaces[nsPath] = nsRes
+				}
+				// Origins may export the same namespace with different capabilities;
+				// the namespace supports whatever any of its origins supports
+				nsRes.Caps.PublicReads = nsRes.Caps.PublicReads || ns.Caps.PublicReads
+				nsRes.Caps.Reads = nsRes.Caps.Reads || ns.Caps.Reads
+				nsRes.Caps.Writes = nsRes.Caps.Writes || ns.Caps.Writes
+				nsRes.Caps.Listings = nsRes.Caps.Listings || ns.Caps.Listings
+				nsRes.Caps.DirectReads = nsRes.Caps.DirectReads || ns.Caps.DirectReads
+				nsRes.Caps.Copies = nsRes.Caps.Copies || ns.Caps.Copies
+				if !slices.Contains(nsRes.Origins, ad.Name) {
+					nsRes.Origins = append(nsRes.Origins, ad.Name)
+				}
+			} else {
+				if !exists {
+					continue
+				}
+				if !slices.Contains(nsRes.Caches, ad.Name) {
+					nsRes.Caches = append(nsRes.Caches, ad.Name)
+				}
+			}
+			servers[ad.Name] = ad
+		}
+	}
+
+	res := server_structs.TopologyResponse{
+		APIVersion: topologyAPIVersion,
+		Namespaces: make([]server_structs.TopologyNamespace, 0, len(namespaces)),
+		Servers:    make([]server_structs.TopologyServer, 0, len(servers)),
+	}
+	for _, nsRes := range namespaces {
+		slices.Sort(nsRes.Origins)
+		slices.Sort(nsRes.Caches)
+		res.Namespaces = append(res.Namespaces, *nsRes)
+	}
+	slices.SortFunc(res.Namespaces, func(a, b server_structs.TopologyNamespace) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	healthTestUtilsMutex.RLock()
+	defer healthTestUtilsMutex.RUnlock()
+	for _, ad := range servers {
+		res.Servers = append(res.Servers, advertisementToTopologyServer(ad))
+	}
+	slices.SortFunc(res.Servers, func(a, b server_structs.TopologyServer) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return res
+}
+
+// Return the namespaces known to the director along with the origins exporting
+// them and the caches serving them.  The response can be limited with the
+// "prefix" query parameter and one or more "capability" query parameters.
+func getTopology(ctx *gin.Context) {
+	req := topologyRequest{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+	prefix := ""
+	if req.Prefix != "" {
+		if !strings.HasPrefix(req.Prefix, "/") {
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "The prefix must be an absolute path",
+			})
+			return
+		}
+		prefix = path.Clean(req.Prefix)
+	}
+	for _, capability := range req.Capabilities {
+		if _, err := getCapability(server_structs.Capabilities{}, capability); err != nil {
+			ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+				Status: server_structs.RespFailed,
+				Msg:    "Invalid capability: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, buildTopology(prefix, req.Capabilities))
+}
@@ -0,0 +1,168 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+func TestNamespaceMatchesPrefix(t *testing.T) {
+	assert.True(t, namespaceMatchesPrefix("/foo", ""))
+	assert.True(t, namespaceMatchesPrefix("/foo", "/"))
+	assert.True(t, namespaceMatchesPrefix("/foo", "/foo"))
+	assert.True(t, namespaceMatchesPrefix("/foo/bar", "/foo"))
+	assert.True(t, namespaceMatchesPrefix("/foo", "/foo/bar/baz.txt"))
+	assert.False(t, namespaceMatchesPrefix("/foobar", "/foo"))
+	assert.False(t, namespaceMatchesPrefix("/foo", "/foobar"))
+	assert.False(t, namespaceMatchesPrefix("/bar", "/foo"))
+}
+
+func TestGetTopology(t *testing.T) {
+	setGinTestMode()
+	t.Cleanup(test_utils.SetupTestLogging(t))
+
+	serverAds.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		filteredServersMutex.Lock()
+		delete(filteredServers, "filtered-cache")
+		filteredServersMutex.Unlock()
+	})
+
+	publicCaps := server_structs.Capabilities{PublicReads: true, Reads: true, Listings: true}
+	writeCaps := server_structs.Capabilities{Reads: true, Writes: true}
+	addAd := func(name string, sType server_structs.ServerType, lat float64, nss ...server_structs.NamespaceAd) {
+		ad := server_structs.ServerAd{
+			URL:              url.URL{Scheme: "https", Host: name + ".example.com"},
+			Type:             sType.String(),
+			Latitude:         lat,
+			Longitude:        -lat,
+			RequiredFeatures: []string{},
+		}
+		ad.Initialize(name)
+		if name == "origin-a" {
+			ad.RequiredFeatures = []string{"CacheAuthz"}
+		}
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{ServerAd: ad, NamespaceAds: nss}, ttlcache.DefaultTTL)
+	}
+	addAd("origin-a", server_structs.OriginType, 10,
+		server_structs.NamespaceAd{Path: "/public", Caps: publicCaps},
+		server_structs.NamespaceAd{Path: "/private/", Caps: writeCaps},
+	)
+	addAd("origin-b", server_structs.OriginType, 20, server_structs.NamespaceAd{Path: "/public", Caps: server_structs.Capabilities{PublicReads: true, Copies: true}})
+	addAd("cache-a", server_structs.CacheType, 30,
+		server_structs.NamespaceAd{Path: "/public", Caps: publicCaps},
+		server_structs.NamespaceAd{Path: "/private", Caps: writeCaps},
+		// No origin exports this namespace so it shouldn't show up
+		server_structs.NamespaceAd{Path: "/orphan", Caps: publicCaps},
+	)
+	addAd("filtered-cache", server_structs.CacheType, 40, server_structs.NamespaceAd{Path: "/public", Caps: publicCaps})
+	filteredServersMutex.Lock()
+	filteredServers["filtered-cache"] = tempFiltered
+	filteredServersMutex.Unlock()
+
+	router := gin.Default()
+	router.GET("/topology", getTopology)
+	query := func(rawQuery string) (int, server_structs.TopologyResponse) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/topology?"+rawQuery, nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+		res := server_structs.TopologyResponse{}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		}
+		return w.Code, res
+	}
+
+	t.Run("full-topology", func(t *testing.T) {
+		code, res := query("")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "v1", res.APIVersion)
+		require.Len(t, res.Namespaces, 2)
+
+		assert.Equal(t, "/private", res.Namespaces[0].Path)
+		assert.Equal(t, []string{"origin-a"}, res.Namespaces[0].Origins)
+		assert.Equal(t, []string{"cache-a"}, res.Namespaces[0].Caches)
+		assert.Equal(t, writeCaps, res.Namespaces[0].Caps)
+
+		assert.Equal(t, "/public", res.Namespaces[1].Path)
+		assert.Equal(t, []string{"origin-a", "origin-b"}, res.Namespaces[1].Origins)
+		assert.Equal(t, []string{"cache-a", "filtered-cache"}, res.Namespaces[1].Caches)
+		assert.Equal(t, server_structs.Capabilities{PublicReads: true, Reads: true, Listings: true, Copies: true}, res.Namespaces[1].Caps)
+
+		require.Len(t, res.Servers, 4)
+		servers := map[string]server_structs.TopologyServer{}
+		for _, server := range res.Servers {
+			servers[server.Name] = server
+		}
+		assert.Equal(t, "unavailable", servers["filtered-cache"].Status)
+		assert.Equal(t, "unknown", servers["cache-a"].Status)
+		assert.Equal(t, []string{"CacheAuthz"}, servers["origin-a"].RequiredFeatures)
+		assert.Equal(t, 20.0, servers["origin-b"].Latitude)
+		assert.Equal(t, "https://cache-a.example.com", servers["cache-a"].URL)
+	})
+
+	t.Run("filter-by-prefix", func(t *testing.T) {
+		code, res := query("prefix=/public/subdir/file.txt")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, res.Namespaces, 1)
+		assert.Equal(t, "/public", res.Namespaces[0].Path)
+		assert.Len(t, res.Servers, 4)
+
+		code, res = query("prefix=/priv")
+		require.Equal(t, http.StatusOK, code)
+		assert.Empty(t, res.Namespaces)
+		assert.Empty(t, res.Servers)
+	})
+
+	t.Run("filter-by-capability", func(t *testing.T) {
+		code, res := query("capability=Writes")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, res.Namespaces, 1)
+		assert.Equal(t, "/private", res.Namespaces[0].Path)
+		require.Len(t, res.Servers, 2)
+
+		// Only origin-a exports /public with listings
+		code, res = query("capability=Listing&capability=publicreads")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, res.Namespaces, 1)
+		assert.Equal(t, "/public", res.Namespaces[0].Path)
+		assert.Equal(t, []string{"origin-a"}, res.Namespaces[0].Origins)
+	})
+
+	t.Run("invalid-requests", func(t *testing.T) {
+		code, _ := query("capability=Teleport")
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = query("prefix=relative/path")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
@@ -0,0 +1,398 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/pelicanplatform/pelican/error_codes"
+	"github.com/pelicanplatform/pelican/metrics"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+/*
+	Clients can report the outcome of their transfers through each cache to the
+	Director.  The reports are aggregated into a per-cache error rate over a sliding
+	window (Director.TransferFeedbackWindow), split into a fixed number of buckets so
+	that old outcomes age out without storing every report.  Caches whose error rate
+	crosses Director.TransferFeedbackErrorThreshold are moved behind the other caches
+	by the cacheNotReportedFailing predicate until their error rate recovers.
+
+	Reports aren't authenticated, since the only credential a client holds is a token
+	scoped to the object rather than to the Director.  Instead, each client address
+	counts at most once per cache per window, so a single client can't push a cache
+	down by itself no matter how many failures it reports, and each address may only
+	send a limited number of reports.
+*/
+
+type (
+	// The transfer outcomes reported during one slice of the feedback window
+	transferFeedbackBucket struct {
+		Start    time.Time
+		Attempts int
+		Failures int
+	}
+
+	// The transfer outcomes clients reported for a single cache, oldest bucket first
+	transferFeedbackStats struct {
+		Buckets []transferFeedbackBucket
+		// When each reporter (see transferFeedbackReporter) last had an outcome counted
+		Reporters map[string]time.Time
+	}
+)
+
+const (
+	// The feedback window is split into this many buckets
+	transferFeedbackBuckets = 10
+	// The maximum number of attempts accepted in a single report
+	maxTransferFeedbackAttempts = 100
+	// The error class clients report for errors that aren't Pelican errors
+	transferFeedbackUnknownError = "Unknown"
+	// The sustained rate (per second) and burst of reports accepted from each reporter
+	transferFeedbackRateLimit = rate.Limit(1)
+	transferFeedbackBurst     = 20
+)
+
+var (
+	// Reported transfer outcomes for each cache, keyed by the cache's URL
+	transferFeedbackMap   = map[string]*transferFeedbackStats{}
+	transferFeedbackMutex sync.RWMutex
+
+	// Rate limiters for the reporters that recently sent transfer feedback
+	transferFeedbackLimiters = ttlcache.New(
+		ttlcache.WithTTL[string, *rate.Limiter](10*time.Minute),
+		ttlcache.WithCapacity[string, *rate.Limiter](100_000),
+	)
+
+	// Error classes that reflect a problem with the client's request, its credentials or the
+	// origin rather than the cache.  Sub-classes (e.g. "Specification.FileNotFound") match too.
+	transferFeedbackIgnoredErrors = []string{
+		"Parameter",
+		"Authorization",
+		"Specification",
+		"Contact.Director",
+		"Contact.Origin",
+		"Contact.Registry",
+		"Transfer.DirectorTimeout",
+		"Transfer.OriginUnresponsive",
+		"Transfer.OriginSlow",
+	}
+)
+
+func getTransferFeedbackWindow() time.Duration {
+	window := param.Director_TransferFeedbackWindow.GetDuration()
+	if window <= 0 {
+		return 15 * time.Minute
+	}
+	return window
+}
+
+// Returns true if an attempt that ended with the error class should count against the cache
+func errorClassCountsAgainstCache(errorClass string) bool {
+	if errorClass == "" {
+		return false
+	}
+	for _, ignored := range transferFeedbackIgnoredErrors {
+		if errorClass == ignored || strings.HasPrefix(errorClass, ignored+".") {
+			return false
+		}
+	}
+	return true
+}
+
+// Drop the buckets that have fallen out of the window
+func (s *transferFeedbackStats) prune(now time.Time, window time.Duration) {
+	idx := 0
+	for idx < len(s.Buckets) && now.Sub(s.Buckets[idx].Start) >= window {
+		idx++
+	}
+	s.Buckets = s.Buckets[idx:]
+}
+
+// Record a single attempt from the reporter.  Returns false without recording anything
+// if the reporter already had an outcome counted for the cache within the window.
+func (s *transferFeedbackStats) add(now time.Time, window time.Duration, reporter string, failed bool) bool {
+	s.prune(now, window)
+	for r, last := range s.Reporters {
+		if now.Sub(last) >= window {
+			delete(s.Reporters, r)
+		}
+	}
+	if _, seen := s.Reporters[reporter]; seen {
+		return false
+	}
+	if s.Reporters == nil {
+		s.Reporters = map[string]time.Time{}
+	}
+	s.Reporters[reporter] = now
+	width := window / transferFeedbackBuckets
+	if len(s.Buckets) == 0 || now.Sub(s.Buckets[len(s.Buckets)-1].Start) >= width {
+		s.Buckets = append(s.Buckets, transferFeedbackBucket{Start: now})
+	}
+	bucket := &s.Buckets[len(s.Buckets)-1]
+	bucket.Attempts++
+	if failed {
+		bucket.Failures++
+	}
+	return true
+}
+
+// Sum the attempts and failures still inside the window
+func (s *transferFeedbackStats) totals(now time.Time, window time.Duration) (attempts, failures int) {
+	for _, bucket := range s.Buckets {
+		if now.Sub(bucket.Start) >= window {
+			continue
+		}
+		attempts += bucket.Attempts
+		failures += bucket.Failures
+	}
+	return
+}
+
+// Map a client-supplied error class onto the known Pelican error types, so that clients
+// can't create arbitrary metric label values
+func sanitizeErrorClass(errorClass string) string {
+	if errorClass == "" || error_codes.IsKnownErrorType(errorClass) {
+		return errorClass
+	}
+	return transferFeedbackUnknownError
+}
+
+// Record an attempt a client reported for a cache.  Returns false if the reporter already
+// had an outcome counted for the cache within the window, in which case nothing is recorded.
+func recordTransferFeedback(ad server_structs.ServerAd, attempt server_structs.TransferFeedbackAttempt, reporter string, now time.Time) bool {
+	errorClass := sanitizeErrorClass(attempt.ErrorClass)
+	failed := errorClassCountsAgainstCache(errorClass)
+	window := getTransferFeedbackWindow()
+
+	transferFeedbackMutex.Lock()
+	stats, ok := transferFeedbackMap[ad.URL.String()]
+	if !ok {
+		stats = &transferFeedbackStats{}
+		transferFeedbackMap[ad.URL.String()] = stats
+	}
+	if !stats.add(now, window, reporter, failed) {
+		transferFeedbackMutex.Unlock()
+		return false
+	}
+	attempts, failures := stats.totals(now, window)
+	transferFeedbackMutex.Unlock()
+
+	status := metrics.MetricSucceeded
+	if errorClass != "" {
+		status = metrics.MetricFailed
+	}
+	metrics.PelicanDirectorTransferFeedbackTotal.With(prometheus.Labels{
+		"server_name": ad.Name,
+		"status":      string(status),
+		"error_class": errorClass,
+	}).Inc()
+	metrics.PelicanDirectorCacheReportedErrorRate.With(prometheus.Labels{
+		"server_name": ad.Name,
+		"server_url":  ad.URL.String(),
+	}).Set(float64(failures) / float64(attempts))
+	return true
+}
+
+// Get the fraction of reported attempts through the cache that failed, along with the number
+// of attempts the rate is based on
+func getCacheReportedErrorRate(serverUrl string, now time.Time) (rate float64, attempts int) {
+	transferFeedbackMutex.RLock()
+	defer transferFeedbackMutex.RUnlock()
+	stats, ok := transferFeedbackMap[serverUrl]
+	if !ok {
+		return 0, 0
+	}
+	attempts, failures := stats.totals(now, getTransferFeedbackWindow())
+	if attempts == 0 {
+		return 0, 0
+	}
+	return float64(failures) / float64(attempts), attempts
+}
+
+// Returns true if clients have recently reported enough failed transfers through the
+// cache that it should be deprioritized
+func cacheIsReportedFailing(serverUrl string, now time.Time) bool {
+	if !param.Director_EnableTransferFeedback.GetBool() {
+		return false
+	}
+	rate, attempts := getCacheReportedErrorRate(serverUrl, now)
+	if attempts == 0 || attempts < param.Director_TransferFeedbackMinAttempts.GetInt() {
+		return false
+	}
+	return rate*100 >= float64(param.Director_TransferFeedbackErrorThreshold.GetInt())
+}
+
+// Forget the reported outcomes for a cache, e.g. when its ad expires
+func deleteTransferFeedback(ad server_structs.ServerAd) {
+	transferFeedbackMutex.Lock()
+	defer transferFeedbackMutex.Unlock()
+	delete(transferFeedbackMap, ad.URL.String())
+	metrics.PelicanDirectorCacheReportedErrorRate.Delete(prometheus.Labels{"server_name": ad.Name, "server_url": ad.URL.String()})
+}
+
+// Identify the reporter a transfer feedback report is attributed to from the client's
+// address.  IPv6 clients are identified by their /64 prefix, since a single host
+// commonly has a whole /64 to pick addresses from.
+func transferFeedbackReporter(addr netip.Addr) string {
+	addr = addr.Unmap()
+	if addr.Is6() {
+		if prefix, err := addr.Prefix(64); err == nil {
+			return prefix.String()
+		}
+	}
+	return addr.String()
+}
+
+// Returns true if the reporter may send another transfer feedback report now
+func allowTransferFeedback(reporter string) bool {
+	item, _ := transferFeedbackLimiters.GetOrSet(reporter, rate.NewLimiter(transferFeedbackRateLimit, transferFeedbackBurst))
+	return item.Value().Allow()
+}
+
+// Find the cache a client used for an attempt.  Clients report the host (and port) of the
+// server they contacted; only caches serving the object's namespace are considered.
+func findFeedbackCache(server string, cAds []copyAd) (server_structs.ServerAd, bool) {
+	host := server
+	if u, err := url.Parse(server); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	for _, cAd := range cAds {
+		if cAd.ServerAd.URL.Host == host {
+			return cAd.ServerAd, true
+		}
+	}
+	return server_structs.ServerAd{}, false
+}
+
+// Accept a client's report of the outcome of each attempt to transfer an object.  Reports
+// aren't authenticated; they're attributed to the client's address and rate limited per
+// address.  Attempts through servers that aren't caches for the namespace are ignored.
+func postTransferFeedback(ctx *gin.Context) {
+	if !param.Director_EnableTransferFeedback.GetBool() {
+		ctx.JSON(http.StatusForbidden, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Transfer feedback is disabled on this director",
+		})
+		return
+	}
+
+	clientAddr := utils.ClientIPAddr(ctx)
+	if !clientAddr.IsValid() {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Unable to determine the client's address",
+		})
+		return
+	}
+	reporter := transferFeedbackReporter(clientAddr)
+	if !allowTransferFeedback(reporter) {
+		ctx.Header("Retry-After", "1")
+		ctx.JSON(http.StatusTooManyRequests, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Too many transfer feedback reports from this client",
+		})
+		return
+	}
+
+	feedback := server_structs.TransferFeedback{}
+	if err := ctx.ShouldBindJSON(&feedback); err != nil {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Invalid transfer feedback: " + err.Error(),
+		})
+		return
+	}
+	if !strings.HasPrefix(feedback.Path, "/") {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "The object path must be an absolute path",
+		})
+		return
+	}
+	if len(feedback.Attempts) > maxTransferFeedbackAttempts {
+		ctx.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("Too many attempts in a single report; at most %d are accepted", maxTransferFeedbackAttempts),
+		})
+		return
+	}
+
+	oAds, cAds := getAdsForPath(path.Clean(feedback.Path))
+	if len(oAds) == 0 {
+		ctx.JSON(http.StatusNotFound, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "No namespace found for the object path " + feedback.Path,
+		})
+		return
+	}
+
+	// A report counts once per cache: as a failure if any attempt through the cache failed
+	// in a way that's the cache's fault, otherwise by its first attempt
+	type cacheOutcome struct {
+		ad      server_structs.ServerAd
+		attempt server_structs.TransferFeedbackAttempt
+	}
+	var outcomes []*cacheOutcome
+	byCache := map[string]*cacheOutcome{}
+	for _, attempt := range feedback.Attempts {
+		ad, ok := findFeedbackCache(attempt.Server, cAds)
+		if !ok {
+			continue
+		}
+		outcome, seen := byCache[ad.URL.String()]
+		if !seen {
+			outcome = &cacheOutcome{ad: ad, attempt: attempt}
+			byCache[ad.URL.String()] = outcome
+			outcomes = append(outcomes, outcome)
+		} else if !errorClassCountsAgainstCache(sanitizeErrorClass(outcome.attempt.ErrorClass)) &&
+			errorClassCountsAgainstCache(sanitizeErrorClass(attempt.ErrorClass)) {
+			outcome.attempt = attempt
+		}
+	}
+
+	now := time.Now()
+	recorded := 0
+	for _, outcome := range outcomes {
+		if recordTransferFeedback(outcome.ad, outcome.attempt, reporter, now) {
+			recorded++
+		}
+	}
+	log.Tracef("Recorded %d of %d reported transfer attempts for %s", recorded, len(feedback.Attempts), feedback.Path)
+
+	ctx.JSON(http.StatusOK, server_structs.SimpleApiResp{
+		Status: server_structs.RespOK,
+		Msg:    fmt.Sprintf("Recorded %d of %d attempts", recorded, len(feedback.Attempts)),
+	})
+}
@@ -0,0 +1,281 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package director
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/server_utils"
+	"github.com/pelicanplatform/pelican/test_utils"
+)
+
+func resetTransferFeedback(t *testing.T) {
+	transferFeedbackMutex.Lock()
+	transferFeedbackMap = map[string]*transferFeedbackStats{}
+	transferFeedbackMutex.Unlock()
+	t.Cleanup(func() {
+		transferFeedbackMutex.Lock()
+		transferFeedbackMap = map[string]*transferFeedbackStats{}
+		transferFeedbackMutex.Unlock()
+	})
+}
+
+func TestErrorClassCountsAgainstCache(t *testing.T) {
+	assert.False(t, errorClassCountsAgainstCache(""))
+	assert.True(t, errorClassCountsAgainstCache("Transfer.SlowTransfer"))
+	assert.True(t, errorClassCountsAgainstCache("Contact.Cache"))
+	assert.True(t, errorClassCountsAgainstCache("Unknown"))
+	assert.False(t, errorClassCountsAgainstCache("Specification.FileNotFound"))
+	assert.False(t, errorClassCountsAgainstCache("Authorization"))
+	assert.False(t, errorClassCountsAgainstCache("Transfer.OriginSlow"))
+	// Only whole components of the class are matched
+	assert.True(t, errorClassCountsAgainstCache("Contact.OriginLike"))
+}
+
+func TestSanitizeErrorClass(t *testing.T) {
+	assert.Equal(t, "", sanitizeErrorClass(""))
+	assert.Equal(t, "Contact.Cache", sanitizeErrorClass("Contact.Cache"))
+	assert.Equal(t, "Specification.FileNotFound", sanitizeErrorClass("Specification.FileNotFound"))
+	// Anything else is a client making up label values
+	assert.Equal(t, "Unknown", sanitizeErrorClass("Contact.OriginLike"))
+	assert.Equal(t, "Unknown", sanitizeErrorClass("made-up-class-12345"))
+}
+
+func TestTransferFeedbackStats(t *testing.T) {
+	window := 10 * time.Minute
+	now := time.Now()
+	stats := transferFeedbackStats{}
+	assert.True(t, stats.add(now, window, "a", true))
+	assert.True(t, stats.add(now.Add(30*time.Second), window, "b", false))
+	assert.Len(t, stats.Buckets, 1, "attempts within a bucket's width should share the bucket")
+	assert.True(t, stats.add(now.Add(5*time.Minute), window, "c", false))
+	require.Len(t, stats.Buckets, 2)
+	assert.False(t, stats.add(now.Add(6*time.Minute), window, "a", true), "a reporter counts once per window")
+
+	attempts, failures := stats.totals(now.Add(5*time.Minute), window)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 1, failures)
+
+	// The first bucket ages out of the window
+	attempts, failures = stats.totals(now.Add(11*time.Minute), window)
+	assert.Equal(t, 1, attempts)
+	assert.Zero(t, failures)
+	assert.True(t, stats.add(now.Add(11*time.Minute), window, "a", true), "the reporter's first outcome has aged out")
+	assert.Len(t, stats.Buckets, 2)
+	assert.NotContains(t, stats.Reporters, "b", "reporters age out with their outcomes")
+}
+
+func TestCacheIsReportedFailing(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+	resetTransferFeedback(t)
+	require.NoError(t, param.Director_EnableTransferFeedback.Set(true))
+	require.NoError(t, param.Director_TransferFeedbackWindow.Set(15*time.Minute))
+	require.NoError(t, param.Director_TransferFeedbackErrorThreshold.Set(50))
+	require.NoError(t, param.Director_TransferFeedbackMinAttempts.Set(4))
+
+	failing := server_structs.ServerAd{URL: url.URL{Scheme: "https", Host: "failing.example.com"}}
+	failing.Initialize("failing")
+	healthy := server_structs.ServerAd{URL: url.URL{Scheme: "https", Host: "healthy.example.com"}}
+	healthy.Initialize("healthy")
+
+	reporters := 0
+	reporter := func() string {
+		reporters++
+		return fmt.Sprintf("https://issuer.example.com user%d", reporters)
+	}
+
+	now := time.Now()
+	for range 3 {
+		assert.True(t, recordTransferFeedback(failing, server_structs.TransferFeedbackAttempt{ErrorClass: "Transfer.SlowTransfer"}, reporter(), now))
+		assert.True(t, recordTransferFeedback(healthy, server_structs.TransferFeedbackAttempt{}, reporter(), now))
+	}
+	assert.False(t, cacheIsReportedFailing(failing.URL.String(), now), "too few attempts have been reported")
+
+	// A single reporter can't make up the difference on its own
+	repeat := reporter()
+	assert.True(t, recordTransferFeedback(healthy, server_structs.TransferFeedbackAttempt{ErrorClass: "Contact.Cache"}, repeat, now))
+	for range 10 {
+		assert.False(t, recordTransferFeedback(healthy, server_structs.TransferFeedbackAttempt{ErrorClass: "Contact.Cache"}, repeat, now))
+	}
+	_, attempts := getCacheReportedErrorRate(healthy.URL.String(), now)
+	assert.Equal(t, 4, attempts)
+
+	recordTransferFeedback(failing, server_structs.TransferFeedbackAttempt{}, reporter(), now)
+	rate, attempts := getCacheReportedErrorRate(failing.URL.String(), now)
+	assert.Equal(t, 4, attempts)
+	assert.InDelta(t, 0.75, rate, 1e-9)
+	assert.True(t, cacheIsReportedFailing(failing.URL.String(), now))
+	assert.False(t, cacheIsReportedFailing(healthy.URL.String(), now))
+
+	// Errors that aren't the cache's fault don't count against it
+	for range 4 {
+		recordTransferFeedback(healthy, server_structs.TransferFeedbackAttempt{ErrorClass: "Specification.FileNotFound"}, reporter(), now)
+	}
+	assert.False(t, cacheIsReportedFailing(healthy.URL.String(), now))
+
+	// The failures age out of the window
+	assert.False(t, cacheIsReportedFailing(failing.URL.String(), now.Add(16*time.Minute)))
+
+	// Failing caches are moved behind the others when sorting
+	ads := []copyAd{{ServerAd: failing}, {ServerAd: healthy}}
+	pass, fail := partitionAds(nil, ads, cacheNotReportedFailing())
+	require.Len(t, pass, 1)
+	assert.Equal(t, "healthy", pass[0].ServerAd.Name)
+	require.Len(t, fail, 1)
+	assert.Equal(t, "failing", fail[0].ServerAd.Name)
+
+	require.NoError(t, param.Director_EnableTransferFeedback.Set(false))
+	assert.False(t, cacheIsReportedFailing(failing.URL.String(), now))
+
+	deleteTransferFeedback(failing)
+	_, attempts = getCacheReportedErrorRate(failing.URL.String(), now)
+	assert.Zero(t, attempts)
+}
+
+func TestTransferFeedbackReporter(t *testing.T) {
+	assert.Equal(t, "192.0.2.1", transferFeedbackReporter(netip.MustParseAddr("192.0.2.1")))
+	assert.Equal(t, "192.0.2.1", transferFeedbackReporter(netip.MustParseAddr("::ffff:192.0.2.1")))
+	// Addresses in the same /64 are the same reporter
+	assert.Equal(t, "2001:db8:1:2::/64", transferFeedbackReporter(netip.MustParseAddr("2001:db8:1:2::1")))
+	assert.Equal(t, "2001:db8:1:2::/64", transferFeedbackReporter(netip.MustParseAddr("2001:db8:1:2:ffff::5")))
+}
+
+func TestPostTransferFeedback(t *testing.T) {
+	setGinTestMode()
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	resetTransferFeedback(t)
+	serverAds.DeleteAll()
+	transferFeedbackLimiters.DeleteAll()
+	t.Cleanup(func() {
+		serverAds.DeleteAll()
+		transferFeedbackLimiters.DeleteAll()
+		server_utils.ResetTestState()
+	})
+	require.NoError(t, param.Director_EnableTransferFeedback.Set(true))
+	require.NoError(t, param.Director_TransferFeedbackWindow.Set(15*time.Minute))
+
+	nsAd := server_structs.NamespaceAd{
+		Path: "/feedback",
+		Caps: server_structs.Capabilities{Reads: true},
+	}
+	addAd := func(name string, sType server_structs.ServerType) server_structs.ServerAd {
+		ad := server_structs.ServerAd{URL: url.URL{Scheme: "https", Host: name + ".example.com:8443"}, Type: sType.String()}
+		ad.Initialize(name)
+		serverAds.Set(ad.URL.String(), &server_structs.Advertisement{ServerAd: ad, NamespaceAds: []server_structs.NamespaceAd{nsAd}}, ttlcache.DefaultTTL)
+		return ad
+	}
+	addAd("origin", server_structs.OriginType)
+	cache := addAd("cache", server_structs.CacheType)
+
+	router := gin.Default()
+	router.POST("/transferFeedback", postTransferFeedback)
+	post := func(clientAddr string, feedback server_structs.TransferFeedback) (int, server_structs.SimpleApiResp) {
+		body, err := json.Marshal(feedback)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, "/transferFeedback", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = clientAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		resp := server_structs.SimpleApiResp{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+	feedback := server_structs.TransferFeedback{
+		Path: "/feedback/dir/object.txt",
+		Attempts: []server_structs.TransferFeedbackAttempt{
+			{Server: "cache.example.com:8443", Bytes: 10, Duration: time.Second, ErrorClass: "Transfer.SlowTransfer"},
+			{Server: "https://cache.example.com:8443", Bytes: 100, Duration: time.Second},
+			// Not a cache for the namespace
+			{Server: "origin.example.com:8443", Bytes: 100, Duration: time.Second},
+		},
+	}
+
+	t.Run("valid-feedback", func(t *testing.T) {
+		code, resp := post("192.0.2.1:1234", feedback)
+		require.Equal(t, http.StatusOK, code, resp.Msg)
+		assert.Equal(t, "Recorded 1 of 3 attempts", resp.Msg, "a report counts once per cache")
+
+		rate, attempts := getCacheReportedErrorRate(cache.URL.String(), time.Now())
+		assert.Equal(t, 1, attempts)
+		assert.InDelta(t, 1, rate, 1e-9, "the failed attempt is the one that counts")
+
+		// The same client can't report against the cache again within the window
+		code, resp = post("192.0.2.1:5678", feedback)
+		require.Equal(t, http.StatusOK, code, resp.Msg)
+		assert.Equal(t, "Recorded 0 of 3 attempts", resp.Msg)
+
+		code, resp = post("192.0.2.2:1234", feedback)
+		require.Equal(t, http.StatusOK, code, resp.Msg)
+		assert.Equal(t, "Recorded 1 of 3 attempts", resp.Msg)
+		_, attempts = getCacheReportedErrorRate(cache.URL.String(), time.Now())
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("rate-limited", func(t *testing.T) {
+		for idx := 0; idx < transferFeedbackBurst; idx++ {
+			code, resp := post("192.0.2.3:1234", feedback)
+			require.Equal(t, http.StatusOK, code, resp.Msg)
+		}
+		code, _ := post("192.0.2.3:1234", feedback)
+		assert.Equal(t, http.StatusTooManyRequests, code)
+
+		// Other clients aren't affected
+		code, resp := post("192.0.2.4:1234", feedback)
+		assert.Equal(t, http.StatusOK, code, resp.Msg)
+	})
+
+	t.Run("invalid-requests", func(t *testing.T) {
+		code, _ := post("192.0.2.5:1234", server_structs.TransferFeedback{Path: "relative/path"})
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = post("192.0.2.5:1234", server_structs.TransferFeedback{Path: "/unknown/object.txt"})
+		assert.Equal(t, http.StatusNotFound, code)
+
+		tooMany := server_structs.TransferFeedback{Path: feedback.Path, Attempts: make([]server_structs.TransferFeedbackAttempt, maxTransferFeedbackAttempts+1)}
+		code, _ = post("192.0.2.5:1234", tooMany)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		require.NoError(t, param.Director_EnableTransferFeedback.Set(false))
+		t.Cleanup(func() { require.NoError(t, param.Director_EnableTransferFeedback.Set(true)) })
+		code, _ := post("192.0.2.6:1234", feedback)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+}
@@ -21,6 +21,7 @@ package error_codes
 
 import (
 	"fmt"
+	"slices"
 )
 
 type PelicanError struct {
@@ -394,3 +395,40 @@ func (e *PelicanError) ErrorType() string {
 func (e *PelicanError) Description() string {
 	return e.description
 }
+
+// IsKnownErrorType returns whether the string is the type of one of the errors above, e.g. "Contact.Cache"
+func IsKnownErrorType(errorType string) bool {
+	return slices.Contains(knownErrorTypes, errorType)
+}
+
+var knownErrorTypes = []string{
+	"Parameter",
+	"Parameter.FileNotFound",
+	"Resolution",
+	"Resolution.Timeout",
+	"Resolution.ConnectionFailure",
+	"Contact",
+	"Contact.Director",
+	"Contact.Cache",
+	"Contact.Origin",
+	"Contact.Registry",
+	"Contact.ConnectionReset",
+	"Contact.ConnectionSetup",
+	"Authorization",
+	"Authorization.TokenNotFound",
+	"Specification",
+	"Specification.FileNotFound",
+	"Specification.FileNotCreated",
+	"Specification.FileAlreadyExists",
+	"Transfer",
+	"Transfer.StoppedTransfer",
+	"Transfer.SlowTransfer",
+	"Transfer.TimedOut",
+	"Transfer.HeaderTimeout",
+	"Transfer.DirectorTimeout",
+	"Transfer.ChecksumMismatch",
+	"Transfer.ChecksumMissing",
+	"Transfer.OriginUnresponsive",
+	"Transfer.OriginSlow",
+	"Transfer.CacheOverloaded",
+}
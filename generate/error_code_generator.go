@@ -196,6 +196,7 @@ package error_codes
 
 import (
 	"fmt"
+	"slices"
 )
 
 type PelicanError struct {
@@ -261,4 +262,15 @@ func (e *PelicanError) ErrorType() string {
 func (e *PelicanError) Description() string {
 	return e.description
 }
+
+// IsKnownErrorType returns whether the string is the type of one of the errors above, e.g. "Contact.Cache"
+func IsKnownErrorType(errorType string) bool {
+	return slices.Contains(knownErrorTypes, errorType)
+}
+
+var knownErrorTypes = []string{
+{{- range $idx, $pelicanError := .PelicanErrors}}
+	"{{$pelicanError.Raw}}",
+{{- end}}
+}
 `))
@@ -48,16 +48,30 @@ func DirectorServe(ctx context.Context, engine *gin.Engine, egrp *errgroup.Group
 
 	director.ConfigFilteredServers()
 
+	if err := director.ConfigSortPolicies(); err != nil {
+		return err
+	}
+
+	if err := director.ConfigSiteNetworks(); err != nil {
+		return err
+	}
+
 	director.LaunchTTLCache(ctx, egrp)
 
 	director.LaunchMapMetrics(ctx, egrp)
 
 	director.ConfigFilteredServers()
 
+	director.LaunchAdPersistence(ctx, egrp)
+
 	director.PeriodicFedDowntimeReload(ctx, egrp)
 
 	director.LaunchServerIOQuery(ctx, egrp)
 
+	if err := director.LaunchCacheProbes(ctx, egrp); err != nil {
+		return err
+	}
+
 	director.LaunchRegistryPeriodicQuery(ctx, egrp)
 
 	director.LaunchMetadataComparisonLoop(ctx, egrp)
@@ -358,6 +358,12 @@ func OriginServeFinish(ctx context.Context, egrp *errgroup.Group, engine *gin.En
 		origin_serve.RegisterStorageAPI(engine.Group("/api/v1.0"),
 			web_ui.AuthHandler, web_ui.AdminAuthHandler)
 
+		// The routes a replication primary ships to, when this origin's pstore
+		// is its secondary.  They authenticate the primary themselves.
+		if err := origin_serve.RegisterPStoreReplicationAPI(ctx, engine); err != nil {
+			return errors.Wrap(err, "failed to register the pstore replication API")
+		}
+
 		// For POSIXv2, the origin serves files directly via the web server, not XRootD.
 		// Update Origin.Url to use the external web URL which is now set to the correct port.
 		externalWebUrl := param.Server_ExternalWebUrl.GetString()
@@ -0,0 +1,363 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"hash/maphash"
+	"io"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+// Reasons an object is streamed through to the client instead of being
+// written to disk, as reported in the bypass metrics.
+const (
+	admissionBypassInfrequent = "infrequent"
+	admissionBypassTooLarge   = "too_large"
+	admissionBypassOverQuota  = "over_quota"
+)
+
+const (
+	// sketchDepth is the number of rows in the count-min sketch
+	sketchDepth = 4
+	// sketchMaxCount caps each sketch counter.  As in TinyLFU, small
+	// counters suffice: admission only needs to tell "a few" from "none".
+	sketchMaxCount = 15
+	// doorkeeperHashes is the number of bits set per key in the doorkeeper
+	doorkeeperHashes = 4
+	// sketchSampleFactor sets the aging period: counts are halved after
+	// this many requests per tracked object.
+	sketchSampleFactor = 10
+)
+
+var (
+	admissionBypassedObjects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_cache_admission_bypassed_objects_total",
+		Help: "Total number of objects streamed to clients without being written to disk, by reason",
+	}, []string{"reason"})
+	admissionBypassedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_cache_admission_bypassed_bytes_total",
+		Help: "Total bytes streamed to clients without being written to disk, by reason",
+	}, []string{"reason"})
+)
+
+// AdmissionConfig configures which objects fetched on a cache miss are
+// written to disk.
+type AdmissionConfig struct {
+	// Filter enables the frequency filter: an object is only stored once it
+	// has been requested MinAccesses times.
+	Filter      bool
+	MinAccesses int
+	// Capacity is the approximate number of distinct objects the frequency
+	// filter tracks.
+	Capacity int
+
+	// MaxObjectSize is the largest object stored; 0 means no limit.
+	// NamespaceMaxObjectSizes overrides it per top-level namespace prefix.
+	MaxObjectSize           uint64
+	NamespaceMaxObjectSizes map[string]uint64
+}
+
+// AdmissionStats reports the admission controller's decisions
+type AdmissionStats struct {
+	FilterEnabled      bool
+	BypassedInfrequent uint64
+	BypassedTooLarge   uint64
+	BypassedOverQuota  uint64
+	BypassedBytes      uint64
+}
+
+// admissionConfigFromParams builds the admission configuration from the
+// Cache.* parameters.
+func admissionConfigFromParams() (AdmissionConfig, error) {
+	cfg := AdmissionConfig{
+		Filter:      param.Cache_EnableAdmissionFilter.GetBool(),
+		MinAccesses: param.Cache_AdmissionMinAccesses.GetInt(),
+		Capacity:    param.Cache_AdmissionFilterCapacity.GetInt(),
+	}
+	if sizeStr := param.Cache_MaxObjectSize.GetString(); sizeStr != "" {
+		maxSize, err := utils.ParseBytes(sizeStr)
+		if err != nil {
+			return cfg, errors.Wrapf(err, "failed to parse %s", param.Cache_MaxObjectSize.GetName())
+		}
+		cfg.MaxObjectSize = maxSize
+	}
+	if param.Cache_NamespaceMaxObjectSizes.IsSet() {
+		var sizeStrs map[string]string
+		if err := param.Cache_NamespaceMaxObjectSizes.Unmarshal(&sizeStrs); err != nil {
+			return cfg, errors.Wrapf(err, "failed to parse %s", param.Cache_NamespaceMaxObjectSizes.GetName())
+		}
+		cfg.NamespaceMaxObjectSizes = make(map[string]uint64, len(sizeStrs))
+		for prefix, sizeStr := range sizeStrs {
+			maxSize, err := utils.ParseBytes(sizeStr)
+			if err != nil {
+				return cfg, errors.Wrapf(err, "failed to parse %s for namespace %s", param.Cache_NamespaceMaxObjectSizes.GetName(), prefix)
+			}
+			cfg.NamespaceMaxObjectSizes[prefix] = maxSize
+		}
+	}
+	return cfg, nil
+}
+
+// admissionController decides whether an object fetched on a cache miss is
+// worth writing to disk.  Objects it turns away are streamed through to the
+// client the same way as responses the origin marks no-store.
+type admissionController struct {
+	cfg             AdmissionConfig
+	namespacePrefix func(NamespaceID) (string, bool)
+
+	mu     sync.Mutex
+	sketch *frequencySketch // nil when the frequency filter is disabled
+
+	bypassedInfrequent atomic.Uint64
+	bypassedTooLarge   atomic.Uint64
+	bypassedOverQuota  atomic.Uint64
+	bypassedBytes      atomic.Uint64
+}
+
+func newAdmissionController(cfg AdmissionConfig, namespacePrefix func(NamespaceID) (string, bool)) *admissionController {
+	ac := &admissionController{cfg: cfg, namespacePrefix: namespacePrefix}
+	if cfg.Filter {
+		if ac.cfg.MinAccesses < 1 {
+			ac.cfg.MinAccesses = 1
+		}
+		if ac.cfg.Capacity < 1 {
+			ac.cfg.Capacity = 100_000
+		}
+		ac.sketch = newFrequencySketch(ac.cfg.Capacity)
+	}
+	return ac
+}
+
+// filtering reports whether the frequency filter is enabled
+func (ac *admissionController) filtering() bool {
+	return ac != nil && ac.sketch != nil
+}
+
+// admitFrequency records a request for an object that is not in the cache
+// and reports whether it has now been requested often enough to be stored.
+func (ac *admissionController) admitFrequency(objectHash ObjectHash) bool {
+	if !ac.filtering() {
+		return true
+	}
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	return ac.sketch.increment(string(objectHash)) >= ac.cfg.MinAccesses
+}
+
+// notePopular records enough requests for an object known to be popular
+// elsewhere (see warmup.go) that the frequency filter admits it.
+func (ac *admissionController) notePopular(objectHash ObjectHash) {
+	if !ac.filtering() {
+		return
+	}
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	for range ac.cfg.MinAccesses {
+		if ac.sketch.increment(string(objectHash)) >= ac.cfg.MinAccesses {
+			return
+		}
+	}
+}
+
+// admitSize reports whether an object of the given size may be stored for
+// the namespace.  Objects of unknown (negative) size are always admitted.
+func (ac *admissionController) admitSize(namespaceID NamespaceID, size int64) bool {
+	if ac == nil || size < 0 {
+		return true
+	}
+	maxSize := ac.cfg.MaxObjectSize
+	if ac.namespacePrefix != nil && len(ac.cfg.NamespaceMaxObjectSizes) > 0 {
+		if prefix, ok := ac.namespacePrefix(namespaceID); ok {
+			if nsMax, ok := ac.cfg.NamespaceMaxObjectSizes[prefix]; ok {
+				maxSize = nsMax
+			}
+		}
+	}
+	return maxSize == 0 || uint64(size) <= maxSize
+}
+
+// bypass records that an object is being streamed through for the given
+// reason and wraps its body so the bytes are counted as they are served.
+func (ac *admissionController) bypass(reason string, rc io.ReadCloser) io.ReadCloser {
+	if ac == nil {
+		return rc
+	}
+	switch reason {
+	case admissionBypassInfrequent:
+		ac.bypassedInfrequent.Add(1)
+	case admissionBypassTooLarge:
+		ac.bypassedTooLarge.Add(1)
+	case admissionBypassOverQuota:
+		ac.bypassedOverQuota.Add(1)
+	}
+	admissionBypassedObjects.WithLabelValues(reason).Inc()
+	return &bypassReader{ReadCloser: rc, ac: ac, bytes: admissionBypassedBytes.WithLabelValues(reason)}
+}
+
+// GetStats returns the admission controller's statistics
+func (ac *admissionController) GetStats() AdmissionStats {
+	if ac == nil {
+		return AdmissionStats{}
+	}
+	return AdmissionStats{
+		FilterEnabled:      ac.filtering(),
+		BypassedInfrequent: ac.bypassedInfrequent.Load(),
+		BypassedTooLarge:   ac.bypassedTooLarge.Load(),
+		BypassedOverQuota:  ac.bypassedOverQuota.Load(),
+		BypassedBytes:      ac.bypassedBytes.Load(),
+	}
+}
+
+// bypassReader counts the bytes of a bypassed object as they are read
+type bypassReader struct {
+	io.ReadCloser
+	ac    *admissionController
+	bytes prometheus.Counter
+}
+
+func (r *bypassReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.ac.bypassedBytes.Add(uint64(n))
+		r.bytes.Add(float64(n))
+	}
+	return n, err
+}
+
+// frequencySketch estimates how often keys have been seen, in the style of
+// TinyLFU: a Bloom-filter "doorkeeper" absorbs the first sighting of each
+// key, so the many keys seen only once never reach the count-min sketch
+// behind it.  Every sampleLimit increments, the doorkeeper is cleared and
+// the sketch's counts halved so that old popularity fades.  Not safe for
+// concurrent use.
+type frequencySketch struct {
+	seed maphash.Seed
+
+	doorkeeper []uint64
+	dkMask     uint64
+
+	counters [sketchDepth][]uint8
+	mask     uint64
+
+	samples     int
+	sampleLimit int
+}
+
+func newFrequencySketch(capacity int) *frequencySketch {
+	width := uint64(1) << bits.Len64(uint64(max(capacity, 64)-1))
+	s := &frequencySketch{
+		seed: maphash.MakeSeed(),
+		// Eight doorkeeper bits per counter keeps false positives near 2%
+		doorkeeper:  make([]uint64, width/8),
+		dkMask:      width*8 - 1,
+		mask:        width - 1,
+		sampleLimit: capacity * sketchSampleFactor,
+	}
+	for row := range s.counters {
+		s.counters[row] = make([]uint8, width)
+	}
+	return s
+}
+
+// hashes returns the two base hashes from which each row's index is
+// derived by double hashing.
+func (s *frequencySketch) hashes(key string) (uint64, uint64) {
+	h := maphash.String(s.seed, key)
+	return h, bits.RotateLeft64(h, 32) | 1
+}
+
+// increment records a sighting of key and returns its estimated count,
+// including this sighting.
+func (s *frequencySketch) increment(key string) int {
+	h1, h2 := s.hashes(key)
+	minCount := uint8(sketchMaxCount)
+	for row := range s.counters {
+		minCount = min(minCount, s.counters[row][(h1+uint64(row)*h2)&s.mask])
+	}
+	// The doorkeeper absorbs the first sighting since the last aging; only
+	// repeat sightings reach the sketch.  Conservative update raises just
+	// the counters at the minimum, which limits over-estimation from
+	// collisions.
+	if !s.doorkeeperTestAndSet(h1, h2) && minCount < sketchMaxCount {
+		for row := range s.counters {
+			if c := &s.counters[row][(h1+uint64(row)*h2)&s.mask]; *c == minCount {
+				*c++
+			}
+		}
+		minCount++
+	}
+
+	s.samples++
+	if s.samples >= s.sampleLimit {
+		s.age()
+	}
+	return 1 + int(minCount)
+}
+
+// estimate returns key's estimated count without recording a sighting
+func (s *frequencySketch) estimate(key string) int {
+	h1, h2 := s.hashes(key)
+	minCount := uint8(sketchMaxCount)
+	for row := range s.counters {
+		minCount = min(minCount, s.counters[row][(h1+uint64(row)*h2)&s.mask])
+	}
+	for i := range uint64(doorkeeperHashes) {
+		bit := (h1 + (i+sketchDepth)*h2) & s.dkMask
+		if s.doorkeeper[bit/64]&(uint64(1)<<(bit%64)) == 0 {
+			return int(minCount)
+		}
+	}
+	return 1 + int(minCount)
+}
+
+// doorkeeperTestAndSet sets key's doorkeeper bits, returning true if they
+// were not all set already, i.e. if this is (probably) the key's first
+// sighting since the last aging.
+func (s *frequencySketch) doorkeeperTestAndSet(h1, h2 uint64) bool {
+	first := false
+	for i := range uint64(doorkeeperHashes) {
+		bit := (h1 + (i+sketchDepth)*h2) & s.dkMask
+		word, mask := &s.doorkeeper[bit/64], uint64(1)<<(bit%64)
+		if *word&mask == 0 {
+			first = true
+			*word |= mask
+		}
+	}
+	return first
+}
+
+// age clears the doorkeeper and halves every count
+func (s *frequencySketch) age() {
+	clear(s.doorkeeper)
+	for row := range s.counters {
+		for idx := range s.counters[row] {
+			s.counters[row][idx] >>= 1
+		}
+	}
+	s.samples /= 2
+}
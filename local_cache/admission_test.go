@@ -0,0 +1,136 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_utils"
+)
+
+func TestFrequencySketch(t *testing.T) {
+	s := newFrequencySketch(1000)
+
+	// The doorkeeper absorbs the first sighting; later ones are counted
+	for expected := 1; expected <= 5; expected++ {
+		assert.Equal(t, expected, s.increment("popular"))
+	}
+
+	// A thousand one-hit keys mostly stay out of the sketch, so their first
+	// sighting is (nearly always) estimated as a single access
+	firstSightings := 0
+	for idx := range 1000 {
+		if s.increment(fmt.Sprintf("one-hit-%d", idx)) == 1 {
+			firstSightings++
+		}
+	}
+	assert.Greater(t, firstSightings, 950)
+
+	// Counts are capped
+	for range 2 * sketchMaxCount {
+		s.increment("capped")
+	}
+	assert.Equal(t, sketchMaxCount+1, s.increment("capped"))
+}
+
+func TestFrequencySketchAging(t *testing.T) {
+	s := newFrequencySketch(64)
+	s.sampleLimit = 11
+	for range 9 {
+		s.increment("old")
+	}
+	// The eleventh sample ages the sketch
+	s.increment("a")
+	s.increment("b")
+	assert.Equal(t, 5, s.samples)
+
+	// Aging clears the doorkeeper and halves the sketch: the 8 counted
+	// sightings become 4, plus this one
+	assert.Equal(t, 5, s.increment("old"))
+	assert.Equal(t, 6, s.increment("old"))
+}
+
+func TestAdmissionController(t *testing.T) {
+	ac := newAdmissionController(AdmissionConfig{
+		Filter:                  true,
+		MinAccesses:             2,
+		MaxObjectSize:           1000,
+		NamespaceMaxObjectSizes: map[string]uint64{"/big": 0, "/small": 10},
+	}, func(id NamespaceID) (string, bool) {
+		prefix, ok := map[NamespaceID]string{1: "/big", 2: "/small"}[id]
+		return prefix, ok
+	})
+
+	assert.False(t, ac.admitFrequency("obj"), "first request must be streamed through")
+	assert.True(t, ac.admitFrequency("obj"), "second request must be admitted")
+	assert.False(t, ac.admitFrequency("other"))
+
+	assert.True(t, ac.admitSize(3, 1000))
+	assert.False(t, ac.admitSize(3, 1001))
+	assert.True(t, ac.admitSize(3, -1), "unknown sizes are admitted")
+	assert.True(t, ac.admitSize(1, 1<<40), "a zero namespace limit means no limit")
+	assert.False(t, ac.admitSize(2, 11))
+
+	rc := ac.bypass(admissionBypassTooLarge, io.NopCloser(strings.NewReader("hello world")))
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+	require.NoError(t, rc.Close())
+	ac.bypass(admissionBypassInfrequent, io.NopCloser(strings.NewReader("")))
+
+	assert.Equal(t, AdmissionStats{
+		FilterEnabled:      true,
+		BypassedInfrequent: 1,
+		BypassedTooLarge:   1,
+		BypassedBytes:      11,
+	}, ac.GetStats())
+
+	// Without the filter, everything passes the frequency check
+	ac = newAdmissionController(AdmissionConfig{}, nil)
+	assert.True(t, ac.admitFrequency("obj"))
+	assert.True(t, ac.admitSize(1, 1<<40))
+	assert.False(t, ac.GetStats().FilterEnabled)
+}
+
+func TestAdmissionConfigFromParams(t *testing.T) {
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+
+	require.NoError(t, param.Cache_EnableAdmissionFilter.Set(true))
+	require.NoError(t, param.Cache_AdmissionMinAccesses.Set(3))
+	require.NoError(t, param.Cache_MaxObjectSize.Set("2GB"))
+	require.NoError(t, param.Cache_NamespaceMaxObjectSizes.Set(map[string]string{"/scans": "500MB"}))
+	cfg, err := admissionConfigFromParams()
+	require.NoError(t, err)
+	assert.True(t, cfg.Filter)
+	assert.Equal(t, 3, cfg.MinAccesses)
+	assert.Equal(t, uint64(2<<30), cfg.MaxObjectSize)
+	assert.Equal(t, map[string]uint64{"/scans": 500 << 20}, cfg.NamespaceMaxObjectSizes)
+
+	require.NoError(t, param.Cache_NamespaceMaxObjectSizes.Set(map[string]string{"/scans": "lots"}))
+	_, err = admissionConfigFromParams()
+	assert.ErrorContains(t, err, "/scans")
+}
@@ -79,6 +79,10 @@ type AppendWriter struct {
 	flushed int64
 	// pending holds bytes not yet block-aligned enough to write.
 	pending []byte
+	// compressed is set when the object uses block compression, in which
+	// case pending is held back to whole compression units so that they can
+	// be compressed.
+	compressed bool
 
 	// provisional is the ContentLength currently recorded in metadata, always
 	// a whole number of chunks and always >= written.
@@ -119,6 +123,10 @@ func (sm *StorageManager) NewAppendWriter(
 		return nil, errors.Wrap(err, "failed to initialize chunked storage")
 	}
 	meta.NamespaceID = namespaceID
+	meta.Dedup = sm.dedupFor(namespaceID)
+	if !meta.Dedup {
+		meta.Compression = sm.compressionFor(namespaceID)
+	}
 	if err := sm.db.SetMetadata(instanceHash, meta); err != nil {
 		rollback()
 		return nil, errors.Wrap(err, "failed to record namespace on new object")
@@ -149,6 +157,7 @@ func (sm *StorageManager) NewAppendWriter(
 		sizeCode:     chunkSizeCode,
 		provisional:  chunkSize,
 		pending:      make([]byte, 0, BlockDataSize*writeBatchBlocks),
+		compressed:   meta.Compression != CompressionNone,
 	}, nil
 }
 
@@ -176,6 +185,9 @@ func (w *AppendWriter) Write(p []byte) (int, error) {
 	// Write out whole blocks, holding back any partial tail: WriteBlocks
 	// requires block-aligned offsets and pstore never rewrites a block.
 	full := (len(w.pending) / BlockDataSize) * BlockDataSize
+	if w.compressed {
+		full = w.unitAligned(len(w.pending))
+	}
 	if full > 0 {
 		if err := w.flush(w.pending[:full]); err != nil {
 			return len(p), err
@@ -185,6 +197,19 @@ func (w *AppendWriter) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
+// unitAligned returns how many of the first n pending bytes can be written
+// without splitting a compression unit.  Units are aligned within each
+// chunk, so the write may end at a unit boundary or a chunk boundary.
+func (w *AppendWriter) unitAligned(n int) int {
+	end := w.flushed + int64(n)
+	chunkStart := end / w.chunkSize * w.chunkSize
+	aligned := chunkStart + (end-chunkStart)/compressionUnitSize*compressionUnitSize
+	if aligned <= w.flushed {
+		return 0
+	}
+	return int(aligned - w.flushed)
+}
+
 // flush writes a block-aligned run of bytes at the current offset, growing the
 // provisional length first so that every chunk the write touches is allocated
 // at full size.
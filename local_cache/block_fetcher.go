@@ -72,6 +72,7 @@ type BlockFetcherV2 struct {
 	originURL    string
 	token        string
 	fedToken     client.TokenProvider // Federation token provider; resolves to access_token query param
+	peer         *url.URL             // Peer cache holding the object, tried before the origin; nil if none
 	meta         *CacheMetadata
 	tc           *client.TransferClient
 
@@ -135,6 +136,10 @@ type BlockFetcherV2Config struct {
 	// concurrent prefetches across all fetchers and downloads.  When
 	// nil, a per-fetcher semaphore is created with capacity 5.
 	PrefetchSem chan struct{}
+	// Peer is the object's URL on a peer cache known to hold a complete
+	// copy.  When set, ranges are fetched from the peer first, falling back
+	// to the origin if it fails.
+	Peer *url.URL
 }
 
 // NewBlockFetcherV2 creates a new block fetcher using the Pelican transfer client.
@@ -186,6 +191,7 @@ func NewBlockFetcherV2(
 		originURL:       originURL,
 		token:           token,
 		fedToken:        fedToken,
+		peer:            cfg.Peer,
 		meta:            meta,
 		tc:              tc,
 		prefetchTimeout: cfg.PrefetchTimeout,
@@ -549,6 +555,10 @@ func (bf *BlockFetcherV2) doFetch(ctx context.Context, op *fetchOperation, key f
 	if bf.fedToken != nil {
 		opts = append(opts, client.WithFedToken(bf.fedToken))
 	}
+	if bf.peer != nil {
+		// The trailing "+" keeps the director's servers as a fallback
+		opts = append(opts, client.WithCaches(bf.peer, &url.URL{Path: "+"}))
+	}
 
 	tj, err := bf.tc.NewTransferJob(ctx, sourceURL, "", false, false, opts...)
 	if err != nil {
@@ -195,6 +195,13 @@ func (obs *ObjectBlockState) ClearDownloading() {
 	obs.cond.Broadcast()
 }
 
+// IsDownloading reports whether a background download is in progress
+func (obs *ObjectBlockState) IsDownloading() bool {
+	obs.mu.RLock()
+	defer obs.mu.RUnlock()
+	return obs.downloading
+}
+
 // WaitForBlock waits until the specified block is available in the bitmap.
 // It returns true if the block is available, false if the context was
 // cancelled or the background download finished without producing the
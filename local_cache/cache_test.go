@@ -97,6 +97,65 @@ func TestFedPublicGet(t *testing.T) {
 	reader.Close()
 }
 
+// Test that the admission controller streams first-time and oversized
+// objects through without storing them.
+func TestFedAdmissionControl(t *testing.T) {
+	t.Cleanup(test_utils.SetupTestLogging(t))
+	server_utils.ResetTestState()
+	ft := fed_test_utils.NewFedTest(t, pubOriginCfg)
+
+	get := func(pc *local_cache.PersistentCache) {
+		reader, err := pc.Get(context.Background(), "/test/hello_world.txt", "")
+		require.NoError(t, err)
+		byteBuff, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello, World!", string(byteBuff))
+		reader.Close()
+	}
+
+	t.Run("seen-twice", func(t *testing.T) {
+		pc, err := local_cache.NewPersistentCache(ft.Ctx, ft.Egrp, local_cache.PersistentCacheConfig{
+			BaseDir:   t.TempDir(),
+			Admission: &local_cache.AdmissionConfig{Filter: true, MinAccesses: 2},
+		})
+		require.NoError(t, err)
+		defer pc.Close()
+
+		// The first read is served but not stored
+		get(pc)
+		meta, err := pc.GetMetadata("/test/hello_world.txt", "")
+		require.NoError(t, err)
+		assert.Nil(t, meta)
+		stats := pc.GetStats().AdmissionStats
+		assert.Equal(t, uint64(1), stats.BypassedInfrequent)
+		assert.Equal(t, uint64(len("Hello, World!")), stats.BypassedBytes)
+
+		// The second is stored
+		get(pc)
+		meta, err = pc.GetMetadata("/test/hello_world.txt", "")
+		require.NoError(t, err)
+		assert.NotNil(t, meta)
+		assert.Equal(t, uint64(1), pc.GetStats().AdmissionStats.BypassedInfrequent)
+	})
+
+	t.Run("too-large", func(t *testing.T) {
+		pc, err := local_cache.NewPersistentCache(ft.Ctx, ft.Egrp, local_cache.PersistentCacheConfig{
+			BaseDir:   t.TempDir(),
+			Admission: &local_cache.AdmissionConfig{MaxObjectSize: 5},
+		})
+		require.NoError(t, err)
+		defer pc.Close()
+
+		for range 2 {
+			get(pc)
+			meta, err := pc.GetMetadata("/test/hello_world.txt", "")
+			require.NoError(t, err)
+			assert.Nil(t, meta)
+		}
+		assert.Equal(t, uint64(2), pc.GetStats().AdmissionStats.BypassedTooLarge)
+	})
+}
+
 // Test the persistent cache library on an authenticated GET.
 func TestFedAuthGet(t *testing.T) {
 	t.Cleanup(test_utils.SetupTestLogging(t))
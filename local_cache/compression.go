@@ -0,0 +1,519 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+// Block compression.
+//
+// Blocks are encrypted at fixed offsets (see BlockOffset), which is what lets
+// any byte range be read without an index.  Compression keeps that layout by
+// working on units of compressionUnitBlocks aligned blocks: a full unit is
+// compressed into a "frame", which is split into BlockDataSize pieces and
+// encrypted into the unit's first slots.  The slots the frame does not need
+// are never written, so on a sparse filesystem they cost nothing; the block
+// offsets of everything else are unchanged.
+//
+// A frame's first piece carries a small header (algorithm and compressed
+// length).  Frame pieces are encrypted with nonces from a separate domain
+// (BlockEncryptor.frameNonce), so a reader tells a compressed unit from a
+// plain one by whether the unit's first slot authenticates as a frame piece.
+// That makes each unit self-describing: units that did not compress well,
+// partial units at the ends of a range fetch, and units rewritten by repair
+// are simply stored as plain blocks, and the two coexist within an object.
+//
+// An object's usage is charged at its full size when storage is allocated.
+// The slots a frame leaves unwritten are credited back as frames are written
+// and recorded in CacheMetadata.CompressedSlack, so the size limits and
+// watermarks see the space compression saves, and deletion, eviction and
+// migration move only what the object was actually charged.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"math/bits"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/pelicanplatform/pelican/param"
+)
+
+// CompressionAlgorithm identifies how an object's blocks are compressed
+type CompressionAlgorithm uint8
+
+const (
+	// CompressionNone stores every block as is
+	CompressionNone CompressionAlgorithm = 0
+	// CompressionZstd compresses units of blocks with zstd
+	CompressionZstd CompressionAlgorithm = 1
+)
+
+const (
+	// compressionUnitBlocks is the number of blocks compressed together.
+	// It matches DefaultReadBatchBlocks, so a compressed unit is read with
+	// the same single ReadAt as a batch of plain blocks.
+	compressionUnitBlocks = 16
+	// compressionUnitSize is the plaintext size of a full unit
+	compressionUnitSize = compressionUnitBlocks * BlockDataSize
+	// frameHeaderSize is the size of the header at the start of a frame: one
+	// byte of algorithm, three reserved, and the compressed length.
+	frameHeaderSize = 8
+	// defaultCompressionMinSavings is the percentage of a unit's slots that
+	// compression must free for the frame to be kept.
+	defaultCompressionMinSavings = 10
+	// compressionLockStripes is the number of locks serializing writes to
+	// the units of compressed objects.
+	compressionLockStripes = 64
+)
+
+var (
+	compressionUnits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_cache_compression_units_total",
+		Help: "Total number of block units considered for compression, by whether they were stored compressed",
+	}, []string{"result"})
+	compressionSavedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_compression_saved_bytes_total",
+		Help: "Total bytes of disk space not written because block units were stored compressed",
+	})
+)
+
+var (
+	zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+		return zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+	})
+	zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+		return zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(compressionUnitSize))
+	})
+)
+
+// String returns the algorithm's configuration name
+func (a CompressionAlgorithm) String() string {
+	switch a {
+	case CompressionNone:
+		return "none"
+	case CompressionZstd:
+		return "zstd"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseCompressionAlgorithm parses an algorithm name as used in
+// Cache.BlockCompression.  The empty string means no compression.
+func ParseCompressionAlgorithm(name string) (CompressionAlgorithm, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "none":
+		return CompressionNone, nil
+	case "zstd":
+		return CompressionZstd, nil
+	default:
+		return CompressionNone, errors.Errorf("unknown block compression %q", name)
+	}
+}
+
+// CompressionConfig selects the block compression for newly stored objects
+type CompressionConfig struct {
+	// Algorithm is the compression for objects in namespaces without an
+	// entry in NamespaceAlgorithms.
+	Algorithm CompressionAlgorithm
+	// NamespaceAlgorithms overrides Algorithm per top-level namespace prefix
+	NamespaceAlgorithms map[string]CompressionAlgorithm
+	// MinSavingsPercent is the share of a unit's space compression must save
+	// for the unit to be stored compressed.  Zero selects the default.
+	MinSavingsPercent int
+}
+
+// CompressionStats reports the effect of block compression
+type CompressionStats struct {
+	CompressedUnits   uint64
+	UncompressedUnits uint64
+	BytesSaved        uint64
+}
+
+// compressionConfigFromParams builds the compression configuration from the
+// Cache.* parameters.
+func compressionConfigFromParams() (CompressionConfig, error) {
+	algorithm, err := ParseCompressionAlgorithm(param.Cache_BlockCompression.GetString())
+	if err != nil {
+		return CompressionConfig{}, errors.Wrapf(err, "invalid %s", param.Cache_BlockCompression.GetName())
+	}
+	cfg := CompressionConfig{
+		Algorithm:         algorithm,
+		MinSavingsPercent: param.Cache_BlockCompressionMinSavings.GetInt(),
+	}
+	if param.Cache_NamespaceBlockCompression.IsSet() {
+		var names map[string]string
+		if err := param.Cache_NamespaceBlockCompression.Unmarshal(&names); err != nil {
+			return cfg, errors.Wrapf(err, "failed to parse %s", param.Cache_NamespaceBlockCompression.GetName())
+		}
+		cfg.NamespaceAlgorithms = make(map[string]CompressionAlgorithm, len(names))
+		for prefix, name := range names {
+			nsAlgorithm, err := ParseCompressionAlgorithm(name)
+			if err != nil {
+				return cfg, errors.Wrapf(err, "invalid %s for namespace %s", param.Cache_NamespaceBlockCompression.GetName(), prefix)
+			}
+			cfg.NamespaceAlgorithms[prefix] = nsAlgorithm
+		}
+	}
+	return cfg, nil
+}
+
+// blockCompression is the storage manager's compression policy and statistics
+type blockCompression struct {
+	cfg             CompressionConfig
+	namespacePrefix func(NamespaceID) (string, bool)
+
+	compressedUnits   atomic.Uint64
+	uncompressedUnits atomic.Uint64
+	bytesSaved        atomic.Uint64
+}
+
+// SetCompression sets the block compression for objects whose storage is
+// initialized from now on.  namespacePrefix resolves the namespace IDs used
+// to look up per-namespace settings; it may be nil when the configuration
+// has none.  Must be called before the storage manager is shared.
+func (sm *StorageManager) SetCompression(cfg CompressionConfig, namespacePrefix func(NamespaceID) (string, bool)) {
+	if cfg.MinSavingsPercent <= 0 {
+		cfg.MinSavingsPercent = defaultCompressionMinSavings
+	}
+	sm.compression = &blockCompression{cfg: cfg, namespacePrefix: namespacePrefix}
+}
+
+// compressionFor returns the compression for a new object in the namespace
+func (sm *StorageManager) compressionFor(namespaceID NamespaceID) CompressionAlgorithm {
+	bc := sm.compression
+	if bc == nil {
+		return CompressionNone
+	}
+	if bc.namespacePrefix != nil && len(bc.cfg.NamespaceAlgorithms) > 0 {
+		if prefix, ok := bc.namespacePrefix(namespaceID); ok {
+			if algorithm, ok := bc.cfg.NamespaceAlgorithms[prefix]; ok {
+				return algorithm
+			}
+		}
+	}
+	return bc.cfg.Algorithm
+}
+
+// GetCompressionStats returns the block compression statistics
+func (sm *StorageManager) GetCompressionStats() CompressionStats {
+	bc := sm.compression
+	if bc == nil {
+		return CompressionStats{}
+	}
+	return CompressionStats{
+		CompressedUnits:   bc.compressedUnits.Load(),
+		UncompressedUnits: bc.uncompressedUnits.Load(),
+		BytesSaved:        bc.bytesSaved.Load(),
+	}
+}
+
+// lockUnits serializes writes to the units of a compressed object.  A
+// partial write to a unit must read back whether the unit holds a frame and
+// rewrite it if so, and two writers interleaving that would corrupt it.
+func (sm *StorageManager) lockUnits(instanceHash InstanceHash) func() {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(instanceHash))
+	mu := &sm.unitLocks[h.Sum32()%compressionLockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// compressFrame compresses a full unit into a frame padded to whole pieces.
+// It returns nil if the frame would not free enough slots to be worth it.
+func (sm *StorageManager) compressFrame(algorithm CompressionAlgorithm, unit []byte) []byte {
+	if algorithm != CompressionZstd {
+		return nil
+	}
+	enc, err := zstdEncoder()
+	if err != nil {
+		return nil
+	}
+	frame := make([]byte, frameHeaderSize, compressionUnitSize)
+	frame = enc.EncodeAll(unit, frame)
+	pieces := (len(frame) + BlockDataSize - 1) / BlockDataSize
+
+	minSavings := defaultCompressionMinSavings
+	if sm.compression != nil {
+		minSavings = sm.compression.cfg.MinSavingsPercent
+	}
+	saved := compressionUnitBlocks - pieces
+	if saved <= 0 || saved*100 < minSavings*compressionUnitBlocks {
+		sm.recordCompression(false, 0)
+		return nil
+	}
+
+	frame[0] = byte(algorithm)
+	binary.BigEndian.PutUint32(frame[4:frameHeaderSize], uint32(len(frame)-frameHeaderSize))
+	frame = append(frame, make([]byte, pieces*BlockDataSize-len(frame))...)
+	sm.recordCompression(true, int64(saved)*BlockTotalSize)
+	return frame
+}
+
+func (sm *StorageManager) recordCompression(compressed bool, savedBytes int64) {
+	if !compressed {
+		compressionUnits.WithLabelValues("uncompressed").Inc()
+		if sm.compression != nil {
+			sm.compression.uncompressedUnits.Add(1)
+		}
+		return
+	}
+	compressionUnits.WithLabelValues("compressed").Inc()
+	compressionSavedBytes.Add(float64(savedBytes))
+	if sm.compression != nil {
+		sm.compression.compressedUnits.Add(1)
+		sm.compression.bytesSaved.Add(uint64(savedBytes))
+	}
+}
+
+// decodeFrame returns the plaintext of a compressed unit, given the
+// encrypted unit as read from disk (n valid bytes of buf).  ok is false when
+// the unit does not hold a valid frame, in which case its slots must be read
+// as plain blocks.  globalBlock0 is the global block number of the unit's
+// first slot, from which the frame nonces are derived.
+func decodeFrame(encryptor *BlockEncryptor, buf []byte, n int, globalBlock0 uint32) (plain []byte, ok bool) {
+	if n < BlockTotalSize {
+		return nil, false
+	}
+	frame := make([]byte, 0, compressionUnitBlocks*BlockDataSize)
+	frame, err := encryptor.DecryptFramePieceTo(frame, globalBlock0, buf[:BlockTotalSize])
+	if err != nil {
+		return nil, false
+	}
+	algorithm := CompressionAlgorithm(frame[0])
+	payloadLen := int(binary.BigEndian.Uint32(frame[4:frameHeaderSize]))
+	pieces := (frameHeaderSize + payloadLen + BlockDataSize - 1) / BlockDataSize
+	if algorithm != CompressionZstd || pieces >= compressionUnitBlocks || pieces*BlockTotalSize > n {
+		return nil, false
+	}
+	for i := 1; i < pieces; i++ {
+		piece := buf[i*BlockTotalSize : (i+1)*BlockTotalSize]
+		if frame, err = encryptor.DecryptFramePieceTo(frame, globalBlock0+uint32(i), piece); err != nil {
+			return nil, false
+		}
+	}
+
+	dec, err := zstdDecoder()
+	if err != nil {
+		return nil, false
+	}
+	plain, err = dec.DecodeAll(frame[frameHeaderSize:frameHeaderSize+payloadLen], make([]byte, 0, compressionUnitSize))
+	if err != nil || len(plain) != compressionUnitSize {
+		return nil, false
+	}
+	return plain, true
+}
+
+// readUnit reads the encrypted slots of the unit starting at file-local
+// block unitStart into buf, returning the number of bytes read.  Slots past
+// the end of the file are simply not read.
+func readUnit(file *os.File, buf []byte, unitStart uint32) (int, error) {
+	n, err := file.ReadAt(buf[:compressionUnitBlocks*BlockTotalSize], BlockOffset(unitStart))
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	return n, nil
+}
+
+// writeCompressible writes plaintext to a compressed object's file starting
+// at file-local block first.  Each full, aligned unit in data is stored as a
+// frame when it compresses well enough; everything else is stored as plain
+// blocks.  A partial write to a unit currently holding a frame rewrites the
+// whole unit, since the frame's slots are about to be overwritten.
+//
+// It returns the change in the number of file bytes that frames leave
+// unwritten, which the caller credits back to the usage counters with
+// CacheDB.CreditCompressedSlack.  globalBlock0 is the global block number of
+// file-local block 0.  The caller must hold lockUnits for the object.
+func (sm *StorageManager) writeCompressible(file *os.File, encryptor *BlockEncryptor, algorithm CompressionAlgorithm, globalBlock0, first uint32, data []byte) (int64, error) {
+	var slack int64
+	for len(data) > 0 {
+		unitStart := first - first%compressionUnitBlocks
+		n := min(len(data), int(unitStart+compressionUnitBlocks-first)*BlockDataSize)
+		delta, err := sm.writeUnit(file, encryptor, algorithm, globalBlock0, unitStart, first, data[:n])
+		slack += delta
+		if err != nil {
+			return slack, err
+		}
+		data = data[n:]
+		first = unitStart + compressionUnitBlocks
+	}
+	return slack, nil
+}
+
+// writeUnit writes data, which starts at block first and lies within the
+// unit starting at unitStart, and returns the change in the unit's slack:
+// the bytes of its slots left unwritten because it holds a frame.  Only the
+// slots a frame leaves empty count; the empty slots of a plain unit are
+// still to be written.  A slot that was ever written stays allocated, so a
+// frame written over a longer frame or over plain blocks frees nothing.
+func (sm *StorageManager) writeUnit(file *os.File, encryptor *BlockEncryptor, algorithm CompressionAlgorithm, globalBlock0, unitStart, first uint32, data []byte) (int64, error) {
+	bp := readBufPool.Get().(*[]byte)
+	n, err := readUnit(file, *bp, unitStart)
+	var written uint16
+	var existing []byte
+	if err == nil {
+		written = writtenSlots(*bp, n)
+		existing, _ = decodeFrame(encryptor, *bp, n, globalBlock0+unitStart)
+	}
+	readBufPool.Put(bp)
+	slackBefore := 0
+	if existing != nil {
+		slackBefore = compressionUnitBlocks - bits.OnesCount16(written)
+	}
+
+	// Only a full unit can hold a frame; if this one does and the write
+	// covers only part of it, merge the new data into it and write the
+	// unit afresh.
+	if existing != nil && (first != unitStart || len(data) != compressionUnitSize) {
+		copy(existing[int(first-unitStart)*BlockDataSize:], data)
+		first, data = unitStart, existing
+	}
+
+	if first == unitStart && len(data) == compressionUnitSize {
+		if frame := sm.compressFrame(algorithm, data); frame != nil {
+			pieces := len(frame) / BlockDataSize
+			buf := make([]byte, 0, pieces*BlockTotalSize)
+			for i := 0; i < pieces; i++ {
+				buf, err = encryptor.EncryptFramePieceTo(buf, globalBlock0+unitStart+uint32(i), frame[i*BlockDataSize:(i+1)*BlockDataSize])
+				if err != nil {
+					return 0, errors.Wrapf(err, "failed to encrypt frame for block %d", globalBlock0+unitStart)
+				}
+			}
+			if _, err := file.WriteAt(buf, BlockOffset(unitStart)); err != nil {
+				return 0, errors.Wrapf(err, "failed to write frame for block %d", globalBlock0+unitStart)
+			}
+			written |= 1<<pieces - 1
+			slackAfter := compressionUnitBlocks - bits.OnesCount16(written)
+			return int64(slackAfter-slackBefore) * BlockTotalSize, nil
+		}
+	}
+	if err := writePlainBlocks(file, encryptor, globalBlock0, first, data); err != nil {
+		return 0, err
+	}
+	return -int64(slackBefore) * BlockTotalSize, nil
+}
+
+// writtenSlots returns a bitmask of the unit's slots (n valid bytes of buf)
+// that hold data.  An encrypted slot is never all zeros, so a zero slot is
+// one that was never written.
+func writtenSlots(buf []byte, n int) uint16 {
+	var mask uint16
+	for i := 0; i < compressionUnitBlocks && i*BlockTotalSize < n; i++ {
+		slot := buf[i*BlockTotalSize : min((i+1)*BlockTotalSize, n)]
+		if !bytes.Equal(slot, zeroSlot[:len(slot)]) {
+			mask |= 1 << i
+		}
+	}
+	return mask
+}
+
+// zeroSlot is compared against by writtenSlots
+var zeroSlot [BlockTotalSize]byte
+
+// writePlainBlocks encrypts data as ordinary blocks starting at file-local
+// block first.
+func writePlainBlocks(file *os.File, encryptor *BlockEncryptor, globalBlock0, first uint32, data []byte) error {
+	buf := make([]byte, 0, (len(data)+BlockDataSize-1)/BlockDataSize*BlockTotalSize)
+	for offset := 0; offset < len(data); offset += BlockDataSize {
+		block := first + uint32(offset/BlockDataSize)
+		var err error
+		buf, err = encryptor.EncryptBlockTo(buf, globalBlock0+block, data[offset:min(offset+BlockDataSize, len(data))])
+		if err != nil {
+			return errors.Wrapf(err, "failed to encrypt block %d", globalBlock0+block)
+		}
+	}
+	_, err := file.WriteAt(buf, BlockOffset(first))
+	return errors.Wrapf(err, "failed to write blocks starting at %d", globalBlock0+first)
+}
+
+// unitIsFull reports whether the unit starting at file-local block unitStart
+// lies entirely within contentLength, i.e. whether it can hold a frame.
+func unitIsFull(unitStart uint32, contentLength int64) bool {
+	return int64(unitStart)*BlockDataSize+compressionUnitSize <= contentLength
+}
+
+// readFrame returns the plaintext of the unit starting at file-local block
+// unitStart if the unit holds a valid frame, or nil if it must be read as
+// plain blocks.
+func readFrame(file *os.File, encryptor *BlockEncryptor, unitStart, globalBlock0 uint32) ([]byte, error) {
+	bp := readBufPool.Get().(*[]byte)
+	defer readBufPool.Put(bp)
+	n, err := readUnit(file, *bp, unitStart)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read blocks %d-%d", globalBlock0+unitStart, globalBlock0+unitStart+compressionUnitBlocks-1)
+	}
+	plain, _ := decodeFrame(encryptor, *bp, n, globalBlock0+unitStart)
+	return plain, nil
+}
+
+// decryptUnitsFromFile is decryptBlocksFromFile for an object stored with
+// block compression.  Each full unit overlapping the request is first tried
+// as a frame; units that are not frames are decrypted block by block.
+// Blocks decoded from a frame are all offered to the memory tier, since the
+// whole unit had to be decompressed anyway.
+func decryptUnitsFromFile(file *os.File, contentLength int64, encryptor *BlockEncryptor, dst []byte, startOffset int64, tier *memoryTier, instanceHash InstanceHash, globalBlockNum0 uint32) (int, error) {
+	endOffset := min(startOffset+int64(len(dst)), contentLength)
+	admit := tier.admits(instanceHash)
+	resultPos := 0
+	for offset := startOffset; offset < endOffset; {
+		unitStart := ContentOffsetToBlock(offset) / compressionUnitBlocks * compressionUnitBlocks
+		unitOffset := int64(unitStart) * BlockDataSize
+		n := int(min(unitOffset+compressionUnitSize, endOffset) - offset)
+		out := dst[resultPos : resultPos+n]
+
+		if tier.readInto(instanceHash, globalBlockNum0, out, offset) {
+			resultPos += n
+			offset += int64(n)
+			continue
+		}
+
+		var plain []byte
+		if unitIsFull(unitStart, contentLength) {
+			var err error
+			if plain, err = readFrame(file, encryptor, unitStart, globalBlockNum0); err != nil {
+				return 0, err
+			}
+		}
+		if plain == nil {
+			m, err := decryptBlocksFromFile(file, contentLength, encryptor, out, offset, tier, instanceHash, globalBlockNum0, CompressionNone)
+			if err != nil {
+				return 0, err
+			}
+			n = m
+		} else {
+			copy(out, plain[offset-unitOffset:])
+			if admit {
+				for i := 0; i < compressionUnitBlocks; i++ {
+					tier.put(instanceHash, globalBlockNum0+unitStart+uint32(i), plain[i*BlockDataSize:(i+1)*BlockDataSize])
+				}
+			}
+		}
+		resultPos += n
+		offset += int64(n)
+	}
+	return resultPos, nil
+}
@@ -0,0 +1,275 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compressibleTestData returns size bytes of repetitive text
+func compressibleTestData(size int) []byte {
+	line := []byte("2026-10-18T12:00:00Z,sensor-42,temperature,21.5,humidity,40.1\n")
+	return bytes.Repeat(line, size/len(line)+1)[:size]
+}
+
+// newCompressedTestObject stores data as a new on-disk object, compressed or
+// not according to the storage manager's current setting.
+func newCompressedTestObject(t *testing.T, sm *StorageManager, data []byte) InstanceHash {
+	t.Helper()
+	hash := InstanceHash(randomHexForTest(t, 32))
+	_, err := sm.InitDiskStorage(t.Context(), hash, int64(len(data)), StorageIDFirstDisk, NamespaceID(1))
+	require.NoError(t, err)
+	require.NoError(t, sm.WriteBlocks(hash, 0, data))
+	return hash
+}
+
+func TestParseCompressionAlgorithm(t *testing.T) {
+	for name, want := range map[string]CompressionAlgorithm{"": CompressionNone, "none": CompressionNone, "ZSTD": CompressionZstd} {
+		got, err := ParseCompressionAlgorithm(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseCompressionAlgorithm("lz4")
+	assert.Error(t, err)
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 1)
+	sm.SetCompression(CompressionConfig{Algorithm: CompressionZstd}, nil)
+
+	// Ten full units plus a partial tail, which is stored uncompressed
+	data := compressibleTestData(10*compressionUnitSize + 3*BlockDataSize + 100)
+	hash := newCompressedTestObject(t, sm, data)
+
+	meta, err := sm.GetMetadata(hash)
+	require.NoError(t, err)
+	assert.Equal(t, CompressionZstd, meta.Compression)
+	assert.False(t, meta.Completed.IsZero())
+
+	got, err := sm.ReadBlocks(hash, 0, len(data))
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	// Reads that start and end mid-unit
+	got, err = sm.ReadBlocks(hash, compressionUnitSize+123, 2*compressionUnitSize)
+	require.NoError(t, err)
+	assert.Equal(t, data[compressionUnitSize+123:3*compressionUnitSize+123], got)
+
+	stats := sm.GetCompressionStats()
+	assert.Equal(t, uint64(10), stats.CompressedUnits)
+	assert.Zero(t, stats.UncompressedUnits)
+	assert.Positive(t, stats.BytesSaved)
+
+	corrupt, err := sm.IdentifyCorruptBlocks(hash, 0, CalculateBlockCount(int64(len(data)))-1)
+	require.NoError(t, err)
+	assert.Empty(t, corrupt)
+}
+
+func TestCompressionSkipsIncompressibleUnits(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 1)
+	sm.SetCompression(CompressionConfig{Algorithm: CompressionZstd}, nil)
+
+	data := make([]byte, 4*compressionUnitSize)
+	_, err := crand.Read(data)
+	require.NoError(t, err)
+	hash := newCompressedTestObject(t, sm, data)
+
+	got, err := sm.ReadBlocks(hash, 0, len(data))
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.Equal(t, CompressionStats{UncompressedUnits: 4}, sm.GetCompressionStats())
+}
+
+func TestCompressionPartialWrites(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 1)
+	sm.SetCompression(CompressionConfig{Algorithm: CompressionZstd}, nil)
+
+	data := compressibleTestData(2 * compressionUnitSize)
+	hash := newCompressedTestObject(t, sm, data)
+
+	// Rewriting blocks inside a compressed unit (as a repair does) merges
+	// them into the unit rather than clobbering the frame.
+	patch := bytes.Repeat([]byte{'x'}, 2*BlockDataSize)
+	require.NoError(t, sm.WriteBlocks(hash, 5*BlockDataSize, patch))
+	copy(data[5*BlockDataSize:], patch)
+
+	got, err := sm.ReadBlocks(hash, 0, len(data))
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	// A unit filled in by separate writes is stored as plain blocks
+	other := InstanceHash(randomHexForTest(t, 32))
+	_, err = sm.InitDiskStorage(t.Context(), other, int64(len(data)), StorageIDFirstDisk, NamespaceID(1))
+	require.NoError(t, err)
+	require.NoError(t, sm.WriteBlocks(other, 0, data[:7*BlockDataSize]))
+	require.NoError(t, sm.WriteBlocks(other, 7*BlockDataSize, data[7*BlockDataSize:]))
+	got, err = sm.ReadBlocks(other, 0, len(data))
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
+
+func TestCompressionChargesWrittenSpace(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 1)
+	sm.SetCompression(CompressionConfig{Algorithm: CompressionZstd}, nil)
+	ns := NamespaceID(1)
+
+	data := compressibleTestData(4*compressionUnitSize + 100)
+	hash := newCompressedTestObject(t, sm, data)
+	full := CalculateFileSize(int64(len(data)))
+
+	// The slots the frames leave empty are credited back to usage
+	meta, err := sm.GetMetadata(hash)
+	require.NoError(t, err)
+	require.Len(t, meta.CompressedSlack, 1)
+	slack := meta.CompressedSlack[0]
+	assert.Equal(t, int64(sm.GetCompressionStats().BytesSaved), slack)
+	usage, err := sm.db.GetUsage(StorageIDFirstDisk, ns)
+	require.NoError(t, err)
+	assert.Equal(t, full-slack, usage)
+	assert.Equal(t, full-slack, meta.PerDirectoryBytes()[StorageIDFirstDisk])
+
+	// Rewriting a compressed unit with data that doesn't compress stores
+	// it as plain blocks, which charges its slots again.
+	unit := make([]byte, compressionUnitSize)
+	_, err = crand.Read(unit)
+	require.NoError(t, err)
+	require.NoError(t, sm.WriteBlocks(hash, 0, unit))
+	meta, err = sm.GetMetadata(hash)
+	require.NoError(t, err)
+	assert.Less(t, meta.CompressedSlack[0], slack)
+	usage, err = sm.db.GetUsage(StorageIDFirstDisk, ns)
+	require.NoError(t, err)
+	assert.Equal(t, full-meta.CompressedSlack[0], usage)
+
+	// Deleting the object uncharges exactly what it was charged
+	require.NoError(t, sm.Delete(hash))
+	usage, err = sm.db.GetUsage(StorageIDFirstDisk, ns)
+	require.NoError(t, err)
+	assert.Zero(t, usage)
+}
+
+func TestCompressionMixedObjects(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 1)
+	data := compressibleTestData(3 * compressionUnitSize)
+
+	plain := newCompressedTestObject(t, sm, data)
+	sm.SetCompression(CompressionConfig{
+		NamespaceAlgorithms: map[string]CompressionAlgorithm{"/logs": CompressionZstd},
+	}, func(id NamespaceID) (string, bool) { return "/logs", id == 1 })
+	compressed := newCompressedTestObject(t, sm, data)
+
+	for hash, want := range map[InstanceHash]CompressionAlgorithm{plain: CompressionNone, compressed: CompressionZstd} {
+		meta, err := sm.GetMetadata(hash)
+		require.NoError(t, err)
+		assert.Equal(t, want, meta.Compression)
+
+		got, err := sm.ReadBlocks(hash, 0, len(data))
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+	}
+}
+
+func TestCompressionDetectsCorruptFrame(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 1)
+	sm.SetCompression(CompressionConfig{Algorithm: CompressionZstd}, nil)
+
+	data := compressibleTestData(2 * compressionUnitSize)
+	hash := newCompressedTestObject(t, sm, data)
+	meta, err := sm.GetMetadata(hash)
+	require.NoError(t, err)
+
+	// Damage the first frame; the second unit stays intact
+	f, err := os.OpenFile(sm.getObjectPathForDir(meta.StorageID, hash), os.O_RDWR, 0)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, BlockOffset(0)+100)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	corrupt, err := sm.IdentifyCorruptBlocks(hash, 0, 2*compressionUnitBlocks-1)
+	require.NoError(t, err)
+	expected := make([]uint32, compressionUnitBlocks)
+	for i := range expected {
+		expected[i] = uint32(i)
+	}
+	assert.Equal(t, expected, corrupt)
+
+	_, err = sm.ReadBlocks(hash, 0, BlockDataSize)
+	assert.Error(t, err)
+	got, err := sm.ReadBlocks(hash, compressionUnitSize, compressionUnitSize)
+	require.NoError(t, err)
+	assert.Equal(t, data[compressionUnitSize:], got)
+}
+
+func TestCompressionBlockWriter(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 1)
+	sm.SetCompression(CompressionConfig{Algorithm: CompressionZstd}, nil)
+
+	data := compressibleTestData(5*compressionUnitSize + 1000)
+	hash := InstanceHash(randomHexForTest(t, 32))
+	_, err := sm.InitDiskStorage(t.Context(), hash, int64(len(data)), StorageIDFirstDisk, NamespaceID(1))
+	require.NoError(t, err)
+
+	bw, err := sm.NewBlockWriter(hash, 0, nil, nil)
+	require.NoError(t, err)
+	for off := 0; off < len(data); off += 10000 {
+		_, err := bw.Write(data[off:min(off+10000, len(data))])
+		require.NoError(t, err)
+	}
+	require.NoError(t, bw.Close())
+
+	got, err := sm.ReadBlocks(hash, 0, len(data))
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.Equal(t, uint64(5), sm.GetCompressionStats().CompressedUnits)
+
+	meta, err := sm.GetMetadata(hash)
+	require.NoError(t, err)
+	require.Len(t, meta.CompressedSlack, 1)
+	assert.Equal(t, int64(sm.GetCompressionStats().BytesSaved), meta.CompressedSlack[0])
+}
+
+func TestCompressionAppendWriter(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 2)
+	sm.SetCompression(CompressionConfig{Algorithm: CompressionZstd}, nil)
+
+	// Chunks of 2 MiB are not a whole number of units, so units are aligned
+	// within each chunk.
+	data := compressibleTestData(5*1024*1024 + 77)
+	hash := InstanceHash(randomHexForTest(t, 32))
+	w, err := sm.NewAppendWriter(t.Context(), hash, NamespaceID(1), BytesToChunkSizeCode(2*1024*1024))
+	require.NoError(t, err)
+	for off := 0; off < len(data); off += 3000 {
+		_, err := w.Write(data[off:min(off+3000, len(data))])
+		require.NoError(t, err)
+	}
+	meta, err := w.Finalize()
+	require.NoError(t, err)
+	assert.Equal(t, CompressionZstd, meta.Compression)
+
+	got, err := sm.ReadBlocks(hash, 0, len(data))
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.Greater(t, sm.GetCompressionStats().CompressedUnits, uint64(70))
+}
@@ -620,16 +620,13 @@ func (cc *ConsistencyChecker) RunMetadataScan(ctx context.Context, progressCh ch
 			// totals match the real state of the database.
 			//
 			// Both completed and in-progress objects are charged at
-			// their actual on-disk size: CalculateFileSize(ContentLength)
-			// for disk objects (which accounts for the 16-byte MAC per
-			// 4080-byte block), or ContentLength for inline objects.
-			if meta.ContentLength > 0 {
-				uk := StorageUsageKey{StorageID: meta.StorageID, NamespaceID: meta.NamespaceID}
-				if meta.StorageID == StorageIDInline {
-					usageDuringScan[uk] += meta.ContentLength
-				} else {
-					usageDuringScan[uk] += CalculateFileSize(meta.ContentLength)
-				}
+			// their actual on-disk size, as given by PerDirectoryBytes:
+			// CalculateFileSize(ContentLength) for disk objects (which
+			// accounts for the 16-byte MAC per 4080-byte block) less the
+			// space compressed units leave unwritten, or ContentLength
+			// for inline objects.
+			for sid, size := range meta.PerDirectoryBytes() {
+				usageDuringScan[StorageUsageKey{StorageID: sid, NamespaceID: meta.NamespaceID}] += size
 			}
 
 			// Only process entries old enough to avoid races
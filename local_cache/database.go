@@ -891,6 +891,12 @@ func mergeMetadataFields(existing, incoming *CacheMetadata) error {
 	if err := mergeSetOnceComparable("NamespaceID", &existing.NamespaceID, incoming.NamespaceID); err != nil {
 		return err
 	}
+	if err := mergeSetOnceComparable("Compression", &existing.Compression, incoming.Compression); err != nil {
+		return err
+	}
+	if err := mergeSetOnceComparable("Dedup", &existing.Dedup, incoming.Dedup); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -1443,6 +1449,64 @@ func (cdb *CacheDB) ChargeUsage(storageID StorageID, namespaceID NamespaceID, de
 	return cdb.AddUsage(storageID, namespaceID, delta)
 }
 
+// CreditCompressedSlack records that delta more bytes of a chunk's file are
+// left unwritten by compressed units (delta is negative when a compressed
+// unit is rewritten uncompressed) and credits them back to the usage counter
+// the chunk is charged to.  If the object has been deleted in the meantime
+// nothing is changed, since its deletion only uncharged what was recorded.
+func (cdb *CacheDB) CreditCompressedSlack(instanceHash InstanceHash, chunkIndex int, delta int64) error {
+	if err := cdb.checkWritable(); err != nil {
+		return err
+	}
+	if delta == 0 {
+		return nil
+	}
+	const maxRetries = 20
+	backoff := 100 * time.Microsecond
+	for attempt := 0; ; attempt++ {
+		var key StorageUsageKey
+		err := cdb.db.Update(func(txn *badger.Txn) error {
+			meta, err := getMetadataInTxn(txn, instanceHash)
+			if err != nil {
+				return err
+			}
+			key = StorageUsageKey{}
+			if meta == nil || meta.GetChunkStorageID(chunkIndex) == StorageIDInline {
+				return nil
+			}
+			for len(meta.CompressedSlack) <= chunkIndex {
+				meta.CompressedSlack = append(meta.CompressedSlack, 0)
+			}
+			meta.CompressedSlack[chunkIndex] += delta
+			data, err := msgpack.Marshal(meta)
+			if err != nil {
+				return errors.Wrap(err, "failed to marshal metadata")
+			}
+			key = StorageUsageKey{StorageID: meta.GetChunkStorageID(chunkIndex), NamespaceID: meta.NamespaceID}
+			return txn.Set(MetaKey(instanceHash), data)
+		})
+		if err == nil {
+			if key.StorageID == StorageIDInline {
+				return nil
+			}
+			// As elsewhere, usage moves through the MergeOperator outside
+			// the transaction so it cannot cause conflicts.
+			return cdb.AddUsage(key.StorageID, key.NamespaceID, -delta)
+		}
+		if errors.Is(err, badger.ErrConflict) && attempt < maxRetries-1 {
+			n, _ := rand.Int(rand.Reader, big.NewInt(int64(backoff)))
+			jitter := time.Duration(n.Int64())
+			time.Sleep(backoff + jitter)
+			backoff *= 2
+			if backoff > 50*time.Millisecond {
+				backoff = 50 * time.Millisecond
+			}
+			continue
+		}
+		return err
+	}
+}
+
 // StorageUsageKey combines storage ID and namespace ID for usage tracking
 type StorageUsageKey struct {
 	StorageID   StorageID
@@ -1889,6 +1953,55 @@ func (cdb *CacheDB) SetUsage(storageID StorageID, namespaceID NamespaceID, value
 	})
 }
 
+// ConsolidateUsage folds every usage counter's outstanding deltas into a
+// single absolute value.
+//
+// A counter that no MergeOperator is watching is read at its latest version
+// only, which is correct once the operator that wrote it has compacted it --
+// as Close guarantees.  A database loaded from a backup makes no such
+// promise: a snapshot of a live store carries whatever deltas had not yet
+// been compacted, and reading only the newest of them under-reports the
+// counter.  Call this after such a load, before anything reads usage.
+func (cdb *CacheDB) ConsolidateUsage() error {
+	if err := cdb.checkWritable(); err != nil {
+		return err
+	}
+	var keys []StorageUsageKey
+	err := cdb.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		prefix := []byte(PrefixUsage)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			storageID, namespaceID, err := ParseUsageKey(it.Item().Key())
+			if err != nil {
+				continue
+			}
+			keys = append(keys, StorageUsageKey{StorageID: storageID, NamespaceID: namespaceID})
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list usage counters")
+	}
+
+	for _, key := range keys {
+		val, err := cdb.getUsageMergeOp(key.StorageID, key.NamespaceID).Get()
+		if err != nil {
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to read usage for storage %d", key.StorageID)
+		}
+		if err := cdb.SetUsage(key.StorageID, key.NamespaceID, decodeUsage(val)); err != nil {
+			return errors.Wrapf(err, "failed to rewrite usage for storage %d", key.StorageID)
+		}
+	}
+	return nil
+}
+
 // ComputeActualUsage performs a full scan of the metadata table to compute
 // the real byte-level usage per (StorageID, NamespaceID).
 //
@@ -2104,6 +2217,7 @@ type evictedObject struct {
 	namespaceID    NamespaceID
 	chunkSizeCode  ChunkSizeCode   // For chunked objects
 	chunkLocations []ChunkLocation // Locations of chunks 1, 2, ...
+	dedup          bool            // Blocks are held in the shared block pool
 }
 
 // evictionSkipBudget bounds how many protected objects the LRU walk (phases 2
@@ -2142,6 +2256,23 @@ const evictionSkipBudget = 1024
 //
 // Returns the evicted objects and the number that were skipped.
 func (cdb *CacheDB) EvictByLRU(storageID StorageID, namespaceID NamespaceID, maxObjects int, maxBytes int64, skip func(InstanceHash) bool) ([]evictedObject, int, error) {
+	return cdb.evictObjects(storageID, namespaceID, nil, maxObjects, maxBytes, skip)
+}
+
+// EvictInOrder is EvictByLRU with the walk of the LRU index replaced by the
+// given eviction order, as chosen by an EvictionPolicy.  Purge-first items are
+// still drained first, and the cross-directory scan for chunked objects still
+// runs if the ordered candidates are not enough to meet the target.  Entries
+// in order that no longer exist are ignored.
+func (cdb *CacheDB) EvictInOrder(storageID StorageID, namespaceID NamespaceID, order []InstanceHash, maxObjects int, maxBytes int64, skip func(InstanceHash) bool) ([]evictedObject, int, error) {
+	if order == nil {
+		order = []InstanceHash{}
+	}
+	return cdb.evictObjects(storageID, namespaceID, order, maxObjects, maxBytes, skip)
+}
+
+// evictObjects implements EvictByLRU (order == nil) and EvictInOrder.
+func (cdb *CacheDB) evictObjects(storageID StorageID, namespaceID NamespaceID, order []InstanceHash, maxObjects int, maxBytes int64, skip func(InstanceHash) bool) ([]evictedObject, int, error) {
 	if err := cdb.checkWritable(); err != nil {
 		return nil, 0, err
 	}
@@ -2208,6 +2339,7 @@ func (cdb *CacheDB) EvictByLRU(storageID StorageID, namespaceID NamespaceID, max
 				namespaceID:    meta.NamespaceID,
 				chunkSizeCode:  meta.ChunkSizeCode,
 				chunkLocations: meta.ChunkLocations,
+				dedup:          meta.Dedup,
 			})
 			// For chunked objects, decrement usage from each storage
 			// based on the on-disk bytes it holds.  For
@@ -2283,9 +2415,19 @@ func (cdb *CacheDB) EvictByLRU(storageID StorageID, namespaceID NamespaceID, max
 			}
 		}
 
+		// Phase 2 (ordered): evict the caller's candidates in the order given.
+		if order != nil {
+			for _, hash := range order {
+				if limitReached() {
+					break
+				}
+				evictOne(hash, nil, &lruSkipped)
+			}
+		}
+
 		// Phase 2: walk the LRU index for the requested storage+namespace.
 		// This finds objects whose base (chunk 0) is in storageID.
-		if !limitReached() {
+		if order == nil && !limitReached() {
 			lruPrefix := []byte(fmt.Sprintf("%s%d:%d:", PrefixLRU, storageID, namespaceID))
 			opts := badger.DefaultIteratorOptions
 			opts.PrefetchValues = false
@@ -2373,6 +2515,143 @@ func (cdb *CacheDB) EvictByLRU(storageID StorageID, namespaceID NamespaceID, max
 	return evicted, lruSkipped + pfSkipped, err
 }
 
+// errLayoutChanged is returned by RelocateChunks when the object's storage
+// layout no longer matches the one its chunks were copied from.
+var errLayoutChanged = errors.New("object storage layout changed during relocation")
+
+// RelocateChunks records that the listed chunks of an object now live in the
+// target storage directory, moving the object's LRU entry along with chunk 0
+// and shifting the usage each chunk is charged to.
+//
+// expected is the metadata the chunks were copied under.  If the stored
+// record no longer has the same layout -- the object was evicted, replaced,
+// or relocated by someone else in the meantime -- nothing is changed and
+// errLayoutChanged is returned, leaving the caller to discard its copies.
+func (cdb *CacheDB) RelocateChunks(instanceHash InstanceHash, expected *CacheMetadata, chunks []int, target StorageID) error {
+	if err := cdb.checkWritable(); err != nil {
+		return err
+	}
+
+	usageDeltas := make(map[StorageUsageKey]int64)
+	err := cdb.db.Update(func(txn *badger.Txn) error {
+		meta, err := readMetadataInTxn(txn, instanceHash)
+		if err != nil {
+			return err
+		}
+		if meta == nil || !sameStorageLayout(meta, expected) {
+			return errLayoutChanged
+		}
+
+		infos := meta.GetChunkInfo()
+		for _, idx := range chunks {
+			if idx < 0 || idx >= len(infos) {
+				return errors.Errorf("chunk %d out of range", idx)
+			}
+			ci := infos[idx]
+			if ci.StorageID == StorageIDInline || ci.StorageID == target {
+				continue
+			}
+			// The copy keeps the file sparse, so unwritten compressed
+			// slots move without being charged.
+			size := CalculateFileSize(ci.Size) - meta.chunkSlack(idx)
+			usageDeltas[StorageUsageKey{StorageID: ci.StorageID, NamespaceID: meta.NamespaceID}] -= size
+			usageDeltas[StorageUsageKey{StorageID: target, NamespaceID: meta.NamespaceID}] += size
+
+			if idx == 0 {
+				// The LRU index is keyed by the directory holding chunk 0.
+				if !meta.LastAccessTime.IsZero() {
+					oldKey := LRUKey(meta.StorageID, meta.NamespaceID, meta.LastAccessTime, instanceHash)
+					if err := txn.Delete(oldKey); err != nil {
+						return errors.Wrap(err, "failed to delete old LRU key")
+					}
+					newKey := LRUKey(target, meta.NamespaceID, meta.LastAccessTime, instanceHash)
+					if err := txn.Set(newKey, nil); err != nil {
+						return errors.Wrap(err, "failed to set new LRU key")
+					}
+				}
+				meta.StorageID = target
+			} else {
+				meta.ChunkLocations[idx-1].StorageID = target
+			}
+		}
+
+		data, err := msgpack.Marshal(meta)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal relocated metadata")
+		}
+		return txn.Set(MetaKey(instanceHash), data)
+	})
+	if err != nil {
+		return err
+	}
+
+	// As in evictObjects, usage moves through the MergeOperator outside the
+	// transaction so it cannot cause conflicts.
+	for key, delta := range usageDeltas {
+		if err := cdb.AddUsage(key.StorageID, key.NamespaceID, delta); err != nil {
+			log.Warnf("Failed to move usage for storage %d namespace %d: %v",
+				key.StorageID, key.NamespaceID, err)
+		}
+	}
+	return nil
+}
+
+// sameStorageLayout reports whether two metadata records describe the same
+// object version stored in the same places.
+func sameStorageLayout(a, b *CacheMetadata) bool {
+	if a.ETag != b.ETag || a.ContentLength != b.ContentLength ||
+		a.StorageID != b.StorageID || a.ChunkSizeCode != b.ChunkSizeCode ||
+		len(a.ChunkLocations) != len(b.ChunkLocations) || !bytes.Equal(a.DataKey, b.DataKey) {
+		return false
+	}
+	for i := range a.ChunkLocations {
+		if a.ChunkLocations[i] != b.ChunkLocations[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// EvictionCandidate is an entry from the LRU index, as handed to an EvictionPolicy
+type EvictionCandidate struct {
+	InstanceHash InstanceHash
+	LastAccess   time.Time
+}
+
+// ListLRUCandidates returns up to limit entries from the LRU index for a
+// storage+namespace combination, least recently used first.  Only keys are
+// read, so this is cheap even for large namespaces.  The boolean result is
+// true when every entry for the combination was returned.
+func (cdb *CacheDB) ListLRUCandidates(storageID StorageID, namespaceID NamespaceID, limit int) ([]EvictionCandidate, bool, error) {
+	var candidates []EvictionCandidate
+	complete := true
+	err := cdb.db.View(func(txn *badger.Txn) error {
+		lruPrefix := []byte(fmt.Sprintf("%s%d:%d:", PrefixLRU, storageID, namespaceID))
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(lruPrefix); it.ValidForPrefix(lruPrefix); it.Next() {
+			if limit > 0 && len(candidates) >= limit {
+				complete = false
+				break
+			}
+			_, _, lastAccess, hash, err := ParseLRUKey(it.Item().Key())
+			if err != nil {
+				continue
+			}
+			candidates = append(candidates, EvictionCandidate{InstanceHash: hash, LastAccess: lastAccess})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to list LRU candidates")
+	}
+	return candidates, complete, nil
+}
+
 // badgerLogger adapts Pelican's logrus to BadgerDB's logger interface
 type badgerLogger struct {
 	log *log.Entry
@@ -2711,3 +2990,572 @@ func (cdb *CacheDB) PurgeStorageID(storageID StorageID) error {
 	log.Infof("Purged storage ID %d: deleted %d objects", storageID, totalDeleted)
 	return nil
 }
+
+// --- Block Deduplication Operations ---
+//
+// The records below back the shared block pool described in dedup.go.  The
+// storage manager serializes every call that changes them, so the
+// transactions never conflict with one another.
+
+// errDedupStale is returned by CommitDedupBlocks when a block the caller
+// found in the index was released before the commit.  Nothing is changed;
+// the caller looks the blocks up again.
+var errDedupStale = errors.New("deduplicated block released during write")
+
+// LookupDedupBlocks returns the pool location of each block in the
+// namespace's index, or zero for blocks that are not in it.
+func (cdb *CacheDB) LookupDedupBlocks(namespaceID NamespaceID, ids []dedupBlockID) ([]dedupRef, error) {
+	refs := make([]dedupRef, len(ids))
+	err := cdb.db.View(func(txn *badger.Txn) error {
+		for i, id := range ids {
+			idx, err := readDedupIndexInTxn(txn, namespaceID, id)
+			if err != nil {
+				return err
+			}
+			refs[i] = idx.ref
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to look up deduplicated blocks")
+	}
+	return refs, nil
+}
+
+// CommitDedupBlocks records that blocks first, first+1, ... of an object
+// hold the given entries, taking a reference on each and dropping the
+// reference held by whatever those blocks held before.
+//
+// An entry whose block is not yet in the index is added at entry.ref, which
+// must be one of the allocated slots; an entry with a zero ref expects the
+// block to be indexed already, and if it no longer is, errDedupStale is
+// returned.  Allocated slots are claimed from the free list as they are
+// used.  The slots left unreferenced -- allocations that turned out not to
+// be needed and blocks whose last reference was dropped -- are put on the
+// free list and returned.
+func (cdb *CacheDB) CommitDedupBlocks(instanceHash InstanceHash, namespaceID NamespaceID, first uint32, entries []dedupEntry, allocated []dedupRef) ([]dedupRef, error) {
+	if err := cdb.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var freed []dedupRef
+	err := cdb.db.Update(func(txn *badger.Txn) error {
+		freed = freed[:0]
+		used := make(map[dedupRef]bool, len(allocated))
+		groups := make(map[uint32][]byte)
+		for i, e := range entries {
+			block := first + uint32(i)
+			group := block / dedupMapGroupBlocks
+			data, ok := groups[group]
+			if !ok {
+				var err error
+				if data, err = readDedupMapGroupInTxn(txn, instanceHash, group); err != nil {
+					return err
+				}
+			}
+			old := dedupMapEntry(data, block%dedupMapGroupBlocks)
+			idx, err := readDedupIndexInTxn(txn, namespaceID, e.id)
+			if err != nil {
+				return err
+			}
+			if old.ref != 0 && old == (dedupEntry{id: e.id, ref: idx.ref}) {
+				// Rewritten with the same content
+				groups[group] = data
+				continue
+			}
+			if idx.ref == 0 {
+				if e.ref == 0 {
+					return errDedupStale
+				}
+				idx.ref = e.ref
+				used[e.ref] = true
+			}
+			idx.refs++
+			if err := txn.Set(DedupIndexKey(namespaceID, e.id), idx.encode()); err != nil {
+				return errors.Wrap(err, "failed to update dedup index")
+			}
+
+			if old.ref != 0 {
+				released, err := releaseDedupBlockInTxn(txn, namespaceID, old)
+				if err != nil {
+					return err
+				}
+				if released != 0 {
+					freed = append(freed, released)
+				}
+			}
+			groups[group] = setDedupMapEntry(data, namespaceID, block%dedupMapGroupBlocks, dedupEntry{id: e.id, ref: idx.ref})
+		}
+
+		for group, data := range groups {
+			if err := txn.Set(DedupMapKey(instanceHash, group), data); err != nil {
+				return errors.Wrap(err, "failed to update dedup block map")
+			}
+		}
+
+		next := make(map[StorageID]uint64)
+		for _, ref := range allocated {
+			if !used[ref] {
+				freed = append(freed, ref)
+				continue
+			}
+			if err := txn.Delete(DedupFreeKey(ref.storageID(), ref.slot())); err != nil {
+				return errors.Wrap(err, "failed to claim free dedup slot")
+			}
+			next[ref.storageID()] = max(next[ref.storageID()], ref.slot()+1)
+		}
+		for sid, slot := range next {
+			if err := raiseDedupNextSlotInTxn(txn, sid, slot); err != nil {
+				return err
+			}
+		}
+		for _, ref := range freed {
+			if err := txn.Set(DedupFreeKey(ref.storageID(), ref.slot()), nil); err != nil {
+				return errors.Wrap(err, "failed to free dedup slot")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return freed, nil
+}
+
+// FreeDedupSlots puts pool slots that were allocated but never referenced
+// back on the free list.
+func (cdb *CacheDB) FreeDedupSlots(refs []dedupRef) error {
+	if err := cdb.checkWritable(); err != nil {
+		return err
+	}
+	return cdb.db.Update(func(txn *badger.Txn) error {
+		for _, ref := range refs {
+			if err := txn.Set(DedupFreeKey(ref.storageID(), ref.slot()), nil); err != nil {
+				return errors.Wrap(err, "failed to free dedup slot")
+			}
+		}
+		return nil
+	})
+}
+
+// errObjectRecreated stops ReleaseDedupBlocks when the object it is
+// releasing has been stored again under the same instance hash.
+var errObjectRecreated = errors.New("object stored again during release")
+
+// ReleaseDedupBlocks drops the references a deleted object holds on pooled
+// blocks and removes its block map, one group of blocks per transaction.  It
+// returns the slots whose last reference was dropped, which are now on the
+// free list.
+//
+// If the object has metadata again -- the same version was fetched anew
+// after the delete -- the remaining map is left to it: the new copy's writes
+// replace its entries block by block, and its own deletion releases the
+// rest.
+func (cdb *CacheDB) ReleaseDedupBlocks(instanceHash InstanceHash) ([]dedupRef, error) {
+	if err := cdb.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	var keys [][]byte
+	prefix := []byte(PrefixDedupMap + string(instanceHash) + ":")
+	err := cdb.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list dedup block map")
+	}
+
+	var freed []dedupRef
+	for _, key := range keys {
+		err := cdb.db.Update(func(txn *badger.Txn) error {
+			if _, err := txn.Get(MetaKey(instanceHash)); err == nil {
+				return errObjectRecreated
+			} else if !errors.Is(err, badger.ErrKeyNotFound) {
+				return err
+			}
+			item, err := txn.Get(key)
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			data, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if len(data) < 4 {
+				return txn.Delete(key)
+			}
+			namespaceID := NamespaceID(binary.BigEndian.Uint32(data))
+			var groupFreed []dedupRef
+			for i := uint32(0); i < dedupMapGroupBlocks; i++ {
+				e := dedupMapEntry(data, i)
+				if e.ref == 0 {
+					continue
+				}
+				released, err := releaseDedupBlockInTxn(txn, namespaceID, e)
+				if err != nil {
+					return err
+				}
+				if released != 0 {
+					if err := txn.Set(DedupFreeKey(released.storageID(), released.slot()), nil); err != nil {
+						return errors.Wrap(err, "failed to free dedup slot")
+					}
+					groupFreed = append(groupFreed, released)
+				}
+			}
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+			freed = append(freed, groupFreed...)
+			return nil
+		})
+		if errors.Is(err, errObjectRecreated) {
+			break
+		}
+		if err != nil {
+			return freed, errors.Wrapf(err, "failed to release deduplicated blocks of %s", instanceHash)
+		}
+	}
+	return freed, nil
+}
+
+// GetDedupMap returns the pooled blocks an object holds from startBlock to
+// endBlock inclusive.  Blocks the object has not stored have a zero entry.
+func (cdb *CacheDB) GetDedupMap(instanceHash InstanceHash, startBlock, endBlock uint32) ([]dedupEntry, error) {
+	entries := make([]dedupEntry, 0, endBlock-startBlock+1)
+	err := cdb.db.View(func(txn *badger.Txn) error {
+		for group := startBlock / dedupMapGroupBlocks; group <= endBlock/dedupMapGroupBlocks; group++ {
+			data, err := readDedupMapGroupInTxn(txn, instanceHash, group)
+			if err != nil {
+				return err
+			}
+			lo := max(startBlock, group*dedupMapGroupBlocks)
+			hi := min(endBlock, group*dedupMapGroupBlocks+dedupMapGroupBlocks-1)
+			for block := lo; block <= hi; block++ {
+				entries = append(entries, dedupMapEntry(data, block%dedupMapGroupBlocks))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read dedup block map of %s", instanceHash)
+	}
+	return entries, nil
+}
+
+// LoadDedupSlots returns the next never-used slot of a storage directory's
+// block pool and the slots on its free list.
+func (cdb *CacheDB) LoadDedupSlots(storageID StorageID) (next uint64, free []uint64, err error) {
+	err = cdb.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(DedupNextKey(storageID))
+		if err == nil {
+			if err := item.Value(func(val []byte) error {
+				if len(val) == 8 {
+					next = binary.BigEndian.Uint64(val)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+
+		prefix := []byte(fmt.Sprintf("%s%d:", PrefixDedupFree, storageID))
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			slot, err := strconv.ParseUint(string(it.Item().Key()[len(prefix):]), 10, 64)
+			if err != nil {
+				log.Warnf("Skipping malformed dedup free-list key %q", it.Item().Key())
+				continue
+			}
+			free = append(free, slot)
+		}
+		return nil
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "failed to load dedup pool slots for storage %d", storageID)
+	}
+	return
+}
+
+// ForgetDedupBlock removes a block from the namespace's index, so that the
+// next object storing the same content writes a fresh copy rather than
+// sharing this one.  Used for blocks found corrupt: the objects still
+// referring to the old copy are repaired one by one, and the slot is not
+// reused.
+func (cdb *CacheDB) ForgetDedupBlock(namespaceID NamespaceID, id dedupBlockID) error {
+	if err := cdb.checkWritable(); err != nil {
+		return err
+	}
+	return cdb.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(DedupIndexKey(namespaceID, id))
+	})
+}
+
+// ListOrphanedDedupMaps returns the objects that have a dedup block map but
+// no metadata: those whose deletion was interrupted before their blocks were
+// released.
+func (cdb *CacheDB) ListOrphanedDedupMaps() ([]InstanceHash, error) {
+	var orphans []InstanceHash
+	err := cdb.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(PrefixDedupMap)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		var last InstanceHash
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := string(it.Item().Key()[len(PrefixDedupMap):])
+			sep := strings.LastIndexByte(key, ':')
+			if sep <= 0 {
+				continue
+			}
+			hash := InstanceHash(key[:sep])
+			if hash == last {
+				continue
+			}
+			last = hash
+			if _, err := txn.Get(MetaKey(hash)); errors.Is(err, badger.ErrKeyNotFound) {
+				orphans = append(orphans, hash)
+			} else if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scan dedup block maps")
+	}
+	return orphans, nil
+}
+
+// dedupIndexEntry is the value of a dedup index key: where the block is
+// pooled and how many object blocks refer to it.
+type dedupIndexEntry struct {
+	ref  dedupRef
+	refs uint64
+}
+
+func (e dedupIndexEntry) encode() []byte {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf, uint64(e.ref))
+	binary.BigEndian.PutUint64(buf[8:], e.refs)
+	return buf
+}
+
+// readDedupIndexInTxn reads a block's index entry; a missing block has a
+// zero entry.
+func readDedupIndexInTxn(txn *badger.Txn, namespaceID NamespaceID, id dedupBlockID) (dedupIndexEntry, error) {
+	var e dedupIndexEntry
+	item, err := txn.Get(DedupIndexKey(namespaceID, id))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return e, nil
+	}
+	if err != nil {
+		return e, errors.Wrap(err, "failed to read dedup index")
+	}
+	err = item.Value(func(val []byte) error {
+		if len(val) != 16 {
+			return errors.Errorf("malformed dedup index entry of %d bytes", len(val))
+		}
+		e.ref = dedupRef(binary.BigEndian.Uint64(val))
+		e.refs = binary.BigEndian.Uint64(val[8:])
+		return nil
+	})
+	return e, err
+}
+
+// releaseDedupBlockInTxn drops the reference a block map entry holds,
+// removing the block from the index when none remain.  It returns the
+// block's slot if it was freed; the caller puts it on the free list.
+func releaseDedupBlockInTxn(txn *badger.Txn, namespaceID NamespaceID, e dedupEntry) (dedupRef, error) {
+	id := e.id
+	idx, err := readDedupIndexInTxn(txn, namespaceID, id)
+	if err != nil || idx.ref != e.ref {
+		// The entry's copy was forgotten as corrupt (see ForgetDedupBlock),
+		// and the index now holds nothing or a fresh copy with references
+		// of its own.  The old slot is abandoned.
+		return 0, err
+	}
+	if idx.refs > 1 {
+		idx.refs--
+		return 0, errors.Wrap(txn.Set(DedupIndexKey(namespaceID, id), idx.encode()), "failed to update dedup index")
+	}
+	if err := txn.Delete(DedupIndexKey(namespaceID, id)); err != nil {
+		return 0, errors.Wrap(err, "failed to update dedup index")
+	}
+	return idx.ref, nil
+}
+
+// readDedupMapGroupInTxn returns a copy of a group of an object's block map,
+// or nil if the object has stored none of the group's blocks.
+func readDedupMapGroupInTxn(txn *badger.Txn, instanceHash InstanceHash, group uint32) ([]byte, error) {
+	item, err := txn.Get(DedupMapKey(instanceHash, group))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read dedup block map")
+	}
+	return item.ValueCopy(nil)
+}
+
+// raiseDedupNextSlotInTxn records that a pool's slots below next have been
+// handed out.
+func raiseDedupNextSlotInTxn(txn *badger.Txn, storageID StorageID, next uint64) error {
+	key := DedupNextKey(storageID)
+	item, err := txn.Get(key)
+	if err == nil {
+		if err := item.Value(func(val []byte) error {
+			if len(val) == 8 {
+				next = max(next, binary.BigEndian.Uint64(val))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, badger.ErrKeyNotFound) {
+		return err
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, next)
+	return errors.Wrap(txn.Set(key, buf), "failed to record dedup pool size")
+}
+
+// --- Warmup Operations ---
+
+// StartWarmupJob replaces any previous warmup job, and its list, with a new
+// one.  See warmup.go.
+func (cdb *CacheDB) StartWarmupJob(job *WarmupJob, objects []PopularObject) error {
+	if err := cdb.checkWritable(); err != nil {
+		return err
+	}
+	if err := cdb.deleteWarmupEntries(); err != nil {
+		return err
+	}
+
+	wb := cdb.db.NewWriteBatch()
+	defer wb.Cancel()
+	for i := range objects {
+		encoded, err := msgpack.Marshal(&objects[i])
+		if err != nil {
+			return errors.Wrap(err, "failed to encode warmup entry")
+		}
+		if err := wb.Set(WarmupEntryKey(i), encoded); err != nil {
+			return errors.Wrap(err, "failed to write warmup entry")
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return errors.Wrap(err, "failed to write warmup list")
+	}
+	return cdb.SaveWarmupJob(job)
+}
+
+// SaveWarmupJob records the progress of the warmup job
+func (cdb *CacheDB) SaveWarmupJob(job *WarmupJob) error {
+	if err := cdb.checkWritable(); err != nil {
+		return err
+	}
+	encoded, err := msgpack.Marshal(job)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode warmup job")
+	}
+	return cdb.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(WarmupJobKey(), encoded)
+	})
+}
+
+// FinishWarmupJob records a finished warmup job and drops its list
+func (cdb *CacheDB) FinishWarmupJob(job *WarmupJob) error {
+	if err := cdb.SaveWarmupJob(job); err != nil {
+		return err
+	}
+	return cdb.deleteWarmupEntries()
+}
+
+// GetWarmupJob returns the most recent warmup job, or nil if the cache has
+// never run one
+func (cdb *CacheDB) GetWarmupJob() (*WarmupJob, error) {
+	var job WarmupJob
+	err := cdb.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(WarmupJobKey())
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return msgpack.Unmarshal(val, &job)
+		})
+	})
+	if err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to read warmup job")
+	}
+	return &job, nil
+}
+
+// ListWarmupEntries returns up to limit objects of the warmup list,
+// starting at index from
+func (cdb *CacheDB) ListWarmupEntries(from, limit int) ([]PopularObject, error) {
+	var objects []PopularObject
+	err := cdb.db.View(func(txn *badger.Txn) error {
+		prefix := []byte(PrefixWarmup + "e:")
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(WarmupEntryKey(from)); it.ValidForPrefix(prefix) && len(objects) < limit; it.Next() {
+			var obj PopularObject
+			if err := it.Item().Value(func(val []byte) error {
+				return msgpack.Unmarshal(val, &obj)
+			}); err != nil {
+				return errors.Wrap(err, "failed to decode warmup entry")
+			}
+			objects = append(objects, obj)
+		}
+		return nil
+	})
+	return objects, err
+}
+
+// deleteWarmupEntries removes the warmup list
+func (cdb *CacheDB) deleteWarmupEntries() error {
+	prefix := []byte(PrefixWarmup + "e:")
+	var keys [][]byte
+	if err := cdb.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "failed to list warmup entries")
+	}
+
+	wb := cdb.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, key := range keys {
+		if err := wb.Delete(key); err != nil {
+			return errors.Wrap(err, "failed to delete warmup entry")
+		}
+	}
+	return errors.Wrap(wb.Flush(), "failed to delete warmup list")
+}
@@ -0,0 +1,668 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+// Block deduplication.
+//
+// Every object version normally gets its own random data key, so two
+// versions of a file that differ in one block share nothing on disk.  With
+// deduplication enabled for a namespace, an object's blocks are instead kept
+// in a block pool shared by every object of that namespace, and a block whose
+// content is already pooled is stored once and referenced twice.
+//
+// A block is named by a keyed hash of its plaintext and namespace (the block
+// ID), and encrypted under a key derived from that ID.  Identical blocks
+// therefore encrypt identically -- which is what lets them be shared -- while
+// both keys come from the master key, so the pool says nothing about content
+// to anyone without it.  Namespaces never share blocks.
+//
+// The pool is a set of segment files per storage directory, outside the
+// objects tree, each holding dedupSegmentSlots block-sized slots.  Three
+// kinds of database record track it: the index maps a block ID to its slot
+// and reference count, each object has a block map from its block numbers to
+// the block IDs and slots it holds, and each pool has a free list.  A block
+// is written to its slot before the transaction that indexes it commits, so
+// a crash leaves at worst an unreferenced slot; a slot whose last reference
+// is dropped goes back on the free list and is reused.
+//
+// Objects keep their own (sparse, never written) files and are charged for
+// their full size exactly as other objects are, so eviction and quotas see
+// the logical size; the pool only makes the disk fill more slowly.
+// Deduplicated objects are not compressed and are not migrated between
+// storage tiers, since their data does not live in their files.
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/param"
+)
+
+const (
+	// dedupIDSize is the length of a block ID, a truncated HMAC-SHA-256
+	dedupIDSize = 16
+	// dedupEntrySize is the size of one block map entry: the block ID and
+	// its pool location.
+	dedupEntrySize = dedupIDSize + 8
+	// dedupMapGroupBlocks is the number of blocks per block map record
+	dedupMapGroupBlocks = 1024
+	// dedupSegmentSlots is the number of slots per pool segment file
+	// (about 1GiB of blocks).
+	dedupSegmentSlots = 1 << 18
+	// dedupBatchBlocks is the number of blocks indexed per transaction
+	dedupBatchBlocks = 64
+	// dedupCommitAttempts bounds the retries of a batch whose shared
+	// blocks were released while it was being written.
+	dedupCommitAttempts = 3
+	// dedupSubDir is the pool's directory, beside the objects directory
+	dedupSubDir = "dedup"
+)
+
+var (
+	dedupBlocks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_cache_dedup_blocks_total",
+		Help: "Total number of blocks written to deduplicated objects, by whether they were stored or shared an existing copy",
+	}, []string{"result"})
+	dedupFreedBlocks = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_dedup_freed_blocks_total",
+		Help: "Total number of pooled blocks freed because no object referred to them any more",
+	})
+)
+
+// dedupBlockID names a block by its content
+type dedupBlockID [dedupIDSize]byte
+
+// dedupRef is a slot of a block pool: the storage ID in the top byte, the
+// slot number below.  Zero means no slot.
+type dedupRef uint64
+
+func newDedupRef(storageID StorageID, slot uint64) dedupRef {
+	return dedupRef(uint64(storageID)<<56 | slot)
+}
+
+func (r dedupRef) storageID() StorageID { return StorageID(r >> 56) }
+
+func (r dedupRef) slot() uint64 { return uint64(r) & (1<<56 - 1) }
+
+// dedupEntry is one block of an object's block map
+type dedupEntry struct {
+	id  dedupBlockID
+	ref dedupRef
+}
+
+// dedupMapEntry returns entry i of a block map group record.  The record is
+// the namespace ID followed by the entries; a short record ends early.
+func dedupMapEntry(data []byte, i uint32) dedupEntry {
+	var e dedupEntry
+	off := 4 + int(i)*dedupEntrySize
+	if off+dedupEntrySize > len(data) {
+		return e
+	}
+	copy(e.id[:], data[off:])
+	e.ref = dedupRef(binary.BigEndian.Uint64(data[off+dedupIDSize:]))
+	return e
+}
+
+// setDedupMapEntry sets entry i of a block map group record, growing it as
+// needed, and returns the record.
+func setDedupMapEntry(data []byte, namespaceID NamespaceID, i uint32, e dedupEntry) []byte {
+	if len(data) < 4 {
+		data = make([]byte, 4)
+	}
+	binary.BigEndian.PutUint32(data, uint32(namespaceID))
+	off := 4 + int(i)*dedupEntrySize
+	if need := off + dedupEntrySize; need > len(data) {
+		data = append(data, make([]byte, need-len(data))...)
+	}
+	copy(data[off:], e.id[:])
+	binary.BigEndian.PutUint64(data[off+dedupIDSize:], uint64(e.ref))
+	return data
+}
+
+// DedupConfig selects which newly stored objects are deduplicated
+type DedupConfig struct {
+	// Enabled deduplicates objects in namespaces without an entry in
+	// Namespaces.
+	Enabled bool
+	// Namespaces overrides Enabled per top-level namespace prefix
+	Namespaces map[string]bool
+}
+
+// DedupStats reports the effect of block deduplication
+type DedupStats struct {
+	// BlocksStored is the number of blocks written to the pool
+	BlocksStored uint64
+	// BlocksShared is the number of blocks that referred to a copy already
+	// in the pool instead of being written
+	BlocksShared uint64
+	// BlocksFreed is the number of pooled blocks freed after their last
+	// reference was dropped
+	BlocksFreed uint64
+	// BytesSaved is the disk space not written thanks to shared blocks
+	BytesSaved uint64
+}
+
+// dedupConfigFromParams builds the deduplication configuration from the
+// Cache.* parameters.
+func dedupConfigFromParams() (DedupConfig, error) {
+	cfg := DedupConfig{Enabled: param.Cache_BlockDedup.GetBool()}
+	if param.Cache_NamespaceBlockDedup.IsSet() {
+		if err := param.Cache_NamespaceBlockDedup.Unmarshal(&cfg.Namespaces); err != nil {
+			return cfg, errors.Wrapf(err, "failed to parse %s", param.Cache_NamespaceBlockDedup.GetName())
+		}
+	}
+	return cfg, nil
+}
+
+// blockDedup is the storage manager's deduplication policy and block pool.
+// The zero value reads deduplicated objects but stores none.
+type blockDedup struct {
+	cfg             DedupConfig
+	namespacePrefix func(NamespaceID) (string, bool)
+
+	keysOnce sync.Once
+	indexKey []byte
+	blockKey []byte
+	keysErr  error
+
+	// mu serializes every change to the index, the block maps and the
+	// slot allocator, so their transactions never conflict.
+	mu    sync.Mutex
+	pools map[StorageID]*dedupPool
+
+	filesMu sync.Mutex
+	files   map[dedupSegment]*os.File
+
+	blocksStored atomic.Uint64
+	blocksShared atomic.Uint64
+	blocksFreed  atomic.Uint64
+}
+
+// dedupPool is the in-memory allocator of one storage directory's pool,
+// loaded from the database on first use.
+type dedupPool struct {
+	next uint64
+	free []uint64
+}
+
+type dedupSegment struct {
+	storageID StorageID
+	segment   uint64
+}
+
+// SetDedup sets which objects whose storage is initialized from now on are
+// deduplicated.  namespacePrefix resolves the namespace IDs used to look up
+// per-namespace settings; it may be nil when the configuration has none.
+// Must be called before the storage manager is shared.
+func (sm *StorageManager) SetDedup(cfg DedupConfig, namespacePrefix func(NamespaceID) (string, bool)) {
+	sm.dedup.cfg = cfg
+	sm.dedup.namespacePrefix = namespacePrefix
+}
+
+// dedupFor reports whether a new object in the namespace is deduplicated
+func (sm *StorageManager) dedupFor(namespaceID NamespaceID) bool {
+	bd := &sm.dedup
+	if bd.namespacePrefix != nil && len(bd.cfg.Namespaces) > 0 {
+		if prefix, ok := bd.namespacePrefix(namespaceID); ok {
+			if enabled, ok := bd.cfg.Namespaces[prefix]; ok {
+				return enabled
+			}
+		}
+	}
+	return bd.cfg.Enabled
+}
+
+// GetDedupStats returns the block deduplication statistics
+func (sm *StorageManager) GetDedupStats() DedupStats {
+	shared := sm.dedup.blocksShared.Load()
+	return DedupStats{
+		BlocksStored: sm.dedup.blocksStored.Load(),
+		BlocksShared: shared,
+		BlocksFreed:  sm.dedup.blocksFreed.Load(),
+		BytesSaved:   shared * BlockTotalSize,
+	}
+}
+
+// dedupKeys derives the pool's keys on first use
+func (sm *StorageManager) dedupKeys() error {
+	bd := &sm.dedup
+	bd.keysOnce.Do(func() {
+		bd.indexKey, bd.blockKey, bd.keysErr = sm.db.GetEncryptionManager().DeriveDedupKeys()
+	})
+	return bd.keysErr
+}
+
+// blockID names a block of plaintext in a namespace
+func (bd *blockDedup) blockID(namespaceID NamespaceID, data []byte) dedupBlockID {
+	mac := hmac.New(sha256.New, bd.indexKey)
+	var ns [4]byte
+	binary.BigEndian.PutUint32(ns[:], uint32(namespaceID))
+	mac.Write(ns[:])
+	mac.Write(data)
+	var id dedupBlockID
+	copy(id[:], mac.Sum(nil))
+	return id
+}
+
+// encryptor returns the encryptor of a pooled block.  Its key is unique to
+// the block's content, so the block is always encrypted as block 0.
+func (bd *blockDedup) encryptor(id dedupBlockID) (*BlockEncryptor, error) {
+	mac := hmac.New(sha256.New, bd.blockKey)
+	mac.Write(id[:])
+	return NewBlockEncryptor(mac.Sum(nil), zeroNonce())
+}
+
+// dedupPoolFile returns the segment file holding a slot's block, creating
+// it if create is set.  Files stay open until the storage manager closes.
+func (sm *StorageManager) dedupPoolFile(ref dedupRef, create bool) (*os.File, error) {
+	bd := &sm.dedup
+	key := dedupSegment{storageID: ref.storageID(), segment: ref.slot() / dedupSegmentSlots}
+	bd.filesMu.Lock()
+	defer bd.filesMu.Unlock()
+	if file := bd.files[key]; file != nil {
+		return file, nil
+	}
+
+	objectsDir, ok := sm.dirs[key.storageID]
+	if !ok {
+		return nil, errors.Errorf("storage directory %d is not configured", key.storageID)
+	}
+	path := filepath.Join(filepath.Dir(objectsDir), dedupSubDir, fmt.Sprintf("%08d", key.segment))
+	flags := os.O_RDWR
+	if create {
+		flags |= os.O_CREATE
+	}
+	file, err := os.OpenFile(path, flags, 0600)
+	if create && errors.Is(err, os.ErrNotExist) {
+		if mkErr := os.MkdirAll(filepath.Dir(path), 0750); mkErr != nil {
+			return nil, errors.Wrap(mkErr, "failed to create block pool directory")
+		}
+		file, err = os.OpenFile(path, flags, 0600)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open block pool segment")
+	}
+	if bd.files == nil {
+		bd.files = make(map[dedupSegment]*os.File)
+	}
+	bd.files[key] = file
+	return file, nil
+}
+
+// closeDedupFiles closes the pool's segment files
+func (sm *StorageManager) closeDedupFiles() {
+	bd := &sm.dedup
+	bd.filesMu.Lock()
+	defer bd.filesMu.Unlock()
+	for key, file := range bd.files {
+		_ = file.Close()
+		delete(bd.files, key)
+	}
+}
+
+// allocateDedupSlot hands out a free slot of a storage directory's pool.
+// The caller holds dedup.mu.
+func (sm *StorageManager) allocateDedupSlot(storageID StorageID) (dedupRef, error) {
+	bd := &sm.dedup
+	pool := bd.pools[storageID]
+	if pool == nil {
+		next, free, err := sm.db.LoadDedupSlots(storageID)
+		if err != nil {
+			return 0, err
+		}
+		pool = &dedupPool{next: next, free: free}
+		if bd.pools == nil {
+			bd.pools = make(map[StorageID]*dedupPool)
+		}
+		bd.pools[storageID] = pool
+	}
+	if n := len(pool.free); n > 0 {
+		slot := pool.free[n-1]
+		pool.free = pool.free[:n-1]
+		return newDedupRef(storageID, slot), nil
+	}
+	slot := pool.next
+	pool.next++
+	return newDedupRef(storageID, slot), nil
+}
+
+// returnDedupSlots gives slots that are on the database free list back to
+// the allocator.  The caller holds dedup.mu.
+func (sm *StorageManager) returnDedupSlots(refs []dedupRef) {
+	for _, ref := range refs {
+		// A pool not yet loaded reads its free list from the database.
+		if pool := sm.dedup.pools[ref.storageID()]; pool != nil {
+			pool.free = append(pool.free, ref.slot())
+		}
+	}
+}
+
+// writeDedupBlocks is writeBlocks for a deduplicated object.  Each chunk's
+// file is still allocated, so that the object is charged for it and laid
+// out like any other; its blocks go to the pool of the chunk's directory.
+func (sm *StorageManager) writeDedupBlocks(instanceHash InstanceHash, meta *CacheMetadata, startOffset int64, data []byte, getChunkFile func(int) (*os.File, error)) error {
+	for len(data) > 0 {
+		chunkIdx := ContentOffsetToChunk(startOffset, meta.ChunkSizeCode)
+		n := len(data)
+		if meta.IsChunked() {
+			_, chunkEnd := GetChunkRange(meta.ContentLength, meta.ChunkSizeCode, chunkIdx)
+			n = int(min(int64(n), chunkEnd+1-startOffset))
+		}
+		if _, err := getChunkFile(chunkIdx); err != nil {
+			return errors.Wrapf(err, "failed to open chunk %d", chunkIdx)
+		}
+
+		storageID := meta.GetChunkStorageID(chunkIdx)
+		startBlock := ContentOffsetToBlock(startOffset)
+		if err := sm.storeDedupBlocks(instanceHash, meta.NamespaceID, storageID, startBlock, data[:n]); err != nil {
+			return err
+		}
+		endBlock := startBlock + uint32((n+BlockDataSize-1)/BlockDataSize) - 1
+		if err := sm.db.MarkBlocksDownloaded(instanceHash, startBlock, endBlock, storageID, meta.NamespaceID, meta.ContentLength); err != nil {
+			return errors.Wrap(err, "failed to update block state")
+		}
+		data = data[n:]
+		startOffset += int64(n)
+	}
+
+	sm.checkAndMarkComplete(instanceHash, meta)
+	return nil
+}
+
+// storeDedupBlocks stores data as blocks first, first+1, ... of an object,
+// writing the blocks not already pooled to the pool of storageID.
+func (sm *StorageManager) storeDedupBlocks(instanceHash InstanceHash, namespaceID NamespaceID, storageID StorageID, first uint32, data []byte) error {
+	if err := sm.dedupKeys(); err != nil {
+		return err
+	}
+	for len(data) > 0 {
+		n := min(len(data), dedupBatchBlocks*BlockDataSize)
+		if err := sm.storeDedupBatch(instanceHash, namespaceID, storageID, first, data[:n]); err != nil {
+			return errors.Wrapf(err, "failed to store deduplicated blocks %d-%d", first, first+uint32((n-1)/BlockDataSize))
+		}
+		data = data[n:]
+		first += dedupBatchBlocks
+	}
+	return nil
+}
+
+func (sm *StorageManager) storeDedupBatch(instanceHash InstanceHash, namespaceID NamespaceID, storageID StorageID, first uint32, data []byte) error {
+	bd := &sm.dedup
+	blockData := func(i int) []byte {
+		return data[i*BlockDataSize : min((i+1)*BlockDataSize, len(data))]
+	}
+	ids := make([]dedupBlockID, (len(data)+BlockDataSize-1)/BlockDataSize)
+	for i := range ids {
+		ids[i] = bd.blockID(namespaceID, blockData(i))
+	}
+
+	for attempt := 1; ; attempt++ {
+		// Find the blocks not yet pooled and reserve slots for them.
+		bd.mu.Lock()
+		refs, err := sm.db.LookupDedupBlocks(namespaceID, ids)
+		entries := make([]dedupEntry, len(ids))
+		var allocated []dedupRef
+		var toWrite []int
+		if err == nil {
+			reserved := make(map[dedupBlockID]dedupRef)
+			for i, id := range ids {
+				entries[i].id = id
+				if refs[i] != 0 {
+					continue
+				}
+				if ref, ok := reserved[id]; ok {
+					entries[i].ref = ref
+					continue
+				}
+				var ref dedupRef
+				if ref, err = sm.allocateDedupSlot(storageID); err != nil {
+					break
+				}
+				reserved[id] = ref
+				entries[i].ref = ref
+				allocated = append(allocated, ref)
+				toWrite = append(toWrite, i)
+			}
+		}
+		if err != nil {
+			sm.abandonDedupSlots(allocated)
+			bd.mu.Unlock()
+			return err
+		}
+		bd.mu.Unlock()
+
+		// Write them outside the lock; nothing refers to the slots yet.
+		for _, i := range toWrite {
+			if err = sm.writeDedupBlock(entries[i], blockData(i)); err != nil {
+				break
+			}
+		}
+
+		bd.mu.Lock()
+		var freed []dedupRef
+		if err == nil {
+			freed, err = sm.db.CommitDedupBlocks(instanceHash, namespaceID, first, entries, allocated)
+		}
+		if err == nil {
+			sm.returnDedupSlots(freed)
+		} else {
+			sm.abandonDedupSlots(allocated)
+		}
+		bd.mu.Unlock()
+
+		if errors.Is(err, errDedupStale) && attempt < dedupCommitAttempts {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		bd.blocksStored.Add(uint64(len(toWrite)))
+		bd.blocksShared.Add(uint64(len(ids) - len(toWrite)))
+		dedupBlocks.WithLabelValues("stored").Add(float64(len(toWrite)))
+		dedupBlocks.WithLabelValues("shared").Add(float64(len(ids) - len(toWrite)))
+		if n := len(freed); n > 0 {
+			bd.blocksFreed.Add(uint64(n))
+			dedupFreedBlocks.Add(float64(n))
+		}
+		return nil
+	}
+}
+
+// abandonDedupSlots returns slots reserved for a batch that was not
+// committed.  The caller holds dedup.mu.
+func (sm *StorageManager) abandonDedupSlots(refs []dedupRef) {
+	if len(refs) == 0 {
+		return
+	}
+	if err := sm.db.FreeDedupSlots(refs); err != nil {
+		// The slots are lost to the pool until it is rebuilt, which
+		// costs space but not correctness.
+		log.Warnf("Failed to return %d block pool slots to the free list: %v", len(refs), err)
+		return
+	}
+	sm.returnDedupSlots(refs)
+}
+
+// writeDedupBlock encrypts a block into its pool slot
+func (sm *StorageManager) writeDedupBlock(e dedupEntry, data []byte) error {
+	enc, err := sm.dedup.encryptor(e.id)
+	if err != nil {
+		return err
+	}
+	buf, err := enc.EncryptBlockTo(make([]byte, 0, len(data)+AuthTagSize), 0, data)
+	if err != nil {
+		return errors.Wrap(err, "failed to encrypt pooled block")
+	}
+	file, err := sm.dedupPoolFile(e.ref, true)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteAt(buf, BlockOffset(uint32(e.ref.slot()%dedupSegmentSlots)))
+	return errors.Wrap(err, "failed to write pooled block")
+}
+
+// readDedupBlock reads and decrypts a pooled block of plainLen bytes,
+// appending it to dst.
+func (sm *StorageManager) readDedupBlock(e dedupEntry, plainLen int, dst []byte) ([]byte, error) {
+	if e.ref == 0 {
+		return nil, errors.New("block is not stored")
+	}
+	if err := sm.dedupKeys(); err != nil {
+		return nil, err
+	}
+	file, err := sm.dedupPoolFile(e.ref, false)
+	if err != nil {
+		return nil, err
+	}
+	bp := readBufPool.Get().(*[]byte)
+	defer readBufPool.Put(bp)
+	buf := (*bp)[:plainLen+AuthTagSize]
+	n, err := file.ReadAt(buf, BlockOffset(uint32(e.ref.slot()%dedupSegmentSlots)))
+	if n < len(buf) {
+		if err == nil {
+			err = errors.New("short read")
+		}
+		return nil, errors.Wrap(err, "failed to read pooled block")
+	}
+	enc, err := sm.dedup.encryptor(e.id)
+	if err != nil {
+		return nil, err
+	}
+	return enc.DecryptBlockTo(dst, 0, buf)
+}
+
+// dedupBlockLen returns the plaintext length of a block of an object
+func dedupBlockLen(contentLength int64, block uint32) int {
+	return int(min(BlockDataSize, contentLength-int64(block)*BlockDataSize))
+}
+
+// readDedupInto is readBlocksChunkedInto for a deduplicated object
+func (sm *StorageManager) readDedupInto(instanceHash InstanceHash, meta *CacheMetadata, dst []byte, startOffset int64) (int, error) {
+	endOffset := min(startOffset+int64(len(dst)), meta.ContentLength)
+	if endOffset <= startOffset {
+		return 0, nil
+	}
+	length := int(endOffset - startOffset)
+	tier := sm.memTier
+	if tier.readInto(instanceHash, 0, dst[:length], startOffset) {
+		return length, nil
+	}
+	admit := tier.admits(instanceHash)
+
+	startBlock := ContentOffsetToBlock(startOffset)
+	endBlock := ContentOffsetToBlock(endOffset - 1)
+	entries, err := sm.db.GetDedupMap(instanceHash, startBlock, endBlock)
+	if err != nil {
+		return 0, err
+	}
+
+	plainBuf := make([]byte, 0, BlockDataSize)
+	pos := 0
+	for block := startBlock; block <= endBlock; block++ {
+		plain, ok := tier.get(instanceHash, block)
+		if !ok {
+			plain, err = sm.readDedupBlock(entries[block-startBlock], dedupBlockLen(meta.ContentLength, block), plainBuf[:0])
+			if err != nil {
+				return 0, errors.Wrapf(err, "failed to read block %d", block)
+			}
+			if admit {
+				tier.put(instanceHash, block, plain)
+			}
+		}
+		within := int(max(0, startOffset-int64(block)*BlockDataSize))
+		copied := copy(dst[pos:length], plain[within:])
+		if ok {
+			tier.served(copied)
+		}
+		pos += copied
+	}
+	return pos, nil
+}
+
+// identifyCorruptDedupBlocks is IdentifyCorruptBlocks for a deduplicated
+// object.  A pooled block found corrupt is forgotten, so that the repair
+// stores a fresh copy instead of referring to the bad one again.
+func (sm *StorageManager) identifyCorruptDedupBlocks(instanceHash InstanceHash, meta *CacheMetadata, blockState *ObjectBlockState, startBlock, endBlock uint32) ([]uint32, error) {
+	entries, err := sm.db.GetDedupMap(instanceHash, startBlock, endBlock)
+	if err != nil {
+		return nil, err
+	}
+	var corrupt []uint32
+	for block := startBlock; block <= endBlock; block++ {
+		if !blockState.Contains(block) {
+			continue
+		}
+		e := entries[block-startBlock]
+		if _, err := sm.readDedupBlock(e, dedupBlockLen(meta.ContentLength, block), nil); err == nil {
+			continue
+		}
+		corrupt = append(corrupt, block)
+		if e.ref != 0 {
+			sm.dedup.mu.Lock()
+			err := sm.db.ForgetDedupBlock(meta.NamespaceID, e.id)
+			sm.dedup.mu.Unlock()
+			if err != nil {
+				log.Warnf("Failed to forget corrupt pooled block of %s: %v", instanceHash, err)
+			}
+		}
+	}
+	return corrupt, nil
+}
+
+// releaseDedupBlocks drops a deleted object's references to pooled blocks.
+// Failures are logged; ReclaimOrphanedDedupMaps retries them.
+func (sm *StorageManager) releaseDedupBlocks(instanceHash InstanceHash) {
+	sm.dedup.mu.Lock()
+	freed, err := sm.db.ReleaseDedupBlocks(instanceHash)
+	sm.returnDedupSlots(freed)
+	sm.dedup.mu.Unlock()
+	if n := len(freed); n > 0 {
+		sm.dedup.blocksFreed.Add(uint64(n))
+		dedupFreedBlocks.Add(float64(n))
+	}
+	if err != nil {
+		log.Warnf("Failed to release pooled blocks of %s: %v", instanceHash, err)
+	}
+}
+
+// ReclaimOrphanedDedupMaps releases the pooled blocks of objects that were
+// deleted without their blocks being released, which happens only when the
+// process stops between the two.  Returns the number of objects released.
+func (sm *StorageManager) ReclaimOrphanedDedupMaps() (int, error) {
+	orphans, err := sm.db.ListOrphanedDedupMaps()
+	if err != nil {
+		return 0, err
+	}
+	for _, hash := range orphans {
+		sm.releaseDedupBlocks(hash)
+	}
+	return len(orphans), nil
+}
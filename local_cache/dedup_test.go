@@ -0,0 +1,218 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storeDedupTestObject stores data as a new deduplicated object
+func storeDedupTestObject(t *testing.T, sm *StorageManager, nsID NamespaceID, data []byte) InstanceHash {
+	t.Helper()
+	hash := InstanceHash(randomHexForTest(t, 32))
+	meta, err := sm.InitDiskStorage(t.Context(), hash, int64(len(data)), sm.DirIDs()[0], nsID)
+	require.NoError(t, err)
+	require.True(t, meta.Dedup)
+	require.NoError(t, sm.WriteBlocks(hash, 0, data))
+	return hash
+}
+
+func requireDedupContent(t *testing.T, sm *StorageManager, hash InstanceHash, want []byte) {
+	t.Helper()
+	got, err := sm.ReadBlocks(hash, 0, len(want))
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+// A new version differing in one block stores only that block; each block
+// is freed when the last version using it goes.
+func TestDedupSharesUnchangedBlocks(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 1)
+	sm.SetDedup(DedupConfig{Enabled: true}, nil)
+
+	v1 := make([]byte, 8*BlockDataSize+100)
+	_, err := rand.Read(v1)
+	require.NoError(t, err)
+	v2 := append([]byte(nil), v1...)
+	v2[3*BlockDataSize+7] ^= 0xff
+
+	h1 := storeDedupTestObject(t, sm, 1, v1)
+	h2 := storeDedupTestObject(t, sm, 1, v2)
+	stats := sm.GetDedupStats()
+	assert.Equal(t, uint64(10), stats.BlocksStored)
+	assert.Equal(t, uint64(8), stats.BlocksShared)
+	assert.Equal(t, uint64(8*BlockTotalSize), stats.BytesSaved)
+
+	requireDedupContent(t, sm, h1, v1)
+	requireDedupContent(t, sm, h2, v2)
+
+	// A read starting mid-block, through the reader the server uses
+	reader, err := sm.NewObjectReader(h2)
+	require.NoError(t, err)
+	buf := make([]byte, 2*BlockDataSize)
+	n, err := reader.ReadAt(buf, 3*BlockDataSize-10)
+	if err != io.EOF {
+		require.NoError(t, err)
+	}
+	assert.Equal(t, v2[3*BlockDataSize-10:5*BlockDataSize-10], buf[:n])
+	require.NoError(t, reader.Close())
+
+	require.NoError(t, sm.Delete(h1))
+	assert.Equal(t, uint64(1), sm.GetDedupStats().BlocksFreed)
+	requireDedupContent(t, sm, h2, v2)
+
+	require.NoError(t, sm.Delete(h2))
+	assert.Equal(t, uint64(10), sm.GetDedupStats().BlocksFreed)
+
+	// Freed slots are reused rather than growing the pool.
+	segment := filepath.Join(filepath.Dir(sm.GetDirs()[sm.DirIDs()[0]]), dedupSubDir, "00000000")
+	before, err := os.Stat(segment)
+	require.NoError(t, err)
+	h3 := storeDedupTestObject(t, sm, 1, v2)
+	requireDedupContent(t, sm, h3, v2)
+	after, err := os.Stat(segment)
+	require.NoError(t, err)
+	assert.Equal(t, before.Size(), after.Size())
+}
+
+func TestDedupNamespacesDoNotShare(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 1)
+	sm.SetDedup(DedupConfig{Enabled: true}, nil)
+
+	data := make([]byte, 4*BlockDataSize)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	storeDedupTestObject(t, sm, 1, data)
+	storeDedupTestObject(t, sm, 2, data)
+	assert.Equal(t, uint64(0), sm.GetDedupStats().BlocksShared)
+}
+
+func TestDedupNamespaceOverride(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 1)
+	prefixes := map[NamespaceID]string{1: "/datasets", 2: "/scratch"}
+	sm.SetDedup(DedupConfig{Namespaces: map[string]bool{"/datasets": true}}, func(id NamespaceID) (string, bool) {
+		prefix, ok := prefixes[id]
+		return prefix, ok
+	})
+	assert.True(t, sm.dedupFor(1))
+	assert.False(t, sm.dedupFor(2))
+	assert.False(t, sm.dedupFor(3))
+}
+
+// Eviction releases an object's pooled blocks just as Delete does.
+func TestDedupEvictionReleasesBlocks(t *testing.T) {
+	db, sm := newAppendTestStorage(t, 1)
+	sm.SetDedup(DedupConfig{Enabled: true}, nil)
+
+	data := make([]byte, 3*BlockDataSize)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	hash := storeDedupTestObject(t, sm, 1, data)
+	require.NoError(t, db.UpdateLRU(hash, 0))
+
+	evicted, _, _, err := sm.EvictByLRU(sm.DirIDs()[0], 1, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, evicted, 1)
+	assert.Equal(t, uint64(3), sm.GetDedupStats().BlocksFreed)
+}
+
+// A deletion that never released its blocks is caught by the orphan sweep.
+func TestDedupReclaimsOrphanedMaps(t *testing.T) {
+	db, sm := newAppendTestStorage(t, 1)
+	sm.SetDedup(DedupConfig{Enabled: true}, nil)
+
+	data := make([]byte, 2*BlockDataSize)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	hash := storeDedupTestObject(t, sm, 1, data)
+
+	// Still live: nothing to reclaim
+	n, err := sm.ReclaimOrphanedDedupMaps()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	require.NoError(t, db.DeleteObject(hash))
+	n, err = sm.ReclaimOrphanedDedupMaps()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, uint64(2), sm.GetDedupStats().BlocksFreed)
+
+	n, err = sm.ReclaimOrphanedDedupMaps()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+// A corrupt pooled block is reported, and rewriting it stores a fresh copy
+// instead of referring to the damaged one.
+func TestDedupRepairsCorruptBlock(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 1)
+	sm.SetDedup(DedupConfig{Enabled: true}, nil)
+
+	data := make([]byte, 2*BlockDataSize)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	hash := storeDedupTestObject(t, sm, 1, data)
+
+	entries, err := sm.db.GetDedupMap(hash, 1, 1)
+	require.NoError(t, err)
+	file, err := sm.dedupPoolFile(entries[0].ref, false)
+	require.NoError(t, err)
+	_, err = file.WriteAt([]byte{0xde, 0xad}, BlockOffset(uint32(entries[0].ref.slot()))+10)
+	require.NoError(t, err)
+
+	_, err = sm.ReadBlocks(hash, 0, len(data))
+	require.Error(t, err)
+	corrupt, err := sm.IdentifyCorruptBlocks(hash, 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{1}, corrupt)
+
+	require.NoError(t, sm.WriteBlocks(hash, BlockDataSize, data[BlockDataSize:]))
+	requireDedupContent(t, sm, hash, data)
+}
+
+// Objects streamed through a BlockWriter are deduplicated too.
+func TestDedupBlockWriter(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 1)
+	sm.SetDedup(DedupConfig{Enabled: true}, nil)
+
+	data := make([]byte, 20*BlockDataSize+5)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	h1 := storeDedupTestObject(t, sm, 1, data)
+
+	h2 := InstanceHash(randomHexForTest(t, 32))
+	_, err = sm.InitDiskStorage(t.Context(), h2, int64(len(data)), sm.DirIDs()[0], 1)
+	require.NoError(t, err)
+	bw, err := sm.NewBlockWriter(h2, 0, nil, nil)
+	require.NoError(t, err)
+	_, err = bw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, bw.Close())
+
+	requireDedupContent(t, sm, h1, data)
+	requireDedupContent(t, sm, h2, data)
+	assert.Equal(t, uint64(21), sm.GetDedupStats().BlocksShared)
+}
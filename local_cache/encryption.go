@@ -88,6 +88,12 @@ func NewEncryptionManager(baseDir string) (*EncryptionManager, error) {
 	return em, nil
 }
 
+// MasterKeyPath returns the file in baseDir holding the master key, sealed to
+// the issuer keys.  A backup of a store's data is unreadable without it.
+func MasterKeyPath(baseDir string) string {
+	return filepath.Join(baseDir, masterKeyFileName)
+}
+
 // loadOrCreateMasterKey loads the master key from disk or creates a new one
 func (em *EncryptionManager) loadOrCreateMasterKey() error {
 	keyPath := filepath.Join(em.baseDir, masterKeyFileName)
@@ -242,6 +248,20 @@ func (em *EncryptionManager) DeriveDBKey() ([]byte, error) {
 	return dbKey, nil
 }
 
+// DeriveDedupKeys derives the keys of the shared block pool (see dedup.go)
+// using HKDF: indexKey names a block by its content, and blockKey is the
+// root from which each pooled block's own encryption key is derived.
+// Neither is usable without the master key, so the pool reveals nothing
+// about which blocks hold which content.
+func (em *EncryptionManager) DeriveDedupKeys() (indexKey, blockKey []byte, err error) {
+	hkdfReader := hkdf.New(sha256.New, em.masterKey, nil, []byte("pelican-cache-block-dedup"))
+	keys := make([]byte, 2*KeySize)
+	if _, err := io.ReadFull(hkdfReader, keys); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to derive block dedup keys")
+	}
+	return keys[:KeySize], keys[KeySize:], nil
+}
+
 // GenerateDataKey generates a new random data encryption key (DEK)
 func (em *EncryptionManager) GenerateDataKey() ([]byte, error) {
 	key := make([]byte, KeySize)
@@ -401,6 +421,35 @@ func (be *BlockEncryptor) DecryptBlockTo(dst []byte, blockNum uint32, encryptedB
 	return be.gcm.Open(dst, nonce[:], encryptedBlock, nil)
 }
 
+// frameNonce derives the nonce for a piece of a compressed frame stored in
+// the slot of blockNum.  It differs from blockNonce in the top bit of the
+// first byte, so a frame piece and a plain block written to the same slot
+// never share a nonce, and neither authenticates as the other.
+func (be *BlockEncryptor) frameNonce(nonce *[NonceSize]byte, blockNum uint32) {
+	be.blockNonce(nonce, blockNum)
+	nonce[0] ^= 0x80
+}
+
+// EncryptFramePieceTo encrypts one BlockDataSize piece of a compressed frame
+// for the slot of blockNum, appending the result to dst.
+func (be *BlockEncryptor) EncryptFramePieceTo(dst []byte, blockNum uint32, data []byte) ([]byte, error) {
+	if len(data) > BlockDataSize {
+		return nil, errors.Errorf("frame piece too large: %d > %d", len(data), BlockDataSize)
+	}
+
+	var nonce [NonceSize]byte
+	be.frameNonce(&nonce, blockNum)
+	return be.gcm.Seal(dst, nonce[:], data, nil), nil
+}
+
+// DecryptFramePieceTo decrypts one piece of a compressed frame stored in the
+// slot of blockNum, appending the plaintext to dst.
+func (be *BlockEncryptor) DecryptFramePieceTo(dst []byte, blockNum uint32, encryptedPiece []byte) ([]byte, error) {
+	var nonce [NonceSize]byte
+	be.frameNonce(&nonce, blockNum)
+	return be.gcm.Open(dst, nonce[:], encryptedPiece, nil)
+}
+
 // EncryptInline encrypts data for inline storage (small objects)
 func (em *EncryptionManager) EncryptInline(data, dek, nonce []byte) ([]byte, error) {
 	block, err := aes.NewCipher(dek)
@@ -23,6 +23,7 @@ import (
 	fmt "fmt"
 	"math"
 	rand "math/rand/v2"
+	"slices"
 	"sort"
 	"sync"
 	"sync/atomic"
@@ -38,6 +39,31 @@ import (
 
 const rrTableSize = 1024
 
+// evictionCandidateLimit bounds how many entries of a namespace's LRU index a
+// frequency-aware EvictionPolicy is asked to rank in a single eviction pass.
+// Objects newer than the oldest evictionCandidateLimit are not considered
+// until the older ones are gone.
+const evictionCandidateLimit = 100_000
+
+// EvictionPolicy decides the order in which the objects of one storage
+// directory + namespace combination are evicted.  The eviction manager keeps
+// one instance per combination and tells it about every access and eviction;
+// when space must be freed, the policy ranks the combination's entries from
+// the LRU index.  Implementations must be safe for concurrent use.
+type EvictionPolicy interface {
+	// Name returns the policy's configuration name, e.g. "lfu".
+	Name() string
+	// RecordAccess notes that an object of the given size was read.
+	RecordAccess(instanceHash InstanceHash, size int64, now time.Time)
+	// RecordEviction notes that an object was evicted.
+	RecordEviction(instanceHash InstanceHash)
+	// OrderCandidates returns the candidates, which arrive least recently
+	// used first, in the order they should be evicted.  complete is true when
+	// the candidates are every object the policy is responsible for, which
+	// lets it forget state about objects removed by other means.
+	OrderCandidates(candidates []EvictionCandidate, complete bool, now time.Time) []InstanceHash
+}
+
 // EvictionManager handles fairness-aware cache eviction.
 // Each storage directory has independent watermarks; eviction is triggered
 // per-directory when a directory exceeds its high-water mark and proceeds
@@ -58,6 +84,14 @@ type EvictionManager struct {
 	// Sorted list of directory IDs.  Read-only after construction.
 	dirIDs []StorageID
 
+	// tiered is set when both fast and slow directories are configured, in
+	// which case new objects go to the fast tier and cold objects migrate to
+	// the slow tier before anything is evicted.  See tiering.go.
+	tiered          bool
+	migrateChan     chan struct{}
+	migratedObjects atomic.Uint64
+	migratedBytes   atomic.Uint64
+
 	// Pre-computed shuffled lookup table for ChooseDiskStorage.
 	// The table has rrTableSize entries, each containing a storageID.
 	// Entries are assigned proportional to free space and then
@@ -72,6 +106,42 @@ type EvictionManager struct {
 	evicting        bool
 	evictChan       chan struct{}
 	evictRunCounter atomic.Uint64
+
+	// Eviction policy selection.  The policy names are read-only after
+	// construction; namespacePrefix is set once by SetNamespaceResolver
+	// before the cache starts serving.
+	defaultPolicy     string
+	namespacePolicies map[string]string
+	frequencyHalfLife time.Duration
+	namespacePrefix   func(NamespaceID) (string, bool)
+
+	// Per-namespace quotas, keyed by prefix and read-only after
+	// construction.  quotaRoom holds, per namespace, the largest object
+	// admission turned away for lack of room since the last eviction pass;
+	// quotaMu guards the map.  See quota.go.
+	namespaceQuotas map[string]NamespaceQuota
+	quotaMu         sync.Mutex
+	quotaRoom       map[NamespaceID]int64
+
+	// One policy instance per storage+namespace, and per-namespace
+	// hit and eviction counters, both created on first use.
+	policyMu sync.Mutex
+	policies map[StorageUsageKey]EvictionPolicy
+	nsStats  map[NamespaceID]*namespaceEvictionCounters
+}
+
+// namespaceEvictionCounters tracks how well a namespace's eviction policy is doing
+type namespaceEvictionCounters struct {
+	hits           atomic.Uint64
+	misses         atomic.Uint64
+	evictedObjects atomic.Uint64
+	evictedBytes   atomic.Uint64
+
+	// Objects turned away at the hard quota, and what was evicted to keep
+	// the namespace within its quota (also counted in evicted*)
+	quotaRejected       atomic.Uint64
+	quotaEvictedObjects atomic.Uint64
+	quotaEvictedBytes   atomic.Uint64
 }
 
 // dirEvictionLimits holds the size limits for a single storage directory.
@@ -79,6 +149,7 @@ type dirEvictionLimits struct {
 	maxSize   uint64
 	highWater uint64
 	lowWater  uint64
+	tier      StorageTier
 }
 
 // EvictionConfig holds configuration for the eviction manager
@@ -86,15 +157,27 @@ type EvictionConfig struct {
 	// DirConfigs maps storageID to its eviction limits.
 	// Each entry describes one storage directory.
 	DirConfigs map[StorageID]EvictionDirConfig
+
+	// Policy names the EvictionPolicy used for namespaces without an entry
+	// in NamespacePolicies ("" = "lru").
+	Policy string
+	// NamespacePolicies maps a namespace prefix to the name of its policy.
+	NamespacePolicies map[string]string
+	// FrequencyHalfLife is the half-life of the "lfu" policy's access
+	// counts (0 = default 24h).
+	FrequencyHalfLife time.Duration
+	// NamespaceQuotas maps a namespace prefix to its quota; see quota.go.
+	NamespaceQuotas map[string]NamespaceQuota
 }
 
 // EvictionDirConfig holds per-directory eviction configuration.
 type EvictionDirConfig struct {
-	MaxSize             uint64 // Maximum cache size in bytes for this directory
-	HighWaterPercentage int    // Percentage at which eviction starts (0 = default 90)
-	LowWaterPercentage  int    // Percentage at which eviction stops  (0 = default 80)
-	HighWaterBytes      uint64 // Absolute byte threshold (overrides percentage when > 0)
-	LowWaterBytes       uint64 // Absolute byte threshold (overrides percentage when > 0)
+	MaxSize             uint64      // Maximum cache size in bytes for this directory
+	HighWaterPercentage int         // Percentage at which eviction starts (0 = default 90)
+	LowWaterPercentage  int         // Percentage at which eviction stops  (0 = default 80)
+	HighWaterBytes      uint64      // Absolute byte threshold (overrides percentage when > 0)
+	LowWaterBytes       uint64      // Absolute byte threshold (overrides percentage when > 0)
+	Tier                StorageTier // Storage tier ("" = fast); see tiering.go
 }
 
 // NewEvictionManager creates a new eviction manager
@@ -139,10 +222,15 @@ func NewEvictionManager(db *CacheDB, storage *StorageManager, config EvictionCon
 			lowWater = uint64(math.MaxInt64)
 		}
 
+		tier := dcfg.Tier
+		if tier == "" {
+			tier = StorageTierFast
+		}
 		dirLimits[id] = &dirEvictionLimits{
 			maxSize:   dcfg.MaxSize,
 			highWater: highWater,
 			lowWater:  lowWater,
+			tier:      tier,
 		}
 	}
 
@@ -154,14 +242,40 @@ func NewEvictionManager(db *CacheDB, storage *StorageManager, config EvictionCon
 	}
 	sort.Slice(dirIDs, func(i, j int) bool { return dirIDs[i] < dirIDs[j] })
 
+	// Unknown policy names fall back to LRU rather than failing startup.
+	checkPolicy := func(name, what string) string {
+		if _, err := newEvictionPolicy(name, 0); err != nil {
+			log.Warnf("%v for %s; falling back to LRU eviction", err, what)
+			return evictionPolicyLRU
+		}
+		return name
+	}
+	namespacePolicies := make(map[string]string, len(config.NamespacePolicies))
+	for prefix, name := range config.NamespacePolicies {
+		namespacePolicies[prefix] = checkPolicy(name, "namespace "+prefix)
+	}
+	halfLife := config.FrequencyHalfLife
+	if halfLife <= 0 {
+		halfLife = 24 * time.Hour
+	}
+
 	em := &EvictionManager{
-		db:        db,
-		storage:   storage,
-		dirLimits: dirLimits,
-		dirUsage:  dirUsage,
-		dirIDs:    dirIDs,
-		evictChan: make(chan struct{}, 1),
+		db:                db,
+		storage:           storage,
+		dirLimits:         dirLimits,
+		dirUsage:          dirUsage,
+		dirIDs:            dirIDs,
+		evictChan:         make(chan struct{}, 1),
+		migrateChan:       make(chan struct{}, 1),
+		defaultPolicy:     checkPolicy(config.Policy, "the default policy"),
+		namespacePolicies: namespacePolicies,
+		frequencyHalfLife: halfLife,
+		namespaceQuotas:   config.NamespaceQuotas,
+		policies:          make(map[StorageUsageKey]EvictionPolicy),
+		nsStats:           make(map[NamespaceID]*namespaceEvictionCounters),
+		quotaRoom:         make(map[NamespaceID]int64),
 	}
+	em.tiered = em.isTiered()
 	em.rebuildRRTable()
 	return em
 }
@@ -174,6 +288,11 @@ func (em *EvictionManager) Start(ctx context.Context, egrp *errgroup.Group) {
 	egrp.Go(func() error {
 		return em.evictionLoop(ctx)
 	})
+	if em.tiered {
+		egrp.Go(func() error {
+			return em.migrationLoop(ctx)
+		})
+	}
 }
 
 // appendReclaimInterval is how often the janitor looks for objects left
@@ -223,6 +342,14 @@ func (em *EvictionManager) reclaimAbandonedAppends() {
 		log.Infof("Reclaimed %d object(s) left behind by interrupted streaming appends", n)
 		em.recalculateDirUsage()
 	}
+
+	// Deletions interrupted before releasing their pooled blocks are
+	// rarer still, and are caught on the same schedule.
+	if n, err := em.storage.ReclaimOrphanedDedupMaps(); err != nil {
+		log.Warnf("Failed to release pooled blocks of deleted objects: %v", err)
+	} else if n > 0 {
+		log.Infof("Released the pooled blocks of %d deleted object(s)", n)
+	}
 }
 
 // TriggerEviction triggers an eviction check
@@ -289,9 +416,25 @@ func (em *EvictionManager) GetNamespaceUsage(storageID StorageID, namespaceID Na
 	return em.db.GetUsage(storageID, namespaceID)
 }
 
-// GetAllNamespaceUsage returns usage for all storage+namespace combinations
-func (em *EvictionManager) GetAllNamespaceUsage() (map[StorageUsageKey]int64, error) {
-	return em.db.GetAllUsage()
+// GetAllNamespaceUsage returns each namespace's usage, summed across all
+// storage directories, along with its quota
+func (em *EvictionManager) GetAllNamespaceUsage() (map[NamespaceID]NamespaceUsage, error) {
+	allUsage, err := em.db.GetAllUsage()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[NamespaceID]NamespaceUsage)
+	for key, usage := range allUsage {
+		nsUsage, ok := result[key.NamespaceID]
+		if !ok {
+			nsUsage.ByDir = make(map[StorageID]int64, len(em.dirIDs))
+			nsUsage.Quota, _, _ = em.quotaFor(key.NamespaceID)
+		}
+		nsUsage.ByDir[key.StorageID] += usage
+		nsUsage.Bytes += usage
+		result[key.NamespaceID] = nsUsage
+	}
+	return result, nil
 }
 
 // recalculateDirUsage queries the database for all usage counters and
@@ -363,6 +506,11 @@ func (em *EvictionManager) checkAndEvict() {
 	var totalSkipped atomic.Int64
 	var totalConflicts atomic.Int64
 
+	// Namespaces over quota are trimmed whether or not any directory is
+	// full, and first, since that may be all the room the directories need.
+	orders := newEvictionOrders()
+	em.enforceQuotas(rl, orders, startTime.Add(30*time.Second))
+
 	var wg sync.WaitGroup
 	for sid, limits := range em.dirLimits {
 		wg.Add(1)
@@ -379,6 +527,18 @@ func (em *EvictionManager) checkAndEvict() {
 				"highWater": limits.highWater,
 			}).Info("Starting eviction")
 
+			// On a tiered cache, cold objects move to the slow tier in the
+			// background (see migrationLoop), which copies whole files and
+			// so must not hold up eviction.  A fast directory is evicted
+			// from only when the slow tier is full or migration has fallen
+			// so far behind that the directory is past its maximum size.
+			if em.tiered && em.isFastTier(sid) {
+				em.triggerMigration()
+				if _, ok := em.migrationTarget(); ok && uint64(dirUsage) <= limits.maxSize {
+					return
+				}
+			}
+
 			for dirUsage = em.getDirUsage(sid); dirUsage > 0 && uint64(dirUsage) > limits.lowWater; dirUsage = em.getDirUsage(sid) {
 				// Find the greediest namespace in this directory
 				targetKey, targetUsage, err := em.findGreediestNamespaceInDir(sid)
@@ -400,7 +560,7 @@ func (em *EvictionManager) checkAndEvict() {
 					"needToFree":  utils.HumanBytes(overhead),
 				}).Debug("Evicting from namespace")
 
-				bytes, count, skipped, conflicts, err := em.evictFromNamespace(rl, targetKey.StorageID, targetKey.NamespaceID, 0, overhead)
+				bytes, count, skipped, conflicts, err := em.evictFromNamespace(rl, orders, targetKey.StorageID, targetKey.NamespaceID, 0, overhead)
 				totalConflicts.Add(int64(conflicts))
 				if err != nil {
 					rl.WithFields(log.Fields{
@@ -477,11 +637,16 @@ func (em *EvictionManager) findGreediestNamespaceInDir(storageID StorageID) (Sto
 	return StorageUsageKey{StorageID: storageID, NamespaceID: bestNS}, bestUsage, nil
 }
 
-// evictFromNamespace walks the LRU index for a storage+namespace and evicts
-// the oldest objects until either maxObjects have been removed or maxBytes of
-// content has been freed — whichever comes first.  Pass 0 for either limit to
-// leave it unconstrained.  The eviction is allowed to go one object over the
-// byte threshold to prevent starvation when only large objects remain.
+// evictFromNamespace evicts objects from a storage+namespace in the order
+// chosen by its EvictionPolicy (by default, the LRU index) until either
+// maxObjects have been removed or maxBytes of content has been freed —
+// whichever comes first.  Pass 0 for either limit to leave it
+// unconstrained.  The eviction is allowed to go one object over the byte
+// threshold to prevent starvation when only large objects remain.
+//
+// orders holds the policy orders computed earlier in the same eviction
+// pass, so that a pass evicting in several batches lists the candidates
+// only once; pass nil to compute the order afresh.
 //
 // On a BadgerDB transaction conflict the method retries with progressively
 // smaller batch sizes (50, then 10 objects) before giving up.
@@ -490,13 +655,23 @@ func (em *EvictionManager) findGreediestNamespaceInDir(storageID StorageID) (Sto
 //
 // Returns total bytes freed, number of objects evicted, number spared, number
 // of conflicts, and any non-retryable error.
-func (em *EvictionManager) evictFromNamespace(rl *log.Entry, storageID StorageID, namespaceID NamespaceID, maxObjects int, maxBytes int64) (totalFreed uint64, totalCount int, skipped int, conflicts int, err error) {
+func (em *EvictionManager) evictFromNamespace(rl *log.Entry, orders *evictionOrders, storageID StorageID, namespaceID NamespaceID, maxObjects int, maxBytes int64) (totalFreed uint64, totalCount int, skipped int, conflicts int, err error) {
 	// batchCaps defines the decreasing batch sizes used on successive
 	// conflict retries.  The first attempt uses the caller's original
 	// limits; subsequent retries cap maxObjects to reduce the transaction
 	// footprint and lower the probability of another conflict.
 	batchCaps := []int{0, 50, 10} // 0 means "use caller's value"
 
+	// Frequency-aware policies choose the order up front; LRU walks the index
+	// inside the eviction transaction instead.
+	key := StorageUsageKey{StorageID: storageID, NamespaceID: namespaceID}
+	order, err := orders.get(key, func() ([]InstanceHash, error) {
+		return em.policyOrder(storageID, namespaceID)
+	})
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+
 	for attempt, cap := range batchCaps {
 		effMaxObjects := maxObjects
 		if cap > 0 && (effMaxObjects == 0 || effMaxObjects > cap) {
@@ -505,7 +680,11 @@ func (em *EvictionManager) evictFromNamespace(rl *log.Entry, storageID StorageID
 
 		var evicted []evictedObject
 		var freed uint64
-		evicted, freed, skipped, err = em.storage.EvictByLRU(storageID, namespaceID, effMaxObjects, maxBytes)
+		if order == nil {
+			evicted, freed, skipped, err = em.storage.EvictByLRU(storageID, namespaceID, effMaxObjects, maxBytes)
+		} else {
+			evicted, freed, skipped, err = em.storage.EvictInOrder(storageID, namespaceID, order, effMaxObjects, maxBytes)
+		}
 
 		if err != nil && errors.Is(err, badger.ErrConflict) {
 			conflicts++
@@ -532,6 +711,9 @@ func (em *EvictionManager) evictFromNamespace(rl *log.Entry, storageID StorageID
 		}
 
 		em.noteEvicted(evicted)
+		if order != nil {
+			orders.advance(key, evicted)
+		}
 
 		for _, obj := range evicted {
 			rl.WithFields(log.Fields{
@@ -551,11 +733,17 @@ func (em *EvictionManager) evictFromNamespace(rl *log.Entry, storageID StorageID
 // noteEvicted adjusts the per-directory in-memory atomic counters after
 // a batch of objects has been removed from the DB.  The DB-level usage
 // counters were already decremented inside the transaction; this keeps
-// the in-memory estimates in sync.
+// the in-memory estimates in sync.  It also tells each object's eviction
+// policy that the object is gone.
 func (em *EvictionManager) noteEvicted(evicted []evictedObject) {
 	// Accumulate per-storageID totals to minimize atomic operations.
 	perDir := make(map[StorageID]int64, 2)
 	for _, obj := range evicted {
+		em.policyFor(obj.storageID, obj.namespaceID).RecordEviction(obj.instanceHash)
+		counters := em.namespaceCounters(obj.namespaceID)
+		counters.evictedObjects.Add(1)
+		counters.evictedBytes.Add(uint64(max(obj.contentLen, 0)))
+
 		// For chunked objects, attribute bytes to each directory
 		// proportional to the chunks stored there.
 		meta := &CacheMetadata{
@@ -575,12 +763,149 @@ func (em *EvictionManager) noteEvicted(evicted []evictedObject) {
 	}
 }
 
-// RecordAccess records an access to an object, updating LRU
-func (em *EvictionManager) RecordAccess(instanceHash InstanceHash) error {
+// RecordAccess records an access to an object, updating LRU and the
+// object's eviction policy.  hit is false when the object had to be fetched
+// before it could be read; it only feeds the namespace's hit ratio.
+func (em *EvictionManager) RecordAccess(instanceHash InstanceHash, meta *CacheMetadata, hit bool) error {
+	if meta != nil {
+		em.policyFor(meta.StorageID, meta.NamespaceID).RecordAccess(instanceHash, meta.ContentLength, time.Now())
+		counters := em.namespaceCounters(meta.NamespaceID)
+		if hit {
+			counters.hits.Add(1)
+		} else {
+			counters.misses.Add(1)
+		}
+	}
 	// Use 10 minute debounce as specified in design doc
 	return em.db.UpdateLRU(instanceHash, 10*time.Minute)
 }
 
+// SetNamespaceResolver tells the eviction manager how to map a namespace ID
+// back to its prefix, which is how Cache.NamespaceEvictionPolicies selects a
+// namespace's policy.  Until it is called, every namespace uses the default
+// policy, so it must be called before the cache starts serving.
+func (em *EvictionManager) SetNamespaceResolver(fn func(NamespaceID) (string, bool)) {
+	em.policyMu.Lock()
+	defer em.policyMu.Unlock()
+	em.namespacePrefix = fn
+}
+
+// policyName returns the name of the eviction policy configured for a
+// namespace.  Callers must hold policyMu.
+func (em *EvictionManager) policyName(namespaceID NamespaceID) string {
+	if em.namespacePrefix != nil && len(em.namespacePolicies) > 0 {
+		if prefix, ok := em.namespacePrefix(namespaceID); ok {
+			if name, ok := em.namespacePolicies[prefix]; ok {
+				return name
+			}
+		}
+	}
+	return em.defaultPolicy
+}
+
+// policyFor returns the eviction policy for a storage+namespace, creating it
+// on first use.
+func (em *EvictionManager) policyFor(storageID StorageID, namespaceID NamespaceID) EvictionPolicy {
+	key := StorageUsageKey{StorageID: storageID, NamespaceID: namespaceID}
+	em.policyMu.Lock()
+	defer em.policyMu.Unlock()
+	if policy, ok := em.policies[key]; ok {
+		return policy
+	}
+	policy, err := newEvictionPolicy(em.policyName(namespaceID), em.frequencyHalfLife)
+	if err != nil {
+		// Names were validated by NewEvictionManager, so this is unreachable.
+		policy = lruPolicy{}
+	}
+	em.policies[key] = policy
+	return policy
+}
+
+// namespaceCounters returns the hit and eviction counters for a namespace,
+// creating them on first use.
+func (em *EvictionManager) namespaceCounters(namespaceID NamespaceID) *namespaceEvictionCounters {
+	em.policyMu.Lock()
+	defer em.policyMu.Unlock()
+	counters, ok := em.nsStats[namespaceID]
+	if !ok {
+		counters = &namespaceEvictionCounters{}
+		em.nsStats[namespaceID] = counters
+	}
+	return counters
+}
+
+// policyOrder asks a storage+namespace's eviction policy for the order in
+// which to evict its objects.  A nil order means plain LRU, which needs no
+// candidate list because EvictByLRU walks the index itself.
+func (em *EvictionManager) policyOrder(storageID StorageID, namespaceID NamespaceID) ([]InstanceHash, error) {
+	policy := em.policyFor(storageID, namespaceID)
+	if _, isLRU := policy.(lruPolicy); isLRU {
+		return nil, nil
+	}
+	candidates, complete, err := em.db.ListLRUCandidates(storageID, namespaceID, evictionCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+	return policy.OrderCandidates(candidates, complete, time.Now()), nil
+}
+
+// evictionOrders remembers the policy order of each storage+namespace for
+// the rest of an eviction pass.  A nil *evictionOrders remembers nothing.
+type evictionOrders struct {
+	mu     sync.Mutex
+	orders map[StorageUsageKey][]InstanceHash
+}
+
+func newEvictionOrders() *evictionOrders {
+	return &evictionOrders{orders: make(map[StorageUsageKey][]InstanceHash)}
+}
+
+// get returns the remembered order for a storage+namespace, calling compute
+// the first time it is asked for.
+func (o *evictionOrders) get(key StorageUsageKey, compute func() ([]InstanceHash, error)) ([]InstanceHash, error) {
+	if o == nil {
+		return compute()
+	}
+	o.mu.Lock()
+	order, ok := o.orders[key]
+	o.mu.Unlock()
+	if ok {
+		return order, nil
+	}
+	order, err := compute()
+	if err != nil {
+		return nil, err
+	}
+	o.mu.Lock()
+	o.orders[key] = order
+	o.mu.Unlock()
+	return order, nil
+}
+
+// advance drops the part of a remembered order that a batch has walked
+// through, up to its last evicted object.  Everything before that was
+// evicted, already gone, or in use, and the next batch would only pass over
+// it again.
+func (o *evictionOrders) advance(key StorageUsageKey, evicted []evictedObject) {
+	if o == nil || len(evicted) == 0 {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	order, ok := o.orders[key]
+	if !ok {
+		return
+	}
+	// Purge-first objects are evicted ahead of the order and may not be in
+	// it; the last object from the order itself is then further back.
+	for idx := len(evicted) - 1; idx >= 0; idx-- {
+		if pos := slices.Index(order, evicted[idx].instanceHash); pos >= 0 {
+			o.orders[key] = order[pos+1:]
+			return
+		}
+	}
+}
+
 // GetStats returns eviction manager statistics
 func (em *EvictionManager) GetStats() EvictionStats {
 	usage, _ := em.db.GetAllUsage()
@@ -591,21 +916,66 @@ func (em *EvictionManager) GetStats() EvictionStats {
 			MaxSize:   limits.maxSize,
 			HighWater: limits.highWater,
 			LowWater:  limits.lowWater,
+			Tier:      limits.tier,
+		}
+	}
+
+	em.policyMu.Lock()
+	nsStats := make(map[NamespaceID]NamespaceEvictionStats, len(em.nsStats))
+	for ns, counters := range em.nsStats {
+		stats := NamespaceEvictionStats{
+			Policy:              em.policyName(ns),
+			Hits:                counters.hits.Load(),
+			Misses:              counters.misses.Load(),
+			EvictedObjects:      counters.evictedObjects.Load(),
+			EvictedBytes:        counters.evictedBytes.Load(),
+			QuotaRejected:       counters.quotaRejected.Load(),
+			QuotaEvictedObjects: counters.quotaEvictedObjects.Load(),
+			QuotaEvictedBytes:   counters.quotaEvictedBytes.Load(),
+		}
+		if total := stats.Hits + stats.Misses; total > 0 {
+			stats.HitRatio = float64(stats.Hits) / float64(total)
 		}
+		nsStats[ns] = stats
 	}
+	em.policyMu.Unlock()
 
 	return EvictionStats{
-		TotalUsage:     em.GetTotalUsage(),
-		DirStats:       dirStats,
-		NamespaceUsage: usage,
+		TotalUsage:      em.GetTotalUsage(),
+		DirStats:        dirStats,
+		NamespaceUsage:  usage,
+		NamespaceStats:  nsStats,
+		MigratedObjects: em.migratedObjects.Load(),
+		MigratedBytes:   em.migratedBytes.Load(),
 	}
 }
 
-// EvictionStats contains eviction manager statistics
+// EvictionStats contains eviction manager statistics.  MigratedObjects and
+// MigratedBytes count what moved to the slow storage tier since startup.
 type EvictionStats struct {
-	TotalUsage     uint64
-	DirStats       map[StorageID]DirEvictionStats
-	NamespaceUsage map[StorageUsageKey]int64
+	TotalUsage      uint64
+	DirStats        map[StorageID]DirEvictionStats
+	NamespaceUsage  map[StorageUsageKey]int64
+	NamespaceStats  map[NamespaceID]NamespaceEvictionStats
+	MigratedObjects uint64
+	MigratedBytes   uint64
+}
+
+// NamespaceEvictionStats shows how well a namespace's eviction policy is
+// doing.  Hits and misses count reads since startup; a miss is a read that
+// had to fetch the object first.  The Quota* counters cover objects turned
+// away at the namespace's hard quota and evictions made to keep it within
+// its quota; the latter are included in EvictedObjects and EvictedBytes.
+type NamespaceEvictionStats struct {
+	Policy              string
+	Hits                uint64
+	Misses              uint64
+	HitRatio            float64
+	EvictedObjects      uint64
+	EvictedBytes        uint64
+	QuotaRejected       uint64
+	QuotaEvictedObjects uint64
+	QuotaEvictedBytes   uint64
 }
 
 // DirEvictionStats contains per-directory eviction statistics
@@ -613,6 +983,7 @@ type DirEvictionStats struct {
 	MaxSize   uint64
 	HighWater uint64
 	LowWater  uint64
+	Tier      StorageTier
 }
 
 // HasSpace returns true if there's room for more data in at least one
@@ -634,14 +1005,16 @@ func (em *EvictionManager) HasSpace(needed uint64) bool {
 // ChooseDiskStorage.  Each of the rrTableSize entries is assigned to
 // a directory ID proportional to that directory's free space, then
 // the table is shuffled so that a linear walk produces a uniform
-// spread.
+// spread.  On a tiered cache only the fast tier is considered (see
+// placementDirs).
 func (em *EvictionManager) rebuildRRTable() {
 	var table [rrTableSize]StorageID
+	dirIDs := em.placementDirs()
 
-	if len(em.dirIDs) == 1 {
+	if len(dirIDs) == 1 {
 		// Single-directory fast path: fill the entire table.
 		for i := range table {
-			table[i] = em.dirIDs[0]
+			table[i] = dirIDs[0]
 		}
 		em.rrTable.Store(&table)
 		em.rrLastUpdate.Store(time.Now().UnixMilli())
@@ -653,9 +1026,9 @@ func (em *EvictionManager) rebuildRRTable() {
 		id   StorageID
 		free int64
 	}
-	raw := make([]dirWeight, 0, len(em.dirIDs))
+	raw := make([]dirWeight, 0, len(dirIDs))
 	var rawTotal int64
-	for _, sid := range em.dirIDs {
+	for _, sid := range dirIDs {
 		used := em.dirUsage[sid].Load()
 		if used < 0 {
 			used = 0
@@ -812,6 +1185,7 @@ func (em *EvictionManager) forcePurgeToTargets(label string, targets map[Storage
 	startTime := time.Now()
 	var evictedBytes atomic.Uint64
 	var evictedObjects atomic.Int64
+	orders := newEvictionOrders()
 
 	var wg sync.WaitGroup
 	for sid := range em.dirLimits {
@@ -834,7 +1208,7 @@ func (em *EvictionManager) forcePurgeToTargets(label string, targets map[Storage
 				}
 
 				overhead := dirUsage - dirTarget
-				bytes, count, skipped, _, err := em.evictFromNamespace(rl, targetKey.StorageID, targetKey.NamespaceID, 0, overhead)
+				bytes, count, skipped, _, err := em.evictFromNamespace(rl, orders, targetKey.StorageID, targetKey.NamespaceID, 0, overhead)
 				if err != nil {
 					rl.WithFields(log.Fields{
 						"storageID":   targetKey.StorageID,
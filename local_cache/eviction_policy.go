@@ -0,0 +1,328 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Names of the eviction policies, as used in Cache.EvictionPolicy
+const (
+	evictionPolicyLRU    = "lru"
+	evictionPolicyLFU    = "lfu"
+	evictionPolicyS3FIFO = "s3fifo"
+	evictionPolicyGDSF   = "gdsf"
+)
+
+// s3fifoGhostCapacity bounds how many recently evicted one-hit objects the
+// S3-FIFO policy remembers.  An object that comes back while still remembered
+// skips the small queue and goes straight to the main queue.
+const s3fifoGhostCapacity = 65536
+
+// s3fifoMaxFreq caps the S3-FIFO access counter, as in the original design:
+// a handful of reads is enough to prove an object belongs in the main queue.
+const s3fifoMaxFreq = 3
+
+// newEvictionPolicy creates the named eviction policy.  halfLife is only
+// used by the "lfu" policy.
+func newEvictionPolicy(name string, halfLife time.Duration) (EvictionPolicy, error) {
+	switch strings.ToLower(name) {
+	case "", evictionPolicyLRU:
+		return lruPolicy{}, nil
+	case evictionPolicyLFU:
+		return &lfuPolicy{halfLife: halfLife, entries: make(map[InstanceHash]*lfuEntry)}, nil
+	case evictionPolicyS3FIFO:
+		return &s3fifoPolicy{entries: make(map[InstanceHash]*s3fifoEntry), ghosts: make(map[InstanceHash]struct{})}, nil
+	case evictionPolicyGDSF:
+		return &gdsfPolicy{entries: make(map[InstanceHash]*gdsfEntry)}, nil
+	default:
+		return nil, errors.Errorf("unknown eviction policy %q", name)
+	}
+}
+
+// forgetMissing drops the entries of a policy's state map whose objects are
+// no longer among the candidates.  Only valid when the candidates are complete.
+func forgetMissing[V any](entries map[InstanceHash]V, candidates []EvictionCandidate) {
+	present := make(map[InstanceHash]struct{}, len(candidates))
+	for _, c := range candidates {
+		present[c.InstanceHash] = struct{}{}
+	}
+	for hash := range entries {
+		if _, ok := present[hash]; !ok {
+			delete(entries, hash)
+		}
+	}
+}
+
+// orderByScore returns the candidates' hashes sorted by ascending score.  The
+// sort is stable, so candidates with equal scores stay in LRU order.
+func orderByScore(candidates []EvictionCandidate, score func(EvictionCandidate) float64) []InstanceHash {
+	type scored struct {
+		hash  InstanceHash
+		score float64
+	}
+	ranked := make([]scored, len(candidates))
+	for idx, c := range candidates {
+		ranked[idx] = scored{hash: c.InstanceHash, score: score(c)}
+	}
+	slices.SortStableFunc(ranked, func(a, b scored) int { return cmp.Compare(a.score, b.score) })
+	order := make([]InstanceHash, len(ranked))
+	for idx, r := range ranked {
+		order[idx] = r.hash
+	}
+	return order
+}
+
+// lruPolicy is the default policy.  It keeps no state of its own: the LRU
+// index in the database already is the eviction order.
+type lruPolicy struct{}
+
+func (lruPolicy) Name() string                                                        { return evictionPolicyLRU }
+func (lruPolicy) RecordAccess(InstanceHash, int64, time.Time)                         {}
+func (lruPolicy) RecordEviction(InstanceHash)                                         {}
+func (lruPolicy) OrderCandidates([]EvictionCandidate, bool, time.Time) []InstanceHash { return nil }
+
+// lfuPolicy evicts the least frequently used objects first.  Access counts
+// decay exponentially so that objects which were popular long ago age out.
+type lfuPolicy struct {
+	halfLife time.Duration
+
+	mu      sync.Mutex
+	entries map[InstanceHash]*lfuEntry
+}
+
+type lfuEntry struct {
+	count   float64
+	updated time.Time
+}
+
+// decayed returns the entry's access count as of now
+func (e *lfuEntry) decayed(now time.Time, halfLife time.Duration) float64 {
+	if halfLife <= 0 || !now.After(e.updated) {
+		return e.count
+	}
+	return e.count * math.Exp2(-now.Sub(e.updated).Seconds()/halfLife.Seconds())
+}
+
+func (p *lfuPolicy) Name() string { return evictionPolicyLFU }
+
+func (p *lfuPolicy) RecordAccess(instanceHash InstanceHash, _ int64, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[instanceHash]
+	if !ok {
+		e = &lfuEntry{}
+		p.entries[instanceHash] = e
+	}
+	e.count = e.decayed(now, p.halfLife) + 1
+	e.updated = now
+}
+
+func (p *lfuPolicy) RecordEviction(instanceHash InstanceHash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.entries, instanceHash)
+}
+
+func (p *lfuPolicy) OrderCandidates(candidates []EvictionCandidate, complete bool, now time.Time) []InstanceHash {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if complete {
+		forgetMissing(p.entries, candidates)
+	}
+	return orderByScore(candidates, func(c EvictionCandidate) float64 {
+		if e, ok := p.entries[c.InstanceHash]; ok {
+			return e.decayed(now, p.halfLife)
+		}
+		return 0
+	})
+}
+
+// s3fifoPolicy is modeled on S3-FIFO: objects enter a small FIFO queue and
+// are evicted from it, oldest first, unless they are read again, in which
+// case they are promoted to the main queue.  This makes a one-shot scan
+// evict itself instead of the working set.  The main queue is evicted least
+// frequently used first, oldest first among equals, and each eviction pass
+// ages it by decrementing every counter, like a CLOCK sweep giving each
+// object a second chance per access.  Objects evicted from the small queue
+// are remembered in a ghost set; if they come back, they skip straight to
+// the main queue.
+type s3fifoPolicy struct {
+	mu         sync.Mutex
+	entries    map[InstanceHash]*s3fifoEntry
+	nextSeq    uint64
+	ghosts     map[InstanceHash]struct{}
+	ghostOrder []InstanceHash
+}
+
+type s3fifoEntry struct {
+	freq uint8
+	main bool
+	seq  uint64 // insertion order
+}
+
+func (p *s3fifoPolicy) Name() string { return evictionPolicyS3FIFO }
+
+func (p *s3fifoPolicy) RecordAccess(instanceHash InstanceHash, _ int64, _ time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.entries[instanceHash]; ok {
+		if e.freq < s3fifoMaxFreq {
+			e.freq++
+		}
+		return
+	}
+	p.nextSeq++
+	e := &s3fifoEntry{seq: p.nextSeq}
+	if _, ok := p.ghosts[instanceHash]; ok {
+		delete(p.ghosts, instanceHash)
+		e.main = true
+	}
+	p.entries[instanceHash] = e
+}
+
+func (p *s3fifoPolicy) RecordEviction(instanceHash InstanceHash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[instanceHash]
+	if !ok {
+		return
+	}
+	delete(p.entries, instanceHash)
+	if e.main {
+		return
+	}
+	p.ghosts[instanceHash] = struct{}{}
+	p.ghostOrder = append(p.ghostOrder, instanceHash)
+	for len(p.ghostOrder) > s3fifoGhostCapacity {
+		delete(p.ghosts, p.ghostOrder[0])
+		p.ghostOrder = p.ghostOrder[1:]
+	}
+}
+
+func (p *s3fifoPolicy) OrderCandidates(candidates []EvictionCandidate, complete bool, _ time.Time) []InstanceHash {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if complete {
+		forgetMissing(p.entries, candidates)
+	}
+
+	var small, main []EvictionCandidate
+	for _, c := range candidates {
+		e, ok := p.entries[c.InstanceHash]
+		switch {
+		case !ok:
+			// Objects we've never seen (e.g. from before a restart) survived
+			// at least one prior run, so they belong to the main queue.
+			main = append(main, c)
+		case !e.main && e.freq == 0:
+			small = append(small, c)
+		default:
+			if !e.main {
+				// Read again while in the small queue: promote
+				e.main = true
+				e.freq = 0
+			}
+			main = append(main, c)
+		}
+	}
+
+	seq := func(c EvictionCandidate) float64 {
+		if e, ok := p.entries[c.InstanceHash]; ok {
+			return float64(e.seq)
+		}
+		return 0
+	}
+	order := orderByScore(small, seq)
+	order = append(order, orderByScore(main, func(c EvictionCandidate) float64 {
+		var freq uint8
+		if e, ok := p.entries[c.InstanceHash]; ok {
+			freq = e.freq
+		}
+		// Frequency dominates; insertion order breaks ties
+		return float64(freq)*float64(p.nextSeq+1) + seq(c)
+	})...)
+
+	for _, c := range main {
+		if e, ok := p.entries[c.InstanceHash]; ok && e.freq > 0 {
+			e.freq--
+		}
+	}
+	return order
+}
+
+// gdsfPolicy implements Greedy-Dual-Size-Frequency: each object's priority
+// is the cache's inflation value plus its access count divided by its size,
+// and the lowest-priority objects are evicted first.  The inflation value
+// rises to the priority of each evicted object, so objects that stop being
+// read are eventually overtaken by newer ones.
+type gdsfPolicy struct {
+	mu        sync.Mutex
+	entries   map[InstanceHash]*gdsfEntry
+	inflation float64
+}
+
+type gdsfEntry struct {
+	freq     float64
+	priority float64
+}
+
+func (p *gdsfPolicy) Name() string { return evictionPolicyGDSF }
+
+func (p *gdsfPolicy) RecordAccess(instanceHash InstanceHash, size int64, _ time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[instanceHash]
+	if !ok {
+		e = &gdsfEntry{}
+		p.entries[instanceHash] = e
+	}
+	e.freq++
+	e.priority = p.inflation + e.freq/float64(max(size, 1))
+}
+
+func (p *gdsfPolicy) RecordEviction(instanceHash InstanceHash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.entries[instanceHash]; ok {
+		p.inflation = max(p.inflation, e.priority)
+		delete(p.entries, instanceHash)
+	}
+}
+
+func (p *gdsfPolicy) OrderCandidates(candidates []EvictionCandidate, complete bool, _ time.Time) []InstanceHash {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if complete {
+		forgetMissing(p.entries, candidates)
+	}
+	return orderByScore(candidates, func(c EvictionCandidate) float64 {
+		if e, ok := p.entries[c.InstanceHash]; ok {
+			return e.priority
+		}
+		return 0
+	})
+}
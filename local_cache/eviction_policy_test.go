@@ -0,0 +1,252 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+func candidatesFor(hashes ...InstanceHash) []EvictionCandidate {
+	candidates := make([]EvictionCandidate, len(hashes))
+	for idx, hash := range hashes {
+		candidates[idx] = EvictionCandidate{InstanceHash: hash}
+	}
+	return candidates
+}
+
+func TestNewEvictionPolicy(t *testing.T) {
+	for name, expected := range map[string]string{
+		"":       evictionPolicyLRU,
+		"lru":    evictionPolicyLRU,
+		"LFU":    evictionPolicyLFU,
+		"s3fifo": evictionPolicyS3FIFO,
+		"gdsf":   evictionPolicyGDSF,
+	} {
+		policy, err := newEvictionPolicy(name, time.Hour)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, policy.Name())
+	}
+	_, err := newEvictionPolicy("arc", time.Hour)
+	assert.ErrorContains(t, err, "unknown eviction policy")
+
+	// LRU leaves the order to the LRU index
+	assert.Nil(t, lruPolicy{}.OrderCandidates(candidatesFor("a", "b"), true, time.Now()))
+}
+
+func TestLFUPolicy(t *testing.T) {
+	policy, err := newEvictionPolicy(evictionPolicyLFU, time.Hour)
+	require.NoError(t, err)
+	now := time.Now()
+
+	// "old" was popular two hours ago; "recent" was read twice just now
+	for range 6 {
+		policy.RecordAccess("old", 100, now.Add(-2*time.Hour))
+	}
+	policy.RecordAccess("recent", 100, now)
+	policy.RecordAccess("recent", 100, now)
+	policy.RecordAccess("once", 100, now)
+
+	// Two half-lives decay old's count of 6 to 1.5, below recent's 2
+	order := policy.OrderCandidates(candidatesFor("old", "recent", "once", "unknown"), false, now)
+	assert.Equal(t, []InstanceHash{"unknown", "once", "old", "recent"}, order)
+
+	// Evicted objects are forgotten
+	policy.RecordEviction("recent")
+	order = policy.OrderCandidates(candidatesFor("recent", "once"), false, now)
+	assert.Equal(t, []InstanceHash{"recent", "once"}, order)
+}
+
+func TestS3FIFOPolicy(t *testing.T) {
+	policy, err := newEvictionPolicy(evictionPolicyS3FIFO, 0)
+	require.NoError(t, err)
+	now := time.Now()
+
+	// The working set is read repeatedly, then a scan reads each object once
+	for _, hash := range []InstanceHash{"hot1", "hot2"} {
+		policy.RecordAccess(hash, 100, now)
+		policy.RecordAccess(hash, 100, now)
+	}
+	for idx := range 3 {
+		policy.RecordAccess(InstanceHash(fmt.Sprintf("scan%d", idx)), 100, now)
+	}
+
+	// Candidates arrive in LRU order, with the working set oldest
+	candidates := candidatesFor("hot1", "hot2", "scan0", "scan1", "scan2")
+	order := policy.OrderCandidates(candidates, true, now)
+	assert.Equal(t, []InstanceHash{"scan0", "scan1", "scan2", "hot1", "hot2"}, order)
+
+	// A scan object evicted from the small queue goes straight to the main
+	// queue if it comes back
+	policy.RecordEviction("scan0")
+	policy.RecordAccess("scan0", 100, now)
+	policy.RecordAccess("new", 100, now)
+	order = policy.OrderCandidates(candidatesFor("scan0", "new"), false, now)
+	assert.Equal(t, []InstanceHash{"new", "scan0"}, order)
+}
+
+func TestGDSFPolicy(t *testing.T) {
+	policy, err := newEvictionPolicy(evictionPolicyGDSF, 0)
+	require.NoError(t, err)
+	now := time.Now()
+
+	policy.RecordAccess("big", 1<<30, now)
+	policy.RecordAccess("big", 1<<30, now)
+	policy.RecordAccess("small", 1<<10, now)
+	policy.RecordAccess("medium", 1<<20, now)
+
+	// Frequency per byte: the large object goes first despite being read twice
+	order := policy.OrderCandidates(candidatesFor("small", "medium", "big"), false, now)
+	assert.Equal(t, []InstanceHash{"big", "medium", "small"}, order)
+
+	// Evicting raises the inflation value, so a new object outranks objects
+	// that were popular before it
+	policy.RecordEviction("small")
+	policy.RecordAccess("new", 1<<20, now)
+	order = policy.OrderCandidates(candidatesFor("medium", "new"), false, now)
+	assert.Equal(t, []InstanceHash{"medium", "new"}, order)
+}
+
+// With S3-FIFO, a one-shot scan of new objects should be evicted before the
+// older but repeatedly read working set, which plain LRU would evict first.
+func TestEvictionPolicyScanResistance(t *testing.T) {
+	InitIssuerKeyForTests(t)
+
+	for _, tc := range []struct {
+		policy      string
+		hotSurvives bool
+	}{
+		{evictionPolicyLRU, false},
+		{evictionPolicyS3FIFO, true},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			db, err := NewCacheDB(ctx, tmpDir)
+			require.NoError(t, err)
+			defer db.Close()
+
+			egrp, _ := errgroup.WithContext(ctx)
+			storage, err := NewStorageManager(db, []string{tmpDir}, 0, egrp)
+			require.NoError(t, err)
+			defer storage.Close()
+
+			const nsID NamespaceID = 1
+			em := NewEvictionManager(db, storage, EvictionConfig{
+				DirConfigs: map[StorageID]EvictionDirConfig{
+					StorageIDFirstDisk: {MaxSize: 1 << 20},
+				},
+				NamespacePolicies: map[string]string{"/data": tc.policy},
+			})
+			em.SetNamespaceResolver(func(id NamespaceID) (string, bool) { return "/data", id == nsID })
+
+			data := []byte("some cached object data")
+			store := func(hash InstanceHash, reads int) {
+				meta := &CacheMetadata{ContentLength: int64(len(data)), NamespaceID: nsID}
+				require.NoError(t, storage.StoreInline(ctx, hash, meta, data))
+				meta, err := storage.GetMetadata(hash)
+				require.NoError(t, err)
+				for idx := range reads {
+					require.NoError(t, em.RecordAccess(hash, meta, idx > 0))
+				}
+				// Keep the LRU timestamps strictly increasing
+				time.Sleep(time.Millisecond)
+			}
+			hot := []InstanceHash{"hot1", "hot2"}
+			for _, hash := range hot {
+				store(hash, 3)
+			}
+			for idx := range 4 {
+				store(InstanceHash(fmt.Sprintf("scan%d", idx)), 1)
+			}
+
+			_, count, _, _, err := em.evictFromNamespace(log.WithField("test", t.Name()), nil, StorageIDInline, nsID, 2, 0)
+			require.NoError(t, err)
+			assert.Equal(t, 2, count)
+
+			for _, hash := range hot {
+				ok, err := storage.HasObject(hash)
+				require.NoError(t, err)
+				assert.Equal(t, tc.hotSurvives, ok, "object %s", hash)
+			}
+
+			stats := em.GetStats().NamespaceStats[nsID]
+			assert.Equal(t, tc.policy, stats.Policy)
+			assert.Equal(t, uint64(4), stats.Hits)
+			assert.Equal(t, uint64(6), stats.Misses)
+			assert.InDelta(t, 0.4, stats.HitRatio, 1e-9)
+			assert.Equal(t, uint64(2), stats.EvictedObjects)
+			assert.Equal(t, uint64(2*len(data)), stats.EvictedBytes)
+		})
+	}
+}
+
+func TestEvictionManagerUnknownPolicy(t *testing.T) {
+	em := NewEvictionManager(nil, nil, EvictionConfig{
+		DirConfigs: map[StorageID]EvictionDirConfig{
+			StorageIDFirstDisk: {MaxSize: 1 << 20},
+		},
+		Policy:            "bogus",
+		NamespacePolicies: map[string]string{"/a": "gdsf", "/b": "bogus"},
+	})
+	em.SetNamespaceResolver(func(id NamespaceID) (string, bool) {
+		return map[NamespaceID]string{1: "/a", 2: "/b"}[id], id == 1 || id == 2
+	})
+	assert.Equal(t, evictionPolicyGDSF, em.policyFor(StorageIDFirstDisk, 1).Name())
+	assert.Equal(t, evictionPolicyLRU, em.policyFor(StorageIDFirstDisk, 2).Name())
+	assert.Equal(t, evictionPolicyLRU, em.policyFor(StorageIDFirstDisk, 3).Name())
+}
+
+func TestEvictionOrders(t *testing.T) {
+	key := StorageUsageKey{StorageID: StorageIDFirstDisk, NamespaceID: 1}
+	computed := 0
+	compute := func() ([]InstanceHash, error) {
+		computed++
+		return []InstanceHash{"a", "b", "c", "d"}, nil
+	}
+
+	orders := newEvictionOrders()
+	order, err := orders.get(key, compute)
+	require.NoError(t, err)
+	assert.Equal(t, []InstanceHash{"a", "b", "c", "d"}, order)
+
+	// "a" was in use, "b" was evicted, and "x" was a purge-first object
+	// evicted ahead of the order
+	orders.advance(key, []evictedObject{{instanceHash: "b"}, {instanceHash: "x"}})
+	order, err = orders.get(key, compute)
+	require.NoError(t, err)
+	assert.Equal(t, []InstanceHash{"c", "d"}, order)
+	assert.Equal(t, 1, computed, "the order is computed once per pass")
+
+	// Without a pass, every call computes the order afresh
+	var none *evictionOrders
+	_, err = none.get(key, compute)
+	require.NoError(t, err)
+	none.advance(key, []evictedObject{{instanceHash: "a"}})
+	assert.Equal(t, 2, computed)
+}
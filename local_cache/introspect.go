@@ -67,6 +67,8 @@ type ObjectDetails struct {
 	Checksums     []ChecksumInfo    `json:"checksums,omitempty"`
 	BlockSummary  *BlockSummary     `json:"block_summary,omitempty"` // nil for inline storage
 	ChunkSummary  *ChunkInfoSummary `json:"chunk_info,omitempty"`    // nil for non-chunked
+	Compression   string            `json:"compression,omitempty"`   // block compression; empty if none
+	Dedup         bool              `json:"dedup,omitempty"`         // blocks held in the shared block pool
 }
 
 // ChecksumInfo describes a stored checksum.
@@ -101,13 +103,22 @@ type ChecksumStatus struct {
 
 // CacheStats contains aggregate size statistics about the cache.
 type CacheStats struct {
-	TotalInlineBytes     int64                       `json:"total_inline_bytes"`
-	TotalMetadataEntries int64                       `json:"total_metadata_entries"`
-	TotalBytesMetadata   int64                       `json:"total_bytes_metadata"`     // Sum of ContentLength from metadata entries
-	UsageCounters        map[string]int64            `json:"usage_counters,omitempty"` // Pre-computed usage from u: prefix keys
-	StorageBreakdown     map[string]*StorageDirStats `json:"storage_breakdown,omitempty"`
-	DirPaths             map[uint8]string            `json:"dir_paths,omitempty"`       // StorageID → directory path
-	NamespaceNames       map[uint32]string           `json:"namespace_names,omitempty"` // NamespaceID → prefix string
+	TotalInlineBytes     int64                           `json:"total_inline_bytes"`
+	TotalMetadataEntries int64                           `json:"total_metadata_entries"`
+	TotalBytesMetadata   int64                           `json:"total_bytes_metadata"`     // Sum of ContentLength from metadata entries
+	UsageCounters        map[string]int64                `json:"usage_counters,omitempty"` // Pre-computed usage from u: prefix keys
+	StorageBreakdown     map[string]*StorageDirStats     `json:"storage_breakdown,omitempty"`
+	DirPaths             map[uint8]string                `json:"dir_paths,omitempty"`        // StorageID → directory path
+	NamespaceNames       map[uint32]string               `json:"namespace_names,omitempty"`  // NamespaceID → prefix string
+	NamespaceQuotas      map[string]*NamespaceQuotaStats `json:"namespace_quotas,omitempty"` // Namespace prefix → usage against quota (live cache only)
+}
+
+// NamespaceQuotaStats reports a namespace's usage against its quota
+// (Cache.NamespaceQuotas).  A zero quota means none.
+type NamespaceQuotaStats struct {
+	SoftQuota uint64 `json:"soft_quota,omitempty"`
+	HardQuota uint64 `json:"hard_quota,omitempty"`
+	Usage     int64  `json:"usage"`
 }
 
 // StorageDirStats holds per-storage-directory statistics.
@@ -123,18 +134,20 @@ type StorageDirStats struct {
 
 // DiskUsageResult contains the result of an expensive disk walk.
 type DiskUsageResult struct {
-	TotalBytesOnDisk int64                   `json:"total_bytes_on_disk"`
-	TotalFiles       int64                   `json:"total_files"`
-	Directories      map[string]*DirDiskStat `json:"directories,omitempty"`
-	Duration         string                  `json:"duration"` // How long the walk took
+	TotalBytesOnDisk    int64                   `json:"total_bytes_on_disk"`
+	TotalBytesAllocated int64                   `json:"total_bytes_allocated"` // Space actually allocated; less than on disk when blocks are compressed
+	TotalFiles          int64                   `json:"total_files"`
+	Directories         map[string]*DirDiskStat `json:"directories,omitempty"`
+	Duration            string                  `json:"duration"` // How long the walk took
 }
 
 // DirDiskStat holds per-directory disk usage from walking the filesystem.
 type DirDiskStat struct {
-	StorageID uint8  `json:"storage_id"`
-	Path      string `json:"path"`
-	BytesUsed int64  `json:"bytes_used"`
-	FileCount int64  `json:"file_count"`
+	StorageID      uint8  `json:"storage_id"`
+	Path           string `json:"path"`
+	BytesUsed      int64  `json:"bytes_used"`
+	BytesAllocated int64  `json:"bytes_allocated"` // Space actually allocated by the filesystem
+	FileCount      int64  `json:"file_count"`
 }
 
 // ConsistencyCheckResult contains the result of a consistency check run.
@@ -337,6 +350,10 @@ func (api *IntrospectAPIOpen) GetObjectDetails(instanceHash string) (*ObjectDeta
 		StorageID:     uint8(meta.StorageID),
 		LastValidated: meta.LastValidated,
 	}
+	if meta.Compression != CompressionNone {
+		details.Compression = meta.Compression.String()
+	}
+	details.Dedup = meta.Dedup
 
 	// Extract cache-control as string
 	cc := meta.GetCacheDirectives()
@@ -723,6 +740,7 @@ func (api *IntrospectAPIOpen) GetDiskUsage() (*DiskUsageResult, error) {
 			}
 			ds.FileCount++
 			ds.BytesUsed += info.Size()
+			ds.BytesAllocated += allocatedSize(info)
 			return nil
 		})
 		if err != nil {
@@ -731,6 +749,7 @@ func (api *IntrospectAPIOpen) GetDiskUsage() (*DiskUsageResult, error) {
 
 		result.Directories[dirKey] = ds
 		result.TotalBytesOnDisk += ds.BytesUsed
+		result.TotalBytesAllocated += ds.BytesAllocated
 		result.TotalFiles += ds.FileCount
 	}
 
@@ -241,10 +241,19 @@ func TestOpenCacheDBReadOnlyRefusesWrites(t *testing.T) {
 			return db.EnsureStoreMode(StoreModeCache)
 		},
 		"ReloadSalt": func() error { return db.ReloadSalt() },
+		"StartWarmupJob": func() error {
+			return db.StartWarmupJob(&WarmupJob{}, []PopularObject{{Path: "/nope"}})
+		},
+		"SaveWarmupJob":   func() error { return db.SaveWarmupJob(&WarmupJob{}) },
+		"FinishWarmupJob": func() error { return db.FinishWarmupJob(&WarmupJob{}) },
 		"EvictByLRU": func() error {
 			_, _, err := db.EvictByLRU(StorageIDInline, 1, 1, 0, nil)
 			return err
 		},
+		"EvictInOrder": func() error {
+			_, _, err := db.EvictInOrder(StorageIDInline, 1, []InstanceHash{"x"}, 1, 0, nil)
+			return err
+		},
 		// A batch holds an open BadgerDB write transaction, so each of these
 		// cancels the one it took -- the refusal is the assertion, not a reason
 		// to leak the transaction until Close.
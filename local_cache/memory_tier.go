@@ -0,0 +1,229 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"sync"
+	"sync/atomic"
+
+	ristretto "github.com/dgraph-io/ristretto/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+var (
+	memoryTierHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_memory_tier_hits_total",
+		Help: "Total number of blocks served from the in-memory tier of decrypted blocks",
+	})
+	memoryTierMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_memory_tier_misses_total",
+		Help: "Total number of blocks read from disk because they were not in the in-memory tier",
+	})
+	memoryTierServedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_memory_tier_served_bytes_total",
+		Help: "Total bytes served from the in-memory tier without reading or decrypting them from disk",
+	})
+)
+
+// MemoryTierStats reports the in-memory tier's size and effectiveness
+type MemoryTierStats struct {
+	CapacityBytes int64
+	Hits          uint64
+	Misses        uint64
+	BytesServed   uint64
+}
+
+// memoryTier holds decrypted blocks of the most frequently read objects, so
+// hot data is served without a disk read or AES-GCM decryption.
+//
+// Two levels of frequency filtering apply.  An object's blocks are only
+// offered to the tier once the object has been opened for reading
+// minAccesses times (tracked in a frequency sketch, as for cache admission),
+// which keeps one-off scans from churning it.  The block cache itself
+// (ristretto) then applies its own TinyLFU admission and eviction.
+//
+// A nil *memoryTier is a disabled tier; all methods are safe to call on it.
+type memoryTier struct {
+	blocks      *ristretto.Cache[uint64, []byte]
+	capacity    int64
+	minAccesses int
+
+	mu     sync.Mutex
+	sketch *frequencySketch // nil when every object is admitted
+
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	bytesServed atomic.Uint64
+}
+
+// memoryTierFromParams builds the memory tier from Cache.MemoryCacheSize
+// (falling back to LocalCache.MemoryCacheSize for the local cache module)
+// and Cache.MemoryCacheMinAccesses.  Returns nil if the tier is disabled.
+func memoryTierFromParams() (*memoryTier, error) {
+	sizeStr := param.Cache_MemoryCacheSize.GetString()
+	if sizeStr == "" || sizeStr == "0" {
+		sizeStr = param.LocalCache_MemoryCacheSize.GetString()
+	}
+	if sizeStr == "" || sizeStr == "0" {
+		return nil, nil
+	}
+	size, err := utils.ParseBytes(sizeStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse MemoryCacheSize value %q", sizeStr)
+	}
+	return newMemoryTier(int64(size), param.Cache_MemoryCacheMinAccesses.GetInt())
+}
+
+// newMemoryTier creates a tier holding up to size bytes of blocks, admitting
+// objects opened at least minAccesses times.  Returns nil if size is zero.
+func newMemoryTier(size int64, minAccesses int) (*memoryTier, error) {
+	if size <= 0 {
+		return nil, nil
+	}
+	// NumCounters should be ~10× the expected max number of entries.
+	numEntries := size / BlockDataSize
+	blocks, err := ristretto.NewCache(&ristretto.Config[uint64, []byte]{
+		NumCounters: max(numEntries*10, 1000),
+		MaxCost:     size,
+		BufferItems: 64,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create plaintext block cache")
+	}
+	mt := &memoryTier{blocks: blocks, capacity: size, minAccesses: minAccesses}
+	if minAccesses > 1 {
+		// Objects average many blocks; a sketch sized for a quarter as many
+		// objects as the tier has blocks is ample.
+		mt.sketch = newFrequencySketch(int(min(max(numEntries/4, 1024), 1<<20)))
+	}
+	return mt, nil
+}
+
+// recordAccess notes that an object was opened for reading
+func (mt *memoryTier) recordAccess(instanceHash InstanceHash) {
+	if mt == nil || mt.sketch == nil {
+		return
+	}
+	mt.mu.Lock()
+	mt.sketch.increment(string(instanceHash))
+	mt.mu.Unlock()
+}
+
+// admits reports whether the object is read often enough for its blocks to
+// be added to the tier.
+func (mt *memoryTier) admits(instanceHash InstanceHash) bool {
+	if mt == nil {
+		return false
+	}
+	if mt.sketch == nil {
+		return true
+	}
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	return mt.sketch.estimate(string(instanceHash)) >= mt.minAccesses
+}
+
+// get returns a block's plaintext, counting the lookup as a hit or miss
+func (mt *memoryTier) get(instanceHash InstanceHash, block uint32) ([]byte, bool) {
+	if mt == nil {
+		return nil, false
+	}
+	data, ok := mt.blocks.Get(ptCacheKey(instanceHash, block))
+	if ok {
+		mt.hits.Add(1)
+		memoryTierHits.Inc()
+	} else {
+		mt.misses.Add(1)
+		memoryTierMisses.Inc()
+	}
+	return data, ok
+}
+
+// served records bytes copied to a reader from the tier
+func (mt *memoryTier) served(n int) {
+	mt.bytesServed.Add(uint64(n))
+	memoryTierServedBytes.Add(float64(n))
+}
+
+// put adds a copy of a block's plaintext to the tier.  Callers check admits
+// first.
+func (mt *memoryTier) put(instanceHash InstanceHash, block uint32, data []byte) {
+	if mt == nil {
+		return
+	}
+	entry := make([]byte, len(data))
+	copy(entry, data)
+	mt.blocks.Set(ptCacheKey(instanceHash, block), entry, int64(BlockDataSize))
+}
+
+// readInto fills dst, which holds the content at file-local offset, from
+// the tier.  It returns false without counting any hits if any of the blocks
+// is missing, in which case the caller reads the range from disk.
+func (mt *memoryTier) readInto(instanceHash InstanceHash, globalBlockNum0 uint32, dst []byte, offset int64) bool {
+	if mt == nil {
+		return false
+	}
+	blocks := 0
+	for pos := 0; pos < len(dst); blocks++ {
+		block := ContentOffsetToBlock(offset + int64(pos))
+		cached, ok := mt.blocks.Get(ptCacheKey(instanceHash, globalBlockNum0+block))
+		if !ok {
+			return false
+		}
+		within := int(ContentOffsetWithinBlock(offset + int64(pos)))
+		if within >= len(cached) {
+			return false
+		}
+		pos += copy(dst[pos:], cached[within:])
+	}
+	mt.hits.Add(uint64(blocks))
+	memoryTierHits.Add(float64(blocks))
+	mt.served(len(dst))
+	return true
+}
+
+// GetStats returns the tier's statistics
+func (mt *memoryTier) GetStats() MemoryTierStats {
+	if mt == nil {
+		return MemoryTierStats{}
+	}
+	return MemoryTierStats{
+		CapacityBytes: mt.capacity,
+		Hits:          mt.hits.Load(),
+		Misses:        mt.misses.Load(),
+		BytesServed:   mt.bytesServed.Load(),
+	}
+}
+
+// Close releases the tier's memory
+func (mt *memoryTier) Close() {
+	if mt != nil {
+		mt.blocks.Close()
+	}
+}
+
+// GetMemoryTierStats returns the statistics of the in-memory block tier
+func (sm *StorageManager) GetMemoryTierStats() MemoryTierStats {
+	return sm.memTier.GetStats()
+}
@@ -0,0 +1,119 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_utils"
+)
+
+// readWholeObject reads an object in one call through a fresh ObjectReader,
+// which counts as one access for memory tier admission.
+func readWholeObject(t *testing.T, sm *StorageManager, hash InstanceHash) []byte {
+	t.Helper()
+	r, err := sm.NewObjectReader(hash)
+	require.NoError(t, err)
+	defer r.Close()
+	data := make([]byte, r.Size())
+	n, err := r.ReadAt(data, 0)
+	if err != io.EOF {
+		require.NoError(t, err)
+	}
+	require.Equal(t, len(data), n)
+	sm.memTier.blocks.Wait()
+	return data
+}
+
+func TestMemoryTierAdmitsRepeatedlyReadObjects(t *testing.T) {
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+	require.NoError(t, param.Cache_MemoryCacheSize.Set("4MB"))
+	require.NoError(t, param.Cache_MemoryCacheMinAccesses.Set(2))
+
+	_, sm := newAppendTestStorage(t, 1)
+	require.NotNil(t, sm.memTier)
+	data := compressibleTestData(10*BlockDataSize + 7)
+	hash := newCompressedTestObject(t, sm, data)
+
+	// The first read only counts the access; the second one is admitted
+	// and fills the tier.
+	assert.Equal(t, data, readWholeObject(t, sm, hash))
+	_, ok := sm.memTier.blocks.Get(ptCacheKey(hash, 0))
+	assert.False(t, ok)
+	assert.Equal(t, data, readWholeObject(t, sm, hash))
+
+	// Damage the file: the third read must not touch it
+	meta, err := sm.GetMetadata(hash)
+	require.NoError(t, err)
+	f, err := os.OpenFile(sm.getObjectPathForDir(meta.StorageID, hash), os.O_RDWR, 0)
+	require.NoError(t, err)
+	_, err = f.WriteAt(make([]byte, 2*BlockTotalSize), 0)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, data, readWholeObject(t, sm, hash))
+
+	stats := sm.GetMemoryTierStats()
+	assert.Equal(t, int64(4*1024*1024), stats.CapacityBytes)
+	assert.Equal(t, uint64(len(data)), stats.BytesServed)
+	assert.Equal(t, uint64(11), stats.Hits)
+	assert.Positive(t, stats.Misses)
+}
+
+func TestMemoryTierCompressedObjects(t *testing.T) {
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+	require.NoError(t, param.Cache_MemoryCacheSize.Set("4MB"))
+	require.NoError(t, param.Cache_MemoryCacheMinAccesses.Set(1))
+
+	_, sm := newAppendTestStorage(t, 1)
+	sm.SetCompression(CompressionConfig{Algorithm: CompressionZstd}, nil)
+	data := compressibleTestData(3*compressionUnitSize + 10)
+	hash := newCompressedTestObject(t, sm, data)
+
+	assert.Equal(t, data, readWholeObject(t, sm, hash))
+	assert.Zero(t, sm.GetMemoryTierStats().BytesServed)
+	assert.Equal(t, data, readWholeObject(t, sm, hash))
+	assert.Equal(t, uint64(len(data)), sm.GetMemoryTierStats().BytesServed)
+}
+
+func TestMemoryTierDisabled(t *testing.T) {
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+
+	_, sm := newAppendTestStorage(t, 1)
+	assert.Nil(t, sm.memTier)
+	data := compressibleTestData(3 * BlockDataSize)
+	hash := newCompressedTestObject(t, sm, data)
+
+	r, err := sm.NewObjectReader(hash)
+	require.NoError(t, err)
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.Equal(t, MemoryTierStats{}, sm.GetMemoryTierStats())
+}
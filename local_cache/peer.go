@@ -0,0 +1,242 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/param"
+)
+
+// peerCompleteHeader is set on a HEAD only-if-cached response when the cache
+// holds every block of the object, i.e. when a peer may fetch any range of
+// it without the answering cache going to the origin.
+const peerCompleteHeader = "X-Pelican-Cache-Complete"
+
+const (
+	// peerSourceTTL is how long a block fetcher keeps using the peer that was
+	// found for an object instance before falling back to the origin.
+	peerSourceTTL = 10 * time.Minute
+	// peerSourceCapacity bounds the number of remembered peer sources
+	peerSourceCapacity = 10000
+)
+
+var (
+	peerQueries = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_peer_queries_total",
+		Help: "Total number of cache misses for which peer caches were asked whether they hold the object",
+	})
+	peerHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_peer_hits_total",
+		Help: "Total number of cache misses fetched from a peer cache instead of the origin",
+	})
+)
+
+// PeerConfig configures the sibling caches consulted on a cache miss
+type PeerConfig struct {
+	// Caches are the base URLs of the peer caches' web interfaces
+	Caches []string
+	// QueryTimeout bounds how long the peers are given to answer
+	QueryTimeout time.Duration
+}
+
+// PeerStats reports how often peer caches were consulted and used
+type PeerStats struct {
+	Peers   int
+	Queries uint64
+	Hits    uint64
+}
+
+// peerConfigFromParams builds the peer configuration from the Cache.*
+// parameters.
+func peerConfigFromParams() PeerConfig {
+	return PeerConfig{
+		Caches:       param.Cache_PeerCaches.GetStringSlice(),
+		QueryTimeout: param.Cache_PeerQueryTimeout.GetDuration(),
+	}
+}
+
+// peerSet finds sibling caches that already hold a complete copy of an
+// object, so a miss can be filled from the site rather than from a distant
+// origin.
+type peerSet struct {
+	peers      []*url.URL
+	timeout    time.Duration
+	httpClient *http.Client
+
+	// sources remembers the peer chosen for each object instance, so the
+	// block fetchers that later fill in ranges use the same peer.
+	sources *ttlcache.Cache[InstanceHash, *url.URL]
+
+	queries atomic.Uint64
+	hits    atomic.Uint64
+}
+
+// newPeerSet parses the configured peers.  Entries for this cache itself
+// (matching Server.ExternalWebUrl) are dropped so a site can give all of its
+// caches the same list.  Only https peers are accepted, since every query
+// forwards the client's token.  Returns nil if no peers remain.
+func newPeerSet(cfg PeerConfig) (*peerSet, error) {
+	var self string
+	if selfURL, err := url.Parse(param.Server_ExternalWebUrl.GetString()); err == nil {
+		self = selfURL.Host
+	}
+
+	ps := &peerSet{timeout: cfg.QueryTimeout}
+	for _, peerStr := range cfg.Caches {
+		peerURL, err := url.Parse(strings.TrimSpace(peerStr))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid peer cache URL %q", peerStr)
+		}
+		if peerURL.Scheme != "https" || peerURL.Host == "" {
+			return nil, errors.Errorf("invalid peer cache URL %q: must be an absolute https URL, since clients' tokens are forwarded to peers", peerStr)
+		}
+		if self != "" && peerURL.Host == self {
+			log.Debugln("Ignoring this cache's own URL in the peer cache list:", peerStr)
+			continue
+		}
+		peerURL.Path = strings.TrimSuffix(peerURL.Path, "/")
+		ps.peers = append(ps.peers, peerURL)
+	}
+	if len(ps.peers) == 0 {
+		return nil, nil
+	}
+	if ps.timeout <= 0 {
+		ps.timeout = 2 * time.Second
+	}
+	ps.httpClient = &http.Client{
+		Transport: config.GetTransport(),
+		// A peer answering with a redirect is not serving from its cache
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	ps.sources = ttlcache.New[InstanceHash, *url.URL](
+		ttlcache.WithTTL[InstanceHash, *url.URL](peerSourceTTL),
+		ttlcache.WithCapacity[InstanceHash, *url.URL](peerSourceCapacity),
+		ttlcache.WithDisableTouchOnHit[InstanceHash, *url.URL](),
+	)
+	return ps, nil
+}
+
+// objectURL returns the URL of an object in a peer's cache data API
+func (ps *peerSet) objectURL(peer *url.URL, pelicanURL *url.URL) *url.URL {
+	return peer.JoinPath("api/v1.0/cache/data", pelicanURL.Host, pelicanURL.Path)
+}
+
+// find asks the peers, in parallel, whether they hold a complete copy of the
+// object with the given ETag, forwarding the client's token so each peer can
+// apply its own authorization.  It returns the object's URL on the first
+// peer to say yes, or nil if none does within the query timeout.
+func (ps *peerSet) find(ctx context.Context, pelicanURL, token, etag string) *url.URL {
+	if ps == nil || etag == "" {
+		return nil
+	}
+	objURL, err := url.Parse(pelicanURL)
+	if err != nil {
+		return nil
+	}
+	ps.queries.Add(1)
+	peerQueries.Inc()
+
+	ctx, cancel := context.WithTimeout(ctx, ps.timeout)
+	defer cancel()
+
+	found := make(chan *url.URL, len(ps.peers))
+	for _, peer := range ps.peers {
+		go func(candidate *url.URL) {
+			if ps.holds(ctx, candidate, token, etag) {
+				found <- candidate
+			} else {
+				found <- nil
+			}
+		}(ps.objectURL(peer, objURL))
+	}
+	for range ps.peers {
+		if candidate := <-found; candidate != nil {
+			ps.hits.Add(1)
+			peerHits.Inc()
+			return candidate
+		}
+	}
+	return nil
+}
+
+// holds reports whether the peer answers a HEAD only-if-cached request for
+// objectURL with a complete copy whose ETag matches.
+func (ps *peerSet) holds(ctx context.Context, objectURL *url.URL, token, etag string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, objectURL.String(), nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Cache-Control", "only-if-cached")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := ps.httpClient.Do(req)
+	if err != nil {
+		log.Debugf("Peer cache query to %s failed: %v", objectURL.Host, err)
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK &&
+		resp.Header.Get(peerCompleteHeader) == "true" &&
+		resp.Header.Get("ETag") == etag
+}
+
+// remember records the peer chosen for an object instance
+func (ps *peerSet) remember(instanceHash InstanceHash, source *url.URL) {
+	if ps == nil || source == nil {
+		return
+	}
+	ps.sources.Set(instanceHash, source, ttlcache.DefaultTTL)
+}
+
+// sourceFor returns the peer remembered for an object instance, if any
+func (ps *peerSet) sourceFor(instanceHash InstanceHash) *url.URL {
+	if ps == nil {
+		return nil
+	}
+	if item := ps.sources.Get(instanceHash); item != nil {
+		return item.Value()
+	}
+	return nil
+}
+
+// GetStats returns the peer statistics
+func (ps *peerSet) GetStats() PeerStats {
+	if ps == nil {
+		return PeerStats{}
+	}
+	return PeerStats{
+		Peers:   len(ps.peers),
+		Queries: ps.queries.Load(),
+		Hits:    ps.hits.Load(),
+	}
+}
@@ -0,0 +1,143 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/server_utils"
+)
+
+// newTestPeer starts a peer cache that answers HEAD only-if-cached requests
+// for /test/obj the way serveObject does.
+func newTestPeer(t *testing.T, status int, etag string, complete bool, delay time.Duration) (*httptest.Server, *atomic.Value) {
+	var lastToken atomic.Value
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastToken.Store(r.Header.Get("Authorization"))
+		if r.Method != http.MethodHead || r.Header.Get("Cache-Control") != "only-if-cached" ||
+			r.URL.Path != "/api/v1.0/cache/data/fed.example.org/test/obj" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		time.Sleep(delay)
+		if status == http.StatusOK {
+			w.Header().Set("ETag", etag)
+			if complete {
+				w.Header().Set(peerCompleteHeader, "true")
+			}
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &lastToken
+}
+
+func TestNewPeerSet(t *testing.T) {
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+	require.NoError(t, param.Server_ExternalWebUrl.Set("https://cache1.example.org:8443"))
+
+	ps, err := newPeerSet(PeerConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, ps)
+
+	// This cache's own entry is dropped from a shared list
+	ps, err = newPeerSet(PeerConfig{Caches: []string{"https://cache1.example.org:8443", "https://cache2.example.org:8443/"}})
+	require.NoError(t, err)
+	require.NotNil(t, ps)
+	require.Len(t, ps.peers, 1)
+	assert.Equal(t, "https://cache2.example.org:8443", ps.peers[0].String())
+	assert.Equal(t, 2*time.Second, ps.timeout)
+
+	ps, err = newPeerSet(PeerConfig{Caches: []string{"https://cache1.example.org:8443"}})
+	require.NoError(t, err)
+	assert.Nil(t, ps)
+
+	_, err = newPeerSet(PeerConfig{Caches: []string{"cache2.example.org"}})
+	assert.ErrorContains(t, err, "invalid peer cache URL")
+
+	// Tokens are forwarded to peers, so they're never sent in the clear
+	_, err = newPeerSet(PeerConfig{Caches: []string{"http://cache2.example.org:8000"}})
+	assert.ErrorContains(t, err, "must be an absolute https URL")
+
+	require.NoError(t, param.Cache_PeerCaches.Set([]string{"https://cache3.example.org"}))
+	require.NoError(t, param.Cache_PeerQueryTimeout.Set(500*time.Millisecond))
+	assert.Equal(t, PeerConfig{Caches: []string{"https://cache3.example.org"}, QueryTimeout: 500 * time.Millisecond}, peerConfigFromParams())
+}
+
+func TestPeerSetFind(t *testing.T) {
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+
+	const pelicanURL = "pelican://fed.example.org/test/obj"
+	miss, _ := newTestPeer(t, http.StatusGatewayTimeout, "", false, 0)
+	stale, _ := newTestPeer(t, http.StatusOK, `"old"`, true, 0)
+	partial, _ := newTestPeer(t, http.StatusOK, `"v2"`, false, 0)
+	good, goodToken := newTestPeer(t, http.StatusOK, `"v2"`, true, 0)
+
+	ps, err := newPeerSet(PeerConfig{Caches: []string{miss.URL, stale.URL, partial.URL, good.URL}})
+	require.NoError(t, err)
+	// Every httptest TLS server shares one certificate, so any of their clients trusts them all
+	ps.httpClient.Transport = good.Client().Transport
+
+	source := ps.find(context.Background(), pelicanURL, "sometoken", `"v2"`)
+	require.NotNil(t, source)
+	assert.Equal(t, good.URL+"/api/v1.0/cache/data/fed.example.org/test/obj", source.String())
+	assert.Equal(t, "Bearer sometoken", goodToken.Load())
+
+	// No peer holds this version
+	assert.Nil(t, ps.find(context.Background(), pelicanURL, "", `"v3"`))
+	// Without the origin's ETag, a peer's copy can't be trusted
+	assert.Nil(t, ps.find(context.Background(), pelicanURL, "", ""))
+
+	assert.Equal(t, PeerStats{Peers: 4, Queries: 2, Hits: 1}, ps.GetStats())
+
+	ps.remember("instance", source)
+	assert.Equal(t, source, ps.sourceFor("instance"))
+	assert.Nil(t, ps.sourceFor("other"))
+
+	// A nil set (no peers configured) is inert
+	var none *peerSet
+	assert.Nil(t, none.find(context.Background(), pelicanURL, "", `"v2"`))
+	none.remember("instance", source)
+	assert.Nil(t, none.sourceFor("instance"))
+}
+
+func TestPeerSetFindTimeout(t *testing.T) {
+	server_utils.ResetTestState()
+	t.Cleanup(server_utils.ResetTestState)
+
+	slow, _ := newTestPeer(t, http.StatusOK, `"v1"`, true, time.Second)
+	ps, err := newPeerSet(PeerConfig{Caches: []string{slow.URL}, QueryTimeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+	ps.httpClient.Transport = slow.Client().Transport
+
+	start := time.Now()
+	assert.Nil(t, ps.find(context.Background(), "pelican://fed.example.org/test/obj", "", `"v1"`))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
@@ -41,6 +41,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
@@ -174,6 +175,12 @@ type PersistentCache struct {
 	storage     *StorageManager
 	eviction    *EvictionManager
 	consistency *ConsistencyChecker
+	admission   *admissionController
+	peers       *peerSet // nil when no peer caches are configured
+
+	// readAheadBlocks is the largest read-ahead window of a RangeReader
+	// streaming a partially cached object; zero disables read-ahead.
+	readAheadBlocks uint32
 
 	// Transfer engine for creating per-request clients
 	te *client.TransferEngine
@@ -226,6 +233,9 @@ type PersistentCache struct {
 
 	// Prestage worker pool manager (created lazily on first API call).
 	prestageManager *PrestageManager
+
+	// Runs cache warmup jobs; see warmup.go
+	warmup *warmupManager
 }
 
 // persistentDownload tracks an active download operation
@@ -258,6 +268,15 @@ type persistentDownload struct {
 	// the path is served the index instead.
 	forceNoStore bool
 
+	// bypassReason, when set, is why the admission controller declined to
+	// store this object; it is then streamed through like a no-store response.
+	bypassReason string
+
+	// peer, when set, is the object's URL on a peer cache that holds a
+	// complete copy of the origin's current version; it is fetched from
+	// there before the origin.
+	peer *url.URL
+
 	// Background completion tracking (for non-blocking downloads)
 	completionDone chan struct{} // Closed when background finalization completes
 	completionErr  atomic.Value  // Stores error from background finalization (type error)
@@ -407,6 +426,38 @@ type PersistentCacheConfig struct {
 
 	DefaultFederation string
 
+	// EvictionPolicy and NamespaceEvictionPolicies select the eviction
+	// policies (see EvictionPolicy).  When unset, Cache.EvictionPolicy and
+	// Cache.NamespaceEvictionPolicies are used.
+	EvictionPolicy            string
+	NamespaceEvictionPolicies map[string]string
+
+	// NamespaceQuotas maps a namespace prefix to its quota.  When nil,
+	// Cache.NamespaceQuotas is used.
+	NamespaceQuotas map[string]NamespaceQuota
+
+	// Admission controls which objects fetched on a miss are written to
+	// disk.  When nil, the Cache.EnableAdmissionFilter and
+	// Cache.MaxObjectSize families of parameters are used.
+	Admission *AdmissionConfig
+
+	// Peers lists sibling caches to fetch from before the origin.  When nil,
+	// Cache.PeerCaches and Cache.PeerQueryTimeout are used.
+	Peers *PeerConfig
+
+	// Compression selects the block compression for newly stored objects.
+	// When nil, the Cache.BlockCompression family of parameters is used.
+	Compression *CompressionConfig
+
+	// Dedup selects which newly stored objects have their blocks
+	// deduplicated.  When nil, Cache.BlockDedup and
+	// Cache.NamespaceBlockDedup are used.
+	Dedup *DedupConfig
+
+	// Warmup configures cache warmup jobs.  When nil, the Cache.Warmup*
+	// parameters are used.
+	Warmup *WarmupConfig
+
 	// DeferConfig delays the initial director namespace fetch until
 	// Config() is called explicitly.  The server launcher sets this to
 	// true because the director may not be reachable when the cache is
@@ -601,14 +652,81 @@ func NewPersistentCache(ctx context.Context, egrp *errgroup.Group, cfg Persisten
 			LowWaterPercentage:  lwp,
 			HighWaterBytes:      defaultHWBytes,
 			LowWaterBytes:       defaultLWBytes,
+			Tier:                sd.Tier,
 		}
 	}
 
 	// Initialize eviction manager
+	evictionPolicy := cfg.EvictionPolicy
+	if evictionPolicy == "" {
+		evictionPolicy = param.Cache_EvictionPolicy.GetString()
+	}
+	nsEvictionPolicies := cfg.NamespaceEvictionPolicies
+	if nsEvictionPolicies == nil && param.Cache_NamespaceEvictionPolicies.IsSet() {
+		if err := param.Cache_NamespaceEvictionPolicies.Unmarshal(&nsEvictionPolicies); err != nil {
+			return failInit(errors.Wrapf(err, "failed to parse %s", param.Cache_NamespaceEvictionPolicies.GetName()))
+		}
+	}
+	nsQuotas := cfg.NamespaceQuotas
+	if nsQuotas == nil {
+		if nsQuotas, err = namespaceQuotasFromParams(); err != nil {
+			return failInit(err)
+		}
+	}
 	eviction := NewEvictionManager(db, storage, EvictionConfig{
-		DirConfigs: evictionDirCfgs,
+		DirConfigs:        evictionDirCfgs,
+		Policy:            evictionPolicy,
+		NamespacePolicies: nsEvictionPolicies,
+		FrequencyHalfLife: param.Cache_EvictionFrequencyHalfLife.GetDuration(),
+		NamespaceQuotas:   nsQuotas,
 	})
 
+	var admissionCfg AdmissionConfig
+	if cfg.Admission != nil {
+		admissionCfg = *cfg.Admission
+	} else if admissionCfg, err = admissionConfigFromParams(); err != nil {
+		return failInit(err)
+	}
+
+	peerCfg := peerConfigFromParams()
+	if cfg.Peers != nil {
+		peerCfg = *cfg.Peers
+	}
+	peers, err := newPeerSet(peerCfg)
+	if err != nil {
+		return failInit(err)
+	}
+
+	var readAheadBlocks uint32
+	if sizeStr := param.Cache_ReadAheadSize.GetString(); sizeStr != "" && sizeStr != "0" {
+		size, err := utils.ParseBytes(sizeStr)
+		if err != nil {
+			return failInit(errors.Wrapf(err, "failed to parse %s", param.Cache_ReadAheadSize.GetName()))
+		}
+		readAheadBlocks = uint32(min(size/BlockDataSize, math.MaxUint32))
+	}
+
+	var compressionCfg CompressionConfig
+	if cfg.Compression != nil {
+		compressionCfg = *cfg.Compression
+	} else if compressionCfg, err = compressionConfigFromParams(); err != nil {
+		return failInit(err)
+	}
+
+	var dedupCfg DedupConfig
+	if cfg.Dedup != nil {
+		dedupCfg = *cfg.Dedup
+	} else if dedupCfg, err = dedupConfigFromParams(); err != nil {
+		return failInit(err)
+	}
+
+	var warmupCfg WarmupConfig
+	if cfg.Warmup != nil {
+		warmupCfg = *cfg.Warmup
+	} else if warmupCfg, err = warmupConfigFromParams(); err != nil {
+		return failInit(err)
+	}
+
 	// Wire chunk allocation to use the eviction manager's weighted
 	// directory selection (proportional to free space per directory).
 	// Safe: this runs during single-threaded init, before any downloads.
@@ -697,6 +815,7 @@ func NewPersistentCache(ctx context.Context, egrp *errgroup.Group, cfg Persisten
 	}
 	pc.fedTokenReady = make(chan struct{})
 	pc.prestageManager = NewPrestageManager(pc)
+	pc.warmup = newWarmupManager(pc, warmupCfg)
 
 	// Restore persisted namespace mappings so that LRU keys and usage
 	// counters from prior runs remain valid.
@@ -708,6 +827,12 @@ func NewPersistentCache(ctx context.Context, egrp *errgroup.Group, cfg Persisten
 		pc.nextNamespaceID.Store(uint32(maxID))
 		log.Infof("Restored %d namespace mappings (max ID %d)", len(nsMap), maxID)
 	}
+	eviction.SetNamespaceResolver(pc.getNamespacePrefix)
+	pc.admission = newAdmissionController(admissionCfg, pc.getNamespacePrefix)
+	pc.peers = peers
+	pc.readAheadBlocks = readAheadBlocks
+	storage.SetCompression(compressionCfg, pc.getNamespacePrefix)
+	storage.SetDedup(dedupCfg, pc.getNamespacePrefix)
 
 	// Start background tasks
 	db.StartGC(ctx, egrp)
@@ -865,6 +990,9 @@ func (pc *PersistentCache) Config(egrp *errgroup.Group) error {
 	}
 
 	egrp.Go(pc.periodicUpdateConfig)
+
+	// Warming needs the authorization configuration loaded above
+	pc.warmup.resume()
 	return nil
 }
 
@@ -886,6 +1014,11 @@ func (pc *PersistentCache) Close() error {
 	}
 	defer close(pc.closeDone)
 
+	// 0. Interrupt any warmup job; it resumes at the next startup.
+	if pc.warmup != nil {
+		pc.warmup.stop()
+	}
+
 	// 1. Cancel all in-flight transfers.  This causes transfer workers to
 	//    produce error results, which flow back through the engine to each
 	//    completeDownload goroutine.
@@ -1024,6 +1157,7 @@ func (pc *PersistentCache) resolveObject(
 	}
 
 	var dl *persistentDownload
+	hit := true
 
 	// Treat metadata with ContentLength < 0 the same as a cache miss.
 	// An in-progress chunked-encoding download stores metadata early
@@ -1032,6 +1166,7 @@ func (pc *PersistentCache) resolveObject(
 	// skip the download path, and fail with "invalid range" because the
 	// content length is negative.
 	if meta == nil || meta.ContentLength < 0 {
+		hit = false
 		if meta == nil && tryRangeInit {
 			instanceHash, meta, err = pc.initObjectFromStat(ctx, pelicanURL, objectHash, namespaceID, token)
 			if err != nil {
@@ -1093,7 +1228,7 @@ func (pc *PersistentCache) resolveObject(
 		}
 	}
 
-	if err := pc.eviction.RecordAccess(instanceHash); err != nil {
+	if err := pc.eviction.RecordAccess(instanceHash, meta, hit); err != nil {
 		// A common cause is a Badger OCC conflict with a concurrent write to
 		// the same object's metadata key (e.g. the background download's own
 		// onComplete callback). When it happens on the first access to a
@@ -1187,7 +1322,7 @@ func (pc *PersistentCache) newFetchingRangeReader(
 				var bf *BlockFetcherV2
 				bf, lazyErr = NewBlockFetcherV2(
 					pc.storage, instanceHash, pelicanURL, token, fedTP, pc.te,
-					BlockFetcherV2Config{PrefetchSem: pc.prefetchSem},
+					BlockFetcherV2Config{PrefetchSem: pc.prefetchSem, Peer: pc.peers.sourceFor(instanceHash)},
 				)
 				if bf != nil {
 					lazyBf = bf
@@ -1229,6 +1364,7 @@ func (pc *PersistentCache) newFetchingRangeReader(
 		closeLazy()
 		return nil, err
 	}
+	rr.enableReadAhead(pc.readAheadBlocks)
 
 	// If a download is backing this reader, expose its terminal state so
 	// the serving path can fail the response with an X-Transfer-Status
@@ -1881,7 +2017,24 @@ func (pc *PersistentCache) downloadObject(ctx context.Context, pelicanURL string
 	// Deliberately after the mutex is released: this is a round trip to the
 	// origin, and holding the download registry across it would stall every
 	// other cache miss in the process behind one stat.
-	dl.forceNoStore = pc.sourceIsCollection(ctx, pelicanURL, token)
+	isCollection, sourceETag := pc.statSource(ctx, pelicanURL, token)
+	dl.forceNoStore = isCollection
+
+	// An object that hasn't been requested often enough is streamed through
+	// rather than stored.  If any version of it is already cached (e.g. this
+	// is a revalidation), it was admitted before and stays admitted.
+	if !dl.forceNoStore && pc.admission.filtering() {
+		if _, cached, err := pc.db.GetLatestETag(objectHash); err == nil && !cached && !pc.admission.admitFrequency(objectHash) {
+			dl.bypassReason = admissionBypassInfrequent
+		}
+	}
+
+	// A sibling cache may already hold this version; fetching from it spares
+	// the trip to the origin.  Only objects we are going to store are worth
+	// the extra round trip to the peers.
+	if pc.peers != nil && !dl.forceNoStore && dl.bypassReason == "" {
+		dl.peer = pc.peers.find(ctx, pelicanURL, token, sourceETag)
+	}
 
 	// Perform download (this will set dl.instanceHash and dl.etag)
 	err := pc.performDownload(ctx, dl, token)
@@ -2000,6 +2153,10 @@ func (pc *PersistentCache) performDownload(ctx context.Context, dl *persistentDo
 	if fedTP != nil {
 		transferOpts = append(transferOpts, client.WithFedToken(fedTP))
 	}
+	if dl.peer != nil {
+		// The trailing "+" keeps the director's servers as a fallback
+		transferOpts = append(transferOpts, client.WithCaches(dl.peer, &url.URL{Path: "+"}))
+	}
 	// Propagate the client's request ID (X-Pelican-JobId) so the origin
 	// can correlate cache-miss fetches with the original client request.
 	if reqId, ok := client.RequestIdFromContext(ctx); ok {
@@ -2070,12 +2227,19 @@ func (pc *PersistentCache) performDownload(ctx context.Context, dl *persistentDo
 		dl.lastModified = metadata.LastModified
 		dl.cacheControl = metadata.CacheControl
 		dl.instanceHash = pc.db.InstanceHash(dl.etag, dl.objectHash)
+		pc.peers.remember(dl.instanceHash, dl.peer)
 
 		// Parse Cache-Control directives to decide whether to persist
 		ccDirectives := ParseCacheControl(dl.cacheControl)
+		if dl.bypassReason == "" && !pc.admission.admitSize(dl.namespaceID, metadata.ObjectSize) {
+			dl.bypassReason = admissionBypassTooLarge
+		}
+		if dl.bypassReason == "" && ccDirectives.ShouldStore() && !dl.forceNoStore && !pc.eviction.AdmitToQuota(dl.namespaceID, metadata.ObjectSize) {
+			dl.bypassReason = admissionBypassOverQuota
+		}
 
 		// Check if object with this ETag already exists (only relevant for storable responses)
-		if ccDirectives.ShouldStore() && !dl.forceNoStore {
+		if ccDirectives.ShouldStore() && !dl.forceNoStore && dl.bypassReason == "" {
 			existingMeta, err := pc.storage.GetMetadata(dl.instanceHash)
 			if err != nil {
 				log.Warnf("Failed to check existing metadata: %v", err)
@@ -2097,10 +2261,13 @@ func (pc *PersistentCache) performDownload(ctx context.Context, dl *persistentDo
 			// Origin says not to store — stream directly to the caller via
 			// an io.Pipe instead of buffering the entire response in memory
 			// (which could OOM on large objects).
-			if dl.forceNoStore {
+			switch {
+			case dl.forceNoStore:
 				log.Debugln("performDownload: source is a collection — serving it through without persisting")
-			} else {
+			case !ccDirectives.ShouldStore():
 				log.Debugf("performDownload: Origin sent Cache-Control %q — will not persist", dl.cacheControl)
+			default:
+				log.Debugf("performDownload: Object not admitted to the cache (%s) — serving it through without persisting", dl.bypassReason)
 			}
 
 			pr, pw := io.Pipe()
@@ -2118,6 +2285,9 @@ func (pc *PersistentCache) performDownload(ctx context.Context, dl *persistentDo
 			} else {
 				noStoreReader = pr
 			}
+			if dl.bypassReason != "" && ccDirectives.ShouldStore() && !dl.forceNoStore {
+				noStoreReader = pc.admission.bypass(dl.bypassReason, noStoreReader)
+			}
 
 			dl.noStoreReader = noStoreReader
 			dl.noStoreMeta = &CacheMetadata{
@@ -2296,7 +2466,7 @@ func (pc *PersistentCache) performDownload(ctx context.Context, dl *persistentDo
 	// fetches for blocks ahead of the sequential position.
 	fetcher, fetcherErr := NewBlockFetcherV2(
 		pc.storage, dl.instanceHash, dl.sourceURL, userToken, fedTP, pc.te,
-		BlockFetcherV2Config{PrefetchSem: pc.prefetchSem},
+		BlockFetcherV2Config{PrefetchSem: pc.prefetchSem, Peer: dl.peer},
 	)
 	if fetcherErr != nil {
 		return errors.Wrap(fetcherErr, "failed to create block fetcher for handoff")
@@ -2834,6 +3004,18 @@ func (pc *PersistentCache) getNamespaceID(objectPath string) NamespaceID {
 	return id
 }
 
+// getNamespacePrefix is the reverse of getNamespaceID
+func (pc *PersistentCache) getNamespacePrefix(id NamespaceID) (string, bool) {
+	pc.namespaceMapMu.RLock()
+	defer pc.namespaceMapMu.RUnlock()
+	for prefix, nsID := range pc.namespaceMap {
+		if nsID == id {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
 // extractNamespacePrefix extracts the namespace prefix from a path
 func extractNamespacePrefix(objectPath string) string {
 	// Parse URL if present
@@ -2926,30 +3108,53 @@ func (pc *PersistentCache) GetStats() PersistentCacheStats {
 		nsUsage[key] = v
 	}
 
+	// Key the eviction stats by namespace prefix, which is what operators configure policies by
+	nsEviction := make(map[string]NamespaceEvictionStats, len(evictStats.NamespaceStats))
+	for id, v := range evictStats.NamespaceStats {
+		prefix, ok := pc.getNamespacePrefix(id)
+		if !ok {
+			prefix = fmt.Sprintf("ns%d", id)
+		}
+		nsEviction[prefix] = v
+	}
+
 	return PersistentCacheStats{
-		TotalUsage:       evictStats.TotalUsage,
-		DirStats:         evictStats.DirStats,
-		NamespaceUsage:   nsUsage,
-		ConsistencyStats: consistStats,
+		TotalUsage:        evictStats.TotalUsage,
+		DirStats:          evictStats.DirStats,
+		NamespaceUsage:    nsUsage,
+		NamespaceEviction: nsEviction,
+		ConsistencyStats:  consistStats,
+		AdmissionStats:    pc.admission.GetStats(),
+		PeerStats:         pc.peers.GetStats(),
+		CompressionStats:  pc.storage.GetCompressionStats(),
+		DedupStats:        pc.storage.GetDedupStats(),
+		MemoryTierStats:   pc.storage.GetMemoryTierStats(),
 	}
 }
 
 // PersistentCacheStats holds cache statistics
 type PersistentCacheStats struct {
-	TotalUsage       uint64
-	DirStats         map[StorageID]DirEvictionStats
-	NamespaceUsage   map[string]int64
-	ConsistencyStats ConsistencyStats
-}
-
-// sourceIsCollection reports whether the object being fetched is a collection.
+	TotalUsage        uint64
+	DirStats          map[StorageID]DirEvictionStats
+	NamespaceUsage    map[string]int64
+	NamespaceEviction map[string]NamespaceEvictionStats
+	ConsistencyStats  ConsistencyStats
+	AdmissionStats    AdmissionStats
+	PeerStats         PeerStats
+	CompressionStats  CompressionStats
+	DedupStats        DedupStats
+	MemoryTierStats   MemoryTierStats
+}
+
+// statSource reports whether the object being fetched is a collection, along
+// with the ETag of the origin's current version ("" if unknown).
 //
 // A false answer covers both "it is an object" and "the origin would not say",
 // which is the right default here: the consequence of being wrong is that a
 // response gets cached that should not have been, and refusing to serve
 // anything an origin declined to describe would be a far larger blast radius
 // than the defect this guards against.
-func (pc *PersistentCache) sourceIsCollection(ctx context.Context, pelicanURL, token string) bool {
+func (pc *PersistentCache) statSource(ctx context.Context, pelicanURL, token string) (isCollection bool, etag string) {
 	// pelicanURL is already a complete pelican:// URL (normalizePath returns
 	// one), unlike the bare object paths the other stat sites are handed.
 	opts := []client.TransferOption{
@@ -2962,7 +3167,7 @@ func (pc *PersistentCache) sourceIsCollection(ctx context.Context, pelicanURL, t
 	statInfo, err := pc.te.Stat(ctx, pelicanURL, opts...)
 	if err != nil {
 		log.Debugln("Could not determine whether", pelicanURL, "is a collection:", err)
-		return false
+		return false, ""
 	}
-	return statInfo.IsCollection
+	return statInfo.IsCollection, statInfo.ETag
 }
@@ -498,6 +498,17 @@ func (pc *PersistentCache) serveObject(w http.ResponseWriter, r *http.Request) {
 			}
 			w.Header().Set("Content-Length", strconv.FormatUint(size, 10))
 			w.Header().Set("Accept-Ranges", "bytes")
+			// Peer caches probe with only-if-cached HEADs before fetching
+			// from us; they need the ETag to match it against the origin's
+			// and to know whether every block is present.
+			if meta, metaErr := pc.GetMetadata(objectPath, bearerToken); metaErr == nil && meta != nil {
+				if meta.ETag != "" {
+					w.Header().Set("ETag", meta.ETag)
+				}
+				if !meta.Completed.IsZero() {
+					w.Header().Set(peerCompleteHeader, "true")
+				}
+			}
 			w.WriteHeader(http.StatusOK)
 			return
 		}
@@ -1642,6 +1653,17 @@ func (pc *PersistentCache) RegisterCacheHandlers(engine *gin.Engine, directorEna
 	adminPurge.POST("/purge_first", func(c *gin.Context) { pc.purgeFirstCmd(c) })
 	adminPurge.POST("/purge_to_target", func(c *gin.Context) { pc.purgeToTargetCmd(c) })
 
+	// Cache warmup (see warmup.go).  The export of popular objects does
+	// its own authorization, since federation servers as well as
+	// administrators may ask for it.
+	if param.Cache_EnableWarmupExport.GetBool() {
+		engine.GET(warmupExportPath, pc.popularHandler)
+	}
+	adminWarmup := engine.Group("/api/v1.0/cache/warmup", web_ui.AuthHandler, web_ui.AdminAuthHandler)
+	adminWarmup.GET("", pc.warmupStatusHandler)
+	adminWarmup.POST("", pc.warmupStartHandler)
+	adminWarmup.DELETE("", pc.warmupCancelHandler)
+
 	// Prestage and eviction API — compatible with the xrdhttp-pelican
 	// C++ plugin.
 	engine.GET("/pelican/api/v1.0/prestage", func(c *gin.Context) {
@@ -2128,10 +2150,34 @@ func (pc *PersistentCache) GetCacheStats() (*CacheStats, error) {
 			stats.NamespaceNames[uint32(id)] = prefix
 		}
 	}
+	stats.NamespaceQuotas = pc.namespaceQuotaStats()
 
 	return stats, nil
 }
 
+// namespaceQuotaStats reports the usage of every namespace with a quota
+func (pc *PersistentCache) namespaceQuotaStats() map[string]*NamespaceQuotaStats {
+	if len(pc.eviction.namespaceQuotas) == 0 {
+		return nil
+	}
+	usage, err := pc.eviction.GetAllNamespaceUsage()
+	if err != nil {
+		log.Warnf("Failed to read namespace usage: %v", err)
+	}
+	quotas := make(map[string]*NamespaceQuotaStats, len(pc.eviction.namespaceQuotas))
+	for prefix, quota := range pc.eviction.namespaceQuotas {
+		quotas[prefix] = &NamespaceQuotaStats{SoftQuota: quota.Soft, HardQuota: quota.Hard}
+	}
+	for id, nsUsage := range usage {
+		if prefix, ok := pc.getNamespacePrefix(id); ok {
+			if qs, ok := quotas[prefix]; ok {
+				qs.Usage = nsUsage.Bytes
+			}
+		}
+	}
+	return quotas
+}
+
 // GET /api/v1.0/cache/introspect/stats
 func (pc *PersistentCache) introspectStatsHandler(c *gin.Context) {
 	stats := &CacheStats{
@@ -2197,6 +2243,7 @@ func (pc *PersistentCache) introspectStatsHandler(c *gin.Context) {
 			stats.NamespaceNames[uint32(id)] = prefix
 		}
 	}
+	stats.NamespaceQuotas = pc.namespaceQuotaStats()
 
 	c.JSON(http.StatusOK, stats)
 }
@@ -19,6 +19,7 @@
 package local_cache
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"fmt"
@@ -816,6 +817,45 @@ func TestSetUsageFollowedByAddUsage(t *testing.T) {
 	assert.Equal(t, int64(1500), usage, "SetUsage followed by AddUsage should not duplicate old compacted value")
 }
 
+// TestConsolidateUsageAfterLoad loads a backup taken while a counter still
+// held uncompacted deltas, and checks that consolidating it recovers their
+// sum rather than the newest delta alone.
+func TestConsolidateUsageAfterLoad(t *testing.T) {
+	InitIssuerKeyForTests(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	source, err := NewCacheDB(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer source.Close()
+
+	namespaceID := NamespaceID(1)
+	storageID := StorageIDFirstDisk
+	for _, delta := range []int64{1000, 200, 30} {
+		require.NoError(t, source.AddUsage(storageID, namespaceID, delta))
+	}
+	var snapshot bytes.Buffer
+	_, err = source.db.Backup(&snapshot, 0)
+	require.NoError(t, err)
+
+	restored, err := NewCacheDB(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer restored.Close()
+	require.NoError(t, restored.db.Load(&snapshot, 16))
+
+	require.NoError(t, restored.ConsolidateUsage())
+	usage, err := restored.GetUsage(storageID, namespaceID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1230), usage)
+
+	// And the counter carries on from there.
+	require.NoError(t, restored.AddUsage(storageID, namespaceID, 5))
+	usage, err = restored.GetUsage(storageID, namespaceID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1235), usage)
+}
+
 func TestEncryptionManager(t *testing.T) {
 	// Initialize issuer keys for encryption
 	InitIssuerKeyForTests(t)
@@ -1066,8 +1106,8 @@ func TestEvictionManager(t *testing.T) {
 	})
 
 	// Test recording access
-	require.NoError(t, eviction.RecordAccess("instance_hash_1"))
-	require.NoError(t, eviction.RecordAccess("instance_hash_2"))
+	require.NoError(t, eviction.RecordAccess("instance_hash_1", nil, true))
+	require.NoError(t, eviction.RecordAccess("instance_hash_2", nil, false))
 
 	// Test adding usage via AddUsage (MergeOperator-backed)
 	require.NoError(t, seedUsage(db, StorageIDFirstDisk, 1, 100000))
@@ -0,0 +1,365 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+// Per-namespace quotas
+//
+// The watermarks in eviction.go keep each storage directory within its
+// size, and fairness picks the greediest namespace to evict from, but
+// nothing stops one namespace from filling most of the cache as long as
+// the others are quiet.  Cache.NamespaceQuotas lets an operator cap a
+// namespace, by top-level prefix, at a hard and/or a soft quota.  Both
+// count the namespace's usage across every storage directory, as recorded
+// by the usage counters.
+//
+//   - The hard quota is enforced at admission.  A miss that would take its
+//     namespace past the hard quota is streamed through without being
+//     stored, like any other admission bypass, so the client never waits on
+//     evictions.  Unless the object alone is larger than the quota, the
+//     miss also asks the next eviction pass to make room for an object its
+//     size, and triggers that pass right away.  Usage only grows as blocks
+//     land, so concurrent misses and objects of unknown size can still carry
+//     a namespace a little past its hard quota; the eviction pass below
+//     reels it back.
+//
+//   - The soft quota is enforced by eviction.  Every eviction pass (at
+//     least every ten seconds) brings each namespace over its soft quota
+//     back down to it, whether or not any directory is above its high-water
+//     mark.  A namespace with only a hard quota is brought down to that, or
+//     further if a turned-away miss asked for room.
+//
+// Quota eviction goes through evictFromNamespace, so it uses the
+// namespace's own eviction policy, and takes from the directory where the
+// namespace holds the most first.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+var (
+	namespaceQuotaBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pelican_cache_namespace_quota_bytes",
+		Help: "Configured quota of a namespace, by namespace prefix and quota type (soft or hard)",
+	}, []string{"namespace", "type"})
+	namespaceQuotaUsageBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pelican_cache_namespace_quota_usage_bytes",
+		Help: "Bytes stored for a namespace that has a quota, as of the last eviction pass",
+	}, []string{"namespace"})
+	namespaceQuotaRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_cache_namespace_quota_rejected_objects_total",
+		Help: "Total number of objects not stored because they would have taken a namespace past its hard quota",
+	}, []string{"namespace"})
+	namespaceQuotaEvictedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_cache_namespace_quota_evicted_bytes_total",
+		Help: "Total bytes evicted to keep a namespace within its quota",
+	}, []string{"namespace"})
+)
+
+// NamespaceQuota caps the space a namespace may use in the cache.  Either
+// limit may be 0, meaning none.
+type NamespaceQuota struct {
+	// Soft is the usage eviction brings the namespace back down to
+	Soft uint64
+	// Hard is the usage a new object may not take the namespace past
+	Hard uint64
+}
+
+// target returns the usage the eviction pass trims the namespace to, or 0
+// if the quota is empty.
+func (q NamespaceQuota) target() uint64 {
+	if q.Soft > 0 && (q.Hard == 0 || q.Soft < q.Hard) {
+		return q.Soft
+	}
+	return q.Hard
+}
+
+// NamespaceUsage is a namespace's usage across all storage directories,
+// together with its quota.
+type NamespaceUsage struct {
+	Bytes int64
+	ByDir map[StorageID]int64
+	Quota NamespaceQuota // zero when the namespace has no quota
+}
+
+// namespaceQuotasFromParams reads Cache.NamespaceQuotas
+func namespaceQuotasFromParams() (map[string]NamespaceQuota, error) {
+	if !param.Cache_NamespaceQuotas.IsSet() {
+		return nil, nil
+	}
+	return ParseNamespaceQuotasValue(param.Cache_NamespaceQuotas.GetRaw(), param.Cache_NamespaceQuotas.GetName())
+}
+
+// ParseNamespaceQuotasValue parses the value of Cache.NamespaceQuotas: a map
+// from namespace prefix to either a size (the hard quota) or a map with Soft
+// and Hard sizes.  name is used in error messages.
+func ParseNamespaceQuotasValue(raw any, name string) (map[string]NamespaceQuota, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	entries, ok := stringKeyedMap(raw)
+	if !ok {
+		return nil, errors.Errorf("%s: expected a map from namespace prefix to quota, got %T", name, raw)
+	}
+
+	quotas := make(map[string]NamespaceQuota, len(entries))
+	for prefix, value := range entries {
+		var quota NamespaceQuota
+		if limits, ok := stringKeyedMap(value); ok {
+			for key, limit := range limits {
+				size, err := parseQuotaSize(limit)
+				if err != nil {
+					return nil, errors.Wrapf(err, "%s[%s].%s", name, prefix, key)
+				}
+				switch {
+				case strings.EqualFold(key, "Soft"):
+					quota.Soft = size
+				case strings.EqualFold(key, "Hard"):
+					quota.Hard = size
+				default:
+					return nil, errors.Errorf("%s[%s]: unknown key %q (expected Soft or Hard)", name, prefix, key)
+				}
+			}
+		} else {
+			size, err := parseQuotaSize(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s[%s]", name, prefix)
+			}
+			quota.Hard = size
+		}
+		if quota.Soft > 0 && quota.Hard > 0 && quota.Soft > quota.Hard {
+			return nil, errors.Errorf("%s[%s]: soft quota %s is larger than hard quota %s", name, prefix,
+				utils.HumanBytes(quota.Soft), utils.HumanBytes(quota.Hard))
+		}
+		if quota.target() > 0 {
+			quotas[prefix] = quota
+		}
+	}
+	return quotas, nil
+}
+
+// stringKeyedMap converts the map forms a configuration value can take
+func stringKeyedMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case map[any]any:
+		converted := make(map[string]any, len(m))
+		for k, val := range m {
+			converted[fmt.Sprint(k)] = val
+		}
+		return converted, true
+	}
+	return nil, false
+}
+
+// parseQuotaSize parses a size given as a human-readable string or a number of bytes
+func parseQuotaSize(v any) (uint64, error) {
+	switch s := v.(type) {
+	case string:
+		return utils.ParseBytes(s)
+	case int:
+		return uint64(max(s, 0)), nil
+	case int64:
+		return uint64(max(s, 0)), nil
+	case uint64:
+		return s, nil
+	case float64:
+		return uint64(max(s, 0)), nil
+	}
+	return 0, errors.Errorf("unsupported size type %T", v)
+}
+
+// quotaFor returns the quota configured for a namespace
+func (em *EvictionManager) quotaFor(namespaceID NamespaceID) (NamespaceQuota, string, bool) {
+	if len(em.namespaceQuotas) == 0 {
+		return NamespaceQuota{}, "", false
+	}
+	prefix, ok := em.namespacePrefixFor(namespaceID)
+	if !ok {
+		return NamespaceQuota{}, "", false
+	}
+	quota, ok := em.namespaceQuotas[prefix]
+	return quota, prefix, ok
+}
+
+// namespaceUsage returns a namespace's usage in each storage directory and in total
+func (em *EvictionManager) namespaceUsage(namespaceID NamespaceID) (map[StorageID]int64, int64, error) {
+	byDir := make(map[StorageID]int64, len(em.dirIDs))
+	var total int64
+	for _, sid := range em.dirIDs {
+		usage, err := em.db.GetUsage(sid, namespaceID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if usage > 0 {
+			byDir[sid] = usage
+			total += usage
+		}
+	}
+	return byDir, total, nil
+}
+
+// AdmitToQuota reports whether an object of the given size may be stored
+// for a namespace without taking it past its hard quota.  It never evicts:
+// an object that does not fit is turned away, and the next eviction pass,
+// triggered here, makes room for the next one.  Objects of unknown
+// (negative) size and namespaces without a hard quota are always admitted.
+func (em *EvictionManager) AdmitToQuota(namespaceID NamespaceID, size int64) bool {
+	if size < 0 {
+		return true
+	}
+	quota, prefix, ok := em.quotaFor(namespaceID)
+	if !ok || quota.Hard == 0 {
+		return true
+	}
+
+	reject := func(reason string) bool {
+		em.namespaceCounters(namespaceID).quotaRejected.Add(1)
+		namespaceQuotaRejected.WithLabelValues(prefix).Inc()
+		log.WithFields(log.Fields{
+			"namespace": prefix,
+			"size":      utils.HumanBytes(size),
+			"hardQuota": utils.HumanBytes(quota.Hard),
+		}).Debugf("Not storing object: %s", reason)
+		return false
+	}
+	if uint64(size) > quota.Hard {
+		return reject("larger than the namespace's hard quota")
+	}
+
+	if _, usage, err := em.namespaceUsage(namespaceID); err == nil && usage+size <= int64(quota.Hard) {
+		return true
+	}
+
+	em.quotaMu.Lock()
+	em.quotaRoom[namespaceID] = max(em.quotaRoom[namespaceID], size)
+	em.quotaMu.Unlock()
+	em.TriggerEviction()
+	return reject("the namespace is at its hard quota")
+}
+
+// takeQuotaRoom returns, per namespace, the room that misses turned away at
+// admission asked for since the last call
+func (em *EvictionManager) takeQuotaRoom() map[NamespaceID]int64 {
+	em.quotaMu.Lock()
+	defer em.quotaMu.Unlock()
+	room := em.quotaRoom
+	em.quotaRoom = make(map[NamespaceID]int64)
+	return room
+}
+
+// trimNamespace evicts a namespace's objects, from the directory where it
+// holds the most first, until its total usage is at most target, no more
+// progress can be made, or the deadline passes.  It returns the usage it
+// got down to.
+func (em *EvictionManager) trimNamespace(rl *log.Entry, orders *evictionOrders, namespaceID NamespaceID, prefix string, target int64, deadline time.Time) (int64, error) {
+	counters := em.namespaceCounters(namespaceID)
+	for {
+		byDir, usage, err := em.namespaceUsage(namespaceID)
+		if err != nil || usage <= target || time.Now().After(deadline) {
+			return usage, err
+		}
+
+		var sid StorageID
+		var most int64
+		for id, dirUsage := range byDir {
+			if dirUsage > most {
+				sid, most = id, dirUsage
+			}
+		}
+
+		freed, count, _, _, err := em.evictFromNamespace(rl, orders, sid, namespaceID, 0, usage-target)
+		if err != nil {
+			return usage, err
+		}
+		if count == 0 {
+			// Everything left in the greediest directory is in use; the
+			// next pass will try again.
+			return usage, nil
+		}
+		counters.quotaEvictedObjects.Add(uint64(count))
+		counters.quotaEvictedBytes.Add(freed)
+		namespaceQuotaEvictedBytes.WithLabelValues(prefix).Add(float64(freed))
+	}
+}
+
+// enforceQuotas brings every namespace over its soft quota (or, lacking
+// one, its hard quota) back down to it.  Called at the start of each
+// eviction pass.
+func (em *EvictionManager) enforceQuotas(rl *log.Entry, orders *evictionOrders, deadline time.Time) {
+	if len(em.namespaceQuotas) == 0 {
+		return
+	}
+	room := em.takeQuotaRoom()
+	usage, err := em.GetAllNamespaceUsage()
+	if err != nil {
+		rl.WithError(err).Warn("Failed to read namespace usage for quota enforcement")
+		return
+	}
+
+	for namespaceID, nsUsage := range usage {
+		quota := nsUsage.Quota
+		target := quota.target()
+		if target == 0 {
+			continue
+		}
+		if need := room[namespaceID]; need > 0 && quota.Hard > 0 {
+			target = min(target, quota.Hard-uint64(need))
+		}
+		prefix, _ := em.namespacePrefixFor(namespaceID)
+		namespaceQuotaBytes.WithLabelValues(prefix, "soft").Set(float64(quota.Soft))
+		namespaceQuotaBytes.WithLabelValues(prefix, "hard").Set(float64(quota.Hard))
+		namespaceQuotaUsageBytes.WithLabelValues(prefix).Set(float64(nsUsage.Bytes))
+		if nsUsage.Bytes <= int64(target) {
+			continue
+		}
+
+		nsLog := rl.WithField("namespace", prefix)
+		nsLog.WithFields(log.Fields{
+			"usage":  utils.HumanBytes(nsUsage.Bytes),
+			"target": utils.HumanBytes(target),
+		}).Info("Namespace is over its quota; evicting")
+		after, err := em.trimNamespace(nsLog, orders, namespaceID, prefix, int64(target), deadline)
+		if err != nil {
+			nsLog.WithError(err).Warn("Error evicting namespace to its quota")
+		}
+		namespaceQuotaUsageBytes.WithLabelValues(prefix).Set(float64(after))
+	}
+}
+
+// namespacePrefixFor resolves a namespace ID to its prefix, if known
+func (em *EvictionManager) namespacePrefixFor(namespaceID NamespaceID) (string, bool) {
+	em.policyMu.Lock()
+	resolve := em.namespacePrefix
+	em.policyMu.Unlock()
+	if resolve == nil {
+		return "", false
+	}
+	return resolve(namespaceID)
+}
@@ -0,0 +1,163 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNamespaceQuotasValue(t *testing.T) {
+	quotas, err := ParseNamespaceQuotasValue(map[string]interface{}{
+		"/scans": "2GB",
+		"/analysis": map[string]interface{}{
+			"soft": "400MB",
+			"Hard": 500 << 20,
+		},
+		"/reference": map[interface{}]interface{}{"Soft": "1GB"},
+	}, "Cache.NamespaceQuotas")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]NamespaceQuota{
+		"/scans":     {Hard: 2 << 30},
+		"/analysis":  {Soft: 400 << 20, Hard: 500 << 20},
+		"/reference": {Soft: 1 << 30},
+	}, quotas)
+
+	_, err = ParseNamespaceQuotasValue(map[string]interface{}{
+		"/scans": map[string]interface{}{"Soft": "2GB", "Hard": "1GB"},
+	}, "Cache.NamespaceQuotas")
+	assert.ErrorContains(t, err, "larger than hard quota")
+
+	_, err = ParseNamespaceQuotasValue(map[string]interface{}{
+		"/scans": map[string]interface{}{"Max": "1GB"},
+	}, "Cache.NamespaceQuotas")
+	assert.ErrorContains(t, err, "Cache.NamespaceQuotas[/scans]")
+}
+
+// newQuotaTestManager stores count objects of size bytes in each namespace,
+// oldest first, and returns an eviction manager with the given quotas.
+func newQuotaTestManager(t *testing.T, size int, counts map[NamespaceID]int, quotas map[string]NamespaceQuota) (*EvictionManager, map[NamespaceID][]InstanceHash) {
+	t.Helper()
+	db, sm := newAppendTestStorage(t, 1)
+	sid := sm.DirIDs()[0]
+
+	hashes := make(map[NamespaceID][]InstanceHash)
+	for nsID, count := range counts {
+		for range count {
+			hash := InstanceHash(randomHexForTest(t, 32))
+			data := make([]byte, size)
+			_, err := rand.Read(data)
+			require.NoError(t, err)
+			_, err = sm.InitDiskStorage(t.Context(), hash, int64(size), sid, nsID)
+			require.NoError(t, err)
+			require.NoError(t, sm.WriteBlocks(hash, 0, data))
+			require.NoError(t, db.UpdateLRU(hash, 0))
+			time.Sleep(2 * time.Millisecond)
+			hashes[nsID] = append(hashes[nsID], hash)
+		}
+	}
+
+	em := NewEvictionManager(db, sm, EvictionConfig{
+		DirConfigs:      map[StorageID]EvictionDirConfig{sid: {MaxSize: 1 << 40}},
+		NamespaceQuotas: quotas,
+	})
+	prefixes := map[NamespaceID]string{1: "/scans", 2: "/analysis"}
+	em.SetNamespaceResolver(func(id NamespaceID) (string, bool) {
+		prefix, ok := prefixes[id]
+		return prefix, ok
+	})
+	em.recalculateDirUsage()
+	return em, hashes
+}
+
+// A new object that would take its namespace past the hard quota is turned
+// away without waiting on evictions, and the next eviction pass makes room
+// for it from that namespace's oldest objects, and no one else's.  One that
+// can never fit is turned away for good.
+func TestQuotaAdmission(t *testing.T) {
+	size := 4 * BlockDataSize
+	onDisk := uint64(CalculateFileSize(int64(size)))
+	em, hashes := newQuotaTestManager(t, size, map[NamespaceID]int{1: 3, 2: 3}, map[string]NamespaceQuota{
+		"/scans": {Hard: 3 * onDisk},
+	})
+
+	assert.True(t, em.AdmitToQuota(2, int64(size)), "a namespace without a quota is unconstrained")
+	assert.False(t, em.AdmitToQuota(1, int64(size)))
+	for _, hash := range hashes[1] {
+		meta, err := em.storage.GetMetadata(hash)
+		require.NoError(t, err)
+		assert.NotNil(t, meta, "admission must not evict")
+	}
+	select {
+	case <-em.evictChan:
+	default:
+		t.Fatal("turning the object away did not trigger an eviction pass")
+	}
+
+	em.checkAndEvict()
+	for i, hash := range hashes[1] {
+		meta, err := em.storage.GetMetadata(hash)
+		require.NoError(t, err)
+		assert.Equal(t, i == 0, meta == nil, "object %d", i)
+	}
+	for _, hash := range hashes[2] {
+		meta, err := em.storage.GetMetadata(hash)
+		require.NoError(t, err)
+		assert.NotNil(t, meta)
+	}
+	assert.True(t, em.AdmitToQuota(1, int64(size)))
+
+	assert.False(t, em.AdmitToQuota(1, int64(4*onDisk)))
+	stats := em.GetStats().NamespaceStats[1]
+	assert.Equal(t, uint64(2), stats.QuotaRejected)
+	assert.Equal(t, uint64(1), stats.QuotaEvictedObjects)
+	assert.Equal(t, uint64(1), stats.EvictedObjects)
+}
+
+// Eviction brings a namespace over its soft quota back down to it even
+// though no directory is near its high-water mark.
+func TestQuotaEvictionToSoftQuota(t *testing.T) {
+	size := 4 * BlockDataSize
+	onDisk := int64(CalculateFileSize(int64(size)))
+	em, hashes := newQuotaTestManager(t, size, map[NamespaceID]int{1: 4, 2: 4}, map[string]NamespaceQuota{
+		"/scans": {Soft: uint64(2 * onDisk), Hard: uint64(3 * onDisk)},
+	})
+
+	usage, err := em.GetAllNamespaceUsage()
+	require.NoError(t, err)
+	assert.Equal(t, 4*onDisk, usage[1].Bytes)
+	assert.Equal(t, NamespaceQuota{Soft: uint64(2 * onDisk), Hard: uint64(3 * onDisk)}, usage[1].Quota)
+	assert.Equal(t, NamespaceQuota{}, usage[2].Quota)
+
+	em.checkAndEvict()
+
+	usage, err = em.GetAllNamespaceUsage()
+	require.NoError(t, err)
+	assert.Equal(t, 2*onDisk, usage[1].Bytes)
+	assert.Equal(t, 4*onDisk, usage[2].Bytes)
+	for i, hash := range hashes[1] {
+		meta, err := em.storage.GetMetadata(hash)
+		require.NoError(t, err)
+		assert.Equal(t, i < 2, meta == nil, "object %d", i)
+	}
+}
@@ -159,6 +159,10 @@ type RangeReader struct {
 	// Fetch callback for missing blocks
 	fetchBlocks func(ctx context.Context, startBlock, endBlock uint32) error
 
+	// readAhead prefetches the blocks after a sequential reader's position;
+	// nil unless enabled with enableReadAhead.
+	readAhead *readAhead
+
 	// noStoreReader is the read end of an io.Pipe for streaming no-store
 	// responses.  When set, Read delegates here and Seek returns an error
 	// because a pipe is forward-only.
@@ -223,6 +227,8 @@ func NewRangeReader(
 		return nil, errors.New("invalid range")
 	}
 
+	storage.memTier.recordAccess(instanceHash)
+
 	// Get shared block state (thread-safe, shared across all readers for this object)
 	blockState, err := storage.GetSharedBlockState(instanceHash)
 	if err != nil {
@@ -344,6 +350,7 @@ func (rr *RangeReader) ReadContext(ctx context.Context, p []byte) (n int, err er
 		}
 	}
 
+	rr.readAhead.observe(rr.blockState, rr.position, n, ContentOffsetToBlock(rr.end))
 	rr.position += int64(n)
 
 	if rr.position > rr.end {
@@ -353,6 +360,14 @@ func (rr *RangeReader) ReadContext(ctx context.Context, p []byte) (n int, err er
 	return n, nil
 }
 
+// enableReadAhead turns on read-ahead of up to maxBlocks blocks through the
+// reader's fetch callback.  Must be called before the first Read.
+func (rr *RangeReader) enableReadAhead(maxBlocks uint32) {
+	if rr.meta.IsDisk() {
+		rr.readAhead = newReadAhead(rr.fetchBlocks, maxBlocks)
+	}
+}
+
 // ensureBlocks checks that all blocks in [startBlock, endBlock] are in the
 // shared block state.  If a background download is in progress (indicated by
 // ObjectBlockState.downloading), it waits for each block to be written before
@@ -569,7 +584,7 @@ func (rr *RangeReader) readDiskDirect(dst []byte, off int64) (int, error) {
 		return 0, errors.New("blocks not yet downloaded")
 	}
 
-	if meta.IsChunked() {
+	if meta.IsChunked() || meta.Dedup {
 		return rr.storage.readBlocksChunkedInto(rr.instanceHash, meta, encryptor, dst[:actualLen], off)
 	}
 
@@ -577,11 +592,13 @@ func (rr *RangeReader) readDiskDirect(dst []byte, off int64) (int, error) {
 		return 0, errors.New("object file is missing from disk")
 	}
 
-	return decryptBlocksFromFile(rr.file.File(), meta.ContentLength, encryptor, dst[:actualLen], off, rr.storage.ptCache, rr.instanceHash, 0)
+	return decryptBlocksFromFile(rr.file.File(), meta.ContentLength, encryptor, dst[:actualLen], off, rr.storage.memTier, rr.instanceHash, 0, meta.Compression)
 }
 
 // Close closes the range reader
 func (rr *RangeReader) Close() error {
+	// Stop reading ahead before onClose can tear down the fetcher
+	rr.readAhead.stop()
 	if rr.file != nil {
 		rr.file.Release()
 		rr.file = nil
@@ -0,0 +1,206 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// readAheadTrigger is the number of consecutive sequential reads after
+	// which a reader starts reading ahead.
+	readAheadTrigger = 2
+	// readAheadInitialBlocks is the first read-ahead window (~256 KB); it
+	// doubles with every window issued, up to the configured maximum.
+	readAheadInitialBlocks = 64
+)
+
+var (
+	readAheadBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_readahead_bytes_total",
+		Help: "Total bytes requested from upstream by range reader read-ahead",
+	})
+	readAheadWastedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_readahead_wasted_bytes_total",
+		Help: "Total bytes read ahead and stored that the reader had not read by the time it was closed",
+	})
+	readAheadErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_readahead_errors_total",
+		Help: "Total number of read-ahead fetches that failed",
+	})
+)
+
+// readAhead detects sequential access by a RangeReader and fetches the
+// blocks ahead of it from upstream before they are asked for, so a client
+// streaming a large object does not wait out a full origin round trip on
+// every read.
+//
+// The window starts small and doubles each time one is issued, so short
+// sequential runs cost little while long streams reach the full window.
+// At most one read-ahead fetch is in flight per reader; a new one is issued
+// when the reader has consumed half of what was read ahead.  Reading
+// elsewhere resets the detection, and closing the reader cancels any
+// fetch in flight.
+//
+// Blocks read ahead stay in the cache even if the reader moves elsewhere,
+// so they only count as wasted if they were stored and the reader still
+// had not read them when it was closed.
+type readAhead struct {
+	fetch     func(ctx context.Context, startBlock, endBlock uint32) error
+	maxBlocks uint32
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu         sync.Mutex
+	window     uint32
+	nextPos    int64 // where the next read starts if access is sequential
+	sequential int   // consecutive sequential reads
+	lastBlock  uint32
+	inflight   bool
+	// issuedStart / issuedEnd are the blocks read ahead since access last
+	// became sequential; issued is false when there are none.
+	issued      bool
+	issuedStart uint32
+	issuedEnd   uint32
+	// unread holds every block read ahead that the reader has not read
+	// yet, and blockState the object's block state, to tell at close which
+	// of them were actually stored.
+	unread     *roaring.Bitmap
+	blockState *ObjectBlockState
+}
+
+// newReadAhead creates the read-ahead state for a reader, or returns nil if
+// read-ahead is disabled (maxBlocks is zero) or there is no way to fetch.
+func newReadAhead(fetch func(ctx context.Context, startBlock, endBlock uint32) error, maxBlocks uint32) *readAhead {
+	if fetch == nil || maxBlocks == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &readAhead{
+		fetch:     fetch,
+		maxBlocks: maxBlocks,
+		ctx:       ctx,
+		cancel:    cancel,
+		window:    min(readAheadInitialBlocks, maxBlocks),
+		nextPos:   -1,
+		unread:    roaring.New(),
+	}
+}
+
+// observe records that the reader returned [pos, pos+n) and, once access
+// looks sequential, fetches the blocks that follow, up to and including
+// limitBlock (the last block of the reader's range).
+func (ra *readAhead) observe(blockState *ObjectBlockState, pos int64, n int, limitBlock uint32) {
+	if ra == nil || n <= 0 {
+		return
+	}
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+
+	ra.blockState = blockState
+	if pos == ra.nextPos {
+		ra.sequential++
+	} else {
+		ra.issued = false
+		ra.sequential = 1
+		ra.window = min(readAheadInitialBlocks, ra.maxBlocks)
+	}
+	ra.nextPos = pos + int64(n)
+	ra.lastBlock = ContentOffsetToBlock(ra.nextPos - 1)
+	ra.unread.RemoveRange(uint64(ContentOffsetToBlock(pos)), uint64(ra.lastBlock)+1)
+
+	if ra.sequential < readAheadTrigger || ra.inflight || ra.lastBlock >= limitBlock {
+		return
+	}
+	// Wait until half of what was read ahead has been consumed
+	if ra.issued && ra.issuedEnd > ra.lastBlock && ra.issuedEnd-ra.lastBlock > ra.window/2 {
+		return
+	}
+	// A background download of the whole object is already ahead of us
+	if blockState.IsDownloading() {
+		return
+	}
+
+	first := ra.lastBlock + 1
+	if ra.issued && ra.issuedEnd >= first {
+		first = ra.issuedEnd + 1
+	}
+	last := min(ra.lastBlock+ra.window, limitBlock)
+	for first <= last && blockState.Contains(first) {
+		first++
+	}
+	if first > last {
+		return
+	}
+
+	if !ra.issued {
+		ra.issued = true
+		ra.issuedStart = first
+	}
+	ra.issuedEnd = last
+	ra.window = min(ra.window*2, ra.maxBlocks)
+	ra.inflight = true
+	ra.unread.AddRange(uint64(first), uint64(last)+1)
+	readAheadBytes.Add(float64(int64(last-first+1) * BlockDataSize))
+
+	ra.wg.Add(1)
+	go func() {
+		defer ra.wg.Done()
+		err := ra.fetch(ra.ctx, first, last)
+		if err != nil && ra.ctx.Err() == nil {
+			readAheadErrors.Inc()
+			log.Debugf("Read-ahead of blocks %d-%d failed: %v", first, last, err)
+		}
+		ra.mu.Lock()
+		ra.inflight = false
+		ra.mu.Unlock()
+	}()
+}
+
+// stop cancels any read-ahead in flight, waits for it to finish, and counts
+// the blocks it stored that the reader never read as wasted.
+func (ra *readAhead) stop() {
+	if ra == nil {
+		return
+	}
+	ra.cancel()
+	ra.wg.Wait()
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	if ra.blockState == nil {
+		return
+	}
+	var wasted int64
+	it := ra.unread.Iterator()
+	for it.HasNext() {
+		if ra.blockState.Contains(it.Next()) {
+			wasted++
+		}
+	}
+	readAheadWastedBytes.Add(float64(wasted * BlockDataSize))
+	ra.unread.Clear()
+}
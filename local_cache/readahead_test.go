@@ -0,0 +1,171 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReadAheadFetcher records the ranges read ahead and marks them present
+type fakeReadAheadFetcher struct {
+	mu     sync.Mutex
+	ranges [][2]uint32
+	state  *ObjectBlockState
+	block  chan struct{} // when non-nil, fetches wait on it or on cancellation
+}
+
+func (f *fakeReadAheadFetcher) fetch(ctx context.Context, start, end uint32) error {
+	f.mu.Lock()
+	f.ranges = append(f.ranges, [2]uint32{start, end})
+	f.mu.Unlock()
+	if f.block != nil {
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	f.state.AddRange(start, end)
+	return nil
+}
+
+func (f *fakeReadAheadFetcher) fetched() [][2]uint32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][2]uint32(nil), f.ranges...)
+}
+
+func TestReadAheadSequential(t *testing.T) {
+	state := NewObjectBlockState(nil)
+	f := &fakeReadAheadFetcher{state: state}
+	ra := newReadAhead(f.fetch, 256)
+	require.NotNil(t, ra)
+
+	const readSize = 16 * BlockDataSize
+	read := func(pos int64) {
+		state.AddRange(ContentOffsetToBlock(pos), ContentOffsetToBlock(pos+readSize-1))
+		ra.observe(state, pos, readSize, 10000)
+		ra.wg.Wait()
+	}
+
+	// One read is not a pattern yet
+	read(0)
+	assert.Empty(t, f.fetched())
+
+	// The second sequential read reads ahead the initial window
+	read(readSize)
+	assert.Equal(t, [][2]uint32{{32, 95}}, f.fetched())
+
+	// Each window issued doubles the next, and the reader is topped up
+	// whenever less than half a window lies ahead of it.
+	read(2 * readSize)
+	assert.Equal(t, [][2]uint32{{32, 95}, {96, 175}}, f.fetched())
+	read(3 * readSize)
+	assert.Equal(t, [][2]uint32{{32, 95}, {96, 175}, {176, 319}}, f.fetched())
+	read(4 * readSize)
+	assert.Len(t, f.fetched(), 3)
+
+	ra.stop()
+}
+
+func TestReadAheadRandomAccess(t *testing.T) {
+	state := NewObjectBlockState(nil)
+	f := &fakeReadAheadFetcher{state: state}
+	ra := newReadAhead(f.fetch, 256)
+
+	for _, pos := range []int64{0, 100 * BlockDataSize, 50 * BlockDataSize, 7 * BlockDataSize} {
+		ra.observe(state, pos, BlockDataSize, 10000)
+	}
+	ra.stop()
+	assert.Empty(t, f.fetched())
+
+	// Disabled read-ahead is inert
+	assert.Nil(t, newReadAhead(f.fetch, 0))
+	assert.Nil(t, newReadAhead(nil, 256))
+	var none *readAhead
+	none.observe(state, 0, BlockDataSize, 10)
+	none.stop()
+}
+
+func TestReadAheadStopsAtRangeEnd(t *testing.T) {
+	state := NewObjectBlockState(nil)
+	f := &fakeReadAheadFetcher{state: state}
+	ra := newReadAhead(f.fetch, 256)
+
+	ra.observe(state, 0, BlockDataSize, 20)
+	ra.observe(state, BlockDataSize, BlockDataSize, 20)
+	ra.wg.Wait()
+	assert.Equal(t, [][2]uint32{{2, 20}}, f.fetched())
+	ra.stop()
+}
+
+func TestReadAheadCancel(t *testing.T) {
+	state := NewObjectBlockState(nil)
+	f := &fakeReadAheadFetcher{state: state, block: make(chan struct{})}
+	ra := newReadAhead(f.fetch, 256)
+	wastedBefore := testutil.ToFloat64(readAheadWastedBytes)
+
+	ra.observe(state, 0, BlockDataSize, 10000)
+	ra.observe(state, BlockDataSize, BlockDataSize, 10000)
+	require.Eventually(t, func() bool { return len(f.fetched()) == 1 }, time.Second, time.Millisecond)
+
+	// Closing the reader cancels the fetch in flight.  Nothing it would
+	// have read ahead was stored, so nothing was wasted.
+	done := make(chan struct{})
+	go func() {
+		ra.stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop did not cancel the read-ahead in flight")
+	}
+	assert.Zero(t, testutil.ToFloat64(readAheadWastedBytes)-wastedBefore)
+}
+
+func TestReadAheadWaste(t *testing.T) {
+	state := NewObjectBlockState(nil)
+	f := &fakeReadAheadFetcher{state: state}
+	ra := newReadAhead(f.fetch, 256)
+	wastedBefore := testutil.ToFloat64(readAheadWastedBytes)
+
+	ra.observe(state, 0, BlockDataSize, 10000)
+	ra.observe(state, BlockDataSize, BlockDataSize, 10000)
+	ra.wg.Wait()
+	require.Equal(t, [][2]uint32{{2, 65}}, f.fetched())
+
+	// Seeking away doesn't waste what was read ahead; it's still stored
+	ra.observe(state, 1000*BlockDataSize, BlockDataSize, 10000)
+	assert.Zero(t, testutil.ToFloat64(readAheadWastedBytes)-wastedBefore)
+
+	// Blocks the reader comes back for aren't wasted either
+	ra.observe(state, 2*BlockDataSize, 10*BlockDataSize, 10000)
+
+	// Whatever is still unread when the reader closes is
+	ra.stop()
+	assert.Equal(t, float64((readAheadInitialBlocks-10)*BlockDataSize), testutil.ToFloat64(readAheadWastedBytes)-wastedBefore)
+}
@@ -76,6 +76,23 @@ const (
 	// remove it, and anything left over belongs to a writer that did not
 	// survive.  See StorageManager.ReclaimAbandonedAppends.
 	PrefixAppendIntent = "aw:"
+	// PrefixDedupIndex maps a deduplicated block to its place in the shared
+	// block pool and its reference count: dx:<namespace_id>:<block_id>.
+	// See dedup.go.
+	PrefixDedupIndex = "dx:"
+	// PrefixDedupMap stores, per group of blocks of a deduplicated object,
+	// the pooled block each one holds: dm:<instance_hash>:<group>
+	PrefixDedupMap = "dm:"
+	// PrefixDedupFree lists the free slots of a storage directory's block
+	// pool: df:<storage_id>:<slot>
+	PrefixDedupFree = "df:"
+	// PrefixDedupNext stores the number of slots ever handed out from a
+	// storage directory's block pool: dn:<storage_id>
+	PrefixDedupNext = "dn:"
+	// PrefixWarmup stores the cache warmup job, wu:job -> msgpack(WarmupJob),
+	// and the objects it is to fetch, in order: wu:e:<index> ->
+	// msgpack(PopularObject).  See warmup.go.
+	PrefixWarmup = "wu:"
 	// The keys below describe the database as a whole rather than any one
 	// object.  They are single, underscore-prefixed keys, they are written at
 	// open before any consumer touches a record, and none of them is ever
@@ -134,6 +151,21 @@ const (
 	PrefixDirent = "pd:"
 	// PrefixGarbage stores pstore instances and subtrees awaiting reclamation
 	PrefixGarbage = "pg:"
+	// PrefixVersion stores the retained prior versions of pstore objects,
+	// keyed by path and the time each was superseded:
+	// pv:<path>\x00<nanoseconds>:<generation>
+	PrefixVersion = "pv:"
+	// PrefixReplication stores a pstore's replication role and position,
+	// pr:state, and a primary's log of committed mutations awaiting its
+	// secondary: pr:l:<zero-padded sequence number>
+	PrefixReplication = "pr:"
+	// PrefixErasure stores the erasure-coding layout of a pstore object
+	// version -- where its parity shards are and the digest of every shard:
+	// pe:<instance hash>
+	PrefixErasure = "pe:"
+	// PrefixRetention stores the retention rule and legal hold set on a
+	// pstore path, which lock the objects at and below it: pl:<path>
+	PrefixRetention = "pl:"
 )
 
 // StoreMode identifies which subsystem owns a database.
@@ -182,6 +214,9 @@ type StorageDirConfig struct {
 	// LowWaterMarkPercentage overrides the global low-water mark for this
 	// directory.  0 means use the global default.
 	LowWaterMarkPercentage int
+	// Tier places the directory on the fast or slow storage tier.  Empty
+	// means fast.  See tiering.go.
+	Tier StorageTier
 }
 
 // ParseStorageDirsConfig reads the LocalCache.StorageDirs setting from Viper
@@ -203,6 +238,7 @@ type StorageDirConfig struct {
 //     LowWaterMarkPercentage: 85
 //     - Path: /mnt/cache2
 //     MaxSize: 2TB
+//     Tier: slow
 //
 // Returns nil (not an error) when the key is unset or empty.
 func ParseStorageDirsConfig() ([]StorageDirConfig, error) {
@@ -352,6 +388,20 @@ func parseStorageDirEntry(name string, idx int, m map[string]interface{}) (Stora
 		}
 	}
 
+	// Tier (optional)
+	if _, ok := m["Tier"]; !ok {
+		if v, ok := m["tier"]; ok {
+			m["Tier"] = v
+		}
+	}
+	if v, ok := m["Tier"]; ok && v != nil {
+		tier, err := ParseStorageTier(fmt.Sprint(v))
+		if err != nil {
+			return cfg, fmt.Errorf("%s[%d].Tier: %w", name, idx, err)
+		}
+		cfg.Tier = tier
+	}
+
 	return cfg, nil
 }
 
@@ -455,6 +505,21 @@ type CacheMetadata struct {
 	ChunkSizeCode  ChunkSizeCode   `msgpack:"csc,omitempty"` // 0 = disabled, see ChunkSizeCodeToBytes()
 	ChunkLocations []ChunkLocation `msgpack:"chl,omitempty"` // Locations for chunks after chunk 0
 
+	// Compression is the block compression applied to the object's on-disk
+	// data (see compression.go).  It is chosen when storage is initialized;
+	// objects stored without it are read exactly as before.
+	Compression CompressionAlgorithm `msgpack:"cmp,omitempty"`
+	// CompressedSlack is, per chunk (chunk 0 first), the number of bytes of
+	// the chunk's file that compressed units leave unwritten.  These bytes
+	// have been credited back to the usage counters (see
+	// CacheDB.CreditCompressedSlack), so PerDirectoryBytes leaves them out.
+	CompressedSlack []int64 `msgpack:"cslk,omitempty"`
+
+	// Dedup is set when the object's blocks live in the shared block pool
+	// rather than in its own files (see dedup.go).  Deduplicated objects are
+	// never compressed.
+	Dedup bool `msgpack:"dd,omitempty"`
+
 	// Namespace and storage tracking for fairness-aware eviction
 	NamespaceID NamespaceID `msgpack:"ns"` // ID of the namespace prefix
 	// Usage is tracked per (StorageID, NamespaceID) pair for multi-storage fairness
@@ -568,7 +633,9 @@ func (m *CacheMetadata) AllStorageIDs() []StorageID {
 // bytes that live in each storage directory.  For non-chunked objects the
 // entire ContentLength is attributed to the base StorageID.  For chunked
 // objects the byte count is split according to each chunk's assigned
-// directory.  Unallocated chunks (StorageID 0) are skipped.
+// directory.  Unallocated chunks (StorageID 0) are skipped.  Space left
+// unwritten by compressed units (CompressedSlack) is not counted, matching
+// what the object is charged in the usage counters.
 func (m *CacheMetadata) PerDirectoryBytes() map[StorageID]int64 {
 	result := make(map[StorageID]int64)
 	if !m.IsChunked() {
@@ -576,7 +643,7 @@ func (m *CacheMetadata) PerDirectoryBytes() map[StorageID]int64 {
 			if m.StorageID == StorageIDInline {
 				result[m.StorageID] = m.ContentLength
 			} else {
-				result[m.StorageID] = CalculateFileSize(m.ContentLength)
+				result[m.StorageID] = CalculateFileSize(m.ContentLength) - m.chunkSlack(0)
 			}
 		}
 		return result
@@ -585,11 +652,19 @@ func (m *CacheMetadata) PerDirectoryBytes() map[StorageID]int64 {
 		if ci.StorageID == StorageIDInline {
 			continue // unallocated
 		}
-		result[ci.StorageID] += CalculateFileSize(ci.Size)
+		result[ci.StorageID] += CalculateFileSize(ci.Size) - m.chunkSlack(ci.Index)
 	}
 	return result
 }
 
+// chunkSlack returns the CompressedSlack recorded for a chunk
+func (m *CacheMetadata) chunkSlack(chunkIndex int) int64 {
+	if chunkIndex < 0 || chunkIndex >= len(m.CompressedSlack) {
+		return 0
+	}
+	return m.CompressedSlack[chunkIndex]
+}
+
 // SetCacheControl parses a Cache-Control header and stores the directives efficiently
 func (m *CacheMetadata) SetCacheControl(header string) {
 	if header == "" {
@@ -865,6 +940,42 @@ func AppendIntentKey(instanceHash InstanceHash) []byte {
 	return []byte(PrefixAppendIntent + string(instanceHash))
 }
 
+// DedupIndexKey returns the BadgerDB key for a pooled block
+// Format: dx:<namespace_id>:<block_id hex>
+func DedupIndexKey(namespaceID NamespaceID, id dedupBlockID) []byte {
+	return []byte(fmt.Sprintf("%s%d:%x", PrefixDedupIndex, namespaceID, id[:]))
+}
+
+// DedupMapKey returns the BadgerDB key for a group of a deduplicated
+// object's block map
+// Format: dm:<instance_hash>:<group>
+func DedupMapKey(instanceHash InstanceHash, group uint32) []byte {
+	return []byte(fmt.Sprintf("%s%s:%010d", PrefixDedupMap, string(instanceHash), group))
+}
+
+// DedupFreeKey returns the BadgerDB key for a free block pool slot
+// Format: df:<storage_id>:<slot>
+func DedupFreeKey(storageID StorageID, slot uint64) []byte {
+	return []byte(fmt.Sprintf("%s%d:%d", PrefixDedupFree, storageID, slot))
+}
+
+// DedupNextKey returns the BadgerDB key for the size of a block pool
+// Format: dn:<storage_id>
+func DedupNextKey(storageID StorageID) []byte {
+	return []byte(fmt.Sprintf("%s%d", PrefixDedupNext, storageID))
+}
+
+// WarmupJobKey returns the BadgerDB key for the cache warmup job
+func WarmupJobKey() []byte {
+	return []byte(PrefixWarmup + "job")
+}
+
+// WarmupEntryKey returns the BadgerDB key for an object of the warmup list
+// Format: wu:e:<index>
+func WarmupEntryKey(index int) []byte {
+	return []byte(fmt.Sprintf("%se:%010d", PrefixWarmup, index))
+}
+
 // AppendIntent is the record written while an AppendWriter is building an
 // object.  StartedAt lets a reclamation pass leave very recent appends alone
 // even when it cannot consult the in-process registry of live writers.
@@ -31,7 +31,6 @@ import (
 	"time"
 
 	"github.com/RoaringBitmap/roaring"
-	ristretto "github.com/dgraph-io/ristretto/v2"
 	"github.com/google/uuid"
 	"github.com/jellydator/ttlcache/v3"
 	"github.com/pkg/errors"
@@ -39,7 +38,6 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/pelicanplatform/pelican/param"
-	"github.com/pelicanplatform/pelican/utils"
 )
 
 // removeFileWithRetry removes a file, retrying briefly on Windows if the
@@ -348,12 +346,10 @@ type StorageManager struct {
 	// call opens a fresh descriptor.
 	fdCacheMaxSize uint64
 
-	// ptCache is an optional in-memory plaintext block cache backed by
-	// ristretto.  When non-nil, decrypted 4080-byte blocks are cached
-	// so that repeated reads bypass AES-GCM decryption.  Keyed by
-	// (InstanceHash, blockNumber); cost = BlockDataSize per entry.
-	// Nil when disabled (MemoryCacheSize == 0).
-	ptCache *ristretto.Cache[uint64, []byte]
+	// memTier is the optional in-memory tier of decrypted blocks of hot
+	// objects, so that repeated reads bypass disk and AES-GCM decryption.
+	// See memory_tier.go.  Nil when disabled (MemoryCacheSize == 0).
+	memTier *memoryTier
 
 	// pins tracks object versions with live readers, so a delete path that
 	// respects them does not pull data out from under one.  See pin.go.
@@ -371,6 +367,26 @@ type StorageManager struct {
 	// overwrites this with EvictionManager.ChooseDiskStorage (weighted
 	// by free space) before any concurrent access begins.
 	chooseDir func() StorageID
+
+	// placeChunk, when set, is consulted instead of chooseDir for each new
+	// chunk, with the object's metadata so the choice can depend on where
+	// its other chunks already are.  See SetChunkPlacement.
+	placeChunk func(meta *CacheMetadata, chunkIndex int) StorageID
+
+	// compression is the block compression policy set by SetCompression;
+	// nil means new objects are stored uncompressed.  unitLocks serialize
+	// writes to the units of compressed objects (see lockUnits); the zero
+	// values work in every constructor.
+	compression *blockCompression
+	unitLocks   [compressionLockStripes]sync.Mutex
+
+	// retired holds the old files of objects migrated to another storage
+	// tier while a reader was still on them.  See tiering.go.
+	retired retiredChunks
+
+	// dedup is the block deduplication policy set by SetDedup and the
+	// shared block pool that deduplicated objects live in.  See dedup.go.
+	dedup blockDedup
 }
 
 // StorageDirInfo describes a configured storage directory at runtime.
@@ -400,6 +416,27 @@ func writeDirUUID(dir, id string) error {
 	return os.WriteFile(filepath.Join(dir, uuidFileName), []byte(id), 0600)
 }
 
+// StorageDataSubdirs returns the subdirectories of a storage directory that
+// hold object data: the objects tree and the block pool.  Nothing else in it
+// is data -- its identity file, and the catalog when it doubles as the base
+// directory, are not.
+func StorageDataSubdirs() []string {
+	return []string{objectsSubDir, dedupSubDir}
+}
+
+// ClaimStorageDir prepares dir to be mounted as the storage directory with
+// the given identity: the one recorded for it in the catalog, when its files
+// are being put back from elsewhere.
+func ClaimStorageDir(dir, id string) error {
+	if err := os.MkdirAll(filepath.Join(dir, objectsSubDir), 0750); err != nil {
+		return errors.Wrapf(err, "failed to prepare storage directory %s", dir)
+	}
+	if err := writeDirUUID(dir, id); err != nil {
+		return errors.Wrapf(err, "failed to write UUID file in %s", dir)
+	}
+	return nil
+}
+
 // NewStorageManager creates a new storage manager with UUID-based directory
 // identity.  It performs the following steps:
 //
@@ -525,42 +562,10 @@ func NewStorageManager(db *CacheDB, dirs []string, inlineMax int, egrp *errgroup
 	}
 	// fdCacheSizeParam <= 0 → fdCacheSize stays 0, disabling caching.
 
-	// Plaintext block cache: when MemoryCacheSize > 0, create a
-	// ristretto cache that stores decrypted 4080-byte blocks keyed by
-	// (instanceHash, blockNum).  MaxCost = configured size in bytes;
-	// each entry costs BlockDataSize (4080) bytes.
-	//
-	// Check Cache.MemoryCacheSize (cache server) first, then fall back
-	// to LocalCache.MemoryCacheSize (local cache module).
-	var ptCache *ristretto.Cache[uint64, []byte]
-	ptCacheSizeStr := param.Cache_MemoryCacheSize.GetString()
-	if ptCacheSizeStr == "" || ptCacheSizeStr == "0" {
-		ptCacheSizeStr = param.LocalCache_MemoryCacheSize.GetString()
-	}
-	var ptCacheSize uint64
-	if ptCacheSizeStr != "" && ptCacheSizeStr != "0" {
-		var parseErr error
-		ptCacheSize, parseErr = utils.ParseBytes(ptCacheSizeStr)
-		if parseErr != nil {
-			return nil, errors.Wrapf(parseErr, "failed to parse MemoryCacheSize value %q", ptCacheSizeStr)
-		}
-	}
-	if ptCacheSize > 0 {
-		// NumCounters should be ~10× the expected max number of entries.
-		numEntries := int64(ptCacheSize) / BlockDataSize
-		numCounters := numEntries * 10
-		if numCounters < 1000 {
-			numCounters = 1000
-		}
-		var err error
-		ptCache, err = ristretto.NewCache(&ristretto.Config[uint64, []byte]{
-			NumCounters: numCounters,
-			MaxCost:     int64(ptCacheSize),
-			BufferItems: 64,
-		})
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to create plaintext block cache")
-		}
+	// In-memory tier of decrypted blocks; nil when MemoryCacheSize is 0.
+	memTier, err := memoryTierFromParams()
+	if err != nil {
+		return nil, err
 	}
 
 	// Build sorted directory ID list for default round-robin.
@@ -581,7 +586,7 @@ func NewStorageManager(db *CacheDB, dirs []string, inlineMax int, egrp *errgroup
 		dirs:           objDirs,
 		inlineMaxBytes: inlineMax,
 		fdCacheMaxSize: fdCacheSize,
-		ptCache:        ptCache,
+		memTier:        memTier,
 		chooseDir:      defaultChooseDir,
 		pins:           newPinSet(),
 		blockStates:    newBlockStateCache(db),
@@ -629,11 +634,86 @@ func (sm *StorageManager) SetChooseDir(fn func() StorageID) {
 	}
 }
 
+// SetChunkPlacement installs a per-chunk placement function that replaces
+// the directory chooser when a chunk is allocated.
+//
+// The chooser sees nothing of the object, which is enough to balance free
+// space but not to keep an object's chunks apart; a pstore laying chunks out
+// for erasure coding needs each stripe's chunks in different directories, so
+// it places them knowing where the earlier ones landed.
+//
+// Must be called before any concurrent access begins.
+func (sm *StorageManager) SetChunkPlacement(fn func(meta *CacheMetadata, chunkIndex int) StorageID) {
+	sm.placeChunk = fn
+}
+
 // GetDirs returns the configured storage directories (storageID → objects dir).
 func (sm *StorageManager) GetDirs() map[StorageID]string {
 	return sm.dirs
 }
 
+// ChunkFilePath returns the path of one chunk file of an object stored in the
+// given directory, or false when the directory is not mounted.
+func (sm *StorageManager) ChunkFilePath(storageID StorageID, instanceHash InstanceHash, chunkIndex int) (string, bool) {
+	if _, ok := sm.dirs[storageID]; !ok {
+		return "", false
+	}
+	return sm.getChunkPath(storageID, instanceHash, chunkIndex), true
+}
+
+// InvalidateObjectFiles drops the open file handles and cached metadata held
+// for an object, so that the next reader opens its files afresh.  Readers
+// already open keep the handles they hold.
+//
+// A caller that replaces an object's files in place -- rebuilding a lost or
+// corrupt chunk -- calls it afterwards; without it new readers would be handed
+// the descriptor of the file that was replaced.
+func (sm *StorageManager) InvalidateObjectFiles(instanceHash InstanceHash) {
+	chunkCount := 1
+	if meta, err := sm.db.GetMetadata(instanceHash); err == nil && meta != nil && meta.IsChunked() {
+		chunkCount = meta.ChunkCount()
+	}
+	sm.invalidateObjectCaches(instanceHash, chunkCount)
+}
+
+// AdoptReplacedDirs gives each mounted directory that has lost its identity
+// file -- a replacement disk mounted where a failed one was -- the identity
+// recorded for that path, and returns the storage IDs it adopted.  A recorded
+// directory whose path no longer exists at all has been retired rather than
+// replaced: it is unmounted, so nothing is written there, and returned as
+// missing.
+//
+// Only the read-only manager used for offline maintenance mounts directories
+// by their recorded path, so that is where this is meaningful: a full
+// NewStorageManager would instead assign an empty replacement a fresh ID,
+// stranding every file rebuilt into it under the old one.
+func (sm *StorageManager) AdoptReplacedDirs() (adopted, missing []StorageID, err error) {
+	mappings, err := sm.db.LoadDiskMappings()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to load disk mappings")
+	}
+	for _, dm := range mappings {
+		if _, mounted := sm.dirs[dm.ID]; !mounted {
+			continue
+		}
+		if _, statErr := os.Stat(dm.Directory); errors.Is(statErr, os.ErrNotExist) {
+			log.Warnf("Storage directory %s (ID %d) no longer exists; treating it as retired", dm.Directory, dm.ID)
+			delete(sm.dirs, dm.ID)
+			missing = append(missing, dm.ID)
+			continue
+		}
+		if _, ok := readDirUUID(dm.Directory); ok {
+			continue
+		}
+		if err := ClaimStorageDir(dm.Directory, dm.UUID); err != nil {
+			return adopted, missing, err
+		}
+		log.Infof("Adopted %s as storage ID %d (UUID %s)", dm.Directory, dm.ID, dm.UUID)
+		adopted = append(adopted, dm.ID)
+	}
+	return adopted, missing, nil
+}
+
 // Close stops TTL cache eviction goroutines and releases cached resources.
 //
 // Only the caches that were actually started are stopped.  ttlcache's Stop is
@@ -659,9 +739,8 @@ func (sm *StorageManager) Close() {
 	// Closing caches evicts all entries, triggering OnEviction which
 	// closes each file descriptor.
 	sm.openFiles.DeleteAll()
-	if sm.ptCache != nil {
-		sm.ptCache.Close()
-	}
+	sm.memTier.Close()
+	sm.closeDedupFiles()
 }
 
 // NewStorageManagerReadOnly creates a storage manager for read-only introspection.
@@ -1011,6 +1090,10 @@ func (sm *StorageManager) InitDiskStorage(ctx context.Context, instanceHash Inst
 		NamespaceID:   namespaceID,
 		ContentLength: contentLength,
 		DataKey:       encryptedDEK,
+		Dedup:         sm.dedupFor(namespaceID),
+	}
+	if !meta.Dedup {
+		meta.Compression = sm.compressionFor(namespaceID)
 	}
 
 	// Create the file; createFile lazily creates the parent directory
@@ -1166,7 +1249,12 @@ func (sm *StorageManager) AllocateChunk(
 	}
 
 	// Choose storage directory.
-	storageID := sm.chooseDir()
+	var storageID StorageID
+	if sm.placeChunk != nil {
+		storageID = sm.placeChunk(meta, chunkIndex)
+	} else {
+		storageID = sm.chooseDir()
+	}
 
 	// Create the chunk file
 	chunkPath := sm.getChunkPath(storageID, instanceHash, chunkIndex)
@@ -1292,6 +1380,13 @@ func (sm *StorageManager) writeBlocks(instanceHash InstanceHash, meta *CacheMeta
 		return rc.File(), nil
 	}
 
+	if meta.Dedup {
+		return sm.writeDedupBlocks(instanceHash, meta, startOffset, data, getChunkFile)
+	}
+	if meta.Compression != CompressionNone {
+		return sm.writeCompressedBlocks(instanceHash, meta, encryptor, startOffset, data, getChunkFile)
+	}
+
 	// Pooled write buffer for batching.
 	writeBufSize := int(writeBatchBlocks) * BlockTotalSize
 	wbp := writeBufPool.Get().(*[]byte)
@@ -1415,6 +1510,51 @@ func (sm *StorageManager) writeBlocks(instanceHash InstanceHash, meta *CacheMeta
 	return nil
 }
 
+// writeCompressedBlocks is writeBlocks for an object stored with block
+// compression.  Data is written one chunk at a time through
+// writeCompressible, which decides unit by unit whether to store a frame.
+func (sm *StorageManager) writeCompressedBlocks(instanceHash InstanceHash, meta *CacheMetadata, encryptor *BlockEncryptor, startOffset int64, data []byte, getChunkFile func(int) (*os.File, error)) error {
+	for len(data) > 0 {
+		chunkIdx := ContentOffsetToChunk(startOffset, meta.ChunkSizeCode)
+		chunkStart := int64(0)
+		n := len(data)
+		if meta.IsChunked() {
+			var chunkEnd int64
+			chunkStart, chunkEnd = GetChunkRange(meta.ContentLength, meta.ChunkSizeCode, chunkIdx)
+			n = int(min(int64(n), chunkEnd+1-startOffset))
+		}
+		file, err := getChunkFile(chunkIdx)
+		if err != nil {
+			return errors.Wrapf(err, "failed to open chunk %d", chunkIdx)
+		}
+
+		globalBlock0 := ContentOffsetToBlock(chunkStart)
+		first := ContentOffsetToBlock(startOffset - chunkStart)
+		unlock := sm.lockUnits(instanceHash)
+		slack, err := sm.writeCompressible(file, encryptor, meta.Compression, globalBlock0, first, data[:n])
+		unlock()
+		// Credit what was written even if the write then failed, since
+		// the frames already on disk are what a later rewrite measures.
+		if cerr := sm.db.CreditCompressedSlack(instanceHash, chunkIdx, slack); cerr != nil && err == nil {
+			err = errors.Wrap(cerr, "failed to credit space saved by compression")
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to write to chunk %d", chunkIdx)
+		}
+
+		startBlock := globalBlock0 + first
+		endBlock := startBlock + uint32((n+BlockDataSize-1)/BlockDataSize) - 1
+		if err := sm.db.MarkBlocksDownloaded(instanceHash, startBlock, endBlock, meta.GetChunkStorageID(chunkIdx), meta.NamespaceID, meta.ContentLength); err != nil {
+			return errors.Wrap(err, "failed to update block state")
+		}
+		data = data[n:]
+		startOffset += int64(n)
+	}
+
+	sm.checkAndMarkComplete(instanceHash, meta)
+	return nil
+}
+
 // checkAndMarkComplete checks if all blocks are downloaded and marks the object as complete
 func (sm *StorageManager) checkAndMarkComplete(instanceHash InstanceHash, meta *CacheMetadata) {
 	totalBlocks := CalculateBlockCount(meta.ContentLength)
@@ -1508,8 +1648,17 @@ func (sm *StorageManager) ReadBlocksInto(dst []byte, instanceHash InstanceHash,
 // directly into dst.  It uses a pooled read buffer for the encrypted
 // disk I/O.  globalBlockNum0 is the global block number corresponding to
 // file-local block 0; pass 0 for non-chunked files (where local == global).
-// For chunk files, pass ContentOffsetToBlock(chunkStart).
-func decryptBlocksFromFile(file *os.File, contentLength int64, encryptor *BlockEncryptor, dst []byte, startOffset int64, ptCache *ristretto.Cache[uint64, []byte], instanceHash InstanceHash, globalBlockNum0 uint32) (int, error) {
+// For chunk files, pass ContentOffsetToBlock(chunkStart).  compression is
+// the object's block compression; see decryptUnitsFromFile.
+//
+// Each batch of blocks is served from the memory tier when all of its
+// blocks are there; otherwise it is read from disk, and the decrypted
+// blocks are added to the tier if the object is hot enough.
+func decryptBlocksFromFile(file *os.File, contentLength int64, encryptor *BlockEncryptor, dst []byte, startOffset int64, tier *memoryTier, instanceHash InstanceHash, globalBlockNum0 uint32, compression CompressionAlgorithm) (int, error) {
+	if compression != CompressionNone {
+		return decryptUnitsFromFile(file, contentLength, encryptor, dst, startOffset, tier, instanceHash, globalBlockNum0)
+	}
+
 	endOffset := startOffset + int64(len(dst))
 	if endOffset > contentLength {
 		endOffset = contentLength
@@ -1522,6 +1671,7 @@ func decryptBlocksFromFile(file *os.File, contentLength int64, encryptor *BlockE
 
 	resultLen := int(endOffset - startOffset)
 	resultPos := 0
+	admit := tier.admits(instanceHash)
 
 	// Use pooled read buffer.
 	bp := readBufPool.Get().(*[]byte)
@@ -1535,6 +1685,14 @@ func decryptBlocksFromFile(file *os.File, contentLength int64, encryptor *BlockE
 		}
 		batchBlockCount := int(batchEnd - batchStart + 1)
 
+		// Skip the disk entirely if the memory tier holds the whole batch.
+		batchEndOffset := min(int64(batchEnd+1)*BlockDataSize, endOffset)
+		batchLen := int(batchEndOffset - (startOffset + int64(resultPos)))
+		if tier.readInto(instanceHash, globalBlockNum0, dst[resultPos:resultPos+batchLen], startOffset+int64(resultPos)) {
+			resultPos += batchLen
+			continue
+		}
+
 		// Calculate read size — last block of file may be smaller.
 		var readSize int
 		if batchEnd == lastObjectBlock {
@@ -1581,29 +1739,23 @@ func decryptBlocksFromFile(file *os.File, contentLength int64, encryptor *BlockE
 			isPartialFirst := block == startBlock && offsetWithinFirstBlock > 0
 			isPartialLast := block == endBlock && resultLen-resultPos < blockDataSize
 
-			// Check plaintext cache first.
-			if ptCache != nil {
-				if cached, ok := ptCache.Get(ptCacheKey(instanceHash, globalBlock)); ok {
-					if !isPartialFirst && !isPartialLast {
-						copy(dst[resultPos:], cached[:blockDataSize])
-						resultPos += blockDataSize
-					} else {
-						dataStart := 0
-						dataEnd := len(cached)
-						if isPartialFirst {
-							dataStart = int(offsetWithinFirstBlock)
-						}
-						if isPartialLast {
-							remaining := resultLen - resultPos
-							if dataEnd-dataStart > remaining {
-								dataEnd = dataStart + remaining
-							}
-						}
-						copy(dst[resultPos:], cached[dataStart:dataEnd])
-						resultPos += dataEnd - dataStart
+			// Check the memory tier first.
+			if cached, ok := tier.get(instanceHash, globalBlock); ok {
+				dataStart := 0
+				dataEnd := blockDataSize
+				if isPartialFirst {
+					dataStart = int(offsetWithinFirstBlock)
+				}
+				if isPartialLast {
+					remaining := resultLen - resultPos
+					if dataEnd-dataStart > remaining {
+						dataEnd = dataStart + remaining
 					}
-					continue
 				}
+				copy(dst[resultPos:], cached[dataStart:dataEnd])
+				tier.served(dataEnd - dataStart)
+				resultPos += dataEnd - dataStart
+				continue
 			}
 
 			if !isPartialFirst && !isPartialLast {
@@ -1612,10 +1764,8 @@ func decryptBlocksFromFile(file *os.File, contentLength int64, encryptor *BlockE
 				if err != nil {
 					return 0, errors.Wrapf(err, "failed to decrypt block %d", globalBlock)
 				}
-				if ptCache != nil {
-					entry := make([]byte, blockDataSize)
-					copy(entry, dst[resultPos:resultPos+blockDataSize])
-					ptCache.Set(ptCacheKey(instanceHash, globalBlock), entry, int64(BlockDataSize))
+				if admit {
+					tier.put(instanceHash, globalBlock, dst[resultPos:resultPos+blockDataSize])
 				}
 				resultPos += blockDataSize
 			} else {
@@ -1625,10 +1775,8 @@ func decryptBlocksFromFile(file *os.File, contentLength int64, encryptor *BlockE
 					return 0, errors.Wrapf(err, "failed to decrypt block %d", globalBlock)
 				}
 
-				if ptCache != nil {
-					entry := make([]byte, len(decrypted))
-					copy(entry, decrypted)
-					ptCache.Set(ptCacheKey(instanceHash, globalBlock), entry, int64(BlockDataSize))
+				if admit {
+					tier.put(instanceHash, globalBlock, decrypted)
 				}
 
 				dataStart := 0
@@ -1673,8 +1821,13 @@ func (sm *StorageManager) readBlocksChunked(instanceHash InstanceHash, meta *Cac
 
 // readBlocksChunkedInto reads blocks from a chunked object directly into dst.
 // It iterates over the chunks that overlap the requested range and delegates
-// each chunk's I/O to decryptBlocksFromFile.
+// each chunk's I/O to decryptBlocksFromFile.  Deduplicated objects, chunked
+// or not, are read from the block pool instead.
 func (sm *StorageManager) readBlocksChunkedInto(instanceHash InstanceHash, meta *CacheMetadata, encryptor *BlockEncryptor, dst []byte, startOffset int64) (int, error) {
+	if meta.Dedup {
+		return sm.readDedupInto(instanceHash, meta, dst, startOffset)
+	}
+
 	endOffset := startOffset + int64(len(dst))
 	if endOffset > meta.ContentLength {
 		endOffset = meta.ContentLength
@@ -1715,7 +1868,7 @@ func (sm *StorageManager) readBlocksChunkedInto(instanceHash InstanceHash, meta
 
 		n, err := decryptBlocksFromFile(file, chunkContentLen, encryptor,
 			dst[resultPos:resultPos+int(readLen)], chunkLocalOffset,
-			sm.ptCache, instanceHash, globalBlockNum0)
+			sm.memTier, instanceHash, globalBlockNum0, meta.Compression)
 		if err != nil {
 			return 0, err
 		}
@@ -1743,6 +1896,9 @@ func (sm *StorageManager) IdentifyCorruptBlocks(instanceHash InstanceHash, start
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get block state")
 	}
+	if meta.Dedup {
+		return sm.identifyCorruptDedupBlocks(instanceHash, meta, blockState, startBlock, endBlock)
+	}
 
 	// Try the cached FD first; fall back to a direct open so we can
 	// detect "file missing" as a special case.
@@ -1763,12 +1919,27 @@ func (sm *StorageManager) IdentifyCorruptBlocks(instanceHash InstanceHash, start
 
 	totalBlocks := CalculateBlockCount(meta.ContentLength)
 	var corrupt []uint32
+	// validFrames caches, per unit of a compressed object, whether the unit
+	// holds a frame that decodes; such a unit's blocks are all intact.
+	validFrames := make(map[uint32]bool)
 
 	for block := startBlock; block <= endBlock; block++ {
 		if !blockState.Contains(block) {
 			continue
 		}
 
+		if unitStart := block - block%compressionUnitBlocks; meta.Compression != CompressionNone && unitIsFull(unitStart, meta.ContentLength) {
+			valid, seen := validFrames[unitStart]
+			if !seen {
+				plain, _ := readFrame(file, encryptor, unitStart, 0)
+				valid = plain != nil
+				validFrames[unitStart] = valid
+			}
+			if valid {
+				continue
+			}
+		}
+
 		readSize := BlockTotalSize
 		if block == totalBlocks-1 {
 			lastBlockDataSize := int(meta.ContentLength % BlockDataSize)
@@ -1892,6 +2063,9 @@ func (sm *StorageManager) Delete(instanceHash InstanceHash) error {
 	if meta != nil && meta.IsDisk() {
 		sm.deleteChunkFiles(instanceHash, meta.ContentLength, meta.StorageID, meta.ChunkSizeCode, meta.ChunkLocations)
 	}
+	if meta != nil && meta.Dedup {
+		sm.releaseDedupBlocks(instanceHash)
+	}
 
 	return nil
 }
@@ -1952,7 +2126,12 @@ func (sm *StorageManager) EvictByLRU(storageID StorageID, namespaceID NamespaceI
 		// so the caller can log which objects were involved in a conflict.
 		return evicted, 0, skipped, errors.Wrap(err, "failed to evict objects by LRU")
 	}
+	return evicted, sm.removeEvicted(evicted), skipped, nil
+}
 
+// removeEvicted releases the in-memory state and on-disk data of objects whose
+// records have already been deleted by an eviction, returning the bytes freed.
+func (sm *StorageManager) removeEvicted(evicted []evictedObject) uint64 {
 	var totalFreed uint64
 	for _, obj := range evicted {
 		// Use PerDirectoryBytes to compute the actual on-disk size
@@ -1975,9 +2154,22 @@ func (sm *StorageManager) EvictByLRU(storageID StorageID, namespaceID NamespaceI
 		if obj.storageID != StorageIDInline {
 			sm.deleteChunkFiles(obj.instanceHash, obj.contentLen, obj.storageID, obj.chunkSizeCode, obj.chunkLocations)
 		}
+		if obj.dedup {
+			sm.releaseDedupBlocks(obj.instanceHash)
+		}
 	}
+	return totalFreed
+}
 
-	return evicted, totalFreed, skipped, nil
+// EvictInOrder is EvictByLRU with the eviction order chosen by the caller
+// (typically an EvictionPolicy) rather than by the LRU index.  Objects under a
+// live reader are spared, exactly as for EvictByLRU.
+func (sm *StorageManager) EvictInOrder(storageID StorageID, namespaceID NamespaceID, order []InstanceHash, maxObjects int, maxBytes int64) ([]evictedObject, uint64, int, error) {
+	evicted, skipped, err := sm.db.EvictInOrder(storageID, namespaceID, order, maxObjects, maxBytes, sm.pins.isPinned)
+	if err != nil {
+		return evicted, 0, skipped, errors.Wrap(err, "failed to evict objects in policy order")
+	}
+	return evicted, sm.removeEvicted(evicted), skipped, nil
 }
 
 // GetObjectSize returns the content length of a cached object
@@ -2042,6 +2234,8 @@ func (sm *StorageManager) NewObjectReader(instanceHash InstanceHash) (*ObjectRea
 		return nil, errors.New("object not found")
 	}
 
+	sm.memTier.recordAccess(instanceHash)
+
 	reader := &ObjectReader{
 		sm:           sm,
 		instanceHash: instanceHash,
@@ -2128,9 +2322,10 @@ func (r *ObjectReader) readDiskDirect(dst []byte, off int64) (int, error) {
 		return 0, errors.New("blocks not yet downloaded")
 	}
 
-	if meta.IsChunked() {
-		// Chunked objects need to open multiple chunk files; use the
-		// "into" variant that writes directly into dst with pooled readBuf.
+	if meta.IsChunked() || meta.Dedup {
+		// Chunked objects need to open multiple chunk files, and
+		// deduplicated ones read from the block pool; use the "into"
+		// variant that writes directly into dst with pooled readBuf.
 		return r.sm.readBlocksChunkedInto(r.instanceHash, meta, encryptor, dst[:actualLen], off)
 	}
 
@@ -2142,7 +2337,7 @@ func (r *ObjectReader) readDiskDirect(dst []byte, off int64) (int, error) {
 // into dst.  It delegates to decryptBlocksFromFile which uses a pooled read
 // buffer.
 func (r *ObjectReader) readSimpleInto(dst []byte, off int64) (int, error) {
-	return decryptBlocksFromFile(r.file.File(), r.meta.ContentLength, r.encryptor, dst, off, r.sm.ptCache, r.instanceHash, 0)
+	return decryptBlocksFromFile(r.file.File(), r.meta.ContentLength, r.encryptor, dst, off, r.sm.memTier, r.instanceHash, 0, r.meta.Compression)
 }
 
 // Read implements io.Reader
@@ -2360,6 +2555,15 @@ type BlockWriter struct {
 	batchStart uint32 // first block number in the current batch
 	batchCount uint32 // number of blocks in the current batch
 
+	// For objects stored with block compression, plaintext blocks are
+	// gathered in unitBuf (starting at block unitFirst) instead, so that a
+	// full unit can be compressed as one.  See flushUnit.  slack
+	// accumulates the space the written frames leave unused, which is
+	// credited back to the usage counters on Close.
+	unitBuf   []byte
+	unitFirst uint32
+	slack     int64
+
 	mu         sync.Mutex
 	closed     bool
 	onComplete func() // Called when all blocks are written
@@ -2481,6 +2685,9 @@ func (bw *BlockWriter) writeCurrentBlock() error {
 	if len(bw.buffer) == 0 {
 		return nil
 	}
+	if bw.meta.Compression != CompressionNone || bw.meta.Dedup {
+		return bw.bufferUnitBlock()
+	}
 
 	// Check if this block already exists — first the static snapshot
 	// (cheap), then the live shared state which other concurrent writers
@@ -2532,11 +2739,84 @@ func (bw *BlockWriter) writeCurrentBlock() error {
 	return nil
 }
 
+// bufferUnitBlock is writeCurrentBlock for a compressed or deduplicated
+// object: the block is added to unitBuf, which is written out when the unit
+// is complete, when the object ends, or when a block that already exists
+// breaks the run.
+func (bw *BlockWriter) bufferUnitBlock() error {
+	alreadyExists := bw.bitmap != nil && bw.bitmap.Contains(bw.currentBlock)
+	if !alreadyExists && bw.sharedState != nil {
+		alreadyExists = bw.sharedState.Contains(bw.currentBlock)
+	}
+
+	if alreadyExists {
+		if err := bw.flushUnit(); err != nil {
+			return err
+		}
+	} else {
+		if len(bw.unitBuf) == 0 {
+			if bw.unitBuf == nil {
+				bw.unitBuf = make([]byte, 0, compressionUnitSize)
+			}
+			bw.unitFirst = bw.currentBlock
+		}
+		bw.unitBuf = append(bw.unitBuf, bw.buffer...)
+		lastOfUnit := (bw.currentBlock+1)%compressionUnitBlocks == 0
+		if lastOfUnit || bw.currentBlock+1 == bw.totalBlocks || len(bw.buffer) < BlockDataSize {
+			if err := bw.flushUnit(); err != nil {
+				return err
+			}
+		}
+	}
+
+	bw.buffer = bw.buffer[:0]
+	bw.currentBlock++
+	return nil
+}
+
+// flushUnit writes the blocks gathered in unitBuf, compressing them if they
+// make up a full unit, and marks them downloaded.  A unit flushed before it
+// is complete (by Flush, for streaming readers) is stored uncompressed.  A
+// deduplicated object's unit goes to the block pool as one batch.
+func (bw *BlockWriter) flushUnit() error {
+	if len(bw.unitBuf) == 0 {
+		return nil
+	}
+	var err error
+	if bw.meta.Dedup {
+		err = bw.sm.storeDedupBlocks(bw.instanceHash, bw.meta.NamespaceID, bw.meta.StorageID, bw.unitFirst, bw.unitBuf)
+	} else {
+		unlock := bw.sm.lockUnits(bw.instanceHash)
+		var slack int64
+		slack, err = bw.sm.writeCompressible(bw.file.File(), bw.encryptor, bw.meta.Compression, 0, bw.unitFirst, bw.unitBuf)
+		unlock()
+		bw.slack += slack
+	}
+	if err != nil {
+		return err
+	}
+
+	endBlock := bw.unitFirst + uint32((len(bw.unitBuf)+BlockDataSize-1)/BlockDataSize) - 1
+	if err := bw.sm.db.MarkBlocksDownloaded(bw.instanceHash, bw.unitFirst, endBlock, bw.meta.StorageID, bw.meta.NamespaceID, bw.meta.ContentLength); err != nil {
+		return errors.Wrapf(err, "failed to mark blocks %d–%d as downloaded", bw.unitFirst, endBlock)
+	}
+	if bw.sharedState != nil {
+		for block := bw.unitFirst; block <= endBlock; block++ {
+			bw.sharedState.Add(block)
+		}
+	}
+	bw.unitBuf = bw.unitBuf[:0]
+	return nil
+}
+
 // flushWriteBatch writes the accumulated encrypted blocks to disk in a
 // single WriteAt call, marks them as downloaded in the database, and
 // updates the shared in-memory block state so that concurrent readers
 // see the new blocks immediately.
 func (bw *BlockWriter) flushWriteBatch() error {
+	if err := bw.flushUnit(); err != nil {
+		return err
+	}
 	if bw.batchCount == 0 {
 		return nil
 	}
@@ -2609,6 +2889,15 @@ func (bw *BlockWriter) Close() error {
 		return errors.Wrap(err, "failed to flush write batch")
 	}
 
+	// Credit the space left unused by compressed units in one update
+	// rather than one per unit.
+	if bw.slack != 0 {
+		if err := bw.sm.db.CreditCompressedSlack(bw.instanceHash, 0, bw.slack); err != nil {
+			log.Warnf("Failed to credit space saved by compression: %v", err)
+		}
+		bw.slack = 0
+	}
+
 	// For unknown-size (chunked) transfers the file was allocated with
 	// size 0 and grew via block writes; truncate to the exact final size
 	// now that ContentLength is known.  For known-size transfers the file
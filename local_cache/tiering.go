@@ -0,0 +1,467 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+// Storage tiers.
+//
+// A storage directory may be labelled "fast" (the default) or "slow".  When
+// both tiers are configured, new objects are placed on the fast tier only, and
+// a fast directory that crosses its high-water mark migrates its coldest
+// objects to the slow tier, in the order its eviction policy would have evicted
+// them.  Migration runs in its own goroutine, since copying files is slow and
+// must not hold up eviction of the other directories.  A fast directory is
+// evicted from outright only when the slow tier is full or the directory has
+// outrun migration past its maximum size; the slow tier evicts as any
+// directory does.  Without a slow directory none of this runs and placement is
+// exactly as before.
+//
+// Migration copies each chunk file that lives on the fast tier to a slow
+// directory, then records the new locations in a single transaction that first
+// checks the object's layout has not changed underneath it.  Only complete,
+// unpinned objects are moved: a writer would race the copy, and a reader
+// holding the old metadata would go looking for the old files.  A reader that
+// arrives between the pin check and the commit is the same race
+// DeleteIfUnpinned accepts, except that here the old files are kept until the
+// reader is gone rather than removed at once.  Files orphaned by a crash
+// mid-migration are in the wrong directory for their metadata, which the
+// consistency checker already cleans up.
+
+package local_cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/utils"
+)
+
+// StorageTier labels a storage directory as fast or slow storage.
+type StorageTier string
+
+const (
+	// StorageTierFast is the tier new objects are placed on.  Directories
+	// without a tier label belong to it.
+	StorageTierFast StorageTier = "fast"
+	// StorageTierSlow holds objects migrated off the fast tier.
+	StorageTierSlow StorageTier = "slow"
+)
+
+// ParseStorageTier parses a tier label.  The empty string is the fast tier.
+func ParseStorageTier(s string) (StorageTier, error) {
+	switch StorageTier(strings.ToLower(strings.TrimSpace(s))) {
+	case "", StorageTierFast:
+		return StorageTierFast, nil
+	case StorageTierSlow:
+		return StorageTierSlow, nil
+	default:
+		return "", errors.Errorf("unknown storage tier %q (expected %q or %q)", s, StorageTierFast, StorageTierSlow)
+	}
+}
+
+var (
+	tierMigratedObjects = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_tier_migrated_objects_total",
+		Help: "Objects moved from the fast storage tier to the slow tier instead of being evicted",
+	})
+	tierMigratedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_tier_migrated_bytes_total",
+		Help: "On-disk bytes moved from the fast storage tier to the slow tier",
+	})
+	tierMigrationErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_tier_migration_errors_total",
+		Help: "Object migrations between storage tiers that failed and were rolled back",
+	})
+)
+
+// migrationCopyBufSize is the buffer used to copy chunk files.  Runs of
+// zeroes this long are skipped rather than written, which keeps the holes
+// in sparse (compressed) chunk files.
+const migrationCopyBufSize = 1 << 20
+
+// retiredChunks holds the files of migrated objects that were pinned when the
+// migration committed.  They are removed once the reader is gone.  The zero
+// value is ready to use.
+type retiredChunks struct {
+	mu    sync.Mutex
+	paths map[InstanceHash][]string
+}
+
+func (rc *retiredChunks) add(h InstanceHash, paths []string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.paths == nil {
+		rc.paths = make(map[InstanceHash][]string)
+	}
+	rc.paths[h] = append(rc.paths[h], paths...)
+}
+
+// MigrateObject moves every chunk of an object that lives on a directory
+// accepted by isSource into the target directory.  It returns the on-disk
+// bytes moved off each source directory; an empty result means the object
+// was not eligible (missing, inline, deduplicated, incomplete, or in use)
+// and nothing changed.  On error the object is left where it was.
+func (sm *StorageManager) MigrateObject(instanceHash InstanceHash, isSource func(StorageID) bool, target StorageID) (map[StorageID]int64, error) {
+	if _, ok := sm.dirs[target]; !ok {
+		return nil, errors.Errorf("unknown storage directory %d", target)
+	}
+	if sm.pins.isPinned(instanceHash) {
+		return nil, nil
+	}
+	if _, appending := sm.liveAppends.Load(instanceHash); appending {
+		return nil, nil
+	}
+	meta, err := sm.db.GetMetadata(instanceHash)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get metadata")
+	}
+	if meta == nil || !meta.IsDisk() || meta.Dedup || meta.Completed.IsZero() {
+		return nil, nil
+	}
+
+	var chunks []int
+	var oldPaths, newPaths []string
+	moved := make(map[StorageID]int64)
+	removeCopies := func() {
+		for _, p := range newPaths {
+			if err := removeFileWithRetry(p); err != nil && !os.IsNotExist(err) {
+				log.Warnf("Failed to remove partial migration copy %s: %v", p, err)
+			}
+		}
+	}
+	for _, ci := range meta.GetChunkInfo() {
+		if ci.StorageID == StorageIDInline || ci.StorageID == target || !isSource(ci.StorageID) {
+			continue
+		}
+		src := sm.getChunkPath(ci.StorageID, instanceHash, ci.Index)
+		dst := sm.getChunkPath(target, instanceHash, ci.Index)
+		newPaths = append(newPaths, dst)
+		if err := copyChunkFile(src, dst); err != nil {
+			removeCopies()
+			return nil, errors.Wrapf(err, "failed to copy chunk %d", ci.Index)
+		}
+		chunks = append(chunks, ci.Index)
+		oldPaths = append(oldPaths, src)
+		moved[ci.StorageID] += CalculateFileSize(ci.Size)
+	}
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+
+	if err := sm.db.RelocateChunks(instanceHash, meta, chunks, target); err != nil {
+		removeCopies()
+		return nil, err
+	}
+
+	// Cached metadata, encryptors and descriptors all point at the old files.
+	sm.invalidateObjectCaches(instanceHash, meta.ChunkCount())
+
+	if sm.pins.isPinned(instanceHash) {
+		sm.retired.add(instanceHash, oldPaths)
+	} else {
+		for _, p := range oldPaths {
+			if err := removeFileWithRetry(p); err != nil && !os.IsNotExist(err) {
+				log.Warnf("Failed to remove migrated chunk file %s: %v", p, err)
+			}
+		}
+	}
+	return moved, nil
+}
+
+// reapRetiredChunks removes the old files of migrated objects whose readers
+// have since finished.
+func (sm *StorageManager) reapRetiredChunks() {
+	sm.retired.mu.Lock()
+	var reap []string
+	for h, paths := range sm.retired.paths {
+		if sm.pins.isPinned(h) {
+			continue
+		}
+		reap = append(reap, paths...)
+		delete(sm.retired.paths, h)
+	}
+	sm.retired.mu.Unlock()
+
+	for _, p := range reap {
+		if err := removeFileWithRetry(p); err != nil && !os.IsNotExist(err) {
+			log.Warnf("Failed to remove migrated chunk file %s: %v", p, err)
+		}
+	}
+}
+
+// copyChunkFile copies src to dst, creating dst's parent directories, and
+// syncs the copy before returning.  Holes are preserved.
+func copyChunkFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := createFile(dst)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, migrationCopyBufSize)
+	zero := make([]byte, migrationCopyBufSize)
+	var size int64
+	for {
+		n, rerr := io.ReadFull(in, buf)
+		if n > 0 {
+			if !bytes.Equal(buf[:n], zero[:n]) {
+				if _, err := out.WriteAt(buf[:n], size); err != nil {
+					out.Close()
+					return err
+				}
+			}
+			size += int64(n)
+		}
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			break
+		}
+		if rerr != nil {
+			out.Close()
+			return rerr
+		}
+	}
+	if err := out.Truncate(size); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// isTiered reports whether both tiers are configured, which is what turns on
+// fast-tier placement and migration.
+func (em *EvictionManager) isTiered() bool {
+	var fast, slow bool
+	for _, limits := range em.dirLimits {
+		if limits.tier == StorageTierSlow {
+			slow = true
+		} else {
+			fast = true
+		}
+	}
+	return fast && slow
+}
+
+// isFastTier reports whether a directory belongs to the fast tier.
+func (em *EvictionManager) isFastTier(sid StorageID) bool {
+	limits, ok := em.dirLimits[sid]
+	return ok && limits.tier != StorageTierSlow
+}
+
+// placementDirs returns the directories new objects may be placed on.  With
+// tiering that is the fast tier, unless every fast directory is already over
+// its high-water mark -- migration has fallen behind -- in which case new
+// objects may go anywhere rather than forcing evictions.
+func (em *EvictionManager) placementDirs() []StorageID {
+	if !em.tiered {
+		return em.dirIDs
+	}
+	var fast []StorageID
+	roomy := false
+	for _, sid := range em.dirIDs {
+		if !em.isFastTier(sid) {
+			continue
+		}
+		fast = append(fast, sid)
+		if em.dirUsage[sid].Load() < int64(em.dirLimits[sid].highWater) {
+			roomy = true
+		}
+	}
+	if !roomy {
+		return em.dirIDs
+	}
+	return fast
+}
+
+// migrationTarget picks the slow directory with the most room below its
+// maximum size.  Returns false when the slow tier is full.
+func (em *EvictionManager) migrationTarget() (StorageID, bool) {
+	var best StorageID
+	var bestFree int64
+	for _, sid := range em.dirIDs {
+		if em.isFastTier(sid) {
+			continue
+		}
+		free := int64(em.dirLimits[sid].maxSize) - em.dirUsage[sid].Load()
+		if free > bestFree {
+			best, bestFree = sid, free
+		}
+	}
+	return best, bestFree > 0
+}
+
+// migrationPassDuration bounds a single pass over a fast directory, so that
+// the other fast directories get their turn.
+const migrationPassDuration = 30 * time.Second
+
+// migrationLoop migrates cold objects off the fast tier, checking every
+// so often and whenever eviction finds a fast directory over its high-water
+// mark.
+func (em *EvictionManager) migrationLoop(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			em.migrateTiers()
+		case <-em.migrateChan:
+			em.migrateTiers()
+		}
+	}
+}
+
+// triggerMigration asks migrationLoop for a pass
+func (em *EvictionManager) triggerMigration() {
+	select {
+	case em.migrateChan <- struct{}{}:
+	default:
+		// Migration already pending
+	}
+}
+
+// migrateTiers moves cold objects off each fast directory that is over its
+// high-water mark.
+func (em *EvictionManager) migrateTiers() {
+	for _, sid := range em.dirIDs {
+		limits := em.dirLimits[sid]
+		if !em.isFastTier(sid) {
+			continue
+		}
+		usage := em.getDirUsage(sid)
+		if usage <= 0 || uint64(usage) <= limits.highWater {
+			continue
+		}
+		rl := log.WithField("storageID", sid)
+		moved, count := em.migrateColdObjects(rl, sid, limits, time.Now().Add(migrationPassDuration))
+		if count > 0 {
+			rl.WithFields(log.Fields{
+				"migratedBytes":   utils.HumanBytes(moved),
+				"migratedObjects": count,
+			}).Info("Migrated cold objects to slow tier")
+		}
+	}
+}
+
+// migrateColdObjects moves the coldest objects of a fast directory to the
+// slow tier until the directory is back at its low-water mark, the slow tier
+// is full, or the pass has run too long.  Namespaces are visited greediest
+// first, and within each the objects are taken in eviction-policy order.
+// Returns the bytes moved off the directory and the number of objects moved.
+func (em *EvictionManager) migrateColdObjects(rl *log.Entry, sid StorageID, limits *dirEvictionLimits, deadline time.Time) (uint64, int) {
+	em.storage.reapRetiredChunks()
+
+	nsUsage, err := em.db.GetDirUsage(sid)
+	if err != nil {
+		rl.WithField("storageID", sid).WithError(err).Warn("Failed to get namespace usage for migration")
+		return 0, 0
+	}
+	namespaces := make([]NamespaceID, 0, len(nsUsage))
+	for ns, usage := range nsUsage {
+		if usage > 0 {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Slice(namespaces, func(i, j int) bool { return nsUsage[namespaces[i]] > nsUsage[namespaces[j]] })
+
+	usage := em.getDirUsage(sid)
+	var movedBytes uint64
+	var movedObjects int
+	for _, ns := range namespaces {
+		if usage <= int64(limits.lowWater) || time.Now().After(deadline) {
+			break
+		}
+		order, err := em.policyOrder(sid, ns)
+		if err == nil && order == nil {
+			var candidates []EvictionCandidate
+			candidates, _, err = em.db.ListLRUCandidates(sid, ns, evictionCandidateLimit)
+			order = make([]InstanceHash, len(candidates))
+			for i, c := range candidates {
+				order[i] = c.InstanceHash
+			}
+		}
+		if err != nil {
+			rl.WithFields(log.Fields{"storageID": sid, "namespaceID": ns}).WithError(err).Warn("Failed to list migration candidates")
+			continue
+		}
+
+		for _, hash := range order {
+			if usage <= int64(limits.lowWater) || time.Now().After(deadline) {
+				break
+			}
+			target, ok := em.migrationTarget()
+			if !ok {
+				rl.WithField("storageID", sid).Debug("Slow storage tier is full; falling back to eviction")
+				return movedBytes, movedObjects
+			}
+			moved, err := em.storage.MigrateObject(hash, em.isFastTier, target)
+			if err != nil {
+				tierMigrationErrors.Inc()
+				rl.WithFields(log.Fields{"object": string(hash), "target": target}).WithError(err).Debug("Failed to migrate object")
+				continue
+			}
+			if len(moved) == 0 {
+				continue
+			}
+
+			var total int64
+			for src, n := range moved {
+				if counter, ok := em.dirUsage[src]; ok {
+					counter.Add(-n)
+				}
+				if src == sid {
+					usage -= n
+					movedBytes += uint64(n)
+				}
+				total += n
+			}
+			em.NoteUsageIncrease(target, total)
+			em.policyFor(sid, ns).RecordEviction(hash)
+			em.migratedObjects.Add(1)
+			em.migratedBytes.Add(uint64(total))
+			tierMigratedObjects.Inc()
+			tierMigratedBytes.Add(float64(total))
+			movedObjects++
+			rl.WithFields(log.Fields{
+				"object":      string(hash),
+				"bytes":       total,
+				"namespaceID": ns,
+				"target":      target,
+			}).Debug("Migrated object to slow tier")
+		}
+	}
+	return movedBytes, movedObjects
+}
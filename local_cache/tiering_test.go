@@ -0,0 +1,196 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"crypto/rand"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStorageDirsTier(t *testing.T) {
+	cfgs, err := ParseStorageDirsValue([]interface{}{
+		map[string]interface{}{"Path": "/nvme"},
+		map[string]interface{}{"Path": "/hdd", "Tier": "Slow"},
+	}, "LocalCache.StorageDirs")
+	require.NoError(t, err)
+	require.Len(t, cfgs, 2)
+	assert.Equal(t, StorageTier(""), cfgs[0].Tier)
+	assert.Equal(t, StorageTierSlow, cfgs[1].Tier)
+
+	_, err = ParseStorageDirsValue([]interface{}{
+		map[string]interface{}{"Path": "/tape", "Tier": "glacial"},
+	}, "LocalCache.StorageDirs")
+	assert.ErrorContains(t, err, "LocalCache.StorageDirs[0].Tier")
+}
+
+// New objects go to the fast tier until it is full, then anywhere.
+func TestTieredPlacement(t *testing.T) {
+	em := NewEvictionManager(nil, nil, EvictionConfig{
+		DirConfigs: map[StorageID]EvictionDirConfig{
+			1: {MaxSize: 1000, HighWaterBytes: 900, LowWaterBytes: 800},
+			2: {MaxSize: 1000, HighWaterBytes: 900, LowWaterBytes: 800, Tier: StorageTierSlow},
+		},
+	})
+	for range 2 * rrTableSize {
+		require.Equal(t, StorageID(1), em.ChooseDiskStorage())
+	}
+
+	em.dirUsage[1].Store(950)
+	em.rebuildRRTable()
+	seen := make(map[StorageID]bool)
+	for range rrTableSize {
+		seen[em.ChooseDiskStorage()] = true
+	}
+	assert.True(t, seen[2], "a full fast tier should spill new objects to the slow tier")
+}
+
+// A fast directory over its high-water mark moves its least recently used
+// objects to the slow tier instead of evicting them.
+func TestTierMigration(t *testing.T) {
+	db, sm := newAppendTestStorage(t, 2)
+	ids := sm.DirIDs()
+	fast, slow := ids[0], ids[1]
+	const nsID NamespaceID = 1
+
+	size := 10 * BlockDataSize
+	onDisk := uint64(CalculateFileSize(int64(size)))
+	var hashes []InstanceHash
+	contents := make(map[InstanceHash][]byte)
+	for range 3 {
+		hash := InstanceHash(randomHexForTest(t, 32))
+		data := make([]byte, size)
+		_, err := rand.Read(data)
+		require.NoError(t, err)
+		_, err = sm.InitDiskStorage(t.Context(), hash, int64(size), fast, nsID)
+		require.NoError(t, err)
+		require.NoError(t, sm.WriteBlocks(hash, 0, data))
+		require.NoError(t, db.UpdateLRU(hash, 0))
+		time.Sleep(2 * time.Millisecond)
+		hashes = append(hashes, hash)
+		contents[hash] = data
+	}
+
+	em := NewEvictionManager(db, sm, EvictionConfig{
+		DirConfigs: map[StorageID]EvictionDirConfig{
+			fast: {MaxSize: 3 * onDisk, HighWaterBytes: 2 * onDisk, LowWaterBytes: onDisk},
+			slow: {MaxSize: 100 * onDisk, Tier: StorageTierSlow},
+		},
+	})
+	em.recalculateDirUsage()
+
+	// Eviction leaves the fast directory to migration, which it only
+	// triggers, while the slow tier has room.
+	em.checkAndEvict()
+	for i, hash := range hashes {
+		meta, err := sm.GetMetadata(hash)
+		require.NoError(t, err)
+		require.NotNil(t, meta, "object %d should not have been evicted", i)
+		assert.Equal(t, fast, meta.StorageID, "object %d", i)
+	}
+
+	// Keep the oldest object busy; migration must pass it over.
+	unpin := sm.PinObject(hashes[0])
+	em.migrateTiers()
+	unpin()
+
+	for i, hash := range hashes {
+		meta, err := sm.GetMetadata(hash)
+		require.NoError(t, err)
+		require.NotNil(t, meta, "object %d should not have been evicted", i)
+		want := slow
+		if i == 0 {
+			want = fast
+		}
+		assert.Equal(t, want, meta.StorageID, "object %d", i)
+
+		got, err := sm.ReadBlocks(hash, 0, size)
+		require.NoError(t, err)
+		assert.Equal(t, contents[hash], got, "object %d", i)
+
+		_, err = os.Stat(sm.getObjectPathForDir(fast, hash))
+		assert.Equal(t, i == 0, err == nil, "object %d fast-tier file", i)
+	}
+
+	assert.Equal(t, int64(onDisk), em.getDirUsage(fast))
+	assert.Equal(t, int64(2*onDisk), em.getDirUsage(slow))
+	stats := em.GetStats()
+	assert.Equal(t, uint64(2), stats.MigratedObjects)
+	assert.Equal(t, 2*onDisk, stats.MigratedBytes)
+	assert.Equal(t, StorageTierSlow, stats.DirStats[slow].Tier)
+
+	// The LRU entry moved with the object, so the slow tier can evict it.
+	candidates, _, err := db.ListLRUCandidates(slow, nsID, 0)
+	require.NoError(t, err)
+	assert.Len(t, candidates, 2)
+}
+
+// An object read while being migrated keeps its old files until the reader
+// is done.
+func TestTierMigrationRetiresPinnedFiles(t *testing.T) {
+	_, sm := newAppendTestStorage(t, 2)
+	ids := sm.DirIDs()
+	fast, slow := ids[0], ids[1]
+
+	data := make([]byte, 3*BlockDataSize)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	hash := InstanceHash(randomHexForTest(t, 32))
+	_, err = sm.InitDiskStorage(t.Context(), hash, int64(len(data)), fast, NamespaceID(1))
+	require.NoError(t, err)
+	require.NoError(t, sm.WriteBlocks(hash, 0, data))
+
+	reader, err := sm.NewObjectReader(hash)
+	require.NoError(t, err)
+	// Drop the pin so the migration goes ahead, then take it back as a
+	// reader arriving between the pin check and the commit would.
+	reader.unpin()
+	var repin func()
+	moved, err := sm.MigrateObject(hash, func(sid StorageID) bool {
+		if repin == nil {
+			repin = sm.PinObject(hash)
+		}
+		return sid == fast
+	}, slow)
+	require.NoError(t, err)
+	assert.Equal(t, CalculateFileSize(int64(len(data))), moved[fast])
+
+	oldPath := sm.getObjectPathForDir(fast, hash)
+	_, err = os.Stat(oldPath)
+	require.NoError(t, err, "old file must survive while pinned")
+
+	got := make([]byte, len(data))
+	n, err := reader.ReadAt(got, 0)
+	if err != io.EOF {
+		require.NoError(t, err)
+	}
+	assert.Equal(t, len(data), n)
+	assert.Equal(t, data, got)
+	require.NoError(t, reader.Close())
+
+	repin()
+	sm.reapRetiredChunks()
+	_, err = os.Stat(oldPath)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,800 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+// Cache warmup
+//
+// A cache whose disk has just been replaced starts cold, and the prestage
+// API only helps if someone names every object.  A warmup job refills the
+// cache from a list of popular objects instead:
+//
+//   - The list comes from Cache.WarmupSources (by default the sibling caches
+//     of Cache.PeerCaches).  A sibling cache with Cache.EnableWarmupExport
+//     exports its most recently used objects at /api/v1.0/cache/popular; any
+//     other popularity feed can serve the same JSON.  The export answers only
+//     administrators and callers with a token issued by the federation (as
+//     every cache's federation token is) or by the cache itself, lists only
+//     objects the token's read scopes cover, and is rate limited, since each
+//     request scans the cache's metadata.  Lists from several sources are
+//     interleaved, each source's most popular first, and duplicates dropped.
+//
+//   - The list is saved in the database before any object is fetched, and
+//     the job's progress every few seconds, so a restart resumes the job
+//     where it left off rather than asking the sources again.  The saved
+//     progress is the index below which every object has been handled;
+//     objects handled out of order beyond it are simply fetched again after
+//     a restart, which for a cached object is a metadata lookup.
+//
+//   - Objects are fetched in list order through the prestage worker pool,
+//     a few at a time (Cache.WarmupConcurrency), and paced by their listed
+//     size to Cache.WarmupRate so that warming does not crowd out clients.
+//     They are fetched with the token in Cache.WarmupTokenLocation, if any;
+//     objects it may not read are skipped.  That token is also presented to
+//     popularity feeds, and the cache's federation token to sibling caches,
+//     in both cases only over https.  Since every listed object is by
+//     definition popular, it is let past the admission frequency filter.
+//
+// Only one job runs at a time.  It is started by an administrator through
+// the cache's web API, or at startup by Cache.WarmupOnStart when the
+// database has no record of any job.
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/token"
+	"github.com/pelicanplatform/pelican/token_scopes"
+	"github.com/pelicanplatform/pelican/utils"
+	"github.com/pelicanplatform/pelican/web_ui"
+)
+
+const (
+	// warmupExportPath is where a cache exports its most recently used objects
+	warmupExportPath = "/api/v1.0/cache/popular"
+	// warmupIdentity is the prestage queue warmup requests are made under
+	warmupIdentity = "cache-warmup"
+	// warmupListBatch is how many list entries are read from the database at once
+	warmupListBatch = 256
+	// warmupSaveInterval is how often a running job's progress is saved
+	warmupSaveInterval = 5 * time.Second
+	// warmupSourceTimeout bounds how long a source has to return its list
+	warmupSourceTimeout = 2 * time.Minute
+	// defaultPopularLimit and maxPopularLimit bound the export's length
+	defaultPopularLimit = 1000
+	maxPopularLimit     = 100000
+	// warmupExportInterval and warmupExportBurst rate limit the export,
+	// which is only asked for when a sibling cache starts a warmup job.
+	warmupExportInterval = 10 * time.Second
+	warmupExportBurst    = 3
+)
+
+// Outcomes of warming one object, as counted in the metrics
+const (
+	warmupResultFetched = "fetched"
+	warmupResultCached  = "cached"
+	warmupResultSkipped = "skipped"
+	warmupResultFailed  = "failed"
+	// warmupResultInterrupted means the job stopped before the object was
+	// handled; it is retried when the job resumes and is not counted.
+	warmupResultInterrupted = "interrupted"
+)
+
+var (
+	warmupObjects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_cache_warmup_objects_total",
+		Help: "Total number of objects handled by cache warmup jobs, by result (fetched, cached, skipped, failed)",
+	}, []string{"result"})
+	warmupBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_cache_warmup_bytes_total",
+		Help: "Total bytes fetched into the cache by warmup jobs",
+	})
+)
+
+// errWarmupRunning is returned when a warmup job is started while another runs
+var errWarmupRunning = errors.New("a warmup job is already running")
+
+// PopularObject is one entry of a popularity list, most popular first
+type PopularObject struct {
+	Path       string    `json:"path" msgpack:"p"`
+	Size       int64     `json:"size" msgpack:"s"`
+	LastAccess time.Time `json:"-" msgpack:"-"`
+}
+
+// WarmupJob records a cache warmup job and its progress.  Next is the
+// index into the job's list below which every object has been handled.
+type WarmupJob struct {
+	Sources   []string  `json:"sources" msgpack:"src"`
+	Started   time.Time `json:"started" msgpack:"st"`
+	Finished  time.Time `json:"finished" msgpack:"fin"`
+	Cancelled bool      `json:"cancelled,omitempty" msgpack:"cx,omitempty"`
+	Error     string    `json:"error,omitempty" msgpack:"err,omitempty"`
+	Total     int       `json:"total" msgpack:"tot"`
+	Next      int       `json:"next" msgpack:"nx"`
+	Fetched   int       `json:"fetched" msgpack:"f"`
+	Cached    int       `json:"already_cached" msgpack:"c"`
+	Skipped   int       `json:"skipped" msgpack:"sk"`
+	Failed    int       `json:"failed" msgpack:"fl"`
+	Bytes     int64     `json:"bytes" msgpack:"b"`
+}
+
+// WarmupStatus reports the current or most recent warmup job
+type WarmupStatus struct {
+	Running bool       `json:"running"`
+	Job     *WarmupJob `json:"job,omitempty"`
+}
+
+// WarmupConfig configures cache warmup jobs
+type WarmupConfig struct {
+	// Sources are sibling cache base URLs or popularity feed URLs
+	Sources []string
+	// OnStart runs a job at startup if the database has never had one
+	OnStart bool
+	// MaxObjects is the longest list requested from each source
+	MaxObjects int
+	// BytesPerSecond paces the job; 0 means no limit
+	BytesPerSecond uint64
+	// Concurrency is the number of objects fetched at once
+	Concurrency int
+	// TokenFile holds the token presented to sources and used for fetching
+	TokenFile string
+}
+
+// warmupConfigFromParams builds the warmup configuration from the
+// Cache.Warmup* parameters.
+func warmupConfigFromParams() (WarmupConfig, error) {
+	cfg := WarmupConfig{
+		Sources:     param.Cache_WarmupSources.GetStringSlice(),
+		OnStart:     param.Cache_WarmupOnStart.GetBool(),
+		MaxObjects:  param.Cache_WarmupMaxObjects.GetInt(),
+		Concurrency: param.Cache_WarmupConcurrency.GetInt(),
+		TokenFile:   param.Cache_WarmupTokenLocation.GetString(),
+	}
+	if len(cfg.Sources) == 0 {
+		cfg.Sources = param.Cache_PeerCaches.GetStringSlice()
+	}
+	if rateStr := param.Cache_WarmupRate.GetString(); rateStr != "" && rateStr != "0" {
+		bps, err := utils.ParseBytes(rateStr)
+		if err != nil {
+			return cfg, errors.Wrapf(err, "failed to parse %s", param.Cache_WarmupRate.GetName())
+		}
+		cfg.BytesPerSecond = bps
+	}
+	return cfg, nil
+}
+
+// warmupManager runs cache warmup jobs, one at a time
+type warmupManager struct {
+	pc         *PersistentCache
+	cfg        WarmupConfig
+	httpClient *http.Client
+	// exportLimiter rate limits the export of this cache's popular objects
+	exportLimiter *rate.Limiter
+
+	mu     sync.Mutex
+	job    *WarmupJob         // the running or most recent job
+	cancel context.CancelFunc // non-nil while a job runs
+	done   chan struct{}      // closed when the running job stops
+}
+
+func newWarmupManager(pc *PersistentCache, cfg WarmupConfig) *warmupManager {
+	if cfg.MaxObjects <= 0 {
+		cfg.MaxObjects = 10000
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	return &warmupManager{
+		pc:            pc,
+		cfg:           cfg,
+		httpClient:    &http.Client{Transport: config.GetTransport(), Timeout: warmupSourceTimeout},
+		exportLimiter: rate.NewLimiter(rate.Every(warmupExportInterval), warmupExportBurst),
+	}
+}
+
+// resume restarts an unfinished job after a restart, or runs the startup
+// job requested by Cache.WarmupOnStart.  It is called once the cache's
+// authorization configuration is loaded.
+func (wm *warmupManager) resume() {
+	job, err := wm.pc.db.GetWarmupJob()
+	if err != nil {
+		log.Warnf("Failed to read the saved cache warmup job: %v", err)
+		return
+	}
+	switch {
+	case job != nil && job.Finished.IsZero():
+		log.Infof("Resuming cache warmup job at object %d of %d", job.Next, job.Total)
+		wm.mu.Lock()
+		defer wm.mu.Unlock()
+		if wm.cancel == nil {
+			wm.launch(job, false)
+		}
+	case job == nil && wm.cfg.OnStart && len(wm.cfg.Sources) > 0:
+		log.Info("Starting cache warmup of a new cache")
+		if err := wm.Start(nil); err != nil {
+			log.Warnf("Failed to start cache warmup: %v", err)
+		}
+	}
+}
+
+// Start begins a warmup job from the given sources, or from the configured
+// ones if sources is empty.  The list is fetched in the background.
+func (wm *warmupManager) Start(sources []string) error {
+	if len(sources) == 0 {
+		sources = wm.cfg.Sources
+	}
+	if len(sources) == 0 {
+		return errors.New("no warmup sources given or configured")
+	}
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	if wm.cancel != nil {
+		return errWarmupRunning
+	}
+	wm.launch(&WarmupJob{Sources: sources, Started: time.Now()}, true)
+	return nil
+}
+
+// launch runs a job in the background.  fresh jobs fetch their list first.
+// Callers must hold mu.
+func (wm *warmupManager) launch(job *WarmupJob, fresh bool) {
+	ctx, cancel := context.WithCancel(wm.pc.ctx)
+	wm.job = job
+	wm.cancel = cancel
+	wm.done = make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		defer cancel()
+		wm.run(ctx, job, fresh)
+		wm.mu.Lock()
+		wm.cancel = nil
+		wm.mu.Unlock()
+	}(wm.done)
+}
+
+// Cancel stops the running job and marks it finished.  It reports whether
+// a job was running.
+func (wm *warmupManager) Cancel() bool {
+	wm.mu.Lock()
+	if wm.cancel == nil {
+		wm.mu.Unlock()
+		return false
+	}
+	wm.job.Cancelled = true
+	cancel, done := wm.cancel, wm.done
+	wm.mu.Unlock()
+	cancel()
+	<-done
+	return true
+}
+
+// stop interrupts the running job, if any, leaving it to resume at the next
+// startup.
+func (wm *warmupManager) stop() {
+	wm.mu.Lock()
+	cancel, done := wm.cancel, wm.done
+	wm.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Status returns a copy of the running or most recent job
+func (wm *warmupManager) Status() (WarmupStatus, error) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	status := WarmupStatus{Running: wm.cancel != nil}
+	if wm.job != nil {
+		job := *wm.job
+		status.Job = &job
+		return status, nil
+	}
+	job, err := wm.pc.db.GetWarmupJob()
+	status.Job = job
+	return status, err
+}
+
+// readToken reads the token warmup uses, if one is configured
+func (wm *warmupManager) readToken() (string, error) {
+	if wm.cfg.TokenFile == "" {
+		return "", nil
+	}
+	contents, err := os.ReadFile(wm.cfg.TokenFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", param.Cache_WarmupTokenLocation.GetName())
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
+
+// run carries out a job: fetching and saving its list if fresh, then
+// prestaging each object from job.Next on
+func (wm *warmupManager) run(ctx context.Context, job *WarmupJob, fresh bool) {
+	finish := func(errMsg string) {
+		wm.mu.Lock()
+		defer wm.mu.Unlock()
+		if errMsg != "" {
+			job.Error = errMsg
+		}
+		job.Finished = time.Now()
+		if err := wm.pc.db.FinishWarmupJob(job); err != nil {
+			log.Warnf("Failed to save the finished cache warmup job: %v", err)
+		}
+	}
+
+	token, err := wm.readToken()
+	if err != nil {
+		log.Warnf("Cache warmup: %v", err)
+		finish(err.Error())
+		return
+	}
+
+	if fresh {
+		objects, err := wm.fetchList(ctx, job.Sources, token, wm.pc.getFedToken())
+		if err == nil && len(objects) == 0 {
+			err = errors.New("the warmup sources listed no objects")
+		}
+		if err != nil {
+			log.Warnf("Cache warmup: %v", err)
+			finish(err.Error())
+			return
+		}
+		wm.mu.Lock()
+		job.Total = len(objects)
+		err = wm.pc.db.StartWarmupJob(job, objects)
+		wm.mu.Unlock()
+		if err != nil {
+			log.Warnf("Failed to save the cache warmup list: %v", err)
+			finish(err.Error())
+			return
+		}
+		log.Infof("Cache warmup: warming %d objects from %s", len(objects), strings.Join(job.Sources, ", "))
+	}
+
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if wm.cfg.BytesPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(wm.cfg.BytesPerSecond), int(min(wm.cfg.BytesPerSecond, 1<<30)))
+	}
+
+	// handled holds the indices beyond job.Next that are done
+	handled := make(map[int]bool)
+	lastSave := time.Now()
+	record := func(index int, result string, bytes int64) {
+		if result == warmupResultInterrupted {
+			return
+		}
+		warmupObjects.WithLabelValues(result).Inc()
+		warmupBytes.Add(float64(bytes))
+
+		wm.mu.Lock()
+		defer wm.mu.Unlock()
+		switch result {
+		case warmupResultFetched:
+			job.Fetched++
+		case warmupResultCached:
+			job.Cached++
+		case warmupResultSkipped:
+			job.Skipped++
+		case warmupResultFailed:
+			job.Failed++
+		}
+		job.Bytes += bytes
+		handled[index] = true
+		for handled[job.Next] {
+			delete(handled, job.Next)
+			job.Next++
+		}
+		if time.Since(lastSave) >= warmupSaveInterval {
+			lastSave = time.Now()
+			if err := wm.pc.db.SaveWarmupJob(job); err != nil {
+				log.Warnf("Failed to save cache warmup progress: %v", err)
+			}
+		}
+	}
+
+	sem := make(chan struct{}, wm.cfg.Concurrency)
+	var wg sync.WaitGroup
+	wm.mu.Lock()
+	next := job.Next
+	wm.mu.Unlock()
+dispatch:
+	for ctx.Err() == nil {
+		objects, err := wm.pc.db.ListWarmupEntries(next, warmupListBatch)
+		if err != nil {
+			log.Warnf("Failed to read the cache warmup list: %v", err)
+			break
+		}
+		if len(objects) == 0 {
+			break
+		}
+		for i, obj := range objects {
+			if err := waitForBytes(ctx, limiter, obj.Size); err != nil {
+				break dispatch
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				break dispatch
+			}
+			wg.Add(1)
+			go func(index int, obj PopularObject) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				result, bytes := wm.warmObject(ctx, obj, token)
+				record(index, result, bytes)
+			}(next+i, obj)
+		}
+		next += len(objects)
+	}
+	wg.Wait()
+
+	wm.mu.Lock()
+	cancelled := job.Cancelled
+	wm.mu.Unlock()
+	if ctx.Err() != nil && !cancelled {
+		// Shutting down: keep the job to resume at the next startup
+		wm.mu.Lock()
+		if err := wm.pc.db.SaveWarmupJob(job); err != nil {
+			log.Warnf("Failed to save cache warmup progress: %v", err)
+		}
+		wm.mu.Unlock()
+		return
+	}
+	finish("")
+	log.WithFields(log.Fields{
+		"fetched":       job.Fetched,
+		"alreadyCached": job.Cached,
+		"skipped":       job.Skipped,
+		"failed":        job.Failed,
+		"bytes":         utils.HumanBytes(job.Bytes),
+		"cancelled":     cancelled,
+	}).Info("Cache warmup finished")
+}
+
+// waitForBytes paces the job by an object's size, in pieces no larger than
+// the limiter's burst
+func waitForBytes(ctx context.Context, limiter *rate.Limiter, size int64) error {
+	if limiter.Limit() == rate.Inf {
+		return ctx.Err()
+	}
+	burst := int64(limiter.Burst())
+	for size > 0 {
+		n := min(size, burst)
+		if err := limiter.WaitN(ctx, int(n)); err != nil {
+			return err
+		}
+		size -= n
+	}
+	return ctx.Err()
+}
+
+// warmObject fetches one object into the cache through the prestage pool
+// and returns the outcome and the bytes fetched
+func (wm *warmupManager) warmObject(ctx context.Context, obj PopularObject, token string) (string, int64) {
+	pc := wm.pc
+	if pc.IsFullyCached(ctx, obj.Path, token) {
+		return warmupResultCached, 0
+	}
+	if ok, _ := pc.ac.authorize(token_scopes.Wlcg_Storage_Read, obj.Path, token); !ok {
+		return warmupResultSkipped, 0
+	}
+	pc.admission.notePopular(pc.db.ObjectHash(pc.normalizePath(obj.Path)))
+
+	req := newPrestageRequest(obj.Path, token)
+	for !pc.prestageManager.Submit(warmupIdentity, req) {
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return warmupResultInterrupted, 0
+		}
+	}
+	for {
+		status := req.WaitFor(time.Second)
+		if status > 0 {
+			switch {
+			case status < 300:
+				return warmupResultFetched, req.progress
+			case status == http.StatusUnauthorized || status == http.StatusForbidden || status == http.StatusNotFound:
+				return warmupResultSkipped, 0
+			default:
+				log.Debugf("Cache warmup failed to fetch %s: %d: %s", obj.Path, status, req.message)
+				return warmupResultFailed, 0
+			}
+		}
+		if ctx.Err() != nil {
+			return warmupResultInterrupted, 0
+		}
+	}
+}
+
+// fetchList asks each source for its popular objects and interleaves the
+// lists, dropping duplicates.  It fails only if every source does.  Sibling
+// caches are presented fedToken, other feeds token.
+func (wm *warmupManager) fetchList(ctx context.Context, sources []string, token, fedToken string) ([]PopularObject, error) {
+	var lists [][]PopularObject
+	var lastErr error
+	for _, source := range sources {
+		list, err := wm.fetchSource(ctx, source, token, fedToken)
+		if err != nil {
+			log.Warnf("Cache warmup: failed to get popular objects from %s: %v", source, err)
+			lastErr = err
+			continue
+		}
+		lists = append(lists, list)
+	}
+	if len(lists) == 0 {
+		return nil, errors.Wrap(lastErr, "no warmup source could be read")
+	}
+
+	var merged []PopularObject
+	seen := make(map[string]bool)
+	for rank := 0; ; rank++ {
+		more := false
+		for _, list := range lists {
+			if rank >= len(list) {
+				continue
+			}
+			more = true
+			if obj := list[rank]; obj.Path != "" && !seen[obj.Path] {
+				seen[obj.Path] = true
+				merged = append(merged, obj)
+			}
+		}
+		if !more {
+			return merged, nil
+		}
+	}
+}
+
+// fetchSource retrieves the list of one source.  A source given as a bare
+// cache URL is asked for its export, with the cache's federation token.
+func (wm *warmupManager) fetchSource(ctx context.Context, source, token, fedToken string) ([]PopularObject, error) {
+	sourceURL, err := url.Parse(strings.TrimSpace(source))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid warmup source URL")
+	}
+	if (sourceURL.Scheme != "https" && sourceURL.Scheme != "http") || sourceURL.Host == "" {
+		return nil, errors.Errorf("invalid warmup source URL %q: must be an absolute http(s) URL", source)
+	}
+	if sourceURL.Path == "" || sourceURL.Path == "/" {
+		sourceURL.Path = warmupExportPath
+		token = fedToken
+		if token == "" {
+			return nil, errors.New("the cache has no federation token to present to its sibling")
+		}
+	}
+	if token != "" && sourceURL.Scheme != "https" {
+		return nil, errors.Errorf("invalid warmup source URL %q: must be an https URL, since a token is sent to it", source)
+	}
+	query := sourceURL.Query()
+	if !query.Has("limit") {
+		query.Set("limit", strconv.Itoa(wm.cfg.MaxObjects))
+		sourceURL.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := wm.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s", resp.Status)
+	}
+	var list []PopularObject
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, errors.Wrap(err, "failed to decode popular object list")
+	}
+	if len(list) > wm.cfg.MaxObjects {
+		list = list[:wm.cfg.MaxObjects]
+	}
+	return list, nil
+}
+
+// PopularObjects returns up to limit complete cached objects for which
+// mayRead is true, most recently used first
+func (pc *PersistentCache) PopularObjects(limit int, mayRead func(objectPath string) bool) ([]PopularObject, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	top := &popularHeap{}
+	err := pc.db.ScanMetadata(func(_ InstanceHash, meta *CacheMetadata) error {
+		if meta.SourceURL == "" || meta.Completed.IsZero() || meta.ContentLength < 0 {
+			return nil
+		}
+		if top.Len() == limit && !meta.LastAccessTime.After((*top)[0].LastAccess) {
+			return nil
+		}
+		objectPath := meta.SourceURL
+		if u, err := url.Parse(meta.SourceURL); err == nil && u.Scheme != "" {
+			objectPath = u.Path
+		}
+		if !mayRead(objectPath) {
+			return nil
+		}
+		heap.Push(top, PopularObject{Path: objectPath, Size: meta.ContentLength, LastAccess: meta.LastAccessTime})
+		if top.Len() > limit {
+			heap.Pop(top)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scan metadata")
+	}
+
+	objects := make([]PopularObject, top.Len())
+	for i := len(objects) - 1; i >= 0; i-- {
+		objects[i] = heap.Pop(top).(PopularObject)
+	}
+	return objects, nil
+}
+
+// popularHeap is a min-heap of objects by last access, holding the most
+// recently used objects seen so far
+type popularHeap []PopularObject
+
+func (h popularHeap) Len() int           { return len(h) }
+func (h popularHeap) Less(i, j int) bool { return h[i].LastAccess.Before(h[j].LastAccess) }
+func (h popularHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *popularHeap) Push(x any)        { *h = append(*h, x.(PopularObject)) }
+func (h *popularHeap) Pop() any {
+	old := *h
+	obj := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return obj
+}
+
+// popularHandler exports the cache's most recently used objects for the
+// warmup of a sibling cache.  It is only registered with
+// Cache.EnableWarmupExport.
+//
+// GET /api/v1.0/cache/popular?limit=1000
+func (pc *PersistentCache) popularHandler(c *gin.Context) {
+	mayRead, ok := authorizeExport(c)
+	if !ok {
+		return
+	}
+	if !pc.warmup.exportLimiter.Allow() {
+		c.Header("Retry-After", strconv.Itoa(int(warmupExportInterval/time.Second)))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "the popular object list was requested too often; try again later"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPopularLimit)))
+	if err != nil || limit <= 0 {
+		limit = defaultPopularLimit
+	}
+	limit = min(limit, maxPopularLimit)
+
+	objects, err := pc.PopularObjects(limit, mayRead)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, objects)
+}
+
+// authorizeExport admits administrators, who may see every object, and
+// callers with a token issued by the federation or by this cache, who see
+// the objects its storage.read scopes cover.  Anyone else is turned away and
+// ok is false.
+func authorizeExport(c *gin.Context) (mayRead func(objectPath string) bool, ok bool) {
+	if user, userId, groups, err := web_ui.GetUserGroups(c); user != "" && err == nil {
+		identity := web_ui.UserIdentity{Username: user, ID: userId, Groups: groups, Sub: c.GetString("OIDCSub")}
+		if isAdmin, _ := web_ui.CheckAdmin(identity); isAdmin {
+			return func(string) bool { return true }, true
+		}
+	}
+
+	result, status, ok, err := token.VerifyAndExtract(c, token.AuthOption{
+		Sources: []token.TokenSource{token.Header},
+		Issuers: []token.TokenIssuer{token.FederationIssuer, token.LocalIssuer},
+	})
+	if !ok || result.Token == nil {
+		if status == 0 || status == http.StatusOK {
+			status = http.StatusUnauthorized
+		}
+		msg := "a token issued by the federation or administrator access is required"
+		if err != nil {
+			msg += ": " + err.Error()
+		}
+		c.JSON(status, gin.H{"error": msg})
+		return nil, false
+	}
+	scopes := token_scopes.ParseResourceScopeString(result.Token)
+	return func(objectPath string) bool {
+		wanted := token_scopes.NewResourceScope(token_scopes.Wlcg_Storage_Read, objectPath)
+		for _, scope := range scopes {
+			if scope.Contains(wanted) {
+				return true
+			}
+		}
+		return false
+	}, true
+}
+
+// warmupStartHandler starts a warmup job.  The optional body names its
+// sources; otherwise Cache.WarmupSources is used.
+//
+// POST /api/v1.0/cache/warmup
+func (pc *PersistentCache) warmupStartHandler(c *gin.Context) {
+	var body struct {
+		Sources []string `json:"sources"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+	}
+	if err := pc.warmup.Start(body.Sources); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errWarmupRunning) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	pc.warmupStatusHandler(c)
+}
+
+// warmupStatusHandler reports the running or most recent warmup job.
+//
+// GET /api/v1.0/cache/warmup
+func (pc *PersistentCache) warmupStatusHandler(c *gin.Context) {
+	status, err := pc.warmup.Status()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// warmupCancelHandler stops the running warmup job.
+//
+// DELETE /api/v1.0/cache/warmup
+func (pc *PersistentCache) warmupCancelHandler(c *gin.Context) {
+	if !pc.warmup.Cancel() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no warmup job is running"})
+		return
+	}
+	pc.warmupStatusHandler(c)
+}
@@ -0,0 +1,131 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package local_cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func servePopularList(t *testing.T, wantPath, wantToken string, list []PopularObject) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, wantPath, r.URL.Path)
+		assert.Equal(t, "3", r.URL.Query().Get("limit"))
+		assert.Equal(t, "Bearer "+wantToken, r.Header.Get("Authorization"))
+		require.NoError(t, json.NewEncoder(w).Encode(list))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Lists from several sources are interleaved by rank without duplicates; a
+// bare cache URL is asked for its export, with the federation token.
+func TestWarmupFetchList(t *testing.T) {
+	sibling := servePopularList(t, warmupExportPath, "fed-token", []PopularObject{
+		{Path: "/ns/a", Size: 1}, {Path: "/ns/b", Size: 2}, {Path: "/ns/c", Size: 3},
+	})
+	feed := servePopularList(t, "/feed.json", "warmup-token", []PopularObject{
+		{Path: "/ns/b", Size: 2}, {Path: "/ns/d", Size: 4},
+	})
+
+	wm := newWarmupManager(nil, WarmupConfig{MaxObjects: 3})
+	wm.httpClient.Transport = sibling.Client().Transport
+	list, err := wm.fetchList(t.Context(), []string{sibling.URL, feed.URL + "/feed.json", "https://127.0.0.1:1/"}, "warmup-token", "fed-token")
+	require.NoError(t, err)
+	var paths []string
+	for _, obj := range list {
+		paths = append(paths, obj.Path)
+	}
+	assert.Equal(t, []string{"/ns/a", "/ns/b", "/ns/d", "/ns/c"}, paths)
+
+	_, err = wm.fetchList(t.Context(), []string{"https://127.0.0.1:1/"}, "", "fed-token")
+	assert.Error(t, err)
+
+	// Tokens are never sent in the clear, and a sibling is not asked
+	// without a federation token.
+	_, err = wm.fetchSource(t.Context(), "http://cache2.example.com/", "", "fed-token")
+	assert.ErrorContains(t, err, "must be an https URL")
+	_, err = wm.fetchSource(t.Context(), "http://feed.example.com/feed.json", "warmup-token", "")
+	assert.ErrorContains(t, err, "must be an https URL")
+	_, err = wm.fetchSource(t.Context(), sibling.URL, "warmup-token", "")
+	assert.ErrorContains(t, err, "no federation token")
+}
+
+// The export turns away callers with neither a token nor an admin login.
+func TestWarmupExportRequiresAuthorization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, warmupExportPath, nil)
+
+	_, ok := authorizeExport(c)
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+// A job's list survives in the database until the job finishes, and can be
+// read from where the job left off.
+func TestWarmupJobRecord(t *testing.T) {
+	db, _ := newAppendTestStorage(t, 1)
+
+	job, err := db.GetWarmupJob()
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	objects := make([]PopularObject, 5)
+	for i := range objects {
+		objects[i] = PopularObject{Path: "/ns/" + string(rune('a'+i)), Size: int64(i)}
+	}
+	job = &WarmupJob{Sources: []string{"https://cache2"}, Started: time.Now().Truncate(time.Second), Total: len(objects)}
+	require.NoError(t, db.StartWarmupJob(job, objects))
+
+	job.Next = 3
+	job.Fetched = 3
+	require.NoError(t, db.SaveWarmupJob(job))
+	saved, err := db.GetWarmupJob()
+	require.NoError(t, err)
+	assert.True(t, saved.Finished.IsZero())
+	assert.Equal(t, 3, saved.Next)
+	rest, err := db.ListWarmupEntries(saved.Next, warmupListBatch)
+	require.NoError(t, err)
+	assert.Equal(t, objects[3:], rest)
+
+	// A new job replaces the old list entirely
+	require.NoError(t, db.StartWarmupJob(&WarmupJob{Total: 2}, objects[:2]))
+	rest, err = db.ListWarmupEntries(0, warmupListBatch)
+	require.NoError(t, err)
+	assert.Equal(t, objects[:2], rest)
+
+	job.Finished = time.Now()
+	require.NoError(t, db.FinishWarmupJob(job))
+	rest, err = db.ListWarmupEntries(0, warmupListBatch)
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+	saved, err = db.GetWarmupJob()
+	require.NoError(t, err)
+	assert.False(t, saved.Finished.IsZero())
+}
@@ -24,9 +24,10 @@ import (
 )
 
 type (
-	MetricSimpleStatus    string
-	DirectorFTXTestStatus MetricSimpleStatus
-	DirectorStatResult    string
+	MetricSimpleStatus      string
+	DirectorFTXTestStatus   MetricSimpleStatus
+	DirectorStatResult      string
+	DirectorStatCacheResult string
 )
 
 const (
@@ -39,6 +40,10 @@ const (
 	StatCancelled  DirectorStatResult = "Cancelled"
 	StatForbidden  DirectorStatResult = "Forbidden"
 	StatUnknownErr DirectorStatResult = "UnknownErr"
+
+	StatCacheHitPresent DirectorStatCacheResult = "hit_present"
+	StatCacheHitAbsent  DirectorStatCacheResult = "hit_absent"
+	StatCacheMiss       DirectorStatCacheResult = "miss"
 )
 
 var (
@@ -82,6 +87,16 @@ var (
 		Help: "The total stat queries the director issues. The status can be Succeeded, Cancelled, Timeout, Forbidden, or UnknownErr",
 	}, []string{"server_name", "server_url", "server_type", "result", "cached_result"}) // result: see enums for DirectorStatResult
 
+	PelicanDirectorStatCacheLookupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_director_stat_cache_lookups_total",
+		Help: "The number of object location lookups made against the director's per-server stat cache. The result is hit_present, hit_absent or miss",
+	}, []string{"server_name", "server_type", "result"})
+
+	PelicanDirectorStatCacheInvalidationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_director_stat_cache_invalidations_total",
+		Help: "The number of times the director discarded a server's cached object locations because the server's advertisement changed. The reason is restart or namespaces",
+	}, []string{"server_name", "server_type", "reason"})
+
 	PelicanDirectorServerCount = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "pelican_director_server_count",
 		Help: "The number of servers currently recognized by the Director, delineated by pelican/non-pelican and origin/cache",
@@ -151,4 +166,29 @@ var (
 		Help: "The total number of requests made by a service (cache, origin, etc.) to fetch federation metadata hosted by the Director. " +
 			"Can be used to detect misconfigured servers, as federations with non-Director discovery URLs should generally not be discovering federation info via the Director",
 	}, []string{"network", "service_type"})
+
+	PelicanDirectorCacheProbeTTFB = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pelican_director_cache_probe_ttfb_seconds",
+		Help: "The EWMA-smoothed time to first byte measured by the Director's probes of each cache",
+	}, []string{"server_name", "server_url"})
+
+	PelicanDirectorCacheProbeThroughput = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pelican_director_cache_probe_throughput_bytes_per_second",
+		Help: "The EWMA-smoothed throughput measured by the Director's probes of each cache",
+	}, []string{"server_name", "server_url"})
+
+	PelicanDirectorCacheProbesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_director_cache_probes_total",
+		Help: "The total number of probes the Director has run against each cache",
+	}, []string{"server_name", "status"}) // status is a MetricSimpleStatus
+
+	PelicanDirectorTransferFeedbackTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_director_transfer_feedback_total",
+		Help: "The total number of transfer attempts through each cache reported to the Director by clients",
+	}, []string{"server_name", "status", "error_class"}) // status is a MetricSimpleStatus; error_class is empty on success
+
+	PelicanDirectorCacheReportedErrorRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pelican_director_cache_reported_error_rate",
+		Help: "The fraction of transfer attempts through each cache that clients reported as failed over the feedback window",
+	}, []string{"server_name", "server_url"})
 )
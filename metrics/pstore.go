@@ -66,11 +66,12 @@ import (
 )
 
 // Label values for the `pass` label on the scheduled-pass metrics below.
-// They name the three loops in pstore that run work on a schedule.
+// They name the loops in pstore that run work on a schedule.
 const (
 	PStorePassIndexCheck     = "index_check"
 	PStorePassDataScan       = "data_scan"
 	PStorePassMetadataBackup = "metadata_backup"
+	PStorePassDataBackup     = "data_backup"
 )
 
 // Label values for the `tier` label on PStoreWritesTotal.  They are the three
@@ -103,6 +104,14 @@ const (
 	PStoreFsckUsageDrift        = "usage_drift_directories"
 )
 
+// Label values for the `trigger` label on PStoreErasureShardsRebuiltTotal:
+// what found the lost or corrupt shard.
+const (
+	PStoreErasureTriggerRead    = "read"
+	PStoreErasureTriggerScan    = "scan"
+	PStoreErasureTriggerRebuild = "rebuild"
+)
+
 // PStoreDirectoryInline is the `directory` label value for the pseudo-directory
 // that holds objects stored inline in the catalog rather than in a storage
 // directory.  It is deliberately not a path: inline objects live wherever the
@@ -227,6 +236,13 @@ var (
 			"collapse in this against a store that is still growing is the shape of " +
 			"a backup that succeeds while capturing nothing.",
 	})
+
+	PStoreDataBackupBytesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_pstore_data_backup_bytes_total",
+		Help: "Bytes of object data covered by pstore object-data backups, by whether " +
+			"they were uploaded or were already held by the target from an earlier " +
+			"backup (result=\"uploaded\" or \"shared\").",
+	}, []string{"result"})
 )
 
 // ---------------------------------------------------------------------------
@@ -325,6 +341,30 @@ var (
 	})
 )
 
+// ---------------------------------------------------------------------------
+// Versioning
+// ---------------------------------------------------------------------------
+
+// Retained versions hold space exactly as current ones do, but nothing else
+// reports them: `du` and the object count describe what the namespace shows.
+// A retention policy set too generously for a directory that is rewritten
+// constantly is a capacity leak that looks like an ordinary full store.
+var (
+	// Set by the prune sweep, which is the one pass that walks every retained
+	// version anyway, so it is as of the last sweep.
+	PStoreRetainedVersions = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pelican_pstore_retained_versions",
+		Help: "Superseded or deleted object versions the pstore is keeping under a " +
+			"versioning policy (Origin.PStoreVersioning), as of the last prune sweep.",
+	})
+
+	PStoreVersionsPrunedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_pstore_versions_pruned_total",
+		Help: "Retained object versions that aged or were pushed out of their " +
+			"directory's versioning policy and were handed to the janitor.",
+	})
+)
+
 // ---------------------------------------------------------------------------
 // Write path
 // ---------------------------------------------------------------------------
@@ -360,3 +400,101 @@ var (
 			"PUTs rather than answering them with a transaction conflict.",
 	})
 )
+
+// ---------------------------------------------------------------------------
+// Replication
+// ---------------------------------------------------------------------------
+
+// A secondary is only worth failing over to if it is close behind, and
+// nothing on the secondary can say how close: it knows what it has applied,
+// not what the primary has committed since.  So lag is published by the
+// primary, which knows both ends, and refreshed whenever the shipper runs --
+// at least once a poll interval while the secondary is unreachable, which is
+// exactly when the figure matters.
+var (
+	PStoreReplicationLagRecords = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pelican_pstore_replication_lag_records",
+		Help: "Committed mutations a replication primary has logged that its secondary " +
+			"has not yet confirmed applying.",
+	})
+
+	// Seconds rather than a timestamp, because the question is "how much would
+	// a failover lose right now", and an idle primary with nothing unshipped is
+	// zero behind however long ago it last wrote.
+	PStoreReplicationLagSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pelican_pstore_replication_lag_seconds",
+		Help: "Age of the oldest mutation a replication primary has logged that its " +
+			"secondary has not yet confirmed applying; zero when it is caught up.",
+	})
+
+	PStoreReplicationShippedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_pstore_replication_shipped_total",
+		Help: "Log records a replication primary's secondary confirmed applying, " +
+			"including those sent by a full resynchronization.",
+	})
+
+	PStoreReplicationFullSyncsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_pstore_replication_full_syncs_total",
+		Help: "Full resynchronizations a replication primary started, because its " +
+			"secondary was new, followed another primary, or had fallen behind the log.",
+	})
+
+	PStoreReplicationErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_pstore_replication_errors_total",
+		Help: "Shipping rounds a replication primary abandoned because the secondary " +
+			"was unreachable or refused a record; each is retried with backoff.",
+	})
+
+	// A synchronous write that gives up waiting still succeeds -- the
+	// alternative is an origin that stops accepting writes whenever its
+	// secondary is down -- so this is the count of writes a failover at that
+	// moment could have lost.
+	PStoreReplicationSyncTimeoutsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_pstore_replication_sync_timeouts_total",
+		Help: "Writes under synchronous replication acknowledged before the secondary " +
+			"confirmed them, because it did not within Origin.PStoreReplicationSyncTimeout.",
+	})
+
+	// Published by the secondary, so that one whose primary has died still
+	// says how current it is.
+	PStoreReplicationLastAppliedTimestamp = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pelican_pstore_replication_last_applied_timestamp_seconds",
+		Help: "When the mutation a replication secondary most recently applied was " +
+			"committed on its primary, in Unix seconds.",
+	})
+)
+
+// ---------------------------------------------------------------------------
+// Erasure coding
+// ---------------------------------------------------------------------------
+
+// A rebuilt shard is a disk or a block that failed and was absorbed, which is
+// worth seeing even though nothing was lost: a steady rate of them from one
+// directory is the disk announcing itself.  An unrecoverable stripe is the
+// event the layout exists to prevent, and should page someone.
+var (
+	PStoreErasureShardsRebuiltTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pelican_pstore_erasure_shards_rebuilt_total",
+		Help: "Data and parity shards of erasure-coded objects found missing or corrupt " +
+			"and reconstructed from the rest of their stripe, by what found them.",
+	}, []string{"trigger"})
+
+	PStoreErasureUnrecoverableTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pelican_pstore_erasure_unrecoverable_total",
+		Help: "Stripes of erasure-coded objects found with more shards lost than their " +
+			"parity can reconstruct.",
+	})
+)
+
+// ---------------------------------------------------------------------------
+// Object lock
+// ---------------------------------------------------------------------------
+
+// Each one is a client that tried to change data it was not allowed to.  A
+// trickle is a workflow that has not learned about the lock yet; a burst is
+// worth asking about.
+var PStoreRetentionRefusalsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "pelican_pstore_retention_refusals_total",
+	Help: "Writes, renames, restores, and deletes refused because an object was locked " +
+		"by a retention rule or legal hold, by operation.",
+}, []string{"operation"})
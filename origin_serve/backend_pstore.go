@@ -26,8 +26,11 @@ package origin_serve
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -103,11 +106,32 @@ func newPStoreBackend(
 	// opens it and starts its background work.
 	store, opened := openStores[baseDir]
 	if !opened {
+		compression, err := local_cache.ParseCompressionAlgorithm(param.Origin_PStoreBlockCompression.GetString())
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", param.Origin_PStoreBlockCompression.GetName())
+		}
+		versioning, err := pstore.ParseVersioningValue(
+			param.Origin_PStoreVersioning.GetRaw(), param.Origin_PStoreVersioning.GetName())
+		if err != nil {
+			return nil, err
+		}
+		replication, err := pstoreReplicationRole()
+		if err != nil {
+			return nil, err
+		}
 		store, err = pstore.Open(ctx, egrp, pstore.Config{
 			BaseDir:        baseDir,
 			StorageDirs:    dirs,
 			InlineMaxBytes: param.Origin_PStoreInlineMaxBytes.GetInt(),
+			Compression:    local_cache.CompressionConfig{Algorithm: compression},
+			Dedup:          param.Origin_PStoreBlockDedup.GetBool(),
 			NamespaceLabel: baseDir,
+			Versioning:     versioning,
+			Replication:    replication,
+			Erasure: pstore.ErasureConfig{
+				DataShards:   param.Origin_PStoreErasureDataShards.GetInt(),
+				ParityShards: param.Origin_PStoreErasureParityShards.GetInt(),
+			},
 		})
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to open the pstore at %s", baseDir)
@@ -126,12 +150,31 @@ func newPStoreBackend(
 			return nil, kErr
 		}
 		store.StartBackups(ctx, egrp, pstore.BackupConfig{
-			Dir:      param.Origin_PStoreMetadataBackupLocation.GetString(),
-			Interval: param.Origin_PStoreMetadataBackupInterval.GetDuration(),
-			Keep:     param.Origin_PStoreMetadataBackupsToKeep.GetInt(),
-			Keys:     backupKeys,
+			Dir:       param.Origin_PStoreMetadataBackupLocation.GetString(),
+			Interval:  param.Origin_PStoreMetadataBackupInterval.GetDuration(),
+			Keep:      param.Origin_PStoreMetadataBackupsToKeep.GetInt(),
+			FullEvery: param.Origin_PStoreMetadataBackupFullEvery.GetInt(),
+			Keys:      backupKeys,
 		})
 
+		// The object data too, when a target is configured: the metadata
+		// snapshots above are useless once the disks holding the blocks are.
+		if location := param.Origin_PStoreDataBackupLocation.GetString(); location != "" {
+			target, tErr := OpenPStoreBackupTarget(ctx, location)
+			if tErr != nil {
+				return nil, tErr
+			}
+			store.StartDataBackups(ctx, egrp, pstore.DataBackupConfig{
+				Target:   target,
+				Interval: param.Origin_PStoreDataBackupInterval.GetDuration(),
+				DataBackupOptions: pstore.DataBackupOptions{
+					Keys:      backupKeys,
+					FullEvery: param.Origin_PStoreDataBackupFullEvery.GetInt(),
+					Keep:      param.Origin_PStoreDataBackupsToKeep.GetInt(),
+				},
+			})
+		}
+
 		// Check the store against itself on a schedule.  An origin needs this
 		// more than a cache does: a cache that finds a corrupt object
 		// re-fetches it, and an origin has nowhere to re-fetch from.
@@ -141,6 +184,12 @@ func newPStoreBackend(
 			DataScanBytesPerSec: int64(param.Origin_PStoreDataScanRate.GetByteRate()),
 		})
 
+		// Ship committed changes to the secondary, when this is a replication
+		// primary.  A secondary's side is the routes the launcher registers.
+		if rErr := startPStoreReplication(ctx, egrp, store); rErr != nil {
+			return nil, rErr
+		}
+
 		// Close the store when the server context is cancelled.
 		//
 		// Nothing in the origin's shutdown path knows about backends, so
@@ -254,6 +303,56 @@ func (b *pstoreBackend) HasCapacityFor(nBytes int64) error {
 	return b.store.HasCapacityFor(nBytes)
 }
 
+// ListVersions implements server_utils.VersionedBackend.
+func (b *pstoreBackend) ListVersions(relativePath string) ([]server_utils.ObjectVersion, error) {
+	full, err := confineToPrefix(b.storagePrefix, relativePath)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := b.store.Versions(full)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]server_utils.ObjectVersion, len(versions))
+	for i := range versions {
+		out[i] = objectVersionInfo(&versions[i])
+	}
+	return out, nil
+}
+
+// OpenVersion implements server_utils.VersionedBackend.
+func (b *pstoreBackend) OpenVersion(relativePath, etag string) (io.ReadSeekCloser, server_utils.ObjectVersion, error) {
+	full, err := confineToPrefix(b.storagePrefix, relativePath)
+	if err != nil {
+		return nil, server_utils.ObjectVersion{}, err
+	}
+	h, err := b.store.OpenVersion(full, etag)
+	if err != nil {
+		return nil, server_utils.ObjectVersion{}, err
+	}
+	d := h.Dirent()
+	return h, server_utils.ObjectVersion{
+		ETag:    d.ETag(),
+		Size:    d.Size,
+		ModTime: time.Unix(0, d.MTimeNanos),
+	}, nil
+}
+
+func objectVersionInfo(v *pstore.ObjectVersion) server_utils.ObjectVersion {
+	info := server_utils.ObjectVersion{
+		ETag:    v.ETag(),
+		Size:    v.Size,
+		ModTime: v.ModTime(),
+		Deleted: v.Deleted,
+		Current: v.Current,
+	}
+	if !v.Current {
+		superseded := v.Superseded()
+		info.Superseded = &superseded
+	}
+	return info
+}
+
 // pstoreBackupKeys resolves the keys metadata snapshots are sealed to.
 //
 // The store does not read configuration itself -- pstore deliberately does not
@@ -268,12 +367,56 @@ func (b *pstoreBackend) HasCapacityFor(nBytes int64) error {
 // metadata-backup-key <file>` rather than fixed for the whole series.
 func pstoreBackupKeys() (pstore.BackupKeys, error) {
 	issuerKeys := config.GetIssuerPrivateKeys()
-	if len(issuerKeys) == 0 && param.Origin_PStoreMetadataBackupLocation.GetString() != "" {
+	enabled := param.Origin_PStoreMetadataBackupLocation.GetString() != "" ||
+		param.Origin_PStoreDataBackupLocation.GetString() != ""
+	if len(issuerKeys) == 0 && enabled {
 		return pstore.BackupKeys{}, errors.Errorf(
-			"metadata backups are enabled (%s) but the origin has no issuer keys to "+
+			"backups are enabled (%s or %s) but the origin has no issuer keys to "+
 				"derive a backup key from; a snapshot is a logical dump of the namespace "+
 				"and is never written unencrypted",
-			param.Origin_PStoreMetadataBackupLocation.GetName())
+			param.Origin_PStoreMetadataBackupLocation.GetName(),
+			param.Origin_PStoreDataBackupLocation.GetName())
 	}
 	return pstore.BackupKeys{IssuerKeys: issuerKeys}, nil
 }
+
+// OpenPStoreBackupTarget opens a pstore data backup target, reading the S3
+// settings from Origin.PStoreDataBackupS3* when location is an s3:// URL.
+//
+// Shared with the CLI, so that `origin pstore data-backup` and
+// `data-restore` reach a bucket exactly as the running origin does.
+func OpenPStoreBackupTarget(ctx context.Context, location string) (pstore.BackupTarget, error) {
+	s3cfg := pstore.S3TargetConfig{
+		Endpoint:         param.Origin_PStoreDataBackupS3ServiceUrl.GetString(),
+		Region:           param.Origin_PStoreDataBackupS3Region.GetString(),
+		VirtualHostStyle: param.Origin_PStoreDataBackupS3UrlStyle.GetString() == "virtual",
+	}
+	if strings.HasPrefix(location, "s3://") {
+		var err error
+		if s3cfg.AccessKey, err = readPStoreKeyfile(param.Origin_PStoreDataBackupS3AccessKeyfile); err != nil {
+			return nil, err
+		}
+		if s3cfg.SecretKey, err = readPStoreKeyfile(param.Origin_PStoreDataBackupS3SecretKeyfile); err != nil {
+			return nil, err
+		}
+	}
+	target, err := pstore.OpenBackupTarget(ctx, location, s3cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", param.Origin_PStoreDataBackupLocation.GetName())
+	}
+	return target, nil
+}
+
+// readPStoreKeyfile returns the trimmed contents of the file a parameter
+// names, or "" when it is unset.
+func readPStoreKeyfile(p param.StringParam) (string, error) {
+	path := p.GetString()
+	if path == "" {
+		return "", nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", p.GetName())
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -181,6 +181,14 @@ func statusForBackendError(err error, conditional bool) int {
 		// 409 tells a client -- and is a great deal more useful than the 500
 		// an opaque index-transaction error would otherwise produce.
 		return http.StatusConflict
+	case errors.Is(err, pstore.ErrReplica):
+		// Not a permission problem: the same write succeeds on the primary,
+		// and here once this origin is promoted.
+		return http.StatusServiceUnavailable
+	case errors.Is(err, pstore.ErrRetained):
+		// A retention rule or legal hold locks the object.  No other token
+		// will do better, and serveWebDAV says until when in the body.
+		return http.StatusForbidden
 	case errors.Is(err, fs.ErrPermission):
 		// A path refused by the export's containment check, among others.
 		return http.StatusForbidden
@@ -45,6 +45,7 @@ import (
 	"github.com/pelicanplatform/pelican/identity"
 	"github.com/pelicanplatform/pelican/metrics"
 	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/pstore"
 	"github.com/pelicanplatform/pelican/server_structs"
 	"github.com/pelicanplatform/pelican/server_utils"
 	"github.com/pelicanplatform/pelican/ssh_posixv2"
@@ -929,6 +930,8 @@ func exportRequestHandler(backend server_utils.OriginBackend, handler *webdav.Ha
 		// resolves them into a neighbouring export.
 		if isTPCRequest(c.Request) {
 			handleCopyTPC(c, backend, federationPrefix)
+		} else if isVersionRequest(c.Request) {
+			handleVersionRequest(c, backend, newPath)
 		} else if c.Request.Method == http.MethodHead {
 			// For HEAD requests, pass the modified request to the WebDAV
 			// handler (it needs the full URL so its Prefix stripping works
@@ -1160,6 +1163,15 @@ func serveWebDAV(dw *deferredHeaderWriter, handler *webdav.Handler, req *http.Re
 		return
 	}
 	status := statusForBackendError(captured, conditional)
+	var retained *pstore.RetentionError
+	if errors.As(captured, &retained) {
+		// "Forbidden" alone reads as a token problem.  The reason leaves out
+		// the store path, which is not the one the client asked about.
+		log.Infof("Refusing %s %s: %v", req.Method, req.URL.Path, captured)
+		dw.code = status
+		dw.buf = []byte("The object " + retained.Reason())
+		return
+	}
 	if status == 0 || status == dw.code {
 		return
 	}
@@ -0,0 +1,420 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+// Replication between two pstore origins, over HTTPS.
+//
+// The store does the replicating (pstore/replication.go); this is the wire.
+// A primary reads its secondary's position and sends it log records, one per
+// request:
+//
+//	GET  /api/v1.0/origin/pstore/replication/status
+//	POST /api/v1.0/origin/pstore/replication/apply
+//
+// An apply carries the record, base64url-encoded JSON, in the
+// X-Pelican-Replication-Record header and a put's object content as the body,
+// so the content streams from the primary's blocks into the secondary's
+// without either side holding the object in memory.  Both answer with the
+// secondary's position.
+//
+// The primary signs a short-lived token with its own issuer key, scoped
+// pelican.pstore_replicate and addressed to the secondary.  The secondary
+// accepts only tokens from the one issuer it is configured to follow,
+// Origin.PStoreReplicationPrimaryIssuer: these routes rewrite the whole store,
+// and nothing short of the primary's key should reach them.
+
+package origin_serve
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/config"
+	"github.com/pelicanplatform/pelican/param"
+	"github.com/pelicanplatform/pelican/pstore"
+	"github.com/pelicanplatform/pelican/server_structs"
+	"github.com/pelicanplatform/pelican/token"
+	"github.com/pelicanplatform/pelican/token_scopes"
+)
+
+const (
+	// replicationAPIPath is where a secondary mounts the replication routes.
+	replicationAPIPath = "/api/v1.0/origin/pstore/replication"
+
+	// replicationRecordHeader carries an apply's record.
+	replicationRecordHeader = "X-Pelican-Replication-Record"
+
+	// replicationTokenLifetime bounds the primary's token.  It is reused
+	// until a minute before it expires, so one is minted every few minutes
+	// however many records ship.
+	replicationTokenLifetime = 10 * time.Minute
+)
+
+// pstoreReplicationRole reads Origin.PStoreReplicationRole.
+func pstoreReplicationRole() (pstore.ReplicationRole, error) {
+	role, err := pstore.ParseReplicationRole(param.Origin_PStoreReplicationRole.GetString())
+	if err != nil {
+		return role, errors.Wrapf(err, "invalid %s", param.Origin_PStoreReplicationRole.GetName())
+	}
+	return role, nil
+}
+
+// startPStoreReplication starts a primary's shipper.  Anything else has
+// nothing to start: a secondary only answers requests.
+func startPStoreReplication(ctx context.Context, egrp *errgroup.Group, store *pstore.Store) error {
+	if store.ReplicationRole() != pstore.RolePrimary {
+		return nil
+	}
+	secondary := param.Origin_PStoreReplicationSecondaryUrl.GetString()
+	if secondary == "" {
+		return errors.Errorf("%s must be set on a replication primary",
+			param.Origin_PStoreReplicationSecondaryUrl.GetName())
+	}
+	transport, err := newReplicationTransport(secondary)
+	if err != nil {
+		return err
+	}
+	store.StartReplication(ctx, egrp, pstore.ReplicationConfig{
+		Transport:   transport,
+		Synchronous: param.Origin_PStoreReplicationSynchronous.GetBool(),
+		SyncTimeout: param.Origin_PStoreReplicationSyncTimeout.GetDuration(),
+	})
+	log.Infof("Replicating the pstore to %s", secondary)
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Primary: the transport
+// ---------------------------------------------------------------------------
+
+// replicationTransport implements pstore.ReplicationTransport over HTTPS.
+type replicationTransport struct {
+	statusURL string
+	applyURL  string
+	audience  string
+	client    *http.Client
+
+	// mint signs a token; replaced in tests.
+	mint func(audience string) (string, time.Duration, error)
+
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// newReplicationTransport builds the transport to the secondary at base, its
+// web URL.
+func newReplicationTransport(base string) (*replicationTransport, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, errors.Errorf("invalid %s %q: expected the secondary's web URL",
+			param.Origin_PStoreReplicationSecondaryUrl.GetName(), base)
+	}
+	audience, err := token.GetWLCGAudience(base)
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSuffix(baseURL.String(), "/") + replicationAPIPath
+	return &replicationTransport{
+		statusURL: root + "/status",
+		applyURL:  root + "/apply",
+		audience:  audience,
+		// No overall timeout: an apply carries a whole object.  Each request
+		// is bounded by the shipper's context instead.
+		client: &http.Client{Transport: config.GetTransport()},
+		mint:   mintReplicationToken,
+	}, nil
+}
+
+// mintReplicationToken signs a token for the secondary with the origin's
+// issuer key.
+func mintReplicationToken(audience string) (string, time.Duration, error) {
+	tokenCfg := token.NewWLCGToken()
+	tokenCfg.Lifetime = replicationTokenLifetime
+	tokenCfg.Subject = "pstore-replication"
+	tokenCfg.AddAudiences(audience)
+	tokenCfg.AddScopes(token_scopes.Pelican_PstoreReplicate)
+	tok, err := tokenCfg.CreateToken()
+	if err != nil {
+		return "", 0, errors.Wrap(err, "failed to create a pstore replication token")
+	}
+	return tok, tokenCfg.Lifetime, nil
+}
+
+// bearer returns a token with at least a minute left to run.
+func (t *replicationTransport) bearer() (string, error) {
+	t.tokenMu.Lock()
+	defer t.tokenMu.Unlock()
+	if time.Now().Add(time.Minute).Before(t.tokenExpiry) {
+		return t.token, nil
+	}
+	tok, lifetime, err := t.mint(t.audience)
+	if err != nil {
+		return "", err
+	}
+	t.token, t.tokenExpiry = tok, time.Now().Add(lifetime)
+	return tok, nil
+}
+
+// Status implements pstore.ReplicationTransport.
+func (t *replicationTransport) Status(ctx context.Context) (pstore.ReplicaStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.statusURL, nil)
+	if err != nil {
+		return pstore.ReplicaStatus{}, err
+	}
+	return t.do(req)
+}
+
+// Apply implements pstore.ReplicationTransport.
+func (t *replicationTransport) Apply(ctx context.Context, rec *pstore.ReplicationRecord, content io.Reader) (pstore.ReplicaStatus, error) {
+	encoded, err := json.Marshal(rec)
+	if err != nil {
+		return pstore.ReplicaStatus{}, errors.Wrap(err, "failed to encode the replication record")
+	}
+	body := content
+	if body == nil {
+		body = http.NoBody
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.applyURL, body)
+	if err != nil {
+		return pstore.ReplicaStatus{}, err
+	}
+	if content != nil {
+		req.ContentLength = rec.Size
+	}
+	req.Header.Set(replicationRecordHeader, base64.RawURLEncoding.EncodeToString(encoded))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return t.do(req)
+}
+
+// do sends an authenticated request and decodes the secondary's position.
+func (t *replicationTransport) do(req *http.Request) (pstore.ReplicaStatus, error) {
+	var status pstore.ReplicaStatus
+	tok, err := t.bearer()
+	if err != nil {
+		return status, err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return status, errors.Wrapf(err, "failed to reach the replication secondary at %s", req.URL.Host)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return status, errors.Wrap(err, "failed to read the replication secondary's response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiResp server_structs.SimpleApiResp
+		msg := strings.TrimSpace(string(body))
+		if json.Unmarshal(body, &apiResp) == nil && apiResp.Msg != "" {
+			msg = apiResp.Msg
+		}
+		return status, errors.Errorf("the replication secondary answered %d: %s", resp.StatusCode, msg)
+	}
+	if err := json.Unmarshal(body, &status); err != nil {
+		return status, errors.Wrap(err, "failed to decode the replication secondary's position")
+	}
+	return status, nil
+}
+
+// ---------------------------------------------------------------------------
+// Secondary: the receiver
+// ---------------------------------------------------------------------------
+
+// replicationReceiver answers a primary's requests.
+type replicationReceiver struct {
+	store *pstore.Store
+	// issuer is the primary's issuer URL and audience this origin's own, the
+	// two claims a token must carry beyond the scope.
+	issuer   string
+	audience string
+
+	ctx context.Context
+
+	// keys is the primary's public key set, looked up on first use rather
+	// than at startup: a secondary has to come up while its primary is down.
+	keysMu sync.Mutex
+	keys   jwk.Set
+}
+
+// RegisterPStoreReplicationAPI mounts the routes a replication primary ships
+// to, when this origin's store is a secondary.  Any other origin registers
+// nothing, so a primary misconfigured to ship to one gets a plain 404.
+func RegisterPStoreReplicationAPI(ctx context.Context, engine *gin.Engine) error {
+	store := singlePStore()
+	if store == nil || store.ReplicationRole() != pstore.RoleSecondary {
+		return nil
+	}
+	issuer := param.Origin_PStoreReplicationPrimaryIssuer.GetString()
+	if issuer == "" {
+		return errors.Errorf("%s must be set on a replication secondary",
+			param.Origin_PStoreReplicationPrimaryIssuer.GetName())
+	}
+	audience, err := token.GetWLCGAudience(param.Server_ExternalWebUrl.GetString())
+	if err != nil {
+		return errors.Wrap(err, "failed to determine the audience replication tokens must name")
+	}
+	rr := &replicationReceiver{
+		store:    store,
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		audience: audience,
+		ctx:      ctx,
+	}
+	rr.register(engine.Group(replicationAPIPath))
+	log.Infof("Accepting pstore replication from the primary with issuer %s", rr.issuer)
+	return nil
+}
+
+// register mounts the receiver's routes on a group.
+func (rr *replicationReceiver) register(group *gin.RouterGroup) {
+	group.Use(rr.authorize)
+	group.GET("/status", rr.handleStatus)
+	group.POST("/apply", rr.handleApply)
+}
+
+// keySet returns the primary's public keys.
+func (rr *replicationReceiver) keySet() (jwk.Set, error) {
+	rr.keysMu.Lock()
+	defer rr.keysMu.Unlock()
+	if rr.keys != nil {
+		return rr.keys, nil
+	}
+	jwksURL, err := token.LookupIssuerJwksUrl(rr.ctx, rr.issuer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to look up the keys of the replication primary %s", rr.issuer)
+	}
+	cache := jwk.NewCache(rr.ctx)
+	client := &http.Client{Transport: config.GetBasicTransport()}
+	if err := cache.Register(jwksURL.String(), jwk.WithMinRefreshInterval(15*time.Minute),
+		jwk.WithHTTPClient(client)); err != nil {
+		return nil, errors.Wrap(err, "failed to register the replication primary's keys")
+	}
+	rr.keys = jwk.NewCachedSet(cache, jwksURL.String())
+	return rr.keys, nil
+}
+
+// authorize admits only the configured primary.
+func (rr *replicationReceiver) authorize(c *gin.Context) {
+	tok, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || tok == "" {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "Replication requests must carry the primary's bearer token",
+		})
+		return
+	}
+	keys, err := rr.keySet()
+	if err != nil {
+		log.Warnln("Cannot verify a pstore replication request:", err)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "The secondary cannot fetch the primary's keys: " + err.Error(),
+		})
+		return
+	}
+	if _, err := token.VerifyWithKeyset(tok, keys,
+		jwt.WithIssuer(rr.issuer),
+		jwt.WithAudience(rr.audience),
+		jwt.WithValidator(token_scopes.CreateScopeValidator(
+			[]token_scopes.TokenScope{token_scopes.Pelican_PstoreReplicate}, false)),
+	); err != nil {
+		log.Warnln("Rejected a pstore replication request:", err)
+		c.AbortWithStatusJSON(http.StatusForbidden, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    "The token is not the replication primary's: " + err.Error(),
+		})
+		return
+	}
+	c.Next()
+}
+
+func (rr *replicationReceiver) handleStatus(c *gin.Context) {
+	status, err := rr.store.ReplicaStatus()
+	if err != nil {
+		respondReplicationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func (rr *replicationReceiver) handleApply(c *gin.Context) {
+	encoded, err := base64.RawURLEncoding.DecodeString(c.GetHeader(replicationRecordHeader))
+	var rec pstore.ReplicationRecord
+	if err == nil {
+		err = json.NewDecoder(bytes.NewReader(encoded)).Decode(&rec)
+	}
+	if err != nil || rec.Op == "" {
+		c.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+			Status: server_structs.RespFailed,
+			Msg:    fmt.Sprintf("Missing or malformed %s header", replicationRecordHeader),
+		})
+		return
+	}
+
+	var content io.Reader
+	if rec.Op == pstore.ReplicatePut {
+		content = c.Request.Body
+	}
+	status, err := rr.store.ApplyReplicated(c.Request.Context(), &rec, content)
+	if err != nil {
+		respondReplicationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// respondReplicationError reports a failed request to the primary, which logs
+// the message and retries.
+func respondReplicationError(c *gin.Context, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, pstore.ErrPreconditionFailed):
+		// Out of order or from another primary: the shipper asks for the
+		// position again and resumes from there.
+		code = http.StatusPreconditionFailed
+	case errors.Is(err, pstore.ErrNotSupported):
+		code = http.StatusServiceUnavailable
+	case errors.Is(err, pstore.ErrConflict):
+		code = http.StatusConflict
+	}
+	if code == http.StatusInternalServerError {
+		log.Warnln("Failed to apply a pstore replication record:", err)
+	}
+	c.JSON(code, server_structs.SimpleApiResp{
+		Status: server_structs.RespFailed,
+		Msg:    err.Error(),
+	})
+}
@@ -30,15 +30,18 @@ package origin_serve
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jellydator/ttlcache/v3"
@@ -647,6 +650,9 @@ func TestStatusForBackendError(t *testing.T) {
 		"and an ordinary conflict is left to the WebDAV handler")
 	assert.Equal(t, http.StatusForbidden,
 		statusForBackendError(&os.PathError{Op: "open", Path: "/x", Err: os.ErrPermission}, false))
+	assert.Equal(t, http.StatusForbidden,
+		statusForBackendError(&os.PathError{Op: "remove", Path: "/x",
+			Err: &pstore.RetentionError{Path: "/x", Lock: pstore.ObjectLock{Locked: true, HoldPath: "/"}}}, false))
 	assert.Equal(t, 0, statusForBackendError(nil, false))
 	assert.Equal(t, 0, statusForBackendError(errors.New("something unrecognized"), false),
 		"an unrecognized error must not be given a made-up status")
@@ -896,3 +902,116 @@ func TestGetDigestsRefusesTraversal(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotEmpty(t, digests, "digests for the export's own objects are unaffected")
 }
+
+// ---------------------------------------------------------------------------
+// Version history
+// ---------------------------------------------------------------------------
+
+// TestVersionHistoryOverHTTP lists an overwritten object's versions and reads
+// the earlier one back by its entity tag.
+func TestVersionHistoryOverHTTP(t *testing.T) {
+	require.NoError(t, param.Origin_PStoreVersioning.Set([]any{
+		map[string]any{"Path": "/tenant", "Keep": 2},
+	}))
+	t.Cleanup(func() { _ = param.Origin_PStoreVersioning.Set(nil) })
+
+	issuer := "https://issuer.example.com"
+	o := newServedOrigin(t, []server_utils.OriginExport{{
+		FederationPrefix: "/data",
+		StoragePrefix:    "/tenant",
+		IssuerUrls:       []string{issuer},
+		Capabilities:     server_structs.Capabilities{Reads: true, Writes: true},
+	}}, nil)
+	tok := o.token(t, issuer, "storage.read:/ storage.create:/ storage.modify:/")
+
+	rec := o.put(t, tok, "/data/obj.txt", "first", nil)
+	require.Less(t, rec.Code, 300, rec.Body.String())
+	firstETag := rec.Header().Get("ETag")
+	require.NotEmpty(t, firstETag)
+	rec = o.put(t, tok, "/data/obj.txt", "second", nil)
+	require.Less(t, rec.Code, 300, rec.Body.String())
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer "+tok)
+		return o.do(req)
+	}
+
+	rec = get("/data/obj.txt?versions")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var versions []server_utils.ObjectVersion
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &versions))
+	require.Len(t, versions, 2)
+	assert.True(t, versions[0].Current)
+	assert.Equal(t, firstETag, versions[1].ETag)
+	assert.NotNil(t, versions[1].Superseded)
+
+	rec = get("/data/obj.txt?version=" + url.QueryEscape(firstETag))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "first", rec.Body.String())
+	assert.Equal(t, firstETag, rec.Header().Get("ETag"))
+
+	rec = get("/data/obj.txt")
+	assert.Equal(t, "second", rec.Body.String(), "a plain GET still serves the current version")
+
+	rec = get("/data/obj.txt?version=%22nope%22")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = get("/data/missing.txt?versions")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// ---------------------------------------------------------------------------
+// Object lock
+// ---------------------------------------------------------------------------
+
+// TestLockedObjectsAreRefusedOverHTTP checks what a client sees when it tries
+// to change a retained object: 403 with the reason, on every method that
+// could change it, and the object as it was afterwards.
+func TestLockedObjectsAreRefusedOverHTTP(t *testing.T) {
+	issuer := "https://issuer.example.com"
+	o := newServedOrigin(t, []server_utils.OriginExport{{
+		FederationPrefix: "/data",
+		StoragePrefix:    "/tenant",
+		IssuerUrls:       []string{issuer},
+		Capabilities:     server_structs.Capabilities{Reads: true, Writes: true},
+	}}, nil)
+	tok := o.token(t, issuer, "storage.read:/ storage.create:/ storage.modify:/")
+
+	rec := o.put(t, tok, "/data/raw/obj.txt", "immutable", nil)
+	require.Less(t, rec.Code, 300, rec.Body.String())
+	store := o.store(t, "/data")
+	_, err := store.SetRetention("/tenant/raw", pstore.RetentionCompliance, 24*time.Hour)
+	require.NoError(t, err)
+
+	refused := func(t *testing.T, rec *httptest.ResponseRecorder) {
+		t.Helper()
+		assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), "retained in compliance mode")
+		assert.NotContains(t, rec.Body.String(), "/tenant", "the store path is not the client's")
+	}
+
+	t.Run("overwrite", func(t *testing.T) {
+		refused(t, o.put(t, tok, "/data/raw/obj.txt", "replaced", nil))
+		req := httptest.NewRequest(http.MethodPut, "/data/raw/obj.txt",
+			unsizedBody{strings.NewReader("replaced")})
+		req.Header.Set("Authorization", "Bearer "+tok)
+		refused(t, o.do(req))
+	})
+	for _, method := range []string{http.MethodDelete, "MOVE"} {
+		for _, target := range []string{"/data/raw/obj.txt", "/data/raw"} {
+			t.Run(method+" "+target, func(t *testing.T) {
+				req := httptest.NewRequest(method, target, nil)
+				req.Header.Set("Authorization", "Bearer "+tok)
+				req.Header.Set("Destination", "/data/elsewhere")
+				refused(t, o.do(req))
+			})
+		}
+	}
+
+	got, err := store.ReadAll("/tenant/raw/obj.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "immutable", string(got))
+
+	rec = o.put(t, tok, "/data/raw/new.txt", "written once", nil)
+	assert.Less(t, rec.Code, 300, "new objects can still be written under a locked path: %s", rec.Body.String())
+}
@@ -0,0 +1,213 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+// Retention rules and legal holds over the administrative storage API.
+//
+//	GET    /retention/<path>   the settings locking <path>, those beneath it,
+//	                           and the lock on the object there
+//	PUT    /retention/<path>   set a rule: {"mode": "compliance", "period": "8760h"}
+//	DELETE /retention/<path>   remove a governance rule
+//	PUT    /hold/<path>        place a legal hold: {"reason": "..."}
+//	DELETE /hold/<path>        release it
+//
+// Paths are store paths, as everywhere else in this API.  These are the only
+// routes here that change anything, and they are here rather than offline
+// because a hold has to go on while the data is being served: a store that
+// must be stopped before it can be protected is not protected in the meantime.
+// The locks themselves are enforced by the store (pstore/retention.go); a
+// client that trips one gets 403 with the reason (serveWebDAV).
+
+package origin_serve
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/pelicanplatform/pelican/pstore"
+	"github.com/pelicanplatform/pelican/server_structs"
+)
+
+// retentionRuleRequest is the body of a PUT to /retention.
+type retentionRuleRequest struct {
+	Mode string `json:"mode"`
+	// Period is a Go duration, such as "8760h" for a year.
+	Period string `json:"period"`
+}
+
+// legalHoldRequest is the body of a PUT to /hold; it may be empty.
+type legalHoldRequest struct {
+	Reason string `json:"reason"`
+}
+
+type retentionRuleResponse struct {
+	Mode   pstore.RetentionMode `json:"mode"`
+	Period string               `json:"period"`
+	Set    time.Time            `json:"set"`
+}
+
+type legalHoldResponse struct {
+	Reason string    `json:"reason,omitempty"`
+	Set    time.Time `json:"set"`
+}
+
+// retentionSettingsResponse is what is set on one path.
+type retentionSettingsResponse struct {
+	Path string                 `json:"path"`
+	Rule *retentionRuleResponse `json:"rule,omitempty"`
+	Hold *legalHoldResponse     `json:"hold,omitempty"`
+}
+
+// objectLockResponse is what keeps one object from changing.
+type objectLockResponse struct {
+	Locked      bool                 `json:"locked"`
+	Mode        pstore.RetentionMode `json:"mode,omitempty"`
+	RetainUntil *time.Time           `json:"retainUntil,omitempty"`
+	RulePath    string               `json:"rulePath,omitempty"`
+	HoldPath    string               `json:"holdPath,omitempty"`
+	HoldReason  string               `json:"holdReason,omitempty"`
+}
+
+// retentionStatusResponse describes the retention settings around a path.
+type retentionStatusResponse struct {
+	Backend storageBackendKind `json:"backend"`
+	Path    string             `json:"path"`
+	// Covering lists the settings on the path and its ancestors, nearest
+	// first.
+	Covering []retentionSettingsResponse `json:"covering"`
+	// Below lists the settings on paths beneath it.
+	Below []retentionSettingsResponse `json:"below"`
+	// Lock is present when the path is an object.
+	Lock *objectLockResponse `json:"lock,omitempty"`
+}
+
+func handleRetentionStatus(c *gin.Context, store *pstore.Store) {
+	path := requestPath(c)
+	status, err := store.Retention(path)
+	if err != nil {
+		respondStorageError(c, err)
+		return
+	}
+	resp := retentionStatusResponse{
+		Backend:  storageBackendPStore,
+		Path:     path,
+		Covering: settingsResponses(status.Covering),
+		Below:    settingsResponses(status.Below),
+	}
+	if l := status.Lock; l != nil {
+		resp.Lock = &objectLockResponse{
+			Locked:     l.Locked,
+			Mode:       l.Mode,
+			RulePath:   l.RulePath,
+			HoldPath:   l.HoldPath,
+			HoldReason: l.HoldReason,
+		}
+		if !l.RetainUntil.IsZero() {
+			until := l.RetainUntil.UTC()
+			resp.Lock.RetainUntil = &until
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func handleSetRetention(c *gin.Context, store *pstore.Store) {
+	var req retentionRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondRetentionBadRequest(c, "the body must be JSON naming a mode and a period")
+		return
+	}
+	mode, err := pstore.ParseRetentionMode(req.Mode)
+	if err != nil {
+		respondRetentionBadRequest(c, err.Error())
+		return
+	}
+	period, err := time.ParseDuration(req.Period)
+	if err != nil || period <= 0 {
+		respondRetentionBadRequest(c, "the period must be a positive duration, such as \"8760h\"")
+		return
+	}
+	rs, err := store.SetRetention(requestPath(c), mode, period)
+	if err != nil {
+		respondStorageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, settingsResponse(*rs))
+}
+
+func handleClearRetention(c *gin.Context, store *pstore.Store) {
+	if err := store.ClearRetention(requestPath(c)); err != nil {
+		respondStorageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, server_structs.SimpleApiResp{Status: server_structs.RespOK})
+}
+
+func handleSetLegalHold(c *gin.Context, store *pstore.Store) {
+	var req legalHoldRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondRetentionBadRequest(c, "the body must be JSON, optionally giving a reason")
+			return
+		}
+	}
+	rs, err := store.SetLegalHold(requestPath(c), req.Reason)
+	if err != nil {
+		respondStorageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, settingsResponse(*rs))
+}
+
+func handleReleaseLegalHold(c *gin.Context, store *pstore.Store) {
+	if err := store.ReleaseLegalHold(requestPath(c)); err != nil {
+		respondStorageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, server_structs.SimpleApiResp{Status: server_structs.RespOK})
+}
+
+func respondRetentionBadRequest(c *gin.Context, msg string) {
+	c.JSON(http.StatusBadRequest, server_structs.SimpleApiResp{
+		Status: server_structs.RespFailed,
+		Msg:    msg,
+	})
+}
+
+func settingsResponses(settings []pstore.RetentionSettings) []retentionSettingsResponse {
+	out := make([]retentionSettingsResponse, 0, len(settings))
+	for _, rs := range settings {
+		out = append(out, settingsResponse(rs))
+	}
+	return out
+}
+
+func settingsResponse(rs pstore.RetentionSettings) retentionSettingsResponse {
+	out := retentionSettingsResponse{Path: rs.Path}
+	if r := rs.Rule; r != nil {
+		out.Rule = &retentionRuleResponse{
+			Mode:   r.Mode,
+			Period: r.Period.String(),
+			Set:    time.Unix(0, r.SetNanos).UTC(),
+		}
+	}
+	if h := rs.Hold; h != nil {
+		out.Hold = &legalHoldResponse{Reason: h.Reason, Set: time.Unix(0, h.SetNanos).UTC()}
+	}
+	return out
+}
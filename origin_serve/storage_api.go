@@ -35,7 +35,9 @@
 // rewritten produces findings that are indistinguishable from real ones, so
 // fsck stays offline where its answers mean something.  Repair is likewise
 // absent -- changing a store underneath a running origin is not something to
-// offer over HTTP.
+// offer over HTTP.  The one exception is retention (retention_api.go), which
+// changes what may be done to the data rather than the data itself, and has
+// to be settable while the origin serves.
 
 package origin_serve
 
@@ -123,6 +125,12 @@ func RegisterStorageAPI(router *gin.RouterGroup, auth ...gin.HandlerFunc) {
 		group.GET("/stat/*path", func(c *gin.Context) { handleStorageStat(c, store) })
 		group.GET("/digest/*path", func(c *gin.Context) { handleStorageDigest(c, store) })
 		group.GET("/usage", func(c *gin.Context) { handleStorageUsage(c, store) })
+
+		group.GET("/retention/*path", func(c *gin.Context) { handleRetentionStatus(c, store) })
+		group.PUT("/retention/*path", func(c *gin.Context) { handleSetRetention(c, store) })
+		group.DELETE("/retention/*path", func(c *gin.Context) { handleClearRetention(c, store) })
+		group.PUT("/hold/*path", func(c *gin.Context) { handleSetLegalHold(c, store) })
+		group.DELETE("/hold/*path", func(c *gin.Context) { handleReleaseLegalHold(c, store) })
 	}
 }
 
@@ -173,6 +181,12 @@ func storageErrorStatus(err error) int {
 		return http.StatusBadRequest
 	case errors.Is(err, pstore.ErrNotExist):
 		return http.StatusNotFound
+	case errors.Is(err, pstore.ErrRetained):
+		// A compliance rule refusing to be relaxed.  Not 403: the caller is
+		// an administrator, and no credential would do better.
+		return http.StatusConflict
+	case errors.Is(err, pstore.ErrReplica):
+		return http.StatusServiceUnavailable
 	default:
 		return http.StatusInternalServerError
 	}
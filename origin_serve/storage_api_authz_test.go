@@ -32,6 +32,7 @@ package origin_serve
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -109,10 +110,12 @@ func TestStorageAPIRejectsUnauthenticated(t *testing.T) {
 func TestStorageAPIRejectsNonAdmin(t *testing.T) {
 	engine := newAuthenticatedStorageAPI(t)
 
-	for _, route := range []string{"usage", "ls/", "stat/x", "digest/x"} {
+	for _, route := range []string{"GET usage", "GET ls/", "GET stat/x", "GET digest/x",
+		"GET retention/x", "PUT retention/x", "DELETE retention/x", "PUT hold/x", "DELETE hold/x"} {
 		t.Run(route, func(t *testing.T) {
-			req := httptest.NewRequest(http.MethodGet,
-				"/api/v1.0/origin/storage/pstore/"+route, nil)
+			method, endpoint, _ := strings.Cut(route, " ")
+			req := httptest.NewRequest(method,
+				"/api/v1.0/origin/storage/pstore/"+endpoint, nil)
 			req.Header.Set("Authorization", "Bearer "+localBearerToken(t, "not-an-admin"))
 
 			rec := httptest.NewRecorder()
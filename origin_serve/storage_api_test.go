@@ -199,3 +199,57 @@ func TestStorageAPIRequiresAdmin(t *testing.T) {
 	assert.True(t, denied, "the auth handler must run before the route")
 	assert.Equal(t, http.StatusForbidden, rec.Code)
 }
+
+// TestStorageAPIRetention sets, reads, and removes retention settings, and
+// checks that a compliance rule's refusal to be relaxed is a conflict rather
+// than the authorization failure a 403 would suggest to the CLI.
+func TestStorageAPIRetention(t *testing.T) {
+	engine, backend := newStorageAPI(t)
+	require.NoError(t, backend.FileSystem().Mkdir(t.Context(), "/raw", 0755))
+	seedObject(t, backend, "/raw/obj.txt", "x")
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/v1.0/origin/storage/pstore"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		engine.ServeHTTP(rec, req)
+		t.Logf("%s %s -> %d: %s", method, path, rec.Code, strings.TrimSpace(rec.Body.String()))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK,
+		send(http.MethodPut, "/retention/raw", `{"mode": "governance", "period": "1h"}`).Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPut, "/hold/raw/obj.txt", `{"reason": "audit"}`).Code)
+
+	var status retentionStatusResponse
+	require.Equal(t, http.StatusOK,
+		getJSON(t, engine, "/api/v1.0/origin/storage/pstore/retention/raw/obj.txt", &status))
+	require.Len(t, status.Covering, 2)
+	assert.Equal(t, "/raw/obj.txt", status.Covering[0].Path)
+	assert.Equal(t, "audit", status.Covering[0].Hold.Reason)
+	assert.Equal(t, "1h0m0s", status.Covering[1].Rule.Period)
+	require.NotNil(t, status.Lock)
+	assert.True(t, status.Lock.Locked)
+	require.NotNil(t, status.Lock.RetainUntil)
+
+	var root retentionStatusResponse
+	require.Equal(t, http.StatusOK,
+		getJSON(t, engine, "/api/v1.0/origin/storage/pstore/retention/", &root))
+	assert.Len(t, root.Below, 2, "the root lists every setting")
+	assert.Nil(t, root.Lock)
+
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, "/hold/raw/obj.txt", "").Code)
+	assert.Equal(t, http.StatusNotFound, send(http.MethodDelete, "/hold/raw/obj.txt", "").Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, "/retention/raw", "").Code)
+
+	assert.Equal(t, http.StatusOK,
+		send(http.MethodPut, "/retention/raw", `{"mode": "compliance", "period": "1h"}`).Code)
+	assert.Equal(t, http.StatusConflict, send(http.MethodDelete, "/retention/raw", "").Code)
+	assert.Equal(t, http.StatusConflict,
+		send(http.MethodPut, "/retention/raw", `{"mode": "compliance", "period": "1m"}`).Code)
+
+	assert.Equal(t, http.StatusBadRequest,
+		send(http.MethodPut, "/retention/raw", `{"mode": "forever", "period": "1h"}`).Code)
+	assert.Equal(t, http.StatusBadRequest,
+		send(http.MethodPut, "/retention/raw", `{"mode": "governance", "period": "a while"}`).Code)
+}
@@ -0,0 +1,110 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+// Object version history over HTTP.
+//
+// A backend that retains the versions of overwritten and deleted objects
+// (server_utils.VersionedBackend) exposes them through two query parameters on
+// an ordinary GET or HEAD of the object's URL:
+//
+//	?versions          lists the object's versions, newest first, as JSON
+//	?version=<etag>    serves one version's content
+//
+// Both are reads, so the export's read authorization already covers them.
+// Restoring a version is an administrative action and is done with
+// `pelican-server origin pstore restore`, not over HTTP.
+
+package origin_serve
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pelicanplatform/pelican/pstore"
+	"github.com/pelicanplatform/pelican/server_utils"
+)
+
+// isVersionRequest reports whether a request asks for version history rather
+// than for the object itself.
+func isVersionRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	q := r.URL.Query()
+	return q.Has("versions") || q.Has("version")
+}
+
+// handleVersionRequest answers a version-history request for relativePath.
+func handleVersionRequest(c *gin.Context, backend server_utils.OriginBackend, relativePath string) {
+	versioned, ok := backend.(server_utils.VersionedBackend)
+	if !ok {
+		// Serving the current content instead would hand a client asking for
+		// a particular version something else under the same URL.
+		c.String(http.StatusNotImplemented, "This export does not keep object versions")
+		return
+	}
+
+	q := c.Request.URL.Query()
+	if !q.Has("version") {
+		versions, err := versioned.ListVersions(relativePath)
+		if err != nil {
+			versionError(c, relativePath, err)
+			return
+		}
+		c.JSON(http.StatusOK, versions)
+		return
+	}
+
+	etag := q.Get("version")
+	if etag == "" {
+		c.String(http.StatusBadRequest, "The version parameter requires an entity tag")
+		return
+	}
+	rc, info, err := versioned.OpenVersion(relativePath, etag)
+	if err != nil {
+		versionError(c, relativePath, err)
+		return
+	}
+	defer rc.Close()
+
+	// ServeContent takes care of HEAD, ranges, and If-None-Match against the
+	// ETag set here; a version's content never changes, so all of them are
+	// answered exactly as for a current object.
+	c.Header("ETag", info.ETag)
+	http.ServeContent(c.Writer, c.Request, path.Base(relativePath), info.ModTime, rc)
+}
+
+// versionError reports a failed version lookup.
+func versionError(c *gin.Context, relativePath string, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		c.String(http.StatusNotFound, "No such version of %s", relativePath)
+	case errors.Is(err, fs.ErrPermission):
+		c.String(http.StatusForbidden, "Forbidden")
+	case errors.Is(err, pstore.ErrIsDir):
+		c.String(http.StatusBadRequest, "%s is a directory; only objects have versions", relativePath)
+	default:
+		log.Errorf("Failed to read the versions of %s: %v", relativePath, err)
+		c.String(http.StatusInternalServerError, "Failed to read the versions of %s", relativePath)
+	}
+}
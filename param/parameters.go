@@ -97,7 +97,12 @@ func GetDeprecated() map[string][]string {
 // It is generated from docs/parameters.yaml and indicates whether a parameter can be reloaded
 // at runtime without requiring a server restart.
 var runtimeConfigurableMap = map[string]bool{
+	"Cache.AdmissionFilterCapacity": false,
+	"Cache.AdmissionMinAccesses": false,
 	"Cache.AllowedFederations": false,
+	"Cache.BlockCompression": false,
+	"Cache.BlockCompressionMinSavings": false,
+	"Cache.BlockDedup": false,
 	"Cache.BlocksToPrefetch": false,
 	"Cache.ClientStatisticsLocation": false,
 	"Cache.Concurrency": false,
@@ -110,6 +115,7 @@ var runtimeConfigurableMap = map[string]bool{
 	"Cache.DefaultCacheTimeout": false,
 	"Cache.DirectorTest": false,
 	"Cache.DisableClientX509": false,
+	"Cache.EnableAdmissionFilter": false,
 	"Cache.EnableBroker": false,
 	"Cache.EnableChaosAPI": false,
 	"Cache.EnableEvictionMonitoring": false,
@@ -120,8 +126,11 @@ var runtimeConfigurableMap = map[string]bool{
 	"Cache.EnableTLSClientAuth": false,
 	"Cache.EnableV2": false,
 	"Cache.EnableVoms": false,
+	"Cache.EnableWarmupExport": false,
+	"Cache.EvictionFrequencyHalfLife": false,
 	"Cache.EvictionMonitoringInterval": false,
 	"Cache.EvictionMonitoringMaxDepth": false,
+	"Cache.EvictionPolicy": false,
 	"Cache.ExportLocation": false,
 	"Cache.FedTokenLocation": false,
 	"Cache.FilesBaseSize": false,
@@ -130,13 +139,23 @@ var runtimeConfigurableMap = map[string]bool{
 	"Cache.HighWaterMark": false,
 	"Cache.LocalRoot": false,
 	"Cache.LowWaterMark": false,
+	"Cache.MaxObjectSize": false,
+	"Cache.MemoryCacheMinAccesses": false,
 	"Cache.MemoryCacheSize": false,
 	"Cache.MetaLocations": false,
 	"Cache.MinDirectorRefreshInterval": false,
+	"Cache.NamespaceBlockCompression": false,
+	"Cache.NamespaceBlockDedup": false,
+	"Cache.NamespaceEvictionPolicies": false,
 	"Cache.NamespaceLocation": false,
+	"Cache.NamespaceMaxObjectSizes": false,
+	"Cache.NamespaceQuotas": false,
 	"Cache.PSSOrigin": false,
+	"Cache.PeerCaches": false,
+	"Cache.PeerQueryTimeout": false,
 	"Cache.PermittedNamespaces": false,
 	"Cache.Port": false,
+	"Cache.ReadAheadSize": false,
 	"Cache.RunLocation": false,
 	"Cache.SelfTest": false,
 	"Cache.SelfTestInterval": false,
@@ -150,6 +169,12 @@ var runtimeConfigurableMap = map[string]bool{
 	"Cache.Throttle.PerOriginStarvingPercent": false,
 	"Cache.Throttle.RetryAfter": false,
 	"Cache.Url": false,
+	"Cache.WarmupConcurrency": false,
+	"Cache.WarmupMaxObjects": false,
+	"Cache.WarmupOnStart": false,
+	"Cache.WarmupRate": false,
+	"Cache.WarmupSources": false,
+	"Cache.WarmupTokenLocation": false,
 	"Cache.WorkerCount": false,
 	"Cache.XRootDPrefix": false,
 	"Client.AssumeDirectorServerHeader": false,
@@ -158,6 +183,7 @@ var runtimeConfigurableMap = map[string]bool{
 	"Client.DisableHttpProxy": false,
 	"Client.DisableProxyFallback": false,
 	"Client.EnableOverwrites": false,
+	"Client.EnableTransferFeedback": false,
 	"Client.IsPlugin": false,
 	"Client.MaximumDownloadSpeed": false,
 	"Client.MinimumDownloadSpeed": false,
@@ -176,27 +202,41 @@ var runtimeConfigurableMap = map[string]bool{
 	"ConfigBase": false,
 	"ConfigLocations": false,
 	"Debug": false,
+	"Director.ASNLocalityFactor": false,
+	"Director.AdPersistenceInterval": false,
 	"Director.AdaptiveSortEWMATimeConstant": false,
 	"Director.AdaptiveSortTruncateConstant": false,
+	"Director.AdaptiveSortUseCacheProbes": false,
 	"Director.AdvertiseUrl": false,
 	"Director.AdvertisementTTL": false,
 	"Director.AssumePresenceAtSingleOrigin": false,
 	"Director.CachePresenceCapacity": false,
+	"Director.CachePresenceNegativeTTL": false,
 	"Director.CachePresenceTTL": false,
+	"Director.CacheProbeInterval": false,
+	"Director.CacheProbeObject": false,
+	"Director.CacheProbeTimeout": false,
 	"Director.CacheResponseHostnames": false,
 	"Director.CacheSortMethod": false,
 	"Director.CachesPullFromCaches": false,
 	"Director.CheckCachePresence": false,
 	"Director.CheckOriginPresence": false,
+	"Director.ConsistentHashKey": false,
+	"Director.ConsistentHashWorkingSetSize": false,
 	"Director.DbLocation": false,
 	"Director.DefaultResponse": false,
+	"Director.EnableASNLocality": false,
+	"Director.EnableAdPersistence": false,
 	"Director.EnableBroker": false,
+	"Director.EnableCacheProbes": false,
 	"Director.EnableFederationMetadataHosting": false,
 	"Director.EnableOIDC": false,
 	"Director.EnableStat": false,
+	"Director.EnableTransferFeedback": false,
 	"Director.FedTokenLifetime": false,
 	"Director.FilterCachesInErrorState": false,
 	"Director.FilteredServers": false,
+	"Director.GeoIPASNLocation": false,
 	"Director.GeoIPLocation": false,
 	"Director.MaxMindKeyFile": false,
 	"Director.MaxStatResponse": false,
@@ -205,10 +245,16 @@ var runtimeConfigurableMap = map[string]bool{
 	"Director.OriginCacheHealthTestInterval": false,
 	"Director.OriginResponseHostnames": false,
 	"Director.RegistryQueryInterval": false,
+	"Director.SiteLocalityFactor": false,
+	"Director.SiteNetworks": false,
+	"Director.SortPolicies": false,
 	"Director.StatConcurrencyLimit": false,
 	"Director.StatTimeout": false,
 	"Director.SupportContactEmail": false,
 	"Director.SupportContactUrl": false,
+	"Director.TransferFeedbackErrorThreshold": false,
+	"Director.TransferFeedbackMinAttempts": false,
+	"Director.TransferFeedbackWindow": false,
 	"DisableHttpProxy": false,
 	"DisableProxyFallback": false,
 	"Federation.BrokerUrl": false,
@@ -394,15 +440,35 @@ var runtimeConfigurableMap = map[string]bool{
 	"Origin.MultiuserVarlinkSocketPath": false,
 	"Origin.NamespacePrefix": false,
 	"Origin.ObjectProviderURL": false,
+	"Origin.PStoreBlockCompression": false,
+	"Origin.PStoreBlockDedup": false,
+	"Origin.PStoreDataBackupFullEvery": false,
+	"Origin.PStoreDataBackupInterval": false,
+	"Origin.PStoreDataBackupLocation": false,
+	"Origin.PStoreDataBackupS3AccessKeyfile": false,
+	"Origin.PStoreDataBackupS3Region": false,
+	"Origin.PStoreDataBackupS3SecretKeyfile": false,
+	"Origin.PStoreDataBackupS3ServiceUrl": false,
+	"Origin.PStoreDataBackupS3UrlStyle": false,
+	"Origin.PStoreDataBackupsToKeep": false,
 	"Origin.PStoreDataScanInterval": false,
 	"Origin.PStoreDataScanRate": false,
+	"Origin.PStoreErasureDataShards": false,
+	"Origin.PStoreErasureParityShards": false,
 	"Origin.PStoreIndexCheckInterval": false,
 	"Origin.PStoreInlineMaxBytes": false,
 	"Origin.PStoreLocation": false,
+	"Origin.PStoreMetadataBackupFullEvery": false,
 	"Origin.PStoreMetadataBackupInterval": false,
 	"Origin.PStoreMetadataBackupLocation": false,
 	"Origin.PStoreMetadataBackupsToKeep": false,
+	"Origin.PStoreReplicationPrimaryIssuer": false,
+	"Origin.PStoreReplicationRole": false,
+	"Origin.PStoreReplicationSecondaryUrl": false,
+	"Origin.PStoreReplicationSyncTimeout": false,
+	"Origin.PStoreReplicationSynchronous": false,
 	"Origin.PStoreStorageDirs": false,
+	"Origin.PStoreVersioning": false,
 	"Origin.Port": false,
 	"Origin.RunLocation": false,
 	"Origin.S3AccessKeyfile": false,
@@ -613,10 +679,12 @@ func paramNameToEnvVar(paramName string) string {
 // single parameter refers to a sensitive value like a password location.
 // The same logic applies to the other maps generated by this file.
 var stringAccessors = map[string]func(*Config) string{
+	"Cache.BlockCompression": func(c *Config) string { return c.Cache.BlockCompression },
 	"Cache.ClientStatisticsLocation": func(c *Config) string { return c.Cache.ClientStatisticsLocation },
 	"Cache.DataLocation": func(c *Config) string { return c.Cache.DataLocation },
 	"Cache.DataScanMode": func(c *Config) string { return c.Cache.DataScanMode },
 	"Cache.DbLocation": func(c *Config) string { return c.Cache.DbLocation },
+	"Cache.EvictionPolicy": func(c *Config) string { return c.Cache.EvictionPolicy },
 	"Cache.ExportLocation": func(c *Config) string { return c.Cache.ExportLocation },
 	"Cache.FedTokenLocation": func(c *Config) string { return c.Cache.FedTokenLocation },
 	"Cache.FilesBaseSize": func(c *Config) string { return c.Cache.FilesBaseSize },
@@ -625,13 +693,17 @@ var stringAccessors = map[string]func(*Config) string{
 	"Cache.HighWaterMark": func(c *Config) string { return c.Cache.HighWaterMark },
 	"Cache.LocalRoot": func(c *Config) string { return c.Cache.LocalRoot },
 	"Cache.LowWaterMark": func(c *Config) string { return c.Cache.LowWaterMark },
+	"Cache.MaxObjectSize": func(c *Config) string { return c.Cache.MaxObjectSize },
 	"Cache.MemoryCacheSize": func(c *Config) string { return c.Cache.MemoryCacheSize },
 	"Cache.NamespaceLocation": func(c *Config) string { return c.Cache.NamespaceLocation },
 	"Cache.PSSOrigin": func(c *Config) string { return c.Cache.PSSOrigin },
+	"Cache.ReadAheadSize": func(c *Config) string { return c.Cache.ReadAheadSize },
 	"Cache.RunLocation": func(c *Config) string { return c.Cache.RunLocation },
 	"Cache.SentinelLocation": func(c *Config) string { return c.Cache.SentinelLocation },
 	"Cache.StorageLocation": func(c *Config) string { return c.Cache.StorageLocation },
 	"Cache.Url": func(c *Config) string { return c.Cache.Url },
+	"Cache.WarmupRate": func(c *Config) string { return c.Cache.WarmupRate },
+	"Cache.WarmupTokenLocation": func(c *Config) string { return c.Cache.WarmupTokenLocation },
 	"Cache.XRootDPrefix": func(c *Config) string { return c.Cache.XRootDPrefix },
 	"ClientAgent.DbLocation": func(c *Config) string { return c.ClientAgent.DbLocation },
 	"ClientAgent.PidFile": func(c *Config) string { return c.ClientAgent.PidFile },
@@ -639,9 +711,12 @@ var stringAccessors = map[string]func(*Config) string{
 	"Client.CredentialFile": func(c *Config) string { return c.Client.CredentialFile },
 	"ConfigBase": func(c *Config) string { return c.ConfigBase },
 	"Director.AdvertiseUrl": func(c *Config) string { return c.Director.AdvertiseUrl },
+	"Director.CacheProbeObject": func(c *Config) string { return c.Director.CacheProbeObject },
 	"Director.CacheSortMethod": func(c *Config) string { return c.Director.CacheSortMethod },
+	"Director.ConsistentHashKey": func(c *Config) string { return c.Director.ConsistentHashKey },
 	"Director.DbLocation": func(c *Config) string { return c.Director.DbLocation },
 	"Director.DefaultResponse": func(c *Config) string { return c.Director.DefaultResponse },
+	"Director.GeoIPASNLocation": func(c *Config) string { return c.Director.GeoIPASNLocation },
 	"Director.GeoIPLocation": func(c *Config) string { return c.Director.GeoIPLocation },
 	"Director.MaxMindKeyFile": func(c *Config) string { return c.Director.MaxMindKeyFile },
 	"Director.SupportContactEmail": func(c *Config) string { return c.Director.SupportContactEmail },
@@ -731,8 +806,18 @@ var stringAccessors = map[string]func(*Config) string{
 	"Origin.MultiuserVarlinkSocketPath": func(c *Config) string { return c.Origin.MultiuserVarlinkSocketPath },
 	"Origin.NamespacePrefix": func(c *Config) string { return c.Origin.NamespacePrefix },
 	"Origin.ObjectProviderURL": func(c *Config) string { return c.Origin.ObjectProviderURL },
+	"Origin.PStoreBlockCompression": func(c *Config) string { return c.Origin.PStoreBlockCompression },
+	"Origin.PStoreDataBackupLocation": func(c *Config) string { return c.Origin.PStoreDataBackupLocation },
+	"Origin.PStoreDataBackupS3AccessKeyfile": func(c *Config) string { return c.Origin.PStoreDataBackupS3AccessKeyfile },
+	"Origin.PStoreDataBackupS3Region": func(c *Config) string { return c.Origin.PStoreDataBackupS3Region },
+	"Origin.PStoreDataBackupS3SecretKeyfile": func(c *Config) string { return c.Origin.PStoreDataBackupS3SecretKeyfile },
+	"Origin.PStoreDataBackupS3ServiceUrl": func(c *Config) string { return c.Origin.PStoreDataBackupS3ServiceUrl },
+	"Origin.PStoreDataBackupS3UrlStyle": func(c *Config) string { return c.Origin.PStoreDataBackupS3UrlStyle },
 	"Origin.PStoreLocation": func(c *Config) string { return c.Origin.PStoreLocation },
 	"Origin.PStoreMetadataBackupLocation": func(c *Config) string { return c.Origin.PStoreMetadataBackupLocation },
+	"Origin.PStoreReplicationPrimaryIssuer": func(c *Config) string { return c.Origin.PStoreReplicationPrimaryIssuer },
+	"Origin.PStoreReplicationRole": func(c *Config) string { return c.Origin.PStoreReplicationRole },
+	"Origin.PStoreReplicationSecondaryUrl": func(c *Config) string { return c.Origin.PStoreReplicationSecondaryUrl },
 	"Origin.RunLocation": func(c *Config) string { return c.Origin.RunLocation },
 	"Origin.S3AccessKeyfile": func(c *Config) string { return c.Origin.S3AccessKeyfile },
 	"Origin.S3Bucket": func(c *Config) string { return c.Origin.S3Bucket },
@@ -847,7 +932,9 @@ var stringSliceAccessors = map[string]func(*Config) []string{
 	"Cache.AllowedFederations": func(c *Config) []string { return c.Cache.AllowedFederations },
 	"Cache.DataLocations": func(c *Config) []string { return c.Cache.DataLocations },
 	"Cache.MetaLocations": func(c *Config) []string { return c.Cache.MetaLocations },
+	"Cache.PeerCaches": func(c *Config) []string { return c.Cache.PeerCaches },
 	"Cache.PermittedNamespaces": func(c *Config) []string { return c.Cache.PermittedNamespaces },
+	"Cache.WarmupSources": func(c *Config) []string { return c.Cache.WarmupSources },
 	"Client.PreferredCaches": func(c *Config) []string { return c.Client.PreferredCaches },
 	"ConfigLocations": func(c *Config) []string { return c.ConfigLocations },
 	"Director.CacheResponseHostnames": func(c *Config) []string { return c.Director.CacheResponseHostnames },
@@ -910,16 +997,22 @@ func (slP StringSliceParam) Set(value []string) error {
 }
 
 var intAccessors = map[string]func(*Config) int{
+	"Cache.AdmissionFilterCapacity": func(c *Config) int { return c.Cache.AdmissionFilterCapacity },
+	"Cache.AdmissionMinAccesses": func(c *Config) int { return c.Cache.AdmissionMinAccesses },
+	"Cache.BlockCompressionMinSavings": func(c *Config) int { return c.Cache.BlockCompressionMinSavings },
 	"Cache.BlocksToPrefetch": func(c *Config) int { return c.Cache.BlocksToPrefetch },
 	"Cache.Concurrency": func(c *Config) int { return c.Cache.Concurrency },
 	"Cache.ConcurrencyDegradedThreshold": func(c *Config) int { return c.Cache.ConcurrencyDegradedThreshold },
 	"Cache.DataScanResampleInterval": func(c *Config) int { return c.Cache.DataScanResampleInterval },
 	"Cache.EvictionMonitoringMaxDepth": func(c *Config) int { return c.Cache.EvictionMonitoringMaxDepth },
+	"Cache.MemoryCacheMinAccesses": func(c *Config) int { return c.Cache.MemoryCacheMinAccesses },
 	"Cache.Port": func(c *Config) int { return c.Cache.Port },
 	"Cache.Throttle.PendingBufferSize": func(c *Config) int { return c.Cache.Throttle.PendingBufferSize },
 	"Cache.Throttle.PerOriginActivePercent": func(c *Config) int { return c.Cache.Throttle.PerOriginActivePercent },
 	"Cache.Throttle.PerOriginPendingSize": func(c *Config) int { return c.Cache.Throttle.PerOriginPendingSize },
 	"Cache.Throttle.PerOriginStarvingPercent": func(c *Config) int { return c.Cache.Throttle.PerOriginStarvingPercent },
+	"Cache.WarmupConcurrency": func(c *Config) int { return c.Cache.WarmupConcurrency },
+	"Cache.WarmupMaxObjects": func(c *Config) int { return c.Cache.WarmupMaxObjects },
 	"Cache.WorkerCount": func(c *Config) int { return c.Cache.WorkerCount },
 	"ClientAgent.HistoryRetentionDays": func(c *Config) int { return c.ClientAgent.HistoryRetentionDays },
 	"ClientAgent.MaxConcurrentJobs": func(c *Config) int { return c.ClientAgent.MaxConcurrentJobs },
@@ -927,11 +1020,16 @@ var intAccessors = map[string]func(*Config) int{
 	"Client.MaximumDownloadSpeed": func(c *Config) int { return c.Client.MaximumDownloadSpeed },
 	"Client.MinimumDownloadSpeed": func(c *Config) int { return c.Client.MinimumDownloadSpeed },
 	"Client.WorkerCount": func(c *Config) int { return c.Client.WorkerCount },
+	"Director.ASNLocalityFactor": func(c *Config) int { return c.Director.ASNLocalityFactor },
 	"Director.AdaptiveSortTruncateConstant": func(c *Config) int { return c.Director.AdaptiveSortTruncateConstant },
 	"Director.CachePresenceCapacity": func(c *Config) int { return c.Director.CachePresenceCapacity },
+	"Director.ConsistentHashWorkingSetSize": func(c *Config) int { return c.Director.ConsistentHashWorkingSetSize },
 	"Director.MaxStatResponse": func(c *Config) int { return c.Director.MaxStatResponse },
 	"Director.MinStatResponse": func(c *Config) int { return c.Director.MinStatResponse },
+	"Director.SiteLocalityFactor": func(c *Config) int { return c.Director.SiteLocalityFactor },
 	"Director.StatConcurrencyLimit": func(c *Config) int { return c.Director.StatConcurrencyLimit },
+	"Director.TransferFeedbackErrorThreshold": func(c *Config) int { return c.Director.TransferFeedbackErrorThreshold },
+	"Director.TransferFeedbackMinAttempts": func(c *Config) int { return c.Director.TransferFeedbackMinAttempts },
 	"LocalCache.FDCacheSize": func(c *Config) int { return c.LocalCache.FDCacheSize },
 	"LocalCache.HighWaterMarkPercentage": func(c *Config) int { return c.LocalCache.HighWaterMarkPercentage },
 	"LocalCache.LowWaterMarkPercentage": func(c *Config) int { return c.LocalCache.LowWaterMarkPercentage },
@@ -952,7 +1050,12 @@ var intAccessors = map[string]func(*Config) int{
 	"Origin.DiskUsageCalculationRateLimit": func(c *Config) int { return c.Origin.DiskUsageCalculationRateLimit },
 	"Origin.MultiuserMinID": func(c *Config) int { return c.Origin.MultiuserMinID },
 	"Origin.MultiuserUmask": func(c *Config) int { return c.Origin.MultiuserUmask },
+	"Origin.PStoreDataBackupFullEvery": func(c *Config) int { return c.Origin.PStoreDataBackupFullEvery },
+	"Origin.PStoreDataBackupsToKeep": func(c *Config) int { return c.Origin.PStoreDataBackupsToKeep },
+	"Origin.PStoreErasureDataShards": func(c *Config) int { return c.Origin.PStoreErasureDataShards },
+	"Origin.PStoreErasureParityShards": func(c *Config) int { return c.Origin.PStoreErasureParityShards },
 	"Origin.PStoreInlineMaxBytes": func(c *Config) int { return c.Origin.PStoreInlineMaxBytes },
+	"Origin.PStoreMetadataBackupFullEvery": func(c *Config) int { return c.Origin.PStoreMetadataBackupFullEvery },
 	"Origin.PStoreMetadataBackupsToKeep": func(c *Config) int { return c.Origin.PStoreMetadataBackupsToKeep },
 	"Origin.Port": func(c *Config) int { return c.Origin.Port },
 	"Origin.SSH.MaxRetries": func(c *Config) int { return c.Origin.SSH.MaxRetries },
@@ -1045,8 +1148,10 @@ func (bRP ByteRateParam) SetString(value string) error {
 }
 
 var boolAccessors = map[string]func(*Config) bool{
+	"Cache.BlockDedup": func(c *Config) bool { return c.Cache.BlockDedup },
 	"Cache.DirectorTest": func(c *Config) bool { return c.Cache.DirectorTest },
 	"Cache.DisableClientX509": func(c *Config) bool { return c.Cache.DisableClientX509 },
+	"Cache.EnableAdmissionFilter": func(c *Config) bool { return c.Cache.EnableAdmissionFilter },
 	"Cache.EnableBroker": func(c *Config) bool { return c.Cache.EnableBroker },
 	"Cache.EnableChaosAPI": func(c *Config) bool { return c.Cache.EnableChaosAPI },
 	"Cache.EnableEvictionMonitoring": func(c *Config) bool { return c.Cache.EnableEvictionMonitoring },
@@ -1057,21 +1162,29 @@ var boolAccessors = map[string]func(*Config) bool{
 	"Cache.EnableTLSClientAuth": func(c *Config) bool { return c.Cache.EnableTLSClientAuth },
 	"Cache.EnableV2": func(c *Config) bool { return c.Cache.EnableV2 },
 	"Cache.EnableVoms": func(c *Config) bool { return c.Cache.EnableVoms },
+	"Cache.EnableWarmupExport": func(c *Config) bool { return c.Cache.EnableWarmupExport },
 	"Cache.SelfTest": func(c *Config) bool { return c.Cache.SelfTest },
+	"Cache.WarmupOnStart": func(c *Config) bool { return c.Cache.WarmupOnStart },
 	"Client.AssumeDirectorServerHeader": func(c *Config) bool { return c.Client.AssumeDirectorServerHeader },
 	"Client.DisableHttpProxy": func(c *Config) bool { return c.Client.DisableHttpProxy },
 	"Client.DisableProxyFallback": func(c *Config) bool { return c.Client.DisableProxyFallback },
 	"Client.EnableOverwrites": func(c *Config) bool { return c.Client.EnableOverwrites },
+	"Client.EnableTransferFeedback": func(c *Config) bool { return c.Client.EnableTransferFeedback },
 	"Client.IsPlugin": func(c *Config) bool { return c.Client.IsPlugin },
 	"Debug": func(c *Config) bool { return c.Debug },
+	"Director.AdaptiveSortUseCacheProbes": func(c *Config) bool { return c.Director.AdaptiveSortUseCacheProbes },
 	"Director.AssumePresenceAtSingleOrigin": func(c *Config) bool { return c.Director.AssumePresenceAtSingleOrigin },
 	"Director.CachesPullFromCaches": func(c *Config) bool { return c.Director.CachesPullFromCaches },
 	"Director.CheckCachePresence": func(c *Config) bool { return c.Director.CheckCachePresence },
 	"Director.CheckOriginPresence": func(c *Config) bool { return c.Director.CheckOriginPresence },
+	"Director.EnableASNLocality": func(c *Config) bool { return c.Director.EnableASNLocality },
+	"Director.EnableAdPersistence": func(c *Config) bool { return c.Director.EnableAdPersistence },
 	"Director.EnableBroker": func(c *Config) bool { return c.Director.EnableBroker },
+	"Director.EnableCacheProbes": func(c *Config) bool { return c.Director.EnableCacheProbes },
 	"Director.EnableFederationMetadataHosting": func(c *Config) bool { return c.Director.EnableFederationMetadataHosting },
 	"Director.EnableOIDC": func(c *Config) bool { return c.Director.EnableOIDC },
 	"Director.EnableStat": func(c *Config) bool { return c.Director.EnableStat },
+	"Director.EnableTransferFeedback": func(c *Config) bool { return c.Director.EnableTransferFeedback },
 	"Director.FilterCachesInErrorState": func(c *Config) bool { return c.Director.FilterCachesInErrorState },
 	"DisableHttpProxy": func(c *Config) bool { return c.DisableHttpProxy },
 	"DisableProxyFallback": func(c *Config) bool { return c.DisableProxyFallback },
@@ -1109,6 +1222,8 @@ var boolAccessors = map[string]func(*Config) bool{
 	"Origin.EnableWrites": func(c *Config) bool { return c.Origin.EnableWrites },
 	"Origin.HttpAuthTokenPassthrough": func(c *Config) bool { return c.Origin.HttpAuthTokenPassthrough },
 	"Origin.Multiuser": func(c *Config) bool { return c.Origin.Multiuser },
+	"Origin.PStoreBlockDedup": func(c *Config) bool { return c.Origin.PStoreBlockDedup },
+	"Origin.PStoreReplicationSynchronous": func(c *Config) bool { return c.Origin.PStoreReplicationSynchronous },
 	"Origin.SSH.AutoAddHostKey": func(c *Config) bool { return c.Origin.SSH.AutoAddHostKey },
 	"Origin.SSH.TunnelCallback": func(c *Config) bool { return c.Origin.SSH.TunnelCallback },
 	"Origin.ScitokensMapSubject": func(c *Config) bool { return c.Origin.ScitokensMapSubject },
@@ -1169,8 +1284,10 @@ func (bP BoolParam) Set(value bool) error {
 
 var durationAccessors = map[string]func(*Config) time.Duration{
 	"Cache.DefaultCacheTimeout": func(c *Config) time.Duration { return c.Cache.DefaultCacheTimeout },
+	"Cache.EvictionFrequencyHalfLife": func(c *Config) time.Duration { return c.Cache.EvictionFrequencyHalfLife },
 	"Cache.EvictionMonitoringInterval": func(c *Config) time.Duration { return c.Cache.EvictionMonitoringInterval },
 	"Cache.MinDirectorRefreshInterval": func(c *Config) time.Duration { return c.Cache.MinDirectorRefreshInterval },
+	"Cache.PeerQueryTimeout": func(c *Config) time.Duration { return c.Cache.PeerQueryTimeout },
 	"Cache.SelfTestInterval": func(c *Config) time.Duration { return c.Cache.SelfTestInterval },
 	"Cache.SelfTestMaxAge": func(c *Config) time.Duration { return c.Cache.SelfTestMaxAge },
 	"Cache.Throttle.EMAWindow": func(c *Config) time.Duration { return c.Cache.Throttle.EMAWindow },
@@ -1180,14 +1297,19 @@ var durationAccessors = map[string]func(*Config) time.Duration{
 	"Client.SlowTransferRampupTime": func(c *Config) time.Duration { return c.Client.SlowTransferRampupTime },
 	"Client.SlowTransferWindow": func(c *Config) time.Duration { return c.Client.SlowTransferWindow },
 	"Client.StoppedTransferTimeout": func(c *Config) time.Duration { return c.Client.StoppedTransferTimeout },
+	"Director.AdPersistenceInterval": func(c *Config) time.Duration { return c.Director.AdPersistenceInterval },
 	"Director.AdaptiveSortEWMATimeConstant": func(c *Config) time.Duration { return c.Director.AdaptiveSortEWMATimeConstant },
 	"Director.AdvertisementTTL": func(c *Config) time.Duration { return c.Director.AdvertisementTTL },
+	"Director.CachePresenceNegativeTTL": func(c *Config) time.Duration { return c.Director.CachePresenceNegativeTTL },
 	"Director.CachePresenceTTL": func(c *Config) time.Duration { return c.Director.CachePresenceTTL },
+	"Director.CacheProbeInterval": func(c *Config) time.Duration { return c.Director.CacheProbeInterval },
+	"Director.CacheProbeTimeout": func(c *Config) time.Duration { return c.Director.CacheProbeTimeout },
 	"Director.FedTokenLifetime": func(c *Config) time.Duration { return c.Director.FedTokenLifetime },
 	"Director.MetadataComparisonInterval": func(c *Config) time.Duration { return c.Director.MetadataComparisonInterval },
 	"Director.OriginCacheHealthTestInterval": func(c *Config) time.Duration { return c.Director.OriginCacheHealthTestInterval },
 	"Director.RegistryQueryInterval": func(c *Config) time.Duration { return c.Director.RegistryQueryInterval },
 	"Director.StatTimeout": func(c *Config) time.Duration { return c.Director.StatTimeout },
+	"Director.TransferFeedbackWindow": func(c *Config) time.Duration { return c.Director.TransferFeedbackWindow },
 	"Federation.TopologyReloadInterval": func(c *Config) time.Duration { return c.Federation.TopologyReloadInterval },
 	"Issuer.AccessTokenLifetime": func(c *Config) time.Duration { return c.Issuer.AccessTokenLifetime },
 	"Issuer.AuthorizationCodeLifetime": func(c *Config) time.Duration { return c.Issuer.AuthorizationCodeLifetime },
@@ -1216,9 +1338,11 @@ var durationAccessors = map[string]func(*Config) time.Duration{
 	"Origin.DiskUsageCalculationDelay": func(c *Config) time.Duration { return c.Origin.DiskUsageCalculationDelay },
 	"Origin.DiskUsageCalculationInterval": func(c *Config) time.Duration { return c.Origin.DiskUsageCalculationInterval },
 	"Origin.Globusv2TokenRefreshInterval": func(c *Config) time.Duration { return c.Origin.Globusv2TokenRefreshInterval },
+	"Origin.PStoreDataBackupInterval": func(c *Config) time.Duration { return c.Origin.PStoreDataBackupInterval },
 	"Origin.PStoreDataScanInterval": func(c *Config) time.Duration { return c.Origin.PStoreDataScanInterval },
 	"Origin.PStoreIndexCheckInterval": func(c *Config) time.Duration { return c.Origin.PStoreIndexCheckInterval },
 	"Origin.PStoreMetadataBackupInterval": func(c *Config) time.Duration { return c.Origin.PStoreMetadataBackupInterval },
+	"Origin.PStoreReplicationSyncTimeout": func(c *Config) time.Duration { return c.Origin.PStoreReplicationSyncTimeout },
 	"Origin.SSH.ChallengeTimeout": func(c *Config) time.Duration { return c.Origin.SSH.ChallengeTimeout },
 	"Origin.SSH.ConnectTimeout": func(c *Config) time.Duration { return c.Origin.SSH.ConnectTimeout },
 	"Origin.SSH.KeepaliveInterval": func(c *Config) time.Duration { return c.Origin.SSH.KeepaliveInterval },
@@ -1339,7 +1463,12 @@ func (oqP OpaqueParam) GetEnvVarName() string {
 // docs/parameters.yaml. It is primarily used to bind environment variables so
 // that env-only overrides are included in viper.AllSettings().
 var allParameterNames = []string{
+	"Cache.AdmissionFilterCapacity",
+	"Cache.AdmissionMinAccesses",
 	"Cache.AllowedFederations",
+	"Cache.BlockCompression",
+	"Cache.BlockCompressionMinSavings",
+	"Cache.BlockDedup",
 	"Cache.BlocksToPrefetch",
 	"Cache.ClientStatisticsLocation",
 	"Cache.Concurrency",
@@ -1352,6 +1481,7 @@ var allParameterNames = []string{
 	"Cache.DefaultCacheTimeout",
 	"Cache.DirectorTest",
 	"Cache.DisableClientX509",
+	"Cache.EnableAdmissionFilter",
 	"Cache.EnableBroker",
 	"Cache.EnableChaosAPI",
 	"Cache.EnableEvictionMonitoring",
@@ -1362,8 +1492,11 @@ var allParameterNames = []string{
 	"Cache.EnableTLSClientAuth",
 	"Cache.EnableV2",
 	"Cache.EnableVoms",
+	"Cache.EnableWarmupExport",
+	"Cache.EvictionFrequencyHalfLife",
 	"Cache.EvictionMonitoringInterval",
 	"Cache.EvictionMonitoringMaxDepth",
+	"Cache.EvictionPolicy",
 	"Cache.ExportLocation",
 	"Cache.FedTokenLocation",
 	"Cache.FilesBaseSize",
@@ -1372,13 +1505,23 @@ var allParameterNames = []string{
 	"Cache.HighWaterMark",
 	"Cache.LocalRoot",
 	"Cache.LowWaterMark",
+	"Cache.MaxObjectSize",
+	"Cache.MemoryCacheMinAccesses",
 	"Cache.MemoryCacheSize",
 	"Cache.MetaLocations",
 	"Cache.MinDirectorRefreshInterval",
+	"Cache.NamespaceBlockCompression",
+	"Cache.NamespaceBlockDedup",
+	"Cache.NamespaceEvictionPolicies",
 	"Cache.NamespaceLocation",
+	"Cache.NamespaceMaxObjectSizes",
+	"Cache.NamespaceQuotas",
 	"Cache.PSSOrigin",
+	"Cache.PeerCaches",
+	"Cache.PeerQueryTimeout",
 	"Cache.PermittedNamespaces",
 	"Cache.Port",
+	"Cache.ReadAheadSize",
 	"Cache.RunLocation",
 	"Cache.SelfTest",
 	"Cache.SelfTestInterval",
@@ -1392,6 +1535,12 @@ var allParameterNames = []string{
 	"Cache.Throttle.PerOriginStarvingPercent",
 	"Cache.Throttle.RetryAfter",
 	"Cache.Url",
+	"Cache.WarmupConcurrency",
+	"Cache.WarmupMaxObjects",
+	"Cache.WarmupOnStart",
+	"Cache.WarmupRate",
+	"Cache.WarmupSources",
+	"Cache.WarmupTokenLocation",
 	"Cache.WorkerCount",
 	"Cache.XRootDPrefix",
 	"Client.AssumeDirectorServerHeader",
@@ -1400,6 +1549,7 @@ var allParameterNames = []string{
 	"Client.DisableHttpProxy",
 	"Client.DisableProxyFallback",
 	"Client.EnableOverwrites",
+	"Client.EnableTransferFeedback",
 	"Client.IsPlugin",
 	"Client.MaximumDownloadSpeed",
 	"Client.MinimumDownloadSpeed",
@@ -1418,27 +1568,41 @@ var allParameterNames = []string{
 	"ConfigBase",
 	"ConfigLocations",
 	"Debug",
+	"Director.ASNLocalityFactor",
+	"Director.AdPersistenceInterval",
 	"Director.AdaptiveSortEWMATimeConstant",
 	"Director.AdaptiveSortTruncateConstant",
+	"Director.AdaptiveSortUseCacheProbes",
 	"Director.AdvertiseUrl",
 	"Director.AdvertisementTTL",
 	"Director.AssumePresenceAtSingleOrigin",
 	"Director.CachePresenceCapacity",
+	"Director.CachePresenceNegativeTTL",
 	"Director.CachePresenceTTL",
+	"Director.CacheProbeInterval",
+	"Director.CacheProbeObject",
+	"Director.CacheProbeTimeout",
 	"Director.CacheResponseHostnames",
 	"Director.CacheSortMethod",
 	"Director.CachesPullFromCaches",
 	"Director.CheckCachePresence",
 	"Director.CheckOriginPresence",
+	"Director.ConsistentHashKey",
+	"Director.ConsistentHashWorkingSetSize",
 	"Director.DbLocation",
 	"Director.DefaultResponse",
+	"Director.EnableASNLocality",
+	"Director.EnableAdPersistence",
 	"Director.EnableBroker",
+	"Director.EnableCacheProbes",
 	"Director.EnableFederationMetadataHosting",
 	"Director.EnableOIDC",
 	"Director.EnableStat",
+	"Director.EnableTransferFeedback",
 	"Director.FedTokenLifetime",
 	"Director.FilterCachesInErrorState",
 	"Director.FilteredServers",
+	"Director.GeoIPASNLocation",
 	"Director.GeoIPLocation",
 	"Director.MaxMindKeyFile",
 	"Director.MaxStatResponse",
@@ -1447,10 +1611,16 @@ var allParameterNames = []string{
 	"Director.OriginCacheHealthTestInterval",
 	"Director.OriginResponseHostnames",
 	"Director.RegistryQueryInterval",
+	"Director.SiteLocalityFactor",
+	"Director.SiteNetworks",
+	"Director.SortPolicies",
 	"Director.StatConcurrencyLimit",
 	"Director.StatTimeout",
 	"Director.SupportContactEmail",
 	"Director.SupportContactUrl",
+	"Director.TransferFeedbackErrorThreshold",
+	"Director.TransferFeedbackMinAttempts",
+	"Director.TransferFeedbackWindow",
 	"DisableHttpProxy",
 	"DisableProxyFallback",
 	"Federation.BrokerUrl",
@@ -1636,15 +1806,35 @@ var allParameterNames = []string{
 	"Origin.MultiuserVarlinkSocketPath",
 	"Origin.NamespacePrefix",
 	"Origin.ObjectProviderURL",
+	"Origin.PStoreBlockCompression",
+	"Origin.PStoreBlockDedup",
+	"Origin.PStoreDataBackupFullEvery",
+	"Origin.PStoreDataBackupInterval",
+	"Origin.PStoreDataBackupLocation",
+	"Origin.PStoreDataBackupS3AccessKeyfile",
+	"Origin.PStoreDataBackupS3Region",
+	"Origin.PStoreDataBackupS3SecretKeyfile",
+	"Origin.PStoreDataBackupS3ServiceUrl",
+	"Origin.PStoreDataBackupS3UrlStyle",
+	"Origin.PStoreDataBackupsToKeep",
 	"Origin.PStoreDataScanInterval",
 	"Origin.PStoreDataScanRate",
+	"Origin.PStoreErasureDataShards",
+	"Origin.PStoreErasureParityShards",
 	"Origin.PStoreIndexCheckInterval",
 	"Origin.PStoreInlineMaxBytes",
 	"Origin.PStoreLocation",
+	"Origin.PStoreMetadataBackupFullEvery",
 	"Origin.PStoreMetadataBackupInterval",
 	"Origin.PStoreMetadataBackupLocation",
 	"Origin.PStoreMetadataBackupsToKeep",
+	"Origin.PStoreReplicationPrimaryIssuer",
+	"Origin.PStoreReplicationRole",
+	"Origin.PStoreReplicationSecondaryUrl",
+	"Origin.PStoreReplicationSyncTimeout",
+	"Origin.PStoreReplicationSynchronous",
 	"Origin.PStoreStorageDirs",
+	"Origin.PStoreVersioning",
 	"Origin.Port",
 	"Origin.RunLocation",
 	"Origin.S3AccessKeyfile",
@@ -1828,10 +2018,12 @@ var allParameterNames = []string{
 }
 
 var (
+	Cache_BlockCompression = StringParam{"Cache.BlockCompression"}
 	Cache_ClientStatisticsLocation = StringParam{"Cache.ClientStatisticsLocation"}
 	Cache_DataLocation = StringParam{"Cache.DataLocation"}
 	Cache_DataScanMode = StringParam{"Cache.DataScanMode"}
 	Cache_DbLocation = StringParam{"Cache.DbLocation"}
+	Cache_EvictionPolicy = StringParam{"Cache.EvictionPolicy"}
 	Cache_ExportLocation = StringParam{"Cache.ExportLocation"}
 	Cache_FedTokenLocation = StringParam{"Cache.FedTokenLocation"}
 	Cache_FilesBaseSize = StringParam{"Cache.FilesBaseSize"}
@@ -1840,13 +2032,17 @@ var (
 	Cache_HighWaterMark = StringParam{"Cache.HighWaterMark"}
 	Cache_LocalRoot = StringParam{"Cache.LocalRoot"}
 	Cache_LowWaterMark = StringParam{"Cache.LowWaterMark"}
+	Cache_MaxObjectSize = StringParam{"Cache.MaxObjectSize"}
 	Cache_MemoryCacheSize = StringParam{"Cache.MemoryCacheSize"}
 	Cache_NamespaceLocation = StringParam{"Cache.NamespaceLocation"}
 	Cache_PSSOrigin = StringParam{"Cache.PSSOrigin"}
+	Cache_ReadAheadSize = StringParam{"Cache.ReadAheadSize"}
 	Cache_RunLocation = StringParam{"Cache.RunLocation"}
 	Cache_SentinelLocation = StringParam{"Cache.SentinelLocation"}
 	Cache_StorageLocation = StringParam{"Cache.StorageLocation"}
 	Cache_Url = StringParam{"Cache.Url"}
+	Cache_WarmupRate = StringParam{"Cache.WarmupRate"}
+	Cache_WarmupTokenLocation = StringParam{"Cache.WarmupTokenLocation"}
 	Cache_XRootDPrefix = StringParam{"Cache.XRootDPrefix"}
 	ClientAgent_DbLocation = StringParam{"ClientAgent.DbLocation"}
 	ClientAgent_PidFile = StringParam{"ClientAgent.PidFile"}
@@ -1854,9 +2050,12 @@ var (
 	Client_CredentialFile = StringParam{"Client.CredentialFile"}
 	ConfigBase = StringParam{"ConfigBase"}
 	Director_AdvertiseUrl = StringParam{"Director.AdvertiseUrl"}
+	Director_CacheProbeObject = StringParam{"Director.CacheProbeObject"}
 	Director_CacheSortMethod = StringParam{"Director.CacheSortMethod"}
+	Director_ConsistentHashKey = StringParam{"Director.ConsistentHashKey"}
 	Director_DbLocation = StringParam{"Director.DbLocation"}
 	Director_DefaultResponse = StringParam{"Director.DefaultResponse"}
+	Director_GeoIPASNLocation = StringParam{"Director.GeoIPASNLocation"}
 	Director_GeoIPLocation = StringParam{"Director.GeoIPLocation"}
 	Director_MaxMindKeyFile = StringParam{"Director.MaxMindKeyFile"}
 	Director_SupportContactEmail = StringParam{"Director.SupportContactEmail"}
@@ -1946,8 +2145,18 @@ var (
 	Origin_MultiuserVarlinkSocketPath = StringParam{"Origin.MultiuserVarlinkSocketPath"}
 	Origin_NamespacePrefix = StringParam{"Origin.NamespacePrefix"}
 	Origin_ObjectProviderURL = StringParam{"Origin.ObjectProviderURL"}
+	Origin_PStoreBlockCompression = StringParam{"Origin.PStoreBlockCompression"}
+	Origin_PStoreDataBackupLocation = StringParam{"Origin.PStoreDataBackupLocation"}
+	Origin_PStoreDataBackupS3AccessKeyfile = StringParam{"Origin.PStoreDataBackupS3AccessKeyfile"}
+	Origin_PStoreDataBackupS3Region = StringParam{"Origin.PStoreDataBackupS3Region"}
+	Origin_PStoreDataBackupS3SecretKeyfile = StringParam{"Origin.PStoreDataBackupS3SecretKeyfile"}
+	Origin_PStoreDataBackupS3ServiceUrl = StringParam{"Origin.PStoreDataBackupS3ServiceUrl"}
+	Origin_PStoreDataBackupS3UrlStyle = StringParam{"Origin.PStoreDataBackupS3UrlStyle"}
 	Origin_PStoreLocation = StringParam{"Origin.PStoreLocation"}
 	Origin_PStoreMetadataBackupLocation = StringParam{"Origin.PStoreMetadataBackupLocation"}
+	Origin_PStoreReplicationPrimaryIssuer = StringParam{"Origin.PStoreReplicationPrimaryIssuer"}
+	Origin_PStoreReplicationRole = StringParam{"Origin.PStoreReplicationRole"}
+	Origin_PStoreReplicationSecondaryUrl = StringParam{"Origin.PStoreReplicationSecondaryUrl"}
 	Origin_RunLocation = StringParam{"Origin.RunLocation"}
 	Origin_S3AccessKeyfile = StringParam{"Origin.S3AccessKeyfile"}
 	Origin_S3Bucket = StringParam{"Origin.S3Bucket"}
@@ -2034,7 +2243,9 @@ var (
 	Cache_AllowedFederations = StringSliceParam{"Cache.AllowedFederations"}
 	Cache_DataLocations = StringSliceParam{"Cache.DataLocations"}
 	Cache_MetaLocations = StringSliceParam{"Cache.MetaLocations"}
+	Cache_PeerCaches = StringSliceParam{"Cache.PeerCaches"}
 	Cache_PermittedNamespaces = StringSliceParam{"Cache.PermittedNamespaces"}
+	Cache_WarmupSources = StringSliceParam{"Cache.WarmupSources"}
 	Client_PreferredCaches = StringSliceParam{"Client.PreferredCaches"}
 	ConfigLocations = StringSliceParam{"ConfigLocations"}
 	Director_CacheResponseHostnames = StringSliceParam{"Director.CacheResponseHostnames"}
@@ -2069,16 +2280,22 @@ var (
 )
 
 var (
+	Cache_AdmissionFilterCapacity = IntParam{"Cache.AdmissionFilterCapacity"}
+	Cache_AdmissionMinAccesses = IntParam{"Cache.AdmissionMinAccesses"}
+	Cache_BlockCompressionMinSavings = IntParam{"Cache.BlockCompressionMinSavings"}
 	Cache_BlocksToPrefetch = IntParam{"Cache.BlocksToPrefetch"}
 	Cache_Concurrency = IntParam{"Cache.Concurrency"}
 	Cache_ConcurrencyDegradedThreshold = IntParam{"Cache.ConcurrencyDegradedThreshold"}
 	Cache_DataScanResampleInterval = IntParam{"Cache.DataScanResampleInterval"}
 	Cache_EvictionMonitoringMaxDepth = IntParam{"Cache.EvictionMonitoringMaxDepth"}
+	Cache_MemoryCacheMinAccesses = IntParam{"Cache.MemoryCacheMinAccesses"}
 	Cache_Port = IntParam{"Cache.Port"}
 	Cache_Throttle_PendingBufferSize = IntParam{"Cache.Throttle.PendingBufferSize"}
 	Cache_Throttle_PerOriginActivePercent = IntParam{"Cache.Throttle.PerOriginActivePercent"}
 	Cache_Throttle_PerOriginPendingSize = IntParam{"Cache.Throttle.PerOriginPendingSize"}
 	Cache_Throttle_PerOriginStarvingPercent = IntParam{"Cache.Throttle.PerOriginStarvingPercent"}
+	Cache_WarmupConcurrency = IntParam{"Cache.WarmupConcurrency"}
+	Cache_WarmupMaxObjects = IntParam{"Cache.WarmupMaxObjects"}
 	Cache_WorkerCount = IntParam{"Cache.WorkerCount"}
 	ClientAgent_HistoryRetentionDays = IntParam{"ClientAgent.HistoryRetentionDays"}
 	ClientAgent_MaxConcurrentJobs = IntParam{"ClientAgent.MaxConcurrentJobs"}
@@ -2086,11 +2303,16 @@ var (
 	Client_MaximumDownloadSpeed = IntParam{"Client.MaximumDownloadSpeed"}
 	Client_MinimumDownloadSpeed = IntParam{"Client.MinimumDownloadSpeed"}
 	Client_WorkerCount = IntParam{"Client.WorkerCount"}
+	Director_ASNLocalityFactor = IntParam{"Director.ASNLocalityFactor"}
 	Director_AdaptiveSortTruncateConstant = IntParam{"Director.AdaptiveSortTruncateConstant"}
 	Director_CachePresenceCapacity = IntParam{"Director.CachePresenceCapacity"}
+	Director_ConsistentHashWorkingSetSize = IntParam{"Director.ConsistentHashWorkingSetSize"}
 	Director_MaxStatResponse = IntParam{"Director.MaxStatResponse"}
 	Director_MinStatResponse = IntParam{"Director.MinStatResponse"}
+	Director_SiteLocalityFactor = IntParam{"Director.SiteLocalityFactor"}
 	Director_StatConcurrencyLimit = IntParam{"Director.StatConcurrencyLimit"}
+	Director_TransferFeedbackErrorThreshold = IntParam{"Director.TransferFeedbackErrorThreshold"}
+	Director_TransferFeedbackMinAttempts = IntParam{"Director.TransferFeedbackMinAttempts"}
 	LocalCache_FDCacheSize = IntParam{"LocalCache.FDCacheSize"}
 	LocalCache_HighWaterMarkPercentage = IntParam{"LocalCache.HighWaterMarkPercentage"}
 	LocalCache_LowWaterMarkPercentage = IntParam{"LocalCache.LowWaterMarkPercentage"}
@@ -2111,7 +2333,12 @@ var (
 	Origin_DiskUsageCalculationRateLimit = IntParam{"Origin.DiskUsageCalculationRateLimit"}
 	Origin_MultiuserMinID = IntParam{"Origin.MultiuserMinID"}
 	Origin_MultiuserUmask = IntParam{"Origin.MultiuserUmask"}
+	Origin_PStoreDataBackupFullEvery = IntParam{"Origin.PStoreDataBackupFullEvery"}
+	Origin_PStoreDataBackupsToKeep = IntParam{"Origin.PStoreDataBackupsToKeep"}
+	Origin_PStoreErasureDataShards = IntParam{"Origin.PStoreErasureDataShards"}
+	Origin_PStoreErasureParityShards = IntParam{"Origin.PStoreErasureParityShards"}
 	Origin_PStoreInlineMaxBytes = IntParam{"Origin.PStoreInlineMaxBytes"}
+	Origin_PStoreMetadataBackupFullEvery = IntParam{"Origin.PStoreMetadataBackupFullEvery"}
 	Origin_PStoreMetadataBackupsToKeep = IntParam{"Origin.PStoreMetadataBackupsToKeep"}
 	Origin_Port = IntParam{"Origin.Port"}
 	Origin_SSH_MaxRetries = IntParam{"Origin.SSH.MaxRetries"}
@@ -2139,8 +2366,10 @@ var (
 )
 
 var (
+	Cache_BlockDedup = BoolParam{"Cache.BlockDedup"}
 	Cache_DirectorTest = BoolParam{"Cache.DirectorTest"}
 	Cache_DisableClientX509 = BoolParam{"Cache.DisableClientX509"}
+	Cache_EnableAdmissionFilter = BoolParam{"Cache.EnableAdmissionFilter"}
 	Cache_EnableBroker = BoolParam{"Cache.EnableBroker"}
 	Cache_EnableChaosAPI = BoolParam{"Cache.EnableChaosAPI"}
 	Cache_EnableEvictionMonitoring = BoolParam{"Cache.EnableEvictionMonitoring"}
@@ -2151,21 +2380,29 @@ var (
 	Cache_EnableTLSClientAuth = BoolParam{"Cache.EnableTLSClientAuth"}
 	Cache_EnableV2 = BoolParam{"Cache.EnableV2"}
 	Cache_EnableVoms = BoolParam{"Cache.EnableVoms"}
+	Cache_EnableWarmupExport = BoolParam{"Cache.EnableWarmupExport"}
 	Cache_SelfTest = BoolParam{"Cache.SelfTest"}
+	Cache_WarmupOnStart = BoolParam{"Cache.WarmupOnStart"}
 	Client_AssumeDirectorServerHeader = BoolParam{"Client.AssumeDirectorServerHeader"}
 	Client_DisableHttpProxy = BoolParam{"Client.DisableHttpProxy"}
 	Client_DisableProxyFallback = BoolParam{"Client.DisableProxyFallback"}
 	Client_EnableOverwrites = BoolParam{"Client.EnableOverwrites"}
+	Client_EnableTransferFeedback = BoolParam{"Client.EnableTransferFeedback"}
 	Client_IsPlugin = BoolParam{"Client.IsPlugin"}
 	Debug = BoolParam{"Debug"}
+	Director_AdaptiveSortUseCacheProbes = BoolParam{"Director.AdaptiveSortUseCacheProbes"}
 	Director_AssumePresenceAtSingleOrigin = BoolParam{"Director.AssumePresenceAtSingleOrigin"}
 	Director_CachesPullFromCaches = BoolParam{"Director.CachesPullFromCaches"}
 	Director_CheckCachePresence = BoolParam{"Director.CheckCachePresence"}
 	Director_CheckOriginPresence = BoolParam{"Director.CheckOriginPresence"}
+	Director_EnableASNLocality = BoolParam{"Director.EnableASNLocality"}
+	Director_EnableAdPersistence = BoolParam{"Director.EnableAdPersistence"}
 	Director_EnableBroker = BoolParam{"Director.EnableBroker"}
+	Director_EnableCacheProbes = BoolParam{"Director.EnableCacheProbes"}
 	Director_EnableFederationMetadataHosting = BoolParam{"Director.EnableFederationMetadataHosting"}
 	Director_EnableOIDC = BoolParam{"Director.EnableOIDC"}
 	Director_EnableStat = BoolParam{"Director.EnableStat"}
+	Director_EnableTransferFeedback = BoolParam{"Director.EnableTransferFeedback"}
 	Director_FilterCachesInErrorState = BoolParam{"Director.FilterCachesInErrorState"}
 	DisableHttpProxy = BoolParam{"DisableHttpProxy"}
 	DisableProxyFallback = BoolParam{"DisableProxyFallback"}
@@ -2203,6 +2440,8 @@ var (
 	Origin_EnableWrites = BoolParam{"Origin.EnableWrites"}
 	Origin_HttpAuthTokenPassthrough = BoolParam{"Origin.HttpAuthTokenPassthrough"}
 	Origin_Multiuser = BoolParam{"Origin.Multiuser"}
+	Origin_PStoreBlockDedup = BoolParam{"Origin.PStoreBlockDedup"}
+	Origin_PStoreReplicationSynchronous = BoolParam{"Origin.PStoreReplicationSynchronous"}
 	Origin_SSH_AutoAddHostKey = BoolParam{"Origin.SSH.AutoAddHostKey"}
 	Origin_SSH_TunnelCallback = BoolParam{"Origin.SSH.TunnelCallback"}
 	Origin_ScitokensMapSubject = BoolParam{"Origin.ScitokensMapSubject"}
@@ -2235,8 +2474,10 @@ var (
 
 var (
 	Cache_DefaultCacheTimeout = DurationParam{"Cache.DefaultCacheTimeout"}
+	Cache_EvictionFrequencyHalfLife = DurationParam{"Cache.EvictionFrequencyHalfLife"}
 	Cache_EvictionMonitoringInterval = DurationParam{"Cache.EvictionMonitoringInterval"}
 	Cache_MinDirectorRefreshInterval = DurationParam{"Cache.MinDirectorRefreshInterval"}
+	Cache_PeerQueryTimeout = DurationParam{"Cache.PeerQueryTimeout"}
 	Cache_SelfTestInterval = DurationParam{"Cache.SelfTestInterval"}
 	Cache_SelfTestMaxAge = DurationParam{"Cache.SelfTestMaxAge"}
 	Cache_Throttle_EMAWindow = DurationParam{"Cache.Throttle.EMAWindow"}
@@ -2246,14 +2487,19 @@ var (
 	Client_SlowTransferRampupTime = DurationParam{"Client.SlowTransferRampupTime"}
 	Client_SlowTransferWindow = DurationParam{"Client.SlowTransferWindow"}
 	Client_StoppedTransferTimeout = DurationParam{"Client.StoppedTransferTimeout"}
+	Director_AdPersistenceInterval = DurationParam{"Director.AdPersistenceInterval"}
 	Director_AdaptiveSortEWMATimeConstant = DurationParam{"Director.AdaptiveSortEWMATimeConstant"}
 	Director_AdvertisementTTL = DurationParam{"Director.AdvertisementTTL"}
+	Director_CachePresenceNegativeTTL = DurationParam{"Director.CachePresenceNegativeTTL"}
 	Director_CachePresenceTTL = DurationParam{"Director.CachePresenceTTL"}
+	Director_CacheProbeInterval = DurationParam{"Director.CacheProbeInterval"}
+	Director_CacheProbeTimeout = DurationParam{"Director.CacheProbeTimeout"}
 	Director_FedTokenLifetime = DurationParam{"Director.FedTokenLifetime"}
 	Director_MetadataComparisonInterval = DurationParam{"Director.MetadataComparisonInterval"}
 	Director_OriginCacheHealthTestInterval = DurationParam{"Director.OriginCacheHealthTestInterval"}
 	Director_RegistryQueryInterval = DurationParam{"Director.RegistryQueryInterval"}
 	Director_StatTimeout = DurationParam{"Director.StatTimeout"}
+	Director_TransferFeedbackWindow = DurationParam{"Director.TransferFeedbackWindow"}
 	Federation_TopologyReloadInterval = DurationParam{"Federation.TopologyReloadInterval"}
 	Issuer_AccessTokenLifetime = DurationParam{"Issuer.AccessTokenLifetime"}
 	Issuer_AuthorizationCodeLifetime = DurationParam{"Issuer.AuthorizationCodeLifetime"}
@@ -2282,9 +2528,11 @@ var (
 	Origin_DiskUsageCalculationDelay = DurationParam{"Origin.DiskUsageCalculationDelay"}
 	Origin_DiskUsageCalculationInterval = DurationParam{"Origin.DiskUsageCalculationInterval"}
 	Origin_Globusv2TokenRefreshInterval = DurationParam{"Origin.Globusv2TokenRefreshInterval"}
+	Origin_PStoreDataBackupInterval = DurationParam{"Origin.PStoreDataBackupInterval"}
 	Origin_PStoreDataScanInterval = DurationParam{"Origin.PStoreDataScanInterval"}
 	Origin_PStoreIndexCheckInterval = DurationParam{"Origin.PStoreIndexCheckInterval"}
 	Origin_PStoreMetadataBackupInterval = DurationParam{"Origin.PStoreMetadataBackupInterval"}
+	Origin_PStoreReplicationSyncTimeout = DurationParam{"Origin.PStoreReplicationSyncTimeout"}
 	Origin_SSH_ChallengeTimeout = DurationParam{"Origin.SSH.ChallengeTimeout"}
 	Origin_SSH_ConnectTimeout = DurationParam{"Origin.SSH.ConnectTimeout"}
 	Origin_SSH_KeepaliveInterval = DurationParam{"Origin.SSH.KeepaliveInterval"}
@@ -2318,6 +2566,13 @@ var (
 )
 
 var (
+	Cache_NamespaceBlockCompression = ObjectParam{"Cache.NamespaceBlockCompression"}
+	Cache_NamespaceBlockDedup = ObjectParam{"Cache.NamespaceBlockDedup"}
+	Cache_NamespaceEvictionPolicies = ObjectParam{"Cache.NamespaceEvictionPolicies"}
+	Cache_NamespaceMaxObjectSizes = ObjectParam{"Cache.NamespaceMaxObjectSizes"}
+	Cache_NamespaceQuotas = ObjectParam{"Cache.NamespaceQuotas"}
+	Director_SiteNetworks = ObjectParam{"Director.SiteNetworks"}
+	Director_SortPolicies = ObjectParam{"Director.SortPolicies"}
 	GeoIPOverrides = ObjectParam{"GeoIPOverrides"}
 	Issuer_AuthorizationTemplates = ObjectParam{"Issuer.AuthorizationTemplates"}
 	Issuer_OIDCAuthenticationRequirements = ObjectParam{"Issuer.OIDCAuthenticationRequirements"}
@@ -2325,6 +2580,7 @@ var (
 	Lotman_PolicyDefinitions = ObjectParam{"Lotman.PolicyDefinitions"}
 	Origin_Exports = ObjectParam{"Origin.Exports"}
 	Origin_PStoreStorageDirs = ObjectParam{"Origin.PStoreStorageDirs"}
+	Origin_PStoreVersioning = ObjectParam{"Origin.PStoreVersioning"}
 	Registry_CustomRegistrationFields = ObjectParam{"Registry.CustomRegistrationFields"}
 	Registry_Institutions = ObjectParam{"Registry.Institutions"}
 	Shoveler_IPMapping = ObjectParam{"Shoveler.IPMapping"}
@@ -2348,10 +2604,12 @@ var paramByEnvVar map[string]Param
 
 func init() {
 	paramByName = map[string]Param{
+		"Cache.BlockCompression": Cache_BlockCompression,
 		"Cache.ClientStatisticsLocation": Cache_ClientStatisticsLocation,
 		"Cache.DataLocation": Cache_DataLocation,
 		"Cache.DataScanMode": Cache_DataScanMode,
 		"Cache.DbLocation": Cache_DbLocation,
+		"Cache.EvictionPolicy": Cache_EvictionPolicy,
 		"Cache.ExportLocation": Cache_ExportLocation,
 		"Cache.FedTokenLocation": Cache_FedTokenLocation,
 		"Cache.FilesBaseSize": Cache_FilesBaseSize,
@@ -2360,13 +2618,17 @@ func init() {
 		"Cache.HighWaterMark": Cache_HighWaterMark,
 		"Cache.LocalRoot": Cache_LocalRoot,
 		"Cache.LowWaterMark": Cache_LowWaterMark,
+		"Cache.MaxObjectSize": Cache_MaxObjectSize,
 		"Cache.MemoryCacheSize": Cache_MemoryCacheSize,
 		"Cache.NamespaceLocation": Cache_NamespaceLocation,
 		"Cache.PSSOrigin": Cache_PSSOrigin,
+		"Cache.ReadAheadSize": Cache_ReadAheadSize,
 		"Cache.RunLocation": Cache_RunLocation,
 		"Cache.SentinelLocation": Cache_SentinelLocation,
 		"Cache.StorageLocation": Cache_StorageLocation,
 		"Cache.Url": Cache_Url,
+		"Cache.WarmupRate": Cache_WarmupRate,
+		"Cache.WarmupTokenLocation": Cache_WarmupTokenLocation,
 		"Cache.XRootDPrefix": Cache_XRootDPrefix,
 		"ClientAgent.DbLocation": ClientAgent_DbLocation,
 		"ClientAgent.PidFile": ClientAgent_PidFile,
@@ -2374,9 +2636,12 @@ func init() {
 		"Client.CredentialFile": Client_CredentialFile,
 		"ConfigBase": ConfigBase,
 		"Director.AdvertiseUrl": Director_AdvertiseUrl,
+		"Director.CacheProbeObject": Director_CacheProbeObject,
 		"Director.CacheSortMethod": Director_CacheSortMethod,
+		"Director.ConsistentHashKey": Director_ConsistentHashKey,
 		"Director.DbLocation": Director_DbLocation,
 		"Director.DefaultResponse": Director_DefaultResponse,
+		"Director.GeoIPASNLocation": Director_GeoIPASNLocation,
 		"Director.GeoIPLocation": Director_GeoIPLocation,
 		"Director.MaxMindKeyFile": Director_MaxMindKeyFile,
 		"Director.SupportContactEmail": Director_SupportContactEmail,
@@ -2466,8 +2731,18 @@ func init() {
 		"Origin.MultiuserVarlinkSocketPath": Origin_MultiuserVarlinkSocketPath,
 		"Origin.NamespacePrefix": Origin_NamespacePrefix,
 		"Origin.ObjectProviderURL": Origin_ObjectProviderURL,
+		"Origin.PStoreBlockCompression": Origin_PStoreBlockCompression,
+		"Origin.PStoreDataBackupLocation": Origin_PStoreDataBackupLocation,
+		"Origin.PStoreDataBackupS3AccessKeyfile": Origin_PStoreDataBackupS3AccessKeyfile,
+		"Origin.PStoreDataBackupS3Region": Origin_PStoreDataBackupS3Region,
+		"Origin.PStoreDataBackupS3SecretKeyfile": Origin_PStoreDataBackupS3SecretKeyfile,
+		"Origin.PStoreDataBackupS3ServiceUrl": Origin_PStoreDataBackupS3ServiceUrl,
+		"Origin.PStoreDataBackupS3UrlStyle": Origin_PStoreDataBackupS3UrlStyle,
 		"Origin.PStoreLocation": Origin_PStoreLocation,
 		"Origin.PStoreMetadataBackupLocation": Origin_PStoreMetadataBackupLocation,
+		"Origin.PStoreReplicationPrimaryIssuer": Origin_PStoreReplicationPrimaryIssuer,
+		"Origin.PStoreReplicationRole": Origin_PStoreReplicationRole,
+		"Origin.PStoreReplicationSecondaryUrl": Origin_PStoreReplicationSecondaryUrl,
 		"Origin.RunLocation": Origin_RunLocation,
 		"Origin.S3AccessKeyfile": Origin_S3AccessKeyfile,
 		"Origin.S3Bucket": Origin_S3Bucket,
@@ -2551,7 +2826,9 @@ func init() {
 		"Cache.AllowedFederations": Cache_AllowedFederations,
 		"Cache.DataLocations": Cache_DataLocations,
 		"Cache.MetaLocations": Cache_MetaLocations,
+		"Cache.PeerCaches": Cache_PeerCaches,
 		"Cache.PermittedNamespaces": Cache_PermittedNamespaces,
+		"Cache.WarmupSources": Cache_WarmupSources,
 		"Client.PreferredCaches": Client_PreferredCaches,
 		"ConfigLocations": ConfigLocations,
 		"Director.CacheResponseHostnames": Director_CacheResponseHostnames,
@@ -2583,16 +2860,22 @@ func init() {
 		"Server.UserAdminUsers": Server_UserAdminUsers,
 		"Shoveler.OutputDestinations": Shoveler_OutputDestinations,
 		"Transfer.EnabledGroups": Transfer_EnabledGroups,
+		"Cache.AdmissionFilterCapacity": Cache_AdmissionFilterCapacity,
+		"Cache.AdmissionMinAccesses": Cache_AdmissionMinAccesses,
+		"Cache.BlockCompressionMinSavings": Cache_BlockCompressionMinSavings,
 		"Cache.BlocksToPrefetch": Cache_BlocksToPrefetch,
 		"Cache.Concurrency": Cache_Concurrency,
 		"Cache.ConcurrencyDegradedThreshold": Cache_ConcurrencyDegradedThreshold,
 		"Cache.DataScanResampleInterval": Cache_DataScanResampleInterval,
 		"Cache.EvictionMonitoringMaxDepth": Cache_EvictionMonitoringMaxDepth,
+		"Cache.MemoryCacheMinAccesses": Cache_MemoryCacheMinAccesses,
 		"Cache.Port": Cache_Port,
 		"Cache.Throttle.PendingBufferSize": Cache_Throttle_PendingBufferSize,
 		"Cache.Throttle.PerOriginActivePercent": Cache_Throttle_PerOriginActivePercent,
 		"Cache.Throttle.PerOriginPendingSize": Cache_Throttle_PerOriginPendingSize,
 		"Cache.Throttle.PerOriginStarvingPercent": Cache_Throttle_PerOriginStarvingPercent,
+		"Cache.WarmupConcurrency": Cache_WarmupConcurrency,
+		"Cache.WarmupMaxObjects": Cache_WarmupMaxObjects,
 		"Cache.WorkerCount": Cache_WorkerCount,
 		"ClientAgent.HistoryRetentionDays": ClientAgent_HistoryRetentionDays,
 		"ClientAgent.MaxConcurrentJobs": ClientAgent_MaxConcurrentJobs,
@@ -2600,11 +2883,16 @@ func init() {
 		"Client.MaximumDownloadSpeed": Client_MaximumDownloadSpeed,
 		"Client.MinimumDownloadSpeed": Client_MinimumDownloadSpeed,
 		"Client.WorkerCount": Client_WorkerCount,
+		"Director.ASNLocalityFactor": Director_ASNLocalityFactor,
 		"Director.AdaptiveSortTruncateConstant": Director_AdaptiveSortTruncateConstant,
 		"Director.CachePresenceCapacity": Director_CachePresenceCapacity,
+		"Director.ConsistentHashWorkingSetSize": Director_ConsistentHashWorkingSetSize,
 		"Director.MaxStatResponse": Director_MaxStatResponse,
 		"Director.MinStatResponse": Director_MinStatResponse,
+		"Director.SiteLocalityFactor": Director_SiteLocalityFactor,
 		"Director.StatConcurrencyLimit": Director_StatConcurrencyLimit,
+		"Director.TransferFeedbackErrorThreshold": Director_TransferFeedbackErrorThreshold,
+		"Director.TransferFeedbackMinAttempts": Director_TransferFeedbackMinAttempts,
 		"LocalCache.FDCacheSize": LocalCache_FDCacheSize,
 		"LocalCache.HighWaterMarkPercentage": LocalCache_HighWaterMarkPercentage,
 		"LocalCache.LowWaterMarkPercentage": LocalCache_LowWaterMarkPercentage,
@@ -2625,7 +2913,12 @@ func init() {
 		"Origin.DiskUsageCalculationRateLimit": Origin_DiskUsageCalculationRateLimit,
 		"Origin.MultiuserMinID": Origin_MultiuserMinID,
 		"Origin.MultiuserUmask": Origin_MultiuserUmask,
+		"Origin.PStoreDataBackupFullEvery": Origin_PStoreDataBackupFullEvery,
+		"Origin.PStoreDataBackupsToKeep": Origin_PStoreDataBackupsToKeep,
+		"Origin.PStoreErasureDataShards": Origin_PStoreErasureDataShards,
+		"Origin.PStoreErasureParityShards": Origin_PStoreErasureParityShards,
 		"Origin.PStoreInlineMaxBytes": Origin_PStoreInlineMaxBytes,
+		"Origin.PStoreMetadataBackupFullEvery": Origin_PStoreMetadataBackupFullEvery,
 		"Origin.PStoreMetadataBackupsToKeep": Origin_PStoreMetadataBackupsToKeep,
 		"Origin.Port": Origin_Port,
 		"Origin.SSH.MaxRetries": Origin_SSH_MaxRetries,
@@ -2647,8 +2940,10 @@ func init() {
 		"Xrootd.SummaryMonitoringPort": Xrootd_SummaryMonitoringPort,
 		"Origin.PStoreDataScanRate": Origin_PStoreDataScanRate,
 		"Origin.TransferRateLimit": Origin_TransferRateLimit,
+		"Cache.BlockDedup": Cache_BlockDedup,
 		"Cache.DirectorTest": Cache_DirectorTest,
 		"Cache.DisableClientX509": Cache_DisableClientX509,
+		"Cache.EnableAdmissionFilter": Cache_EnableAdmissionFilter,
 		"Cache.EnableBroker": Cache_EnableBroker,
 		"Cache.EnableChaosAPI": Cache_EnableChaosAPI,
 		"Cache.EnableEvictionMonitoring": Cache_EnableEvictionMonitoring,
@@ -2659,21 +2954,29 @@ func init() {
 		"Cache.EnableTLSClientAuth": Cache_EnableTLSClientAuth,
 		"Cache.EnableV2": Cache_EnableV2,
 		"Cache.EnableVoms": Cache_EnableVoms,
+		"Cache.EnableWarmupExport": Cache_EnableWarmupExport,
 		"Cache.SelfTest": Cache_SelfTest,
+		"Cache.WarmupOnStart": Cache_WarmupOnStart,
 		"Client.AssumeDirectorServerHeader": Client_AssumeDirectorServerHeader,
 		"Client.DisableHttpProxy": Client_DisableHttpProxy,
 		"Client.DisableProxyFallback": Client_DisableProxyFallback,
 		"Client.EnableOverwrites": Client_EnableOverwrites,
+		"Client.EnableTransferFeedback": Client_EnableTransferFeedback,
 		"Client.IsPlugin": Client_IsPlugin,
 		"Debug": Debug,
+		"Director.AdaptiveSortUseCacheProbes": Director_AdaptiveSortUseCacheProbes,
 		"Director.AssumePresenceAtSingleOrigin": Director_AssumePresenceAtSingleOrigin,
 		"Director.CachesPullFromCaches": Director_CachesPullFromCaches,
 		"Director.CheckCachePresence": Director_CheckCachePresence,
 		"Director.CheckOriginPresence": Director_CheckOriginPresence,
+		"Director.EnableASNLocality": Director_EnableASNLocality,
+		"Director.EnableAdPersistence": Director_EnableAdPersistence,
 		"Director.EnableBroker": Director_EnableBroker,
+		"Director.EnableCacheProbes": Director_EnableCacheProbes,
 		"Director.EnableFederationMetadataHosting": Director_EnableFederationMetadataHosting,
 		"Director.EnableOIDC": Director_EnableOIDC,
 		"Director.EnableStat": Director_EnableStat,
+		"Director.EnableTransferFeedback": Director_EnableTransferFeedback,
 		"Director.FilterCachesInErrorState": Director_FilterCachesInErrorState,
 		"DisableHttpProxy": DisableHttpProxy,
 		"DisableProxyFallback": DisableProxyFallback,
@@ -2711,6 +3014,8 @@ func init() {
 		"Origin.EnableWrites": Origin_EnableWrites,
 		"Origin.HttpAuthTokenPassthrough": Origin_HttpAuthTokenPassthrough,
 		"Origin.Multiuser": Origin_Multiuser,
+		"Origin.PStoreBlockDedup": Origin_PStoreBlockDedup,
+		"Origin.PStoreReplicationSynchronous": Origin_PStoreReplicationSynchronous,
 		"Origin.SSH.AutoAddHostKey": Origin_SSH_AutoAddHostKey,
 		"Origin.SSH.TunnelCallback": Origin_SSH_TunnelCallback,
 		"Origin.ScitokensMapSubject": Origin_ScitokensMapSubject,
@@ -2740,8 +3045,10 @@ func init() {
 		"Xrootd.AutoShutdownEnabled": Xrootd_AutoShutdownEnabled,
 		"Xrootd.EnableLocalMonitoring": Xrootd_EnableLocalMonitoring,
 		"Cache.DefaultCacheTimeout": Cache_DefaultCacheTimeout,
+		"Cache.EvictionFrequencyHalfLife": Cache_EvictionFrequencyHalfLife,
 		"Cache.EvictionMonitoringInterval": Cache_EvictionMonitoringInterval,
 		"Cache.MinDirectorRefreshInterval": Cache_MinDirectorRefreshInterval,
+		"Cache.PeerQueryTimeout": Cache_PeerQueryTimeout,
 		"Cache.SelfTestInterval": Cache_SelfTestInterval,
 		"Cache.SelfTestMaxAge": Cache_SelfTestMaxAge,
 		"Cache.Throttle.EMAWindow": Cache_Throttle_EMAWindow,
@@ -2751,14 +3058,19 @@ func init() {
 		"Client.SlowTransferRampupTime": Client_SlowTransferRampupTime,
 		"Client.SlowTransferWindow": Client_SlowTransferWindow,
 		"Client.StoppedTransferTimeout": Client_StoppedTransferTimeout,
+		"Director.AdPersistenceInterval": Director_AdPersistenceInterval,
 		"Director.AdaptiveSortEWMATimeConstant": Director_AdaptiveSortEWMATimeConstant,
 		"Director.AdvertisementTTL": Director_AdvertisementTTL,
+		"Director.CachePresenceNegativeTTL": Director_CachePresenceNegativeTTL,
 		"Director.CachePresenceTTL": Director_CachePresenceTTL,
+		"Director.CacheProbeInterval": Director_CacheProbeInterval,
+		"Director.CacheProbeTimeout": Director_CacheProbeTimeout,
 		"Director.FedTokenLifetime": Director_FedTokenLifetime,
 		"Director.MetadataComparisonInterval": Director_MetadataComparisonInterval,
 		"Director.OriginCacheHealthTestInterval": Director_OriginCacheHealthTestInterval,
 		"Director.RegistryQueryInterval": Director_RegistryQueryInterval,
 		"Director.StatTimeout": Director_StatTimeout,
+		"Director.TransferFeedbackWindow": Director_TransferFeedbackWindow,
 		"Federation.TopologyReloadInterval": Federation_TopologyReloadInterval,
 		"Issuer.AccessTokenLifetime": Issuer_AccessTokenLifetime,
 		"Issuer.AuthorizationCodeLifetime": Issuer_AuthorizationCodeLifetime,
@@ -2787,9 +3099,11 @@ func init() {
 		"Origin.DiskUsageCalculationDelay": Origin_DiskUsageCalculationDelay,
 		"Origin.DiskUsageCalculationInterval": Origin_DiskUsageCalculationInterval,
 		"Origin.Globusv2TokenRefreshInterval": Origin_Globusv2TokenRefreshInterval,
+		"Origin.PStoreDataBackupInterval": Origin_PStoreDataBackupInterval,
 		"Origin.PStoreDataScanInterval": Origin_PStoreDataScanInterval,
 		"Origin.PStoreIndexCheckInterval": Origin_PStoreIndexCheckInterval,
 		"Origin.PStoreMetadataBackupInterval": Origin_PStoreMetadataBackupInterval,
+		"Origin.PStoreReplicationSyncTimeout": Origin_PStoreReplicationSyncTimeout,
 		"Origin.SSH.ChallengeTimeout": Origin_SSH_ChallengeTimeout,
 		"Origin.SSH.ConnectTimeout": Origin_SSH_ConnectTimeout,
 		"Origin.SSH.KeepaliveInterval": Origin_SSH_KeepaliveInterval,
@@ -2820,6 +3134,13 @@ func init() {
 		"Xrootd.HttpMaxDelay": Xrootd_HttpMaxDelay,
 		"Xrootd.MaxStartupWait": Xrootd_MaxStartupWait,
 		"Xrootd.ShutdownTimeout": Xrootd_ShutdownTimeout,
+		"Cache.NamespaceBlockCompression": Cache_NamespaceBlockCompression,
+		"Cache.NamespaceBlockDedup": Cache_NamespaceBlockDedup,
+		"Cache.NamespaceEvictionPolicies": Cache_NamespaceEvictionPolicies,
+		"Cache.NamespaceMaxObjectSizes": Cache_NamespaceMaxObjectSizes,
+		"Cache.NamespaceQuotas": Cache_NamespaceQuotas,
+		"Director.SiteNetworks": Director_SiteNetworks,
+		"Director.SortPolicies": Director_SortPolicies,
 		"GeoIPOverrides": GeoIPOverrides,
 		"Issuer.AuthorizationTemplates": Issuer_AuthorizationTemplates,
 		"Issuer.OIDCAuthenticationRequirements": Issuer_OIDCAuthenticationRequirements,
@@ -2827,6 +3148,7 @@ func init() {
 		"Lotman.PolicyDefinitions": Lotman_PolicyDefinitions,
 		"Origin.Exports": Origin_Exports,
 		"Origin.PStoreStorageDirs": Origin_PStoreStorageDirs,
+		"Origin.PStoreVersioning": Origin_PStoreVersioning,
 		"Registry.CustomRegistrationFields": Registry_CustomRegistrationFields,
 		"Registry.Institutions": Registry_Institutions,
 		"Shoveler.IPMapping": Shoveler_IPMapping,
@@ -27,7 +27,12 @@ import (
 
 type Config struct {
 	Cache struct {
+		AdmissionFilterCapacity int `mapstructure:"admissionfiltercapacity" yaml:"AdmissionFilterCapacity"`
+		AdmissionMinAccesses int `mapstructure:"admissionminaccesses" yaml:"AdmissionMinAccesses"`
 		AllowedFederations []string `mapstructure:"allowedfederations" yaml:"AllowedFederations"`
+		BlockCompression string `mapstructure:"blockcompression" yaml:"BlockCompression"`
+		BlockCompressionMinSavings int `mapstructure:"blockcompressionminsavings" yaml:"BlockCompressionMinSavings"`
+		BlockDedup bool `mapstructure:"blockdedup" yaml:"BlockDedup"`
 		BlocksToPrefetch int `mapstructure:"blockstoprefetch" yaml:"BlocksToPrefetch"`
 		ClientStatisticsLocation string `mapstructure:"clientstatisticslocation" yaml:"ClientStatisticsLocation"`
 		Concurrency int `mapstructure:"concurrency" yaml:"Concurrency"`
@@ -40,6 +45,7 @@ type Config struct {
 		DefaultCacheTimeout time.Duration `mapstructure:"defaultcachetimeout" yaml:"DefaultCacheTimeout"`
 		DirectorTest bool `mapstructure:"directortest" yaml:"DirectorTest"`
 		DisableClientX509 bool `mapstructure:"disableclientx509" yaml:"DisableClientX509"`
+		EnableAdmissionFilter bool `mapstructure:"enableadmissionfilter" yaml:"EnableAdmissionFilter"`
 		EnableBroker bool `mapstructure:"enablebroker" yaml:"EnableBroker"`
 		EnableChaosAPI bool `mapstructure:"enablechaosapi" yaml:"EnableChaosAPI"`
 		EnableEvictionMonitoring bool `mapstructure:"enableevictionmonitoring" yaml:"EnableEvictionMonitoring"`
@@ -50,8 +56,11 @@ type Config struct {
 		EnableTLSClientAuth bool `mapstructure:"enabletlsclientauth" yaml:"EnableTLSClientAuth"`
 		EnableV2 bool `mapstructure:"enablev2" yaml:"EnableV2"`
 		EnableVoms bool `mapstructure:"enablevoms" yaml:"EnableVoms"`
+		EnableWarmupExport bool `mapstructure:"enablewarmupexport" yaml:"EnableWarmupExport"`
+		EvictionFrequencyHalfLife time.Duration `mapstructure:"evictionfrequencyhalflife" yaml:"EvictionFrequencyHalfLife"`
 		EvictionMonitoringInterval time.Duration `mapstructure:"evictionmonitoringinterval" yaml:"EvictionMonitoringInterval"`
 		EvictionMonitoringMaxDepth int `mapstructure:"evictionmonitoringmaxdepth" yaml:"EvictionMonitoringMaxDepth"`
+		EvictionPolicy string `mapstructure:"evictionpolicy" yaml:"EvictionPolicy"`
 		ExportLocation string `mapstructure:"exportlocation" yaml:"ExportLocation"`
 		FedTokenLocation string `mapstructure:"fedtokenlocation" yaml:"FedTokenLocation"`
 		FilesBaseSize string `mapstructure:"filesbasesize" yaml:"FilesBaseSize"`
@@ -60,13 +69,23 @@ type Config struct {
 		HighWaterMark string `mapstructure:"highwatermark" yaml:"HighWaterMark"`
 		LocalRoot string `mapstructure:"localroot" yaml:"LocalRoot"`
 		LowWaterMark string `mapstructure:"lowwatermark" yaml:"LowWaterMark"`
+		MaxObjectSize string `mapstructure:"maxobjectsize" yaml:"MaxObjectSize"`
+		MemoryCacheMinAccesses int `mapstructure:"memorycacheminaccesses" yaml:"MemoryCacheMinAccesses"`
 		MemoryCacheSize string `mapstructure:"memorycachesize" yaml:"MemoryCacheSize"`
 		MetaLocations []string `mapstructure:"metalocations" yaml:"MetaLocations"`
 		MinDirectorRefreshInterval time.Duration `mapstructure:"mindirectorrefreshinterval" yaml:"MinDirectorRefreshInterval"`
+		NamespaceBlockCompression any `mapstructure:"namespaceblockcompression" yaml:"NamespaceBlockCompression"`
+		NamespaceBlockDedup any `mapstructure:"namespaceblockdedup" yaml:"NamespaceBlockDedup"`
+		NamespaceEvictionPolicies any `mapstructure:"namespaceevictionpolicies" yaml:"NamespaceEvictionPolicies"`
 		NamespaceLocation string `mapstructure:"namespacelocation" yaml:"NamespaceLocation"`
+		NamespaceMaxObjectSizes any `mapstructure:"namespacemaxobjectsizes" yaml:"NamespaceMaxObjectSizes"`
+		NamespaceQuotas any `mapstructure:"namespacequotas" yaml:"NamespaceQuotas"`
 		PSSOrigin string `mapstructure:"pssorigin" yaml:"PSSOrigin"`
+		PeerCaches []string `mapstructure:"peercaches" yaml:"PeerCaches"`
+		PeerQueryTimeout time.Duration `mapstructure:"peerquerytimeout" yaml:"PeerQueryTimeout"`
 		PermittedNamespaces []string `mapstructure:"permittednamespaces" yaml:"PermittedNamespaces"`
 		Port int `mapstructure:"port" yaml:"Port"`
+		ReadAheadSize string `mapstructure:"readaheadsize" yaml:"ReadAheadSize"`
 		RunLocation string `mapstructure:"runlocation" yaml:"RunLocation"`
 		SelfTest bool `mapstructure:"selftest" yaml:"SelfTest"`
 		SelfTestInterval time.Duration `mapstructure:"selftestinterval" yaml:"SelfTestInterval"`
@@ -82,6 +101,12 @@ type Config struct {
 			RetryAfter time.Duration `mapstructure:"retryafter" yaml:"RetryAfter"`
 		} `mapstructure:"throttle" yaml:"Throttle"`
 		Url string `mapstructure:"url" yaml:"Url"`
+		WarmupConcurrency int `mapstructure:"warmupconcurrency" yaml:"WarmupConcurrency"`
+		WarmupMaxObjects int `mapstructure:"warmupmaxobjects" yaml:"WarmupMaxObjects"`
+		WarmupOnStart bool `mapstructure:"warmuponstart" yaml:"WarmupOnStart"`
+		WarmupRate string `mapstructure:"warmuprate" yaml:"WarmupRate"`
+		WarmupSources []string `mapstructure:"warmupsources" yaml:"WarmupSources"`
+		WarmupTokenLocation string `mapstructure:"warmuptokenlocation" yaml:"WarmupTokenLocation"`
 		WorkerCount int `mapstructure:"workercount" yaml:"WorkerCount"`
 		XRootDPrefix string `mapstructure:"xrootdprefix" yaml:"XRootDPrefix"`
 	} `mapstructure:"cache" yaml:"Cache"`
@@ -92,6 +117,7 @@ type Config struct {
 		DisableHttpProxy bool `mapstructure:"disablehttpproxy" yaml:"DisableHttpProxy"`
 		DisableProxyFallback bool `mapstructure:"disableproxyfallback" yaml:"DisableProxyFallback"`
 		EnableOverwrites bool `mapstructure:"enableoverwrites" yaml:"EnableOverwrites"`
+		EnableTransferFeedback bool `mapstructure:"enabletransferfeedback" yaml:"EnableTransferFeedback"`
 		IsPlugin bool `mapstructure:"isplugin" yaml:"IsPlugin"`
 		MaximumDownloadSpeed int `mapstructure:"maximumdownloadspeed" yaml:"MaximumDownloadSpeed"`
 		MinimumDownloadSpeed int `mapstructure:"minimumdownloadspeed" yaml:"MinimumDownloadSpeed"`
@@ -114,27 +140,41 @@ type Config struct {
 	ConfigLocations []string `mapstructure:"configlocations" yaml:"ConfigLocations"`
 	Debug bool `mapstructure:"debug" yaml:"Debug"`
 	Director struct {
+		ASNLocalityFactor int `mapstructure:"asnlocalityfactor" yaml:"ASNLocalityFactor"`
+		AdPersistenceInterval time.Duration `mapstructure:"adpersistenceinterval" yaml:"AdPersistenceInterval"`
 		AdaptiveSortEWMATimeConstant time.Duration `mapstructure:"adaptivesortewmatimeconstant" yaml:"AdaptiveSortEWMATimeConstant"`
 		AdaptiveSortTruncateConstant int `mapstructure:"adaptivesorttruncateconstant" yaml:"AdaptiveSortTruncateConstant"`
+		AdaptiveSortUseCacheProbes bool `mapstructure:"adaptivesortusecacheprobes" yaml:"AdaptiveSortUseCacheProbes"`
 		AdvertiseUrl string `mapstructure:"advertiseurl" yaml:"AdvertiseUrl"`
 		AdvertisementTTL time.Duration `mapstructure:"advertisementttl" yaml:"AdvertisementTTL"`
 		AssumePresenceAtSingleOrigin bool `mapstructure:"assumepresenceatsingleorigin" yaml:"AssumePresenceAtSingleOrigin"`
 		CachePresenceCapacity int `mapstructure:"cachepresencecapacity" yaml:"CachePresenceCapacity"`
+		CachePresenceNegativeTTL time.Duration `mapstructure:"cachepresencenegativettl" yaml:"CachePresenceNegativeTTL"`
 		CachePresenceTTL time.Duration `mapstructure:"cachepresencettl" yaml:"CachePresenceTTL"`
+		CacheProbeInterval time.Duration `mapstructure:"cacheprobeinterval" yaml:"CacheProbeInterval"`
+		CacheProbeObject string `mapstructure:"cacheprobeobject" yaml:"CacheProbeObject"`
+		CacheProbeTimeout time.Duration `mapstructure:"cacheprobetimeout" yaml:"CacheProbeTimeout"`
 		CacheResponseHostnames []string `mapstructure:"cacheresponsehostnames" yaml:"CacheResponseHostnames"`
 		CacheSortMethod string `mapstructure:"cachesortmethod" yaml:"CacheSortMethod"`
 		CachesPullFromCaches bool `mapstructure:"cachespullfromcaches" yaml:"CachesPullFromCaches"`
 		CheckCachePresence bool `mapstructure:"checkcachepresence" yaml:"CheckCachePresence"`
 		CheckOriginPresence bool `mapstructure:"checkoriginpresence" yaml:"CheckOriginPresence"`
+		ConsistentHashKey string `mapstructure:"consistenthashkey" yaml:"ConsistentHashKey"`
+		ConsistentHashWorkingSetSize int `mapstructure:"consistenthashworkingsetsize" yaml:"ConsistentHashWorkingSetSize"`
 		DbLocation string `mapstructure:"dblocation" yaml:"DbLocation"`
 		DefaultResponse string `mapstructure:"defaultresponse" yaml:"DefaultResponse"`
+		EnableASNLocality bool `mapstructure:"enableasnlocality" yaml:"EnableASNLocality"`
+		EnableAdPersistence bool `mapstructure:"enableadpersistence" yaml:"EnableAdPersistence"`
 		EnableBroker bool `mapstructure:"enablebroker" yaml:"EnableBroker"`
+		EnableCacheProbes bool `mapstructure:"enablecacheprobes" yaml:"EnableCacheProbes"`
 		EnableFederationMetadataHosting bool `mapstructure:"enablefederationmetadatahosting" yaml:"EnableFederationMetadataHosting"`
 		EnableOIDC bool `mapstructure:"enableoidc" yaml:"EnableOIDC"`
 		EnableStat bool `mapstructure:"enablestat" yaml:"EnableStat"`
+		EnableTransferFeedback bool `mapstructure:"enabletransferfeedback" yaml:"EnableTransferFeedback"`
 		FedTokenLifetime time.Duration `mapstructure:"fedtokenlifetime" yaml:"FedTokenLifetime"`
 		FilterCachesInErrorState bool `mapstructure:"filtercachesinerrorstate" yaml:"FilterCachesInErrorState"`
 		FilteredServers []string `mapstructure:"filteredservers" yaml:"FilteredServers"`
+		GeoIPASNLocation string `mapstructure:"geoipasnlocation" yaml:"GeoIPASNLocation"`
 		GeoIPLocation string `mapstructure:"geoiplocation" yaml:"GeoIPLocation"`
 		MaxMindKeyFile string `mapstructure:"maxmindkeyfile" yaml:"MaxMindKeyFile"`
 		MaxStatResponse int `mapstructure:"maxstatresponse" yaml:"MaxStatResponse"`
@@ -143,10 +183,16 @@ type Config struct {
 		OriginCacheHealthTestInterval time.Duration `mapstructure:"origincachehealthtestinterval" yaml:"OriginCacheHealthTestInterval"`
 		OriginResponseHostnames []string `mapstructure:"originresponsehostnames" yaml:"OriginResponseHostnames"`
 		RegistryQueryInterval time.Duration `mapstructure:"registryqueryinterval" yaml:"RegistryQueryInterval"`
+		SiteLocalityFactor int `mapstructure:"sitelocalityfactor" yaml:"SiteLocalityFactor"`
+		SiteNetworks any `mapstructure:"sitenetworks" yaml:"SiteNetworks"`
+		SortPolicies any `mapstructure:"sortpolicies" yaml:"SortPolicies"`
 		StatConcurrencyLimit int `mapstructure:"statconcurrencylimit" yaml:"StatConcurrencyLimit"`
 		StatTimeout time.Duration `mapstructure:"stattimeout" yaml:"StatTimeout"`
 		SupportContactEmail string `mapstructure:"supportcontactemail" yaml:"SupportContactEmail"`
 		SupportContactUrl string `mapstructure:"supportcontacturl" yaml:"SupportContactUrl"`
+		TransferFeedbackErrorThreshold int `mapstructure:"transferfeedbackerrorthreshold" yaml:"TransferFeedbackErrorThreshold"`
+		TransferFeedbackMinAttempts int `mapstructure:"transferfeedbackminattempts" yaml:"TransferFeedbackMinAttempts"`
+		TransferFeedbackWindow time.Duration `mapstructure:"transferfeedbackwindow" yaml:"TransferFeedbackWindow"`
 	} `mapstructure:"director" yaml:"Director"`
 	DisableHttpProxy bool `mapstructure:"disablehttpproxy" yaml:"DisableHttpProxy"`
 	DisableProxyFallback bool `mapstructure:"disableproxyfallback" yaml:"DisableProxyFallback"`
@@ -358,15 +404,35 @@ type Config struct {
 		MultiuserVarlinkSocketPath string `mapstructure:"multiuservarlinksocketpath" yaml:"MultiuserVarlinkSocketPath"`
 		NamespacePrefix string `mapstructure:"namespaceprefix" yaml:"NamespacePrefix"`
 		ObjectProviderURL string `mapstructure:"objectproviderurl" yaml:"ObjectProviderURL"`
+		PStoreBlockCompression string `mapstructure:"pstoreblockcompression" yaml:"PStoreBlockCompression"`
+		PStoreBlockDedup bool `mapstructure:"pstoreblockdedup" yaml:"PStoreBlockDedup"`
+		PStoreDataBackupFullEvery int `mapstructure:"pstoredatabackupfullevery" yaml:"PStoreDataBackupFullEvery"`
+		PStoreDataBackupInterval time.Duration `mapstructure:"pstoredatabackupinterval" yaml:"PStoreDataBackupInterval"`
+		PStoreDataBackupLocation string `mapstructure:"pstoredatabackuplocation" yaml:"PStoreDataBackupLocation"`
+		PStoreDataBackupS3AccessKeyfile string `mapstructure:"pstoredatabackups3accesskeyfile" yaml:"PStoreDataBackupS3AccessKeyfile"`
+		PStoreDataBackupS3Region string `mapstructure:"pstoredatabackups3region" yaml:"PStoreDataBackupS3Region"`
+		PStoreDataBackupS3SecretKeyfile string `mapstructure:"pstoredatabackups3secretkeyfile" yaml:"PStoreDataBackupS3SecretKeyfile"`
+		PStoreDataBackupS3ServiceUrl string `mapstructure:"pstoredatabackups3serviceurl" yaml:"PStoreDataBackupS3ServiceUrl"`
+		PStoreDataBackupS3UrlStyle string `mapstructure:"pstoredatabackups3urlstyle" yaml:"PStoreDataBackupS3UrlStyle"`
+		PStoreDataBackupsToKeep int `mapstructure:"pstoredatabackupstokeep" yaml:"PStoreDataBackupsToKeep"`
 		PStoreDataScanInterval time.Duration `mapstructure:"pstoredatascaninterval" yaml:"PStoreDataScanInterval"`
 		PStoreDataScanRate byte_rate.ByteRate `mapstructure:"pstoredatascanrate" yaml:"PStoreDataScanRate"`
+		PStoreErasureDataShards int `mapstructure:"pstoreerasuredatashards" yaml:"PStoreErasureDataShards"`
+		PStoreErasureParityShards int `mapstructure:"pstoreerasureparityshards" yaml:"PStoreErasureParityShards"`
 		PStoreIndexCheckInterval time.Duration `mapstructure:"pstoreindexcheckinterval" yaml:"PStoreIndexCheckInterval"`
 		PStoreInlineMaxBytes int `mapstructure:"pstoreinlinemaxbytes" yaml:"PStoreInlineMaxBytes"`
 		PStoreLocation string `mapstructure:"pstorelocation" yaml:"PStoreLocation"`
+		PStoreMetadataBackupFullEvery int `mapstructure:"pstoremetadatabackupfullevery" yaml:"PStoreMetadataBackupFullEvery"`
 		PStoreMetadataBackupInterval time.Duration `mapstructure:"pstoremetadatabackupinterval" yaml:"PStoreMetadataBackupInterval"`
 		PStoreMetadataBackupLocation string `mapstructure:"pstoremetadatabackuplocation" yaml:"PStoreMetadataBackupLocation"`
 		PStoreMetadataBackupsToKeep int `mapstructure:"pstoremetadatabackupstokeep" yaml:"PStoreMetadataBackupsToKeep"`
+		PStoreReplicationPrimaryIssuer string `mapstructure:"pstorereplicationprimaryissuer" yaml:"PStoreReplicationPrimaryIssuer"`
+		PStoreReplicationRole string `mapstructure:"pstorereplicationrole" yaml:"PStoreReplicationRole"`
+		PStoreReplicationSecondaryUrl string `mapstructure:"pstorereplicationsecondaryurl" yaml:"PStoreReplicationSecondaryUrl"`
+		PStoreReplicationSyncTimeout time.Duration `mapstructure:"pstorereplicationsynctimeout" yaml:"PStoreReplicationSyncTimeout"`
+		PStoreReplicationSynchronous bool `mapstructure:"pstorereplicationsynchronous" yaml:"PStoreReplicationSynchronous"`
 		PStoreStorageDirs any `mapstructure:"pstorestoragedirs" yaml:"PStoreStorageDirs"`
+		PStoreVersioning any `mapstructure:"pstoreversioning" yaml:"PStoreVersioning"`
 		Port int `mapstructure:"port" yaml:"Port"`
 		RunLocation string `mapstructure:"runlocation" yaml:"RunLocation"`
 		S3AccessKeyfile string `mapstructure:"s3accesskeyfile" yaml:"S3AccessKeyfile"`
@@ -577,7 +643,12 @@ type Config struct {
 
 type configWithType struct {
 	Cache struct {
+		AdmissionFilterCapacity struct { Type string; Value int }
+		AdmissionMinAccesses struct { Type string; Value int }
 		AllowedFederations struct { Type string; Value []string }
+		BlockCompression struct { Type string; Value string }
+		BlockCompressionMinSavings struct { Type string; Value int }
+		BlockDedup struct { Type string; Value bool }
 		BlocksToPrefetch struct { Type string; Value int }
 		ClientStatisticsLocation struct { Type string; Value string }
 		Concurrency struct { Type string; Value int }
@@ -590,6 +661,7 @@ type configWithType struct {
 		DefaultCacheTimeout struct { Type string; Value time.Duration }
 		DirectorTest struct { Type string; Value bool }
 		DisableClientX509 struct { Type string; Value bool }
+		EnableAdmissionFilter struct { Type string; Value bool }
 		EnableBroker struct { Type string; Value bool }
 		EnableChaosAPI struct { Type string; Value bool }
 		EnableEvictionMonitoring struct { Type string; Value bool }
@@ -600,8 +672,11 @@ type configWithType struct {
 		EnableTLSClientAuth struct { Type string; Value bool }
 		EnableV2 struct { Type string; Value bool }
 		EnableVoms struct { Type string; Value bool }
+		EnableWarmupExport struct { Type string; Value bool }
+		EvictionFrequencyHalfLife struct { Type string; Value time.Duration }
 		EvictionMonitoringInterval struct { Type string; Value time.Duration }
 		EvictionMonitoringMaxDepth struct { Type string; Value int }
+		EvictionPolicy struct { Type string; Value string }
 		ExportLocation struct { Type string; Value string }
 		FedTokenLocation struct { Type string; Value string }
 		FilesBaseSize struct { Type string; Value string }
@@ -610,13 +685,23 @@ type configWithType struct {
 		HighWaterMark struct { Type string; Value string }
 		LocalRoot struct { Type string; Value string }
 		LowWaterMark struct { Type string; Value string }
+		MaxObjectSize struct { Type string; Value string }
+		MemoryCacheMinAccesses struct { Type string; Value int }
 		MemoryCacheSize struct { Type string; Value string }
 		MetaLocations struct { Type string; Value []string }
 		MinDirectorRefreshInterval struct { Type string; Value time.Duration }
+		NamespaceBlockCompression struct { Type string; Value any }
+		NamespaceBlockDedup struct { Type string; Value any }
+		NamespaceEvictionPolicies struct { Type string; Value any }
 		NamespaceLocation struct { Type string; Value string }
+		NamespaceMaxObjectSizes struct { Type string; Value any }
+		NamespaceQuotas struct { Type string; Value any }
 		PSSOrigin struct { Type string; Value string }
+		PeerCaches struct { Type string; Value []string }
+		PeerQueryTimeout struct { Type string; Value time.Duration }
 		PermittedNamespaces struct { Type string; Value []string }
 		Port struct { Type string; Value int }
+		ReadAheadSize struct { Type string; Value string }
 		RunLocation struct { Type string; Value string }
 		SelfTest struct { Type string; Value bool }
 		SelfTestInterval struct { Type string; Value time.Duration }
@@ -632,6 +717,12 @@ type configWithType struct {
 			RetryAfter struct { Type string; Value time.Duration }
 		}
 		Url struct { Type string; Value string }
+		WarmupConcurrency struct { Type string; Value int }
+		WarmupMaxObjects struct { Type string; Value int }
+		WarmupOnStart struct { Type string; Value bool }
+		WarmupRate struct { Type string; Value string }
+		WarmupSources struct { Type string; Value []string }
+		WarmupTokenLocation struct { Type string; Value string }
 		WorkerCount struct { Type string; Value int }
 		XRootDPrefix struct { Type string; Value string }
 	}
@@ -642,6 +733,7 @@ type configWithType struct {
 		DisableHttpProxy struct { Type string; Value bool }
 		DisableProxyFallback struct { Type string; Value bool }
 		EnableOverwrites struct { Type string; Value bool }
+		EnableTransferFeedback struct { Type string; Value bool }
 		IsPlugin struct { Type string; Value bool }
 		MaximumDownloadSpeed struct { Type string; Value int }
 		MinimumDownloadSpeed struct { Type string; Value int }
@@ -664,27 +756,41 @@ type configWithType struct {
 	ConfigLocations struct { Type string; Value []string }
 	Debug struct { Type string; Value bool }
 	Director struct {
+		ASNLocalityFactor struct { Type string; Value int }
+		AdPersistenceInterval struct { Type string; Value time.Duration }
 		AdaptiveSortEWMATimeConstant struct { Type string; Value time.Duration }
 		AdaptiveSortTruncateConstant struct { Type string; Value int }
+		AdaptiveSortUseCacheProbes struct { Type string; Value bool }
 		AdvertiseUrl struct { Type string; Value string }
 		AdvertisementTTL struct { Type string; Value time.Duration }
 		AssumePresenceAtSingleOrigin struct { Type string; Value bool }
 		CachePresenceCapacity struct { Type string; Value int }
+		CachePresenceNegativeTTL struct { Type string; Value time.Duration }
 		CachePresenceTTL struct { Type string; Value time.Duration }
+		CacheProbeInterval struct { Type string; Value time.Duration }
+		CacheProbeObject struct { Type string; Value string }
+		CacheProbeTimeout struct { Type string; Value time.Duration }
 		CacheResponseHostnames struct { Type string; Value []string }
 		CacheSortMethod struct { Type string; Value string }
 		CachesPullFromCaches struct { Type string; Value bool }
 		CheckCachePresence struct { Type string; Value bool }
 		CheckOriginPresence struct { Type string; Value bool }
+		ConsistentHashKey struct { Type string; Value string }
+		ConsistentHashWorkingSetSize struct { Type string; Value int }
 		DbLocation struct { Type string; Value string }
 		DefaultResponse struct { Type string; Value string }
+		EnableASNLocality struct { Type string; Value bool }
+		EnableAdPersistence struct { Type string; Value bool }
 		EnableBroker struct { Type string; Value bool }
+		EnableCacheProbes struct { Type string; Value bool }
 		EnableFederationMetadataHosting struct { Type string; Value bool }
 		EnableOIDC struct { Type string; Value bool }
 		EnableStat struct { Type string; Value bool }
+		EnableTransferFeedback struct { Type string; Value bool }
 		FedTokenLifetime struct { Type string; Value time.Duration }
 		FilterCachesInErrorState struct { Type string; Value bool }
 		FilteredServers struct { Type string; Value []string }
+		GeoIPASNLocation struct { Type string; Value string }
 		GeoIPLocation struct { Type string; Value string }
 		MaxMindKeyFile struct { Type string; Value string }
 		MaxStatResponse struct { Type string; Value int }
@@ -693,10 +799,16 @@ type configWithType struct {
 		OriginCacheHealthTestInterval struct { Type string; Value time.Duration }
 		OriginResponseHostnames struct { Type string; Value []string }
 		RegistryQueryInterval struct { Type string; Value time.Duration }
+		SiteLocalityFactor struct { Type string; Value int }
+		SiteNetworks struct { Type string; Value any }
+		SortPolicies struct { Type string; Value any }
 		StatConcurrencyLimit struct { Type string; Value int }
 		StatTimeout struct { Type string; Value time.Duration }
 		SupportContactEmail struct { Type string; Value string }
 		SupportContactUrl struct { Type string; Value string }
+		TransferFeedbackErrorThreshold struct { Type string; Value int }
+		TransferFeedbackMinAttempts struct { Type string; Value int }
+		TransferFeedbackWindow struct { Type string; Value time.Duration }
 	}
 	DisableHttpProxy struct { Type string; Value bool }
 	DisableProxyFallback struct { Type string; Value bool }
@@ -908,15 +1020,35 @@ type configWithType struct {
 		MultiuserVarlinkSocketPath struct { Type string; Value string }
 		NamespacePrefix struct { Type string; Value string }
 		ObjectProviderURL struct { Type string; Value string }
+		PStoreBlockCompression struct { Type string; Value string }
+		PStoreBlockDedup struct { Type string; Value bool }
+		PStoreDataBackupFullEvery struct { Type string; Value int }
+		PStoreDataBackupInterval struct { Type string; Value time.Duration }
+		PStoreDataBackupLocation struct { Type string; Value string }
+		PStoreDataBackupS3AccessKeyfile struct { Type string; Value string }
+		PStoreDataBackupS3Region struct { Type string; Value string }
+		PStoreDataBackupS3SecretKeyfile struct { Type string; Value string }
+		PStoreDataBackupS3ServiceUrl struct { Type string; Value string }
+		PStoreDataBackupS3UrlStyle struct { Type string; Value string }
+		PStoreDataBackupsToKeep struct { Type string; Value int }
 		PStoreDataScanInterval struct { Type string; Value time.Duration }
 		PStoreDataScanRate struct { Type string; Value byte_rate.ByteRate }
+		PStoreErasureDataShards struct { Type string; Value int }
+		PStoreErasureParityShards struct { Type string; Value int }
 		PStoreIndexCheckInterval struct { Type string; Value time.Duration }
 		PStoreInlineMaxBytes struct { Type string; Value int }
 		PStoreLocation struct { Type string; Value string }
+		PStoreMetadataBackupFullEvery struct { Type string; Value int }
 		PStoreMetadataBackupInterval struct { Type string; Value time.Duration }
 		PStoreMetadataBackupLocation struct { Type string; Value string }
 		PStoreMetadataBackupsToKeep struct { Type string; Value int }
+		PStoreReplicationPrimaryIssuer struct { Type string; Value string }
+		PStoreReplicationRole struct { Type string; Value string }
+		PStoreReplicationSecondaryUrl struct { Type string; Value string }
+		PStoreReplicationSyncTimeout struct { Type string; Value time.Duration }
+		PStoreReplicationSynchronous struct { Type string; Value bool }
 		PStoreStorageDirs struct { Type string; Value any }
+		PStoreVersioning struct { Type string; Value any }
 		Port struct { Type string; Value int }
 		RunLocation struct { Type string; Value string }
 		S3AccessKeyfile struct { Type string; Value string }
@@ -102,6 +102,8 @@ import (
 	"bufio"
 	"compress/gzip"
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -116,7 +118,9 @@ import (
 	"github.com/pelicanplatform/pelican/metrics"
 )
 
-// BackupEncrypted writes a snapshot sealed to keys.
+// BackupEncrypted writes a full snapshot sealed to keys, and returns the
+// catalog version it runs through: the since for an incremental that follows
+// it is one past that.
 //
 // This is the only way to write a snapshot.  Encryption is not optional and
 // there is no key to forget to configure: the archive's backup key is derived
@@ -137,14 +141,73 @@ import (
 // Truncation and tampering are detected by the AEAD construction itself, so
 // there is no second framing layer inside the sealed one.
 func (s *Store) BackupEncrypted(w io.Writer, keys BackupKeys) (uint64, error) {
-	// BadgerDB writes the snapshot rather than handing it back, so bridge the
+	return s.backupSince(w, keys, 0)
+}
+
+// BackupIncremental writes a snapshot of only what changed in the catalog
+// from version since onwards -- new and rewritten records, and the tombstones
+// of deleted ones -- sealed exactly as a full one is.  since is one past the
+// version the previous snapshot of the chain ran through, and the return is
+// this snapshot's, for the next.
+//
+// An incremental restores only on top of the chain it continues; Restore
+// checks that, so a snapshot applied out of order or with one missing is
+// refused rather than loaded.
+func (s *Store) BackupIncremental(w io.Writer, keys BackupKeys, since uint64) (uint64, error) {
+	if since == 0 {
+		return 0, errors.New("an incremental backup needs the version the previous snapshot ran through")
+	}
+	return s.backupSince(w, keys, since)
+}
+
+// backupSince writes a snapshot of every record at version since or later;
+// zero is a full snapshot.
+func (s *Store) backupSince(w io.Writer, keys BackupKeys, since uint64) (uint64, error) {
+	// Read the catalog's high-water mark before the stream starts, so that it
+	// cannot claim a version the stream did not see.  A write that lands
+	// between the two is in this snapshot and, because the next one starts
+	// from here, in that one too; overlap is harmless, a gap would not be.
+	through := s.bdb.MaxVersion()
+	info := snapshotInfo{Kind: snapshotFull, Through: through}
+	if since > 0 {
+		info = snapshotInfo{Kind: snapshotIncremental, Since: since, Through: through}
+	}
+	var version uint64
+	err := sealStream(w, keys, info, func(zw io.Writer) error {
+		var bErr error
+		version, bErr = s.bdb.Backup(zw, badgerSince(since))
+		return errors.Wrap(bErr, "failed to back up the pstore metadata")
+	})
+	if err != nil {
+		return 0, err
+	}
+	return max(through, version), nil
+}
+
+// badgerSince converts a chain's since, the first version an incremental
+// covers, into BadgerDB's, which is the last version it leaves out.
+func badgerSince(since uint64) uint64 {
+	if since == 0 {
+		return 0
+	}
+	return since - 1
+}
+
+// sealStream writes a sealed container whose body is whatever write produces.
+//
+// What the container holds rides in the gzip header, inside the sealed body,
+// so it is authenticated along with the records it describes.
+func sealStream(w io.Writer, keys BackupKeys, info snapshotInfo, write func(io.Writer) error) error {
+	extra, err := json.Marshal(info)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode the snapshot header")
+	}
+
+	// BadgerDB writes a snapshot rather than handing it back, so bridge the
 	// two with a pipe instead of buffering a whole catalog in memory.
 	pr, pw := io.Pipe()
 
-	var (
-		version uint64
-		bErr    error
-	)
+	var wErr error
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
@@ -152,27 +215,79 @@ func (s *Store) BackupEncrypted(w io.Writer, keys BackupKeys) (uint64, error) {
 		// path strings and near-identical metadata records, so it shrinks a
 		// great deal, and ciphertext would not compress at all afterwards.
 		zw := gzip.NewWriter(pw)
-		version, bErr = s.bdb.Backup(zw, 0)
-		if bErr == nil {
-			bErr = zw.Close()
+		zw.Extra = extra
+		wErr = write(zw)
+		if wErr == nil {
+			wErr = zw.Close()
 		}
-		pw.CloseWithError(bErr)
+		pw.CloseWithError(wErr)
 	}()
 
 	if err := encryptBackup(w, pr, keys); err != nil {
 		pr.CloseWithError(err)
 		<-done
-		return 0, err
+		return err
 	}
 	<-done
-	if bErr != nil {
-		return 0, errors.Wrap(bErr, "failed to back up the pstore metadata")
+	return wErr
+}
+
+// Snapshot kinds, as recorded in a sealed container's header.
+const (
+	snapshotFull        = "full"
+	snapshotIncremental = "incremental"
+	// snapshotManifest is an object-data backup's manifest (backup_data.go),
+	// which shares the container but is not a catalog at all.
+	snapshotManifest = "data-manifest"
+)
+
+// snapshotInfo says what a sealed container holds.
+//
+// Snapshots written before incrementals existed carry none, and are full.
+type snapshotInfo struct {
+	Kind string `json:"kind"`
+	// Since is the first catalog version an incremental covers.
+	Since uint64 `json:"since,omitempty"`
+	// Through is the last catalog version the snapshot covers.
+	Through uint64 `json:"through,omitempty"`
+}
+
+// readSnapshotInfo decodes the header of an opened container, treating one
+// without a header as the full snapshot it must be.
+func readSnapshotInfo(zr *gzip.Reader) (snapshotInfo, error) {
+	if len(zr.Extra) == 0 {
+		return snapshotInfo{Kind: snapshotFull}, nil
+	}
+	var info snapshotInfo
+	if err := json.Unmarshal(zr.Extra, &info); err != nil {
+		return info, errors.Wrap(err, "the snapshot's header is not readable")
+	}
+	switch info.Kind {
+	case snapshotFull, snapshotIncremental, snapshotManifest:
+		return info, nil
+	default:
+		return info, errors.Errorf("the snapshot is of a kind this build does not know (%q)", info.Kind)
 	}
-	return version, nil
 }
 
 // restoreEncrypted loads a snapshot sealed by BackupEncrypted.
 func (s *Store) restoreEncrypted(r io.Reader, keys BackupKeys) error {
+	return openSealed(r, keys, func(info snapshotInfo, body io.Reader) error {
+		if info.Kind == snapshotManifest {
+			return errors.New("this file is the manifest of an object-data backup, not a " +
+				"metadata snapshot; restore it with `pelican-server origin pstore data-restore`")
+		}
+		return s.restoreStream(body, info)
+	})
+}
+
+// openSealed decrypts and decompresses a sealed container, handing its header
+// and body to fn.
+//
+// fn may stop reading early; the rest of the body is drained afterwards, so
+// the decryption is always carried through to the terminating chunk and a
+// damaged tail is reported even when fn did not need it.
+func openSealed(r io.Reader, keys BackupKeys, fn func(info snapshotInfo, body io.Reader) error) error {
 	pr, pw := io.Pipe()
 
 	// The decryption error is kept rather than only propagated through the
@@ -200,7 +315,16 @@ func (s *Store) restoreEncrypted(r io.Reader, keys BackupKeys) error {
 	}
 	defer zr.Close()
 
-	if err := s.restoreStream(zr); err != nil {
+	info, err := readSnapshotInfo(zr)
+	if err == nil {
+		err = fn(info, zr)
+	}
+	if err == nil {
+		if _, dErr := io.Copy(io.Discard, zr); dErr != nil {
+			err = errors.Wrap(dErr, "the snapshot is damaged past the point that was read")
+		}
+	}
+	if err != nil {
 		pr.CloseWithError(err)
 		<-done
 		return err
@@ -230,8 +354,13 @@ func (s *Store) restoreEncrypted(r io.Reader, keys BackupKeys) error {
 //   - keys.FileKey -- that one archive's own key from DeriveFileKey, which
 //     opens it and nothing else.
 //
-// The store must be empty: restoring over live records would interleave two
-// namespaces rather than replace one.
+// A full snapshot needs an empty store: restoring over live records would
+// interleave two namespaces rather than replace one.  An incremental is the
+// opposite, and applies only on top of the snapshot it continues, restored
+// into this same handle: a chain is restored by calling Restore once per file,
+// full snapshot first.  One that skips a link -- or arrives with nothing
+// restored before it -- is refused before a record is written, since the
+// catalog it would leave behind is missing whatever the absent link changed.
 //
 // **A failed restore is not a no-op.**  BadgerDB's loader writes as it reads,
 // so a snapshot that turns out to be truncated or damaged part-way through
@@ -285,17 +414,33 @@ func (s *Store) Restore(r io.Reader, keys BackupKeys) error {
 // r must fail rather than report EOF when its input was truncated, which the
 // sealed container's reader does: its terminating chunk is the only clean end
 // of stream.
-func (s *Store) restoreStream(r io.Reader) error {
+func (s *Store) restoreStream(r io.Reader, info snapshotInfo) error {
 	if err := s.checkWritable(); err != nil {
 		return err
 	}
-	empty, err := s.isEmpty()
-	if err != nil {
-		return err
-	}
-	if !empty {
-		return errors.New("refusing to restore into a store that already holds objects; " +
-			"restore into an empty directory instead")
+	if info.Kind == snapshotIncremental {
+		// Continuity is all that can be checked, and it is enough: the chain's
+		// snapshots were written one after another from a single catalog, so
+		// one that starts no later than the last one restored ended leaves no
+		// version uncovered.
+		switch {
+		case s.restoredThrough == 0:
+			return errors.New("this is an incremental metadata backup; restore the full " +
+				"backup that starts its chain first, then each incremental in order")
+		case info.Since > s.restoredThrough+1:
+			return errors.Errorf("this incremental metadata backup starts at catalog "+
+				"version %d, but what has been restored so far ends at %d; the "+
+				"incremental in between is missing", info.Since, s.restoredThrough)
+		}
+	} else {
+		empty, err := s.isEmpty()
+		if err != nil {
+			return err
+		}
+		if !empty {
+			return errors.New("refusing to restore into a store that already holds objects; " +
+				"restore into an empty directory instead")
+		}
 	}
 	if err := s.bdb.Load(r, restoreMaxPendingWrites); err != nil {
 		// A load that stops part-way through leaves records behind *and*
@@ -329,6 +474,22 @@ func (s *Store) restoreStream(r io.Reader) error {
 		return err
 	}
 
+	// A snapshot of a running store catches its usage counters mid-stream, as
+	// deltas not yet folded together; read as they stand, each counter would
+	// report only its latest delta.
+	if err := s.db.ConsolidateUsage(); err != nil {
+		return errors.Wrap(err, "failed to settle the restored usage counters")
+	}
+
+	// Remember where the chain has reached, for the incremental that follows.
+	// A snapshot from before incrementals existed does not say; whatever it
+	// held is in the catalog now, so the catalog's own high-water mark stands
+	// in.
+	s.restoredThrough = info.Through
+	if s.restoredThrough == 0 {
+		s.restoredThrough = s.bdb.MaxVersion()
+	}
+
 	// Detached-subtree state belongs to the restored catalog too.
 	s.detached = newDetachedSet()
 	return s.reloadDetached()
@@ -392,6 +553,11 @@ func (s *Store) StartBackups(ctx context.Context, egrp *errgroup.Group, cfg Back
 		s.runBackupLoop(ctx, cfg, realSchedule())
 		return nil
 	})
+	if cfg.FullEvery > 1 {
+		log.Infof("pstore metadata backups every %s to %s, a full one every %d (keeping %d)",
+			cfg.Interval, cfg.Dir, cfg.FullEvery, cfg.Keep)
+		return
+	}
 	log.Infof("pstore metadata backups every %s to %s (keeping %d)",
 		cfg.Interval, cfg.Dir, cfg.Keep)
 }
@@ -407,9 +573,15 @@ func (s *Store) runBackupLoop(ctx context.Context, cfg BackupConfig, sched sched
 	// store that has simply not been up long enough to snapshot yet -- and the
 	// startup snapshot exists precisely because that first hour is the window
 	// backups are most often silently broken in.
+	//
+	// The chain lives only here.  A restart begins a new one with a full
+	// snapshot, which is also what the startup snapshot would want regardless:
+	// nothing about the previous process's chain can be trusted to have been
+	// published.
+	var chain backupChain
 	observedSnapshot := func() error {
 		pass := beginPass(metrics.PStorePassMetadataBackup, cfg.Interval, sched)
-		err := s.snapshotOnce(cfg)
+		err := s.snapshotNext(cfg, &chain)
 		pass.finish(err == nil)
 		return err
 	}
@@ -433,8 +605,14 @@ type BackupConfig struct {
 	Dir string
 	// Interval between snapshots.
 	Interval time.Duration
-	// Keep bounds how many snapshots are retained; zero keeps them all.
+	// Keep bounds how many snapshots are retained; zero keeps them all.  A
+	// chain is pruned whole, oldest first, so up to FullEvery-1 more may be
+	// kept rather than strand an incremental without the snapshots it builds
+	// on.
 	Keep int
+	// FullEvery makes every FullEvery'th snapshot a full one and the rest
+	// incrementals on top of it.  Zero or one takes only full snapshots.
+	FullEvery int
 	// Keys seal each snapshot.  They are supplied by the caller rather than
 	// read from configuration here, so this package stays independent of
 	// config and a test can seal to keys of its own.  Backups do not start
@@ -447,7 +625,44 @@ type BackupConfig struct {
 // its own output and leave anything else in the directory alone.
 const metadataBackupPrefix = "pstore-metadata-"
 
-// snapshotOnce writes one snapshot and prunes older ones.
+// incrementalMarker follows the timestamp in an incremental's name, ahead of
+// the first catalog version it covers.  Two incrementals taken inside one
+// second -- the timestamp's resolution -- would otherwise share a name, and
+// the second would replace the first and leave a hole in the chain.
+const incrementalMarker = "-inc-"
+
+// snapshotTimeFormat is the timestamp in a snapshot's name.  It is
+// fixed-width, so lexical order is chronological.
+const snapshotTimeFormat = "20060102T150405Z"
+
+// backupChain is how far the scheduled backup's current chain has reached.
+type backupChain struct {
+	// through is the last catalog version the chain covers; zero means there
+	// is no chain yet, and the next snapshot is full.
+	through uint64
+	// length counts the snapshots in it, the full one included.
+	length int
+}
+
+// snapshotName names a snapshot taken at t.
+func snapshotName(t time.Time, info snapshotInfo) string {
+	name := metadataBackupPrefix + t.UTC().Format(snapshotTimeFormat)
+	if info.Kind == snapshotIncremental {
+		name += fmt.Sprintf("%s%020d", incrementalMarker, info.Since)
+	}
+	return name + ".pmb"
+}
+
+// snapshotOnce writes one full snapshot and prunes older ones.
+func (s *Store) snapshotOnce(cfg BackupConfig) error {
+	var chain backupChain
+	return s.snapshotNext(cfg, &chain)
+}
+
+// snapshotNext writes the next snapshot of chain -- an incremental unless the
+// chain is new or has reached cfg.FullEvery -- and prunes older ones.  chain
+// advances only once the snapshot is published, so a failed pass is simply
+// covered by the one after it.
 //
 // It writes to a temporary name, flushes it to stable storage, and only then
 // renames, so neither a crash nor a power cut can publish a truncated file
@@ -455,20 +670,23 @@ const metadataBackupPrefix = "pstore-metadata-"
 // is not enough: it orders nothing against the data, so a machine that loses
 // power just after the rename can come back with the directory entry present
 // and the file's contents partly unwritten.
-func (s *Store) snapshotOnce(cfg BackupConfig) error {
+func (s *Store) snapshotNext(cfg BackupConfig, chain *backupChain) error {
 	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
 		return errors.Wrapf(err, "failed to create the backup directory %s", cfg.Dir)
 	}
 
-	name := metadataBackupPrefix + time.Now().UTC().Format("20060102T150405Z") + ".pmb"
-	final := filepath.Join(cfg.Dir, name)
+	info := snapshotInfo{Kind: snapshotFull}
+	if cfg.FullEvery > 1 && chain.through > 0 && chain.length < cfg.FullEvery {
+		info = snapshotInfo{Kind: snapshotIncremental, Since: chain.through + 1}
+	}
+	final := filepath.Join(cfg.Dir, snapshotName(time.Now(), info))
 	tmp := final + ".partial"
 
 	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create %s", tmp)
 	}
-	_, bErr := s.BackupEncrypted(f, cfg.Keys)
+	through, bErr := s.backupSince(f, cfg.Keys, info.Since)
 	if bErr != nil {
 		f.Close()
 		_ = os.Remove(tmp)
@@ -502,6 +720,13 @@ func (s *Store) snapshotOnce(cfg BackupConfig) error {
 	// crash while pointing at a file the filesystem has forgotten.
 	syncDir(cfg.Dir)
 
+	if info.Kind == snapshotFull {
+		*chain = backupChain{through: through, length: 1}
+	} else {
+		chain.through = through
+		chain.length++
+	}
+
 	log.Debugf("Wrote pstore metadata backup %s", final)
 	return s.pruneSnapshots(cfg)
 }
@@ -521,6 +746,13 @@ func syncDir(dir string) {
 }
 
 // pruneSnapshots removes the oldest snapshots beyond the retention count.
+//
+// It removes whole chains -- a full snapshot and the incrementals after it --
+// and only while at least cfg.Keep snapshots would remain.  An incremental
+// cannot be restored without every snapshot before it in its chain, so pruning
+// one of those would quietly make the rest of the chain worthless.
+// Incrementals with no full snapshot before them at all are already worthless,
+// and go first.
 func (s *Store) pruneSnapshots(cfg BackupConfig) error {
 	if cfg.Keep <= 0 {
 		return nil
@@ -541,12 +773,50 @@ func (s *Store) pruneSnapshots(cfg BackupConfig) error {
 		return nil
 	}
 
-	// The timestamp is fixed-width, so lexical order is chronological.
-	sort.Strings(snapshots)
-	for _, name := range snapshots[:len(snapshots)-cfg.Keep] {
-		if rErr := os.Remove(filepath.Join(cfg.Dir, name)); rErr != nil {
-			log.Warnf("Failed to prune old pstore metadata backup %s: %v", name, rErr)
+	chains := snapshotChains(snapshots)
+	remaining := len(snapshots)
+	for _, chain := range chains {
+		if remaining-len(chain) < cfg.Keep {
+			break
 		}
+		for _, name := range chain {
+			if rErr := os.Remove(filepath.Join(cfg.Dir, name)); rErr != nil {
+				log.Warnf("Failed to prune old pstore metadata backup %s: %v", name, rErr)
+			}
+		}
+		remaining -= len(chain)
 	}
 	return nil
 }
+
+// snapshotChains groups snapshot names into chains, oldest first: each full
+// snapshot with the incrementals taken after it.
+func snapshotChains(names []string) [][]string {
+	// The timestamp orders snapshots, and within one second a full snapshot
+	// precedes the incrementals that build on it -- which the names alone do
+	// not sort into, since the full one's ends where an incremental's carries
+	// on.  Incrementals of a single second sort by their first version.
+	stamp := func(name string) string {
+		rest := strings.TrimPrefix(name, metadataBackupPrefix)
+		return rest[:min(len(rest), len(snapshotTimeFormat))]
+	}
+	incremental := func(name string) bool { return strings.Contains(name, incrementalMarker) }
+	sort.Slice(names, func(i, j int) bool {
+		if si, sj := stamp(names[i]), stamp(names[j]); si != sj {
+			return si < sj
+		}
+		if ii, ij := incremental(names[i]), incremental(names[j]); ii != ij {
+			return ij
+		}
+		return names[i] < names[j]
+	})
+
+	var chains [][]string
+	for _, name := range names {
+		if len(chains) == 0 || !incremental(name) {
+			chains = append(chains, nil)
+		}
+		chains[len(chains)-1] = append(chains[len(chains)-1], name)
+	}
+	return chains
+}
@@ -0,0 +1,809 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+// Object-data backups: the catalog and the block files together.
+//
+// A metadata snapshot (backup.go) is half of a recovery, and the other half --
+// the block files and masterkey.json -- used to be left to whatever backs up
+// the host.  An object-data backup carries all three to a target (a directory
+// or an S3-compatible bucket, backup_target.go), and RestoreData turns one
+// back into a store that opens and serves.
+//
+// # What goes to the target
+//
+//	metadata/pstore-metadata-<time>[-inc-<since>].pmb   sealed catalog snapshots
+//	masterkey/<sha256>.json                             the store's master key file
+//	pieces/<aa>/<sha256>                                block file contents
+//	manifests/pstore-data-<time>-<through>.pdm          one per backup, sealed
+//
+// Every file under a storage directory's objects tree and block pool is cut
+// into pieces of dataBackupPieceSize, and each piece is stored under the
+// SHA-256 of its bytes.  A piece the target already holds is not sent again,
+// which is the deduplication against earlier backups: an object version is
+// immutable once written, so after the first backup only new versions -- and
+// the pool segments that changed -- cost anything.  All-zero pieces, which is
+// what the sparse files of deduplicated objects are made of, are not stored at
+// all.
+//
+// The pieces need no sealing of their own because a block file is already
+// ciphertext: each object's data key is wrapped by the master key, which is in
+// turn sealed to the issuer keys.  masterkey.json goes to the target for the
+// same reason a recovery needs it at all, and is no more exposed there than
+// on the origin's disk.  What *is* readable is the catalog, so the snapshots
+// are sealed as every snapshot is, and so is the manifest, which lists each
+// file's pieces.
+//
+// # Consistency
+//
+// The snapshot is taken first and the files copied after, so every version the
+// snapshot names must still be on disk when its turn comes.  Versions are
+// immutable, so the only threat is reclamation, and the janitor frees nothing
+// while a backup holds the store (Store.RunGC).  Files created after the
+// snapshot are copied too and are harmless: a restored store's fsck sees them
+// as the orphans they are.
+//
+// Erasure parity lives beside the chunk files and is copied with them, so a
+// restore comes back fully protected rather than waiting on the scrub.
+//
+// # Chains
+//
+// Each manifest names the metadata snapshots that restore its catalog: a full
+// one and the incrementals taken since, the same chain backup.go writes to a
+// directory.  A backup extends the newest manifest's chain when that chain is
+// shorter than FullEvery and was written by this store; otherwise it starts a
+// new one.
+
+package pstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/local_cache"
+	"github.com/pelicanplatform/pelican/metrics"
+)
+
+const (
+	// dataBackupPieceSize is the unit block files are deduplicated in.  Large
+	// enough that a big store's manifest stays manageable, small enough that
+	// a pool segment which gained a few blocks resends little.
+	dataBackupPieceSize = 8 << 20
+
+	// The top-level directories of a target.
+	manifestsDir = "manifests"
+	metadataDir  = "metadata"
+	piecesDir    = "pieces"
+	masterKeyDir = "masterkey"
+
+	// dataManifestPrefix names the manifests, which are what a restore is
+	// pointed at.
+	dataManifestPrefix = "pstore-data-"
+)
+
+// DataBackupOptions configures one object-data backup.
+type DataBackupOptions struct {
+	// Keys seal the catalog snapshots and the manifest, exactly as for a
+	// metadata backup.  They are also what reads the previous manifest, to
+	// extend its chain and skip the pieces it already stored.
+	Keys BackupKeys
+	// FullEvery bounds a chain: every FullEvery'th backup takes a full
+	// catalog snapshot and the rest incrementals.  Zero or one takes only
+	// full ones.
+	FullEvery int
+	// Keep bounds how many backups the target retains, oldest pruned first
+	// together with whatever only they refer to.  Zero keeps them all.
+	Keep int
+}
+
+// DataBackupReport says what one object-data backup did.
+type DataBackupReport struct {
+	// Manifest is the name of the backup, for RestoreData.
+	Manifest string
+	// Incremental reports that the catalog went as an incremental snapshot.
+	Incremental bool
+	// Files is the number of block files covered.
+	Files int
+	// BytesUploaded is how much block data went to the target, and
+	// BytesShared how much it already held from earlier backups.
+	BytesUploaded int64
+	BytesShared   int64
+	// Pruned is the number of older backups removed under Keep.
+	Pruned int
+}
+
+// dataManifest is the sealed record of one object-data backup.
+type dataManifest struct {
+	Created time.Time `json:"created"`
+	// Store identifies the store the backup was taken from, so that a chain
+	// is only ever extended by the store that started it.
+	Store string `json:"store"`
+	// Through is the last catalog version the newest snapshot covers.
+	Through uint64 `json:"through"`
+	// Metadata is the snapshot chain, full snapshot first.
+	Metadata  []string      `json:"metadata"`
+	MasterKey string        `json:"masterKey"`
+	PieceSize int64         `json:"pieceSize"`
+	Dirs      []manifestDir `json:"dirs"`
+}
+
+// manifestDir is one storage directory as it was backed up.
+type manifestDir struct {
+	ID        local_cache.StorageID `json:"id"`
+	UUID      string                `json:"uuid"`
+	Directory string                `json:"directory"`
+	Files     []manifestFile        `json:"files"`
+}
+
+// manifestFile is one block file: its path under the storage directory and
+// the hash of each piece, empty for an all-zero one.
+type manifestFile struct {
+	Path   string   `json:"path"`
+	Size   int64    `json:"size"`
+	Pieces []string `json:"pieces"`
+}
+
+// pieceName is where the piece with hash sum is stored.
+func pieceName(sum string) string {
+	return path.Join(piecesDir, sum[:2], sum)
+}
+
+// BackupData backs up the catalog, the master key and every block file to
+// target.
+//
+// The store keeps serving throughout, but frees no space until the backup is
+// done: see the package comment above on why reclamation is held.
+func (s *Store) BackupData(ctx context.Context, target BackupTarget, opts DataBackupOptions) (*DataBackupReport, error) {
+	if opts.Keys.Empty() {
+		return nil, errors.New("no keys are available to seal the backup; the catalog is " +
+			"never written unencrypted")
+	}
+
+	s.backupHolds.Add(1)
+	defer s.backupHolds.Add(-1)
+
+	mappings, err := s.db.LoadDiskMappings()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the storage directory mappings")
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].ID < mappings[j].ID })
+	storeID := ""
+	if len(mappings) > 0 {
+		storeID = mappings[0].UUID
+	}
+
+	// The previous backup is read to extend its chain and to know which
+	// pieces the target holds without asking for each.  One that cannot be
+	// read costs a full backup and a question per piece, not correctness.
+	prev, _, err := latestManifest(ctx, target, opts.Keys)
+	if err != nil {
+		log.Warnf("Taking a full pstore backup to %s, because the previous one could not be read: %v", target, err)
+		prev = nil
+	}
+
+	report := &DataBackupReport{}
+	manifest := &dataManifest{
+		Created:   time.Now().UTC(),
+		Store:     storeID,
+		PieceSize: dataBackupPieceSize,
+	}
+
+	// The catalog first: everything copied after it is at least as new as
+	// what it names, and reclamation is held, so nothing it names is gone.
+	info := snapshotInfo{Kind: snapshotFull}
+	if opts.FullEvery > 1 && prev != nil && prev.Store == storeID && prev.Through > 0 &&
+		len(prev.Metadata) < opts.FullEvery && prev.Through <= s.bdb.MaxVersion() {
+		info = snapshotInfo{Kind: snapshotIncremental, Since: prev.Through + 1}
+		manifest.Metadata = append(manifest.Metadata, prev.Metadata...)
+		report.Incremental = true
+	}
+	snapshot, through, err := s.uploadSnapshot(ctx, target, opts.Keys, info)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Metadata = append(manifest.Metadata, snapshot)
+	manifest.Through = through
+
+	if manifest.MasterKey, err = s.uploadMasterKey(ctx, target); err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	if prev != nil {
+		for _, d := range prev.Dirs {
+			for _, f := range d.Files {
+				for _, sum := range f.Pieces {
+					known[sum] = true
+				}
+			}
+		}
+	}
+	mounted := s.storage.GetDirs()
+	buf := make([]byte, dataBackupPieceSize)
+	for _, dm := range mappings {
+		if _, ok := mounted[dm.ID]; !ok {
+			continue
+		}
+		md := manifestDir{ID: dm.ID, UUID: dm.UUID, Directory: dm.Directory}
+		for _, sub := range local_cache.StorageDataSubdirs() {
+			err := filepath.WalkDir(filepath.Join(dm.Directory, sub), func(p string, e fs.DirEntry, wErr error) error {
+				if wErr != nil {
+					if errors.Is(wErr, fs.ErrNotExist) {
+						return nil
+					}
+					return wErr
+				}
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if !e.Type().IsRegular() {
+					return nil
+				}
+				rel, err := filepath.Rel(dm.Directory, p)
+				if err != nil {
+					return err
+				}
+				mf, ok, err := s.backupFile(ctx, target, p, filepath.ToSlash(rel), buf, known, report)
+				if err != nil || !ok {
+					return err
+				}
+				md.Files = append(md.Files, mf)
+				return nil
+			})
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to back up storage directory %s", dm.Directory)
+			}
+		}
+		report.Files += len(md.Files)
+		manifest.Dirs = append(manifest.Dirs, md)
+	}
+
+	name := path.Join(manifestsDir, fmt.Sprintf("%s%s-%020d.pdm", dataManifestPrefix,
+		manifest.Created.Format(snapshotTimeFormat), manifest.Through))
+	if err := putSealed(ctx, target, name, opts.Keys, snapshotInfo{Kind: snapshotManifest, Through: through},
+		func(w io.Writer) error { return json.NewEncoder(w).Encode(manifest) }); err != nil {
+		return nil, err
+	}
+	report.Manifest = name
+
+	if opts.Keep > 0 {
+		pruned, err := pruneDataBackups(ctx, target, opts.Keys, opts.Keep)
+		report.Pruned = pruned
+		if err != nil {
+			// The backup itself is complete; only the tidying failed.
+			log.Warnf("Failed to prune old pstore backups from %s: %v", target, err)
+		}
+	}
+	return report, nil
+}
+
+// backupFile uploads the pieces of one block file the target does not hold.
+// A file that has vanished since the walk found it -- an abandoned write
+// the janitor had already finished with -- is skipped.
+func (s *Store) backupFile(ctx context.Context, target BackupTarget, p, rel string, buf []byte,
+	known map[string]bool, report *DataBackupReport) (manifestFile, bool, error) {
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return manifestFile{}, false, nil
+	}
+	if err != nil {
+		return manifestFile{}, false, err
+	}
+	defer f.Close()
+
+	mf := manifestFile{Path: rel}
+	for {
+		n, rErr := io.ReadFull(f, buf)
+		if n > 0 {
+			piece := buf[:n]
+			sum := ""
+			if !allZero(piece) {
+				sum = hashOf(piece)
+				if err := putPiece(ctx, target, sum, piece, known, report); err != nil {
+					return mf, false, err
+				}
+			}
+			mf.Pieces = append(mf.Pieces, sum)
+			mf.Size += int64(n)
+		}
+		if errors.Is(rErr, io.EOF) || errors.Is(rErr, io.ErrUnexpectedEOF) {
+			return mf, true, nil
+		}
+		if rErr != nil {
+			return mf, false, errors.Wrapf(rErr, "failed to read %s", p)
+		}
+	}
+}
+
+// putPiece stores a piece unless the target already holds it.
+func putPiece(ctx context.Context, target BackupTarget, sum string, piece []byte,
+	known map[string]bool, report *DataBackupReport) error {
+	size := int64(len(piece))
+	if known[sum] {
+		report.BytesShared += size
+		metrics.PStoreDataBackupBytesTotal.WithLabelValues("shared").Add(float64(size))
+		return nil
+	}
+	exists, err := target.Exists(ctx, pieceName(sum))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := target.Put(ctx, pieceName(sum), bytes.NewReader(piece), size); err != nil {
+			return err
+		}
+		report.BytesUploaded += size
+		metrics.PStoreDataBackupBytesTotal.WithLabelValues("uploaded").Add(float64(size))
+	} else {
+		report.BytesShared += size
+		metrics.PStoreDataBackupBytesTotal.WithLabelValues("shared").Add(float64(size))
+	}
+	known[sum] = true
+	return nil
+}
+
+func allZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// uploadSnapshot sends a catalog snapshot to the target and returns its name
+// and the version it runs through.
+func (s *Store) uploadSnapshot(ctx context.Context, target BackupTarget, keys BackupKeys, info snapshotInfo) (string, uint64, error) {
+	name := path.Join(metadataDir, snapshotName(time.Now(), info))
+	var through uint64
+	err := putSpooled(ctx, target, name, func(w io.Writer) error {
+		var bErr error
+		through, bErr = s.backupSince(w, keys, info.Since)
+		return bErr
+	})
+	return name, through, err
+}
+
+// uploadMasterKey sends masterkey.json to the target, named by its hash, so
+// that it is stored once for as long as it does not change.
+func (s *Store) uploadMasterKey(ctx context.Context, target BackupTarget) (string, error) {
+	data, err := os.ReadFile(local_cache.MasterKeyPath(s.baseDir))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the store's master key file")
+	}
+	name := path.Join(masterKeyDir, hashOf(data)+".json")
+	exists, err := target.Exists(ctx, name)
+	if err != nil || exists {
+		return name, err
+	}
+	return name, target.Put(ctx, name, bytes.NewReader(data), int64(len(data)))
+}
+
+// putSealed writes a sealed container to the target.
+func putSealed(ctx context.Context, target BackupTarget, name string, keys BackupKeys, info snapshotInfo,
+	write func(io.Writer) error) error {
+	return putSpooled(ctx, target, name, func(w io.Writer) error {
+		return sealStream(w, keys, info, write)
+	})
+}
+
+// putSpooled writes a blob through a temporary file, since an upload must be
+// able to rewind and a catalog snapshot is too large to hold in memory.
+func putSpooled(ctx context.Context, target BackupTarget, name string, write func(io.Writer) error) error {
+	f, err := os.CreateTemp("", "pstore-backup-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a spool file for the backup")
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := write(f); err != nil {
+		return err
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to rewind the backup spool file")
+	}
+	return target.Put(ctx, name, f, size)
+}
+
+// readManifest opens and decodes a sealed manifest.
+func readManifest(ctx context.Context, target BackupTarget, name string, keys BackupKeys) (*dataManifest, error) {
+	rc, err := target.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var manifest dataManifest
+	err = openSealed(rc, keys, func(info snapshotInfo, body io.Reader) error {
+		if info.Kind != snapshotManifest {
+			return errors.Errorf("%s is not the manifest of an object-data backup", name)
+		}
+		return json.NewDecoder(body).Decode(&manifest)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read backup manifest %s", name)
+	}
+	return &manifest, nil
+}
+
+// listManifests returns the target's manifests, oldest first.
+func listManifests(ctx context.Context, target BackupTarget) ([]string, error) {
+	names, err := target.List(ctx, manifestsDir)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, name := range names {
+		base := path.Base(name)
+		if strings.HasPrefix(base, dataManifestPrefix) && strings.HasSuffix(base, ".pdm") {
+			out = append(out, name)
+		}
+	}
+	// Fixed-width timestamp and version, so lexical order is chronological.
+	sort.Strings(out)
+	return out, nil
+}
+
+// latestManifest reads the newest manifest, or returns nil when the target
+// holds none.
+func latestManifest(ctx context.Context, target BackupTarget, keys BackupKeys) (*dataManifest, string, error) {
+	names, err := listManifests(ctx, target)
+	if err != nil || len(names) == 0 {
+		return nil, "", err
+	}
+	name := names[len(names)-1]
+	manifest, err := readManifest(ctx, target, name, keys)
+	return manifest, name, err
+}
+
+// pruneDataBackups removes all but the newest keep backups, then every blob
+// none of the remaining ones refers to.
+//
+// Blobs are only removed once every retained manifest has been read: a piece
+// that looks unreferenced because its manifest could not be opened is still
+// somebody's data.  Pruning assumes one store writes to a target at a time,
+// since a piece uploaded by a backup still in flight is referenced by nothing
+// yet.
+func pruneDataBackups(ctx context.Context, target BackupTarget, keys BackupKeys, keep int) (int, error) {
+	names, err := listManifests(ctx, target)
+	if err != nil || len(names) <= keep {
+		return 0, err
+	}
+
+	referenced := make(map[string]bool)
+	for _, name := range names[len(names)-keep:] {
+		manifest, err := readManifest(ctx, target, name, keys)
+		if err != nil {
+			return 0, errors.Wrap(err, "not pruning, since what it refers to cannot be known")
+		}
+		referenced[manifest.MasterKey] = true
+		for _, m := range manifest.Metadata {
+			referenced[m] = true
+		}
+		for _, d := range manifest.Dirs {
+			for _, f := range d.Files {
+				for _, sum := range f.Pieces {
+					if sum != "" {
+						referenced[pieceName(sum)] = true
+					}
+				}
+			}
+		}
+	}
+
+	pruned := 0
+	for _, name := range names[:len(names)-keep] {
+		if err := target.Delete(ctx, name); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	for _, dir := range []string{metadataDir, masterKeyDir, piecesDir} {
+		blobs, err := target.List(ctx, dir)
+		if err != nil {
+			return pruned, err
+		}
+		for _, blob := range blobs {
+			if referenced[blob] {
+				continue
+			}
+			if err := target.Delete(ctx, blob); err != nil {
+				return pruned, err
+			}
+		}
+	}
+	return pruned, nil
+}
+
+// DataBackupConfig describes the periodic object-data backup.
+type DataBackupConfig struct {
+	// Target receives the backups.  Nil disables them.
+	Target BackupTarget
+	// Interval between backups, measured from the end of one to the start of
+	// the next as for every scheduled pass.
+	Interval time.Duration
+	DataBackupOptions
+}
+
+// StartDataBackups backs the store up to cfg.Target every interval, for as
+// long as the context lives.
+//
+// Unlike the metadata backup there is no backup at startup: one reads every
+// block file, and a restart is no reason to do that again.  The target is
+// checked instead, so that a wrong bucket or unwritable directory is reported
+// now rather than an interval later.
+func (s *Store) StartDataBackups(ctx context.Context, egrp *errgroup.Group, cfg DataBackupConfig) {
+	if cfg.Target == nil || cfg.Interval <= 0 {
+		return
+	}
+	if cfg.Keys.Empty() {
+		log.Errorf("No keys are available to seal pstore backups, so the origin's data is NOT "+
+			"being backed up to %s.", cfg.Target)
+		return
+	}
+	if _, err := listManifests(ctx, cfg.Target); err != nil {
+		log.Errorf("pstore backup target %s is not usable; backups will be attempted on "+
+			"schedule but are likely to fail: %v", cfg.Target, err)
+	}
+	egrp.Go(func() error {
+		sched := realSchedule()
+		runPeriodically(ctx, cfg.Interval, sched, "data backup", func() {
+			pass := beginPass(metrics.PStorePassDataBackup, cfg.Interval, sched)
+			report, err := s.BackupData(ctx, cfg.Target, cfg.DataBackupOptions)
+			pass.finish(err == nil)
+			if err != nil {
+				log.Warnf("pstore backup to %s failed: %v", cfg.Target, err)
+				return
+			}
+			log.Infof("pstore backup %s: %d files, %d bytes uploaded, %d already held",
+				report.Manifest, report.Files, report.BytesUploaded, report.BytesShared)
+		})
+		return nil
+	})
+	log.Infof("pstore object-data backups every %s to %s (keeping %d)", cfg.Interval, cfg.Target, cfg.Keep)
+}
+
+// ---------------------------------------------------------------------------
+// Restore
+// ---------------------------------------------------------------------------
+
+// DataRestoreOptions configures RestoreData.
+type DataRestoreOptions struct {
+	// Keys open the manifest and the catalog snapshots.  A per-file key opens
+	// only one container, so it does not serve here.
+	Keys BackupKeys
+	// Manifest names the backup to restore; empty selects the newest.
+	Manifest string
+	// StorageDirs are where the backed-up storage directories go, in order of
+	// storage ID, one for each.  Empty puts each back where it was.
+	StorageDirs []string
+}
+
+// DataRestoreReport says what RestoreData put back.
+type DataRestoreReport struct {
+	Manifest string
+	// Dirs maps each storage ID to the directory it was restored into.
+	Dirs  map[local_cache.StorageID]string
+	Files int
+	Bytes int64
+}
+
+// RestoreData reconstitutes a store in baseDir from an object-data backup.
+//
+// baseDir must not hold a store already, and each storage directory must have
+// nothing in its objects tree or block pool: like a metadata restore, this
+// replaces rather than merges.  The master key file goes in first, so that the
+// catalog restored after it is encrypted under the key its objects' data keys
+// are wrapped with -- which means the origin's issuer keys that masterkey.json
+// is sealed to must be loaded, whatever Keys holds.  Then the snapshot chain,
+// then each storage directory is given back its identity, recorded against
+// its new path, and its files rewritten piece by piece, each checked against
+// its hash.
+//
+// The result opens with the storage directories it was restored into.  A
+// restore that fails part-way leaves a store that should be discarded, for
+// the reason Store.Restore gives.
+func RestoreData(ctx context.Context, baseDir string, target BackupTarget, opts DataRestoreOptions) (*DataRestoreReport, error) {
+	name := opts.Manifest
+	if name == "" {
+		names, err := listManifests(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return nil, errors.Errorf("%s holds no pstore backups", target)
+		}
+		name = names[len(names)-1]
+	} else if !strings.Contains(name, "/") {
+		name = path.Join(manifestsDir, name)
+	}
+	manifest, err := readManifest(ctx, target, name, opts.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check every destination before writing anything, so that a mistake in
+	// the command line costs nothing.
+	if _, err := os.Stat(local_cache.MasterKeyPath(baseDir)); err == nil {
+		return nil, errors.Errorf("%s already holds a store; restore into a fresh directory", baseDir)
+	}
+	if len(opts.StorageDirs) > 0 && len(opts.StorageDirs) != len(manifest.Dirs) {
+		return nil, errors.Errorf("the backup has %d storage directories, but %d were given to restore them into",
+			len(manifest.Dirs), len(opts.StorageDirs))
+	}
+	report := &DataRestoreReport{Manifest: name, Dirs: make(map[local_cache.StorageID]string)}
+	for i, d := range manifest.Dirs {
+		dest := d.Directory
+		if len(opts.StorageDirs) > 0 {
+			dest = opts.StorageDirs[i]
+		}
+		for _, sub := range local_cache.StorageDataSubdirs() {
+			entries, err := os.ReadDir(filepath.Join(dest, sub))
+			if err == nil && len(entries) > 0 {
+				return nil, errors.Errorf("storage directory %s already holds data; restore into an empty one", dest)
+			}
+		}
+		report.Dirs[d.ID] = dest
+	}
+
+	// The master key, checked against the name it was stored under.
+	if err := os.MkdirAll(baseDir, 0750); err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s", baseDir)
+	}
+	key, err := readBlob(ctx, target, manifest.MasterKey)
+	if err != nil {
+		return nil, err
+	}
+	if want := strings.TrimSuffix(path.Base(manifest.MasterKey), ".json"); hashOf(key) != want {
+		return nil, errors.Errorf("the master key file in the backup is damaged")
+	}
+	if err := os.WriteFile(local_cache.MasterKeyPath(baseDir), key, 0600); err != nil {
+		return nil, errors.Wrap(err, "failed to write the master key file")
+	}
+
+	store, err := OpenMaintenance(ctx, baseDir, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := restoreDataInto(ctx, store, target, manifest, opts.Keys, report); err != nil {
+		store.Close()
+		return nil, err
+	}
+	return report, store.Close()
+}
+
+// restoreDataInto loads the catalog chain into store and puts every storage
+// directory back.
+func restoreDataInto(ctx context.Context, store *Store, target BackupTarget, manifest *dataManifest,
+	keys BackupKeys, report *DataRestoreReport) error {
+	for _, snapshot := range manifest.Metadata {
+		rc, err := target.Get(ctx, snapshot)
+		if err != nil {
+			return err
+		}
+		err = store.Restore(rc, keys)
+		rc.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to restore %s", snapshot)
+		}
+	}
+
+	for _, d := range manifest.Dirs {
+		dest := report.Dirs[d.ID]
+		if err := local_cache.ClaimStorageDir(dest, d.UUID); err != nil {
+			return err
+		}
+		if err := store.db.SaveDiskMapping(local_cache.DiskMapping{ID: d.ID, UUID: d.UUID, Directory: dest}); err != nil {
+			return errors.Wrapf(err, "failed to record %s as storage ID %d", dest, d.ID)
+		}
+
+		// Files are independent of one another, so a few at once keeps a
+		// remote target's latency from dominating.
+		egrp, gctx := errgroup.WithContext(ctx)
+		egrp.SetLimit(4)
+		for _, f := range d.Files {
+			egrp.Go(func() error { return restoreFile(gctx, target, dest, f) })
+			report.Files++
+			report.Bytes += f.Size
+		}
+		if err := egrp.Wait(); err != nil {
+			return errors.Wrapf(err, "failed to restore storage directory %s", dest)
+		}
+	}
+	return nil
+}
+
+// restoreFile rewrites one block file from its pieces.  All-zero pieces are
+// skipped over, so a sparse file comes back sparse.
+func restoreFile(ctx context.Context, target BackupTarget, dest string, mf manifestFile) error {
+	local := filepath.FromSlash(mf.Path)
+	if !filepath.IsLocal(local) {
+		return errors.Errorf("refusing to restore %q outside its storage directory", mf.Path)
+	}
+	p := filepath.Join(dest, local)
+	if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil {
+		return errors.Wrapf(err, "failed to create the directory for %s", p)
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", p)
+	}
+	defer f.Close()
+
+	var offset int64
+	for _, sum := range mf.Pieces {
+		if sum == "" {
+			offset += min(dataBackupPieceSize, mf.Size-offset)
+			continue
+		}
+		piece, err := readBlob(ctx, target, pieceName(sum))
+		if err != nil {
+			return err
+		}
+		if hashOf(piece) != sum {
+			return errors.Errorf("piece %s of %s is damaged in the backup", sum, mf.Path)
+		}
+		if _, err := f.WriteAt(piece, offset); err != nil {
+			return errors.Wrapf(err, "failed to write %s", p)
+		}
+		offset += int64(len(piece))
+	}
+	if err := f.Truncate(mf.Size); err != nil {
+		return errors.Wrapf(err, "failed to size %s", p)
+	}
+	if err := f.Sync(); err != nil {
+		return errors.Wrapf(err, "failed to flush %s", p)
+	}
+	return f.Close()
+}
+
+// readBlob reads a whole blob from the target.
+func readBlob(ctx context.Context, target BackupTarget, name string) ([]byte, error) {
+	rc, err := target.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	return data, errors.Wrapf(err, "failed to read %s from %s", name, target)
+}
+
+func hashOf(b []byte) string {
+	digest := sha256.Sum256(b)
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,345 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package pstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/local_cache"
+)
+
+// TestIncrementalChainRestores is the point of an incremental: a full snapshot
+// and the changes after it, restored in order, give the namespace as it was
+// at the end -- deletions included, which are the changes a naive "records
+// since" dump would lose.
+func TestIncrementalChainRestores(t *testing.T) {
+	source := newTestStore(t)
+	require.NoError(t, source.MkdirAll("/data"))
+	writeObject(t, source, "/data/a.txt", []byte("alpha"))
+	writeObject(t, source, "/data/b.txt", []byte("beta"))
+
+	keys := testBackupKeys(t)
+	var full bytes.Buffer
+	through, err := source.BackupEncrypted(&full, keys)
+	require.NoError(t, err)
+
+	require.NoError(t, source.Remove("/data/a.txt"))
+	writeObject(t, source, "/data/b.txt", []byte("beta, rewritten"))
+	writeObject(t, source, "/data/c.txt", []byte("gamma"))
+
+	var inc bytes.Buffer
+	incThrough, err := source.BackupIncremental(&inc, keys, through+1)
+	require.NoError(t, err)
+	assert.Greater(t, incThrough, through)
+	assert.Less(t, inc.Len(), full.Len(), "an incremental carries only the changes")
+
+	target := newTestStore(t)
+	require.NoError(t, target.Restore(bytes.NewReader(full.Bytes()), keys))
+	require.NoError(t, target.Restore(bytes.NewReader(inc.Bytes()), keys))
+
+	names, err := listNames(target, "/data")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.txt", "c.txt"}, names)
+	d, err := target.Stat("/data/b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("beta, rewritten")), d.Size)
+}
+
+// TestAnIncrementalOutOfPlaceIsRefused covers the two ways a chain is applied
+// wrongly: an incremental with nothing under it, and one with a link missing
+// before it.  Both are refused before a record is written.
+func TestAnIncrementalOutOfPlaceIsRefused(t *testing.T) {
+	source := newTestStore(t)
+	require.NoError(t, source.MkdirAll("/data"))
+	writeObject(t, source, "/data/a.txt", []byte("alpha"))
+
+	keys := testBackupKeys(t)
+	var full, first, second bytes.Buffer
+	through, err := source.BackupEncrypted(&full, keys)
+	require.NoError(t, err)
+	writeObject(t, source, "/data/b.txt", []byte("beta"))
+	through, err = source.BackupIncremental(&first, keys, through+1)
+	require.NoError(t, err)
+	writeObject(t, source, "/data/c.txt", []byte("gamma"))
+	_, err = source.BackupIncremental(&second, keys, through+1)
+	require.NoError(t, err)
+
+	alone := newTestStore(t)
+	err = alone.Restore(bytes.NewReader(first.Bytes()), keys)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "restore the full backup that starts its chain first")
+	empty, err := alone.isEmpty()
+	require.NoError(t, err)
+	assert.True(t, empty, "a refused incremental writes nothing")
+
+	gap := newTestStore(t)
+	require.NoError(t, gap.Restore(bytes.NewReader(full.Bytes()), keys))
+	err = gap.Restore(bytes.NewReader(second.Bytes()), keys)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "incremental in between is missing")
+}
+
+// TestScheduledSnapshotsFormChains drives the scheduled pass through more
+// than one chain: a full snapshot, FullEvery-1 incrementals on it, then a new
+// full one -- and the newest chain on disk restores to the store's state.
+func TestScheduledSnapshotsFormChains(t *testing.T) {
+	s := newTestStore(t)
+	require.NoError(t, s.MkdirAll("/data"))
+
+	dir := t.TempDir()
+	cfg := BackupConfig{Dir: dir, Keys: testBackupKeys(t), FullEvery: 3}
+	var chain backupChain
+	for i := range 4 {
+		if i == 3 {
+			// Snapshot names resolve to the second, and a full snapshot in
+			// the same second as the last one would replace it.
+			time.Sleep(time.Second)
+		}
+		writeObject(t, s, fmt.Sprintf("/data/%d.txt", i), []byte("x"))
+		require.NoError(t, s.snapshotNext(cfg, &chain))
+	}
+
+	chains := snapshotChains(publishedSnapshots(t, dir))
+	require.Len(t, chains, 2, "the fourth pass starts a new chain")
+	require.Len(t, chains[0], 3)
+	for _, name := range chains[0][1:] {
+		assert.Contains(t, name, incrementalMarker)
+	}
+
+	// The first chain, restored in order, ends at the third pass.
+	target := newTestStore(t)
+	for _, name := range chains[0] {
+		f, err := os.Open(filepath.Join(dir, name))
+		require.NoError(t, err)
+		require.NoError(t, target.Restore(f, cfg.Keys), name)
+		f.Close()
+	}
+	names, err := listNames(target, "/data")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0.txt", "1.txt", "2.txt"}, names)
+}
+
+// TestSnapshotRetentionPrunesWholeChains checks that retention never strands
+// an incremental: chains go whole, oldest first, and only while Keep would
+// still be met.
+func TestSnapshotRetentionPrunesWholeChains(t *testing.T) {
+	s := newTestStore(t)
+	dir := t.TempDir()
+
+	names := []string{
+		// An incremental whose full snapshot is already gone.
+		metadataBackupPrefix + "20260101T000000Z" + incrementalMarker + "00000000000000000009.pmb",
+		metadataBackupPrefix + "20260102T000000Z.pmb",
+		metadataBackupPrefix + "20260102T000000Z" + incrementalMarker + "00000000000000000020.pmb",
+		metadataBackupPrefix + "20260103T000000Z" + incrementalMarker + "00000000000000000030.pmb",
+		metadataBackupPrefix + "20260104T000000Z.pmb",
+		metadataBackupPrefix + "20260105T000000Z" + incrementalMarker + "00000000000000000050.pmb",
+	}
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("snapshot"), 0600))
+	}
+
+	require.NoError(t, s.pruneSnapshots(BackupConfig{Dir: dir, Keep: 3}))
+	assert.ElementsMatch(t, names[1:], publishedSnapshots(t, dir),
+		"the stranded incremental goes; dropping the next chain would leave fewer than three")
+
+	require.NoError(t, s.pruneSnapshots(BackupConfig{Dir: dir, Keep: 2}))
+	assert.ElementsMatch(t, names[4:], publishedSnapshots(t, dir))
+}
+
+// openStoreWithDirs opens a store in base with the given storage directories.
+func openStoreWithDirs(t *testing.T, base string, dirs ...string) *Store {
+	t.Helper()
+	local_cache.InitIssuerKeyForTests(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	t.Cleanup(cancel)
+	egrp, _ := errgroup.WithContext(ctx)
+
+	cfg := Config{BaseDir: base}
+	for _, d := range dirs {
+		cfg.StorageDirs = append(cfg.StorageDirs, local_cache.StorageDirConfig{Path: d})
+	}
+	s, err := Open(ctx, egrp, cfg)
+	require.NoError(t, err)
+	return s
+}
+
+// TestDataBackupRestoresAWorkingStore is the recovery drill the object-data
+// backup exists for: two backups of a store -- the second incremental, and
+// sharing the first one's pieces -- restored onto fresh disks, give a store
+// that opens with its new directories and serves every object.
+func TestDataBackupRestoresAWorkingStore(t *testing.T) {
+	ctx := t.Context()
+	srcDirs := []string{t.TempDir(), t.TempDir()}
+	source := openStoreWithDirs(t, t.TempDir(), srcDirs...)
+
+	require.NoError(t, source.MkdirAll("/data"))
+	writeObject(t, source, "/data/small.txt", []byte("inline payload"))
+	big := randomBytes(3<<20, 7)
+	writeObject(t, source, "/data/big.bin", big)
+	writeObject(t, source, "/data/gone.bin", randomBytes(256<<10, 8))
+
+	target, err := NewDirBackupTarget(t.TempDir())
+	require.NoError(t, err)
+	opts := DataBackupOptions{Keys: testBackupKeys(t), FullEvery: 3}
+
+	first, err := source.BackupData(ctx, target, opts)
+	require.NoError(t, err)
+	assert.False(t, first.Incremental)
+	assert.Positive(t, first.BytesUploaded)
+
+	require.NoError(t, source.Remove("/data/gone.bin"))
+	later := randomBytes(512<<10, 9)
+	writeObject(t, source, "/data/later.bin", later)
+
+	second, err := source.BackupData(ctx, target, opts)
+	require.NoError(t, err)
+	assert.True(t, second.Incremental, "the second backup extends the first one's chain")
+	assert.GreaterOrEqual(t, second.BytesShared, int64(len(big)),
+		"what the first backup stored is not sent again")
+	require.NoError(t, source.Close())
+
+	// Onto fresh disk, in directories of different names.
+	base := t.TempDir()
+	newDirs := []string{t.TempDir(), t.TempDir()}
+	restored, err := RestoreData(ctx, base, target, DataRestoreOptions{Keys: opts.Keys, StorageDirs: newDirs})
+	require.NoError(t, err)
+	assert.Equal(t, second.Manifest, restored.Manifest, "the newest backup is the default")
+	assert.Len(t, restored.Dirs, 2)
+
+	store := openStoreWithDirs(t, base, newDirs...)
+	defer store.Close()
+
+	got, err := store.ReadAll("/data/big.bin")
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(big, got))
+	got, err = store.ReadAll("/data/later.bin")
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(later, got))
+	got, err = store.ReadAll("/data/small.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "inline payload", string(got))
+	_, err = store.Stat("/data/gone.bin")
+	assert.Error(t, err, "a deletion in the incremental is restored too")
+
+	report, err := store.FsckWith(ctx, FsckOptions{Deep: true})
+	require.NoError(t, err)
+	assert.Empty(t, report.Unresolved(), "the restored store verifies")
+	assert.Equal(t, 3, report.ObjectsVerified)
+
+	// Restoring over it is refused.
+	_, err = RestoreData(ctx, base, target, DataRestoreOptions{Keys: opts.Keys, StorageDirs: newDirs})
+	assert.ErrorContains(t, err, "already holds a store")
+}
+
+// TestDataBackupRetentionKeepsWhatIsReferenced prunes to one backup and checks
+// that it still restores: pieces and snapshots the survivor shares with the
+// pruned one must outlive it.
+func TestDataBackupRetentionKeepsWhatIsReferenced(t *testing.T) {
+	ctx := t.Context()
+	source := openStoreWithDirs(t, t.TempDir())
+	require.NoError(t, source.MkdirAll("/data"))
+	content := randomBytes(1<<20, 11)
+	writeObject(t, source, "/data/a.bin", content)
+
+	targetDir := t.TempDir()
+	target, err := NewDirBackupTarget(targetDir)
+	require.NoError(t, err)
+	opts := DataBackupOptions{Keys: testBackupKeys(t), FullEvery: 2, Keep: 1}
+
+	_, err = source.BackupData(ctx, target, opts)
+	require.NoError(t, err)
+	writeObject(t, source, "/data/b.bin", randomBytes(1<<20, 12))
+	second, err := source.BackupData(ctx, target, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, second.Pruned)
+
+	manifests, err := listManifests(ctx, target)
+	require.NoError(t, err)
+	assert.Equal(t, []string{second.Manifest}, manifests)
+	snapshots, err := target.List(ctx, metadataDir)
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 2, "the incremental's full snapshot outlives the manifest that wrote it")
+	require.NoError(t, source.Close())
+
+	base := t.TempDir()
+	_, err = RestoreData(ctx, base, target, DataRestoreOptions{Keys: opts.Keys})
+	require.Error(t, err, "the original directory still holds its data")
+
+	// Restored to its own directory, the survivor is complete.
+	_, err = RestoreData(ctx, base, target, DataRestoreOptions{Keys: opts.Keys, StorageDirs: []string{base}})
+	require.NoError(t, err)
+	store := openStoreWithDirs(t, base)
+	defer store.Close()
+	got, err := store.ReadAll("/data/a.bin")
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(content, got))
+}
+
+// TestReclamationWaitsForADataBackup pins the hold: a version deleted while a
+// backup runs keeps its blocks until the backup is done.
+func TestReclamationWaitsForADataBackup(t *testing.T) {
+	s := newTestStore(t)
+	require.NoError(t, s.MkdirAll("/data"))
+	writeObject(t, s, "/data/a.bin", randomBytes(256<<10, 3))
+	require.NoError(t, s.Remove("/data/a.bin"))
+
+	s.backupHolds.Add(1)
+	stats, err := s.RunGC(t.Context())
+	require.NoError(t, err)
+	assert.Zero(t, stats.InstancesFreed, "nothing is freed under a backup")
+
+	s.backupHolds.Add(-1)
+	stats, err = s.RunGC(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.InstancesFreed)
+}
+
+func TestOpenBackupTarget(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "backups")
+	target, err := OpenBackupTarget(t.Context(), dir, S3TargetConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, dir, target.String())
+
+	target, err = OpenBackupTarget(t.Context(), "s3://bucket/some/prefix/", S3TargetConfig{Endpoint: "https://s3.example.org"})
+	require.NoError(t, err)
+	assert.Equal(t, "s3://bucket/some/prefix", target.String())
+
+	for _, bad := range []string{"", "s3://", "https://example.org/x"} {
+		_, err := OpenBackupTarget(t.Context(), bad, S3TargetConfig{})
+		assert.Error(t, err, bad)
+	}
+
+	// Names are confined to the target.
+	target, err = NewDirBackupTarget(dir)
+	require.NoError(t, err)
+	err = target.Put(t.Context(), "../escape", strings.NewReader("x"), 1)
+	assert.ErrorContains(t, err, "invalid backup blob name")
+}
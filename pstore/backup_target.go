@@ -0,0 +1,370 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+// Where an object-data backup goes.
+//
+// A target is a flat store of named, immutable blobs -- which a directory and
+// an S3 bucket both are -- and backup_data.go asks nothing more of it.  Names
+// are slash-separated and relative; each target maps them onto its own
+// namespace.
+
+package pstore
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+)
+
+// BackupTarget stores the blobs of object-data backups.
+type BackupTarget interface {
+	// Put stores size bytes from r under name.  A reader that sees the name
+	// at all sees the whole blob.
+	Put(ctx context.Context, name string, r io.ReadSeeker, size int64) error
+	// Get opens the blob stored under name.
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// Exists reports whether a blob is stored under name.
+	Exists(ctx context.Context, name string) (bool, error)
+	// List returns every name under the directory-like prefix dir, sorted.
+	List(ctx context.Context, dir string) ([]string, error)
+	// Delete removes the blob stored under name, if there is one.
+	Delete(ctx context.Context, name string) error
+	// String describes the target for logs.
+	String() string
+}
+
+// S3TargetConfig is how to reach an S3-compatible backup target.  The bucket
+// and key prefix come from the location itself.
+type S3TargetConfig struct {
+	// Endpoint is the service URL; empty selects AWS.
+	Endpoint string
+	// Region defaults to us-east-1, which most S3-compatible services accept.
+	Region string
+	// AccessKey and SecretKey are static credentials.  When empty, the SDK's
+	// ambient credential chain is used.
+	AccessKey string
+	SecretKey string
+	// VirtualHostStyle addresses the bucket as a hostname rather than a path.
+	// Path style is the default because most S3-compatible services and
+	// custom endpoints require it.
+	VirtualHostStyle bool
+}
+
+// OpenBackupTarget opens the backup target at location: an s3:// URL naming a
+// bucket and an optional key prefix, or a local directory, created if
+// missing.
+func OpenBackupTarget(ctx context.Context, location string, s3cfg S3TargetConfig) (BackupTarget, error) {
+	if location == "" {
+		return nil, errors.New("no backup target location given")
+	}
+	if rest, ok := strings.CutPrefix(location, "s3://"); ok {
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		if bucket == "" {
+			return nil, errors.Errorf("backup target %s names no bucket", location)
+		}
+		return newS3Target(ctx, bucket, strings.Trim(prefix, "/"), s3cfg)
+	}
+	if strings.Contains(location, "://") {
+		return nil, errors.Errorf("backup target %s is neither a directory nor an s3:// URL", location)
+	}
+	return NewDirBackupTarget(location)
+}
+
+// checkBlobName refuses names that would escape a target's namespace.
+func checkBlobName(name string) error {
+	if name == "" || path.Clean(name) != name || !filepath.IsLocal(filepath.FromSlash(name)) {
+		return errors.Errorf("invalid backup blob name %q", name)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// A directory
+// ---------------------------------------------------------------------------
+
+// dirTarget keeps each blob as a file under a root directory.
+type dirTarget struct {
+	root string
+}
+
+// NewDirBackupTarget opens a directory as a backup target, creating it if
+// missing.  It can be anything mounted locally, including a network
+// filesystem on the far side of the failure being insured against.
+func NewDirBackupTarget(root string) (BackupTarget, error) {
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the backup target directory %s", root)
+	}
+	return &dirTarget{root: root}, nil
+}
+
+func (d *dirTarget) String() string { return d.root }
+
+func (d *dirTarget) path(name string) (string, error) {
+	if err := checkBlobName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(d.root, filepath.FromSlash(name)), nil
+}
+
+// Put writes through a temporary name, flushed before the rename, for the
+// same reason snapshotOnce does: a crash must not leave a truncated blob
+// under a name that later backups will take as present and complete.
+func (d *dirTarget) Put(_ context.Context, name string, r io.ReadSeeker, _ int64) error {
+	final, err := d.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(final), 0700); err != nil {
+		return errors.Wrapf(err, "failed to create the directory for %s", final)
+	}
+	tmp := final + ".partial"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %s", tmp)
+	}
+	_, err = io.Copy(f, r)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, final)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return errors.Wrapf(err, "failed to write %s", final)
+	}
+	syncDir(filepath.Dir(final))
+	return nil
+}
+
+func (d *dirTarget) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	p, err := d.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", p)
+	}
+	return f, nil
+}
+
+func (d *dirTarget) Exists(_ context.Context, name string) (bool, error) {
+	p, err := d.path(name)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, errors.Wrapf(err, "failed to check for %s", p)
+}
+
+func (d *dirTarget) List(_ context.Context, dir string) ([]string, error) {
+	base, err := d.path(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	err = filepath.WalkDir(base, func(p string, e fs.DirEntry, wErr error) error {
+		if wErr != nil {
+			if errors.Is(wErr, fs.ErrNotExist) {
+				return nil
+			}
+			return wErr
+		}
+		// A .partial file is a Put that never finished, not a blob.
+		if e.IsDir() || strings.HasSuffix(p, ".partial") {
+			return nil
+		}
+		rel, rErr := filepath.Rel(d.root, p)
+		if rErr != nil {
+			return rErr
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list %s", base)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (d *dirTarget) Delete(_ context.Context, name string) error {
+	p, err := d.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.Wrapf(err, "failed to remove %s", p)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// An S3-compatible bucket
+// ---------------------------------------------------------------------------
+
+// s3Target keeps each blob as an object under a key prefix in a bucket.
+type s3Target struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// newS3Target builds a client carrying its own credentials rather than
+// reading them from the process environment, for the reason
+// origin_serve/backend_blob.go gives: an origin may talk to several accounts
+// at once.
+func newS3Target(ctx context.Context, bucket, prefix string, cfg S3TargetConfig) (BackupTarget, error) {
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	cfgOpts := []func(*config.LoadOptions) error{config.WithRegion(region)}
+	if cfg.AccessKey != "" || cfg.SecretKey != "" {
+		cfgOpts = append(cfgOpts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, cfgOpts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to configure the S3 client for bucket %s", bucket)
+	}
+
+	var s3Opts []func(*s3.Options)
+	if !cfg.VirtualHostStyle {
+		s3Opts = append(s3Opts, func(o *s3.Options) { o.UsePathStyle = true })
+	}
+	if cfg.Endpoint != "" {
+		endpoint := cfg.Endpoint
+		s3Opts = append(s3Opts, func(o *s3.Options) { o.BaseEndpoint = &endpoint })
+	}
+	return &s3Target{client: s3.NewFromConfig(awsCfg, s3Opts...), bucket: bucket, prefix: prefix}, nil
+}
+
+func (t *s3Target) String() string {
+	if t.prefix == "" {
+		return "s3://" + t.bucket
+	}
+	return "s3://" + t.bucket + "/" + t.prefix
+}
+
+func (t *s3Target) key(name string) (string, error) {
+	if err := checkBlobName(name); err != nil {
+		return "", err
+	}
+	if t.prefix == "" {
+		return name, nil
+	}
+	return t.prefix + "/" + name, nil
+}
+
+// Put is a single PutObject, which S3 makes visible only once complete.  The
+// reader is seekable so that the request can be signed and retried.
+func (t *s3Target) Put(ctx context.Context, name string, r io.ReadSeeker, size int64) error {
+	key, err := t.key(name)
+	if err != nil {
+		return err
+	}
+	_, err = t.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        &t.bucket,
+		Key:           &key,
+		Body:          r,
+		ContentLength: &size,
+	})
+	return errors.Wrapf(err, "failed to upload %s to %s", name, t)
+}
+
+func (t *s3Target) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	key, err := t.key(name)
+	if err != nil {
+		return nil, err
+	}
+	out, err := t.client.GetObject(ctx, &s3.GetObjectInput{Bucket: &t.bucket, Key: &key})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %s from %s", name, t)
+	}
+	return out.Body, nil
+}
+
+func (t *s3Target) Exists(ctx context.Context, name string) (bool, error) {
+	key, err := t.key(name)
+	if err != nil {
+		return false, err
+	}
+	_, err = t.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &t.bucket, Key: &key})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	return err == nil, errors.Wrapf(err, "failed to check for %s in %s", name, t)
+}
+
+func (t *s3Target) List(ctx context.Context, dir string) ([]string, error) {
+	prefix, err := t.key(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix += "/"
+
+	var names []string
+	pages := s3.NewListObjectsV2Paginator(t.client, &s3.ListObjectsV2Input{Bucket: &t.bucket, Prefix: &prefix})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list %s in %s", dir, t)
+		}
+		for _, obj := range page.Contents {
+			if obj.Key == nil {
+				continue
+			}
+			name := *obj.Key
+			if t.prefix != "" {
+				name = strings.TrimPrefix(name, t.prefix+"/")
+			}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (t *s3Target) Delete(ctx context.Context, name string) error {
+	key, err := t.key(name)
+	if err != nil {
+		return err
+	}
+	_, err = t.client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &t.bucket, Key: &key})
+	return errors.Wrapf(err, "failed to delete %s from %s", name, t)
+}
@@ -273,6 +273,14 @@ func (ct *capacityTracker) anyDirHasRoomLocked(nBytes, held int64) bool {
 // would mean returning an invalid StorageID, and the reservation path has
 // already declined the write.
 func (ct *capacityTracker) chooseDir() local_cache.StorageID {
+	best, _ := ct.chooseDirExcluding(nil)
+	return best
+}
+
+// chooseDirExcluding is chooseDir restricted to the directories not in
+// exclude, which erasure coding uses to keep a stripe's shards apart.  It
+// reports false when every directory is excluded.
+func (ct *capacityTracker) chooseDirExcluding(exclude map[local_cache.StorageID]bool) (local_cache.StorageID, bool) {
 	ct.mu.Lock()
 	defer ct.mu.Unlock()
 
@@ -282,7 +290,7 @@ func (ct *capacityTracker) chooseDir() local_cache.StorageID {
 		bestFound bool
 	)
 	for id, dc := range ct.dirs {
-		if id == local_cache.StorageIDInline {
+		if id == local_cache.StorageIDInline || exclude[id] {
 			continue
 		}
 		room := int64(math.MaxInt64)
@@ -293,7 +301,15 @@ func (ct *capacityTracker) chooseDir() local_cache.StorageID {
 			best, bestRoom, bestFound = id, room, true
 		}
 	}
-	return best
+	return best, bestFound
+}
+
+// forgetDir stops offering a directory for placement, once it is known to be
+// gone.  What it held stays in the total until it is released or moved.
+func (ct *capacityTracker) forgetDir(id local_cache.StorageID) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	delete(ct.dirs, id)
 }
 
 // releaseReservation returns capacity claimed by a write that never landed.
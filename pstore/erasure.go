@@ -0,0 +1,1112 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+// Erasure coding across storage directories.
+//
+// The block store spreads an object's chunks across directories for capacity,
+// not for safety: a directory lost takes every chunk in it, and with it every
+// object that had one there.  A cache shrugs that off; an origin has nowhere
+// to re-fetch from.  With ErasureConfig set, each run of DataShards
+// consecutive chunks is a stripe, and ParityShards Reed-Solomon parity shards
+// are computed over it at commit, so that any ParityShards of a stripe's
+// files can be lost and rebuilt from the rest.
+//
+// The data shards are the chunk files themselves, exactly as the block store
+// wrote them: reading a healthy object is unchanged, and only a loss costs
+// anything.  What the layout adds is placement -- a stripe's chunks are put in
+// different directories (placeErasureChunk) and its parity in yet others --
+// and a record per object version, under its own prefix:
+//
+//	pe:<instance hash>  ->  erasureLayout
+//
+// The record names each stripe's parity directories and carries the SHA-256
+// of every shard, which is what tells a corrupt shard from a good one: the
+// block MACs would catch a corrupt data shard too, but nothing else covers
+// parity, and one digest per shard checks both the same way.  Shards are
+// coded as stored, ciphertext, so a rebuild needs no keys and reproduces the
+// lost file byte for byte.  A stripe with fewer than DataShards chunks -- the
+// last one, or an object too small to chunk -- is coded as though padded with
+// empty shards, which is why a small object's parity costs as much as the
+// object: below a stripe's worth of data, erasure coding is replication.
+//
+// A lost or corrupt shard is rebuilt where it was when that directory is
+// still mounted, and in another directory outside the stripe when it is not.
+// Three things find one: a read that fails (openReader and ReadHandle), the
+// scheduled data scan, and `pelican-server origin pstore rebuild`, which is
+// also how a replacement disk is put back into service.
+//
+// The record is written before any parity file exists and completed once all
+// of them do, so a crash mid-encode leaves a record that names every file to
+// remove.  The instance is already queued for reclamation by then
+// (beginMaterialize), so the janitor removes the parity with the rest.
+
+package pstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/klauspost/reedsolomon"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/vmihailenco/msgpack/v5"
+	"golang.org/x/time/rate"
+
+	"github.com/pelicanplatform/pelican/local_cache"
+	"github.com/pelicanplatform/pelican/metrics"
+)
+
+const (
+	// maxErasureShards is the most shards a stripe can have: Reed-Solomon over
+	// GF(2^8) has 256 distinct evaluation points.
+	maxErasureShards = 256
+
+	// erasureStreamBlockSize is how much of each shard the coder holds at
+	// once.  A stripe of 64 MiB chunks is coded in pieces of this size rather
+	// than whole, so memory is bounded by the shard count, not the chunk size.
+	erasureStreamBlockSize = 1 << 20
+
+	// erasureScanBatch bounds how many layout records one read transaction
+	// collects during a scan.
+	erasureScanBatch = 256
+
+	// defaultErasureScanRate is the read rate a scan takes when none is
+	// configured, the same default the block store's data scan uses.
+	defaultErasureScanRate = 100 << 20
+)
+
+// errTooManyShardsLost is returned when a stripe has lost more shards than
+// its parity can rebuild.
+var errTooManyShardsLost = errors.New("more shards are lost than parity can rebuild")
+
+// ErasureConfig describes the erasure-coded layout for newly written objects.
+// Objects keep the layout they were written with, so changing it affects only
+// new writes.
+type ErasureConfig struct {
+	// DataShards is the number of chunks in a stripe.  Zero disables erasure
+	// coding.
+	DataShards int
+	// ParityShards is the number of parity shards per stripe, and so the
+	// number of storage directories that can be lost without losing data.
+	ParityShards int
+}
+
+// Enabled reports whether new objects are erasure coded.
+func (c ErasureConfig) Enabled() bool { return c.DataShards > 0 }
+
+// validate checks the layout can be placed on dirCount directories.
+func (c ErasureConfig) validate(dirCount int, dedup bool) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.ParityShards < 1 {
+		return errors.Errorf("erasure coding needs at least one parity shard, not %d", c.ParityShards)
+	}
+	shards := c.DataShards + c.ParityShards
+	if shards > maxErasureShards {
+		return errors.Errorf("erasure coding supports at most %d shards per stripe, not %d",
+			maxErasureShards, shards)
+	}
+	if shards > dirCount {
+		return errors.Errorf("erasure coding %d+%d puts each stripe's shards in %d different "+
+			"storage directories, but the store has %d", c.DataShards, c.ParityShards, shards, dirCount)
+	}
+	// Deduplicated objects have no chunk files of their own to code: their
+	// blocks live in a pool shared with other objects.
+	if dedup {
+		return errors.New("erasure coding cannot be combined with block deduplication")
+	}
+	return nil
+}
+
+// erasureLayout is the persisted layout of one erasure-coded object version.
+type erasureLayout struct {
+	DataShards   int                     `msgpack:"k"`
+	ParityShards int                     `msgpack:"m"`
+	Namespace    local_cache.NamespaceID `msgpack:"ns"`
+	Stripes      []erasureStripe         `msgpack:"s"`
+	// Complete is set once every parity file is written and every digest
+	// recorded.  Until then the record only says which files to remove.
+	Complete bool `msgpack:"c,omitempty"`
+}
+
+// erasureStripe is one stripe's parity placement and shard digests.
+type erasureStripe struct {
+	// ShardSize is the length every shard is coded at: the largest chunk file
+	// in the stripe, the others padded with zeros.  It is also the length of
+	// each parity file.
+	ShardSize int64 `msgpack:"z"`
+	// DataSizes are the lengths of the stripe's chunk files, in order.  A
+	// stripe can have fewer than DataShards of them.
+	DataSizes []int64 `msgpack:"ds"`
+	// Parity is the storage directory of each parity shard.
+	Parity []local_cache.StorageID `msgpack:"p"`
+	// Digests are the SHA-256 of each chunk file and then of each parity
+	// file; empty until the layout is complete.
+	Digests [][]byte `msgpack:"d,omitempty"`
+}
+
+// digest returns the recorded digest of one of the stripe's shards, numbered
+// as in badShards.  A short stripe's digests skip the chunks it does not have.
+func (st *erasureStripe) digest(dataShards, shard int) []byte {
+	if shard < dataShards {
+		return st.Digests[shard]
+	}
+	return st.Digests[len(st.DataSizes)+shard-dataShards]
+}
+
+// parityBytes is the on-disk size of the layout's parity, per directory.
+func (l *erasureLayout) parityBytes() map[local_cache.StorageID]int64 {
+	out := make(map[local_cache.StorageID]int64)
+	for _, st := range l.Stripes {
+		for _, id := range st.Parity {
+			out[id] += st.ShardSize
+		}
+	}
+	return out
+}
+
+// ErasureReport is the result of checking erasure-coded objects.
+type ErasureReport struct {
+	// Objects is the number of erasure-coded object versions checked.
+	Objects int
+	// ObjectsRepaired is how many of those had at least one shard rebuilt.
+	ObjectsRepaired int
+	// ShardsRebuilt is the number of lost or corrupt shards reconstructed.
+	ShardsRebuilt int
+	// ShardsRelocated is how many of those were rebuilt in a different
+	// directory, because their own was no longer mounted.
+	ShardsRelocated int
+	// Unrecoverable maps each object that could not be repaired -- by path,
+	// or by instance hash for a version no path refers to -- to the reason.
+	Unrecoverable map[string]string
+	// AdoptedDirs are the replacement directories a rebuild took into
+	// service under the identity of the ones they replaced.
+	AdoptedDirs []local_cache.StorageID
+	// RetiredDirs are the directories a rebuild found gone altogether, whose
+	// shards it rebuilt elsewhere.
+	RetiredDirs []local_cache.StorageID
+}
+
+func erasureKey(h local_cache.InstanceHash) []byte {
+	return []byte(local_cache.PrefixErasure + string(h))
+}
+
+// loadErasureLayout reads an object version's layout; nil means the version
+// is not erasure coded.
+func (s *Store) loadErasureLayout(h local_cache.InstanceHash) (*erasureLayout, error) {
+	var layout *erasureLayout
+	err := s.bdb.View(func(txn *badger.Txn) error {
+		item, gErr := txn.Get(erasureKey(h))
+		if errors.Is(gErr, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if gErr != nil {
+			return gErr
+		}
+		return item.Value(func(val []byte) error {
+			layout = &erasureLayout{}
+			return msgpack.Unmarshal(val, layout)
+		})
+	})
+	return layout, errors.Wrapf(err, "failed to read the erasure layout of %s", h)
+}
+
+// saveErasureLayout persists an object version's layout.
+func (s *Store) saveErasureLayout(h local_cache.InstanceHash, layout *erasureLayout) error {
+	val, err := msgpack.Marshal(layout)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode the erasure layout")
+	}
+	return errors.Wrapf(s.bdb.Update(func(txn *badger.Txn) error {
+		return txn.Set(erasureKey(h), val)
+	}), "failed to save the erasure layout of %s", h)
+}
+
+// ---------------------------------------------------------------------------
+// Placement
+// ---------------------------------------------------------------------------
+
+// placeErasureChunk chooses the directory for a new chunk so that no two
+// chunks of a stripe share one.  The block store calls it through
+// SetChunkPlacement in place of the capacity chooser.
+func (s *Store) placeErasureChunk(meta *local_cache.CacheMetadata, chunkIndex int) local_cache.StorageID {
+	k := s.erasure.DataShards
+	first := chunkIndex / k * k
+	exclude := make(map[local_cache.StorageID]bool, k)
+	for c := first; c < first+k; c++ {
+		if c == chunkIndex {
+			continue
+		}
+		if id := meta.GetChunkStorageID(c); id != local_cache.StorageIDInline {
+			exclude[id] = true
+		}
+	}
+	if id, ok := s.capacity.chooseDirExcluding(exclude); ok {
+		return id
+	}
+	return s.capacity.chooseDir()
+}
+
+// dataShardPath returns the file of chunk c of an object version.
+func (s *Store) dataShardPath(h local_cache.InstanceHash, meta *local_cache.CacheMetadata, c int) (local_cache.StorageID, string, bool) {
+	id := meta.GetChunkStorageID(c)
+	p, ok := s.storage.ChunkFilePath(id, h, c)
+	return id, p, ok
+}
+
+// parityShardPath returns the file of parity shard j of stripe si when it
+// lives in directory id.  It sits beside the object's chunk files, under a
+// name the block store's own scans do not recognize as one of them.
+func (s *Store) parityShardPath(id local_cache.StorageID, h local_cache.InstanceHash, si, j int) (string, bool) {
+	base, ok := s.storage.ChunkFilePath(id, h, 0)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s.p%d.%d", base, si, j), true
+}
+
+// chunkCount is the number of chunk files an object version has.
+func chunkCount(meta *local_cache.CacheMetadata) int {
+	if meta.IsChunked() {
+		return meta.ChunkCount()
+	}
+	return 1
+}
+
+// chunkFootprint is what the usage counters were charged for chunk c, which
+// is what has to move with it when it is rebuilt in another directory.
+func chunkFootprint(meta *local_cache.CacheMetadata, c int) int64 {
+	if !meta.IsChunked() {
+		return local_cache.CalculateFileSize(meta.ContentLength)
+	}
+	return local_cache.CalculateFileSize(local_cache.ChunkContentLength(meta.ContentLength, meta.ChunkSizeCode, c))
+}
+
+// ---------------------------------------------------------------------------
+// Encoding
+// ---------------------------------------------------------------------------
+
+// encodeErasure writes the parity for a freshly materialized object version
+// and returns the bytes it added per directory, for settle.
+//
+// It runs before the version is installed, so a write is acknowledged only
+// once its parity is on disk.  The parity is reserved like the data is: a
+// store with room for an object but not for its parity refuses the write
+// rather than holding it unprotected.
+func (w *WriteHandle) encodeErasure(meta *local_cache.CacheMetadata) (map[local_cache.StorageID]int64, error) {
+	s := w.store
+	if !s.erasure.Enabled() || meta == nil || !meta.IsDisk() || meta.Dedup {
+		return nil, nil
+	}
+	layout, err := s.planErasure(w.instanceHash, meta)
+	if err != nil {
+		return nil, err
+	}
+	parity := layout.parityBytes()
+	var need, unit int64
+	for _, st := range layout.Stripes {
+		need += st.ShardSize * int64(len(st.Parity))
+		unit = max(unit, st.ShardSize)
+	}
+	if err := s.capacity.reserve(need, unit, w.reserved); err != nil {
+		if errors.Is(err, ErrNoSpace) {
+			metrics.PStoreWriteFailuresTotal.
+				WithLabelValues(metrics.PStoreWriteFailureNoSpace).Inc()
+		}
+		return nil, err
+	}
+	w.reserved += need
+	w.coded = true
+
+	if err := s.writeParity(context.Background(), w.instanceHash, meta, layout); err != nil {
+		return nil, err
+	}
+	return parity, nil
+}
+
+// planErasure lays out an object version's stripes and chooses where each
+// stripe's parity goes: the directories with the most room among those
+// holding none of the stripe's chunks.
+func (s *Store) planErasure(h local_cache.InstanceHash, meta *local_cache.CacheMetadata) (*erasureLayout, error) {
+	k, m := s.erasure.DataShards, s.erasure.ParityShards
+	chunks := chunkCount(meta)
+	layout := &erasureLayout{DataShards: k, ParityShards: m, Namespace: meta.NamespaceID}
+	for first := 0; first < chunks; first += k {
+		var st erasureStripe
+		used := make(map[local_cache.StorageID]bool, k+m)
+		for c := first; c < min(first+k, chunks); c++ {
+			id, p, ok := s.dataShardPath(h, meta, c)
+			if !ok {
+				return nil, errors.Errorf("chunk %d of %s is in storage directory %d, which is not mounted", c, h, id)
+			}
+			fi, err := os.Stat(p)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to stat chunk %d of %s", c, h)
+			}
+			st.DataSizes = append(st.DataSizes, fi.Size())
+			st.ShardSize = max(st.ShardSize, fi.Size())
+			used[id] = true
+		}
+		for j := 0; j < m; j++ {
+			id, ok := s.capacity.chooseDirExcluding(used)
+			if !ok {
+				return nil, errors.Errorf("no storage directory is left for parity shard %d of "+
+					"stripe %d of %s", j, len(layout.Stripes), h)
+			}
+			used[id] = true
+			st.Parity = append(st.Parity, id)
+		}
+		layout.Stripes = append(layout.Stripes, st)
+	}
+	return layout, nil
+}
+
+// writeParity records the layout, charges and writes every parity file, and
+// completes the layout with the shard digests.
+func (s *Store) writeParity(ctx context.Context, h local_cache.InstanceHash, meta *local_cache.CacheMetadata, layout *erasureLayout) error {
+	// The record goes first, so whatever follows can be found and removed.
+	if err := s.saveErasureLayout(h, layout); err != nil {
+		return err
+	}
+	for id, n := range layout.parityBytes() {
+		if err := s.db.ChargeUsage(id, layout.Namespace, n); err != nil {
+			return errors.Wrapf(err, "failed to charge parity usage to storage %d", id)
+		}
+	}
+
+	enc, err := newErasureCoder(layout)
+	if err != nil {
+		return err
+	}
+	for si := range layout.Stripes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.encodeStripe(enc, h, meta, layout, si); err != nil {
+			return errors.Wrapf(err, "failed to encode stripe %d of %s", si, h)
+		}
+	}
+	layout.Complete = true
+	return s.saveErasureLayout(h, layout)
+}
+
+// encodeStripe computes one stripe's parity files and records its digests.
+func (s *Store) encodeStripe(enc reedsolomon.StreamEncoder, h local_cache.InstanceHash, meta *local_cache.CacheMetadata, layout *erasureLayout, si int) error {
+	st := &layout.Stripes[si]
+	k := layout.DataShards
+
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+
+	data := make([]io.Reader, k)
+	hashes := make([]hash.Hash, 0, len(st.DataSizes)+len(st.Parity))
+	for i := range data {
+		if i >= len(st.DataSizes) {
+			data[i] = zeroShard(st.ShardSize)
+			continue
+		}
+		_, p, _ := s.dataShardPath(h, meta, si*k+i)
+		f, err := os.Open(p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to open chunk %d", si*k+i)
+		}
+		files = append(files, f)
+		hh := sha256.New()
+		hashes = append(hashes, hh)
+		data[i] = paddedShard(io.TeeReader(io.LimitReader(f, st.DataSizes[i]), hh), st.DataSizes[i], st.ShardSize)
+	}
+
+	parity := make([]io.Writer, len(st.Parity))
+	outputs := make([]*os.File, len(st.Parity))
+	for j, id := range st.Parity {
+		p, ok := s.parityShardPath(id, h, si, j)
+		if !ok {
+			return errors.Errorf("storage directory %d is not mounted", id)
+		}
+		f, err := createShardFile(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		outputs[j] = f
+		hh := sha256.New()
+		hashes = append(hashes, hh)
+		parity[j] = io.MultiWriter(f, hh)
+	}
+
+	if err := enc.Encode(data, parity); err != nil {
+		return errors.Wrap(err, "failed to compute parity")
+	}
+	for _, f := range outputs {
+		if err := f.Sync(); err != nil {
+			return errors.Wrapf(err, "failed to sync %s", f.Name())
+		}
+	}
+	st.Digests = make([][]byte, len(hashes))
+	for i, hh := range hashes {
+		st.Digests[i] = hh.Sum(nil)
+	}
+	return nil
+}
+
+// dropErasure removes an object version's parity files, uncharges them, and
+// deletes its layout, returning the bytes freed per directory.  A version
+// that is not erasure coded frees nothing.
+func (s *Store) dropErasure(h local_cache.InstanceHash) (map[local_cache.StorageID]int64, error) {
+	layout, err := s.loadErasureLayout(h)
+	if err != nil || layout == nil {
+		return nil, err
+	}
+	for si, st := range layout.Stripes {
+		for j, id := range st.Parity {
+			p, ok := s.parityShardPath(id, h, si, j)
+			if !ok {
+				continue
+			}
+			if rErr := os.Remove(p); rErr != nil && !errors.Is(rErr, os.ErrNotExist) {
+				return nil, errors.Wrapf(rErr, "failed to remove parity shard %s", p)
+			}
+		}
+	}
+	freed := layout.parityBytes()
+	for id, n := range freed {
+		if cErr := s.db.ChargeUsage(id, layout.Namespace, -n); cErr != nil {
+			return nil, errors.Wrapf(cErr, "failed to uncharge parity usage from storage %d", id)
+		}
+	}
+	if err := s.bdb.Update(func(txn *badger.Txn) error {
+		return txn.Delete(erasureKey(h))
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to delete the erasure layout of %s", h)
+	}
+	return freed, nil
+}
+
+// ---------------------------------------------------------------------------
+// Repair
+// ---------------------------------------------------------------------------
+
+// repairResult counts what one repair did.
+type repairResult struct {
+	rebuilt, relocated int
+}
+
+// repairInstance checks every shard of an erasure-coded object version
+// against its digest and rebuilds the ones that are missing or corrupt.  A
+// version that is not erasure coded, or a store open read-only, is left alone.
+//
+// Repairs are serialized store-wide.  They are rare, and two of them racing
+// on one object would each rebuild the same shard into the same place.  The
+// version is pinned for the duration, so the janitor cannot reclaim it
+// underneath.
+func (s *Store) repairInstance(ctx context.Context, h local_cache.InstanceHash, trigger string, lim *rate.Limiter) (repairResult, error) {
+	var res repairResult
+	if s.readOnly {
+		return res, nil
+	}
+	s.erasureMu.Lock()
+	defer s.erasureMu.Unlock()
+	unpin := s.storage.PinObject(h)
+	defer unpin()
+
+	layout, err := s.loadErasureLayout(h)
+	if err != nil || layout == nil || !layout.Complete {
+		return res, err
+	}
+	meta, err := s.db.GetMetadata(h)
+	if err != nil || meta == nil {
+		return res, err
+	}
+	enc, err := newErasureCoder(layout)
+	if err != nil {
+		return res, err
+	}
+
+	var (
+		firstErr                   error
+		metaChanged, layoutChanged bool
+	)
+	for si := range layout.Stripes {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		bad, err := s.badShards(ctx, h, meta, layout, si, lim)
+		if err != nil {
+			return res, err
+		}
+		if len(bad) == 0 {
+			continue
+		}
+		if len(bad) > layout.ParityShards {
+			metrics.PStoreErasureUnrecoverableTotal.Inc()
+			if firstErr == nil {
+				firstErr = errors.Wrapf(errTooManyShardsLost, "stripe %d of %s has lost %d shards and has %d parity",
+					si, h, len(bad), layout.ParityShards)
+			}
+			continue
+		}
+		moved, err := s.rebuildStripe(enc, h, meta, layout, si, bad)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "failed to rebuild stripe %d of %s", si, h)
+			}
+			continue
+		}
+		res.rebuilt += len(bad)
+		for _, i := range moved {
+			res.relocated++
+			if i < layout.DataShards {
+				metaChanged = true
+			} else {
+				layoutChanged = true
+			}
+		}
+	}
+
+	if metaChanged {
+		if err := s.storage.SetMetadata(h, meta); err != nil {
+			return res, errors.Wrapf(err, "failed to record the new chunk locations of %s", h)
+		}
+	}
+	if layoutChanged {
+		if err := s.saveErasureLayout(h, layout); err != nil {
+			return res, err
+		}
+	}
+	if res.rebuilt > 0 {
+		s.storage.InvalidateObjectFiles(h)
+		metrics.PStoreErasureShardsRebuiltTotal.WithLabelValues(trigger).Add(float64(res.rebuilt))
+		log.Warnf("Rebuilt %d shard(s) of erasure-coded object version %s (%d in a new directory)",
+			res.rebuilt, h, res.relocated)
+	}
+	return res, firstErr
+}
+
+// badShards returns the indexes of a stripe's shards that are missing,
+// unreadable, the wrong length, or do not match their digest.  Data shards
+// are 0..DataShards-1 and parity shards follow.
+func (s *Store) badShards(ctx context.Context, h local_cache.InstanceHash, meta *local_cache.CacheMetadata, layout *erasureLayout, si int, lim *rate.Limiter) ([]int, error) {
+	st := &layout.Stripes[si]
+	var bad []int
+	check := func(shard int, p string, ok bool, size int64, digest []byte) error {
+		if !ok {
+			bad = append(bad, shard)
+			return nil
+		}
+		sum, n, err := hashShardFile(ctx, p, lim)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil || n != size || string(sum) != string(digest) {
+			bad = append(bad, shard)
+		}
+		return nil
+	}
+	for i, size := range st.DataSizes {
+		_, p, ok := s.dataShardPath(h, meta, si*layout.DataShards+i)
+		if err := check(i, p, ok, size, st.digest(layout.DataShards, i)); err != nil {
+			return nil, err
+		}
+	}
+	for j, id := range st.Parity {
+		p, ok := s.parityShardPath(id, h, si, j)
+		shard := layout.DataShards + j
+		if err := check(shard, p, ok, st.ShardSize, st.digest(layout.DataShards, shard)); err != nil {
+			return nil, err
+		}
+	}
+	return bad, nil
+}
+
+// rebuildStripe reconstructs the given shards of one stripe from the rest,
+// each into its own directory when that is mounted and into another outside
+// the stripe when it is not.  It returns the shards it moved, having updated
+// meta or layout with their new directories for the caller to persist.
+//
+// Each shard is written beside its final name and renamed over it only once
+// its digest is confirmed, so a failed rebuild never leaves a file that looks
+// right and is not.
+func (s *Store) rebuildStripe(enc reedsolomon.StreamEncoder, h local_cache.InstanceHash, meta *local_cache.CacheMetadata, layout *erasureLayout, si int, bad []int) ([]int, error) {
+	st := &layout.Stripes[si]
+	k := layout.DataShards
+	total := k + layout.ParityShards
+	isBad := make(map[int]bool, len(bad))
+	for _, i := range bad {
+		isBad[i] = true
+	}
+
+	// Where every shard lives, so a relocated one avoids the rest.
+	dirOf := func(i int) local_cache.StorageID {
+		if i < k {
+			return meta.GetChunkStorageID(si*k + i)
+		}
+		return st.Parity[i-k]
+	}
+	used := make(map[local_cache.StorageID]bool, total)
+	for i := 0; i < total; i++ {
+		if i < k && i >= len(st.DataSizes) {
+			continue
+		}
+		used[dirOf(i)] = true
+	}
+
+	type target struct {
+		shard     int
+		path, tmp string
+		from, to  local_cache.StorageID
+		file      *os.File
+		hash      hash.Hash
+	}
+	var (
+		files   []*os.File
+		targets []*target
+	)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+		for _, t := range targets {
+			_ = os.Remove(t.tmp)
+		}
+	}()
+
+	valid := make([]io.Reader, total)
+	fill := make([]io.Writer, total)
+	for i := 0; i < total; i++ {
+		var (
+			p    string
+			ok   bool
+			size = st.ShardSize
+		)
+		switch {
+		case i < k && i >= len(st.DataSizes):
+			valid[i] = zeroShard(st.ShardSize)
+			continue
+		case i < k:
+			_, p, ok = s.dataShardPath(h, meta, si*k+i)
+			size = st.DataSizes[i]
+		default:
+			p, ok = s.parityShardPath(st.Parity[i-k], h, si, i-k)
+		}
+
+		if !isBad[i] {
+			f, err := os.Open(p)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to open shard %d", i)
+			}
+			files = append(files, f)
+			valid[i] = paddedShard(io.LimitReader(f, size), size, st.ShardSize)
+			continue
+		}
+
+		t := &target{shard: i, from: dirOf(i), to: dirOf(i)}
+		if !ok {
+			id, found := s.capacity.chooseDirExcluding(used)
+			if !found {
+				return nil, errors.Errorf("no storage directory outside the stripe is left for shard %d", i)
+			}
+			used[id] = true
+			t.to = id
+			if i < k {
+				p, ok = s.storage.ChunkFilePath(id, h, si*k+i)
+			} else {
+				p, ok = s.parityShardPath(id, h, si, i-k)
+			}
+			if !ok {
+				return nil, errors.Errorf("storage directory %d is not mounted", id)
+			}
+		}
+		t.path, t.tmp = p, p+".rebuild"
+		f, err := createShardFile(t.tmp)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		t.file, t.hash = f, sha256.New()
+		targets = append(targets, t)
+		fill[i] = &truncatingWriter{w: io.MultiWriter(f, t.hash), remaining: size}
+	}
+
+	if err := enc.Reconstruct(valid, fill); err != nil {
+		return nil, errors.Wrap(err, "failed to reconstruct")
+	}
+
+	var moved []int
+	for _, t := range targets {
+		if string(t.hash.Sum(nil)) != string(st.digest(k, t.shard)) {
+			return nil, errors.Errorf("shard %d rebuilt with the wrong digest; the stripe has more damage than was detected", t.shard)
+		}
+		if err := t.file.Sync(); err != nil {
+			return nil, errors.Wrapf(err, "failed to sync %s", t.tmp)
+		}
+		if err := os.Rename(t.tmp, t.path); err != nil {
+			return nil, errors.Wrapf(err, "failed to install rebuilt shard %s", t.path)
+		}
+		if t.to == t.from {
+			continue
+		}
+
+		// The shard changed directory, so its charge moves with it.
+		n := st.ShardSize
+		if t.shard < k {
+			n = chunkFootprint(meta, si*k+t.shard)
+			meta.SetChunkStorageID(si*k+t.shard, t.to)
+		} else {
+			st.Parity[t.shard-k] = t.to
+		}
+		if err := s.db.ChargeUsage(t.from, layout.Namespace, -n); err != nil {
+			log.Warnf("Failed to move the usage of a rebuilt shard off storage %d: %v", t.from, err)
+		}
+		if err := s.db.ChargeUsage(t.to, layout.Namespace, n); err != nil {
+			log.Warnf("Failed to charge the usage of a rebuilt shard to storage %d: %v", t.to, err)
+		}
+		s.capacity.release(t.from, n)
+		s.capacity.settle(0, map[local_cache.StorageID]int64{t.to: n})
+		moved = append(moved, t.shard)
+	}
+	return moved, nil
+}
+
+// repairAfterFailedRead rebuilds an erasure-coded version a read could not
+// get through, and reports whether anything was rebuilt and the read is
+// worth retrying.
+func (s *Store) repairAfterFailedRead(h local_cache.InstanceHash, cause error) bool {
+	res, err := s.repairInstance(context.Background(), h, metrics.PStoreErasureTriggerRead, nil)
+	if err != nil {
+		log.Errorf("Failed to repair object version %s after a failed read (%v): %v", h, cause, err)
+	}
+	return res.rebuilt > 0
+}
+
+// openReader opens an object version for reading, repairing it and trying
+// once more when it will not open.
+func (s *Store) openReader(h local_cache.InstanceHash) (*local_cache.ObjectReader, error) {
+	reader, err := s.storage.NewObjectReader(h)
+	if err == nil || !s.repairAfterFailedRead(h, err) {
+		return reader, err
+	}
+	return s.storage.NewObjectReader(h)
+}
+
+// ---------------------------------------------------------------------------
+// Scans
+// ---------------------------------------------------------------------------
+
+// checkErasure verifies every erasure-coded object version and rebuilds
+// what it can.  bytesPerSec caps the read rate; zero reads at full speed.
+func (s *Store) checkErasure(ctx context.Context, trigger string, bytesPerSec int64) (*ErasureReport, error) {
+	var lim *rate.Limiter
+	if bytesPerSec > 0 {
+		lim = rate.NewLimiter(rate.Limit(bytesPerSec), erasureStreamBlockSize)
+	}
+	report := &ErasureReport{Unrecoverable: make(map[string]string)}
+	failed := make(map[local_cache.InstanceHash]string)
+
+	var after []byte
+	for {
+		batch, last, err := s.erasureBatch(after)
+		if err != nil {
+			return report, err
+		}
+		if last == nil {
+			break
+		}
+		after = last
+		for _, h := range batch {
+			res, err := s.repairInstance(ctx, h, trigger, lim)
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return report, ctxErr
+			}
+			report.Objects++
+			if res.rebuilt > 0 {
+				report.ObjectsRepaired++
+			}
+			report.ShardsRebuilt += res.rebuilt
+			report.ShardsRelocated += res.relocated
+			if err != nil {
+				failed[h] = err.Error()
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		// Name what was lost the way an operator will look for it.
+		if err := s.scanIndexBatched(ctx, func(entryPath string, d *Dirent) error {
+			if d.IsDir() {
+				return nil
+			}
+			h := instanceHashFor(s.db, d.Generation)
+			if reason, ok := failed[h]; ok {
+				report.Unrecoverable[entryPath] = reason
+				delete(failed, h)
+			}
+			return nil
+		}); err != nil {
+			return report, err
+		}
+		for h, reason := range failed {
+			report.Unrecoverable[string(h)] = reason
+		}
+	}
+	return report, nil
+}
+
+// erasureBatch returns the instance hashes of the next layout records after
+// the given key, and the key to resume from.  Records whose version is gone --
+// left by a reclaim that stopped between deleting the version and its parity
+// -- are dropped here rather than returned.
+func (s *Store) erasureBatch(after []byte) ([]local_cache.InstanceHash, []byte, error) {
+	prefix := []byte(local_cache.PrefixErasure)
+	var (
+		batch []local_cache.InstanceHash
+		last  []byte
+	)
+	err := s.bdb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		start := prefix
+		if after != nil {
+			start = nextKeyAfter(after)
+		}
+		for it.Seek(start); it.ValidForPrefix(prefix) && len(batch) < erasureScanBatch; it.Next() {
+			last = it.Item().KeyCopy(nil)
+			batch = append(batch, local_cache.InstanceHash(last[len(prefix):]))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list erasure layouts")
+	}
+
+	live := batch[:0]
+	for _, h := range batch {
+		meta, mErr := s.db.GetMetadata(h)
+		if mErr == nil && meta == nil && !s.readOnly {
+			if _, dErr := s.dropErasure(h); dErr != nil {
+				log.Warnf("Failed to drop the leftover erasure layout of %s: %v", h, dErr)
+			}
+			continue
+		}
+		live = append(live, h)
+	}
+	return live, last, nil
+}
+
+// scrubErasure is the data scan's erasure pass: checkErasure at the scan's
+// rate, with what could not be rebuilt reported loudly.
+func (s *Store) scrubErasure(ctx context.Context, bytesPerSec int64) error {
+	if bytesPerSec <= 0 {
+		bytesPerSec = defaultErasureScanRate
+	}
+	report, err := s.checkErasure(ctx, metrics.PStoreErasureTriggerScan, bytesPerSec)
+	if err != nil {
+		return err
+	}
+	if report.ShardsRebuilt > 0 {
+		log.Warnf("pstore erasure scrub rebuilt %d shard(s) across %d erasure-coded object version(s)",
+			report.ShardsRebuilt, report.ObjectsRepaired)
+	}
+	for name, reason := range report.Unrecoverable {
+		log.Errorf("pstore erasure scrub could not repair %s: %s. "+
+			"Restore it from backup or re-ingest it.", name, reason)
+	}
+	return nil
+}
+
+// RebuildErasure brings an offline store's erasure-coded objects back to
+// full strength after a disk has failed or been replaced.
+//
+// A replacement mounted where a failed directory was is first given that
+// directory's identity, so the shards rebuilt into it are found there when
+// the origin starts; a directory whose path is gone is retired, and its shards
+// go elsewhere.  Then every shard of every erasure-coded version is
+// checked against its digest, and the missing and corrupt ones rebuilt -- in
+// place, or in another directory when theirs is gone for good.
+func (s *Store) RebuildErasure(ctx context.Context) (*ErasureReport, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+	adopted, retired, err := s.storage.AdoptReplacedDirs()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range retired {
+		s.capacity.forgetDir(id)
+	}
+	report, err := s.checkErasure(ctx, metrics.PStoreErasureTriggerRebuild, 0)
+	if report != nil {
+		report.AdoptedDirs = adopted
+		report.RetiredDirs = retired
+	}
+	return report, err
+}
+
+// parityFootprint adds every erasure layout's parity to a per-counter total,
+// for fsck's comparison against the usage counters.
+func (s *Store) parityFootprint(ctx context.Context, out map[local_cache.StorageUsageKey]int64) error {
+	prefix := []byte(local_cache.PrefixErasure)
+	start := prefix
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n := 0
+		var last []byte
+		err := s.bdb.View(func(txn *badger.Txn) error {
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = prefix
+			it := txn.NewIterator(opts)
+			defer it.Close()
+			for it.Seek(start); it.ValidForPrefix(prefix) && n < fsckBatchSize; it.Next() {
+				var layout erasureLayout
+				if err := it.Item().Value(func(val []byte) error {
+					return msgpack.Unmarshal(val, &layout)
+				}); err != nil {
+					return err
+				}
+				for id, bytes := range layout.parityBytes() {
+					out[local_cache.StorageUsageKey{StorageID: id, NamespaceID: layout.Namespace}] += bytes
+				}
+				last = it.Item().KeyCopy(nil)
+				n++
+			}
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to total parity footprints")
+		}
+		if n < fsckBatchSize {
+			return nil
+		}
+		start = nextKeyAfter(last)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Shard I/O
+// ---------------------------------------------------------------------------
+
+// newErasureCoder returns a streaming coder for a layout's shard counts.
+func newErasureCoder(layout *erasureLayout) (reedsolomon.StreamEncoder, error) {
+	enc, err := reedsolomon.NewStream(layout.DataShards, layout.ParityShards,
+		reedsolomon.WithStreamBlockSize(erasureStreamBlockSize))
+	return enc, errors.Wrapf(err, "failed to set up %d+%d erasure coding", layout.DataShards, layout.ParityShards)
+}
+
+// createShardFile creates a shard file for writing, with its directory.
+func createShardFile(p string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the directory for %s", p)
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	return f, errors.Wrapf(err, "failed to create %s", p)
+}
+
+// hashShardFile returns the SHA-256 and length of a shard file, reading no
+// faster than lim allows.
+func hashShardFile(ctx context.Context, p string, lim *rate.Limiter) ([]byte, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if lim != nil {
+		r = &limitedReader{ctx: ctx, r: f, lim: lim}
+	}
+	hh := sha256.New()
+	n, err := io.Copy(hh, r)
+	if err != nil {
+		return nil, n, err
+	}
+	return hh.Sum(nil), n, nil
+}
+
+// zeros reads as an endless run of zero bytes.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// zeroShard is a shard of n zero bytes, standing in for a chunk a short
+// stripe does not have.
+func zeroShard(n int64) io.Reader { return io.LimitReader(zeros{}, n) }
+
+// paddedShard extends a size-byte shard with zeros to the stripe's shard
+// size.
+func paddedShard(r io.Reader, size, shardSize int64) io.Reader {
+	if size >= shardSize {
+		return r
+	}
+	return io.MultiReader(r, zeroShard(shardSize-size))
+}
+
+// truncatingWriter passes through the first remaining bytes written to it and
+// discards the rest: a rebuilt chunk comes out of the coder padded to the
+// stripe's shard size, and the padding is not part of the file.
+type truncatingWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (t *truncatingWriter) Write(p []byte) (int, error) {
+	keep := min(int64(len(p)), t.remaining)
+	if keep > 0 {
+		if _, err := t.w.Write(p[:keep]); err != nil {
+			return 0, err
+		}
+		t.remaining -= keep
+	}
+	return len(p), nil
+}
+
+// limitedReader paces reads through a rate limiter.
+type limitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	lim *rate.Limiter
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if burst := l.lim.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := l.r.Read(p)
+	if n > 0 {
+		if wErr := l.lim.WaitN(l.ctx, n); wErr != nil {
+			return n, wErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,424 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package pstore
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/local_cache"
+	"github.com/pelicanplatform/pelican/metrics"
+)
+
+// erasureFixture is an erasure-coded store over four directories, coded 2+1
+// so that one directory is always left over for a relocated shard.
+type erasureFixture struct {
+	t       *testing.T
+	baseDir string
+	dirs    []string
+	store   *Store
+}
+
+// erasureObjectSize spans three 2 MiB chunks, so the object has one full
+// stripe and one short one.
+const erasureObjectSize = 5<<20 + 12345
+
+func newErasureFixture(t *testing.T) *erasureFixture {
+	t.Helper()
+	local_cache.InitIssuerKeyForTests(t)
+	f := &erasureFixture{t: t, baseDir: t.TempDir()}
+	for i := 0; i < 4; i++ {
+		f.dirs = append(f.dirs, t.TempDir())
+	}
+	f.open()
+	t.Cleanup(func() {
+		if f.store != nil {
+			assert.NoError(t, f.store.Close())
+		}
+	})
+	return f
+}
+
+func (f *erasureFixture) open() {
+	f.t.Helper()
+	ctx, cancel := context.WithCancel(f.t.Context())
+	f.t.Cleanup(cancel)
+	egrp, _ := errgroup.WithContext(ctx)
+
+	dirs := make([]local_cache.StorageDirConfig, len(f.dirs))
+	for i, d := range f.dirs {
+		dirs[i] = local_cache.StorageDirConfig{Path: d}
+	}
+	s, err := Open(ctx, egrp, Config{
+		BaseDir:     f.baseDir,
+		StorageDirs: dirs,
+		Erasure:     ErasureConfig{DataShards: 2, ParityShards: 1},
+	})
+	require.NoError(f.t, err)
+	f.store = s
+}
+
+// reopen closes and reopens the store, so nothing it read before is cached.
+func (f *erasureFixture) reopen() {
+	f.t.Helper()
+	require.NoError(f.t, f.store.Close())
+	f.store = nil
+	f.open()
+}
+
+// write stores a chunked object of erasureObjectSize bytes.  The size hint
+// is what picks the chunk size, so it declares one chunk's worth.
+func (f *erasureFixture) write(name string, seed int64) []byte {
+	f.t.Helper()
+	content := randomBytes(erasureObjectSize, seed)
+	w, err := f.store.CreateSized(name, minStreamChunkSize)
+	require.NoError(f.t, err)
+	_, err = w.Write(content)
+	require.NoError(f.t, err)
+	require.NoError(f.t, w.Close())
+	return content
+}
+
+// shardFiles lists the object and parity files under one storage directory.
+func shardFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	require.NoError(t, filepath.WalkDir(filepath.Join(dir, "objects"), func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	}))
+	return files
+}
+
+// loseShards deletes every shard in one storage directory, leaving the
+// directory itself in service.
+func loseShards(t *testing.T, dir string) int {
+	t.Helper()
+	files := shardFiles(t, dir)
+	for _, p := range files {
+		require.NoError(t, os.Remove(p))
+	}
+	return len(files)
+}
+
+func TestErasureLayoutPlacesStripesApart(t *testing.T) {
+	f := newErasureFixture(t)
+	f.write("/obj", 1)
+
+	d, err := f.store.Stat("/obj")
+	require.NoError(t, err)
+	h := instanceHashFor(f.store.db, d.Generation)
+	meta, err := f.store.db.GetMetadata(h)
+	require.NoError(t, err)
+	layout, err := f.store.loadErasureLayout(h)
+	require.NoError(t, err)
+	require.NotNil(t, layout)
+	assert.True(t, layout.Complete)
+	require.Len(t, layout.Stripes, 2, "three chunks make a full stripe and a short one")
+
+	for si, st := range layout.Stripes {
+		seen := make(map[local_cache.StorageID]bool)
+		for i := range st.DataSizes {
+			id := meta.GetChunkStorageID(si*2 + i)
+			assert.False(t, seen[id], "stripe %d puts two chunks in directory %d", si, id)
+			seen[id] = true
+		}
+		for _, id := range st.Parity {
+			assert.False(t, seen[id], "stripe %d puts parity beside its own data in %d", si, id)
+			seen[id] = true
+		}
+		assert.Len(t, st.Digests, len(st.DataSizes)+len(st.Parity))
+	}
+}
+
+// TestErasureReadSurvivesALostDirectory is the point of the layout: every
+// shard in one directory gone, and the object still reads -- and is whole
+// again afterwards.
+func TestErasureReadSurvivesALostDirectory(t *testing.T) {
+	f := newErasureFixture(t)
+	content := f.write("/obj", 2)
+	small := randomBytes(64<<10, 3)
+	writeObject(t, f.store, "/small", small)
+
+	require.NoError(t, f.store.Close())
+	f.store = nil
+	lost := 0
+	for _, d := range f.dirs {
+		if n := loseShards(t, d); n > 0 {
+			lost = n
+			break
+		}
+	}
+	require.Positive(t, lost)
+	f.open()
+
+	got, err := f.store.ReadAll("/obj")
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+	got, err = f.store.ReadAll("/small")
+	require.NoError(t, err)
+	assert.Equal(t, small, got, "an object below a stripe is protected too")
+
+	// A read repairs the versions it trips over; parity no read needed is
+	// left to the scan.
+	report, err := f.store.checkErasure(t.Context(), metrics.PStoreErasureTriggerScan, 0)
+	require.NoError(t, err)
+	assert.Empty(t, report.Unrecoverable)
+	report, err = f.store.checkErasure(t.Context(), metrics.PStoreErasureTriggerScan, 0)
+	require.NoError(t, err)
+	assert.Zero(t, report.ShardsRebuilt, "everything lost has been rebuilt")
+}
+
+// TestErasureCorruptChunkIsRepairedOnRead covers damage that leaves the file
+// in place: the block MAC refuses it, and the read rebuilds it and carries on.
+func TestErasureCorruptChunkIsRepairedOnRead(t *testing.T) {
+	f := newErasureFixture(t)
+	content := f.write("/obj", 4)
+
+	d, err := f.store.Stat("/obj")
+	require.NoError(t, err)
+	h := instanceHashFor(f.store.db, d.Generation)
+	meta, err := f.store.db.GetMetadata(h)
+	require.NoError(t, err)
+	_, chunk, ok := f.store.dataShardPath(h, meta, 1)
+	require.True(t, ok)
+
+	require.NoError(t, f.store.Close())
+	f.store = nil
+	data, err := os.ReadFile(chunk)
+	require.NoError(t, err)
+	data[len(data)/2] ^= 0xff
+	require.NoError(t, os.WriteFile(chunk, data, 0600))
+	f.open()
+
+	got, err := f.store.ReadAll("/obj")
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	repaired, err := os.ReadFile(chunk)
+	require.NoError(t, err)
+	assert.NotEqual(t, data, repaired, "the chunk was rewritten in place")
+}
+
+func TestErasureScrubRebuildsLostShards(t *testing.T) {
+	f := newErasureFixture(t)
+	content := f.write("/obj", 5)
+
+	lost := 0
+	for _, d := range f.dirs {
+		if lost = loseShards(t, d); lost > 0 {
+			break
+		}
+	}
+	f.reopen()
+
+	report, err := f.store.checkErasure(t.Context(), metrics.PStoreErasureTriggerScan, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Objects)
+	assert.Equal(t, 1, report.ObjectsRepaired)
+	assert.Equal(t, lost, report.ShardsRebuilt)
+	assert.Zero(t, report.ShardsRelocated, "a mounted directory gets its shards back in place")
+
+	report, err = f.store.checkErasure(t.Context(), metrics.PStoreErasureTriggerScan, 0)
+	require.NoError(t, err)
+	assert.Zero(t, report.ObjectsRepaired)
+	assert.Zero(t, report.ShardsRebuilt)
+
+	got, err := f.store.ReadAll("/obj")
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+}
+
+// TestErasureRebuildAfterDiskReplacement walks the operator's path: a disk
+// dies, an empty one is mounted in its place, and `pstore rebuild` puts the
+// store back to full strength under the directory's old identity.
+func TestErasureRebuildAfterDiskReplacement(t *testing.T) {
+	f := newErasureFixture(t)
+	content := f.write("/obj", 6)
+	require.NoError(t, f.store.Close())
+	f.store = nil
+
+	replaced := ""
+	for _, d := range f.dirs {
+		if len(shardFiles(t, d)) > 0 {
+			replaced = d
+			break
+		}
+	}
+	require.NoError(t, os.RemoveAll(replaced))
+	require.NoError(t, os.MkdirAll(replaced, 0750))
+
+	ms, err := OpenMaintenance(t.Context(), f.baseDir, true)
+	require.NoError(t, err)
+	report, err := ms.RebuildErasure(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, ms.Close())
+	assert.Len(t, report.AdoptedDirs, 1)
+	assert.Positive(t, report.ShardsRebuilt)
+	assert.Empty(t, report.Unrecoverable)
+	assert.NotEmpty(t, shardFiles(t, replaced), "the lost shards were rebuilt onto the replacement")
+
+	f.open()
+	report, err = f.store.checkErasure(t.Context(), metrics.PStoreErasureTriggerScan, 0)
+	require.NoError(t, err)
+	assert.Zero(t, report.ShardsRebuilt, "the origin finds the rebuilt shards where it expects them")
+	got, err := f.store.ReadAll("/obj")
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	fsck, err := f.store.Fsck(t.Context(), false)
+	require.NoError(t, err)
+	assert.True(t, fsck.Healthy(), "rebuilding leaves the usage counters right: %+v", fsck.UsageDrift)
+}
+
+// TestErasureRebuildRelocatesFromARetiredDirectory covers a disk that is not
+// replaced: its shards are rebuilt into the directories that remain, and the
+// store serves without it.
+func TestErasureRebuildRelocatesFromARetiredDirectory(t *testing.T) {
+	f := newErasureFixture(t)
+	content := f.write("/obj", 10)
+	require.NoError(t, f.store.Close())
+	f.store = nil
+
+	retired := -1
+	for i, d := range f.dirs {
+		if len(shardFiles(t, d)) > 0 {
+			retired = i
+			break
+		}
+	}
+	require.NoError(t, os.RemoveAll(f.dirs[retired]))
+	f.dirs = append(f.dirs[:retired], f.dirs[retired+1:]...)
+
+	ms, err := OpenMaintenance(t.Context(), f.baseDir, true)
+	require.NoError(t, err)
+	report, err := ms.RebuildErasure(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, ms.Close())
+	assert.Positive(t, report.ShardsRelocated)
+	assert.Equal(t, report.ShardsRebuilt, report.ShardsRelocated, "nothing could be rebuilt in place")
+	assert.Empty(t, report.Unrecoverable)
+
+	f.open()
+	got, err := f.store.ReadAll("/obj")
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+	report, err = f.store.checkErasure(t.Context(), metrics.PStoreErasureTriggerScan, 0)
+	require.NoError(t, err)
+	assert.Zero(t, report.ShardsRebuilt)
+}
+
+func TestErasureTooManyLostIsUnrecoverable(t *testing.T) {
+	f := newErasureFixture(t)
+	f.write("/obj", 7)
+
+	emptied := 0
+	for _, d := range f.dirs {
+		if loseShards(t, d) > 0 {
+			emptied++
+		}
+		if emptied == 2 {
+			break
+		}
+	}
+	f.reopen()
+
+	report, err := f.store.checkErasure(t.Context(), metrics.PStoreErasureTriggerScan, 0)
+	require.NoError(t, err)
+	require.Contains(t, report.Unrecoverable, "/obj", "the loss is reported by path")
+	assert.True(t, strings.Contains(report.Unrecoverable["/obj"], errTooManyShardsLost.Error()))
+
+	_, err = f.store.ReadAll("/obj")
+	assert.Error(t, err)
+}
+
+// TestErasureAccounting checks that parity is charged like data: fsck finds
+// no drift, and reclaiming a version frees its parity and its record.
+func TestErasureAccounting(t *testing.T) {
+	f := newErasureFixture(t)
+	f.write("/obj", 8)
+
+	first, err := f.store.Stat("/obj")
+	require.NoError(t, err)
+	superseded := instanceHashFor(f.store.db, first.Generation)
+	f.write("/obj", 9)
+
+	report, err := f.store.Fsck(t.Context(), false)
+	require.NoError(t, err)
+	assert.True(t, report.Healthy(), "parity is counted: %+v", report.UsageDrift)
+
+	batch, _, _, err := f.store.collectGarbage()
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	_, freed, err := f.store.reclaimInstance(batch[0])
+	require.NoError(t, err)
+	assert.Positive(t, freed)
+
+	layout, err := f.store.loadErasureLayout(superseded)
+	require.NoError(t, err)
+	assert.Nil(t, layout, "the layout record goes with the version")
+
+	report, err = f.store.Fsck(t.Context(), false)
+	require.NoError(t, err)
+	assert.True(t, report.Healthy(), "reclaimed parity is uncharged: %+v", report.UsageDrift)
+}
+
+func TestErasureConfigValidation(t *testing.T) {
+	cases := []struct {
+		name  string
+		cfg   ErasureConfig
+		dirs  int
+		dedup bool
+		ok    bool
+	}{
+		{"disabled", ErasureConfig{}, 1, true, true},
+		{"fits", ErasureConfig{DataShards: 2, ParityShards: 1}, 3, false, true},
+		{"no parity", ErasureConfig{DataShards: 2}, 3, false, false},
+		{"too few directories", ErasureConfig{DataShards: 2, ParityShards: 2}, 3, false, false},
+		{"too many shards", ErasureConfig{DataShards: 200, ParityShards: 100}, 400, false, false},
+		{"with dedup", ErasureConfig{DataShards: 2, ParityShards: 1}, 3, true, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.validate(tc.dirs, tc.dedup)
+			if tc.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
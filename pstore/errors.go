@@ -81,4 +81,17 @@ var (
 	ErrConflict = badger.ErrConflict
 	// ErrClosed is returned when a handle is used after Close.
 	ErrClosed = fs.ErrClosed
+	// ErrReplica is returned for a client write to a replication secondary.
+	// A secondary changes only by applying its primary's log (replication.go);
+	// accepting writes of its own would fork it from the primary with no way
+	// back short of a full resynchronization.  origin_serve.statusForBackendError
+	// reports it as 503 Service Unavailable: the write belongs on the primary,
+	// or on this origin once it has been promoted.
+	ErrReplica = errors.New("the store is a replication secondary and does not accept writes")
+	// ErrRetained is returned when a write, rename, or delete would change an
+	// object that a retention rule or legal hold still locks (retention.go).
+	// The error carrying it is a *RetentionError saying until when and why.
+	// origin_serve.statusForBackendError reports it as 403 Forbidden: no
+	// credential lifts the lock, and retrying before it expires will not help.
+	ErrRetained = errors.New("object is locked by a retention policy")
 )
@@ -45,6 +45,10 @@ import (
 )
 
 // FS adapts a Store to afero.Fs.
+//
+// It is how clients reach the store, which is why it -- rather than the Store
+// -- refuses writes to a replication secondary: the secondary applies its
+// primary's log through the Store's own methods (replication.go).
 type FS struct {
 	store *Store
 }
@@ -86,6 +90,9 @@ func (fs *FS) createWithParents(name string) (*WriteHandle, error) {
 
 // createSizedWithParents is createWithParents with an optional size hint.
 func (fs *FS) createSizedWithParents(name string, size int64) (*WriteHandle, error) {
+	if err := fs.store.checkNotReplica(); err != nil {
+		return nil, err
+	}
 	open := func() (*WriteHandle, error) {
 		if size >= 0 {
 			return fs.store.CreateSized(name, size)
@@ -116,11 +123,17 @@ func (fs *FS) createSizedWithParents(name string, size int64) (*WriteHandle, err
 // Mkdir creates a directory.  perm is ignored: pstore has no per-entry
 // permissions, since access control is the origin's authorization layer.
 func (fs *FS) Mkdir(name string, _ os.FileMode) error {
+	if err := fs.store.checkNotReplica(); err != nil {
+		return pathError("mkdir", name, err)
+	}
 	return pathError("mkdir", name, fs.store.Mkdir(name))
 }
 
 // MkdirAll creates a directory and any missing ancestors.
 func (fs *FS) MkdirAll(path string, _ os.FileMode) error {
+	if err := fs.store.checkNotReplica(); err != nil {
+		return pathError("mkdir", path, err)
+	}
 	return pathError("mkdir", path, fs.store.MkdirAll(path))
 }
 
@@ -242,17 +255,27 @@ func generationFromETag(tag string) string {
 
 // Remove deletes an object, or an empty directory.
 func (fs *FS) Remove(name string) error {
+	if err := fs.store.checkNotReplica(); err != nil {
+		return pathError("remove", name, err)
+	}
 	return pathError("remove", name, fs.store.Remove(name))
 }
 
 // RemoveAll deletes a path and everything beneath it.
 func (fs *FS) RemoveAll(path string) error {
+	if err := fs.store.checkNotReplica(); err != nil {
+		return pathError("removeall", path, err)
+	}
 	return pathError("removeall", path, fs.store.RemoveAll(path))
 }
 
 // Rename moves an entry.
 func (fs *FS) Rename(oldname, newname string) error {
-	if err := fs.store.Rename(oldname, newname); err != nil {
+	err := fs.store.checkNotReplica()
+	if err == nil {
+		err = fs.store.Rename(oldname, newname)
+	}
+	if err != nil {
 		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
 	}
 	return nil
@@ -447,6 +447,17 @@ func (s *Store) FsckWith(ctx context.Context, opts FsckOptions) (*FsckReport, er
 		if opts.SkipOrphanScan {
 			continue
 		}
+		// Retained versions have no entry in the index but are not orphans:
+		// they are reachable through their path's history (versions.go).
+		if err := s.scanVersionsBatched(ctx, func(_ string, v *ObjectVersion) error {
+			hash := instanceHashFor(s.db, v.Generation)
+			if strings.HasPrefix(string(hash), shard) {
+				reachable[truncateHash(hash)] = struct{}{}
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
 		if err := s.collectOrphans(ctx, shard, reachable, minAge, report); err != nil {
 			return nil, err
 		}
@@ -844,10 +855,16 @@ func (s *Store) footprintByUsageKey(ctx context.Context) (map[local_cache.Storag
 			return nil, 0, errors.Wrap(err, "failed to total object footprints")
 		}
 		if err == nil {
-			return out, count, nil
+			break
 		}
 		start = last
 	}
+
+	// Parity is charged to the same counters as the data it protects.
+	if err := s.parityFootprint(ctx, out); err != nil {
+		return nil, 0, err
+	}
+	return out, count, nil
 }
 
 // usageDrift reports actual-minus-recorded per usage counter.
@@ -170,6 +170,10 @@ func (s *Store) StartJanitor(ctx context.Context, egrp *errgroup.Group) {
 		// The store publishes a backlog figure at open, so the first
 		// measurement here is due a full interval later.
 		lastDepth := time.Now()
+		// Versions are swept on the first pass: nothing else publishes how
+		// many are retained, and a policy narrowed across a restart should
+		// take effect without waiting out an interval.
+		var lastPrune time.Time
 
 		for {
 			select {
@@ -188,6 +192,13 @@ func (s *Store) StartJanitor(ctx context.Context, egrp *errgroup.Group) {
 				lastDepth = time.Now()
 			}
 
+			if time.Since(lastPrune) >= versionPruneInterval {
+				if _, pErr := s.PruneVersions(ctx); pErr != nil {
+					log.Warnf("pstore version pruning failed: %v", pErr)
+				}
+				lastPrune = time.Now()
+			}
+
 			if stats.HitBatchLimit {
 				wait /= 2
 				if wait < janitorMinInterval {
@@ -239,6 +250,15 @@ type GCStats struct {
 func (s *Store) RunGC(ctx context.Context) (GCStats, error) {
 	var stats GCStats
 
+	// An object-data backup is copying the files of every version its
+	// snapshot names, and freeing one underneath it would leave the backup
+	// referring to blocks it never got.  Everything queued simply waits for
+	// the first pass after the backup finishes.
+	if s.backupHolds.Load() > 0 {
+		log.Debug("Skipping pstore garbage collection while an object-data backup runs")
+		return stats, nil
+	}
+
 	instances, subtrees, saturated, err := s.collectGarbage()
 	if err != nil {
 		return stats, err
@@ -304,6 +324,12 @@ func (s *Store) RunGC(ctx context.Context) (GCStats, error) {
 	}
 	stats.AbandonedAppendsReclaimed = reclaimed
 
+	// Likewise a delete interrupted between dropping an object version and
+	// releasing its deduplicated blocks.
+	if _, err := s.storage.ReclaimOrphanedDedupMaps(); err != nil {
+		log.Warnf("Failed to release pooled blocks of deleted object versions: %v", err)
+	}
+
 	s.observeGC(stats)
 	return stats, nil
 }
@@ -629,6 +655,19 @@ func (s *Store) reclaimInstance(q queuedInstance) (reclaimOutcome, int64, error)
 		}
 	}
 
+	// Parity belongs to the version too, though the block store knows nothing
+	// of it.  Dropped after the data so that a version is never left readable
+	// without it, and also when the metadata is already gone: an encode that
+	// died mid-write leaves parity with no version behind it.
+	parity, err := s.dropErasure(h)
+	if err != nil {
+		return reclaimWithdrawn, freed, errors.Wrapf(err, "failed to remove the parity of object version %s", h)
+	}
+	for storageID, bytes := range parity {
+		s.capacity.release(storageID, bytes)
+		freed += bytes
+	}
+
 	// Wrapped with the hash because the sweep no longer holds one to name in
 	// its log line: a queuedInstance is not a hash, on purpose.
 	return reclaimFreed, freed, errors.Wrapf(s.bdb.Update(func(txn *badger.Txn) error {
@@ -658,26 +697,32 @@ func (s *Store) instanceQueued(h local_cache.InstanceHash) (bool, error) {
 }
 
 // drainSubtree removes up to subtreeBatchSize entries from a detached
-// subtree, queueing each file's object version for reclamation as it goes.
+// subtree, retiring each file's object version as it goes.
 //
 // It reports how many entries it removed and whether the subtree is now
 // empty; when it is, the queue entry is dropped.  A subtree larger than one
 // batch is finished by later passes.
+//
+// A version retained here is recorded as superseded when the drain reaches
+// it rather than when the subtree was unlinked.  Only subtrees too large to
+// remove inline are drained, and the janitor accelerates for them, so the
+// difference is minutes at most.
 func (s *Store) drainSubtree(root string) (int, bool, error) {
 	removed := 0
 	done := false
+	pruned := 0
 
 	err := s.bdb.Update(func(txn *badger.Txn) error {
 		var batch []string
-		var instances []local_cache.InstanceHash
+		var files []subtreeFile
 
 		if wErr := walkSubtree(txn, root, func(entryPath string, d *Dirent) error {
 			if len(batch) >= subtreeBatchSize {
 				return errStopWalk
 			}
 			batch = append(batch, entryPath)
-			if !d.IsDir() && d.Generation != "" {
-				instances = append(instances, instanceHashFor(s.db, d.Generation))
+			if !d.IsDir() {
+				files = append(files, subtreeFile{entryPath, d})
 			}
 			return nil
 		}); wErr != nil && !errors.Is(wErr, errStopWalk) {
@@ -689,10 +734,9 @@ func (s *Store) drainSubtree(root string) (int, bool, error) {
 				return dErr
 			}
 		}
-		for _, h := range instances {
-			if eErr := enqueueInstance(txn, h); eErr != nil {
-				return eErr
-			}
+		var rErr error
+		if pruned, rErr = s.retireSubtreeFiles(txn, files); rErr != nil {
+			return rErr
 		}
 		removed = len(batch)
 
@@ -707,9 +751,31 @@ func (s *Store) drainSubtree(root string) (int, bool, error) {
 	if err != nil {
 		return removed, false, err
 	}
+	observeVersionsPruned(pruned)
 	return removed, done, nil
 }
 
+// subtreeFile is a file entry collected by a subtree walk.
+type subtreeFile struct {
+	path   string
+	dirent *Dirent
+}
+
+// retireSubtreeFiles retires the versions of files removed along with their
+// subtree, reporting how many older versions were pruned as a result.
+func (s *Store) retireSubtreeFiles(txn *badger.Txn, files []subtreeFile) (int, error) {
+	now := time.Now()
+	pruned := 0
+	for _, f := range files {
+		n, err := s.retireVersion(txn, f.path, f.dirent, true, now)
+		if err != nil {
+			return pruned, err
+		}
+		pruned += n
+	}
+	return pruned, nil
+}
+
 // errStopWalk halts a subtree walk once a batch is full.  It never escapes
 // drainSubtree.
 var errStopWalk = errors.New("stop walk")
@@ -755,18 +821,19 @@ func (s *Store) requireDirResolved(txn *badger.Txn, dir string) error {
 }
 
 // deleteSubtreeInline removes an entire subtree within the caller's
-// transaction, queueing each object version for reclamation.
+// transaction, retiring each object version as it goes.
 //
 // It reports false without modifying anything when the subtree is larger than
 // one transaction should carry, leaving the caller to detach and defer.  The
 // bound is deliberately the same batch size the janitor uses: a removal that
 // the janitor would handle in a single pass may as well complete immediately,
-// which keeps the paths free for reuse.
-func deleteSubtreeInline(txn *badger.Txn, db *local_cache.CacheDB, root string) (bool, error) {
+// which keeps the paths free for reuse.  The count is of versions pruned, as
+// for retireVersion.
+func (s *Store) deleteSubtreeInline(txn *badger.Txn, root string) (bool, int, error) {
 	var (
-		paths     []string
-		instances []local_cache.InstanceHash
-		tooBig    bool
+		paths  []string
+		files  []subtreeFile
+		tooBig bool
 	)
 
 	if err := walkSubtree(txn, root, func(entryPath string, d *Dirent) error {
@@ -775,28 +842,27 @@ func deleteSubtreeInline(txn *badger.Txn, db *local_cache.CacheDB, root string)
 			return errStopWalk
 		}
 		paths = append(paths, entryPath)
-		if !d.IsDir() && d.Generation != "" {
-			instances = append(instances, instanceHashFor(db, d.Generation))
+		if !d.IsDir() {
+			files = append(files, subtreeFile{entryPath, d})
 		}
 		return nil
 	}); err != nil && !errors.Is(err, errStopWalk) {
-		return false, err
+		return false, 0, err
 	}
 	if tooBig {
-		return false, nil
+		return false, 0, nil
 	}
 
 	for _, p := range paths {
 		if err := deleteDirent(txn, p); err != nil {
-			return false, err
+			return false, 0, err
 		}
 	}
-	for _, h := range instances {
-		if err := enqueueInstance(txn, h); err != nil {
-			return false, err
-		}
+	pruned, err := s.retireSubtreeFiles(txn, files)
+	if err != nil {
+		return false, 0, err
 	}
-	return true, nil
+	return true, pruned, nil
 }
 
 // checkNotDraining refuses to create an entry inside a subtree that has been
@@ -82,6 +82,9 @@ type Dirent struct {
 // IsDir reports whether the entry is a directory.
 func (d *Dirent) IsDir() bool { return d.Type == EntryDir }
 
+// ETag returns the entry's entity tag, or "" for a directory.
+func (d *Dirent) ETag() string { return etagFor(d.Generation) }
+
 // direntKey returns the index key for the entry at the given absolute path.
 //
 // The path must already be validated and cleaned.  The root has no parent and
@@ -52,6 +52,13 @@
 // PreserveCorruptObjects, and with SkipChecksumBackfill so that the one other
 // write it would make -- recording checksums for an object that has none --
 // does not happen either.
+//
+// The one exception is an erasure-coded object (erasure.go).  The data scan
+// first checks every shard of those against its digest and rebuilds any that
+// are missing or corrupt from the rest of the stripe.  That is not a judgment
+// call: the rebuilt shard is byte for byte the one that was written, which
+// its digest proves before it is put in place, so nothing is lost or guessed.
+// A stripe that has lost more than its parity can cover is only reported.
 
 package pstore
 
@@ -156,6 +163,16 @@ func (s *Store) runDataScanLoop(ctx context.Context, cfg IntegrityConfig, sched
 		// is reported is what *this* pass found.
 		before := checker.GetStats()
 
+		// Rebuild erasure-coded objects first, so the block scan that follows
+		// sees them whole rather than reporting what was about to be fixed.
+		if err := s.scrubErasure(ctx, cfg.DataScanBytesPerSec); err != nil {
+			pass.finish(false)
+			if ctx.Err() == nil {
+				log.Warnf("pstore erasure scrub failed: %v", err)
+			}
+			return
+		}
+
 		// The block store's own scan does the reading and rate limiting;
 		// reimplementing that here would be a second thing to keep correct.
 		if err := checker.RunDataScan(ctx, nil); err != nil {
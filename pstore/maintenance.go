@@ -106,13 +106,25 @@ func OpenMaintenance(ctx context.Context, baseDir string, allowWrites bool) (*St
 		return nil, err
 	}
 
+	// The persisted role, whatever it is: a restore run against a primary
+	// must still be logged for its secondary, and promote and demote are
+	// maintenance operations themselves.
+	repl, _, err := loadReplication(db.DB())
+	if err != nil {
+		storage.Close()
+		db.Close()
+		return nil, err
+	}
+
 	store := &Store{
+		baseDir:   baseDir,
 		db:        db,
 		storage:   storage,
 		bdb:       db.DB(),
 		capacity:  capacity,
 		dirLabels: storageDirLabels(storage),
 		detached:  newDetachedSet(),
+		repl:      repl,
 		readOnly:  !allowWrites,
 		egrp:      egrp,
 	}
@@ -198,6 +198,10 @@ type WriteHandle struct {
 	// reserved is the capacity claimed so far; released or settled at the end.
 	reserved int64
 
+	// coded is set once encodeErasure has recorded a layout, which discard
+	// must then remove along with the parity it names.
+	coded bool
+
 	// tier records which of the three storage tiers materialize actually
 	// used, for pelican_pstore_writes_total.  It is set there rather than
 	// recomputed from the handle's state at Close so that the counter cannot
@@ -215,12 +219,23 @@ type WriteHandle struct {
 	requireAbsent     bool
 	requireGeneration string
 
+	// mtime carries the primary's modification time into a replicated write;
+	// zero means the time of the commit.
+	mtime time.Time
+
 	done bool
 }
 
 // Create begins a new version of the object at name.  The parent directory
 // must exist.  Nothing changes on disk until Close.
 func (s *Store) Create(name string) (*WriteHandle, error) {
+	return s.create(name, -1, "")
+}
+
+// create begins a new version of the object at name, as Create and
+// CreateSized describe.  An empty generation mints a fresh one; a replicated
+// write passes the primary's, so the object keeps its ETag across a failover.
+func (s *Store) create(name string, size int64, generation string) (*WriteHandle, error) {
 	if err := s.checkWritable(); err != nil {
 		return nil, err
 	}
@@ -250,30 +265,43 @@ func (s *Store) Create(name string) (*WriteHandle, error) {
 		if gErr != nil && !errors.Is(gErr, ErrNotExist) {
 			return gErr
 		}
+		// Likewise for a locked object, before any of the new one is
+		// written rather than after all of it.
+		if gErr == nil {
+			return s.checkRetained(txn, "write", cleanPath, d, time.Now())
+		}
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	generation, err := newGeneration()
-	if err != nil {
-		return nil, err
+	if generation == "" {
+		if generation, err = newGeneration(); err != nil {
+			return nil, err
+		}
 	}
 	digests, err := newIngestDigests()
 	if err != nil {
 		return nil, err
 	}
 
-	return &WriteHandle{
+	w := &WriteHandle{
 		store:        s,
 		path:         cleanPath,
 		generation:   generation,
 		instanceHash: instanceHashFor(s.db, generation),
 		buf:          make([]byte, 0, 64<<10),
 		digests:      digests,
-		declaredSize: -1,
-	}, nil
+		declaredSize: size,
+	}
+	if size >= spillThreshold {
+		if sErr := w.spill(); sErr != nil {
+			_ = w.Abort()
+			return nil, sErr
+		}
+	}
+	return w, nil
 }
 
 // CreateSized is Create for a caller that already knows the object's length.
@@ -289,18 +317,7 @@ func (s *Store) Create(name string) (*WriteHandle, error) {
 // bounded store accept an object a little over the spill threshold: an
 // undeclared write has to assume defaultStreamChunkSize.
 func (s *Store) CreateSized(name string, size int64) (*WriteHandle, error) {
-	w, err := s.Create(name)
-	if err != nil {
-		return nil, err
-	}
-	w.declaredSize = size
-	if size >= spillThreshold {
-		if sErr := w.spill(); sErr != nil {
-			_ = w.Abort()
-			return nil, sErr
-		}
-	}
-	return w, nil
+	return s.create(name, size, "")
 }
 
 // RequireAbsent makes Close fail with ErrExist if the object already exists,
@@ -475,13 +492,21 @@ func (w *WriteHandle) Close() error {
 		w.discard()
 		return err
 	}
+	parity, err := w.encodeErasure(meta)
+	if err != nil {
+		w.discard()
+		return err
+	}
 
-	now := time.Now()
+	mtime := w.mtime
+	if mtime.IsZero() {
+		mtime = time.Now()
+	}
 	entry := &Dirent{
 		Type:       EntryFile,
 		Generation: w.generation,
 		Size:       w.written.Load(),
-		MTimeNanos: now.UnixNano(),
+		MTimeNanos: mtime.UnixNano(),
 		Mode:       uint32(defaultFileMode),
 	}
 
@@ -493,7 +518,11 @@ func (w *WriteHandle) Close() error {
 
 	// The write landed; convert the estimate into real per-directory usage.
 	if meta != nil {
-		w.store.capacity.settle(w.reserved, meta.PerDirectoryBytes())
+		actual := meta.PerDirectoryBytes()
+		for id, n := range parity {
+			actual[id] += n
+		}
+		w.store.capacity.settle(w.reserved, actual)
 	} else {
 		w.store.capacity.settle(w.reserved, nil)
 	}
@@ -537,7 +566,10 @@ const maxInstallAttempts = 8
 // consistent across retries: the entry beginMaterialize wrote is only cleared
 // by the transaction that also makes the version reachable.
 func (w *WriteHandle) install(entry *Dirent) error {
-	var lastErr error
+	var (
+		lastErr error
+		pruned  int
+	)
 	for attempt := range maxInstallAttempts {
 		if attempt > 0 {
 			// Counted here rather than where the conflict is detected so that
@@ -553,51 +585,64 @@ func (w *WriteHandle) install(entry *Dirent) error {
 			runtime.Gosched()
 		}
 
-		err := w.store.bdb.Update(func(txn *badger.Txn) error {
-			var superseded local_cache.InstanceHash
+		err := w.store.update(func(txn *badger.Txn) (*ReplicationRecord, error) {
+			var superseded *Dirent
 
 			parent, _ := splitPath(w.path)
 			if pErr := w.store.requireDirResolved(txn, parent); pErr != nil {
-				return pErr
+				return nil, pErr
 			}
 
 			existing, gErr := w.store.resolve(txn, w.path)
 			switch {
 			case gErr == nil:
 				if existing.IsDir() {
-					return ErrIsDir
+					return nil, ErrIsDir
 				}
 				if w.requireAbsent {
-					return ErrExist
+					return nil, ErrExist
 				}
 				if w.requireGeneration != "" && existing.Generation != w.requireGeneration {
-					return ErrPreconditionFailed
+					return nil, ErrPreconditionFailed
 				}
-				if existing.Generation != "" {
-					superseded = instanceHashFor(w.store.db, existing.Generation)
+				if lErr := w.store.checkRetained(txn, "write", w.path, existing, time.Now()); lErr != nil {
+					return nil, lErr
 				}
+				superseded = existing
 			case errors.Is(gErr, ErrNotExist):
 				if w.requireGeneration != "" {
-					return ErrPreconditionFailed
+					return nil, ErrPreconditionFailed
 				}
 			default:
-				return gErr
+				return nil, gErr
 			}
 
 			if pErr := putDirent(txn, w.path, entry); pErr != nil {
-				return pErr
+				return nil, pErr
 			}
 			// The version stops being an orphan and starts being reachable in
 			// the same transaction, so the crash window beginMaterialize
 			// opened closes exactly when it is no longer needed.
 			if dErr := dequeueInstance(txn, w.instanceHash); dErr != nil {
-				return dErr
+				return nil, dErr
 			}
 			// Same transaction as the swap: the old version must never be
-			// unreachable and unqueued at the same time.
-			return enqueueInstance(txn, superseded)
+			// unreachable and unqueued at the same time.  Retaining it under
+			// a versioning policy counts as queued (versions.go).
+			var rErr error
+			if pruned, rErr = w.store.retireVersion(txn, w.path, superseded, false, time.Now()); rErr != nil {
+				return nil, rErr
+			}
+			return &ReplicationRecord{
+				Op:         ReplicatePut,
+				Path:       w.path,
+				Generation: entry.Generation,
+				Size:       entry.Size,
+				MTimeNanos: entry.MTimeNanos,
+			}, nil
 		})
 		if err == nil {
+			observeVersionsPruned(pruned)
 			return nil
 		}
 		// Match on what BadgerDB actually returns rather than on the package
@@ -757,6 +802,11 @@ func (w *WriteHandle) discard() {
 	} else if err := w.store.storage.Delete(w.instanceHash); err != nil {
 		log.Debugf("Nothing to discard for %s: %v", w.path, err)
 	}
+	if w.coded {
+		if _, err := w.store.dropErasure(w.instanceHash); err != nil {
+			log.Warnf("Failed to discard the parity of %s: %v", w.path, err)
+		}
+	}
 	// The pg: entry beginMaterialize wrote is deliberately left in place: it
 	// costs one janitor visit against an instance that is already gone, and
 	// keeping it is what covers a crash between here and now.
@@ -776,14 +826,94 @@ func (w *WriteHandle) discard() {
 //
 // The underlying ObjectReader pins the version for its own lifetime, so the
 // janitor cannot reclaim it mid-read; nothing extra is needed here.
+//
+// A read that fails on an erasure-coded version repairs it and carries on
+// from where it stopped, once; see recoverRead.
 type ReadHandle struct {
 	*local_cache.ObjectReader
 	dirent *Dirent
+
+	store *Store
+	hash  local_cache.InstanceHash
 }
 
 // Dirent returns the entry the handle was opened from.
 func (r *ReadHandle) Dirent() *Dirent { return r.dirent }
 
+// Read reads from the current position.
+func (r *ReadHandle) Read(p []byte) (int, error) {
+	n, err := r.ObjectReader.Read(p)
+	if n == 0 && err != nil && err != io.EOF && r.recoverRead(err) {
+		return r.ObjectReader.Read(p)
+	}
+	return n, err
+}
+
+// ReadAt reads at an offset, leaving the position alone.
+func (r *ReadHandle) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.ObjectReader.ReadAt(p, off)
+	if err != nil && err != io.EOF && r.recoverRead(err) {
+		return r.ObjectReader.ReadAt(p, off)
+	}
+	return n, err
+}
+
+// WriteTo streams the rest of the object to w.
+//
+// Only a failure to read the object is worth a repair; a client that went
+// away mid-transfer is not, so the writer's own errors are told apart.
+func (r *ReadHandle) WriteTo(w io.Writer) (int64, error) {
+	tw := &trackedWriter{w: w}
+	n, err := r.ObjectReader.WriteTo(tw)
+	if err == nil || tw.err != nil || !r.recoverRead(err) {
+		return n, err
+	}
+	// WriteTo advanced the position past what it delivered, so the new
+	// reader picks up right after it.
+	m, err := r.ObjectReader.WriteTo(w)
+	return n + m, err
+}
+
+// recoverRead repairs the version after a failed read and swaps in a fresh
+// reader at the same position, reporting whether the read is worth retrying.
+// The old reader's metadata may name a directory the repair moved a chunk out
+// of, so it cannot simply be retried.
+func (r *ReadHandle) recoverRead(cause error) bool {
+	if r.store == nil || !r.store.repairAfterFailedRead(r.hash, cause) {
+		return false
+	}
+	pos, err := r.ObjectReader.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false
+	}
+	reader, err := r.store.storage.NewObjectReader(r.hash)
+	if err != nil {
+		return false
+	}
+	if _, err := reader.Seek(pos, io.SeekStart); err != nil {
+		_ = reader.Close()
+		return false
+	}
+	old := r.ObjectReader
+	r.ObjectReader = reader
+	_ = old.Close()
+	return true
+}
+
+// trackedWriter remembers the error its writer returned.
+type trackedWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (t *trackedWriter) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+	if err != nil {
+		t.err = err
+	}
+	return n, err
+}
+
 // Close releases the reader, and with it the pin it holds.
 func (r *ReadHandle) Close() error {
 	return r.ObjectReader.Close()
@@ -839,7 +969,7 @@ func (s *Store) OpenRead(name string) (*ReadHandle, error) {
 			return nil, vErr
 		}
 
-		reader, oErr := s.storage.NewObjectReader(hash)
+		reader, oErr := s.openReader(hash)
 		// NewObjectReader took a pin of its own for the reader's lifetime, so
 		// this one has done its job either way.
 		unpin()
@@ -857,7 +987,7 @@ func (s *Store) OpenRead(name string) (*ReadHandle, error) {
 			log.Debugf("Failed to record access time for %s: %v", cleanPath, lErr)
 		}
 
-		return &ReadHandle{ObjectReader: reader, dirent: entry}, nil
+		return &ReadHandle{ObjectReader: reader, dirent: entry, store: s, hash: hash}, nil
 	}
 }
 
@@ -0,0 +1,1327 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+// Replication between two pstore origins.
+//
+// A primary keeps a log of its committed namespace mutations and ships it to
+// one secondary, which applies each record in order and so holds the same
+// namespace, with the same generations and therefore the same ETags, a short
+// while later.  The log and the replication state live under their own prefix:
+//
+//	pr:l:<sequence number, zero-padded>  ->  ReplicationRecord
+//	pr:state                             ->  replicationState
+//
+// Each mutating transaction appends its record to the log in the transaction
+// that makes the change (Store.update), so nothing is committed without being
+// logged or logged without being committed.  Sequence numbers have no gaps:
+// while a log is kept the commits themselves are serialized, and a number is
+// consumed only by a commit that lands.  The transactions are still built
+// concurrently; only the commit waits its turn, and that is the price of a log
+// the secondary can follow by counting.
+//
+// Records describe operations rather than index keys.  The secondary's keys
+// follow from more than the namespace -- its own versioning policy, its own
+// reclamation queue, instance hashes salted per catalog -- so replaying the
+// primary's keys would be wrong where replaying its operations converges on
+// the same namespace.
+//
+// Object content is not in the log either.  A put names a generation, and the
+// shipper reads that generation's bytes when it ships the record, decrypting
+// them under the primary's keys; the secondary encrypts them under its own as
+// it writes.  Shipping the encrypted blocks as they are would need both stores
+// to share a master key, and each derives its own from its origin's issuer
+// key.  A generation that is gone by the time its record ships was superseded
+// by a later record, so the record goes as a no-op and the later one carries
+// the state forward.
+//
+// The secondary persists which primary it follows and the last record it
+// applied.  The shipper asks for that position and resumes after it, and a
+// record at or before it is skipped, so a resend after a lost response is
+// harmless.  A secondary that follows another primary, is new, is ahead of the
+// log, or has fallen behind the start of it -- the log is trimmed to
+// maxReplicationLog records while the secondary is away -- is resynchronized
+// in full: it empties itself, the shipper walks the whole index, and a marker
+// sets its position to where the log stood when the walk began.  The records
+// committed during the walk are then replayed over what it copied.  Each apply
+// is idempotent and they arrive in order, so the secondary converges on the
+// primary's state even where the walk saw a later state than the record.
+//
+// Under synchronous replication a write is not acknowledged until the
+// secondary confirms it, up to a timeout.  A write that times out is
+// acknowledged anyway and the primary stops waiting until the secondary has
+// caught up: an origin that refuses writes whenever its secondary is down is
+// worse than one that can lose the last few seconds of them in a failover.
+//
+// Roles are persisted, so reconfiguring an origin cannot silently turn one
+// into the other.  A secondary becomes a primary only through Promote and a
+// primary becomes a secondary only through Demote, both offline through
+// `pelican-server origin pstore`.  A promoted store takes a new replication
+// ID, so the old primary, demoted and pointed at it, is resynchronized from
+// scratch rather than trusted to have diverged by nothing.
+
+package pstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/vmihailenco/msgpack/v5"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/local_cache"
+	"github.com/pelicanplatform/pelican/metrics"
+)
+
+const (
+	replicationStateKey  = local_cache.PrefixReplication + "state"
+	replicationLogPrefix = local_cache.PrefixReplication + "l:"
+
+	// maxReplicationLog bounds the log a primary keeps for a secondary that
+	// is not taking it.  Past it the oldest records are dropped, and the
+	// secondary is resynchronized in full when it returns: a cost bounded by
+	// the size of the store rather than by how long it was away.
+	maxReplicationLog = 1_000_000
+
+	// replicationBatchSize bounds how many log records one read transaction
+	// of the shipper collects, and how many keys one trim deletes.
+	replicationBatchSize = 256
+
+	// replicationPollInterval is how often an idle shipper asks the secondary
+	// for its position.  A commit wakes the shipper at once, so this only
+	// bounds how stale the lag gauges are while nothing is written.
+	replicationPollInterval = 30 * time.Second
+
+	// replicationRetryMin and replicationRetryMax bound the backoff after a
+	// failed round.
+	replicationRetryMin = time.Second
+	replicationRetryMax = time.Minute
+
+	// defaultReplicationSyncTimeout is how long a synchronous write waits for
+	// the secondary when the configuration does not say.
+	defaultReplicationSyncTimeout = 10 * time.Second
+)
+
+// ReplicationRole is a store's part in replication.
+type ReplicationRole string
+
+const (
+	// RoleStandalone stores replicate nothing and accept every write.
+	RoleStandalone ReplicationRole = ""
+	// RolePrimary stores log their mutations and ship them to a secondary.
+	RolePrimary ReplicationRole = "primary"
+	// RoleSecondary stores apply a primary's log and refuse client writes.
+	RoleSecondary ReplicationRole = "secondary"
+)
+
+// String names the role for messages.
+func (r ReplicationRole) String() string {
+	if r == RoleStandalone {
+		return "standalone"
+	}
+	return string(r)
+}
+
+// ParseReplicationRole reads the configured role; empty, "none", and
+// "standalone" all mean no replication.
+func ParseReplicationRole(value string) (ReplicationRole, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "none", "standalone":
+		return RoleStandalone, nil
+	case string(RolePrimary):
+		return RolePrimary, nil
+	case string(RoleSecondary):
+		return RoleSecondary, nil
+	}
+	return RoleStandalone, errors.Errorf(
+		"unknown replication role %q: expected primary, secondary, or none", value)
+}
+
+// ReplicationOp names the operation a log record replays.
+type ReplicationOp string
+
+const (
+	// ReplicatePut installs a generation at a path.  Restores are logged as
+	// puts too.
+	ReplicatePut ReplicationOp = "put"
+	// ReplicateMkdir creates a directory and any missing ancestors.
+	ReplicateMkdir ReplicationOp = "mkdir"
+	// ReplicateRemove deletes a file or an empty directory.
+	ReplicateRemove ReplicationOp = "remove"
+	// ReplicateRemoveAll deletes a path and everything beneath it.
+	ReplicateRemoveAll ReplicationOp = "removeall"
+	// ReplicateRename moves Path to Target.
+	ReplicateRename ReplicationOp = "rename"
+	// ReplicateRetention replaces the retention settings on Path with
+	// Retention; nil clears them.
+	ReplicateRetention ReplicationOp = "retention"
+	// ReplicateNoop advances the secondary past a record with nothing left to
+	// apply: a put whose generation was reclaimed before it shipped.
+	ReplicateNoop ReplicationOp = "noop"
+	// ReplicateReset begins a full resynchronization.  The secondary empties
+	// itself and adopts the sender as its primary.  Never logged.
+	ReplicateReset ReplicationOp = "reset"
+	// ReplicateSynced ends one: the secondary's position becomes Seq, the log
+	// position the walk began at, and the records up to Through are the ones
+	// committed while it ran.  Never logged.
+	ReplicateSynced ReplicationOp = "synced"
+)
+
+// ReplicationRecord is one entry of a primary's log, and the unit the
+// secondary applies.
+type ReplicationRecord struct {
+	// Seq is the record's position in the log.  Zero marks a record sent by
+	// a resynchronization walk, which does not move the secondary's position.
+	Seq        uint64        `msgpack:"q" json:"seq"`
+	Op         ReplicationOp `msgpack:"o" json:"op"`
+	Path       string        `msgpack:"p,omitempty" json:"path,omitempty"`
+	Target     string        `msgpack:"t,omitempty" json:"target,omitempty"`
+	Generation string        `msgpack:"g,omitempty" json:"generation,omitempty"`
+	Size       int64         `msgpack:"s,omitempty" json:"size,omitempty"`
+	MTimeNanos int64         `msgpack:"m,omitempty" json:"mtime,omitempty"`
+	// Retention is set on ReplicateRetention only.
+	Retention *RetentionSettings `msgpack:"r,omitempty" json:"retention,omitempty"`
+	// LoggedNanos is when the primary committed the change.
+	LoggedNanos int64 `msgpack:"l,omitempty" json:"logged,omitempty"`
+	// Through is set on ReplicateSynced only.
+	Through uint64 `msgpack:"-" json:"through,omitempty"`
+	// Primary names the store the record comes from.  Stamped by the shipper
+	// rather than stored, since a primary's log only ever holds its own.
+	Primary string `msgpack:"-" json:"primary"`
+}
+
+// ReplicaStatus is a secondary's position, as it reports it to its primary.
+type ReplicaStatus struct {
+	// Primary is the replication ID of the store the secondary follows;
+	// empty until its first resynchronization.
+	Primary string `json:"primary"`
+	// Applied is the last log record it applied.
+	Applied uint64 `json:"applied"`
+}
+
+// ReplicationTransport carries records from a primary to its secondary.
+//
+// The store neither knows nor cares how; origin_serve implements it over
+// HTTPS with a token the secondary verifies against the primary's issuer.
+// Both calls report the secondary's position after the call.
+type ReplicationTransport interface {
+	Status(ctx context.Context) (ReplicaStatus, error)
+	// Apply sends one record.  content is the object's bytes for a put and
+	// nil otherwise.
+	Apply(ctx context.Context, rec *ReplicationRecord, content io.Reader) (ReplicaStatus, error)
+}
+
+// ReplicationConfig describes how a primary ships its log.
+type ReplicationConfig struct {
+	Transport ReplicationTransport
+	// Synchronous holds each write's acknowledgement until the secondary
+	// confirms it, or SyncTimeout passes.
+	Synchronous bool
+	// SyncTimeout bounds that wait.  Zero selects
+	// defaultReplicationSyncTimeout.
+	SyncTimeout time.Duration
+}
+
+// replicationState is the persisted part of a store's replication.
+type replicationState struct {
+	Role ReplicationRole `msgpack:"r"`
+	// ID is a primary's own replication ID, or the ID of the primary a
+	// secondary follows.
+	ID string `msgpack:"i,omitempty"`
+	// Applied is the last record a secondary applied.
+	Applied uint64 `msgpack:"a,omitempty"`
+	// ReplayThrough is the last record a secondary's most recent
+	// resynchronization may have copied ahead of (see applyPut).
+	ReplayThrough uint64 `msgpack:"w,omitempty"`
+	// Truncated is the last record a primary dropped from its log, which
+	// keeps its numbering going across a restart with an empty log.
+	Truncated uint64 `msgpack:"t,omitempty"`
+}
+
+// replicator is a store's in-memory replication state.
+type replicator struct {
+	role ReplicationRole
+
+	// Primary side.  commitMu is held from appending a record until its
+	// commit returns, which is what keeps the log in commit order.
+	commitMu sync.Mutex
+	next     uint64
+
+	// progressMu guards the secondary's confirmed position and the
+	// synchronous-mode settings; progress is closed and replaced whenever
+	// the position advances.
+	progressMu  sync.Mutex
+	acked       uint64
+	progress    chan struct{}
+	synchronous bool
+	syncTimeout time.Duration
+	// degraded marks synchronous mode suspended by a timed-out write until
+	// the secondary catches up.
+	degraded bool
+
+	// wake nudges the shipper when a record is committed.
+	wake chan struct{}
+
+	// Secondary side, guarded by applyMu, which also serializes applies.
+	applyMu       sync.Mutex
+	id            string
+	applied       uint64
+	replayThrough uint64
+}
+
+// newReplicationID mints a primary's replication ID.
+func newReplicationID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.Wrap(err, "failed to generate a replication ID")
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// replicationLogKey returns the key of the record at seq.  The number is
+// zero-padded so the keys sort in log order.
+func replicationLogKey(seq uint64) []byte {
+	return []byte(fmt.Sprintf("%s%020d", replicationLogPrefix, seq))
+}
+
+// seqFromLogKey recovers a record's position from its key.
+func seqFromLogKey(key []byte) (uint64, error) {
+	seq, err := strconv.ParseUint(string(key[len(replicationLogPrefix):]), 10, 64)
+	return seq, errors.Wrapf(err, "malformed replication log key %q", key)
+}
+
+// loadReplicationState reads the persisted state; a store that has never
+// replicated is standalone.
+func loadReplicationState(bdb *badger.DB) (replicationState, error) {
+	var st replicationState
+	err := bdb.View(func(txn *badger.Txn) error {
+		item, gErr := txn.Get([]byte(replicationStateKey))
+		if errors.Is(gErr, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if gErr != nil {
+			return gErr
+		}
+		return item.Value(func(val []byte) error {
+			return msgpack.Unmarshal(val, &st)
+		})
+	})
+	return st, errors.Wrap(err, "failed to read the replication state")
+}
+
+// saveReplicationState persists the state within the given transaction.
+func saveReplicationState(txn *badger.Txn, st replicationState) error {
+	val, err := msgpack.Marshal(&st)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode the replication state")
+	}
+	return errors.Wrap(txn.Set([]byte(replicationStateKey), val), "failed to save the replication state")
+}
+
+// logRange returns the first and last sequence numbers in the log; found is
+// false when it is empty.
+func logRange(bdb *badger.DB) (first, last uint64, found bool, err error) {
+	prefix := []byte(replicationLogPrefix)
+	err = bdb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		it.Seek(prefix)
+		if !it.ValidForPrefix(prefix) {
+			it.Close()
+			return nil
+		}
+		var fErr error
+		if first, fErr = seqFromLogKey(it.Item().Key()); fErr != nil {
+			it.Close()
+			return fErr
+		}
+		it.Close()
+
+		opts.Reverse = true
+		rit := txn.NewIterator(opts)
+		defer rit.Close()
+		rit.Seek(append(append([]byte(nil), prefix...), 0xff))
+		if !rit.ValidForPrefix(prefix) {
+			return errors.New("the replication log changed while being read")
+		}
+		var lErr error
+		last, lErr = seqFromLogKey(rit.Item().Key())
+		found = lErr == nil
+		return lErr
+	})
+	return first, last, found, errors.Wrap(err, "failed to read the replication log bounds")
+}
+
+// loadReplication builds the in-memory state from what the catalog holds.
+func loadReplication(bdb *badger.DB) (*replicator, replicationState, error) {
+	st, err := loadReplicationState(bdb)
+	if err != nil {
+		return nil, st, err
+	}
+	r := &replicator{
+		role:          st.Role,
+		id:            st.ID,
+		applied:       st.Applied,
+		replayThrough: st.ReplayThrough,
+		progress:      make(chan struct{}),
+		wake:          make(chan struct{}, 1),
+	}
+	if st.Role == RolePrimary {
+		first, last, found, err := logRange(bdb)
+		if err != nil {
+			return nil, st, err
+		}
+		r.next = st.Truncated + 1
+		r.acked = st.Truncated
+		if found {
+			r.next = max(r.next, last+1)
+			// Nothing in the log is known to have reached the secondary
+			// until it says so.
+			r.acked = first - 1
+		}
+	}
+	return r, st, nil
+}
+
+// openReplication loads the replication state and reconciles it with the
+// configured role.
+//
+// Moving between standalone and primary, or from standalone to secondary,
+// loses nothing and happens here.  Leaving the secondary role, or moving a
+// primary to it, is refused: the first would fork the store from its primary
+// on a configuration change, and the second would empty it at the next
+// resynchronization.  Those are Promote and Demote, and deliberate.
+func openReplication(bdb *badger.DB, want ReplicationRole) (*replicator, error) {
+	r, st, err := loadReplication(bdb)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case st.Role == want:
+		return r, nil
+	case st.Role == RoleSecondary:
+		return nil, errors.Errorf("the store is a replication secondary; it cannot be opened as %s "+
+			"until it is promoted with `pelican-server origin pstore promote`", want)
+	case st.Role == RolePrimary && want == RoleSecondary:
+		return nil, errors.New("the store is a replication primary; demote it with " +
+			"`pelican-server origin pstore demote` to make it a secondary")
+	}
+
+	next := replicationState{Role: want}
+	if want == RolePrimary {
+		if next.ID, err = newReplicationID(); err != nil {
+			return nil, err
+		}
+	}
+	if err := setReplicationState(bdb, next); err != nil {
+		return nil, err
+	}
+	if want != RoleStandalone {
+		log.Infof("The pstore is now a replication %s", want)
+	}
+	r, _, err = loadReplication(bdb)
+	return r, err
+}
+
+// setReplicationState replaces the persisted state and drops the log, which
+// belongs to the role being left.
+func setReplicationState(bdb *badger.DB, st replicationState) error {
+	if err := truncateLogKeys(bdb, ^uint64(0), nil); err != nil {
+		return err
+	}
+	return bdb.Update(func(txn *badger.Txn) error {
+		return saveReplicationState(txn, st)
+	})
+}
+
+// truncateLogKeys deletes the log records up to and including through, a
+// batch per transaction.  each, when set, is called in every batch's
+// transaction with the last record it deleted.
+func truncateLogKeys(bdb *badger.DB, through uint64, each func(txn *badger.Txn, last uint64) error) error {
+	prefix := []byte(replicationLogPrefix)
+	for {
+		deleted := 0
+		err := bdb.Update(func(txn *badger.Txn) error {
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = prefix
+			opts.PrefetchValues = false
+			it := txn.NewIterator(opts)
+			var (
+				keys [][]byte
+				last uint64
+			)
+			for it.Seek(prefix); it.ValidForPrefix(prefix) && len(keys) < replicationBatchSize; it.Next() {
+				seq, sErr := seqFromLogKey(it.Item().Key())
+				if sErr != nil {
+					it.Close()
+					return sErr
+				}
+				if seq > through {
+					break
+				}
+				keys = append(keys, it.Item().KeyCopy(nil))
+				last = seq
+			}
+			it.Close()
+			for _, k := range keys {
+				if dErr := txn.Delete(k); dErr != nil {
+					return dErr
+				}
+			}
+			deleted = len(keys)
+			if deleted > 0 && each != nil {
+				return each(txn, last)
+			}
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to trim the replication log")
+		}
+		if deleted < replicationBatchSize {
+			return nil
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Logging commits
+// ---------------------------------------------------------------------------
+
+// update runs a read-write transaction built by fn and commits it, logging the
+// record fn returns in the same transaction when the store keeps a log.  fn
+// returns a nil record for a transaction that changed nothing worth
+// replicating.
+//
+// It replaces bdb.Update for every mutation a secondary must see.  The
+// transaction is driven by hand so that only the commit, not the work before
+// it, is serialized behind other logged commits.
+func (s *Store) update(fn func(txn *badger.Txn) (*ReplicationRecord, error)) error {
+	txn := s.bdb.NewTransaction(true)
+	defer txn.Discard()
+
+	rec, err := fn(txn)
+	if err != nil {
+		return err
+	}
+	logged := s.beginLogged(rec)
+	if err := logged.append(txn); err != nil {
+		logged.end(false)
+		return err
+	}
+	err = txn.Commit()
+	logged.end(err == nil)
+	return err
+}
+
+// loggedCommit is one commit's place in the replication log.  A nil one --
+// the store keeps no log, or there is nothing to record -- does nothing.
+type loggedCommit struct {
+	r   *replicator
+	rec *ReplicationRecord
+}
+
+// beginLogged reserves the next log position for a commit about to happen.
+// The caller must call end, whatever happens, once the commit has returned.
+func (s *Store) beginLogged(rec *ReplicationRecord) *loggedCommit {
+	if rec == nil || s.repl == nil || s.repl.role != RolePrimary {
+		return nil
+	}
+	s.repl.commitMu.Lock()
+	rec.Seq = s.repl.next
+	rec.LoggedNanos = time.Now().UnixNano()
+	return &loggedCommit{r: s.repl, rec: rec}
+}
+
+// append writes the record into the transaction being committed.
+func (l *loggedCommit) append(txn *badger.Txn) error {
+	if l == nil {
+		return nil
+	}
+	val, err := msgpack.Marshal(l.rec)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode the replication log record")
+	}
+	return errors.Wrapf(txn.Set(replicationLogKey(l.rec.Seq), val),
+		"failed to log %s of %s for replication", l.rec.Op, l.rec.Path)
+}
+
+// end releases the log position, consuming it only if the commit landed, and
+// then -- under synchronous replication -- waits for the secondary.
+func (l *loggedCommit) end(committed bool) {
+	if l == nil {
+		return
+	}
+	if committed {
+		l.r.next++
+	}
+	l.r.commitMu.Unlock()
+	if !committed {
+		return
+	}
+	select {
+	case l.r.wake <- struct{}{}:
+	default:
+	}
+	l.r.waitAcked(l.rec.Seq)
+}
+
+// lastSeq returns the position of the last committed record.
+func (r *replicator) lastSeq() uint64 {
+	r.commitMu.Lock()
+	defer r.commitMu.Unlock()
+	return r.next - 1
+}
+
+// ackedSeq returns the last position the secondary confirmed.
+func (r *replicator) ackedSeq() uint64 {
+	r.progressMu.Lock()
+	defer r.progressMu.Unlock()
+	return r.acked
+}
+
+// waitAcked blocks a synchronous write until the secondary has applied seq or
+// the timeout passes.  See the file comment for why a timeout suspends the
+// waiting rather than failing the write.
+func (r *replicator) waitAcked(seq uint64) {
+	r.progressMu.Lock()
+	if !r.synchronous || r.degraded || r.acked >= seq {
+		r.progressMu.Unlock()
+		return
+	}
+	ch, timeout := r.progress, r.syncTimeout
+	r.progressMu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ch:
+			r.progressMu.Lock()
+			if r.acked >= seq || r.degraded {
+				r.progressMu.Unlock()
+				return
+			}
+			ch = r.progress
+			r.progressMu.Unlock()
+		case <-timer.C:
+			metrics.PStoreReplicationSyncTimeoutsTotal.Inc()
+			r.progressMu.Lock()
+			if !r.degraded {
+				r.degraded = true
+				log.Warnf("The pstore replication secondary did not confirm a write within %s; "+
+					"acknowledging writes without waiting for it until it catches up", timeout)
+			}
+			r.progressMu.Unlock()
+			return
+		}
+	}
+}
+
+// noteAcked records the secondary's confirmed position and releases the
+// synchronous writes it covers.
+func (r *replicator) noteAcked(applied uint64) {
+	last := r.lastSeq()
+	r.progressMu.Lock()
+	defer r.progressMu.Unlock()
+	if applied != r.acked {
+		r.acked = applied
+		close(r.progress)
+		r.progress = make(chan struct{})
+	}
+	if r.degraded && applied >= last {
+		r.degraded = false
+		log.Info("The pstore replication secondary has caught up; synchronous replication resumed")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Shipping (primary)
+// ---------------------------------------------------------------------------
+
+// StartReplication ships the log to the secondary for as long as the context
+// lives.  It does nothing unless the store is a primary.
+func (s *Store) StartReplication(ctx context.Context, egrp *errgroup.Group, cfg ReplicationConfig) {
+	if s.repl.role != RolePrimary || cfg.Transport == nil {
+		return
+	}
+	timeout := cfg.SyncTimeout
+	if timeout <= 0 {
+		timeout = defaultReplicationSyncTimeout
+	}
+	s.repl.progressMu.Lock()
+	s.repl.synchronous = cfg.Synchronous
+	s.repl.syncTimeout = timeout
+	s.repl.progressMu.Unlock()
+
+	egrp.Go(func() error {
+		s.runShipper(ctx, cfg.Transport)
+		return nil
+	})
+}
+
+// runShipper is the shipping loop: a round whenever a commit wakes it or the
+// poll interval passes, and backoff after a round that failed.
+//
+// A failure never ends the loop.  The secondary being down is the situation
+// replication exists for, and the log holds what it misses.
+func (s *Store) runShipper(ctx context.Context, t ReplicationTransport) {
+	retry := replicationRetryMin
+	for {
+		err := s.shipOnce(ctx, t)
+		s.publishReplicationLag()
+		if ctx.Err() != nil {
+			return
+		}
+
+		wait := replicationPollInterval
+		wake := s.repl.wake
+		if err != nil {
+			metrics.PStoreReplicationErrorsTotal.Inc()
+			log.Warnf("pstore replication to the secondary failed; retrying in %s: %v", retry, err)
+			wait = retry
+			retry = min(retry*2, replicationRetryMax)
+			// Every commit would otherwise cut the backoff short.
+			wake = nil
+		} else {
+			retry = replicationRetryMin
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// shipOnce brings the secondary up to the end of the log, resynchronizing it
+// first when it cannot simply resume.
+func (s *Store) shipOnce(ctx context.Context, t ReplicationTransport) error {
+	if err := s.trimLog(); err != nil {
+		return err
+	}
+	status, err := t.Status(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the secondary's position")
+	}
+
+	first, _, found, err := logRange(s.bdb)
+	if err != nil {
+		return err
+	}
+	last := s.repl.lastSeq()
+	if !found {
+		first = last + 1
+	}
+	if status.Primary != s.repl.id || status.Applied+1 < first || status.Applied > last {
+		if status, err = s.fullSync(ctx, t); err != nil {
+			return err
+		}
+	}
+	s.repl.noteAcked(status.Applied)
+
+	for {
+		recs, err := s.readLog(status.Applied, replicationBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(recs) == 0 {
+			break
+		}
+		for i := range recs {
+			rec := &recs[i]
+			if status, err = s.shipRecord(ctx, t, rec); err != nil {
+				return errors.Wrapf(err, "failed to replicate record %d (%s of %s)", rec.Seq, rec.Op, rec.Path)
+			}
+			if status.Applied < rec.Seq {
+				return errors.Errorf("the secondary did not advance past record %d (it reports %d)",
+					rec.Seq, status.Applied)
+			}
+			metrics.PStoreReplicationShippedTotal.Inc()
+			s.repl.noteAcked(status.Applied)
+		}
+		s.publishReplicationLag()
+	}
+	return s.truncateLog(status.Applied)
+}
+
+// shipRecord sends one record, with its content when it is a put.
+func (s *Store) shipRecord(ctx context.Context, t ReplicationTransport, rec *ReplicationRecord) (ReplicaStatus, error) {
+	out := *rec
+	out.Primary = s.repl.id
+	if out.Op != ReplicatePut {
+		return t.Apply(ctx, &out, nil)
+	}
+
+	reader, err := s.openGeneration(out.Generation)
+	if errors.Is(err, ErrNotExist) {
+		// Superseded and reclaimed: a later record carries the path
+		// forward, and this one only has to be counted.
+		out.Op = ReplicateNoop
+		return t.Apply(ctx, &out, nil)
+	}
+	if err != nil {
+		return ReplicaStatus{}, err
+	}
+	defer reader.Close()
+	return t.Apply(ctx, &out, reader)
+}
+
+// openGeneration opens a generation's content wherever it is held: current at
+// some path, retained, or still awaiting the janitor.
+//
+// Records name generations rather than paths because by the time one ships,
+// a rename may have moved the object.  The pin is held while checking that
+// the version still exists, so an unreclaimed version cannot be reclaimed
+// between that check and the open.
+func (s *Store) openGeneration(generation string) (*local_cache.ObjectReader, error) {
+	hash := instanceHashFor(s.db, generation)
+	unpin := s.storage.PinObject(hash)
+	defer unpin()
+
+	meta, err := s.db.GetMetadata(hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the metadata of version %s", generation)
+	}
+	if meta == nil {
+		return nil, ErrNotExist
+	}
+	reader, err := s.storage.NewObjectReader(hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open version %s", generation)
+	}
+	return reader, nil
+}
+
+// fullSync resynchronizes the secondary from scratch and returns its
+// position afterwards.
+func (s *Store) fullSync(ctx context.Context, t ReplicationTransport) (ReplicaStatus, error) {
+	metrics.PStoreReplicationFullSyncsTotal.Inc()
+	from := s.repl.lastSeq()
+	log.Infof("Resynchronizing the pstore replication secondary in full from log position %d", from)
+
+	if _, err := t.Apply(ctx, &ReplicationRecord{Op: ReplicateReset, Primary: s.repl.id}, nil); err != nil {
+		return ReplicaStatus{}, errors.Wrap(err, "failed to reset the secondary")
+	}
+
+	copied := 0
+	err := s.scanIndexBatched(ctx, func(entryPath string, d *Dirent) error {
+		// A subtree awaiting its drain is already gone.
+		if s.detached.contains(entryPath) {
+			return nil
+		}
+		rec := &ReplicationRecord{Op: ReplicateMkdir, Path: entryPath}
+		if !d.IsDir() {
+			rec = &ReplicationRecord{
+				Op:         ReplicatePut,
+				Path:       entryPath,
+				Generation: d.Generation,
+				Size:       d.Size,
+				MTimeNanos: d.MTimeNanos,
+			}
+		}
+		if _, err := s.shipRecord(ctx, t, rec); err != nil {
+			return errors.Wrapf(err, "failed to copy %s", entryPath)
+		}
+		copied++
+		return nil
+	})
+	if err != nil {
+		return ReplicaStatus{}, err
+	}
+
+	// The retention settings follow the index, so that a secondary promoted
+	// in a failover goes on locking what its primary did.
+	settings, err := s.allRetention()
+	if err != nil {
+		return ReplicaStatus{}, err
+	}
+	for i := range settings {
+		rec := &ReplicationRecord{Op: ReplicateRetention, Path: settings[i].Path, Retention: &settings[i]}
+		if _, err := s.shipRecord(ctx, t, rec); err != nil {
+			return ReplicaStatus{}, errors.Wrapf(err, "failed to copy the retention settings of %s", settings[i].Path)
+		}
+	}
+
+	status, err := t.Apply(ctx, &ReplicationRecord{
+		Op:      ReplicateSynced,
+		Seq:     from,
+		Through: s.repl.lastSeq(),
+		Primary: s.repl.id,
+	}, nil)
+	if err != nil {
+		return ReplicaStatus{}, errors.Wrap(err, "failed to complete the resynchronization")
+	}
+	log.Infof("Resynchronized the pstore replication secondary: %d entries copied", copied)
+	return status, nil
+}
+
+// readLog returns up to limit records after the given position.
+func (s *Store) readLog(after uint64, limit int) ([]ReplicationRecord, error) {
+	var recs []ReplicationRecord
+	prefix := []byte(replicationLogPrefix)
+	err := s.bdb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Seek(replicationLogKey(after + 1)); it.ValidForPrefix(prefix) && len(recs) < limit; it.Next() {
+			var rec ReplicationRecord
+			if err := it.Item().Value(func(val []byte) error {
+				return msgpack.Unmarshal(val, &rec)
+			}); err != nil {
+				return errors.Wrapf(err, "failed to decode replication log record %q", it.Item().Key())
+			}
+			recs = append(recs, rec)
+		}
+		return nil
+	})
+	return recs, errors.Wrap(err, "failed to read the replication log")
+}
+
+// truncateLog drops the records the secondary has applied.
+func (s *Store) truncateLog(through uint64) error {
+	id := s.repl.id
+	return truncateLogKeys(s.bdb, through, func(txn *badger.Txn, last uint64) error {
+		return saveReplicationState(txn, replicationState{Role: RolePrimary, ID: id, Truncated: last})
+	})
+}
+
+// trimLog drops the oldest records beyond maxReplicationLog.
+func (s *Store) trimLog() error {
+	first, last, found, err := logRange(s.bdb)
+	if err != nil || !found || last-first+1 <= maxReplicationLog {
+		return err
+	}
+	log.Warnf("The pstore replication log holds %d records the secondary has not applied; "+
+		"dropping the oldest, so the secondary will be resynchronized in full", last-first+1)
+	return s.truncateLog(last - maxReplicationLog)
+}
+
+// publishReplicationLag refreshes the lag gauges from the log and the
+// secondary's last confirmed position.
+func (s *Store) publishReplicationLag() {
+	last, acked := s.repl.lastSeq(), s.repl.ackedSeq()
+	if acked >= last {
+		metrics.PStoreReplicationLagRecords.Set(0)
+		metrics.PStoreReplicationLagSeconds.Set(0)
+		return
+	}
+	metrics.PStoreReplicationLagRecords.Set(float64(last - acked))
+	recs, err := s.readLog(acked, 1)
+	if err != nil || len(recs) == 0 {
+		return
+	}
+	metrics.PStoreReplicationLagSeconds.Set(time.Since(time.Unix(0, recs[0].LoggedNanos)).Seconds())
+}
+
+// ---------------------------------------------------------------------------
+// Applying (secondary)
+// ---------------------------------------------------------------------------
+
+// checkNotReplica refuses a client write to a secondary.
+func (s *Store) checkNotReplica() error {
+	if s.repl != nil && s.repl.role == RoleSecondary {
+		return ErrReplica
+	}
+	return nil
+}
+
+// ReplicationRole reports the store's part in replication.
+func (s *Store) ReplicationRole() ReplicationRole { return s.repl.role }
+
+// ReplicaStatus reports a secondary's position.
+func (s *Store) ReplicaStatus() (ReplicaStatus, error) {
+	if s.repl.role != RoleSecondary {
+		return ReplicaStatus{}, errors.Wrap(ErrNotSupported, "the store is not a replication secondary")
+	}
+	s.repl.applyMu.Lock()
+	defer s.repl.applyMu.Unlock()
+	return s.repl.status(), nil
+}
+
+// status reports the position; applyMu must be held.
+func (r *replicator) status() ReplicaStatus {
+	return ReplicaStatus{Primary: r.id, Applied: r.applied}
+}
+
+// ApplyReplicated applies one record from the primary and returns the
+// secondary's position afterwards.  content carries a put's bytes.
+//
+// Records are applied one at a time and must arrive in order: one already
+// applied is skipped, and one past a gap is refused with
+// ErrPreconditionFailed, as is one from a primary this store does not follow.
+// The shipper answers either refusal by asking for the position again.
+func (s *Store) ApplyReplicated(ctx context.Context, rec *ReplicationRecord, content io.Reader) (ReplicaStatus, error) {
+	if err := s.checkWritable(); err != nil {
+		return ReplicaStatus{}, err
+	}
+	if s.repl.role != RoleSecondary {
+		return ReplicaStatus{}, errors.Wrap(ErrNotSupported, "the store is not a replication secondary")
+	}
+	r := s.repl
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+
+	switch {
+	case rec.Op == ReplicateReset:
+		if rec.Primary == "" {
+			return r.status(), errors.Wrap(ErrInvalidPath, "a reset must name its primary")
+		}
+		if err := s.resetReplica(ctx); err != nil {
+			return r.status(), errors.Wrap(err, "failed to empty the secondary for resynchronization")
+		}
+		return s.recordApplied(rec.Primary, 0, 0, 0)
+	case rec.Primary != r.id:
+		return r.status(), errors.Wrapf(ErrPreconditionFailed,
+			"the record comes from primary %q, but this secondary follows %q", rec.Primary, r.id)
+	case rec.Op == ReplicateSynced:
+		return s.recordApplied(r.id, rec.Seq, rec.Through, rec.LoggedNanos)
+	case rec.Seq != 0 && rec.Seq <= r.applied:
+		// A resend after a lost response.
+		return r.status(), nil
+	case rec.Seq != 0 && rec.Seq != r.applied+1:
+		return r.status(), errors.Wrapf(ErrPreconditionFailed,
+			"record %d does not follow the last one applied, %d", rec.Seq, r.applied)
+	}
+
+	if err := s.applyRecord(rec, content); err != nil {
+		return r.status(), errors.Wrapf(err, "failed to apply %s of %s", rec.Op, rec.Path)
+	}
+	if rec.Seq == 0 {
+		return r.status(), nil
+	}
+	return s.recordApplied(r.id, rec.Seq, r.replayThrough, rec.LoggedNanos)
+}
+
+// recordApplied persists and adopts a secondary's new position; applyMu must
+// be held.
+//
+// It is written after the change it records rather than with it, so a crash
+// between the two replays one record, which applying idempotently absorbs.
+func (s *Store) recordApplied(primary string, applied, replayThrough uint64, loggedNanos int64) (ReplicaStatus, error) {
+	r := s.repl
+	st := replicationState{
+		Role:          RoleSecondary,
+		ID:            primary,
+		Applied:       applied,
+		ReplayThrough: replayThrough,
+	}
+	if err := s.bdb.Update(func(txn *badger.Txn) error {
+		return saveReplicationState(txn, st)
+	}); err != nil {
+		return r.status(), err
+	}
+	r.id, r.applied, r.replayThrough = primary, applied, replayThrough
+	if loggedNanos > 0 {
+		metrics.PStoreReplicationLastAppliedTimestamp.Set(float64(loggedNanos) / float64(time.Second))
+	}
+	return r.status(), nil
+}
+
+// applyRecord replays one operation.  Each one tolerates the state a
+// resynchronization walk may have left ahead of it.
+func (s *Store) applyRecord(rec *ReplicationRecord, content io.Reader) error {
+	switch rec.Op {
+	case ReplicateNoop:
+		return nil
+	case ReplicatePut:
+		return s.applyPut(rec, content)
+	case ReplicateMkdir:
+		return s.MkdirAll(rec.Path)
+	case ReplicateRemove, ReplicateRemoveAll:
+		// Recursive either way: the primary's directory was empty when it
+		// removed it, and anything under it here was copied ahead.
+		return s.RemoveAll(rec.Path)
+	case ReplicateRename:
+		return s.applyRename(rec)
+	case ReplicateRetention:
+		return s.applyRetention(rec)
+	}
+	return errors.Wrapf(ErrNotSupported, "unknown replication operation %q", rec.Op)
+}
+
+// applyRename replays a rename.
+func (s *Store) applyRename(rec *ReplicationRecord) error {
+	if _, err := s.Stat(rec.Path); errors.Is(err, ErrNotExist) {
+		// Already moved by the time a resynchronization copied it.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	parent, _ := splitPath(rec.Target)
+	if err := s.MkdirAll(parent); err != nil {
+		return err
+	}
+	err := s.Rename(rec.Path, rec.Target)
+	if errors.Is(err, ErrIsDir) || errors.Is(err, ErrNotDir) {
+		// The destination holds what a resynchronization copied ahead.  The
+		// primary's rename replaced at most a file, so clearing the way
+		// reproduces it.
+		if rErr := s.RemoveAll(rec.Target); rErr != nil {
+			return rErr
+		}
+		err = s.Rename(rec.Path, rec.Target)
+	}
+	return err
+}
+
+// applyPut installs the record's generation at its path.
+//
+// Three cases, cheapest first: the path already holds the generation (a
+// replay); this store retains it as a prior version, in which case it is
+// restored in place and content goes unread; or it is written from content
+// under the primary's generation and modification time.
+func (s *Store) applyPut(rec *ReplicationRecord, content io.Reader) error {
+	parent, _ := splitPath(rec.Path)
+	if err := s.MkdirAll(parent); err != nil {
+		return err
+	}
+
+	var current, retained, isDir bool
+	if err := s.bdb.View(func(txn *badger.Txn) error {
+		d, gErr := s.resolve(txn, rec.Path)
+		switch {
+		case gErr == nil && d.IsDir():
+			isDir = true
+		case gErr == nil:
+			current = d.Generation == rec.Generation
+		case !errors.Is(gErr, ErrNotExist):
+			return gErr
+		}
+		versions, _, lErr := listVersions(txn, rec.Path)
+		if lErr != nil {
+			return lErr
+		}
+		for _, v := range versions {
+			if v.Generation == rec.Generation {
+				retained = true
+				break
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if current {
+		return nil
+	}
+	if isDir {
+		// Copied ahead by a resynchronization; the primary put a file here,
+		// so whatever became of the directory came later.
+		if err := s.RemoveAll(rec.Path); err != nil {
+			return err
+		}
+	}
+	mtime := time.Unix(0, rec.MTimeNanos)
+	if retained {
+		_, err := s.restoreVersion(rec.Path, rec.Generation, mtime)
+		return err
+	}
+	if content == nil {
+		return errors.Errorf("no content was sent for version %s", rec.Generation)
+	}
+
+	live, err := s.clearStaleGeneration(rec.Generation)
+	if err != nil {
+		return err
+	}
+	if live {
+		// The generation is current at another path, which a
+		// resynchronization walk that raced a rename can leave; the rename's
+		// own record follows and puts it right.  Outside that window it
+		// would mean a stray instance, which fsck deals with.
+		if rec.Seq == 0 || rec.Seq <= s.repl.replayThrough {
+			log.Debugf("Skipping replicated put of %s: version %s is already held elsewhere",
+				rec.Path, rec.Generation)
+			return nil
+		}
+		return errors.Errorf("version %s is already stored but not at %s; run fsck on the secondary",
+			rec.Generation, rec.Path)
+	}
+
+	w, err := s.create(rec.Path, rec.Size, rec.Generation)
+	if err != nil {
+		return err
+	}
+	w.mtime = mtime
+	n, err := io.Copy(w, content)
+	if err != nil {
+		_ = w.Abort()
+		return errors.Wrap(err, "failed to receive the object's content")
+	}
+	if n != rec.Size {
+		_ = w.Abort()
+		return errors.Errorf("received %d bytes of a %d-byte object", n, rec.Size)
+	}
+	return w.Close()
+}
+
+// clearStaleGeneration makes way for writing a generation this store may
+// still hold from before: superseded or deleted, and queued for the janitor.
+//
+// Writing over it would build the new copy on the queued instance, which the
+// janitor would then delete from under the index.  So a queued one is
+// reclaimed first; a reader still holding it fails the apply, and the primary
+// retries.  live reports an instance that exists but is not queued, which is
+// one some path still holds.
+func (s *Store) clearStaleGeneration(generation string) (live bool, err error) {
+	hash := instanceHashFor(s.db, generation)
+	meta, err := s.db.GetMetadata(hash)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to read the metadata of version %s", generation)
+	}
+	if meta == nil {
+		return false, nil
+	}
+	outcome, _, err := s.reclaimInstance(newQueuedInstance(string(hash)))
+	if err != nil {
+		return false, err
+	}
+	switch outcome {
+	case reclaimFreed:
+		return false, nil
+	case reclaimPinned:
+		return false, errors.Wrapf(ErrConflict, "an earlier copy of version %s is still in use", generation)
+	}
+	return true, nil
+}
+
+// resetReplica empties a secondary for a resynchronization.
+func (s *Store) resetReplica(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entries, _, err := s.List("/", "", replicationBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return s.clearAllRetention()
+		}
+		for _, e := range entries {
+			if err := s.RemoveAll(joinPath("/", e.Name)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Failover
+// ---------------------------------------------------------------------------
+
+// ReplicationInfo describes a store's replication for an operator.
+type ReplicationInfo struct {
+	Role ReplicationRole
+	// ID is a primary's own replication ID, or the one a secondary follows.
+	ID string
+	// Applied is the last record a secondary applied.
+	Applied uint64
+	// LogFirst and LogLast bound a primary's retained log; LogFirst exceeds
+	// LogLast when it is empty.
+	LogFirst, LogLast uint64
+}
+
+// ReplicationInfo reports the store's replication state.
+func (s *Store) ReplicationInfo() (ReplicationInfo, error) {
+	info := ReplicationInfo{Role: s.repl.role}
+	switch s.repl.role {
+	case RoleSecondary:
+		s.repl.applyMu.Lock()
+		info.ID, info.Applied = s.repl.id, s.repl.applied
+		s.repl.applyMu.Unlock()
+	case RolePrimary:
+		info.ID = s.repl.id
+		info.LogLast = s.repl.lastSeq()
+		first, _, found, err := logRange(s.bdb)
+		if err != nil {
+			return info, err
+		}
+		info.LogFirst = info.LogLast + 1
+		if found {
+			info.LogFirst = first
+		}
+	}
+	return info, nil
+}
+
+// Promote makes a secondary a primary, for failover, and returns its new
+// replication ID.
+//
+// Everything the secondary applied is kept, and it accepts writes from then on.
+// Its old primary must not come back as a primary: demote it, and it is
+// resynchronized from this store in full.
+func (s *Store) Promote() (string, error) {
+	if err := s.checkWritable(); err != nil {
+		return "", err
+	}
+	if s.repl.role != RoleSecondary {
+		return "", errors.Errorf("the store is a replication %s, not a secondary", s.repl.role)
+	}
+	id, err := newReplicationID()
+	if err != nil {
+		return "", err
+	}
+	if err := setReplicationState(s.bdb, replicationState{Role: RolePrimary, ID: id}); err != nil {
+		return "", err
+	}
+	r, _, err := loadReplication(s.bdb)
+	if err != nil {
+		return "", err
+	}
+	s.repl = r
+	return id, nil
+}
+
+// Demote makes a primary or standalone store a secondary.  Its contents are
+// replaced with the primary's when that primary first ships to it.
+func (s *Store) Demote() error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	if s.repl.role == RoleSecondary {
+		return errors.New("the store is already a replication secondary")
+	}
+	if err := setReplicationState(s.bdb, replicationState{Role: RoleSecondary}); err != nil {
+		return err
+	}
+	r, _, err := loadReplication(s.bdb)
+	if err != nil {
+		return err
+	}
+	s.repl = r
+	return nil
+}
@@ -0,0 +1,292 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package pstore
+
+import (
+	"context"
+	"io"
+	"os"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/local_cache"
+)
+
+// directTransport hands records straight to a secondary in the same process,
+// which is everything the HTTPS transport does less the wire.
+type directTransport struct {
+	secondary *Store
+	// down simulates a partition: every call fails while it is set.
+	down atomic.Bool
+}
+
+func (d *directTransport) Status(context.Context) (ReplicaStatus, error) {
+	if d.down.Load() {
+		return ReplicaStatus{}, errors.New("secondary unreachable")
+	}
+	return d.secondary.ReplicaStatus()
+}
+
+func (d *directTransport) Apply(ctx context.Context, rec *ReplicationRecord, content io.Reader) (ReplicaStatus, error) {
+	if d.down.Load() {
+		return ReplicaStatus{}, errors.New("secondary unreachable")
+	}
+	return d.secondary.ApplyReplicated(ctx, rec, content)
+}
+
+// openReplicationTestStore opens the store in dir with the given role.
+func openReplicationTestStore(t *testing.T, dir string, role ReplicationRole) (*Store, error) {
+	t.Helper()
+	local_cache.InitIssuerKeyForTests(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	t.Cleanup(cancel)
+	egrp, _ := errgroup.WithContext(ctx)
+	return Open(ctx, egrp, Config{BaseDir: dir, Replication: role})
+}
+
+// newReplicatedPair opens a primary and a secondary joined by a
+// directTransport.
+func newReplicatedPair(t *testing.T) (primary, secondary *Store, transport *directTransport) {
+	t.Helper()
+	primary, err := openReplicationTestStore(t, t.TempDir(), RolePrimary)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, primary.Close()) })
+	secondary, err = openReplicationTestStore(t, t.TempDir(), RoleSecondary)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, secondary.Close()) })
+	return primary, secondary, &directTransport{secondary: secondary}
+}
+
+// snapshot lists every entry in a store with its ETag and content, so two
+// stores can be compared whole.
+func snapshot(t *testing.T, s *Store) map[string]string {
+	t.Helper()
+	out := map[string]string{}
+	var walk func(dir string)
+	walk = func(dir string) {
+		entries, _, err := s.List(dir, "", 0)
+		require.NoError(t, err)
+		for _, e := range entries {
+			p := joinPath(dir, e.Name)
+			if e.Dirent.IsDir() {
+				out[p+"/"] = ""
+				walk(p)
+				continue
+			}
+			out[p] = e.Dirent.ETag() + " " + readObject(t, s, p)
+		}
+	}
+	walk("/")
+	return out
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// A secondary that has never been shipped to is resynchronized in full, and
+// from then on follows the log: creates, overwrites, renames, and removes all
+// arrive with the primary's ETags, and the applied records leave the log.
+func TestReplicationShipsMutations(t *testing.T) {
+	primary, secondary, transport := newReplicatedPair(t)
+	ctx := t.Context()
+
+	require.NoError(t, primary.MkdirAll("/a/b"))
+	writeObject(t, primary, "/a/b/before", []byte("written before the secondary existed"))
+	require.NoError(t, primary.shipOnce(ctx, transport))
+	assert.Equal(t, snapshot(t, primary), snapshot(t, secondary))
+
+	writeObject(t, primary, "/a/one", []byte("one"))
+	writeObject(t, primary, "/a/two", []byte("two"))
+	writeObject(t, primary, "/a/one", []byte("one, again"))
+	require.NoError(t, primary.Rename("/a/two", "/a/b/moved"))
+	require.NoError(t, primary.Remove("/a/b/before"))
+	require.NoError(t, primary.MkdirAll("/c/d"))
+	writeObject(t, primary, "/c/d/gone", []byte("soon gone"))
+	require.NoError(t, primary.RemoveAll("/c"))
+	require.NoError(t, primary.shipOnce(ctx, transport))
+
+	want := snapshot(t, primary)
+	assert.Equal(t, []string{"/a/", "/a/b/", "/a/b/moved", "/a/one"}, sortedKeys(want))
+	assert.Equal(t, want, snapshot(t, secondary))
+
+	info, err := primary.ReplicationInfo()
+	require.NoError(t, err)
+	assert.Greater(t, info.LogFirst, info.LogLast, "records the secondary applied are trimmed")
+	status, err := secondary.ReplicaStatus()
+	require.NoError(t, err)
+	assert.Equal(t, info.LogLast, status.Applied)
+}
+
+// Records committed while the secondary is unreachable wait in the log and
+// ship once it is back, without a full resynchronization.
+func TestReplicationCatchesUpAfterPartition(t *testing.T) {
+	primary, secondary, transport := newReplicatedPair(t)
+	ctx := t.Context()
+
+	require.NoError(t, primary.shipOnce(ctx, transport))
+	before, err := secondary.ReplicaStatus()
+	require.NoError(t, err)
+
+	transport.down.Store(true)
+	writeObject(t, primary, "/during", []byte("written during the partition"))
+	require.NoError(t, primary.Mkdir("/dir"))
+	assert.Error(t, primary.shipOnce(ctx, transport))
+	assert.Equal(t, uint64(2), primary.repl.lastSeq()-primary.repl.ackedSeq())
+
+	transport.down.Store(false)
+	require.NoError(t, primary.shipOnce(ctx, transport))
+	assert.Equal(t, snapshot(t, primary), snapshot(t, secondary))
+
+	after, err := secondary.ReplicaStatus()
+	require.NoError(t, err)
+	assert.Equal(t, before.Primary, after.Primary)
+	assert.Equal(t, before.Applied+2, after.Applied, "caught up record by record, not resynchronized")
+}
+
+// A secondary applies records in order only: a replay is absorbed, a gap or a
+// record from a primary it does not follow is refused.
+func TestReplicaAppliesInOrder(t *testing.T) {
+	primary, secondary, transport := newReplicatedPair(t)
+	ctx := t.Context()
+
+	writeObject(t, primary, "/x", []byte("x"))
+	require.NoError(t, primary.shipOnce(ctx, transport))
+	status, err := secondary.ReplicaStatus()
+	require.NoError(t, err)
+
+	replay := &ReplicationRecord{Seq: status.Applied, Op: ReplicateMkdir, Path: "/replayed", Primary: status.Primary}
+	_, err = secondary.ApplyReplicated(ctx, replay, nil)
+	require.NoError(t, err)
+	_, err = secondary.Stat("/replayed")
+	assert.ErrorIs(t, err, ErrNotExist, "a record already applied is skipped")
+
+	gap := &ReplicationRecord{Seq: status.Applied + 2, Op: ReplicateMkdir, Path: "/gap", Primary: status.Primary}
+	_, err = secondary.ApplyReplicated(ctx, gap, nil)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+	stranger := &ReplicationRecord{Seq: status.Applied + 1, Op: ReplicateMkdir, Path: "/x2", Primary: "someone-else"}
+	_, err = secondary.ApplyReplicated(ctx, stranger, nil)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+}
+
+// Clients cannot write to a secondary; it changes only through its primary.
+func TestReplicaRefusesClientWrites(t *testing.T) {
+	_, secondary, _ := newReplicatedPair(t)
+	fs := NewFS(secondary)
+
+	_, err := fs.Create("/new")
+	assert.ErrorIs(t, err, ErrReplica)
+	assert.ErrorIs(t, fs.MkdirAll("/dir", 0755), ErrReplica)
+	assert.ErrorIs(t, fs.Remove("/anything"), ErrReplica)
+	_, err = secondary.RestoreVersion("/anything", "v")
+	assert.ErrorIs(t, err, ErrReplica)
+}
+
+// Under synchronous replication a write returns only once the secondary holds
+// it; a secondary that stops answering delays one write by the timeout and
+// then no more, until it has caught up.
+func TestSynchronousReplication(t *testing.T) {
+	primary, secondary, transport := newReplicatedPair(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	egrp, _ := errgroup.WithContext(ctx)
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, egrp.Wait())
+	})
+	primary.StartReplication(ctx, egrp, ReplicationConfig{
+		Transport:   transport,
+		Synchronous: true,
+		SyncTimeout: 300 * time.Millisecond,
+	})
+
+	writeObject(t, primary, "/synced", []byte("confirmed before Close returned"))
+	assert.Equal(t, "confirmed before Close returned", readObject(t, secondary, "/synced"))
+
+	transport.down.Store(true)
+	start := time.Now()
+	writeObject(t, primary, "/late1", []byte("times out"))
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+	start = time.Now()
+	writeObject(t, primary, "/late2", []byte("does not wait"))
+	assert.Less(t, time.Since(start), 300*time.Millisecond, "waiting is suspended until the secondary catches up")
+}
+
+// A store remembers its role.  A secondary cannot be reopened as anything
+// else until it is promoted, and the promoted store, under a new replication
+// ID, resynchronizes its demoted former primary in full.
+func TestReplicationFailover(t *testing.T) {
+	ctx := t.Context()
+	primaryDir, secondaryDir := t.TempDir(), t.TempDir()
+
+	primary, err := openReplicationTestStore(t, primaryDir, RolePrimary)
+	require.NoError(t, err)
+	secondary, err := openReplicationTestStore(t, secondaryDir, RoleSecondary)
+	require.NoError(t, err)
+	writeObject(t, primary, "/shipped", []byte("reached the secondary"))
+	require.NoError(t, primary.shipOnce(ctx, &directTransport{secondary: secondary}))
+	writeObject(t, primary, "/lost", []byte("never shipped"))
+	oldID := primary.repl.id
+	require.NoError(t, primary.Close())
+	require.NoError(t, secondary.Close())
+
+	_, err = openReplicationTestStore(t, secondaryDir, RolePrimary)
+	require.Error(t, err, "a secondary is not promoted by configuration")
+
+	maint, err := OpenMaintenance(ctx, secondaryDir, true)
+	require.NoError(t, err)
+	newID, err := maint.Promote()
+	require.NoError(t, err)
+	require.NoError(t, maint.Close())
+	assert.NotEqual(t, oldID, newID)
+
+	maint, err = OpenMaintenance(ctx, primaryDir, true)
+	require.NoError(t, err)
+	require.NoError(t, maint.Demote())
+	require.NoError(t, maint.Close())
+
+	promoted, err := openReplicationTestStore(t, secondaryDir, RolePrimary)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, promoted.Close()) }()
+	demoted, err := openReplicationTestStore(t, primaryDir, RoleSecondary)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, demoted.Close()) }()
+
+	writeObject(t, promoted, "/after", []byte("written after the failover"))
+	require.NoError(t, promoted.shipOnce(ctx, &directTransport{secondary: demoted}))
+
+	want := snapshot(t, promoted)
+	assert.Equal(t, []string{"/after", "/shipped"}, sortedKeys(want))
+	assert.Equal(t, want, snapshot(t, demoted), "the old primary's unshipped write is discarded")
+	_, err = demoted.Stat("/lost")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,560 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+// Object lock: retention rules and legal holds.
+//
+// Some data must stay exactly as it was written for a while, whatever anyone
+// with a write token tries to do to it.  A retention rule on a path locks
+// every object at or below it for a period measured from when the object was
+// written; a legal hold locks them until it is released.  A locked object
+// cannot be overwritten, renamed, restored over, or deleted, and neither can a
+// directory holding one be removed or moved.  New objects can still be written
+// under a locked path -- that is the "write once" of WORM -- and each is
+// locked from the moment it lands.
+//
+// Settings live under their own prefix, one record per path:
+//
+//	pl:<path>  ->  RetentionSettings
+//
+// They are independent of the index: a rule may be set on a directory that
+// does not exist yet, and survives the directory being emptied and removed.
+// Every rule covering an object applies, so the lock lasts as long as the
+// longest of them and a subdirectory cannot weaken its parent.
+//
+// A rule is in one of two modes.  A governance rule can be shortened or
+// removed by an administrator, which is how a mistaken rule is undone.  A
+// compliance rule cannot: it can be lengthened, or set again in compliance
+// mode, and nothing else -- not an administrator, not the origin's owner.
+// That is the whole difference, and the reason compliance mode exists.
+//
+// The checks run inside the transaction making the change, reading the
+// settings of the path and its ancestors, so a rule set concurrently either
+// commits first and is seen, or conflicts with the change and aborts it.  An
+// object's write time is its modification time, which nothing but a write can
+// change (Chtimes is refused), so the period cannot be reset from outside.
+//
+// A replication secondary does not enforce the settings it holds.  It changes
+// only by applying its primary's log, and the primary has already enforced
+// them; refusing a record would fork the two.
+
+package pstore
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/pkg/errors"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/pelicanplatform/pelican/local_cache"
+	"github.com/pelicanplatform/pelican/metrics"
+)
+
+// RetentionMode says who may relax a retention rule.
+type RetentionMode string
+
+const (
+	// RetentionGovernance rules can be shortened or removed by an
+	// administrator.
+	RetentionGovernance RetentionMode = "governance"
+	// RetentionCompliance rules can only be lengthened.
+	RetentionCompliance RetentionMode = "compliance"
+)
+
+// ParseRetentionMode parses a retention mode as it is written in the
+// administrative API and on the command line.
+func ParseRetentionMode(value string) (RetentionMode, error) {
+	switch mode := RetentionMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case RetentionGovernance, RetentionCompliance:
+		return mode, nil
+	}
+	return "", errors.Wrapf(ErrInvalidPath,
+		"unknown retention mode %q: expected governance or compliance", value)
+}
+
+// RetentionRule locks each object at or below a path for Period after it was
+// written.
+type RetentionRule struct {
+	Mode   RetentionMode `msgpack:"m" json:"mode"`
+	Period time.Duration `msgpack:"p" json:"period"`
+	// SetNanos is when the rule was last set.
+	SetNanos int64 `msgpack:"s,omitempty" json:"set,omitempty"`
+}
+
+// LegalHold locks every object at or below a path until it is released.
+type LegalHold struct {
+	Reason string `msgpack:"r,omitempty" json:"reason,omitempty"`
+	// SetNanos is when the hold was placed.
+	SetNanos int64 `msgpack:"s,omitempty" json:"set,omitempty"`
+}
+
+// RetentionSettings is what is set on one path.
+type RetentionSettings struct {
+	Path string         `msgpack:"-" json:"path"`
+	Rule *RetentionRule `msgpack:"r,omitempty" json:"rule,omitempty"`
+	Hold *LegalHold     `msgpack:"h,omitempty" json:"hold,omitempty"`
+}
+
+func (rs *RetentionSettings) empty() bool { return rs.Rule == nil && rs.Hold == nil }
+
+// ObjectLock is what keeps one object from changing.
+type ObjectLock struct {
+	// Locked reports whether the object is locked now.
+	Locked bool
+	// Mode is the strongest mode of the rules still in force; empty when
+	// none is.
+	Mode RetentionMode
+	// RetainUntil is when the last of the rules covering the object expires;
+	// zero when none does.
+	RetainUntil time.Time
+	// RulePath is where the rule that expires last is set.
+	RulePath string
+	// HoldPath is where the nearest legal hold covering the object is
+	// placed, and HoldReason why; empty when it is not held.
+	HoldPath   string
+	HoldReason string
+}
+
+// RetentionError reports a change refused because an object is locked.  It
+// matches ErrRetained.
+type RetentionError struct {
+	// Path is the locked object, which for a directory is one of the objects
+	// beneath it.
+	Path string
+	Lock ObjectLock
+}
+
+func (e *RetentionError) Error() string {
+	return fmt.Sprintf("%s %s", e.Path, e.Reason())
+}
+
+// Reason says why the object is locked without naming it, for a client that
+// asked about a path of its own.
+func (e *RetentionError) Reason() string {
+	if e.Lock.HoldPath != "" {
+		return "is under a legal hold and cannot be modified or deleted until it is released"
+	}
+	return fmt.Sprintf("is retained in %s mode and cannot be modified or deleted until %s",
+		e.Lock.Mode, e.Lock.RetainUntil.UTC().Format(time.RFC3339))
+}
+
+// Is lets errors.Is(err, ErrRetained) recognize the error.
+func (e *RetentionError) Is(target error) bool { return target == ErrRetained }
+
+// RetentionStatus describes the retention settings around a path.
+type RetentionStatus struct {
+	// Covering lists the settings on the path and its ancestors, nearest
+	// first: everything that locks an object there.
+	Covering []RetentionSettings
+	// Below lists the settings on paths beneath it, in key order.
+	Below []RetentionSettings
+	// Lock is the lock on the object at the path; nil when the path is not
+	// an object.
+	Lock *ObjectLock
+}
+
+// ---------------------------------------------------------------------------
+// Keys and records
+// ---------------------------------------------------------------------------
+
+func retentionKey(cleanPath string) []byte {
+	return []byte(local_cache.PrefixRetention + cleanPath)
+}
+
+// retentionBelowPrefix covers the settings on every path beneath dir.
+func retentionBelowPrefix(dir string) []byte {
+	if isRootPath(dir) {
+		return []byte(local_cache.PrefixRetention + "/")
+	}
+	return []byte(local_cache.PrefixRetention + dir + "/")
+}
+
+// getRetention reads the settings on exactly cleanPath; nil when there are
+// none.
+func getRetention(txn *badger.Txn, cleanPath string) (*RetentionSettings, error) {
+	item, err := txn.Get(retentionKey(cleanPath))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the retention settings of %s", cleanPath)
+	}
+	rs := &RetentionSettings{Path: cleanPath}
+	if err := item.Value(func(val []byte) error {
+		return msgpack.Unmarshal(val, rs)
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode the retention settings of %s", cleanPath)
+	}
+	return rs, nil
+}
+
+// putRetention writes the settings on a path, deleting the record once
+// nothing is left in it.
+func putRetention(txn *badger.Txn, rs *RetentionSettings) error {
+	if rs.empty() {
+		return errors.Wrapf(txn.Delete(retentionKey(rs.Path)),
+			"failed to clear the retention settings of %s", rs.Path)
+	}
+	val, err := msgpack.Marshal(rs)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode retention settings")
+	}
+	return errors.Wrapf(txn.Set(retentionKey(rs.Path), val),
+		"failed to write the retention settings of %s", rs.Path)
+}
+
+// coveringRetention reads the settings on cleanPath and each of its
+// ancestors, nearest first.
+func coveringRetention(txn *badger.Txn, cleanPath string) ([]RetentionSettings, error) {
+	var covering []RetentionSettings
+	for cur := cleanPath; ; cur, _ = splitPath(cur) {
+		rs, err := getRetention(txn, cur)
+		if err != nil {
+			return nil, err
+		}
+		if rs != nil {
+			covering = append(covering, *rs)
+		}
+		if isRootPath(cur) {
+			return covering, nil
+		}
+	}
+}
+
+// scanRetention returns the settings under a key prefix, in key order.
+func scanRetention(txn *badger.Txn, prefix []byte) ([]RetentionSettings, error) {
+	var found []RetentionSettings
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		rs := RetentionSettings{Path: string(bytes.TrimPrefix(item.Key(), []byte(local_cache.PrefixRetention)))}
+		if err := item.Value(func(val []byte) error {
+			return msgpack.Unmarshal(val, &rs)
+		}); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode the retention settings of %s", rs.Path)
+		}
+		found = append(found, rs)
+	}
+	return found, nil
+}
+
+// lockOf combines the settings covering an object into its lock at now.
+func lockOf(d *Dirent, covering []RetentionSettings, now time.Time) ObjectLock {
+	var lock ObjectLock
+	written := time.Unix(0, d.MTimeNanos)
+	for _, rs := range covering {
+		if rs.Hold != nil && lock.HoldPath == "" {
+			lock.HoldPath = rs.Path
+			lock.HoldReason = rs.Hold.Reason
+		}
+		if rs.Rule == nil {
+			continue
+		}
+		until := written.Add(rs.Rule.Period)
+		if until.After(lock.RetainUntil) {
+			lock.RetainUntil = until
+			lock.RulePath = rs.Path
+		}
+		if until.After(now) && lock.Mode != RetentionCompliance {
+			lock.Mode = rs.Rule.Mode
+		}
+	}
+	lock.Locked = lock.HoldPath != "" || lock.RetainUntil.After(now)
+	return lock
+}
+
+// ---------------------------------------------------------------------------
+// Enforcement
+// ---------------------------------------------------------------------------
+
+// enforcesRetention reports whether the store refuses changes to locked
+// objects; a secondary leaves that to its primary.
+func (s *Store) enforcesRetention() bool {
+	return s.repl.role != RoleSecondary
+}
+
+// checkRetained refuses op on the object at cleanPath, whose entry is d, while
+// the object is locked.  Directories are not locked themselves; see
+// checkSubtreeRetained.
+func (s *Store) checkRetained(txn *badger.Txn, op, cleanPath string, d *Dirent, now time.Time) error {
+	if d == nil || d.IsDir() || !s.enforcesRetention() {
+		return nil
+	}
+	covering, err := coveringRetention(txn, cleanPath)
+	if err != nil || len(covering) == 0 {
+		return err
+	}
+	if lock := lockOf(d, covering, now); lock.Locked {
+		return refuseRetained(op, cleanPath, lock)
+	}
+	return nil
+}
+
+// checkSubtreeRetained refuses op on the directory dir while any object
+// beneath it is locked.
+//
+// The subtree is walked only when a setting covers it or lies inside it,
+// which for a store without any is two reads.
+func (s *Store) checkSubtreeRetained(txn *badger.Txn, op, dir string, now time.Time) error {
+	if !s.enforcesRetention() {
+		return nil
+	}
+	covering, err := coveringRetention(txn, dir)
+	if err != nil {
+		return err
+	}
+	inside, err := scanRetention(txn, retentionBelowPrefix(dir))
+	if err != nil {
+		return err
+	}
+	if len(covering) == 0 && len(inside) == 0 {
+		return nil
+	}
+	below := make(map[string]RetentionSettings, len(inside))
+	for _, rs := range inside {
+		below[rs.Path] = rs
+	}
+
+	var refused error
+	err = walkSubtree(txn, dir, func(entryPath string, d *Dirent) error {
+		if d.IsDir() {
+			return nil
+		}
+		settings := covering
+		if len(below) > 0 {
+			// The settings between the object and dir go in front, keeping
+			// the nearest-first order lockOf reports holds by.
+			var nearer []RetentionSettings
+			for cur := entryPath; cur != dir; cur, _ = splitPath(cur) {
+				if rs, ok := below[cur]; ok {
+					nearer = append(nearer, rs)
+				}
+			}
+			if len(nearer) > 0 {
+				settings = append(nearer, covering...)
+			}
+		}
+		if len(settings) == 0 {
+			return nil
+		}
+		if lock := lockOf(d, settings, now); lock.Locked {
+			refused = refuseRetained(op, entryPath, lock)
+			return errStopWalk
+		}
+		return nil
+	})
+	if refused != nil {
+		return refused
+	}
+	return err
+}
+
+// refuseRetained counts a refusal and builds the error reporting it.
+func refuseRetained(op, cleanPath string, lock ObjectLock) error {
+	metrics.PStoreRetentionRefusalsTotal.WithLabelValues(op).Inc()
+	return &RetentionError{Path: cleanPath, Lock: lock}
+}
+
+// ---------------------------------------------------------------------------
+// Settings
+// ---------------------------------------------------------------------------
+
+// SetRetention sets the retention rule on a path, replacing any there.
+//
+// A compliance rule can only be replaced by a compliance rule at least as
+// long; anything else is refused with ErrRetained.  The path need not exist.
+func (s *Store) SetRetention(name string, mode RetentionMode, period time.Duration) (*RetentionSettings, error) {
+	if _, err := ParseRetentionMode(string(mode)); err != nil {
+		return nil, err
+	}
+	if period <= 0 {
+		return nil, errors.Wrap(ErrInvalidPath, "a retention period must be positive")
+	}
+	return s.changeRetention(name, func(rs *RetentionSettings) error {
+		if old := rs.Rule; old != nil && old.Mode == RetentionCompliance &&
+			(mode != RetentionCompliance || period < old.Period) {
+			return errors.Wrapf(ErrRetained,
+				"%s has a compliance retention rule of %s, which can be lengthened but not shortened or relaxed",
+				rs.Path, old.Period)
+		}
+		rs.Rule = &RetentionRule{Mode: mode, Period: period, SetNanos: time.Now().UnixNano()}
+		return nil
+	})
+}
+
+// ClearRetention removes the retention rule from a path.  A compliance rule
+// cannot be removed.
+func (s *Store) ClearRetention(name string) error {
+	_, err := s.changeRetention(name, func(rs *RetentionSettings) error {
+		switch {
+		case rs.Rule == nil:
+			return errors.Wrapf(ErrNotExist, "%s has no retention rule", rs.Path)
+		case rs.Rule.Mode == RetentionCompliance:
+			return errors.Wrapf(ErrRetained, "%s has a compliance retention rule, which cannot be removed", rs.Path)
+		}
+		rs.Rule = nil
+		return nil
+	})
+	return err
+}
+
+// SetLegalHold places a legal hold on a path, replacing the reason of any
+// already there.
+func (s *Store) SetLegalHold(name, reason string) (*RetentionSettings, error) {
+	return s.changeRetention(name, func(rs *RetentionSettings) error {
+		rs.Hold = &LegalHold{Reason: reason, SetNanos: time.Now().UnixNano()}
+		return nil
+	})
+}
+
+// ReleaseLegalHold releases the legal hold on a path.
+func (s *Store) ReleaseLegalHold(name string) error {
+	_, err := s.changeRetention(name, func(rs *RetentionSettings) error {
+		if rs.Hold == nil {
+			return errors.Wrapf(ErrNotExist, "%s has no legal hold", rs.Path)
+		}
+		rs.Hold = nil
+		return nil
+	})
+	return err
+}
+
+// changeRetention applies fn to the settings on a path and commits them.
+func (s *Store) changeRetention(name string, fn func(rs *RetentionSettings) error) (*RetentionSettings, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+	if err := s.checkNotReplica(); err != nil {
+		return nil, err
+	}
+	cleanPath, err := cleanRelative(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed *RetentionSettings
+	err = s.update(func(txn *badger.Txn) (*ReplicationRecord, error) {
+		rs, gErr := getRetention(txn, cleanPath)
+		if gErr != nil {
+			return nil, gErr
+		}
+		if rs == nil {
+			rs = &RetentionSettings{Path: cleanPath}
+		}
+		if fErr := fn(rs); fErr != nil {
+			return nil, fErr
+		}
+		if pErr := putRetention(txn, rs); pErr != nil {
+			return nil, pErr
+		}
+		changed = rs
+		return &ReplicationRecord{Op: ReplicateRetention, Path: cleanPath, Retention: rs}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// Retention describes the retention settings that lock the object at name,
+// or would lock one there, and those set beneath it.
+func (s *Store) Retention(name string) (*RetentionStatus, error) {
+	cleanPath, err := cleanRelative(name)
+	if err != nil {
+		return nil, err
+	}
+	status := &RetentionStatus{}
+	err = s.bdb.View(func(txn *badger.Txn) error {
+		var vErr error
+		if status.Covering, vErr = coveringRetention(txn, cleanPath); vErr != nil {
+			return vErr
+		}
+		if status.Below, vErr = scanRetention(txn, retentionBelowPrefix(cleanPath)); vErr != nil {
+			return vErr
+		}
+		if isRootPath(cleanPath) {
+			return nil
+		}
+		d, gErr := s.resolve(txn, cleanPath)
+		switch {
+		case gErr == nil && !d.IsDir():
+			lock := lockOf(d, status.Covering, time.Now())
+			status.Lock = &lock
+		case gErr != nil && !errors.Is(gErr, ErrNotExist):
+			return gErr
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// allRetention returns every path's settings, sorted by path.
+func (s *Store) allRetention() ([]RetentionSettings, error) {
+	var all []RetentionSettings
+	err := s.bdb.View(func(txn *badger.Txn) error {
+		var sErr error
+		all, sErr = scanRetention(txn, []byte(local_cache.PrefixRetention))
+		return sErr
+	})
+	sort.Slice(all, func(i, j int) bool { return all[i].Path < all[j].Path })
+	return all, err
+}
+
+// ---------------------------------------------------------------------------
+// Replication
+// ---------------------------------------------------------------------------
+
+// applyRetention replays a change to a path's settings.  The record carries
+// them whole, so the rules the primary enforced when setting them are not
+// checked again.
+func (s *Store) applyRetention(rec *ReplicationRecord) error {
+	rs := &RetentionSettings{Path: rec.Path}
+	if rec.Retention != nil {
+		rs.Rule, rs.Hold = rec.Retention.Rule, rec.Retention.Hold
+	}
+	return s.bdb.Update(func(txn *badger.Txn) error {
+		return putRetention(txn, rs)
+	})
+}
+
+// clearAllRetention drops every path's settings, for a secondary about to be
+// resynchronized.
+func (s *Store) clearAllRetention() error {
+	all, err := s.allRetention()
+	if err != nil {
+		return err
+	}
+	return s.bdb.Update(func(txn *badger.Txn) error {
+		for _, rs := range all {
+			if err := txn.Delete(retentionKey(rs.Path)); err != nil {
+				return errors.Wrapf(err, "failed to clear the retention settings of %s", rs.Path)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,253 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package pstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backdate makes an object look as though it was written the given time ago,
+// which is what a retention period is measured from.
+func backdate(t *testing.T, s *Store, name string, ago time.Duration) {
+	t.Helper()
+	require.NoError(t, s.bdb.Update(func(txn *badger.Txn) error {
+		d, err := getDirent(txn, name)
+		if err != nil {
+			return err
+		}
+		d.MTimeNanos = time.Now().Add(-ago).UnixNano()
+		return putDirent(txn, name, d)
+	}))
+}
+
+// A retention rule locks the objects under it against every kind of change,
+// and nothing else: new objects may still be written beside them, objects
+// elsewhere are untouched, and one written longer ago than the period is free.
+func TestRetentionLocksObjects(t *testing.T) {
+	s := newTestStore(t)
+	require.NoError(t, s.MkdirAll("/raw/run1"))
+	require.NoError(t, s.Mkdir("/scratch"))
+	writeObject(t, s, "/raw/run1/a", []byte("original"))
+	writeObject(t, s, "/raw/old", []byte("old"))
+	backdate(t, s, "/raw/old", 2*time.Hour)
+	writeObject(t, s, "/scratch/x", []byte("x"))
+
+	_, err := s.SetRetention("/raw", RetentionGovernance, time.Hour)
+	require.NoError(t, err)
+
+	_, err = s.Create("/raw/run1/a")
+	assert.ErrorIs(t, err, ErrRetained, "an overwrite is refused before any of it is written")
+	var retained *RetentionError
+	require.ErrorAs(t, err, &retained)
+	assert.Equal(t, "/raw/run1/a", retained.Path)
+	assert.Equal(t, RetentionGovernance, retained.Lock.Mode)
+	assert.Equal(t, "/raw", retained.Lock.RulePath)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), retained.Lock.RetainUntil, time.Minute)
+
+	assert.ErrorIs(t, s.Remove("/raw/run1/a"), ErrRetained)
+	assert.ErrorIs(t, s.RemoveAll("/raw/run1/a"), ErrRetained)
+	assert.ErrorIs(t, s.RemoveAll("/raw"), ErrRetained, "a tree holding a locked object stays whole")
+	assert.ErrorIs(t, s.Rename("/raw/run1/a", "/scratch/a"), ErrRetained)
+	assert.ErrorIs(t, s.Rename("/raw/run1", "/scratch/run1"), ErrRetained)
+	assert.ErrorIs(t, s.Rename("/scratch/x", "/raw/run1/a"), ErrRetained, "nor may a rename replace one")
+	assert.Equal(t, "original", readObject(t, s, "/raw/run1/a"))
+	_, err = s.Stat("/raw/old")
+	require.NoError(t, err, "the refused recursive delete removed nothing")
+
+	writeObject(t, s, "/raw/run1/b", []byte("new"))
+	_, err = s.Create("/raw/run1/b")
+	assert.ErrorIs(t, err, ErrRetained, "a new object is locked from the moment it lands")
+
+	require.NoError(t, s.Remove("/raw/old"), "an object written before the period is free")
+	require.NoError(t, s.Rename("/scratch/x", "/scratch/y"))
+	require.NoError(t, s.RemoveAll("/scratch"))
+}
+
+// The commit rechecks what Create checked, so a rule set while an overwrite
+// was being written still stops it.
+func TestRetentionIsEnforcedAtCommit(t *testing.T) {
+	s := newTestStore(t)
+	writeObject(t, s, "/obj", []byte("original"))
+
+	w, err := s.Create("/obj")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("replacement"))
+	require.NoError(t, err)
+	_, err = s.SetLegalHold("/obj", "audit")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, w.Close(), ErrRetained)
+	assert.Equal(t, "original", readObject(t, s, "/obj"))
+}
+
+// A compliance rule can only grow; a governance rule is an administrator's
+// to relax.
+func TestRetentionModes(t *testing.T) {
+	s := newTestStore(t)
+	writeObject(t, s, "/locked", []byte("x"))
+
+	_, err := s.SetRetention("/", RetentionCompliance, 24*time.Hour)
+	require.NoError(t, err)
+	_, err = s.SetRetention("/", RetentionCompliance, time.Hour)
+	assert.ErrorIs(t, err, ErrRetained, "a compliance rule cannot be shortened")
+	_, err = s.SetRetention("/", RetentionGovernance, 48*time.Hour)
+	assert.ErrorIs(t, err, ErrRetained, "or relaxed to governance")
+	assert.ErrorIs(t, s.ClearRetention("/"), ErrRetained, "or removed")
+	rs, err := s.SetRetention("/", RetentionCompliance, 48*time.Hour)
+	require.NoError(t, err, "but it can be lengthened")
+	assert.Equal(t, 48*time.Hour, rs.Rule.Period)
+
+	s = newTestStore(t)
+	writeObject(t, s, "/locked", []byte("x"))
+	_, err = s.SetRetention("/", RetentionGovernance, 24*time.Hour)
+	require.NoError(t, err)
+	assert.ErrorIs(t, s.Remove("/locked"), ErrRetained)
+	_, err = s.SetRetention("/", RetentionGovernance, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, s.ClearRetention("/"))
+	assert.ErrorIs(t, s.ClearRetention("/"), ErrNotExist)
+	require.NoError(t, s.Remove("/locked"), "a removed governance rule unlocks")
+
+	_, err = s.SetRetention("/", "forever", time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidPath)
+	_, err = s.SetRetention("/", RetentionGovernance, 0)
+	assert.ErrorIs(t, err, ErrInvalidPath)
+}
+
+// Every rule covering an object applies, so a shorter rule beneath a longer
+// one does not shorten it, and a hold anywhere above it holds it.
+func TestRetentionIsCumulative(t *testing.T) {
+	s := newTestStore(t)
+	require.NoError(t, s.MkdirAll("/a/b/c"))
+	writeObject(t, s, "/a/b/c/obj", []byte("x"))
+	backdate(t, s, "/a/b/c/obj", 2*time.Hour)
+
+	_, err := s.SetRetention("/a", RetentionGovernance, 3*time.Hour)
+	require.NoError(t, err)
+	_, err = s.SetRetention("/a/b", RetentionCompliance, time.Hour)
+	require.NoError(t, err)
+	_, err = s.SetLegalHold("/a/b/c", "litigation 42")
+	require.NoError(t, err)
+
+	status, err := s.Retention("/a/b/c/obj")
+	require.NoError(t, err)
+	require.Len(t, status.Covering, 3)
+	assert.Equal(t, "/a/b/c", status.Covering[0].Path, "nearest first")
+	require.NotNil(t, status.Lock)
+	assert.True(t, status.Lock.Locked)
+	assert.Equal(t, "/a", status.Lock.RulePath, "the longest rule governs")
+	assert.Equal(t, RetentionGovernance, status.Lock.Mode, "the expired compliance rule no longer counts")
+	assert.Equal(t, "/a/b/c", status.Lock.HoldPath)
+	assert.Equal(t, "litigation 42", status.Lock.HoldReason)
+
+	require.NoError(t, s.ClearRetention("/a"))
+	assert.ErrorIs(t, s.RemoveAll("/a"), ErrRetained, "still held")
+	require.NoError(t, s.ReleaseLegalHold("/a/b/c"))
+	assert.ErrorIs(t, s.ReleaseLegalHold("/a/b/c"), ErrNotExist)
+
+	status, err = s.Retention("/")
+	require.NoError(t, err)
+	require.Len(t, status.Below, 1, "only the compliance rule is left")
+	assert.Equal(t, "/a/b", status.Below[0].Path)
+	require.NoError(t, s.RemoveAll("/a"), "and it expired an hour ago")
+}
+
+// A restore is an overwrite too.  A point-in-time restore carries on past a
+// locked object and says which it left.
+func TestRetentionBlocksRestores(t *testing.T) {
+	s := newVersionedTestStore(t, map[string]VersionPolicy{"/": {Keep: 5}})
+	writeObject(t, s, "/obj", []byte("first"))
+	first, err := s.Stat("/obj")
+	require.NoError(t, err)
+	writeObject(t, s, "/obj", []byte("second"))
+	_, err = s.SetLegalHold("/obj", "")
+	require.NoError(t, err)
+
+	_, err = s.RestoreVersion("/obj", first.ETag())
+	assert.ErrorIs(t, err, ErrRetained)
+
+	report, err := s.RestoreAt(t.Context(), "/", time.Unix(0, first.MTimeNanos))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/obj"}, report.Locked)
+	assert.Empty(t, report.Restored)
+	assert.Equal(t, "second", readObject(t, s, "/obj"))
+}
+
+// Settings replicate, both through the log and in a full resynchronization,
+// and a secondary applies its primary's changes without enforcing them.
+func TestRetentionReplicates(t *testing.T) {
+	primary, secondary, transport := newReplicatedPair(t)
+	ctx := t.Context()
+
+	_, err := primary.SetRetention("/raw", RetentionCompliance, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, primary.shipOnce(ctx, transport))
+	status, err := secondary.Retention("/raw")
+	require.NoError(t, err)
+	require.Len(t, status.Covering, 1, "copied by the full resynchronization")
+	assert.Equal(t, RetentionCompliance, status.Covering[0].Rule.Mode)
+
+	_, err = primary.SetLegalHold("/raw", "audit")
+	require.NoError(t, err)
+	writeObject(t, primary, "/free", []byte("x"))
+	require.NoError(t, primary.shipOnce(ctx, transport))
+	status, err = secondary.Retention("/raw")
+	require.NoError(t, err)
+	require.Len(t, status.Covering, 1)
+	require.NotNil(t, status.Covering[0].Hold, "and followed through the log")
+
+	require.NoError(t, primary.ReleaseLegalHold("/raw"))
+	require.NoError(t, primary.shipOnce(ctx, transport))
+	status, err = secondary.Retention("/raw")
+	require.NoError(t, err)
+	require.Len(t, status.Covering, 1)
+	assert.Nil(t, status.Covering[0].Hold)
+
+	_, err = secondary.SetLegalHold("/raw", "")
+	assert.ErrorIs(t, err, ErrReplica, "only the primary changes them")
+
+	// A reset empties a secondary of everything, locked or not.
+	require.NoError(t, secondary.MkdirAll("/raw"))
+	writeObject(t, secondary, "/raw/copied", []byte("held on the secondary"))
+	require.NoError(t, secondary.resetReplica(ctx))
+	all, err := secondary.allRetention()
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+func TestRetentionErrorMessages(t *testing.T) {
+	until := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := error(&RetentionError{Path: "/raw/a", Lock: ObjectLock{
+		Locked: true, Mode: RetentionCompliance, RetainUntil: until, RulePath: "/raw",
+	}})
+	assert.True(t, errors.Is(errors.Wrap(err, "close"), ErrRetained))
+	assert.Equal(t,
+		"/raw/a is retained in compliance mode and cannot be modified or deleted until 2030-01-02T03:04:05Z",
+		err.Error())
+
+	err = &RetentionError{Path: "/raw/a", Lock: ObjectLock{Locked: true, HoldPath: "/raw", HoldReason: "private"}}
+	assert.NotContains(t, err.Error(), "private", "a hold's reason is for administrators")
+	assert.Contains(t, err.Error(), "legal hold")
+}
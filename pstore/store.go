@@ -39,6 +39,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dgraph-io/badger/v4"
@@ -76,6 +77,15 @@ type Config struct {
 	// rather than on disk.  Zero selects local_cache.InlineThreshold.
 	InlineMaxBytes int
 
+	// Compression selects the block compression for newly written objects.
+	// Objects already stored keep the compression they were written with.
+	// Per-namespace settings do not apply: the store is a single namespace.
+	Compression local_cache.CompressionConfig
+
+	// Dedup stores newly written objects' blocks in a pool shared by the
+	// whole store, so that versions of a file share their unchanged blocks.
+	Dedup bool
+
 	// NamespaceLabel names the store in the catalog's usage counters.
 	//
 	// A store is one accounting unit spanning however many exports are mapped
@@ -83,6 +93,21 @@ type Config struct {
 	// Per-export accounting arrives with path-based quotas, where the lot
 	// tree is the natural place for it.  Empty selects the store root.
 	NamespaceLabel string
+
+	// Versioning keeps superseded versions of the objects under the given
+	// store paths (versions.go).  Each object follows the policy of its
+	// nearest listed ancestor, so a zero policy exempts a subtree.
+	Versioning map[string]VersionPolicy
+
+	// Replication is the store's part in replication (replication.go).  A
+	// store remembers its role, so this can move it between standalone and
+	// primary, or make a standalone store a secondary, but not undo being a
+	// secondary: that takes Promote.
+	Replication ReplicationRole
+
+	// Erasure codes newly written objects across the storage directories
+	// (erasure.go), so that losing a directory loses no data.
+	Erasure ErasureConfig
 }
 
 // Store is the object store backing a pstore origin.
@@ -91,6 +116,9 @@ type Config struct {
 // store has exclusive ownership of its directories for its lifetime, exactly
 // as the cache does.
 type Store struct {
+	// baseDir holds the catalog and the master key file.
+	baseDir string
+
 	db      *local_cache.CacheDB
 	storage *local_cache.StorageManager
 	bdb     *badger.DB
@@ -112,6 +140,27 @@ type Store struct {
 	// index, so lookups must treat them as already gone.
 	detached *detachedSet
 
+	// versioning holds the retention policies, keyed by cleaned store path.
+	versioning versionPolicies
+
+	// repl is the store's replication state: the log a primary keeps, or the
+	// position a secondary has reached.
+	repl *replicator
+
+	// erasure is the layout newly written objects are coded with, and
+	// erasureMu serializes repairs (repairInstance).
+	erasure   ErasureConfig
+	erasureMu sync.Mutex
+
+	// restoredThrough is the last catalog version the metadata backups
+	// restored into this handle cover, so that an incremental can check it
+	// continues them (backup.go).  Zero until a restore.
+	restoredThrough uint64
+
+	// backupHolds counts the object-data backups in progress; while there are
+	// any, the janitor frees nothing (backup_data.go).
+	backupHolds atomic.Int32
+
 	// readOnly marks a store opened for offline inspection; every mutating
 	// entry point refuses rather than failing deeper down in BadgerDB.
 	readOnly bool
@@ -152,6 +201,9 @@ func Open(ctx context.Context, egrp *errgroup.Group, cfg Config) (*Store, error)
 		}
 		dirPaths[i] = d.Path
 	}
+	if err := cfg.Erasure.validate(len(dirs), cfg.Dedup); err != nil {
+		return nil, err
+	}
 
 	db, err := local_cache.NewCacheDB(ctx, cfg.BaseDir)
 	if err != nil {
@@ -178,6 +230,13 @@ func Open(ctx context.Context, egrp *errgroup.Group, cfg Config) (*Store, error)
 		return nil, err
 	}
 
+	versioning, err := newVersionPolicies(cfg.Versioning)
+	if err != nil {
+		storage.Close()
+		db.Close()
+		return nil, err
+	}
+
 	namespaceID, err := resolveNamespaceID(db, cfg.NamespaceLabel)
 	if err != nil {
 		storage.Close()
@@ -185,7 +244,15 @@ func Open(ctx context.Context, egrp *errgroup.Group, cfg Config) (*Store, error)
 		return nil, err
 	}
 
+	repl, err := openReplication(db.DB(), cfg.Replication)
+	if err != nil {
+		storage.Close()
+		db.Close()
+		return nil, err
+	}
+
 	store := &Store{
+		baseDir:     cfg.BaseDir,
 		db:          db,
 		storage:     storage,
 		bdb:         db.DB(),
@@ -193,11 +260,21 @@ func Open(ctx context.Context, egrp *errgroup.Group, cfg Config) (*Store, error)
 		dirLabels:   storageDirLabels(storage),
 		namespaceID: namespaceID,
 		detached:    newDetachedSet(),
+		versioning:  versioning,
+		repl:        repl,
+		erasure:     cfg.Erasure,
 	}
 
 	// The block store's default chooser is round-robin, which ignores how
 	// full each directory is; hand it one driven by the capacity limits.
 	storage.SetChooseDir(capacity.chooseDir)
+	storage.SetCompression(cfg.Compression, nil)
+	storage.SetDedup(local_cache.DedupConfig{Enabled: cfg.Dedup}, nil)
+	if cfg.Erasure.Enabled() {
+		// Keep each stripe's chunks in different directories, so that a
+		// directory lost costs a stripe at most one shard.
+		storage.SetChunkPlacement(store.placeErasureChunk)
+	}
 
 	// A crash mid-drain leaves queue entries behind; reload them so the
 	// half-deleted trees stay invisible.
@@ -335,19 +412,19 @@ func (s *Store) Mkdir(name string) error {
 		return err
 	}
 
-	return s.bdb.Update(func(txn *badger.Txn) error {
+	return s.update(func(txn *badger.Txn) (*ReplicationRecord, error) {
 		if pErr := s.requireDirResolved(txn, parent); pErr != nil {
 			if errors.Is(pErr, ErrNotExist) {
-				return errors.Wrapf(ErrNotExist, "parent directory %s does not exist", parent)
+				return nil, errors.Wrapf(ErrNotExist, "parent directory %s does not exist", parent)
 			}
-			return pErr
+			return nil, pErr
 		}
 		if _, gErr := s.resolve(txn, cleanPath); gErr == nil {
-			return ErrExist
+			return nil, ErrExist
 		} else if !errors.Is(gErr, ErrNotExist) {
-			return gErr
+			return nil, gErr
 		}
-		return putDirent(txn, cleanPath, &Dirent{
+		return &ReplicationRecord{Op: ReplicateMkdir, Path: cleanPath}, putDirent(txn, cleanPath, &Dirent{
 			Type:       EntryDir,
 			MTimeNanos: time.Now().UnixNano(),
 			Mode:       uint32(defaultDirMode.Perm()),
@@ -380,29 +457,36 @@ func (s *Store) MkdirAll(name string) error {
 		cur, _ = splitPath(cur)
 	}
 
-	return s.bdb.Update(func(txn *badger.Txn) error {
+	return s.update(func(txn *badger.Txn) (*ReplicationRecord, error) {
 		now := time.Now().UnixNano()
+		created := false
 		for i := len(missing) - 1; i >= 0; i-- {
 			p := missing[i]
 			d, gErr := s.resolve(txn, p)
 			if gErr == nil {
 				if !d.IsDir() {
-					return errors.Wrapf(ErrNotDir, "%s exists and is not a directory", p)
+					return nil, errors.Wrapf(ErrNotDir, "%s exists and is not a directory", p)
 				}
 				continue
 			}
 			if !errors.Is(gErr, ErrNotExist) {
-				return gErr
+				return nil, gErr
 			}
 			if pErr := putDirent(txn, p, &Dirent{
 				Type:       EntryDir,
 				MTimeNanos: now,
 				Mode:       uint32(defaultDirMode.Perm()),
 			}); pErr != nil {
-				return pErr
+				return nil, pErr
 			}
+			created = true
 		}
-		return nil
+		// Every upload into an existing directory comes through here, so
+		// logging the calls that changed nothing would double the log.
+		if !created {
+			return nil, nil
+		}
+		return &ReplicationRecord{Op: ReplicateMkdir, Path: cleanPath}, nil
 	})
 }
 
@@ -427,29 +511,39 @@ func (s *Store) Remove(name string) error {
 		return errors.Wrap(ErrInvalidPath, "cannot remove the store root")
 	}
 
-	return s.bdb.Update(func(txn *badger.Txn) error {
+	var pruned int
+	err = s.update(func(txn *badger.Txn) (*ReplicationRecord, error) {
+		rec := &ReplicationRecord{Op: ReplicateRemove, Path: cleanPath}
 		d, gErr := s.resolve(txn, cleanPath)
 		if gErr != nil {
-			return gErr
+			return nil, gErr
 		}
 		if d.IsDir() {
 			hasChildren, cErr := dirHasChildren(txn, cleanPath)
 			if cErr != nil {
-				return cErr
+				return nil, cErr
 			}
 			if hasChildren {
-				return ErrNotEmpty
+				return nil, ErrNotEmpty
 			}
-			return deleteDirent(txn, cleanPath)
+			return rec, deleteDirent(txn, cleanPath)
 		}
-		if dErr := deleteDirent(txn, cleanPath); dErr != nil {
-			return dErr
+		now := time.Now()
+		if lErr := s.checkRetained(txn, "remove", cleanPath, d, now); lErr != nil {
+			return nil, lErr
 		}
-		if d.Generation == "" {
-			return nil
+		if dErr := deleteDirent(txn, cleanPath); dErr != nil {
+			return nil, dErr
 		}
-		return enqueueInstance(txn, instanceHashFor(s.db, d.Generation))
+		var rErr error
+		pruned, rErr = s.retireVersion(txn, cleanPath, d, true, now)
+		return rec, rErr
 	})
+	if err != nil {
+		return err
+	}
+	observeVersionsPruned(pruned)
+	return nil
 }
 
 // RemoveAll deletes a path and everything beneath it.
@@ -480,19 +574,32 @@ func (s *Store) RemoveAll(name string) error {
 	defer txn.Discard()
 
 	detached := false
+	pruned := 0
 	err = func() error {
 		d, gErr := s.resolve(txn, cleanPath)
 		if gErr != nil {
 			return gErr
 		}
-		if dErr := deleteDirent(txn, cleanPath); dErr != nil {
-			return dErr
-		}
+		now := time.Now()
 		if !d.IsDir() {
-			if d.Generation == "" {
-				return nil
+			if lErr := s.checkRetained(txn, "remove", cleanPath, d, now); lErr != nil {
+				return lErr
+			}
+			if dErr := deleteDirent(txn, cleanPath); dErr != nil {
+				return dErr
 			}
-			return enqueueInstance(txn, instanceHashFor(s.db, d.Generation))
+			var rErr error
+			pruned, rErr = s.retireVersion(txn, cleanPath, d, true, now)
+			return rErr
+		}
+		// Nothing is removed while anything beneath is locked, rather than
+		// removing what is not: a recursive delete that stops part-way would
+		// leave the tree in a state nobody asked for.
+		if lErr := s.checkSubtreeRetained(txn, "remove", cleanPath, now); lErr != nil {
+			return lErr
+		}
+		if dErr := deleteDirent(txn, cleanPath); dErr != nil {
+			return dErr
 		}
 
 		// Remove the whole subtree now when it is small enough to fit in this
@@ -505,7 +612,8 @@ func (s *Store) RemoveAll(name string) error {
 		// removes that window entirely; only a subtree too large for one
 		// transaction is deferred, and re-creating one of those is refused
 		// until the drain completes rather than silently losing data.
-		done, dErr := deleteSubtreeInline(txn, s.db, cleanPath)
+		done, n, dErr := s.deleteSubtreeInline(txn, cleanPath)
+		pruned = n
 		if dErr != nil {
 			return dErr
 		}
@@ -524,13 +632,20 @@ func (s *Store) RemoveAll(name string) error {
 		return err
 	}
 
+	logged := s.beginLogged(&ReplicationRecord{Op: ReplicateRemoveAll, Path: cleanPath})
+	if lErr := logged.append(txn); lErr != nil {
+		logged.end(false)
+		return lErr
+	}
 	if detached {
 		// Descendants keep their index keys until the janitor drains them,
 		// and a path-derived lookup does not consult ancestors, so they must
 		// be masked explicitly until then.  See detached.go.
 		s.detached.add(cleanPath)
 	}
-	if cErr := txn.Commit(); cErr != nil {
+	cErr := txn.Commit()
+	logged.end(cErr == nil)
+	if cErr != nil {
 		if detached {
 			// Nothing was unlinked after all, so the mask must come back down
 			// or the subtree stays invisible for the life of the process.
@@ -538,6 +653,7 @@ func (s *Store) RemoveAll(name string) error {
 		}
 		return errors.Wrapf(cErr, "failed to remove %s", cleanPath)
 	}
+	observeVersionsPruned(pruned)
 	return nil
 }
 
@@ -582,43 +698,61 @@ func (s *Store) Rename(oldName, newName string) error {
 		return err
 	}
 
-	return s.bdb.Update(func(txn *badger.Txn) error {
+	var pruned int
+	err = s.update(func(txn *badger.Txn) (*ReplicationRecord, error) {
 		src, gErr := s.resolve(txn, oldPath)
 		if gErr != nil {
-			return gErr
+			return nil, gErr
+		}
+		now := time.Now()
+		// A locked object may not move: its path is part of what is kept.
+		if src.IsDir() {
+			if lErr := s.checkSubtreeRetained(txn, "rename", oldPath, now); lErr != nil {
+				return nil, lErr
+			}
+		} else if lErr := s.checkRetained(txn, "rename", oldPath, src, now); lErr != nil {
+			return nil, lErr
 		}
 		newParent, _ := splitPath(newPath)
 		if pErr := s.requireDirResolved(txn, newParent); pErr != nil {
-			return pErr
+			return nil, pErr
 		}
 
 		// A destination file is replaced; its version still needs reclaiming.
 		if dst, dErr := s.resolve(txn, newPath); dErr == nil {
 			switch {
 			case dst.IsDir():
-				return ErrIsDir
+				return nil, ErrIsDir
 			case src.IsDir():
-				return ErrNotDir
+				return nil, ErrNotDir
 			}
-			if dst.Generation != "" {
-				if eErr := enqueueInstance(txn, instanceHashFor(s.db, dst.Generation)); eErr != nil {
-					return eErr
-				}
+			if lErr := s.checkRetained(txn, "rename", newPath, dst, now); lErr != nil {
+				return nil, lErr
+			}
+			var rErr error
+			if pruned, rErr = s.retireVersion(txn, newPath, dst, false, now); rErr != nil {
+				return nil, rErr
 			}
 		} else if !errors.Is(dErr, ErrNotExist) {
-			return dErr
+			return nil, dErr
 		}
 
 		if src.IsDir() {
 			if mErr := moveSubtree(txn, oldPath, newPath); mErr != nil {
-				return mErr
+				return nil, mErr
 			}
 		}
 		if dErr := deleteDirent(txn, oldPath); dErr != nil {
-			return dErr
+			return nil, dErr
 		}
-		return putDirent(txn, newPath, src)
+		return &ReplicationRecord{Op: ReplicateRename, Path: oldPath, Target: newPath},
+			putDirent(txn, newPath, src)
 	})
+	if err != nil {
+		return err
+	}
+	observeVersionsPruned(pruned)
+	return nil
 }
 
 // renameSubtreeLimit bounds how many descendants a single directory rename may
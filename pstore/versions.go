@@ -0,0 +1,976 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+// Object versioning.
+//
+// Every write mints a new generation (object.go), and without versioning the
+// one it replaces goes straight onto the reclamation queue (gc.go).  Under a
+// directory with a VersionPolicy it is kept instead, recorded under the path
+// it was superseded at:
+//
+//	pv:<path>\x00<superseded, in nanoseconds>:<generation>  ->  ObjectVersion
+//
+// The record is written by the transaction that makes the version stop being
+// current -- an overwrite, a delete, a rename over it, a recursive delete
+// draining past it -- in place of the queue entry that transaction would
+// otherwise write.  A superseded version is therefore always either retained
+// or queued and never neither, which is the invariant reclamation already
+// rests on, and pruning moves a version from one to the other in a single
+// transaction.
+//
+// Object identity does not depend on the path, so a retained version is an
+// ordinary instance that no directory entry points at, and restoring one is a
+// dirent swap: no bytes move, and the restored object carries its old ETag
+// again, which is right, since it is byte for byte that version.
+//
+// History belongs to the path rather than the object.  Renaming a file leaves
+// its earlier versions under the old name, which is the name someone who
+// overwrote the wrong file will look under.
+//
+// A policy keeps an object's newest Keep prior versions and any version
+// superseded less than Retain ago; a version outside both is pruned.  The
+// count is enforced by the retiring transaction, since it costs a scan of one
+// path's history, and the age by the janitor's periodic PruneVersions, which
+// is also what applies a policy that has since been narrowed or removed.
+
+package pstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/pelicanplatform/pelican/local_cache"
+	"github.com/pelicanplatform/pelican/metrics"
+)
+
+const (
+	// versionPruneInterval bounds how often the janitor walks the retained
+	// versions for ones past their retention period.  Retention is measured
+	// in days, so the lag is immaterial, and the walk visits every versioned
+	// path.
+	versionPruneInterval = 10 * time.Minute
+
+	// versionPruneBatchSize bounds how many versioned paths one read
+	// transaction of the prune sweep collects.
+	versionPruneBatchSize = 256
+)
+
+// VersionPolicy says which superseded versions of the objects under a
+// directory are kept.  The zero policy keeps none.
+type VersionPolicy struct {
+	// Keep is how many of an object's most recent prior versions are kept.
+	Keep int
+	// Retain additionally keeps every version superseded less than this long
+	// ago.
+	Retain time.Duration
+}
+
+// Enabled reports whether the policy keeps anything at all.
+func (p VersionPolicy) Enabled() bool { return p.Keep > 0 || p.Retain > 0 }
+
+// retains reports whether a version is kept.  rank is how many of the path's
+// retained versions are newer than this one.
+func (p VersionPolicy) retains(rank int, superseded, now time.Time) bool {
+	return rank < p.Keep || (p.Retain > 0 && now.Sub(superseded) < p.Retain)
+}
+
+// versionPolicies maps store paths to the policy covering the objects at and
+// below them.
+type versionPolicies map[string]VersionPolicy
+
+// lookup returns the policy for the object at cleanPath: that of the nearest
+// configured ancestor, so a zero policy on a subdirectory exempts it from its
+// parent's.
+func (p versionPolicies) lookup(cleanPath string) VersionPolicy {
+	if len(p) == 0 {
+		return VersionPolicy{}
+	}
+	for cur := cleanPath; ; cur, _ = splitPath(cur) {
+		if policy, ok := p[cur]; ok {
+			return policy
+		}
+		if isRootPath(cur) {
+			return VersionPolicy{}
+		}
+	}
+}
+
+// newVersionPolicies validates configured policies and keys them by cleaned
+// path.
+func newVersionPolicies(configured map[string]VersionPolicy) (versionPolicies, error) {
+	policies := make(versionPolicies, len(configured))
+	for p, policy := range configured {
+		cleanPath, err := cleanRelative(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid versioning path %q", p)
+		}
+		if policy.Keep < 0 || policy.Retain < 0 {
+			return nil, errors.Errorf("versioning policy for %s must not be negative", cleanPath)
+		}
+		policies[cleanPath] = policy
+	}
+	return policies, nil
+}
+
+// ParseVersioningValue converts the raw Origin.PStoreVersioning value into
+// policies keyed by store path.
+//
+// The value is a list of objects with a Path and a Keep count and/or a Retain
+// duration.  A list rather than a map keyed by path because the configuration
+// loader lowercases map keys, and store paths are case-sensitive.
+func ParseVersioningValue(raw any, name string) (map[string]VersionPolicy, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, errors.Errorf("%s: unsupported type %T; expected a list of objects", name, raw)
+	}
+
+	policies := make(map[string]VersionPolicy, len(list))
+	for i, elem := range list {
+		entry := make(map[string]interface{})
+		switch e := elem.(type) {
+		case map[string]interface{}:
+			for k, v := range e {
+				entry[strings.ToLower(k)] = v
+			}
+		case map[interface{}]interface{}:
+			for k, v := range e {
+				entry[strings.ToLower(fmt.Sprint(k))] = v
+			}
+		default:
+			return nil, errors.Errorf("%s[%d]: unsupported type %T", name, i, elem)
+		}
+
+		p, ok := entry["path"].(string)
+		if !ok || p == "" {
+			return nil, errors.Errorf("%s[%d]: a Path is required", name, i)
+		}
+		var policy VersionPolicy
+		for key, val := range entry {
+			var err error
+			switch key {
+			case "path":
+			case "keep":
+				policy.Keep, err = parseVersionKeep(val)
+			case "retain":
+				policy.Retain, err = parseVersionRetain(val)
+			default:
+				err = errors.Errorf("unknown field %q", key)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s[%d] (%s)", name, i, p)
+			}
+		}
+		if !policy.Enabled() {
+			// Not an error: it is how a subdirectory opts out of its parent's
+			// policy.
+			log.Debugf("%s[%d]: versioning is disabled for %s", name, i, p)
+		}
+		if _, dup := policies[p]; dup {
+			return nil, errors.Errorf("%s[%d]: %s is listed more than once", name, i, p)
+		}
+		policies[p] = policy
+	}
+	return policies, nil
+}
+
+func parseVersionKeep(val interface{}) (int, error) {
+	var keep int
+	switch v := val.(type) {
+	case int:
+		keep = v
+	case int64:
+		keep = int(v)
+	case float64:
+		keep = int(v)
+	case string:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, errors.Errorf("invalid Keep %q", v)
+		}
+		keep = n
+	default:
+		return 0, errors.Errorf("invalid Keep of type %T", val)
+	}
+	if keep < 0 {
+		return 0, errors.Errorf("Keep must not be negative")
+	}
+	return keep, nil
+}
+
+func parseVersionRetain(val interface{}) (time.Duration, error) {
+	var retain time.Duration
+	switch v := val.(type) {
+	case time.Duration:
+		retain = v
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, errors.Errorf("invalid Retain %q", v)
+		}
+		retain = d
+	default:
+		return 0, errors.Errorf("invalid Retain of type %T; expected a duration such as \"720h\"", val)
+	}
+	if retain < 0 {
+		return 0, errors.Errorf("Retain must not be negative")
+	}
+	return retain, nil
+}
+
+// ObjectVersion is one readable version of an object: the current one, or one
+// retained after it was superseded.
+type ObjectVersion struct {
+	// Generation identifies the version; it is also its ETag.
+	Generation string `msgpack:"g"`
+	Size       int64  `msgpack:"s"`
+	// MTimeNanos is when the version became current.
+	MTimeNanos int64 `msgpack:"m"`
+	// SupersededNanos is when it stopped being current; zero for the current
+	// version.
+	SupersededNanos int64 `msgpack:"x"`
+	// Deleted reports that it stopped being current because its path was
+	// removed rather than overwritten.
+	Deleted bool `msgpack:"d,omitempty"`
+	// Current marks the version the path holds now.  Never stored.
+	Current bool `msgpack:"-"`
+}
+
+// ETag returns the entity tag the version was served with while current.
+func (v *ObjectVersion) ETag() string { return etagFor(v.Generation) }
+
+// ModTime returns when the version became current.
+func (v *ObjectVersion) ModTime() time.Time { return time.Unix(0, v.MTimeNanos) }
+
+// Superseded returns when the version stopped being current, or the zero time
+// for the current version.
+func (v *ObjectVersion) Superseded() time.Time {
+	if v.SupersededNanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, v.SupersededNanos)
+}
+
+// versionKey returns the key retaining one version of the object at cleanPath.
+func versionKey(cleanPath string, v *ObjectVersion) []byte {
+	return []byte(fmt.Sprintf("%s%s%c%019d:%s",
+		local_cache.PrefixVersion, cleanPath, direntSeparator, v.SupersededNanos, v.Generation))
+}
+
+// versionsPrefix covers the retained versions of exactly the object at
+// cleanPath.  The separator cannot occur in a path, so it does not also cover
+// a sibling whose name merely begins the same way.
+func versionsPrefix(cleanPath string) []byte {
+	return []byte(local_cache.PrefixVersion + cleanPath + string(rune(direntSeparator)))
+}
+
+// versionedSubtreePrefix covers the retained versions of every path strictly
+// below dir.
+func versionedSubtreePrefix(dir string) []byte {
+	if isRootPath(dir) {
+		return []byte(local_cache.PrefixVersion)
+	}
+	return []byte(local_cache.PrefixVersion + dir + "/")
+}
+
+// pathFromVersionKey recovers the path a version key is retained under.
+func pathFromVersionKey(key []byte) (string, bool) {
+	if !bytes.HasPrefix(key, []byte(local_cache.PrefixVersion)) {
+		return "", false
+	}
+	rest := key[len(local_cache.PrefixVersion):]
+	sep := bytes.IndexByte(rest, direntSeparator)
+	if sep <= 0 {
+		return "", false
+	}
+	return string(rest[:sep]), true
+}
+
+// versionOf describes the version an entry holds as it stops being current.
+func versionOf(d *Dirent, now time.Time, deleted bool) *ObjectVersion {
+	return &ObjectVersion{
+		Generation:      d.Generation,
+		Size:            d.Size,
+		MTimeNanos:      d.MTimeNanos,
+		SupersededNanos: now.UnixNano(),
+		Deleted:         deleted,
+	}
+}
+
+// putVersion records a retained version within the given transaction.
+func putVersion(txn *badger.Txn, cleanPath string, v *ObjectVersion) error {
+	val, err := msgpack.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode object version")
+	}
+	return errors.Wrapf(txn.Set(versionKey(cleanPath, v), val),
+		"failed to retain version %s of %s", v.Generation, cleanPath)
+}
+
+// listVersions returns the versions retained for cleanPath, oldest first,
+// along with their keys.
+func listVersions(txn *badger.Txn, cleanPath string) ([]ObjectVersion, [][]byte, error) {
+	prefix := versionsPrefix(cleanPath)
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var (
+		versions []ObjectVersion
+		keys     [][]byte
+	)
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		var v ObjectVersion
+		if err := item.Value(func(val []byte) error {
+			return msgpack.Unmarshal(val, &v)
+		}); err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to read a retained version of %s", cleanPath)
+		}
+		versions = append(versions, v)
+		keys = append(keys, item.KeyCopy(nil))
+	}
+	return versions, keys, nil
+}
+
+// retireVersion makes d, the file entry at cleanPath, stop being current.  It
+// is retained when the path's policy says so and queued for reclamation
+// otherwise, and it must be called inside the transaction that unlinks or
+// replaces the entry.
+//
+// It reports how many older versions the policy pushed out, which the caller
+// publishes once its transaction has committed.
+func (s *Store) retireVersion(txn *badger.Txn, cleanPath string, d *Dirent, deleted bool, now time.Time) (int, error) {
+	if d == nil || d.IsDir() || d.Generation == "" {
+		return 0, nil
+	}
+	policy := s.versioning.lookup(cleanPath)
+	if !policy.Enabled() {
+		return 0, enqueueInstance(txn, instanceHashFor(s.db, d.Generation))
+	}
+	if err := putVersion(txn, cleanPath, versionOf(d, now, deleted)); err != nil {
+		return 0, err
+	}
+	return s.pruneVersionsOf(txn, cleanPath, policy, now)
+}
+
+// pruneVersionsOf hands every retained version of cleanPath that policy no
+// longer keeps to the janitor, within the given transaction.
+func (s *Store) pruneVersionsOf(txn *badger.Txn, cleanPath string, policy VersionPolicy, now time.Time) (int, error) {
+	versions, keys, err := listVersions(txn, cleanPath)
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for i := range versions {
+		rank := len(versions) - 1 - i
+		if policy.retains(rank, versions[i].Superseded(), now) {
+			continue
+		}
+		if err := txn.Delete(keys[i]); err != nil {
+			return pruned, errors.Wrapf(err, "failed to drop version %s of %s", versions[i].Generation, cleanPath)
+		}
+		if err := enqueueInstance(txn, instanceHashFor(s.db, versions[i].Generation)); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// observeVersionsPruned counts versions a committed transaction pruned.
+func observeVersionsPruned(n int) {
+	if n > 0 {
+		metrics.PStoreVersionsPrunedTotal.Add(float64(n))
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Reading history
+// ---------------------------------------------------------------------------
+
+// Versions returns every version of the object at name that can still be
+// read, newest first: the current one, when the path has one, then those
+// retained under its directory's versioning policy.  A path that has been
+// deleted but whose history is retained still has versions.
+func (s *Store) Versions(name string) ([]ObjectVersion, error) {
+	cleanPath, err := cleanRelative(name)
+	if err != nil {
+		return nil, err
+	}
+	if isRootPath(cleanPath) {
+		return nil, ErrIsDir
+	}
+
+	var versions []ObjectVersion
+	err = s.bdb.View(func(txn *badger.Txn) error {
+		var vErr error
+		versions, vErr = s.versionsIn(txn, cleanPath)
+		return vErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrNotExist
+	}
+	return versions, nil
+}
+
+// versionsIn lists a path's versions, newest first, within a transaction.
+func (s *Store) versionsIn(txn *badger.Txn, cleanPath string) ([]ObjectVersion, error) {
+	var versions []ObjectVersion
+	d, err := s.resolve(txn, cleanPath)
+	switch {
+	case err == nil:
+		if d.IsDir() {
+			return nil, ErrIsDir
+		}
+		if d.Generation != "" {
+			versions = append(versions, ObjectVersion{
+				Generation: d.Generation,
+				Size:       d.Size,
+				MTimeNanos: d.MTimeNanos,
+				Current:    true,
+			})
+		}
+	case !errors.Is(err, ErrNotExist):
+		return nil, err
+	}
+
+	retained, _, err := listVersions(txn, cleanPath)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(retained) - 1; i >= 0; i-- {
+		versions = append(versions, retained[i])
+	}
+	return versions, nil
+}
+
+// OpenVersion opens one version of the object at name for reading, current or
+// retained.  The version is named by its generation or by the entity tag
+// minted from it.
+//
+// The version is pinned inside the transaction that finds it, exactly as
+// OpenRead does, so a prune that lands meanwhile queues it but the janitor
+// leaves it until the reader is done.
+func (s *Store) OpenVersion(name, version string) (*ReadHandle, error) {
+	cleanPath, err := cleanRelative(name)
+	if err != nil {
+		return nil, err
+	}
+	generation := generationFromETag(version)
+
+	var (
+		found *ObjectVersion
+		hash  local_cache.InstanceHash
+		unpin func()
+	)
+	if err := s.bdb.View(func(txn *badger.Txn) error {
+		versions, vErr := s.versionsIn(txn, cleanPath)
+		if vErr != nil {
+			return vErr
+		}
+		for i := range versions {
+			if versions[i].Generation == generation {
+				found = &versions[i]
+				break
+			}
+		}
+		if found == nil {
+			return errors.Wrapf(ErrNotExist, "%s has no version %s", cleanPath, generation)
+		}
+		hash = instanceHashFor(s.db, generation)
+		unpin = s.storage.PinObject(hash)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	reader, err := s.openReader(hash)
+	unpin()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open version %s of %s", generation, cleanPath)
+	}
+	return &ReadHandle{ObjectReader: reader, store: s, hash: hash, dirent: &Dirent{
+		Type:       EntryFile,
+		Generation: found.Generation,
+		Size:       found.Size,
+		MTimeNanos: found.MTimeNanos,
+		Mode:       uint32(defaultFileMode),
+	}}, nil
+}
+
+// ---------------------------------------------------------------------------
+// Restore
+// ---------------------------------------------------------------------------
+
+// RestoreVersion makes a retained version of the object at name current
+// again, and returns the entry it installed.  The version is named as for
+// OpenVersion.
+//
+// The version being replaced is retained whatever the path's policy, so a
+// restore can itself be undone; under a path with no policy it goes at the
+// next prune sweep rather than immediately.  Missing parent directories are
+// recreated, so a file whose directory was deleted can be restored.
+func (s *Store) RestoreVersion(name, version string) (*Dirent, error) {
+	if err := s.checkNotReplica(); err != nil {
+		return nil, err
+	}
+	return s.restoreVersion(name, generationFromETag(version), time.Time{})
+}
+
+// restoreVersion is RestoreVersion for a generation, installing it with the
+// given modification time; zero means now.  A secondary applying its
+// primary's restore passes the primary's.
+func (s *Store) restoreVersion(name, generation string, mtime time.Time) (*Dirent, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+	cleanPath, err := cleanRelative(name)
+	if err != nil {
+		return nil, err
+	}
+	if isRootPath(cleanPath) {
+		return nil, ErrIsDir
+	}
+	if err := s.checkNotDraining(cleanPath); err != nil {
+		return nil, err
+	}
+	parent, _ := splitPath(cleanPath)
+	if err := s.MkdirAll(parent); err != nil {
+		return nil, errors.Wrapf(err, "cannot recreate %s", parent)
+	}
+
+	var (
+		installed *Dirent
+		pruned    int
+	)
+	err = s.update(func(txn *badger.Txn) (*ReplicationRecord, error) {
+		now := time.Now()
+		if mtime.IsZero() {
+			mtime = now
+		}
+		current, gErr := s.resolve(txn, cleanPath)
+		switch {
+		case gErr == nil:
+			if current.IsDir() {
+				return nil, ErrIsDir
+			}
+			if current.Generation == generation {
+				installed = current
+				return nil, nil
+			}
+			if lErr := s.checkRetained(txn, "restore", cleanPath, current, now); lErr != nil {
+				return nil, lErr
+			}
+		case errors.Is(gErr, ErrNotExist):
+			current = nil
+		default:
+			return nil, gErr
+		}
+
+		retained, keys, lErr := listVersions(txn, cleanPath)
+		if lErr != nil {
+			return nil, lErr
+		}
+		idx := -1
+		for i := range retained {
+			if retained[i].Generation == generation {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, errors.Wrapf(ErrNotExist, "%s has no retained version %s", cleanPath, generation)
+		}
+
+		if current != nil && current.Generation != "" {
+			if pErr := putVersion(txn, cleanPath, versionOf(current, now, false)); pErr != nil {
+				return nil, pErr
+			}
+		}
+		if dErr := txn.Delete(keys[idx]); dErr != nil {
+			return nil, errors.Wrapf(dErr, "failed to restore version %s of %s", generation, cleanPath)
+		}
+		installed = &Dirent{
+			Type:       EntryFile,
+			Generation: generation,
+			Size:       retained[idx].Size,
+			MTimeNanos: mtime.UnixNano(),
+			Mode:       uint32(defaultFileMode),
+		}
+		if pErr := putDirent(txn, cleanPath, installed); pErr != nil {
+			return nil, pErr
+		}
+		if policy := s.versioning.lookup(cleanPath); policy.Enabled() {
+			var prErr error
+			if pruned, prErr = s.pruneVersionsOf(txn, cleanPath, policy, now); prErr != nil {
+				return nil, prErr
+			}
+		}
+		// Logged as the write it amounts to.  The secondary keeps its own
+		// history under its own policy, so whether it can restore the version
+		// in place or needs the bytes is for it to find out (replication.go).
+		return &ReplicationRecord{
+			Op:         ReplicatePut,
+			Path:       cleanPath,
+			Generation: generation,
+			Size:       installed.Size,
+			MTimeNanos: installed.MTimeNanos,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	observeVersionsPruned(pruned)
+	return installed, nil
+}
+
+// RestoreReport summarizes a point-in-time restore.
+type RestoreReport struct {
+	// Restored lists the paths whose version from the requested time was
+	// made current again.
+	Restored []string
+	// Unchanged counts the paths that already held that version.
+	Unchanged int
+	// Skipped lists the paths with retained history but no retained version
+	// that was current at the requested time: created since, deleted by
+	// then, pruned, or already restored -- a restored version becomes
+	// current anew.  A restore never deletes, so these are left as they are.
+	Skipped []string
+	// Locked lists the paths whose current version a retention rule or legal
+	// hold keeps in place (retention.go).
+	Locked []string
+}
+
+// RestoreAt puts the object at name, or every object below it when it is a
+// directory, back to the version that was current at the given time.
+//
+// Only paths with retained history are considered, and nothing is deleted: an
+// object created since is left in place, as is one whose version from then is
+// no longer retained.
+func (s *Store) RestoreAt(ctx context.Context, name string, at time.Time) (*RestoreReport, error) {
+	if err := s.checkWritable(); err != nil {
+		return nil, err
+	}
+	if err := s.checkNotReplica(); err != nil {
+		return nil, err
+	}
+	cleanPath, err := cleanRelative(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	if !isRootPath(cleanPath) {
+		paths = append(paths, cleanPath)
+	}
+	below, err := s.versionedPaths(ctx, versionedSubtreePrefix(cleanPath))
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, below...)
+
+	report := &RestoreReport{}
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		var versions []ObjectVersion
+		if err := s.bdb.View(func(txn *badger.Txn) error {
+			var vErr error
+			versions, vErr = s.versionsIn(txn, p)
+			return vErr
+		}); err != nil {
+			if errors.Is(err, ErrIsDir) {
+				continue
+			}
+			return report, err
+		}
+		if len(versions) == 0 {
+			continue
+		}
+
+		v := versionAt(versions, at)
+		switch {
+		case v == nil:
+			report.Skipped = append(report.Skipped, p)
+		case v.Current:
+			report.Unchanged++
+		default:
+			_, err := s.RestoreVersion(p, v.Generation)
+			if errors.Is(err, ErrRetained) {
+				report.Locked = append(report.Locked, p)
+				continue
+			}
+			if err != nil {
+				return report, errors.Wrapf(err, "failed to restore %s", p)
+			}
+			report.Restored = append(report.Restored, p)
+		}
+	}
+	return report, nil
+}
+
+// versionAt picks, from versions listed newest first, the one that was
+// current at the given time, or nil when none of them was.
+func versionAt(versions []ObjectVersion, at time.Time) *ObjectVersion {
+	for i := range versions {
+		v := &versions[i]
+		if v.ModTime().After(at) {
+			continue
+		}
+		// The newest version to have become current by then -- but it may
+		// itself have ended before then, in which case what the path held at
+		// that moment is not retained, or was nothing at all.
+		if v.Current || v.Superseded().After(at) {
+			return v
+		}
+		return nil
+	}
+	return nil
+}
+
+// versionedPaths returns every path with retained history under the given
+// key prefix, reading in bounded transactions.
+func (s *Store) versionedPaths(ctx context.Context, prefix []byte) ([]string, error) {
+	var paths []string
+	seek := append([]byte(nil), prefix...)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		batch, next, err := s.versionedPathBatch(prefix, seek)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, batch...)
+		if next == nil {
+			return paths, nil
+		}
+		seek = next
+	}
+}
+
+// versionedPathBatch collects up to versionPruneBatchSize distinct versioned
+// paths starting at seek, returning where the next batch begins, or nil when
+// the prefix is exhausted.
+func (s *Store) versionedPathBatch(prefix, seek []byte) ([]string, []byte, error) {
+	var (
+		paths []string
+		next  []byte
+	)
+	err := s.bdb.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(seek); it.ValidForPrefix(prefix); {
+			p, ok := pathFromVersionKey(it.Item().Key())
+			if !ok {
+				it.Next()
+				continue
+			}
+			if len(paths) == versionPruneBatchSize {
+				next = it.Item().KeyCopy(nil)
+				return nil
+			}
+			paths = append(paths, p)
+			// Skip the rest of this path's history: every key of it sorts
+			// before the separator's successor.
+			it.Seek(append([]byte(local_cache.PrefixVersion+p), direntSeparator+1))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read retained versions")
+	}
+	return paths, next, nil
+}
+
+// ---------------------------------------------------------------------------
+// Pruning
+// ---------------------------------------------------------------------------
+
+// VersionPruneStats reports what one prune sweep found.
+type VersionPruneStats struct {
+	// Retained is how many versions remain retained after the sweep.
+	Retained int
+	// Pruned is how many the sweep handed to the janitor.
+	Pruned int
+}
+
+// PruneVersions applies each versioned path's current policy to its retained
+// versions, handing those it no longer keeps to the janitor.
+//
+// This is what enforces Retain, which the retiring transaction cannot -- a
+// version ages out with no write to notice it -- and what applies a policy
+// that has been narrowed or removed since the versions were kept.
+func (s *Store) PruneVersions(ctx context.Context) (VersionPruneStats, error) {
+	var stats VersionPruneStats
+	// A maintenance open is given no policies, and would otherwise take that
+	// to mean none apply and prune every retained version.
+	if s.readOnly || s.versioning == nil {
+		return stats, nil
+	}
+
+	prefix := []byte(local_cache.PrefixVersion)
+	seek := append([]byte(nil), prefix...)
+	for {
+		if err := ctx.Err(); err != nil {
+			return stats, nil
+		}
+		paths, next, err := s.versionedPathBatch(prefix, seek)
+		if err != nil {
+			return stats, err
+		}
+		for _, p := range paths {
+			retained, pruned, err := s.prunePath(p)
+			if err != nil {
+				log.Warnf("Failed to prune the retained versions of %s: %v", p, err)
+			}
+			stats.Retained += retained
+			stats.Pruned += pruned
+		}
+		if next == nil {
+			break
+		}
+		seek = next
+	}
+
+	observeVersionsPruned(stats.Pruned)
+	metrics.PStoreRetainedVersions.Set(float64(stats.Retained))
+	return stats, nil
+}
+
+// prunePath applies the current policy to one path's retained versions.
+//
+// The decision is made in a read transaction first, because nearly every path
+// has nothing to prune; only one that does pays for a write transaction, which
+// decides again against its own snapshot so a concurrent restore cannot have
+// a version it just reinstated queued for reclamation.
+func (s *Store) prunePath(cleanPath string) (retained, pruned int, err error) {
+	policy := s.versioning.lookup(cleanPath)
+	now := time.Now()
+
+	var versions []ObjectVersion
+	if err := s.bdb.View(func(txn *badger.Txn) error {
+		var lErr error
+		versions, _, lErr = listVersions(txn, cleanPath)
+		return lErr
+	}); err != nil {
+		return 0, 0, err
+	}
+	expired := 0
+	for i := range versions {
+		if !policy.retains(len(versions)-1-i, versions[i].Superseded(), now) {
+			expired++
+		}
+	}
+	if expired == 0 {
+		return len(versions), 0, nil
+	}
+
+	err = s.bdb.Update(func(txn *badger.Txn) error {
+		var pErr error
+		pruned, pErr = s.pruneVersionsOf(txn, cleanPath, policy, now)
+		return pErr
+	})
+	if err != nil {
+		return len(versions), 0, err
+	}
+	return len(versions) - pruned, pruned, nil
+}
+
+// scanVersionsBatched visits every retained version, reading in bounded
+// transactions exactly as scanIndexBatched does, with the callback outside
+// them.
+func (s *Store) scanVersionsBatched(ctx context.Context, fn func(cleanPath string, v *ObjectVersion) error) error {
+	type retained struct {
+		path string
+		v    *ObjectVersion
+	}
+
+	prefix := []byte(local_cache.PrefixVersion)
+	seek := append([]byte(nil), prefix...)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batch := make([]retained, 0, fsckBatchSize)
+		var lastKey []byte
+		exhausted := true
+
+		if err := s.bdb.View(func(txn *badger.Txn) error {
+			opts := badger.DefaultIteratorOptions
+			opts.Prefix = prefix
+			it := txn.NewIterator(opts)
+			defer it.Close()
+
+			for it.Seek(seek); it.ValidForPrefix(prefix); it.Next() {
+				item := it.Item()
+				lastKey = item.KeyCopy(lastKey[:0])
+
+				if p, ok := pathFromVersionKey(item.Key()); ok {
+					v := &ObjectVersion{}
+					if err := item.Value(func(val []byte) error {
+						return msgpack.Unmarshal(val, v)
+					}); err != nil {
+						return errors.Wrapf(err, "failed to read a retained version of %s", p)
+					}
+					batch = append(batch, retained{path: p, v: v})
+				}
+				if len(batch) >= fsckBatchSize {
+					exhausted = false
+					return nil
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, r := range batch {
+			if err := fn(r.path, r.v); err != nil {
+				return err
+			}
+		}
+		if exhausted {
+			return nil
+		}
+		seek = nextKeyAfter(lastKey)
+	}
+}
@@ -0,0 +1,290 @@
+/***************************************************************
+ *
+ * Copyright (C) 2026, Pelican Project, Morgridge Institute for Research
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you
+ * may not use this file except in compliance with the License.  You may
+ * obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ ***************************************************************/
+
+package pstore
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pelicanplatform/pelican/local_cache"
+)
+
+// newVersionedTestStore opens a test store with the given versioning
+// policies.
+func newVersionedTestStore(t *testing.T, versioning map[string]VersionPolicy) *Store {
+	t.Helper()
+	local_cache.InitIssuerKeyForTests(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	t.Cleanup(cancel)
+	egrp, _ := errgroup.WithContext(ctx)
+
+	s, err := Open(ctx, egrp, Config{BaseDir: t.TempDir(), Versioning: versioning})
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, s.Close()) })
+	return s
+}
+
+func readVersion(t *testing.T, s *Store, name, generation string) string {
+	t.Helper()
+	h, err := s.OpenVersion(name, generation)
+	require.NoError(t, err)
+	defer h.Close()
+	b, err := io.ReadAll(h)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func readObject(t *testing.T, s *Store, name string) string {
+	t.Helper()
+	h, err := s.OpenRead(name)
+	require.NoError(t, err)
+	defer h.Close()
+	b, err := io.ReadAll(h)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestVersionPolicyLookup(t *testing.T) {
+	policies, err := newVersionPolicies(map[string]VersionPolicy{
+		"/data":         {Keep: 3},
+		"/data/scratch": {},
+		"/logs/":        {Retain: time.Hour},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, VersionPolicy{Keep: 3}, policies.lookup("/data/a/b"))
+	assert.Equal(t, VersionPolicy{Keep: 3}, policies.lookup("/data"))
+	assert.False(t, policies.lookup("/data/scratch/x").Enabled(), "a zero policy exempts its subtree")
+	assert.False(t, policies.lookup("/database").Enabled(), "a name prefix is not an ancestor")
+	assert.Equal(t, VersionPolicy{Retain: time.Hour}, policies.lookup("/logs/today"))
+	assert.False(t, policies.lookup("/other").Enabled())
+
+	_, err = newVersionPolicies(map[string]VersionPolicy{"/x": {Keep: -1}})
+	assert.Error(t, err)
+}
+
+func TestParseVersioningValue(t *testing.T) {
+	policies, err := ParseVersioningValue([]interface{}{
+		map[string]interface{}{"Path": "/Data", "Keep": 5, "Retain": "720h"},
+		map[interface{}]interface{}{"path": "/Data/tmp", "keep": "0"},
+	}, "Origin.PStoreVersioning")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]VersionPolicy{
+		"/Data":     {Keep: 5, Retain: 720 * time.Hour},
+		"/Data/tmp": {},
+	}, policies)
+
+	for _, bad := range []interface{}{
+		"/data",
+		[]interface{}{map[string]interface{}{"Keep": 1}},
+		[]interface{}{map[string]interface{}{"Path": "/a", "Retain": "soon"}},
+		[]interface{}{map[string]interface{}{"Path": "/a", "Keep": -2}},
+		[]interface{}{map[string]interface{}{"Path": "/a", "Kept": 2}},
+		[]interface{}{map[string]interface{}{"Path": "/a"}, map[string]interface{}{"Path": "/a"}},
+	} {
+		_, err := ParseVersioningValue(bad, "Origin.PStoreVersioning")
+		assert.Error(t, err, "%v", bad)
+	}
+}
+
+// Overwrites and deletes under a policy keep the superseded versions, up to
+// the configured count; elsewhere they are reclaimed as before.
+func TestVersionsRetainedOnOverwriteAndDelete(t *testing.T) {
+	s := newVersionedTestStore(t, map[string]VersionPolicy{"/v": {Keep: 2}})
+	require.NoError(t, s.MkdirAll("/v"))
+	require.NoError(t, s.MkdirAll("/plain"))
+
+	for _, content := range []string{"one", "two", "three"} {
+		writeObject(t, s, "/v/a", []byte(content))
+		writeObject(t, s, "/plain/a", []byte(content))
+	}
+
+	versions, err := s.Versions("/v/a")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.True(t, versions[0].Current)
+	assert.Equal(t, "three", readVersion(t, s, "/v/a", versions[0].Generation))
+	assert.Equal(t, "two", readVersion(t, s, "/v/a", versions[1].Generation))
+	assert.Equal(t, "one", readVersion(t, s, "/v/a", versions[2].Generation))
+	assert.Greater(t, versions[1].SupersededNanos, versions[2].SupersededNanos)
+
+	plain, err := s.Versions("/plain/a")
+	require.NoError(t, err)
+	assert.Len(t, plain, 1, "nothing is retained without a policy")
+
+	// A fourth write pushes the oldest out, and the janitor reclaims it.
+	writeObject(t, s, "/v/a", []byte("four"))
+	versions, err = s.Versions("/v/a")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, "two", readVersion(t, s, "/v/a", versions[2].Generation))
+	stats, err := s.RunGC(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.InstancesFreed, "two from /plain/a and the pruned one")
+
+	// Deleting keeps the last current version as history.
+	require.NoError(t, s.Remove("/v/a"))
+	versions, err = s.Versions("/v/a")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.False(t, versions[0].Current)
+	assert.True(t, versions[0].Deleted)
+	assert.Equal(t, "four", readVersion(t, s, "/v/a", versions[0].Generation))
+
+	_, err = s.Versions("/v/never")
+	assert.ErrorIs(t, err, ErrNotExist)
+	_, err = s.Versions("/v")
+	assert.ErrorIs(t, err, ErrIsDir)
+	_, err = s.OpenVersion("/v/a", "no-such-generation")
+	assert.ErrorIs(t, err, ErrNotExist)
+
+	// Retained versions are reachable, not orphans.
+	report, err := s.FsckWith(t.Context(), FsckOptions{MinAge: FsckNoGracePeriod})
+	require.NoError(t, err)
+	assert.True(t, report.Healthy(), "%+v", report)
+}
+
+// A recursive delete retains the versions of every file in the subtree.
+func TestVersionsRetainedByRemoveAll(t *testing.T) {
+	s := newVersionedTestStore(t, map[string]VersionPolicy{"/": {Keep: 1}})
+	require.NoError(t, s.MkdirAll("/d/e"))
+	writeObject(t, s, "/d/x", []byte("x"))
+	writeObject(t, s, "/d/e/y", []byte("y"))
+
+	require.NoError(t, s.RemoveAll("/d"))
+	for name, content := range map[string]string{"/d/x": "x", "/d/e/y": "y"} {
+		versions, err := s.Versions(name)
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		assert.Equal(t, content, readVersion(t, s, name, versions[0].Generation))
+	}
+}
+
+func TestRestoreVersion(t *testing.T) {
+	s := newVersionedTestStore(t, map[string]VersionPolicy{"/v": {Keep: 5}})
+	require.NoError(t, s.MkdirAll("/v"))
+	writeObject(t, s, "/v/a", []byte("old"))
+	writeObject(t, s, "/v/a", []byte("new"))
+
+	versions, err := s.Versions("/v/a")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	old, replaced := versions[1], versions[0]
+
+	d, err := s.RestoreVersion("/v/a", old.Generation)
+	require.NoError(t, err)
+	assert.Equal(t, old.Generation, d.Generation, "a restored version keeps its ETag")
+	assert.Equal(t, "old", readObject(t, s, "/v/a"))
+
+	// The version it replaced can be restored in turn.
+	versions, err = s.Versions("/v/a")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, replaced.Generation, versions[1].Generation)
+
+	// A deleted file comes back, directory and all.
+	require.NoError(t, s.RemoveAll("/v"))
+	_, err = s.RestoreVersion("/v/a", replaced.Generation)
+	require.NoError(t, err)
+	assert.Equal(t, "new", readObject(t, s, "/v/a"))
+
+	_, err = s.RestoreVersion("/v/a", "no-such-generation")
+	assert.ErrorIs(t, err, ErrNotExist)
+}
+
+// Without a policy, the version a restore replaces survives only until the
+// next sweep; narrowing a policy takes effect at the sweep too.
+func TestPruneVersions(t *testing.T) {
+	s := newVersionedTestStore(t, map[string]VersionPolicy{"/v": {Keep: 5}})
+	require.NoError(t, s.MkdirAll("/v"))
+	for _, content := range []string{"1", "2", "3", "4"} {
+		writeObject(t, s, "/v/a", []byte(content))
+		writeObject(t, s, "/v/b", []byte(content))
+	}
+
+	stats, err := s.PruneVersions(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, VersionPruneStats{Retained: 6}, stats)
+
+	s.versioning["/v"] = VersionPolicy{Keep: 1}
+	stats, err = s.PruneVersions(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, VersionPruneStats{Retained: 2, Pruned: 4}, stats)
+
+	versions, err := s.Versions("/v/a")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, "3", readVersion(t, s, "/v/a", versions[1].Generation))
+
+	delete(s.versioning, "/v")
+	stats, err = s.PruneVersions(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, VersionPruneStats{Pruned: 2}, stats)
+	_, err = s.Versions("/v/a")
+	require.NoError(t, err)
+
+	gc, err := s.RunGC(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 6, gc.InstancesFreed, "every pruned version is reclaimed")
+}
+
+// A point-in-time restore puts back what each path held at that moment and
+// leaves alone what did not exist then.
+func TestRestoreAt(t *testing.T) {
+	s := newVersionedTestStore(t, map[string]VersionPolicy{"/v": {Retain: time.Hour}})
+	require.NoError(t, s.MkdirAll("/v/sub"))
+	writeObject(t, s, "/v/a", []byte("a1"))
+	writeObject(t, s, "/v/sub/b", []byte("b1"))
+	writeObject(t, s, "/v/same", []byte("before"))
+	writeObject(t, s, "/v/same", []byte("same"))
+
+	time.Sleep(5 * time.Millisecond)
+	at := time.Now()
+	time.Sleep(5 * time.Millisecond)
+
+	writeObject(t, s, "/v/a", []byte("a2"))
+	require.NoError(t, s.Remove("/v/sub/b"))
+	writeObject(t, s, "/v/new", []byte("new"))
+	writeObject(t, s, "/v/new", []byte("newer"))
+
+	report, err := s.RestoreAt(t.Context(), "/v", at)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/v/a", "/v/sub/b"}, report.Restored)
+	assert.Equal(t, []string{"/v/new"}, report.Skipped)
+	assert.Equal(t, 1, report.Unchanged)
+
+	assert.Equal(t, "a1", readObject(t, s, "/v/a"))
+	assert.Equal(t, "b1", readObject(t, s, "/v/sub/b"))
+	assert.Equal(t, "same", readObject(t, s, "/v/same"))
+	assert.Equal(t, "newer", readObject(t, s, "/v/new"), "a restore never deletes")
+
+	// A restored version became current anew, so a second run has no
+	// version from then to go back to and leaves it alone.
+	report, err = s.RestoreAt(t.Context(), "/v", at)
+	require.NoError(t, err)
+	assert.Empty(t, report.Restored)
+	assert.Equal(t, "a1", readObject(t, s, "/v/a"))
+}
@@ -144,6 +144,7 @@ type (
 		Downtimes              []Downtime        `json:"downtimes"`              // Would be an empty slice if no downtime
 		RequiredFeatures       []string          `json:"requiredFeatures"`       // A list of feature names required by this server
 		Status                 string            `json:"status"`
+		Restored               bool              `json:"restored"` // True if the ad was restored from the director's database and the server has not re-advertised since
 	}
 
 	// The struct holding a server's advertisement (including ServerAd and NamespaceAd)
@@ -251,6 +252,10 @@ type (
 	ClientRedirectInfo struct {
 		Coordinate Coordinate
 		IpAddr     string `json:"ipAddr"`
+		// The site (from Director.SiteNetworks) and autonomous system the client's
+		// address belongs to, if known
+		Site string `json:"site,omitempty"`
+		ASN  uint   `json:"asn,omitempty"`
 	}
 
 	RedirectWeights struct {
@@ -258,6 +263,14 @@ type (
 		IOLoadWeight       float64 `json:"ioLoadWeight"`
 		StatusWeight       float64 `json:"statusWeight"`
 		AvailabilityWeight float64 `json:"availabilityWeight"`
+		// Derived from the director's cache probes; zero if probe results weren't used
+		ProbeWeight float64 `json:"probeWeight,omitempty"`
+		// Preference for servers at the client's site or in its autonomous system; zero if
+		// network locality wasn't used
+		NetworkWeight float64 `json:"networkWeight,omitempty"`
+		// Weights computed by a configured sort policy that don't map onto one of
+		// the fields above, keyed by the policy weight type (e.g. "proximity")
+		PolicyWeights map[string]float64 `json:"policyWeights,omitempty"`
 	}
 
 	ServerRedirectInfo struct {
@@ -269,6 +282,7 @@ type (
 		ClientInfo         ClientRedirectInfo             `json:"clientInfo"`
 		ServersInfo        map[string]*ServerRedirectInfo `json:"serversInfo"`
 		DirectorSortMethod string                         `json:"directorSortMethod"`
+		DirectorSortPolicy string                         `json:"directorSortPolicy,omitempty"` // Name of the configured sort policy, if one applied
 	}
 
 	DirectorResponse struct {
@@ -318,6 +332,65 @@ type (
 		Origins      []string              `json:"origins"`
 		Caches       []string              `json:"caches"`
 	}
+
+	///////////////////////////////
+	// Director topology structs //
+	///////////////////////////////
+
+	// TopologyServer describes an origin or cache in the public topology API
+	TopologyServer struct {
+		Name             string       `json:"name"`
+		Type             string       `json:"type"`
+		URL              string       `json:"url"`
+		WebURL           string       `json:"webUrl"`
+		Latitude         float64      `json:"latitude"`
+		Longitude        float64      `json:"longitude"`
+		Caps             Capabilities `json:"capabilities"`
+		RequiredFeatures []string     `json:"requiredFeatures"`
+		FromTopology     bool         `json:"fromTopology"`
+		Version          string       `json:"version"`
+		// A summary of whether the director is sending clients to the server:
+		// "unavailable" (filtered or in downtime), "degraded", "ok" or "unknown"
+		Status       string `json:"status"`
+		HealthStatus string `json:"healthStatus"` // The director-->server health test status
+		ServerStatus string `json:"serverStatus"` // The status the server reported in its ad
+	}
+
+	// TopologyNamespace describes a namespace and the servers exporting or caching it
+	TopologyNamespace struct {
+		Path    string       `json:"path"`
+		Caps    Capabilities `json:"capabilities"`
+		Origins []string     `json:"origins"` // Names of servers in TopologyResponse.Servers
+		Caches  []string     `json:"caches"`  // Names of servers in TopologyResponse.Servers
+	}
+
+	// TopologyResponse is the response of the director's public topology API
+	TopologyResponse struct {
+		APIVersion string              `json:"apiVersion"`
+		Namespaces []TopologyNamespace `json:"namespaces"`
+		Servers    []TopologyServer    `json:"servers"`
+	}
+
+	////////////////////////////////////////
+	// Director transfer feedback structs //
+	////////////////////////////////////////
+
+	// TransferFeedbackAttempt is a client's report of a single attempt to transfer an object
+	TransferFeedbackAttempt struct {
+		Server          string        `json:"server"`                    // The host (and port) of the server used for the attempt
+		Bytes           int64         `json:"bytes"`                     // Bytes transferred during the attempt
+		Duration        time.Duration `json:"duration"`                  // How long the attempt took
+		TimeToFirstByte time.Duration `json:"timeToFirstByte,omitempty"` // How long it took to receive the first byte
+		// The type of the Pelican error that ended the attempt (e.g. "Transfer.SlowTransfer"),
+		// "Unknown" for other errors, or empty if the attempt succeeded
+		ErrorClass string `json:"errorClass,omitempty"`
+	}
+
+	// TransferFeedback is the request body of the director's transfer feedback API
+	TransferFeedback struct {
+		Path     string                    `json:"path"` // The federation path of the transferred object
+		Attempts []TransferFeedbackAttempt `json:"attempts"`
+	}
 )
 
 var (
@@ -654,6 +727,8 @@ const (
 	DistanceAndLoadType SortType = "distanceAndLoad"
 	RandomType          SortType = "random"
 	AdaptiveType        SortType = "adaptive"
+	ConsistentHashType  SortType = "consistentHash"
+	PolicyType          SortType = "policy" // Not directly configurable; reported when a Director.SortPolicies entry applies
 
 	AdAfterFalse   AdAfter = 0 // The ad was *not* generated after the compared one
 	AdAfterTrue    AdAfter = 1 // The ad was generated after the compared one
@@ -20,7 +20,9 @@ package server_utils
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"time"
 
 	"golang.org/x/net/webdav"
 )
@@ -73,6 +75,35 @@ type CapacityReporter interface {
 	HasCapacityFor(nBytes int64) error
 }
 
+// VersionedBackend is optionally implemented by backends that retain the
+// versions of objects that are overwritten or deleted.
+//
+// Versions are addressed by the entity tag each was served with while it was
+// current, which a client already holds from the responses that returned it.
+type VersionedBackend interface {
+	// ListVersions returns the readable versions of the object at
+	// relativePath, newest first.
+	ListVersions(relativePath string) ([]ObjectVersion, error)
+
+	// OpenVersion opens one of those versions by entity tag.
+	OpenVersion(relativePath, etag string) (io.ReadSeekCloser, ObjectVersion, error)
+}
+
+// ObjectVersion describes one version of an object held by a
+// VersionedBackend.
+type ObjectVersion struct {
+	ETag    string    `json:"etag"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	// Superseded is when the version stopped being current; nil for the
+	// current version.
+	Superseded *time.Time `json:"superseded,omitempty"`
+	// Deleted reports that it stopped being current because the object was
+	// deleted rather than overwritten.
+	Deleted bool `json:"deleted,omitempty"`
+	Current bool `json:"current,omitempty"`
+}
+
 // HTTPStatusCoder is optionally implemented by errors returned from
 // CheckAvailability to control the HTTP status code sent to clients.
 type HTTPStatusCoder interface {
@@ -35,6 +35,7 @@ const (
 	Pelican_DowntimeCreate TokenScope = "pelican.downtime_create"
 	Pelican_DowntimeModify TokenScope = "pelican.downtime_modify"
 	Pelican_DowntimeDelete TokenScope = "pelican.downtime_delete"
+	Pelican_PstoreReplicate TokenScope = "pelican.pstore_replicate"
 	WebUi_Access TokenScope = "web_ui.access"
 	Server_Admin TokenScope = "server.admin"
 	Server_UserAdmin TokenScope = "server.user_admin"
@@ -131,6 +132,7 @@ var scopeDescriptions = map[TokenScope]string{
 	Pelican_DowntimeCreate: `Permits origin and cache to create downtimes at the registry`,
 	Pelican_DowntimeModify: `Permits origin and cache to modify existing downtimes at the registry`,
 	Pelican_DowntimeDelete: `Permits origin and cache to delete downtimes at the registry`,
+	Pelican_PstoreReplicate: `Permits a pstore origin that is a replication primary to read its secondary's position and ship it the primary's committed changes`,
 	WebUi_Access: `Sign in to the server's web UI and the cookie-authenticated APIs. Auto-granted to new user accounts; granting it explicitly to a user or group lets API tokens (which intersect against effective scopes) carry web-UI access too.`,
 	Server_Admin: `Full server-administration capability. Holders can manage every user/group/collection/setting; equivalent to the historical "system admin" role. Implies server.user_admin and server.collection_admin.`,
 	Server_UserAdmin: `Manage non-admin users and unprivileged groups. Holders can create users, mint password-set invites, and run the user-onboarding flows, but cannot modify system-admin accounts.`,